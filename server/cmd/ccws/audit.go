@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/audit"
+	"github.com/Hukyl/CCWS/internal/humantime"
+)
+
+func newAuditCmd() *cobra.Command {
+	var (
+		filter  audit.Filter
+		since   string
+		verbose bool
+		asJSON  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "List the writes made to Clockify by the server and the CLI",
+		Long: `List the writes made to Clockify by the server and the CLI, newest first.
+
+Every request changing data is recorded in AUDIT_DSN with who made it, the request and the
+result. The actor is cli (cli:<profile> with a profile), server, api or grpc (api:<user> and
+grpc:<user> with api_users), telegram or job:<name> for the server's scheduled jobs, e.g.
+job:watchdog.`,
+		Example: `  ccws audit --since 24h
+  ccws audit --actor 'job:*' --failed
+  ccws audit --url 65f1c0ffee --verbose`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(flags.profile)
+			if err != nil {
+				return err
+			}
+			if cfg.AuditDSN == "" {
+				return errors.New("no audit log configured, set AUDIT_DSN")
+			}
+
+			if since != "" {
+				if filter.Since, err = parseSince(since, time.Now()); err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+			}
+
+			log, err := audit.Open(cfg.AuditDSN)
+			if err != nil {
+				return err
+			}
+			defer log.Close()
+
+			entries, err := log.Query(filter)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if asJSON {
+				if entries == nil {
+					entries = []audit.Entry{}
+				}
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(entries)
+			}
+
+			if len(entries) == 0 {
+				fmt.Fprintln(out, "Nothing recorded")
+				return nil
+			}
+			for _, entry := range entries {
+				fmt.Fprintf(out, "%s  %-14s %-6s %s  %s\n", entry.Time.Local().Format("Jan 2 15:04:05"), entry.Actor, entry.Method, auditPath(entry.URL), auditResult(entry))
+				if verbose {
+					if entry.Request != "" {
+						fmt.Fprintf(out, "    request:  %s\n", entry.Request)
+					}
+					if entry.Response != "" {
+						fmt.Fprintf(out, "    response: %s\n", entry.Response)
+					}
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "only writes since a duration ago or a date, e.g. 24h or 2024-05-02")
+	cmd.Flags().StringVar(&filter.Actor, "actor", "", "only writes of the actor, a trailing * matches a prefix")
+	cmd.Flags().StringVar(&filter.Method, "method", "", "only writes with the HTTP method, e.g. DELETE")
+	cmd.Flags().StringVar(&filter.URL, "url", "", "only writes whose URL contains this, e.g. an entry ID")
+	cmd.Flags().BoolVar(&filter.Failed, "failed", false, "only writes Clockify did not apply")
+	cmd.Flags().IntVarP(&filter.Limit, "limit", "n", audit.DefaultLimit, "number of writes to list")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show the request and response bodies")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the writes as JSON")
+
+	return cmd
+}
+
+// parseSince reads a duration before now or a date
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := humantime.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return humantime.ParseDate(value, now)
+}
+
+// auditPath returns the path of a recorded URL, the API host being the same for every write
+func auditPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if u.RawQuery != "" {
+		return u.Path + "?" + u.RawQuery
+	}
+	return u.Path
+}
+
+// auditResult describes the outcome of a recorded write
+func auditResult(entry audit.Entry) string {
+	switch {
+	case entry.Status == 0:
+		return "failed: " + entry.Error
+	case entry.Error != "":
+		return fmt.Sprintf("%d, %s", entry.Status, entry.Error)
+	}
+	return fmt.Sprintf("%d in %s", entry.Status, entry.Duration.Round(time.Millisecond))
+}
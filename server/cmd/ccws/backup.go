@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/backup"
+)
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Save a workspace to a JSON archive or restore one",
+	}
+
+	cmd.AddCommand(newBackupSaveCmd(), newBackupRestoreCmd())
+	return cmd
+}
+
+func newBackupSaveCmd() *cobra.Command {
+	var allUsers bool
+
+	cmd := &cobra.Command{
+		Use:   "save [file]",
+		Short: "Save the workspace to an archive, stdout if no file is given",
+		Example: `  ccws backup save acme.json
+  ccws backup save --all-users -w Acme > acme.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			var opts []backup.SnapshotOption
+			if !allUsers {
+				opts = append(opts, backup.WithUsers(s.user.ID))
+			}
+
+			archive, err := backup.Snapshot(s.client, *s.workspace, opts...)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Create(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+
+			if err := backup.Write(out, archive); err != nil {
+				return fmt.Errorf("failed to write archive: %w", err)
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Saved %s: %d clients, %d projects, %d tasks, %d tags, %d time entries, %d webhooks\n",
+				s.workspace.Name, len(archive.Clients), len(archive.Projects), len(archive.Tasks),
+				len(archive.Tags), len(archive.TimeEntries), len(archive.Webhooks))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&allUsers, "all-users", false, "include the time entries of every workspace user (requires admin rights)")
+	return cmd
+}
+
+func newBackupRestoreCmd() *cobra.Command {
+	var (
+		noTimeEntries bool
+		noWebhooks    bool
+		yes           bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore file",
+		Short: "Restore an archive into the workspace",
+		Long: `Restore an archive into the selected workspace, which may differ from the archived one.
+
+Clients, projects, tasks and tags already present by name are reused, and time entries and
+webhooks already present are not duplicated, so restoring twice is safe. Time entries of users
+missing from the workspace are assigned to you.`,
+		Example: `  ccws backup restore acme.json -w "Acme Copy"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var in io.Reader = cmd.InOrStdin()
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			archive, err := backup.Read(in)
+			if err != nil {
+				return err
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				ok, err := newPrompter(cmd).confirm(fmt.Sprintf("Restore the %s archive from %s into %s?",
+					archive.Workspace.Name, archive.CreatedAt.Local().Format("2006-01-02 15:04"), s.workspace.Name))
+				if errors.Is(err, errNotInteractive) {
+					return errors.New("refusing to restore without confirmation, pass --yes")
+				}
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Fprintln(cmd.OutOrStdout(), "Cancelled")
+					return nil
+				}
+			}
+
+			opts := []backup.RestoreOption{backup.WithFallbackUser(s.user.ID)}
+			if noTimeEntries {
+				opts = append(opts, backup.WithoutTimeEntries())
+			}
+			if noWebhooks {
+				opts = append(opts, backup.WithoutWebhooks())
+			}
+
+			result, restoreErr := backup.Restore(s.client, archive, s.workspace.ID, opts...)
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Clients:      %s\n", result.Clients)
+			fmt.Fprintf(out, "Projects:     %s\n", result.Projects)
+			fmt.Fprintf(out, "Tasks:        %s\n", result.Tasks)
+			fmt.Fprintf(out, "Tags:         %s\n", result.Tags)
+			fmt.Fprintf(out, "Time entries: %s\n", result.TimeEntries)
+			fmt.Fprintf(out, "Webhooks:     %s\n", result.Webhooks)
+			return restoreErr
+		},
+	}
+
+	cmd.Flags().BoolVar(&noTimeEntries, "no-time-entries", false, "restore only clients, projects, tasks, tags and webhooks")
+	cmd.Flags().BoolVar(&noWebhooks, "no-webhooks", false, "do not recreate webhooks")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "restore without asking for confirmation")
+	return cmd
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/billable"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/humantime"
+	"github.com/Hukyl/CCWS/internal/query"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+func newCleanupCmd() *cobra.Command {
+	var (
+		project    string
+		from       string
+		to         string
+		match      string
+		expr       string
+		remove     bool
+		addTags    []string
+		removeTags []string
+		moveTo     string
+		moveToTask string
+		find       string
+		replace    string
+		tmpl       string
+		setBill    bool
+		nonBill    bool
+		billRules  bool
+		dryRun     bool
+		yes        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Delete, retag, move, reclassify or rewrite all time entries matching filters",
+		Long: `Delete, retag, move, reclassify or rewrite the descriptions of all of your time entries
+matching the filters.
+
+Pass --delete to delete the matching entries, --add-tag/--remove-tag to change their tags,
+--move-to to move them to another project, and with --move-to-task to one of its tasks.
+--billable and --non-billable set their billable flag, --billable-rules sets it by the
+billable_rules section of the config file.
+
+--filter selects the entries by an expression, along with the other filters.
+
+` + queryHelp + `
+
+Descriptions are rewritten with --find and --replace, replacing the matches of a regular
+expression, $1 being its first group, or with --template, a Go template of the entry's
+.Description, .Project, .Start and .Match, the groups of --find, with the upper, lower and
+trim functions. With --find, only the descriptions it matches are rewritten.
+
+The matching entries, or the rewritten descriptions, are listed and the change is applied
+after confirmation.`,
+		Example: `  ccws cleanup --project Acme --from monday --match '^test' --delete
+  ccws cleanup --from 2024-05-01 --to 2024-06-01 --add-tag billed --remove-tag pending --dry-run
+  ccws cleanup --project Internal --from 2024-05-01 --move-to Acme --move-to-task Support
+  ccws cleanup --project Internal --non-billable
+  ccws cleanup --from 2024-05-01 --filter 'tag in ["dev"] && duration < 5m' --delete
+  ccws cleanup --from 2024-01-01 --billable-rules --dry-run
+  ccws cleanup --find '^(?i)eng[ -]?(\d+):?\s*' --replace 'ENG-$1: ' --dry-run
+  ccws cleanup --project Acme --template '{{.Project}}: {{trim .Description}}'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hasRetag := len(addTags) > 0 || len(removeTags) > 0
+			hasMove := moveTo != ""
+			hasRewrite := cmd.Flags().Changed("replace") || tmpl != ""
+			switch actions := countTrue(remove, hasRetag, hasMove, hasRewrite, setBill, nonBill, billRules); {
+			case actions > 1 || actions == 0 && !dryRun:
+				return errors.New("pass either --delete, --add-tag/--remove-tag, --move-to, --replace/--template, --billable, --non-billable or --billable-rules")
+			case moveToTask != "" && !hasMove:
+				return errors.New("--move-to-task requires --move-to")
+			case cmd.Flags().Changed("replace") && (find == "" || tmpl != ""):
+				return errors.New("--replace requires --find and cannot be combined with --template")
+			case find != "" && !hasRewrite:
+				return errors.New("--find requires --replace or --template")
+			}
+
+			now := time.Now()
+			var filter clockify.TimeEntryFilter
+			if from != "" {
+				start, err := humantime.ParseDate(from, now)
+				if err != nil {
+					return fmt.Errorf("invalid --from: %w", err)
+				}
+				filter.Start = &start
+			}
+			if to != "" {
+				end, err := humantime.ParseDate(to, now)
+				if err != nil {
+					return fmt.Errorf("invalid --to: %w", err)
+				}
+				filter.End = &end
+			}
+			if match != "" {
+				pattern, err := regexp.Compile(match)
+				if err != nil {
+					return fmt.Errorf("invalid --match: %w", err)
+				}
+				filter.Description = pattern
+			}
+			var selection *query.Query
+			if expr != "" {
+				var err error
+				if selection, err = query.Parse(expr, now); err != nil {
+					return fmt.Errorf("invalid --filter: %w", err)
+				}
+			}
+
+			var rewrite *descriptionRewrite
+			if hasRewrite {
+				var err error
+				if rewrite, err = newDescriptionRewrite(find, replace, tmpl); err != nil {
+					return err
+				}
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			var reclassify billable.Rules
+			switch {
+			case setBill, nonBill:
+				// A rule without criteria matches every entry
+				reclassify = billable.Rules{{Billable: setBill}}
+			case billRules:
+				if len(s.cfg.BillableRules) == 0 {
+					return errors.New("--billable-rules requires a billable_rules section in the config file")
+				}
+				if reclassify, err = app.ResolveBillableRules(s.client, s.cfg, s.workspace.ID); err != nil {
+					return err
+				}
+			}
+
+			if project != "" {
+				p, err := s.client.FindProjectByName(s.workspace.ID, project)
+				if err != nil {
+					return err
+				}
+				filter.ProjectID = p.ID
+			}
+
+			var moveProjectID, moveTaskID string
+			if hasMove {
+				target, err := s.resolve(entryTarget{project: moveTo, task: moveToTask})
+				if err != nil {
+					return err
+				}
+				moveProjectID = *target.projectID
+				if target.taskID != nil {
+					moveTaskID = *target.taskID
+				}
+			}
+
+			addTagIDs, err := s.tagIDs(addTags)
+			if err != nil {
+				return err
+			}
+			removeTagIDs, err := s.tagIDs(removeTags)
+			if err != nil {
+				return err
+			}
+
+			projectNames, err := report.ProjectNames(s.client, s.workspace.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list projects: %w", err)
+			}
+			if selection != nil {
+				tagNames, err := report.TagNames(s.client, s.workspace.ID)
+				if err != nil {
+					return fmt.Errorf("failed to list tags: %w", err)
+				}
+				filter.Match = selection.Predicate(query.Names{Projects: projectNames, Tags: tagNames})
+			}
+
+			entries, err := s.client.FindTimeEntries(s.workspace.ID, s.user.ID, filter)
+			if err != nil {
+				return fmt.Errorf("failed to list time entries: %w", err)
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No matching time entries")
+				return nil
+			}
+
+			out := cmd.OutOrStdout()
+			if hasRewrite {
+				return rewriteDescriptions(cmd, s, entries, projectNames, rewrite, dryRun, yes)
+			}
+			if reclassify != nil {
+				return reclassifyEntries(cmd, s, entries, projectNames, reclassify, dryRun, yes)
+			}
+			for _, entry := range entries {
+				line := entry.String()
+				if name, ok := projectNames[entry.ProjectID]; ok {
+					line += " [" + name + "]"
+				}
+				fmt.Fprintf(out, "  %s  %s\n", entry.TimeInterval.Start.Local().Format("2006-01-02 15:04"), line)
+			}
+
+			action := "Delete"
+			switch {
+			case hasRetag:
+				action = "Retag"
+			case hasMove:
+				action = "Move"
+			}
+			if dryRun {
+				fmt.Fprintf(out, "Dry run: %d matching time entries\n", len(entries))
+				return nil
+			}
+
+			if !yes {
+				ok, err := newPrompter(cmd).confirm(fmt.Sprintf("%s %d time entries?", action, len(entries)))
+				if errors.Is(err, errNotInteractive) {
+					return errors.New("refusing to change entries without confirmation, pass --yes")
+				}
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Fprintln(out, "Cancelled")
+					return nil
+				}
+			}
+
+			if remove {
+				deleted, err := s.client.DeleteTimeEntries(s.workspace.ID, entries)
+				fmt.Fprintf(out, "Deleted %d of %d time entries\n", deleted, len(entries))
+				return err
+			}
+
+			if hasMove {
+				filter.UserID = s.user.ID
+				stderr := cmd.ErrOrStderr()
+				moved, err := s.client.MoveTimeEntries(s.workspace.ID, filter, moveProjectID, moveTaskID, func(done, total int) {
+					fmt.Fprintf(stderr, "\rMoving %d/%d", done, total)
+					if done == total {
+						fmt.Fprintln(stderr)
+					}
+				})
+				fmt.Fprintf(out, "Moved %d of %d time entries\n", moved, len(entries))
+				return err
+			}
+
+			updated, err := s.client.RetagTimeEntries(s.workspace.ID, entries, addTagIDs, removeTagIDs)
+			fmt.Fprintf(out, "Retagged %d of %d time entries\n", updated, len(entries))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&project, "project", "p", "", "only entries of this project")
+	cmd.Flags().StringVar(&from, "from", "", "only entries starting on or after this day, e.g. monday or 2024-05-01")
+	cmd.Flags().StringVar(&to, "to", "", "only entries starting before this day")
+	cmd.Flags().StringVar(&match, "match", "", "only entries whose description matches this regular expression")
+	cmd.Flags().StringVar(&expr, "filter", "", "only entries matching this expression, see above")
+	cmd.Flags().BoolVar(&remove, "delete", false, "delete the matching entries")
+	cmd.Flags().StringSliceVar(&addTags, "add-tag", nil, "tag name to add, may be repeated")
+	cmd.Flags().StringSliceVar(&removeTags, "remove-tag", nil, "tag name to remove, may be repeated")
+	cmd.Flags().StringVar(&moveTo, "move-to", "", "project name to move the matching entries to")
+	cmd.Flags().StringVar(&moveToTask, "move-to-task", "", "task of the --move-to project, none if not given")
+	cmd.Flags().StringVar(&find, "find", "", "regular expression to rewrite the matches of in the descriptions")
+	cmd.Flags().StringVar(&replace, "replace", "", "replacement of the --find matches, $1 is the first group")
+	cmd.Flags().StringVar(&tmpl, "template", "", "Go template of the new descriptions, e.g. '{{upper .Description}}'")
+	cmd.Flags().BoolVar(&setBill, "billable", false, "make the matching entries billable")
+	cmd.Flags().BoolVar(&nonBill, "non-billable", false, "make the matching entries non-billable")
+	cmd.Flags().BoolVar(&billRules, "billable-rules", false, "set the billable flag of the matching entries by the configured billable_rules")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list the matching entries")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "apply without asking for confirmation")
+
+	return cmd
+}
+
+// descriptionRewrite computes the new descriptions of cleanup --replace and --template
+type descriptionRewrite struct {
+	find     *regexp.Regexp // Nil rewrites every description with the template
+	replace  string
+	template *template.Template
+}
+
+// rewriteData is what --template renders
+type rewriteData struct {
+	Description string
+	Project     string
+	Start       time.Time
+	Match       []string // The match of --find and its groups, empty without --find
+}
+
+var rewriteFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+func newDescriptionRewrite(find, replace, tmpl string) (*descriptionRewrite, error) {
+	var r descriptionRewrite
+	if find != "" {
+		pattern, err := regexp.Compile(find)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --find: %w", err)
+		}
+		r.find = pattern
+	}
+	r.replace = replace
+	if tmpl != "" {
+		t, err := template.New("description").Funcs(rewriteFuncs).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --template: %w", err)
+		}
+		r.template = t
+	}
+	return &r, nil
+}
+
+// apply returns the new description of the entry, the same one if it is left as it is
+func (r *descriptionRewrite) apply(entry clockify.TimeEntry, project string) (string, error) {
+	if r.template == nil {
+		return r.find.ReplaceAllString(entry.Description, r.replace), nil
+	}
+
+	data := rewriteData{Description: entry.Description, Project: project}
+	if entry.TimeInterval != nil {
+		data.Start = entry.TimeInterval.Start.Local()
+	}
+	if r.find != nil {
+		if data.Match = r.find.FindStringSubmatch(entry.Description); data.Match == nil {
+			return entry.Description, nil
+		}
+	}
+	var b strings.Builder
+	if err := r.template.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// rewriteDescriptions lists the descriptions the rewrite changes as a diff and rewrites them
+// after confirmation
+func rewriteDescriptions(cmd *cobra.Command, s *session, entries []clockify.TimeEntry, projectNames map[string]string, rewrite *descriptionRewrite, dryRun, yes bool) error {
+	out := cmd.OutOrStdout()
+
+	rewritten := make(map[string]string)
+	var changed []clockify.TimeEntry
+	for _, entry := range entries {
+		description, err := rewrite.apply(entry, projectNames[entry.ProjectID])
+		if err != nil {
+			return fmt.Errorf("failed to rewrite entry '%s': %w", entry, err)
+		}
+		if description == entry.Description {
+			continue
+		}
+		rewritten[entry.ID] = description
+		changed = append(changed, entry)
+
+		line := entry.TimeInterval.Start.Local().Format("2006-01-02 15:04")
+		if name, ok := projectNames[entry.ProjectID]; ok {
+			line += " [" + name + "]"
+		}
+		fmt.Fprintf(out, "  %s\n  - %s\n  + %s\n", line, entry.Description, description)
+	}
+
+	if len(changed) == 0 {
+		fmt.Fprintln(out, "No descriptions would change")
+		return nil
+	}
+	if dryRun {
+		fmt.Fprintf(out, "Dry run: %d of %d descriptions would change\n", len(changed), len(entries))
+		return nil
+	}
+
+	if !yes {
+		ok, err := newPrompter(cmd).confirm(fmt.Sprintf("Rewrite %d descriptions?", len(changed)))
+		if errors.Is(err, errNotInteractive) {
+			return errors.New("refusing to change entries without confirmation, pass --yes")
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Cancelled")
+			return nil
+		}
+	}
+
+	updated, err := s.client.RewriteDescriptions(s.workspace.ID, changed, func(entry clockify.TimeEntry) (string, error) {
+		return rewritten[entry.ID], nil
+	})
+	fmt.Fprintf(out, "Rewrote %d of %d descriptions\n", updated, len(changed))
+	return err
+}
+
+// reclassifyEntries lists the entries the rules flip the billable flag of and updates them
+// after confirmation
+func reclassifyEntries(cmd *cobra.Command, s *session, entries []clockify.TimeEntry, projectNames map[string]string, rules billable.Rules, dryRun, yes bool) error {
+	out := cmd.OutOrStdout()
+
+	var changed []clockify.TimeEntry
+	for _, entry := range entries {
+		if flag, ok := rules.Classify(entry); !ok || flag == entry.Billable {
+			continue
+		}
+		changed = append(changed, entry)
+
+		line := entry.String()
+		if name, ok := projectNames[entry.ProjectID]; ok {
+			line += " [" + name + "]"
+		}
+		flag := "billable"
+		if entry.Billable {
+			flag = "non-billable"
+		}
+		fmt.Fprintf(out, "  %s  %s -> %s\n", entry.TimeInterval.Start.Local().Format("2006-01-02 15:04"), line, flag)
+	}
+
+	if len(changed) == 0 {
+		fmt.Fprintln(out, "No billable flags would change")
+		return nil
+	}
+	if dryRun {
+		fmt.Fprintf(out, "Dry run: %d of %d billable flags would change\n", len(changed), len(entries))
+		return nil
+	}
+
+	if !yes {
+		ok, err := newPrompter(cmd).confirm(fmt.Sprintf("Reclassify %d time entries?", len(changed)))
+		if errors.Is(err, errNotInteractive) {
+			return errors.New("refusing to change entries without confirmation, pass --yes")
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Cancelled")
+			return nil
+		}
+	}
+
+	updated, err := rules.Apply(s.client, s.workspace.ID, changed)
+	fmt.Fprintf(out, "Reclassified %d of %d time entries\n", updated, len(changed))
+	return err
+}
+
+// countTrue returns how many of the values are true
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
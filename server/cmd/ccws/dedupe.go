@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/dedupe"
+)
+
+func newDedupeCmd() *cobra.Command {
+	var (
+		kinds  []string
+		exact  bool
+		dryRun bool
+		yes    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "dedupe",
+		Short: "Merge duplicate projects, clients and tags",
+		Long: `Merge the projects, clients and tags whose names differ only by case, spacing, punctuation
+or a typo, e.g. "Web site" and "website".
+
+Each duplicate is merged into the oldest object of its group: the time entries of every
+workspace user are moved from a duplicate project to the kept one, with its task of the same
+name, or retagged from a duplicate tag, and the projects of a duplicate client are moved to
+the kept client. The duplicate is then archived. Projects of different clients are never
+duplicates. The merges are listed and the chosen ones applied.`,
+		Example: `  ccws dedupe --dry-run
+  ccws dedupe --kind tags --exact`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			valid := []dedupe.Kind{dedupe.Projects, dedupe.Clients, dedupe.Tags}
+			var selected []dedupe.Kind
+			for _, kind := range kinds {
+				k := dedupe.Kind(strings.ToLower(kind))
+				if !slices.Contains(valid, k) {
+					return fmt.Errorf("invalid --kind %q, must be projects, clients or tags", kind)
+				}
+				selected = append(selected, k)
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			groups, err := dedupe.Scan(s.client, s.workspace.ID, selected, dedupe.Options{Typos: !exact})
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if len(groups) == 0 {
+				fmt.Fprintln(out, "No duplicates found")
+				return nil
+			}
+
+			for i, group := range groups {
+				names := make([]string, len(group.Duplicates))
+				for j, duplicate := range group.Duplicates {
+					names[j] = duplicate.Name
+				}
+				fmt.Fprintf(out, "%2d. %s: keep %s, merge %s\n", i+1, group.Kind, group.Canonical.Name, strings.Join(names, ", "))
+			}
+			if dryRun {
+				fmt.Fprintf(out, "Dry run: %d groups of duplicates\n", len(groups))
+				return nil
+			}
+
+			chosen := groups
+			if !yes {
+				prompt := newPrompter(cmd)
+				answer, err := prompt.ask("Merges to apply, e.g. 1,3-4 (all, none) [all]")
+				if errors.Is(err, errNotInteractive) {
+					return errors.New("refusing to merge without confirmation, pass --yes")
+				}
+				if err != nil {
+					return err
+				}
+				if chosen, err = selectNumbered(groups, answer); err != nil {
+					return err
+				}
+			}
+			if len(chosen) == 0 {
+				return nil
+			}
+
+			var userIDs []string
+			for page, err := range s.client.IterWorkspaceUsers(s.workspace.ID) {
+				if err != nil {
+					return fmt.Errorf("failed to list users: %w", err)
+				}
+				for _, user := range page {
+					userIDs = append(userIDs, user.ID)
+				}
+			}
+
+			merger := dedupe.NewMerger(s.client, s.workspace.ID, userIDs)
+			var (
+				merged int
+				errs   []error
+			)
+			for _, group := range chosen {
+				moved, err := merger.Merge(group)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				merged++
+				what := "time entries"
+				if group.Kind == dedupe.Clients {
+					what = "projects"
+				}
+				fmt.Fprintf(out, "Merged into %s: moved %d %s\n", group.Canonical.Name, moved, what)
+			}
+			fmt.Fprintf(out, "Merged %d of %d groups\n", merged, len(chosen))
+			return errors.Join(errs...)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&kinds, "kind", []string{"projects", "clients", "tags"}, "what to deduplicate: projects, clients or tags, may be repeated")
+	cmd.Flags().BoolVar(&exact, "exact", false, "only merge names differing by case, spacing or punctuation, not typos")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list the duplicates")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "merge every group without asking")
+
+	return cmd
+}
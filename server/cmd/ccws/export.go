@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/export"
+	"github.com/Hukyl/CCWS/internal/humantime"
+)
+
+func newExportCmd() *cobra.Command {
+	var (
+		from     string
+		to       string
+		allUsers bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [dir]",
+		Short: "Export time entries to Parquet files partitioned by month",
+		Long: `Export the time entries of the workspace to dir/time_entries, "export" by default, as
+Parquet files partitioned by the month of their start, e.g. time_entries/month=2024-05/data.parquet.
+DuckDB, Spark and warehouses load them as a table with a month column.
+
+The partitions of the exported months are replaced, so exporting again refreshes them. Without
+--from and --to the whole history is exported. The server exports the events it receives to
+EXPORT_DIR the same way.`,
+		Example: `  ccws export
+  ccws export /data/clockify --all-users --from 2024-01-01
+  duckdb -c "SELECT project_name, sum(duration_ms) / 3.6e6 AS hours FROM 'export/time_entries/*/*.parquet' GROUP BY 1"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "export"
+			if len(args) == 1 {
+				dir = args[0]
+			}
+
+			now := time.Now()
+			var opts []export.Option
+			if from != "" || to != "" {
+				// An open end reaches back to 2000, or forward to the current month
+				start, end := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), now.AddDate(0, 1, 0)
+				var err error
+				if from != "" {
+					if start, err = humantime.ParseDate(from, now); err != nil {
+						return fmt.Errorf("invalid --from: %w", err)
+					}
+				}
+				if to != "" {
+					if end, err = humantime.ParseDate(to, now); err != nil {
+						return fmt.Errorf("invalid --to: %w", err)
+					}
+				}
+				if !start.Before(end) {
+					return errors.New("--from must be before --to")
+				}
+				opts = append(opts, export.WithPeriod(start, end))
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+			if !allUsers {
+				opts = append(opts, export.WithUsers(*s.user))
+			}
+
+			partitions, err := export.TimeEntries(s.client, s.workspace.ID, dir, opts...)
+			if err != nil {
+				return err
+			}
+
+			rows := 0
+			for _, partition := range partitions {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s  %6d entries  %s\n", partition.Month.Format("2006-01"), partition.Rows, partition.Path)
+				rows += partition.Rows
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d time entries of %s in %d partitions\n", rows, s.workspace.Name, len(partitions))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "export from the month of this day, e.g. 2024-01-01")
+	cmd.Flags().StringVar(&to, "to", "", "export up to the month of this day, included unless the day is the 1st")
+	cmd.Flags().BoolVar(&allUsers, "all-users", false, "include the time entries of every workspace user (requires admin rights)")
+	return cmd
+}
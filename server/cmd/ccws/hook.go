@@ -0,0 +1,240 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/githook"
+)
+
+func newHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Start, stop and annotate timers from git hooks",
+		Long: `Start, stop and annotate timers from git hooks.
+
+The git_hooks section of the config file maps repositories and branches to what the hooks do,
+the first rule matching applies. On checkout of a branch, a rule starts a timer tracking its
+project, task and tags, described by its description template, or stops the running timer.
+On commit, a rule with annotate appends the commit subject to the description of the running
+timer, if it tracks the rule's project. Repositories matching no rule are left alone.
+
+ccws hook install writes the post-checkout and post-commit hooks of the repository, which
+run ccws hook post-checkout and ccws hook post-commit.`,
+		Example: `  ccws hook install`,
+	}
+
+	cmd.AddCommand(newHookInstallCmd(), newHookPostCheckoutCmd(), newHookPostCommitCmd())
+	return cmd
+}
+
+func newHookInstallCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the git hooks in the current repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			executable, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to find the ccws executable: %w", err)
+			}
+			command := []string{executable}
+			if flags.profile != "" {
+				command = append(command, "--profile", flags.profile)
+			}
+			command = append(command, "hook")
+
+			written, err := githook.Install(cmd.Context(), ".", command, force)
+			for _, file := range written {
+				fmt.Fprintf(cmd.OutOrStdout(), "Installed: %s\n", file)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "replace hooks not installed by ccws")
+	return cmd
+}
+
+func newHookPostCheckoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "post-checkout <previous> <new> <branch>",
+		Short: "Run the post-checkout hook",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Checkouts of files leave the timer alone
+			if args[2] != "1" {
+				return nil
+			}
+
+			s, rule, err := openHookSession(cmd)
+			if err != nil || s == nil {
+				return err
+			}
+			if rule.Stop {
+				return s.hookStop(cmd)
+			}
+			return s.hookStart(cmd, rule)
+		},
+	}
+}
+
+func newHookPostCommitCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "post-commit",
+		Short: "Run the post-commit hook",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, rule, err := openHookSession(cmd)
+			if err != nil || s == nil || !rule.Annotate {
+				return err
+			}
+			if s.offline {
+				return fmt.Errorf("annotating the timer %w", errOffline)
+			}
+
+			subject, err := githook.CommitSubject(cmd.Context(), ".")
+			if err != nil {
+				return err
+			}
+			running, err := s.client.GetRunningTimeEntry(s.workspace.ID, s.user.ID)
+			if err != nil || running == nil {
+				return err
+			}
+			if rule.Project != "" {
+				project, err := s.client.FindProjectByName(s.workspace.ID, rule.Project)
+				if err != nil {
+					return err
+				}
+				// The timer was started for other work, e.g. by hand
+				if running.ProjectID != project.ID {
+					return nil
+				}
+			}
+
+			description := githook.Annotate(running.Description, subject)
+			if description == running.Description {
+				return nil
+			}
+			_, err = s.client.UpdateTimeEntry(s.workspace.ID, running.ID, clockify.UpdateTimeEntryRequest{
+				Start:       running.TimeInterval.Start,
+				Billable:    running.Billable,
+				Description: description,
+				ProjectID:   running.ProjectID,
+				TaskID:      running.TaskID,
+				TagIDs:      running.TagIDs,
+				Type:        running.Type,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to annotate timer: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Annotated: %s\n", description)
+			return nil
+		},
+	}
+}
+
+// openHookSession opens a write session for the first git_hooks rule matching the repository
+// in the working directory, with the rule's profile if it has one. The session is nil when no
+// rule matches.
+func openHookSession(cmd *cobra.Command) (*session, config.GitHook, error) {
+	cfg, err := loadConfig(flags.profile)
+	if err != nil {
+		return nil, config.GitHook{}, err
+	}
+	repo, err := githook.Inspect(cmd.Context(), ".")
+	if err != nil {
+		return nil, config.GitHook{}, err
+	}
+
+	for _, rule := range cfg.GitHooks {
+		if !githook.Matches(rule.Repo, rule.Branch, repo) {
+			continue
+		}
+		if rule.Profile != "" {
+			if cfg, err = loadConfig(rule.Profile); err != nil {
+				return nil, rule, err
+			}
+		}
+		s, err := newSession(cfg, true)
+		return s, rule, err
+	}
+	return nil, config.GitHook{}, nil
+}
+
+// hookStart starts the timer of the rule, unless the running timer already tracks it
+func (s *session) hookStart(cmd *cobra.Command, rule config.GitHook) error {
+	description := cmp.Or(rule.Description, "{branch}")
+	target := entryTarget{project: rule.Project, task: rule.Task, tags: rule.Tags}
+
+	var (
+		entry *clockify.TimeEntry
+		err   error
+	)
+	if !s.offline {
+		entry, err = s.startUnlessRunning(description, target)
+		if entry == nil && err == nil {
+			return nil
+		}
+	}
+	if s.canQueue(err) {
+		return s.queueTimer(cmd, description, target, s.cfg.DefaultBillable)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Started: %s at %s\n", s.describeEntry(entry), entry.TimeInterval.Start.Local().Format("15:04"))
+	return nil
+}
+
+// startUnlessRunning starts a timer for the target, nil if one of the same project is running
+// with the description, annotated or not, e.g. when switching back to the branch of a ticket
+func (s *session) startUnlessRunning(description string, target entryTarget) (*clockify.TimeEntry, error) {
+	defaults, err := s.timerDefaults(target)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := s.client.ExpandDescription(description)
+	if err != nil {
+		return nil, err
+	}
+	running, err := s.client.GetRunningTimeEntry(s.workspace.ID, s.user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil && strings.HasPrefix(running.Description, expanded) && running.ProjectID == defaults.ProjectID {
+		return nil, nil
+	}
+	return s.client.StartDefaultTimer(s.workspace.ID, s.user.ID, expanded, defaults)
+}
+
+// hookStop stops the running timer, if any
+func (s *session) hookStop(cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	if s.offline {
+		return s.queueStop(out, time.Now())
+	}
+
+	entry, err := s.stopRunning()
+	switch {
+	case errors.Is(err, clockify.ErrNoRunningTimeEntry):
+		return nil
+	case s.canQueue(err):
+		return s.queueStop(out, time.Now())
+	case err != nil:
+		return fmt.Errorf("failed to stop timer: %w", err)
+	}
+	fmt.Fprintf(out, "Stopped: %s at %s\n", s.describeEntry(entry), entry.TimeInterval.End.Local().Format("15:04"))
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/analytics"
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/humantime"
+	"github.com/Hukyl/CCWS/internal/invoice"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+func newInvoiceCmd() *cobra.Command {
+	var (
+		format string
+		output string
+		offset int
+		from   string
+		to     string
+		number string
+		team   bool
+		push   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "invoice <client>",
+		Short: "Bill a client's billable time of a month",
+		Long: `Bill the billable time tracked on a client's projects, grouped per project and user.
+
+Hours are priced at the rates of the config file's rates section, falling back to the rates set
+in Clockify, then INVOICE_DISCOUNT and INVOICE_TAX are applied. Amounts are in INVOICE_CURRENCY,
+the workspace currency by default, Clockify rates in other currencies are converted by the
+FX_RATES_URL exchange rates. The time is rounded by ROUNDING_INCREMENT, ROUNDING_MODE and
+ROUNDING_SCOPE, the entries in Clockify are left as they are. Pass --push to also create the invoice as a draft in Clockify.`,
+		Example: `  ccws invoice Acme
+  ccws invoice Acme --offset 0 -f pdf -o acme.pdf
+  ccws invoice Acme --from 2024-05-01 --to 2024-05-16 --team --push`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			period := report.Month(now, offset)
+			if from != "" {
+				start, err := humantime.ParseDate(from, now)
+				if err != nil {
+					return fmt.Errorf("invalid --from: %w", err)
+				}
+				period.Start = start
+			}
+			if to != "" {
+				end, err := humantime.ParseDate(to, now)
+				if err != nil {
+					return fmt.Errorf("invalid --to: %w", err)
+				}
+				period.End = end
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			customer, err := s.client.FindClientByName(s.workspace.ID, args[0])
+			if err != nil {
+				return err
+			}
+
+			users := []clockify.User{*s.user}
+			if team {
+				if users, err = analytics.Users(s.client, s.workspace.ID); err != nil {
+					return err
+				}
+			}
+
+			rates := make([]invoice.Rate, len(s.cfg.Rates))
+			for i, rate := range s.cfg.Rates {
+				rates[i] = invoice.Rate{Project: rate.Project, User: rate.User, Amount: rate.Amount}
+			}
+
+			exchange, err := app.FetchExchangeRates(cmd.Context(), app.ExchangeRates(s.cfg))
+			if err != nil {
+				return err
+			}
+
+			inv, err := invoice.Build(s.client, s.workspace.ID, *customer, users, period, now,
+				invoice.WithRates(rates...),
+				invoice.WithCurrency(cmp.Or(s.cfg.InvoiceCurrency, s.workspace.DefaultCurrency())),
+				invoice.WithExchangeRates(exchange),
+				invoice.WithRounding(app.Rounding(s.cfg)),
+				invoice.WithDiscount(s.cfg.InvoiceDiscount),
+				invoice.WithTax(s.cfg.InvoiceTax),
+				invoice.WithIssuer(s.cfg.InvoiceIssuer),
+				invoice.WithDueDays(s.cfg.InvoiceDueDays),
+				invoice.WithNumber(number),
+			)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+
+			if err := invoice.Render(out, inv, report.Format(format)); err != nil {
+				return err
+			}
+
+			if push {
+				pushed, err := invoice.Push(s.client, s.workspace.ID, inv)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Created draft invoice %s in Clockify\n", pushed.Number)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", string(report.FormatTable), "output format: table, csv, json or pdf")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write, stdout if not given")
+	cmd.Flags().IntVar(&offset, "offset", -1, "months relative to the current one, 0 for the current month")
+	cmd.Flags().StringVar(&from, "from", "", "bill entries starting on or after this day instead of the month, e.g. 2024-05-01")
+	cmd.Flags().StringVar(&to, "to", "", "bill entries starting before this day")
+	cmd.Flags().StringVar(&number, "number", "", "invoice number (default the issue date, e.g. 20240601)")
+	cmd.Flags().BoolVar(&team, "team", false, "bill the time of every workspace user (requires admin rights)")
+	cmd.Flags().BoolVar(&push, "push", false, "also create the invoice as a draft in Clockify")
+
+	return cmd
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/humantime"
+	"github.com/Hukyl/CCWS/internal/outbox"
+)
+
+// backfill is the entry being logged, assembled from arguments, flags and prompts
+type backfill struct {
+	duration    time.Duration
+	date        *time.Time
+	hour        int
+	minute      int
+	hasStart    bool
+	description []string
+}
+
+// parseBackfillArgs sorts the positional arguments into a duration, a date, a start time and
+// the description. Arguments containing spaces are always part of the description, and
+// H:MM arguments are start times, since durations are given with units positionally.
+func parseBackfillArgs(args []string, now time.Time) backfill {
+	var b backfill
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, " \t") {
+			if b.duration == 0 && !strings.Contains(arg, ":") {
+				if d, err := humantime.ParseDuration(arg); err == nil {
+					b.duration = d
+					continue
+				}
+			}
+			if b.date == nil {
+				if date, err := humantime.ParseDate(arg, now); err == nil {
+					b.date = &date
+					continue
+				}
+			}
+			if !b.hasStart {
+				if hour, minute, err := humantime.ParseClock(arg); err == nil {
+					b.hour, b.minute, b.hasStart = hour, minute, true
+					continue
+				}
+			}
+		}
+		b.description = append(b.description, arg)
+	}
+	return b
+}
+
+func newLogCmd() *cobra.Command {
+	var (
+		target   entryTarget
+		date     string
+		start    string
+		duration string
+		billable bool
+		yes      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "log [duration] [date] [start] [description]",
+		Short: "Log a completed time entry",
+		Long: `Log a completed time entry.
+
+The duration (2h, 1h30m, 90min), the date (today, yesterday, monday, 2024-05-02) and the
+start time (14:00, 2pm) may be given in any order, the remaining arguments form the description.
+Missing pieces are asked for when running in a terminal. The entry is created after confirmation,
+or queued while Clockify is unreachable, see ccws queue.`,
+		Example: `  ccws log 2h yesterday 14:00 "code review" --project Acme --task TASK42
+  ccws log 15m mon 9:30 standup
+  ccws log "code review" --start 14:00 --duration 1h30m --project Acme --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			entry := parseBackfillArgs(args, now)
+			prompt := newPrompter(cmd)
+
+			// Flags take precedence over positional arguments
+			if duration != "" {
+				d, err := humantime.ParseDuration(duration)
+				if err != nil {
+					return fmt.Errorf("invalid --duration: %w", err)
+				}
+				entry.duration = d
+			}
+			if date != "" {
+				d, err := humantime.ParseDate(date, now)
+				if err != nil {
+					return fmt.Errorf("invalid --date: %w", err)
+				}
+				entry.date = &d
+			}
+			if start != "" {
+				hour, minute, err := humantime.ParseClock(start)
+				if err != nil {
+					return fmt.Errorf("invalid --start: %w", err)
+				}
+				entry.hour, entry.minute, entry.hasStart = hour, minute, true
+			}
+
+			if err := completeBackfill(prompt, &entry, now); err != nil {
+				return err
+			}
+
+			s, err := openWriteSession()
+			if err != nil {
+				return err
+			}
+
+			resolved := &resolvedTarget{}
+			if !s.offline {
+				resolved, err = s.resolve(target)
+				if err != nil && !s.canQueue(err) {
+					return err
+				}
+			}
+
+			startTime := humantime.At(*entry.date, entry.hour, entry.minute)
+			description := strings.Join(entry.description, " ")
+
+			summary := fmt.Sprintf("%s, %s from %s to %s",
+				describeBackfill(description, target, s.cfg.DefaultProject),
+				formatElapsed(entry.duration),
+				startTime.Format("Mon 2006-01-02 15:04"),
+				startTime.Add(entry.duration).Format("15:04"),
+			)
+			if !yes {
+				ok, err := prompt.confirm("Log " + summary + "?")
+				if errors.Is(err, errNotInteractive) {
+					return errors.New("refusing to log without confirmation, pass --yes")
+				}
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Fprintln(cmd.OutOrStdout(), "Cancelled")
+					return nil
+				}
+			}
+
+			var created *clockify.TimeEntry
+			if err == nil && !s.offline {
+				created, err = s.client.CreatePastTimeEntry(s.workspace.ID, s.user.ID, startTime, entry.duration, description, resolved.projectID, resolved.taskID, resolved.tagIDs, billable)
+			}
+			// Offline, or Clockify became unreachable since the session was opened
+			if s.canQueue(err) {
+				end := startTime.Add(entry.duration)
+				request := clockify.NewTimeEntryRequest{Start: startTime, End: &end, Description: description, Billable: billable}
+				project := target.project
+				if project == "" {
+					project = s.cfg.DefaultProject
+				}
+
+				op, err := s.queue.Create(s.workspace.ID, s.user.ID, request, &outbox.Target{Project: project, Task: target.task, Tags: target.tags})
+				if err != nil {
+					return fmt.Errorf("failed to queue time entry: %w", err)
+				}
+				printQueued(cmd.OutOrStdout(), op)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to log time entry: %w", err)
+			}
+
+			// BILLING_INCREMENT may have rounded it up
+			logged := entry.duration
+			if created.TimeInterval != nil && created.TimeInterval.End != nil {
+				logged = created.TimeInterval.End.Sub(created.TimeInterval.Start)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Logged: %s, %s from %s\n", s.describeEntry(created), formatElapsed(logged), startTime.Format("2006-01-02 15:04"))
+			return nil
+		},
+	}
+
+	addTargetFlags(cmd, &target)
+	cmd.Flags().StringVar(&date, "date", "", "day of the entry, e.g. yesterday or 2024-05-02 (default today)")
+	cmd.Flags().StringVar(&start, "start", "", "start time, e.g. 14:00 or 2pm")
+	cmd.Flags().StringVarP(&duration, "duration", "d", "", "duration, e.g. 1h30m or 1:30")
+	cmd.Flags().BoolVar(&billable, "billable", true, "mark the entry as billable")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "log without asking for confirmation")
+
+	return cmd
+}
+
+// completeBackfill asks for the pieces missing from entry. The date defaults to today.
+func completeBackfill(prompt *prompter, entry *backfill, now time.Time) error {
+	missing := func(what string, err error) error {
+		if errors.Is(err, errNotInteractive) {
+			return fmt.Errorf("missing %s", what)
+		}
+		return err
+	}
+
+	if entry.duration == 0 {
+		err := prompt.askUntil("Duration (e.g. 1h30m)", func(answer string) (err error) {
+			entry.duration, err = humantime.ParseDuration(answer)
+			return err
+		})
+		if err != nil {
+			return missing("duration", err)
+		}
+	}
+
+	if entry.date == nil {
+		today := humantime.At(now, 0, 0)
+		entry.date = &today
+	}
+
+	if !entry.hasStart {
+		err := prompt.askUntil("Start time (e.g. 14:00)", func(answer string) (err error) {
+			entry.hour, entry.minute, err = humantime.ParseClock(answer)
+			return err
+		})
+		if err != nil {
+			return missing("start time", err)
+		}
+		entry.hasStart = true
+	}
+
+	if len(entry.description) == 0 && prompt.interactive {
+		answer, err := prompt.ask("Description")
+		if err != nil {
+			return err
+		}
+		if answer != "" {
+			entry.description = []string{answer}
+		}
+	}
+
+	return nil
+}
+
+// describeBackfill describes an entry before it is created, without extra API calls
+func describeBackfill(description string, target entryTarget, defaultProject string) string {
+	if description == "" {
+		description = "(no description)"
+	}
+
+	project := target.project
+	if project == "" {
+		project = defaultProject
+	}
+	switch {
+	case project != "" && target.task != "":
+		return fmt.Sprintf("%s [%s / %s]", description, project, target.task)
+	case project != "":
+		return fmt.Sprintf("%s [%s]", description, project)
+	}
+	return description
+}
@@ -0,0 +1,12 @@
+// Command ccws is a command line client for Clockify.
+package main
+
+import (
+	"os"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/pomodoro"
+)
+
+func newPomoCmd() *cobra.Command {
+	var (
+		target   entryTarget
+		settings pomodoro.Settings
+		noNotify bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pomo [description]",
+		Short: "Run pomodoro cycles, tracking the work phases",
+		Long: `Run work/break cycles: a timer runs during every work phase and is stopped for the break.
+Every boundary shows a desktop notification, with POMO_NOTIFY_COMMAND or notify-send/osascript.
+Interrupt with Ctrl+C, the running timer is stopped and the day's cycles are summarized.`,
+		Example: `  ccws pomo "Write report" -p Acme
+  ccws pomo --work 50m --short-break 10m --cycles 4`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			running, err := s.client.GetRunningTimeEntry(s.workspace.ID, s.user.ID)
+			if err != nil {
+				return err
+			}
+			if running != nil {
+				return fmt.Errorf("a timer is already running: %s, stop it first", s.describeEntry(running))
+			}
+
+			resolved, err := s.resolve(target)
+			if err != nil {
+				return err
+			}
+			description := strings.Join(args, " ")
+
+			out := cmd.OutOrStdout()
+			notifier := pomodoro.NewNotifier(s.cfg.PomoNotifyCommand)
+			notify := func(ctx context.Context, title, message string) {
+				if noNotify {
+					return
+				}
+				if err := notifier.Notify(ctx, title, message); err != nil {
+					// Keep going without notifications, the terminal still shows the phases
+					fmt.Fprintln(cmd.ErrOrStderr(), "Desktop notifications disabled:", err)
+					noNotify = true
+				}
+			}
+
+			hooks := pomodoro.Hooks{
+				Start: func(ctx context.Context, interval pomodoro.Interval) error {
+					fmt.Fprintf(out, "Cycle %d: %s until %s\n", interval.Cycle, interval.Phase, interval.End.Format("15:04"))
+					if interval.Phase != pomodoro.Work {
+						notify(ctx, "Time for a "+interval.Phase.String(), fmt.Sprintf("Back to work at %s", interval.End.Format("15:04")))
+						return nil
+					}
+					if interval.Cycle > 1 {
+						notify(ctx, "Back to work", fmt.Sprintf("Cycle %d until %s", interval.Cycle, interval.End.Format("15:04")))
+					}
+
+					if _, err := s.client.StartTimer(s.workspace.ID, s.user.ID, description, resolved.projectID, resolved.taskID, resolved.tagIDs); err != nil {
+						return fmt.Errorf("failed to start timer: %w", err)
+					}
+					return nil
+				},
+				End: func(ctx context.Context, interval pomodoro.Interval, completed bool) error {
+					if interval.Phase != pomodoro.Work {
+						return nil
+					}
+					if _, err := s.client.StopTimeEntry(s.workspace.ID, s.user.ID, interval.End); err != nil {
+						return fmt.Errorf("failed to stop timer: %w", err)
+					}
+					return nil
+				},
+			}
+
+			stats, runErr := pomodoro.Run(ctx, settings, hooks)
+			if stats.Cycles > 0 || stats.Focused > 0 {
+				notify(context.WithoutCancel(ctx), "Pomodoro finished", fmt.Sprintf("%d cycles, %s focused", stats.Cycles, formatElapsed(stats.Focused)))
+			}
+
+			path, err := pomodoro.DefaultStatsPath()
+			if err == nil {
+				var daily pomodoro.Daily
+				if daily, err = pomodoro.Record(path, stats, time.Now()); err == nil {
+					fmt.Fprintf(out, "Today: %d cycles, %s focused\n", daily.Cycles, formatElapsed(daily.Focused))
+				}
+			}
+			if err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), "Failed to record the day's cycles:", err)
+			}
+
+			return runErr
+		},
+	}
+
+	addTargetFlags(cmd, &target)
+	cmd.Flags().DurationVar(&settings.Work, "work", pomodoro.DefaultWork, "length of a work phase")
+	cmd.Flags().DurationVar(&settings.ShortBreak, "short-break", pomodoro.DefaultShortBreak, "length of a short break")
+	cmd.Flags().DurationVar(&settings.LongBreak, "long-break", pomodoro.DefaultLongBreak, "length of a long break")
+	cmd.Flags().IntVar(&settings.LongEvery, "long-every", pomodoro.DefaultLongEvery, "take a long break after this many cycles")
+	cmd.Flags().IntVar(&settings.Cycles, "cycles", 0, "stop after this many cycles (default until interrupted)")
+	cmd.Flags().BoolVar(&noNotify, "no-notify", false, "do not show desktop notifications")
+
+	return cmd
+}
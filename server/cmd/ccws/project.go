@@ -0,0 +1,220 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+func newProjectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Complete, reopen and recolor projects",
+	}
+
+	cmd.AddCommand(newProjectCompleteCmd(), newProjectReopenCmd(), newProjectRecolorCmd())
+	return cmd
+}
+
+func newProjectCompleteCmd() *cobra.Command {
+	var archive bool
+
+	cmd := &cobra.Command{
+		Use:   "complete <project>",
+		Short: "Mark every task of a project done",
+		Long: `Mark every active task of the project done, so that no more time is tracked on them, and
+with --archive archive the project too.`,
+		Example: `  ccws project complete Website --archive`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+			project, err := s.client.FindProjectByName(s.workspace.ID, args[0])
+			if err != nil {
+				return err
+			}
+
+			updated, err := s.setTaskStatuses(project, clockify.TaskDone)
+			fmt.Fprintf(cmd.OutOrStdout(), "Marked %d tasks of %s done\n", updated, project.Name)
+			if err != nil || !archive {
+				return err
+			}
+
+			archived := true
+			if _, err := s.client.UpdateProject(s.workspace.ID, project.ID, clockify.ProjectUpdate{Archived: &archived}); err != nil {
+				return fmt.Errorf("failed to archive project: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Archived: %s\n", project.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&archive, "archive", false, "also archive the project")
+	return cmd
+}
+
+func newProjectReopenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reopen <project>",
+		Short: "Unarchive a project and mark its done tasks active",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+			project, err := s.client.FindProjectByName(s.workspace.ID, args[0])
+			if err != nil {
+				return err
+			}
+
+			if project.Archived {
+				archived := false
+				if _, err := s.client.UpdateProject(s.workspace.ID, project.ID, clockify.ProjectUpdate{Archived: &archived}); err != nil {
+					return fmt.Errorf("failed to unarchive project: %w", err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Unarchived: %s\n", project.Name)
+			}
+
+			updated, err := s.setTaskStatuses(project, clockify.TaskActive)
+			fmt.Fprintf(cmd.OutOrStdout(), "Marked %d tasks of %s active\n", updated, project.Name)
+			return err
+		},
+	}
+}
+
+func newProjectRecolorCmd() *cobra.Command {
+	var (
+		color  string
+		dryRun bool
+		yes    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "recolor",
+		Short: "Give projects the color of their client and name",
+		Long: `Give every active project the palette color picked by a hash of its client's and its own
+name, the color of the projects ccws creates, e.g. after a migration left them all the same
+color. With --color, only the projects of that color are recolored. The changes are listed and
+applied after confirmation.`,
+		Example: `  ccws project recolor --dry-run
+  ccws project recolor --color '#03A9F4' --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			clientNames := make(map[string]string)
+			for page, err := range s.client.IterClients(s.workspace.ID) {
+				if err != nil {
+					return fmt.Errorf("failed to list clients: %w", err)
+				}
+				for _, c := range page {
+					clientNames[c.ID] = c.Name
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			var (
+				projects []clockify.Project
+				colors   []string
+			)
+			for page, err := range s.client.IterProjects(s.workspace.ID) {
+				if err != nil {
+					return fmt.Errorf("failed to list projects: %w", err)
+				}
+				for _, p := range page {
+					if p.Archived || color != "" && !strings.EqualFold(p.Color, color) {
+						continue
+					}
+					target := clockify.ProjectColor(clientNames[p.ClientID], p.Name)
+					if strings.EqualFold(p.Color, target) {
+						continue
+					}
+					projects = append(projects, p)
+					colors = append(colors, target)
+					fmt.Fprintf(out, "  %s: %s -> %s\n", p.Name, cmp.Or(p.Color, "none"), target)
+				}
+			}
+
+			if len(projects) == 0 {
+				fmt.Fprintln(out, "No projects to recolor")
+				return nil
+			}
+			if dryRun {
+				fmt.Fprintf(out, "Dry run: %d projects to recolor\n", len(projects))
+				return nil
+			}
+			if !yes {
+				ok, err := newPrompter(cmd).confirm(fmt.Sprintf("Recolor %d projects?", len(projects)))
+				if errors.Is(err, errNotInteractive) {
+					return errors.New("refusing to recolor without confirmation, pass --yes")
+				}
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Fprintln(out, "Cancelled")
+					return nil
+				}
+			}
+
+			var (
+				updated int
+				errs    []error
+			)
+			for i, p := range projects {
+				if _, err := s.client.UpdateProject(s.workspace.ID, p.ID, clockify.ProjectUpdate{Color: &colors[i]}); err != nil {
+					errs = append(errs, fmt.Errorf("failed to recolor project %s: %w", p.Name, err))
+					continue
+				}
+				updated++
+			}
+			fmt.Fprintf(out, "Recolored %d of %d projects\n", updated, len(projects))
+			return errors.Join(errs...)
+		},
+	}
+
+	cmd.Flags().StringVar(&color, "color", "", "only recolor the projects of this color, e.g. '#03A9F4'")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list the new colors")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "recolor without asking for confirmation")
+	return cmd
+}
+
+// setTaskStatuses sets the status of every task of the project not having it yet, continuing
+// past failures. It returns how many were updated along with every error.
+func (s *session) setTaskStatuses(project *clockify.Project, status string) (int, error) {
+	// Listed in full first, as updating them may shift the pages
+	var tasks []clockify.Task
+	for page, err := range s.client.IterProjectTasks(s.workspace.ID, project.ID) {
+		if err != nil {
+			return 0, fmt.Errorf("failed to list tasks: %w", err)
+		}
+		tasks = append(tasks, page...)
+	}
+
+	var (
+		updated int
+		errs    []error
+	)
+	for _, task := range tasks {
+		if task.Status == status {
+			continue
+		}
+		if _, err := s.client.SetTaskStatus(s.workspace.ID, task, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update task %s: %w", task.Name, err))
+			continue
+		}
+		updated++
+	}
+	return updated, errors.Join(errs...)
+}
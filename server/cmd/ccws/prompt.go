@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// errNotInteractive is returned when input is needed but stdin is not a terminal
+var errNotInteractive = errors.New("stdin is not a terminal")
+
+// prompter asks the user for missing input on the terminal
+type prompter struct {
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+}
+
+func newPrompter(cmd *cobra.Command) *prompter {
+	in := cmd.InOrStdin()
+	return &prompter{
+		in:          bufio.NewReader(in),
+		out:         cmd.ErrOrStderr(),
+		interactive: isTerminal(in),
+	}
+}
+
+// isTerminal reports whether r is a terminal someone can type into
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(f.Fd())
+}
+
+// ask prints the question and returns the trimmed answer
+func (p *prompter) ask(question string) (string, error) {
+	if !p.interactive {
+		return "", errNotInteractive
+	}
+
+	fmt.Fprintf(p.out, "%s: ", question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// askUntil repeats the question until parse accepts the answer
+func (p *prompter) askUntil(question string, parse func(string) error) error {
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return err
+		}
+		if err := parse(answer); err != nil {
+			fmt.Fprintln(p.out, err)
+			continue
+		}
+		return nil
+	}
+}
+
+// confirm asks a yes/no question, an empty answer means yes
+func (p *prompter) confirm(question string) (bool, error) {
+	answer, err := p.ask(question + " [Y/n]")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "", "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// selectNumbered picks the items of an answer like "1,3-4", "all" or "none", numbered from 1
+// as listed
+func selectNumbered[T any](items []T, answer string) ([]T, error) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "all":
+		return items, nil
+	case "none":
+		return nil, nil
+	}
+
+	var chosen []T
+	for _, part := range strings.Split(answer, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil {
+				return nil, fmt.Errorf("invalid selection %q", part)
+			}
+		}
+		if from < 1 || to > len(items) || from > to {
+			return nil, fmt.Errorf("selection %q is out of range 1-%d", part, len(items))
+		}
+		chosen = append(chosen, items[from-1:to]...)
+	}
+	return chosen, nil
+}
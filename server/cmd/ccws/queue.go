@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/lifecycle"
+	"github.com/Hukyl/CCWS/internal/outbox"
+)
+
+// errOffline is returned by the options that cannot be queued while Clockify is unreachable
+var errOffline = errors.New("cannot be queued while clockify is unreachable")
+
+// identity is the user and workspace of the last session, letting writes be queued offline
+type identity struct {
+	User      clockify.User      `json:"user"`
+	Workspace clockify.Workspace `json:"workspace"`
+}
+
+// profileFile returns the path of a per-profile file in the cache directory
+func profileFile(name, profile string) string {
+	if profile != "" {
+		name += "-" + profile
+	}
+	return lifecycle.MarkerPath(name)
+}
+
+// openQueue returns the offline queue of the config's profile, nil if OFFLINE_QUEUE is disabled. Each
+// profile has its own, replayed with its own credentials.
+func openQueue(cfg *config.Config) *outbox.Queue {
+	if !cfg.OfflineQueue {
+		return nil
+	}
+	return outbox.Open(profileFile("outbox", cfg.Profile))
+}
+
+// rememberIdentity caches the session's user and workspace for offline sessions
+func rememberIdentity(cfg *config.Config, user *clockify.User, workspace *clockify.Workspace) error {
+	return lifecycle.NewMarker(profileFile("identity", cfg.Profile)).Save(identity{User: *user, Workspace: *workspace})
+}
+
+// offlineSession opens a session with the identity cached by the last one, when Clockify is
+// unreachable and writes can be queued. It returns cause otherwise.
+func offlineSession(cfg *config.Config, client *clockify.APIClient, cause error) (*session, error) {
+	queue := openQueue(cfg)
+	if queue == nil || !clockify.IsUnreachable(cause) {
+		return nil, cause
+	}
+
+	var cached identity
+	ok, err := lifecycle.NewMarker(profileFile("identity", cfg.Profile)).Load(&cached)
+	if err != nil {
+		return nil, err
+	}
+	// The cache is only good for the workspace it was made with
+	switch {
+	case !ok,
+		cfg.WorkspaceID != "" && cfg.WorkspaceID != cached.Workspace.ID,
+		cfg.WorkspaceName != "" && cfg.WorkspaceName != cached.Workspace.Name:
+		return nil, cause
+	}
+
+	fmt.Fprintf(os.Stderr, "Clockify is unreachable, queueing writes to %s\n", cached.Workspace.Name)
+	return &session{cfg: cfg, client: client, user: &cached.User, workspace: &cached.Workspace, queue: queue, offline: true}, nil
+}
+
+// canQueue reports whether a write that failed with err should be queued instead
+func (s *session) canQueue(err error) bool {
+	return s.queue != nil && (s.offline || clockify.IsUnreachable(err))
+}
+
+// queueTarget returns the names a queued entry tracks: the target, or the configured defaults
+// when it is empty
+func (s *session) queueTarget(target entryTarget) *outbox.Target {
+	if target.project == "" && target.task == "" && len(target.tags) == 0 {
+		return &outbox.Target{Project: s.cfg.DefaultProject, Task: s.cfg.DefaultTask, Tags: s.cfg.DefaultTags}
+	}
+
+	project := target.project
+	if project == "" {
+		project = s.cfg.DefaultProject
+	}
+	return &outbox.Target{Project: project, Task: target.task, Tags: target.tags}
+}
+
+// printQueued reports a queued operation
+func printQueued(w io.Writer, op outbox.Operation) {
+	fmt.Fprintf(w, "Queued %s: %s, replayed once Clockify is reachable\n", op.ID, op.Describe())
+}
+
+// replayQueue replays the operations queued while Clockify was unreachable. Known conflicts
+// alone are left for ccws queue replay, rather than reported by every command.
+func (s *session) replayQueue(w io.Writer) {
+	ops, err := s.queue.Operations()
+	if err != nil || !slices.ContainsFunc(ops, func(op outbox.Operation) bool { return op.Conflict == "" }) {
+		return
+	}
+
+	result, err := s.queue.Replay(s.client, false)
+	printReplay(w, result, false)
+	if err != nil {
+		fmt.Fprintf(w, "Failed to replay the queue: %v\n", err)
+	}
+}
+
+// printReplay lists what a replay did, forced or not
+func printReplay(w io.Writer, result outbox.Result, forced bool) {
+	for _, applied := range result.Applied {
+		fmt.Fprintf(w, "Replayed %s: %s\n", applied.ID, applied.Describe())
+	}
+	for _, op := range result.Conflicts {
+		fmt.Fprintf(w, "Conflict %s: %s: %s\n", op.ID, op.Describe(), op.Conflict)
+	}
+	switch {
+	case len(result.Conflicts) > 0 && forced:
+		fmt.Fprintln(w, "Drop them with `ccws queue drop`")
+	case len(result.Conflicts) > 0:
+		fmt.Fprintln(w, "Replay them anyway with `ccws queue replay --force`, or drop them with `ccws queue drop`")
+	}
+}
+
+func newQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "List and replay the writes queued while Clockify was unreachable",
+		Long: `List and replay the writes queued while Clockify was unreachable.
+
+While OFFLINE_QUEUE is enabled, ccws start, stop and log queue what they cannot send, and the
+next command reaching Clockify replays the queue. Operations whose entry was changed on the
+server meanwhile are kept as conflicts, to be forced or dropped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listQueue(cmd.OutOrStdout())
+		},
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the queued operations",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return listQueue(cmd.OutOrStdout())
+			},
+		},
+		newQueueReplayCmd(),
+		&cobra.Command{
+			Use:   "drop <id>",
+			Short: "Drop a queued operation",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				_, queue, err := loadQueue()
+				if err != nil {
+					return err
+				}
+				if err := queue.Drop(args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Dropped %s\n", args[0])
+				return nil
+			},
+		},
+	)
+
+	return cmd
+}
+
+func newQueueReplayCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay the queued operations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, queue, err := loadQueue()
+			if err != nil {
+				return err
+			}
+
+			// Without the queue, the session does not replay it before this command does
+			cfg.OfflineQueue = false
+			s, err := newSession(cfg, false)
+			if err != nil {
+				return err
+			}
+
+			result, err := queue.Replay(s.client, force)
+			out := cmd.OutOrStdout()
+			printReplay(out, result, force)
+			if err != nil {
+				return err
+			}
+			if len(result.Applied) == 0 && len(result.Conflicts) == 0 {
+				fmt.Fprintln(out, "Nothing is queued")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "replay conflicting operations anyway, overwriting the changes made on the server")
+
+	return cmd
+}
+
+// loadQueue loads the config and opens the queue of its profile
+func loadQueue() (*config.Config, *outbox.Queue, error) {
+	cfg, err := loadConfig(flags.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	queue := openQueue(cfg)
+	if queue == nil {
+		return nil, nil, errors.New("the offline queue is disabled, set OFFLINE_QUEUE")
+	}
+	return cfg, queue, nil
+}
+
+func listQueue(w io.Writer) error {
+	_, queue, err := loadQueue()
+	if err != nil {
+		return err
+	}
+	ops, err := queue.Operations()
+	if err != nil {
+		return err
+	}
+
+	if len(ops) == 0 {
+		fmt.Fprintln(w, "Nothing is queued")
+		return nil
+	}
+	for _, op := range ops {
+		line := fmt.Sprintf("%s  %s  %s", op.ID, op.QueuedAt.Local().Format("Jan 2 15:04"), op.Describe())
+		if op.Conflict != "" {
+			line += "  (conflict: " + op.Conflict + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
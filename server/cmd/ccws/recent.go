@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+func newRecentCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "recent",
+		Short: "List what you tracked recently, to restart with `ccws start --recent N`",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			recent, err := s.client.GetRecentEntries(s.workspace.ID, s.user.ID, limit)
+			if err != nil {
+				return fmt.Errorf("failed to get recent entries: %w", err)
+			}
+			if len(recent) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Nothing tracked yet")
+				return nil
+			}
+
+			names, err := report.ProjectNames(s.client, s.workspace.ID)
+			if err != nil {
+				return err
+			}
+
+			for i, entry := range recent {
+				fmt.Fprintf(cmd.OutOrStdout(), "%2d  %s (last %s)\n", i+1, describeRecent(entry, names), entry.LastStarted.Local().Format("Jan 2 15:04"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", clockify.DefaultRecentEntries, "number of entries to list")
+
+	return cmd
+}
+
+// describeRecent returns a one-line description of a recent entry, like describeEntry
+func describeRecent(entry clockify.RecentEntry, projectNames map[string]string) string {
+	description := entry.Description
+	if description == "" {
+		description = "(no description)"
+	}
+
+	if name, ok := projectNames[entry.ProjectID]; ok {
+		return fmt.Sprintf("%s [%s]", description, name)
+	}
+	return description
+}
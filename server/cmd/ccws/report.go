@@ -0,0 +1,384 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/analytics"
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/capacity"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/issues"
+	"github.com/Hukyl/CCWS/internal/plan"
+	"github.com/Hukyl/CCWS/internal/query"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// queryHelp documents the expressions of --filter
+const queryHelp = `Expressions compare fields to values, combined with &&, || and ! and grouped with
+parentheses, e.g. project == "Acme" && tag in ["dev"] && duration > 30m:
+
+  description, project, type  == or != a value, =~ or !~ 'a regexp', in or not in ["a", "b"]
+  tag                         == or != a tag name, in or not in a list, =~ or !~ a regexp
+  duration                    ==, !=, <, <=, > or >= a duration, e.g. 30m or 1h30m
+  start                       ==, !=, <, <=, > or >= a day, e.g. 2024-05-01 or monday
+  billable, running           alone, negated with !, or == or != true or false
+
+Project and tag names are compared exactly, type is REGULAR, BREAK, HOLIDAY or TIME_OFF.
+Values without spaces or operators may be left unquoted.`
+
+func newReportCmd() *cobra.Command {
+	var (
+		format   string
+		offset   int
+		profiles []string
+		offline  bool
+		expr     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize tracked time per project and per day, or the team utilization",
+	}
+
+	cmd.PersistentFlags().StringVarP(&format, "format", "f", string(report.FormatTable), "output format: table, csv or json")
+	cmd.PersistentFlags().IntVar(&offset, "offset", 0, "periods relative to the current one, e.g. -1 for the previous one")
+
+	run := func(periodFor func(time.Time, int) report.Period) func(*cobra.Command, []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			period := periodFor(now, offset)
+
+			var selection *query.Query
+			if expr != "" {
+				var err error
+				if selection, err = query.Parse(expr, now); err != nil {
+					return fmt.Errorf("invalid --filter: %w", err)
+				}
+			}
+
+			if offline {
+				if len(profiles) > 0 {
+					return errors.New("--offline cannot be combined with --profiles")
+				}
+
+				summary, err := offlineSummary(cmd.ErrOrStderr(), period, selection, now)
+				if err != nil {
+					return err
+				}
+				return report.Render(cmd.OutOrStdout(), summary, report.Format(format))
+			}
+
+			if len(profiles) > 0 {
+				if flags.profile != "" || flags.workspace != "" {
+					return errors.New("--profiles cannot be combined with --profile or --workspace")
+				}
+				if selection != nil {
+					return errors.New("--filter cannot be combined with --profiles")
+				}
+
+				combined, err := combineProfiles(profiles, period, now)
+				if err != nil {
+					return err
+				}
+				return report.RenderCombined(cmd.OutOrStdout(), combined, report.Format(format))
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			summary, err := s.summarize(period, selection, now)
+			if err != nil {
+				return err
+			}
+
+			return report.Render(cmd.OutOrStdout(), summary, report.Format(format))
+		}
+	}
+
+	week := &cobra.Command{
+		Use:   "week",
+		Short: "Report the current (or --offset) week",
+		Long:  "Report the current (or --offset) week, only the entries matching --filter if given.\n\n" + queryHelp,
+		Args:  cobra.NoArgs,
+		RunE:  run(report.Week),
+	}
+	month := &cobra.Command{
+		Use:   "month",
+		Short: "Report the current (or --offset) month",
+		Long:  "Report the current (or --offset) month, only the entries matching --filter if given.\n\n" + queryHelp,
+		Args:  cobra.NoArgs,
+		RunE:  run(report.Month),
+	}
+	for _, c := range []*cobra.Command{week, month} {
+		c.Flags().StringSliceVar(&profiles, "profiles", nil, "combine the workspaces of these config profiles, e.g. one per client")
+		c.Flags().BoolVar(&offline, "offline", false, "report from the mirror filled by ccws sync, without reaching Clockify")
+		c.Flags().StringVar(&expr, "filter", "", `only entries matching this expression, e.g. 'project == "Acme" && duration > 30m'`)
+	}
+
+	cmd.AddCommand(
+		newUtilizationCmd(&format),
+		newCapacityCmd(&format, &offset),
+		newIssuesReportCmd(&format, &offset),
+		newPlanCmd(&format, &offset),
+		week,
+		month,
+	)
+
+	return cmd
+}
+
+// combineProfiles summarizes the user's entries in the workspace of each profile together
+func combineProfiles(profiles []string, period report.Period, now time.Time) (*report.Combined, error) {
+	sources := make([]report.Source, 0, len(profiles))
+	for _, profile := range profiles {
+		s, err := openProfileSession(profile)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile, err)
+		}
+		sources = append(sources, report.Source{Label: profile, Client: s.client, Workspace: *s.workspace, UserID: s.user.ID})
+	}
+
+	return report.BuildCombined(sources, period, now)
+}
+
+// summarize fetches the user's entries in the period and aggregates those the selection
+// matches, all of them when it is nil
+func (s *session) summarize(period report.Period, selection *query.Query, now time.Time) (*report.Summary, error) {
+	entries, err := report.FetchEntries(s.client, s.workspace.ID, s.user.ID, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch time entries: %w", err)
+	}
+
+	projectNames, err := report.ProjectNames(s.client, s.workspace.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+	if selection != nil {
+		tagNames, err := report.TagNames(s.client, s.workspace.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tags: %w", err)
+		}
+		entries = selection.Apply(entries, query.Names{Projects: projectNames, Tags: tagNames})
+	}
+
+	entries = app.Rounding(s.cfg).Apply(entries, period.Start.Location())
+	return report.Summarize(period, entries, projectNames, now), nil
+}
+
+func newUtilizationCmd(format *string) *cobra.Command {
+	var (
+		weeks int
+		team  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "utilization",
+		Short: "Report utilization, project profitability and the weekly trend",
+		Long: "Report the billable share of the weekly capacity (WEEKLY_CAPACITY and capacities), the revenue of each project\n" +
+			"against its budget, and the weekly trend over the last --weeks weeks, ending with the current one.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			users := []clockify.User{*s.user}
+			if team {
+				if users, err = analytics.Users(s.client, s.workspace.ID); err != nil {
+					return err
+				}
+			}
+
+			model, err := app.CapacityModel(s.cfg).Resolve(s.client, s.workspace.ID, users)
+			if err != nil {
+				return err
+			}
+
+			rates, err := app.FetchExchangeRates(cmd.Context(), app.ExchangeRates(s.cfg))
+			if err != nil {
+				return err
+			}
+
+			opts := analytics.Options{Capacity: model, Currency: s.workspace.DefaultCurrency(), Rates: rates}
+			result, err := analytics.Build(s.client, s.workspace.ID, users, weeks, opts, time.Now())
+			if err != nil {
+				return err
+			}
+
+			return analytics.Render(cmd.OutOrStdout(), result, report.Format(*format))
+		},
+	}
+
+	cmd.Flags().IntVar(&weeks, "weeks", 4, "number of weeks, ending with the current one")
+	cmd.Flags().BoolVar(&team, "team", false, "include every workspace user (requires admin rights)")
+
+	return cmd
+}
+
+func newCapacityCmd(format *string, offset *int) *cobra.Command {
+	var (
+		month bool
+		team  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "capacity",
+		Short: "Report the time logged against the capacity of each user",
+		Long: "Report the time logged in the current (or --offset) week or month against the weekly capacity of\n" +
+			"each user, from WEEKLY_CAPACITY, the capacities of the config file and with CAPACITY_SOURCE=clockify\n" +
+			"their member profiles, with how far over or under it they are. Capacity counts weekdays up to today.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			users := []clockify.User{*s.user}
+			if team {
+				if users, err = analytics.Users(s.client, s.workspace.ID); err != nil {
+					return err
+				}
+			}
+
+			model, err := app.CapacityModel(s.cfg).Resolve(s.client, s.workspace.ID, users)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			period := report.Week(now, *offset)
+			if month {
+				period = report.Month(now, *offset)
+			}
+
+			result, err := capacity.Build(s.client, s.workspace.ID, users, period, model, now)
+			if err != nil {
+				return err
+			}
+
+			return capacity.Render(cmd.OutOrStdout(), result, report.Format(*format))
+		},
+	}
+
+	cmd.Flags().BoolVar(&month, "month", false, "report the month instead of the week")
+	cmd.Flags().BoolVar(&team, "team", false, "include every workspace user (requires admin rights)")
+
+	return cmd
+}
+
+func newIssuesReportCmd(format *string, offset *int) *cobra.Command {
+	var month bool
+
+	cmd := &cobra.Command{
+		Use:   "issues",
+		Short: "Report the time per issue referenced in descriptions, with titles and links",
+		Long: "Report the time tracked on the GitLab and Linear issues referenced in the descriptions of the\n" +
+			"current (or --offset) week or month. Trackers are enabled by GITLAB_TOKEN and LINEAR_API_KEY.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			resolvers := app.NewIssueResolvers(s.cfg)
+			if resolvers.Empty() {
+				return errors.New("no issue tracker configured, set GITLAB_TOKEN or LINEAR_API_KEY")
+			}
+
+			now := time.Now()
+			period := report.Week(now, *offset)
+			if month {
+				period = report.Month(now, *offset)
+			}
+
+			entries, err := report.FetchEntries(s.client, s.workspace.ID, s.user.ID, period)
+			if err != nil {
+				return fmt.Errorf("failed to fetch time entries: %w", err)
+			}
+
+			enriched, err := resolvers.Enrich(cmd.Context(), entries)
+			if err != nil {
+				// Report what resolved, a tracker being down should not hide the others
+				fmt.Fprintln(cmd.ErrOrStderr(), "Some issues could not be resolved:", err)
+			}
+
+			return issues.Render(cmd.OutOrStdout(), period, issues.Totals(entries, enriched, now), report.Format(*format))
+		},
+	}
+
+	cmd.Flags().BoolVar(&month, "month", false, "report the month instead of the week")
+	return cmd
+}
+
+func newPlanCmd(format *string, offset *int) *cobra.Command {
+	var (
+		weeks     int
+		team      bool
+		tolerance float64
+		minimum   time.Duration
+		flagged   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Report the time tracked against the scheduled assignments per user, project and week",
+		Long: "Report the time scheduled by the assignments of Clockify's scheduling against the time tracked, per\n" +
+			"user, project and week over the last --weeks weeks, ending with the current (or --offset) one. Rows\n" +
+			"deviating from the plan by --tolerance percent and --min-deviation are flagged with a !, time on\n" +
+			"unscheduled projects by --min-deviation alone. The plan counts days up to today and skips weekends\n" +
+			"unless an assignment includes them. Scheduling requires a Clockify subscription that includes it.",
+		Example: `  ccws report plan --team
+  ccws report plan --team --weeks 1 --offset -1 --flagged
+  ccws report plan --tolerance 10 --min-deviation 30m --format csv`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if weeks < 1 {
+				return fmt.Errorf("--weeks must be at least 1, got %d", weeks)
+			}
+			if tolerance < 0 || minimum < 0 {
+				return errors.New("--tolerance and --min-deviation cannot be negative")
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			users := []clockify.User{*s.user}
+			if team {
+				if users, err = analytics.Users(s.client, s.workspace.ID); err != nil {
+					return err
+				}
+			}
+
+			now := time.Now()
+			period := report.Period{Start: report.Week(now, *offset+1-weeks).Start, End: report.Week(now, *offset).End}
+			result, err := plan.Build(s.client, s.workspace.ID, users, period, plan.Tolerance{Share: tolerance / 100, Min: minimum}, now)
+			if err != nil {
+				return err
+			}
+			if flagged {
+				result.Rows = result.Flagged()
+			}
+
+			return plan.Render(cmd.OutOrStdout(), result, report.Format(*format))
+		},
+	}
+
+	cmd.Flags().IntVar(&weeks, "weeks", 1, "number of weeks, ending with the current (or --offset) one")
+	cmd.Flags().BoolVar(&team, "team", false, "include every workspace user (requires admin rights)")
+	cmd.Flags().Float64Var(&tolerance, "tolerance", 20, "percent of the plan a deviation is flagged from")
+	cmd.Flags().DurationVar(&minimum, "min-deviation", time.Hour, "smallest deviation flagged, whatever its percent")
+	cmd.Flags().BoolVar(&flagged, "flagged", false, "only list the flagged rows")
+
+	return cmd
+}
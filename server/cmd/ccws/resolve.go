@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// entryTarget names what a time entry is tracked against, as given on the command line
+type entryTarget struct {
+	project string
+	task    string
+	tags    []string
+}
+
+// resolvedTarget holds the IDs of an entryTarget
+type resolvedTarget struct {
+	projectID *string
+	taskID    *string
+	tagIDs    []string
+}
+
+// resolve looks up the project, task and tag names. The configured default project
+// is used when no project is given.
+func (s *session) resolve(target entryTarget) (*resolvedTarget, error) {
+	var resolved resolvedTarget
+
+	projectName := target.project
+	if projectName == "" {
+		projectName = s.cfg.DefaultProject
+	}
+
+	if projectName != "" {
+		project, err := s.client.FindProjectByName(s.workspace.ID, projectName)
+		if err != nil {
+			return nil, err
+		}
+		resolved.projectID = &project.ID
+	}
+
+	if target.task != "" {
+		if resolved.projectID == nil {
+			return nil, fmt.Errorf("a task requires a project")
+		}
+		task, err := s.client.FindTaskByName(s.workspace.ID, *resolved.projectID, target.task)
+		if err != nil {
+			return nil, err
+		}
+		resolved.taskID = &task.ID
+	}
+
+	tagIDs, err := s.tagIDs(target.tags)
+	if err != nil {
+		return nil, err
+	}
+	resolved.tagIDs = tagIDs
+
+	return &resolved, nil
+}
+
+// timerDefaults returns what a timer started for target tracks: the configured defaults when
+// the target is empty, the resolved target otherwise
+func (s *session) timerDefaults(target entryTarget) (clockify.TimerDefaults, error) {
+	if target.project == "" && target.task == "" && len(target.tags) == 0 {
+		return app.ResolveTimerDefaults(s.client, s.cfg, s.workspace.ID)
+	}
+
+	resolved, err := s.resolve(target)
+	if err != nil {
+		return clockify.TimerDefaults{}, err
+	}
+
+	defaults := clockify.TimerDefaults{TagIDs: resolved.tagIDs, Billable: s.cfg.DefaultBillable}
+	if resolved.projectID != nil {
+		defaults.ProjectID = *resolved.projectID
+	}
+	if resolved.taskID != nil {
+		defaults.TaskID = *resolved.taskID
+	}
+	return defaults, nil
+}
+
+// tagIDs looks up the IDs of the named tags
+func (s *session) tagIDs(names []string) ([]string, error) {
+	var ids []string
+	for _, name := range names {
+		tag, err := s.client.FindTagByName(s.workspace.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, tag.ID)
+	}
+	return ids, nil
+}
+
+// addTargetFlags registers the --project, --task and --tag flags filling target
+func addTargetFlags(cmd *cobra.Command, target *entryTarget) {
+	cmd.Flags().StringVarP(&target.project, "project", "p", "", "project name (defaults to the configured default project)")
+	cmd.Flags().StringVarP(&target.task, "task", "t", "", "task name within the project")
+	cmd.Flags().StringSliceVar(&target.tags, "tag", nil, "tag name, may be repeated")
+}
+
+// describeEntry returns a one-line human readable description of an entry
+func (s *session) describeEntry(entry *clockify.TimeEntry) string {
+	description := entry.Description
+	if description == "" {
+		description = "(no description)"
+	}
+
+	if entry.ProjectID == "" {
+		return description
+	}
+
+	project, err := s.client.GetProject(s.workspace.ID, entry.ProjectID)
+	if err != nil {
+		return description
+	}
+	return fmt.Sprintf("%s [%s]", description, project.Name)
+}
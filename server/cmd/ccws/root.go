@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/outbox"
+	"github.com/Hukyl/CCWS/internal/placeholders"
+)
+
+// globalFlags are shared by all subcommands
+type globalFlags struct {
+	profile   string
+	workspace string
+}
+
+var flags globalFlags
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "ccws",
+		Short:         "Control Clockify timers and time entries from the terminal",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+	}
+
+	root.PersistentFlags().StringVar(&flags.profile, "profile", "", "config profile to use, overriding CCWS_PROFILE")
+	root.PersistentFlags().StringVarP(&flags.workspace, "workspace", "w", "", "workspace name, overriding the configured one")
+
+	root.AddCommand(
+		newStartCmd(),
+		newRecentCmd(),
+		newStopCmd(),
+		newPomoCmd(),
+		newStatusCmd(),
+		newLogCmd(),
+		newCleanupCmd(),
+		newDedupeCmd(),
+		newProjectCmd(),
+		newBackupCmd(),
+		newExportCmd(),
+		newSyncCmd(),
+		newQueueCmd(),
+		newAuditCmd(),
+		newReportCmd(),
+		newInvoiceCmd(),
+		newSuggestCmd(),
+		newHookCmd(),
+		newTUICmd(),
+	)
+
+	return root
+}
+
+// session holds what every command needs to talk to Clockify
+type session struct {
+	cfg       *config.Config
+	client    *clockify.APIClient
+	user      *clockify.User
+	workspace *clockify.Workspace
+
+	queue *outbox.Queue // Writes made while Clockify is unreachable, nil if disabled
+	// Whether Clockify was unreachable when opening the session, user and workspace being the
+	// cached ones
+	offline bool
+}
+
+// openSession loads the config, applies the global flags and resolves the current user and workspace
+func openSession() (*session, error) {
+	return openProfileSession(flags.profile)
+}
+
+// openWriteSession is openSession for commands queueing their writes when Clockify is
+// unreachable, the session is then offline
+func openWriteSession() (*session, error) {
+	cfg, err := loadConfig(flags.profile)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(cfg, true)
+}
+
+// openProfileSession is openSession for the given profile instead of --profile, none for ""
+func openProfileSession(profile string) (*session, error) {
+	cfg, err := loadConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(cfg, false)
+}
+
+// newSession resolves the current user and workspace, falling back to an offline session if
+// allowed. Once Clockify is reached, the writes queued before are replayed.
+func newSession(cfg *config.Config, allowOffline bool) (*session, error) {
+	// Keep the terminal clean, only warnings and errors are logged
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	// The log is closed when the command exits, writes are recorded as they complete
+	_, clientOpts, err := app.OpenAuditLog(cfg, cliActor(cfg.Profile))
+	if err != nil {
+		return nil, err
+	}
+	expander, err := descriptionExpander(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := app.NewClient(cfg, append(clientOpts, expander)...)
+	if err != nil {
+		return nil, err
+	}
+	offline := func(err error) (*session, error) {
+		if !allowOffline {
+			return nil, err
+		}
+		return offlineSession(cfg, client, err)
+	}
+
+	identity, err := client.ValidateCredentials()
+	if err != nil {
+		return offline(err)
+	}
+	user := identity.User
+
+	workspace, err := app.ResolveWorkspace(cfg, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{cfg: cfg, client: client, user: user, workspace: workspace, queue: openQueue(cfg)}
+	if s.queue != nil {
+		if err := rememberIdentity(cfg, user, workspace); err != nil {
+			slog.Warn("identity_cache_failed", "error", err)
+		}
+		s.replayQueue(os.Stderr)
+	}
+	return s, nil
+}
+
+// descriptionExpander expands the placeholders of the descriptions the CLI starts timers with,
+// e.g. "Review {ticket}" run from a git hook
+func descriptionExpander(cfg *config.Config) (clockify.ClientOption, error) {
+	ticketPattern, err := regexp.Compile(cfg.TicketPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TICKET_PATTERN: %w", err)
+	}
+	expander := placeholders.New(
+		placeholders.Date(),
+		placeholders.Env(),
+		placeholders.GitBranch(),
+		placeholders.Ticket("CCWS_TICKET", ticketPattern),
+	)
+	return clockify.WithDescriptionExpander(expander.Expand), nil
+}
+
+// cliActor is who the writes of the CLI are audited as made by
+func cliActor(profile string) string {
+	if profile == "" {
+		return "cli"
+	}
+	return "cli:" + profile
+}
+
+// loadConfig loads the config with the given profile, that of CCWS_PROFILE for "", and the
+// --workspace flag applied
+func loadConfig(profile string) (*config.Config, error) {
+	cfg, err := config.LoadProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.workspace != "" {
+		cfg.WorkspaceName = flags.workspace
+		cfg.WorkspaceID = ""
+	}
+	return cfg, nil
+}
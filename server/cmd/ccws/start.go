@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+func newStartCmd() *cobra.Command {
+	var (
+		target    entryTarget
+		billable  bool
+		recent    int
+		takeBreak bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "start [description]",
+		Short: "Start a timer",
+		Long: `Start a timer.
+
+Without --project, --task and --tag, the timer tracks the defaults: CLOCKIFY_DEFAULT_PROJECT,
+CLOCKIFY_DEFAULT_TASK and CLOCKIFY_DEFAULT_TAGS. With --recent, it restarts an entry listed by
+ccws recent, with the same description, project, task and tags. With --break, it tracks a
+break, which reports total apart from work, in workspaces that track breaks. While Clockify is
+unreachable, the timer is queued, see ccws queue, except for --recent and --break.
+
+The description may contain placeholders: {branch} is the git branch checked out, {ticket} the
+CCWS_TICKET environment variable or else the match of TICKET_PATTERN in the branch, {date} is
+today, or in a Go layout with {date:Jan 2}, and {env:NAME} an environment variable.`,
+		Example: `  ccws start "Code review" --project Website
+  ccws start "Review {ticket} on {branch}"
+  ccws start --recent 1
+  ccws start Lunch --break`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openWriteSession()
+			if err != nil {
+				return err
+			}
+
+			var entry *clockify.TimeEntry
+			if cmd.Flags().Changed("recent") {
+				if len(args) > 0 {
+					return errors.New("--recent restarts the description of the recent entry, pass no description")
+				}
+				if s.offline {
+					return fmt.Errorf("--recent %w", errOffline)
+				}
+				entry, err = s.client.StartFromRecent(s.workspace.ID, s.user.ID, recent)
+			} else if takeBreak {
+				if s.offline {
+					return fmt.Errorf("--break %w", errOffline)
+				}
+				entry, err = s.client.StartBreak(s.workspace.ID, s.user.ID, strings.Join(args, " "))
+			} else {
+				if !s.offline {
+					var defaults clockify.TimerDefaults
+					if defaults, err = s.timerDefaults(target); err == nil {
+						if cmd.Flags().Changed("billable") {
+							defaults.Billable = billable
+						}
+						entry, err = s.client.StartDefaultTimer(s.workspace.ID, s.user.ID, strings.Join(args, " "), defaults)
+					}
+				}
+				// Offline, or Clockify became unreachable since the session was opened
+				if s.canQueue(err) {
+					return s.queueTimer(cmd, strings.Join(args, " "), target, billable)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to start timer: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Started: %s at %s\n", s.describeEntry(entry), entry.TimeInterval.Start.Local().Format("15:04"))
+			return nil
+		},
+	}
+
+	addTargetFlags(cmd, &target)
+	cmd.Flags().BoolVar(&billable, "billable", true, "whether the entry is billable (defaults to CLOCKIFY_DEFAULT_BILLABLE)")
+	cmd.Flags().IntVarP(&recent, "recent", "r", 1, "restart the n-th entry listed by ccws recent")
+	cmd.MarkFlagsMutuallyExclusive("recent", "project")
+	cmd.MarkFlagsMutuallyExclusive("recent", "task")
+	cmd.MarkFlagsMutuallyExclusive("recent", "tag")
+	cmd.MarkFlagsMutuallyExclusive("recent", "billable")
+	cmd.Flags().BoolVar(&takeBreak, "break", false, "track a break rather than work")
+	for _, flag := range []string{"recent", "project", "task", "tag", "billable"} {
+		cmd.MarkFlagsMutuallyExclusive("break", flag)
+	}
+
+	return cmd
+}
+
+// queueTimer queues starting a timer now, its target resolved when replayed
+func (s *session) queueTimer(cmd *cobra.Command, description string, target entryTarget, billable bool) error {
+	// Expanded now, the branch may be another by the time the queue is replayed
+	description, err := s.client.ExpandDescription(description)
+	if err != nil {
+		return err
+	}
+	request := clockify.NewTimeEntryRequest{Start: time.Now(), Description: description, Billable: s.cfg.DefaultBillable}
+	if cmd.Flags().Changed("billable") {
+		request.Billable = billable
+	}
+
+	op, err := s.queue.Create(s.workspace.ID, s.user.ID, request, s.queueTarget(target))
+	if err != nil {
+		return fmt.Errorf("failed to queue timer: %w", err)
+	}
+	printQueued(cmd.OutOrStdout(), op)
+	return nil
+}
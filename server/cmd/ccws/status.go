@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the running timer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			running, err := s.client.GetRunningTimeEntry(s.workspace.ID, s.user.ID)
+			if err != nil {
+				return err
+			}
+			if running == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "No timer is running")
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Running: %s for %s (since %s)\n",
+				s.describeEntry(running),
+				formatElapsed(time.Since(running.TimeInterval.Start)),
+				running.TimeInterval.Start.Local().Format("15:04"),
+			)
+			return nil
+		},
+	}
+}
+
+// formatElapsed formats a duration as e.g. "1h05m" or "12m30s"
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Second)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh%02dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm%02ds", minutes, seconds)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/humantime"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+func newStopCmd() *cobra.Command {
+	var (
+		idle    string
+		splitAt string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the running timer",
+		Long: `Stop the running timer.
+
+Pass --idle to drop the time you were away, e.g. the last 20 minutes, or --split-at to stop at
+the time you left and record the rest as a second entry you can move or delete. While Clockify
+is unreachable, the stop is queued, see ccws queue.`,
+		Example: `  ccws stop --idle 20m
+  ccws stop --split-at 16:45`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if idle != "" && splitAt != "" {
+				return errors.New("pass either --idle or --split-at")
+			}
+
+			var (
+				idleTime time.Duration
+				boundary time.Time
+			)
+			if idle != "" {
+				d, err := humantime.ParseDuration(idle)
+				if err != nil {
+					return fmt.Errorf("invalid --idle: %w", err)
+				}
+				idleTime = d
+			}
+			if splitAt != "" {
+				hour, minute, err := humantime.ParseClock(splitAt)
+				if err != nil {
+					return fmt.Errorf("invalid --split-at: %w", err)
+				}
+				boundary = humantime.At(time.Now(), hour, minute)
+				// A time later than now means yesterday, for timers running past midnight
+				if boundary.After(time.Now()) {
+					boundary = boundary.AddDate(0, 0, -1)
+				}
+			}
+
+			s, err := openWriteSession()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			var entry, rest *clockify.TimeEntry
+			switch {
+			case s.offline:
+			case idleTime > 0:
+				entry, err = s.client.TrimRunningTimer(s.workspace.ID, s.user.ID, idleTime)
+			case !boundary.IsZero():
+				entry, rest, err = s.client.SplitRunningTimer(s.workspace.ID, s.user.ID, boundary)
+			default:
+				entry, err = s.stopRunning()
+			}
+			// A split stops the timer before creating the rest, only queued if nothing was done
+			if s.canQueue(err) && entry == nil {
+				if !boundary.IsZero() {
+					return fmt.Errorf("--split-at %w", errOffline)
+				}
+				return s.queueStop(out, time.Now().Add(-idleTime))
+			}
+			if errors.Is(err, clockify.ErrNoRunningTimeEntry) {
+				fmt.Fprintln(out, "No timer is running")
+				return nil
+			}
+			if err != nil {
+				if entry != nil {
+					fmt.Fprintf(out, "Stopped: %s at %s\n", s.describeEntry(entry), entry.TimeInterval.End.Local().Format("15:04"))
+				}
+				return fmt.Errorf("failed to stop timer: %w", err)
+			}
+
+			fmt.Fprintf(out, "Stopped: %s after %s\n", s.describeEntry(entry), formatElapsed(report.EntryDuration(*entry, time.Now())))
+			if idleTime > 0 {
+				fmt.Fprintf(out, "Dropped %s of idle time\n", formatElapsed(idleTime))
+			}
+			if rest != nil {
+				fmt.Fprintf(out, "Split off: %s from %s, %s\n", s.describeEntry(rest), boundary.Format("15:04"), formatElapsed(report.EntryDuration(*rest, time.Now())))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&idle, "idle", "", "drop this much idle time before now, e.g. 20m or 0:20")
+	cmd.Flags().StringVar(&splitAt, "split-at", "", "stop at this time, e.g. 16:45, and record the rest as another entry")
+
+	return cmd
+}
+
+// queueStop queues stopping the timer at end: the timer queued last if one is, whatever timer
+// runs otherwise
+func (s *session) queueStop(w io.Writer, end time.Time) error {
+	entryID, err := s.queue.RunningTimer(s.workspace.ID, s.user.ID)
+	if err != nil {
+		return err
+	}
+
+	op, err := s.queue.Stop(s.workspace.ID, s.user.ID, entryID, end)
+	if err != nil {
+		return fmt.Errorf("failed to queue stop: %w", err)
+	}
+	printQueued(w, op)
+	return nil
+}
+
+// stopRunning stops the running timer now, clockify.ErrNoRunningTimeEntry when none is running
+func (s *session) stopRunning() (*clockify.TimeEntry, error) {
+	running, err := s.client.GetRunningTimeEntry(s.workspace.ID, s.user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if running == nil {
+		return nil, clockify.ErrNoRunningTimeEntry
+	}
+	return s.client.StopTimeEntry(s.workspace.ID, s.user.ID, time.Now())
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/github"
+	"github.com/Hukyl/CCWS/internal/humantime"
+)
+
+func newSuggestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "suggest",
+		Short: "Draft time entries from your activity elsewhere",
+	}
+
+	cmd.AddCommand(newSuggestGitHubCmd())
+	return cmd
+}
+
+func newSuggestGitHubCmd() *cobra.Command {
+	var (
+		date string
+		yes  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "github",
+		Short: "Draft entries from a day's GitHub commits and pull request reviews",
+		Long: `Draft time entries from the commits and pull request reviews of a day on GitHub.
+
+Consecutive commits to a repository form an entry from GITHUB_COMMIT_LEAD before the first
+commit to the last one, until commits are more than GITHUB_SESSION_GAP apart. The commit
+subjects become the description, and the github_projects section of the config file maps
+repositories to projects. The drafts are listed and the chosen ones created.`,
+		Example: `  ccws suggest github
+  ccws suggest github --date yesterday --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			day := now
+			if date != "" {
+				var err error
+				if day, err = humantime.ParseDate(date, now); err != nil {
+					return fmt.Errorf("invalid --date: %w", err)
+				}
+			}
+
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+			if s.cfg.GitHubToken == "" {
+				return errors.New("GITHUB_TOKEN is required to read your GitHub activity")
+			}
+
+			var opts []github.Option
+			if s.cfg.GitHubAPIURL != "" {
+				opts = append(opts, github.WithBaseURL(s.cfg.GitHubAPIURL))
+			}
+			gh := github.NewClient(s.cfg.GitHubToken, opts...)
+
+			ctx := cmd.Context()
+			login := s.cfg.GitHubUser
+			if login == "" {
+				if login, err = gh.Login(ctx); err != nil {
+					return fmt.Errorf("failed to get the GitHub user: %w", err)
+				}
+			}
+
+			activities, err := gh.Activity(ctx, login, day)
+			if err != nil {
+				return err
+			}
+			suggestions := github.Suggest(activities, github.SuggestOptions{
+				Projects: s.cfg.GitHubProjects,
+				Lead:     s.cfg.GitHubCommitLead,
+				MaxGap:   s.cfg.GitHubSessionGap,
+			})
+			if len(suggestions) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No GitHub activity of %s on %s\n", login, day.Format("Mon 2006-01-02"))
+				return nil
+			}
+
+			out := cmd.OutOrStdout()
+			for i, suggestion := range suggestions {
+				project := suggestion.Project
+				if project == "" {
+					project = "no project"
+				}
+				fmt.Fprintf(out, "%2d. %s–%s %s [%s, %s]\n", i+1,
+					suggestion.Start.Local().Format("15:04"), suggestion.End.Local().Format("15:04"),
+					suggestion.Description, suggestion.Repo, project)
+			}
+
+			chosen := suggestions
+			if !yes {
+				prompt := newPrompter(cmd)
+				answer, err := prompt.ask("Entries to create, e.g. 1,3-4 (all, none) [all]")
+				if errors.Is(err, errNotInteractive) {
+					return errors.New("refusing to create entries without confirmation, pass --yes")
+				}
+				if err != nil {
+					return err
+				}
+				if chosen, err = selectNumbered(suggestions, answer); err != nil {
+					return err
+				}
+			}
+
+			created, err := s.createSuggestions(chosen)
+			fmt.Fprintf(out, "Created %d of %d entries\n", created, len(chosen))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&date, "date", "", "day of the activity, e.g. yesterday or 2024-05-02 (default today)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "create every draft without asking")
+
+	return cmd
+}
+
+// createSuggestions creates the drafts as entries, continuing past failures.
+// Entries are billable when their project is.
+func (s *session) createSuggestions(suggestions []github.Suggestion) (int, error) {
+	projects := make(map[string]*clockify.Project)
+	var (
+		created int
+		errs    []error
+	)
+
+	for _, suggestion := range suggestions {
+		var projectID *string
+		billable := false
+		if suggestion.Project != "" {
+			project, ok := projects[suggestion.Project]
+			if !ok {
+				var err error
+				if project, err = s.client.FindProjectByName(s.workspace.ID, suggestion.Project); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", suggestion.Repo, err))
+				}
+				projects[suggestion.Project] = project
+			}
+			if project == nil {
+				continue
+			}
+			projectID, billable = &project.ID, project.Billable
+		}
+
+		if _, err := s.client.CreateTimeEntryWithDates(s.workspace.ID, s.user.ID, suggestion.Start, suggestion.End, suggestion.Description, projectID, nil, nil, billable); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create %q: %w", suggestion.Description, err))
+			continue
+		}
+		created++
+	}
+
+	return created, errors.Join(errs...)
+}
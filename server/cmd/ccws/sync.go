@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/mirror"
+	"github.com/Hukyl/CCWS/internal/query"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// errNoMirror is returned by the commands using the mirror when it is not configured
+var errNoMirror = errors.New("no mirror configured, set DATABASE_DSN")
+
+func newSyncCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync",
+		Short: "Mirror your time entries, projects and tags into DATABASE_DSN for offline reports",
+		Long: "Mirror the workspace into the SQLite database at DATABASE_DSN. The first sync fetches every\n" +
+			"entry, later ones those since MIRROR_WINDOW before the previous sync. Reports read the mirror\n" +
+			"with --offline.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+			if s.cfg.DatabaseDSN == "" {
+				return errNoMirror
+			}
+
+			store, err := mirror.Open(s.cfg.DatabaseDSN)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			syncer := mirror.NewSyncer(store, s.client, *s.workspace, []string{s.user.ID}, mirror.WithWindow(s.cfg.MirrorWindow))
+			stats, err := syncer.Sync(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			kind := "Synced"
+			if stats.Backfilled > 0 {
+				kind = "Backfilled"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %d entries, %d projects and %d tags of %s\n", kind, stats.Entries, stats.Projects, stats.Tags, s.workspace.Name)
+			return nil
+		},
+	}
+}
+
+// offlineSummary aggregates the user's entries in the period from the mirror, without
+// reaching Clockify. The workspace is the configured one, or the last synced one when none is.
+// Only the entries the selection matches are aggregated, all of them when it is nil.
+func offlineSummary(stderr io.Writer, period report.Period, selection *query.Query, now time.Time) (*report.Summary, error) {
+	cfg, err := loadConfig(flags.profile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DatabaseDSN == "" {
+		return nil, errNoMirror
+	}
+
+	store, err := mirror.Open(cfg.DatabaseDSN)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	m, err := findMirror(store, cfg)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(stderr, "Offline report of %s as synced %s\n", m.Workspace.Name, m.SyncedAt.Local().Format("Jan 2 15:04"))
+
+	entries, err := store.TimeEntries(m.Workspace.ID, m.UserID, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+	projectNames, err := store.ProjectNames(m.Workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	if selection != nil {
+		tagNames, err := store.TagNames(m.Workspace.ID)
+		if err != nil {
+			return nil, err
+		}
+		entries = selection.Apply(entries, query.Names{Projects: projectNames, Tags: tagNames})
+	}
+
+	return report.Summarize(period, entries, projectNames, now), nil
+}
+
+// findMirror returns the mirror of the configured workspace, the last synced one if none is
+// configured
+func findMirror(store *mirror.Store, cfg *config.Config) (mirror.Mirror, error) {
+	mirrors, err := store.Mirrors()
+	if err != nil {
+		return mirror.Mirror{}, err
+	}
+
+	for _, m := range mirrors {
+		switch {
+		case cfg.WorkspaceID != "":
+			if m.Workspace.ID == cfg.WorkspaceID {
+				return m, nil
+			}
+		case cfg.WorkspaceName != "":
+			if m.Workspace.Name == cfg.WorkspaceName {
+				return m, nil
+			}
+		default:
+			return m, nil
+		}
+	}
+
+	return mirror.Mirror{}, fmt.Errorf("%w, run `ccws sync` first", mirror.ErrNotMirrored)
+}
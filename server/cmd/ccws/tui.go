@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/Hukyl/CCWS/internal/tui"
+)
+
+func newTUICmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Browse and edit the timesheet interactively",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSession()
+			if err != nil {
+				return err
+			}
+
+			var opts []tui.Option
+			if s.queue != nil {
+				opts = append(opts, tui.WithQueue(s.queue))
+			}
+			return tui.Run(s.client, *s.workspace, *s.user, opts...)
+		},
+	}
+}
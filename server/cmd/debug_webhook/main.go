@@ -4,103 +4,123 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/Hukyl/CCWS/internal/app"
 	"github.com/Hukyl/CCWS/internal/clockify"
 	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/lifecycle"
+	"github.com/Hukyl/CCWS/internal/telemetry"
+	"github.com/Hukyl/CCWS/internal/tunnel"
 )
 
-func makeWebhookHandler(webhookService *clockify.WorkspaceWebhookService) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-
-		slog.Info("webhook_received")
-
-		// Log request headers
-		for name, values := range r.Header {
-			for _, value := range values {
-				slog.Info("request_header", "name", name, "value", value)
-			}
-		}
-
-		// Read the request body
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			slog.Error("error_reading_request_body", "error", err)
-			http.Error(w, "Error reading request body", http.StatusBadRequest)
-			return
-		}
-
-		// Output the full request body as text
-		slog.Info("request_body", "body", string(body))
-
-		// Return a success response
-		w.WriteHeader(http.StatusOK)
-
-		event, obj, err := webhookService.ProcessWebhook(r)
-		if err != nil {
-			slog.Error("error_processing_webhook", "error", err)
-		}
-
-		slog.Info("webhook_processed", "event", event, "obj", obj)
-	}
-}
-
 var (
 	webhookURL    string
 	workspaceName string
+	profileName   string
+	tunnelName    string
 )
 
 func main() {
-	flag.StringVar(&webhookURL, "webhook-url", "http://localhost:8080", "The URL to send the webhook to")
-	flag.StringVar(&workspaceName, "workspace-name", "", "The name of the workspace to delete time entries from")
+	// Parsed before loading, so that the profile's secrets are the ones resolved
+	flag.StringVar(&webhookURL, "webhook-url", "", "The URL to send the webhook to (defaults to PUBLIC_WEBHOOK_URL, then localhost on LISTEN_ADDR)")
+	flag.StringVar(&workspaceName, "workspace-name", "", "The name of the workspace to register the webhook in (defaults to the configured one)")
+	flag.StringVar(&profileName, "profile", "", "The config profile to use, overriding CCWS_PROFILE")
+	flag.StringVar(&tunnelName, "tunnel", "", "Open an ngrok or cloudflared tunnel and send the webhook to its public URL (defaults to TUNNEL)")
 	flag.Parse()
 
+	cfg, err := config.LoadProfile(profileName)
+	if err != nil {
+		slog.Error("failed_to_load_config", "error", err)
+		return
+	}
+	slog.SetDefault(cfg.NewLogger(os.Stderr))
+
+	if webhookURL == "" {
+		webhookURL = cfg.PublicWebhookURL
+	}
+	if webhookURL == "" {
+		webhookURL = "http://localhost" + cfg.ListenAddr
+	}
+
+	if workspaceName == "" {
+		workspaceName = cfg.WorkspaceName
+	}
+
 	if workspaceName == "" {
 		slog.Error("workspace_name_is_required")
 		return
 	}
 
-	cfg, err := config.Load()
+	shutdownTracing, err := telemetry.Setup(context.Background(), "ccws-debug-webhook")
 	if err != nil {
-		slog.Error("failed_to_load_config", "error", err)
+		slog.Error("failed_to_setup_tracing", "error", err)
 		return
 	}
+	defer shutdownTracing(context.Background())
 
-	apiKey := cfg.ClockifyAPIKey
-	client := clockify.NewDefaultClient(apiKey)
-
-	workspace, err := client.FindWorkspaceByName(workspaceName)
+	client, err := app.NewClient(cfg)
 	if err != nil {
-		slog.Error("failed_to_find_workspace", "error", err)
+		slog.Error("failed_to_create_client", "error", err)
 		return
 	}
-	fmt.Println("Found workspace:", workspace)
 
-	webhookService := clockify.NewWorkspaceWebhookService(
-		client,
-		*workspace,
-		webhookURL,
-	)
+	identity, err := client.ValidateCredentials()
+	if err != nil {
+		slog.Error("invalid_credentials", "error", err)
+		return
+	}
 
-	err = webhookService.Create()
+	cfg.WorkspaceID, cfg.WorkspaceName = "", workspaceName
+	workspace, err := app.ResolveWorkspace(cfg, identity)
 	if err != nil {
-		slog.Error("failed_to_create_webhook", "error", err)
+		slog.Error("failed_to_find_workspace", "error", err)
 		return
 	}
+	fmt.Println("Found workspace:", workspace)
+
+	lc := lifecycle.New()
 	defer func() {
-		err = webhookService.Delete()
-		if err != nil {
-			slog.Error("failed_to_delete_webhook", "error", err)
+		if err := lc.Stop(context.Background()); err != nil {
+			slog.Error("failed_to_tear_down", "error", err)
 			return
 		}
 		fmt.Println("Webhook deleted")
 	}()
 
+	if tunnelName != "" {
+		cfg.Tunnel = tunnelName
+		var t *tunnel.Tunnel
+		err = lc.Setup(context.Background(), "tunnel", func(ctx context.Context) (err error) {
+			t, webhookURL, err = app.StartTunnel(ctx, cfg)
+			return err
+		}, func(context.Context) error {
+			return t.Close()
+		})
+		if err != nil {
+			slog.Error("failed_to_open_tunnel", "error", err)
+			return
+		}
+		fmt.Println("Tunnel opened:", t.URL())
+	}
+
+	webhookService, err := app.RegisterWebhooks(context.Background(), lc, cfg, client, *workspace, webhookURL, "debug-webhook",
+		clockify.WithHandlerMiddleware(clockify.LogWebhooks(slog.Default())),
+		clockify.WithReceiver(func(ctx context.Context, event clockify.WebhookEvent, payload any) {
+			slog.Info("webhook_processed", "event", event, "obj", payload)
+		}),
+		// Every delivery is shown, redeliveries included
+		clockify.WithDedupeWindow(0),
+	)
+	if err != nil {
+		slog.Error("failed_to_create_webhook", "error", err)
+		return
+	}
+
 	fmt.Println("Webhook created")
 
 	signals := make(chan os.Signal, 1)
@@ -108,8 +128,10 @@ func main() {
 
 	// Create a http server that will receive the webhook
 	server := http.Server{
-		Addr:    ":8080",
-		Handler: makeWebhookHandler(webhookService),
+		Addr:         cfg.ListenAddr,
+		Handler:      webhookService.Handler(),
+		ReadTimeout:  cfg.ServerReadTimeout,
+		WriteTimeout: cfg.ServerWriteTimeout,
 	}
 
 	go func() {
@@ -120,11 +142,14 @@ func main() {
 		}
 	}()
 
-	fmt.Println("Server started on http://localhost:8080")
+	fmt.Println("Server started on", cfg.ListenAddr)
 
 	<-signals
 
-	err = server.Shutdown(context.Background())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ServerShutdownGrace)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
 	if err != nil {
 		slog.Error("failed_to_shutdown_server", "error", err)
 		return
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/Hukyl/CCWS/internal/api"
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/archive"
+	"github.com/Hukyl/CCWS/internal/audit"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/deadletter"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/graph"
+	"github.com/Hukyl/CCWS/internal/mirror"
+	"github.com/Hukyl/CCWS/internal/rpc"
+)
+
+// setupAPI mounts the HTTP API when API_TOKEN or api_users are set, returning nil otherwise.
+// Webhook events drop the cached data, so the API stays close to Clockify without waiting for
+// API_CACHE_TTL, and are published to the event stream. With a mirror, entries, projects and
+// tags are read from it. With an audit log, it is served at /audit, with dead letters at
+// /deadletters, and with an archiver its restores at /archive/restore.
+func setupAPI(cfg *config.Config, mux *http.ServeMux, registry *events.Registry, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User, store *mirror.Store, auditLog *audit.Log, deadLetters *deadletter.Store, archiver *archive.Archiver) *api.API {
+	if !cfg.APIEnabled() {
+		slog.Info("api_disabled", "reason", "neither API_TOKEN nor api_users are set")
+		return nil
+	}
+
+	users := make([]api.User, 0, len(cfg.APIUsers))
+	for _, user := range cfg.APIUsers {
+		users = append(users, api.User{Name: user.Name, Token: user.Token, Role: api.Role(user.Role)})
+	}
+
+	opts := []api.Option{
+		api.WithToken(cfg.APIToken),
+		api.WithUsers(users...),
+		api.WithCacheTTL(cfg.APICacheTTL),
+		api.WithCapacity(app.CapacityModel(cfg)),
+		api.WithExchangeRates(app.ExchangeRates(cfg)),
+		api.WithRounding(app.Rounding(cfg)),
+		api.WithMaxGap(cfg.TimesheetMaxGap),
+		api.WithMigrationDeadline(cfg.MigrationDeadline),
+		api.WithTimerDefaults(api.TimerDefaults{
+			Project:  cfg.DefaultProject,
+			Task:     cfg.DefaultTask,
+			Tags:     cfg.DefaultTags,
+			Billable: cfg.DefaultBillable,
+		}),
+	}
+	var graphOpts []graph.Option
+	if store != nil {
+		opts = append(opts, api.WithMirror(store))
+		graphOpts = append(graphOpts, graph.WithMirror(store, workspace.ID))
+	}
+
+	if auditLog != nil {
+		opts = append(opts, api.WithAudit(auditLog))
+	}
+	if deadLetters != nil {
+		opts = append(opts, api.WithDeadLetters(deadLetters, registry))
+	}
+	if archiver != nil {
+		opts = append(opts, api.WithArchive(archiver, registry))
+	}
+
+	a := api.New(client, workspace, user, opts...)
+	graphql := graph.NewHandler(client, workspace, user, cfg.APICacheTTL, graphOpts...)
+	a.Handle("/graphql", graphql)
+
+	registry.OnAll("api", func(ctx context.Context, event events.Event) error {
+		a.Invalidate()
+		graphql.Invalidate()
+		return a.Publish(ctx, event)
+	})
+
+	mux.Handle(api.Prefix+"/", a.Handler())
+	slog.Info("api_enabled", "prefix", api.Prefix, "cache_ttl", cfg.APICacheTTL, "users", len(users))
+	return a
+}
+
+// startGRPC serves the gRPC API on addr until the returned stop function is called. Stopping
+// waits for running calls, so event subscriptions must be ended first by closing the API.
+func startGRPC(addr string, a *api.API) (stop func(), err error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+
+	server := rpc.NewServer(a)
+	go func() {
+		slog.Info("grpc_server_started", "addr", listener.Addr().String())
+		if err := server.Serve(listener); err != nil {
+			slog.Error("grpc_server_failed", "error", err)
+		}
+	}()
+
+	return func() {
+		server.GracefulStop()
+		slog.Info("grpc_server_stopped")
+	}, nil
+}
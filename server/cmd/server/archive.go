@@ -0,0 +1,41 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/Hukyl/CCWS/internal/archive"
+	"github.com/Hukyl/CCWS/internal/config"
+)
+
+// setupArchive creates an archiver of the webhook payloads in the bucket of ARCHIVE_URL,
+// flushing them every ARCHIVE_FLUSH_INTERVAL. It returns nil if the archive is disabled.
+func setupArchive(ctx context.Context, cfg *config.Config, workspaceID string) *archive.Archiver {
+	if cfg.ArchiveURL == "" {
+		return nil
+	}
+
+	// Validated by the config
+	u, _ := url.Parse(cfg.ArchiveURL)
+	region, endpoint := cfg.ArchiveRegion, cfg.ArchiveEndpoint
+	if u.Scheme == "gs" {
+		region = cmp.Or(region, "auto")
+		endpoint = cmp.Or(endpoint, archive.GCSEndpoint)
+	} else {
+		region = cmp.Or(region, "us-east-1")
+		endpoint = cmp.Or(endpoint, archive.S3Endpoint(region))
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	bucket := archive.NewBucket(endpoint, region, u.Host, cfg.ArchiveAccessKeyID, cfg.ArchiveSecretAccessKey)
+	archiver := archive.New(bucket, workspaceID, archive.WithPrefix(prefix))
+	go archiver.Run(ctx, cfg.ArchiveFlushInterval)
+	slog.Info("webhook_archive_enabled", "bucket", u.Host, "prefix", prefix, "endpoint", endpoint, "flush_interval", cfg.ArchiveFlushInterval)
+	return archiver
+}
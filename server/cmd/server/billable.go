@@ -0,0 +1,26 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/billable"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// setupBillableRules reclassifies the time entries of events by the configured billable rules
+func setupBillableRules(cfg *config.Config, registry *events.Registry, client *clockify.APIClient, workspace *clockify.Workspace) error {
+	if len(cfg.BillableRules) == 0 {
+		return nil
+	}
+
+	rules, err := app.ResolveBillableRules(client, cfg, workspace.ID)
+	if err != nil {
+		return err
+	}
+	billable.NewReclassifier(client, workspace.ID, rules).Subscribe(registry)
+	slog.Info("billable_rules_enabled", "rules", len(rules))
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/export"
+)
+
+// setupEventExport subscribes a writer exporting the events to the Parquet dataset under
+// EXPORT_DIR. It returns nil if the export is disabled.
+func setupEventExport(ctx context.Context, cfg *config.Config, registry *events.Registry) *export.EventWriter {
+	if cfg.ExportDir == "" {
+		return nil
+	}
+
+	writer := export.NewEventWriter(cfg.ExportDir)
+	registry.OnAll("export", writer.Handle)
+	go writer.Run(ctx, cfg.ExportFlushInterval)
+	slog.Info("event_export_enabled", "dir", cfg.ExportDir, "flush_interval", cfg.ExportFlushInterval)
+	return writer
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/forward"
+)
+
+// setupForwarding subscribes a forwarder for the configured downstream webhooks.
+// It returns nil if there are none.
+func setupForwarding(cfg *config.Config, registry *events.Registry) (*forward.Forwarder, error) {
+	if len(cfg.ForwardTargets) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	targets := make([]forward.Target, 0, len(cfg.ForwardTargets))
+	for _, t := range cfg.ForwardTargets {
+		target := forward.Target{Name: t.Name, URL: t.URL, Secret: t.Secret, ContentType: t.ContentType}
+		for _, event := range t.Events {
+			target.Events = append(target.Events, clockify.WebhookEvent(event))
+		}
+		if t.Template != "" {
+			tmpl, err := forward.ParseTemplate(t.Name, t.Template)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("forward %s: template: %w", t.Name, err))
+				continue
+			}
+			target.Template = tmpl
+		}
+		targets = append(targets, target)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	forwarder := forward.New(targets)
+	registry.OnAll("forward", forwarder.Handle)
+	slog.Info("forwarding_enabled", "targets", len(targets))
+	return forwarder, nil
+}
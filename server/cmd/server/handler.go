@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// webhookGuards rate limits the webhook endpoint and checks its path token, before the
+// signature of the deliveries is checked. With shared state, the rate limits and the
+// redeliveries dropped are those of every replica.
+func webhookGuards(cfg *config.Config, shared clockify.SharedState) []clockify.WebhookServiceOption {
+	var guards []clockify.WebhookMiddleware
+	if cfg.WebhookRateLimit > 0 {
+		// Behind a tunnel every request comes from its local agent
+		trustProxy := cfg.WebhookTrustProxy || cfg.Tunnel != ""
+		if shared != nil {
+			guards = append(guards, clockify.LimitSharedWebhookRate(shared, cfg.WebhookRateLimit, trustProxy))
+		} else {
+			guards = append(guards, clockify.LimitWebhookRate(cfg.WebhookRateLimit, trustProxy))
+		}
+	}
+	if cfg.WebhookPathToken != "" {
+		guards = append(guards, clockify.RequirePathToken(cfg.WebhookPathToken))
+	}
+
+	opts := []clockify.WebhookServiceOption{clockify.WithHandlerMiddleware(guards...)}
+	if shared != nil {
+		opts = append(opts, clockify.WithSharedDedupe(shared))
+	}
+	return opts
+}
+
+// webhookReceiver dispatches the accepted Clockify deliveries to the registry. Handler
+// failures are logged by the registry and do not fail the delivery.
+func webhookReceiver(registry *events.Registry, workspaceID string) clockify.WebhookReceiver {
+	return func(ctx context.Context, event clockify.WebhookEvent, payload any) {
+		registry.Dispatch(ctx, events.Event{
+			Type:        event,
+			Source:      events.SourceWebhook,
+			WorkspaceID: workspaceID,
+			Payload:     payload,
+			ReceivedAt:  time.Now(),
+		})
+	}
+}
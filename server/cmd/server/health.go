@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// webhookCheckTTL is how long the webhook listing is reused between health checks, so
+// frequent probes do not use up the Clockify rate limit
+const webhookCheckTTL = time.Minute
+
+// Health check statuses
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+)
+
+type healthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type webhooksHealth struct {
+	healthCheck
+	// Events whose webhook no longer exists in Clockify
+	Missing []clockify.WebhookEvent `json:"missing,omitempty"`
+}
+
+type lastEventHealth struct {
+	healthCheck
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+	Window     string     `json:"window"`
+}
+
+type healthReport struct {
+	Status    string           `json:"status"`
+	Webhooks  *webhooksHealth  `json:"webhooks,omitempty"` // Nil with EVENT_SOURCE=polling
+	LastEvent *lastEventHealth `json:"last_event,omitempty"`
+}
+
+// healthChecker reports whether the registered webhooks still exist and events keep arriving
+type healthChecker struct {
+	webhookService *clockify.WorkspaceWebhookService // Nil without webhooks
+	lastEvent      func() time.Time
+	// Longest time without events before the server is degraded, 0 skips the check
+	eventWindow time.Duration
+	started     time.Time
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	webhooks  webhooksHealth
+}
+
+// makeHealthHandler serves the health report, with 503 when the server is degraded.
+//
+// Without any event yet, the window is counted from the server start. lastEvent returns when
+// the last event was received, from the webhooks or the polled mirror.
+func makeHealthHandler(webhookService *clockify.WorkspaceWebhookService, lastEvent func() time.Time, eventWindow time.Duration) http.HandlerFunc {
+	h := &healthChecker{webhookService: webhookService, lastEvent: lastEvent, eventWindow: eventWindow, started: time.Now()}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := healthReport{Status: healthOK}
+		if h.webhookService != nil {
+			webhooks := h.checkWebhooks(r)
+			report.Webhooks = &webhooks
+			if webhooks.Status != healthOK {
+				report.Status = healthDegraded
+			}
+		}
+		if h.eventWindow > 0 {
+			report.LastEvent = h.checkLastEvent()
+			if report.LastEvent.Status != healthOK {
+				report.Status = healthDegraded
+			}
+		}
+
+		status := http.StatusOK
+		if report.Status != healthOK {
+			status = http.StatusServiceUnavailable
+			var attrs []any
+			if report.Webhooks != nil {
+				attrs = append(attrs, "webhooks", report.Webhooks.Status, "missing", report.Webhooks.Missing)
+			}
+			if report.LastEvent != nil {
+				attrs = append(attrs, "last_event", report.LastEvent.Status)
+			}
+			slog.Warn("health_degraded", attrs...)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// checkWebhooks lists the workspace webhooks, reusing the previous result for webhookCheckTTL
+func (h *healthChecker) checkWebhooks(r *http.Request) webhooksHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.checkedAt.IsZero() && time.Since(h.checkedAt) < webhookCheckTTL {
+		return h.webhooks
+	}
+
+	result := webhooksHealth{healthCheck: healthCheck{Status: healthOK}}
+	missing, err := h.webhookService.Missing(r.Context())
+	switch {
+	case err != nil:
+		result.Status, result.Error = healthDegraded, err.Error()
+	case len(missing) > 0:
+		result.Status = healthDegraded
+		for _, webhook := range missing {
+			result.Missing = append(result.Missing, webhook.Event)
+		}
+	}
+
+	h.checkedAt, h.webhooks = time.Now(), result
+	return result
+}
+
+func (h *healthChecker) checkLastEvent() *lastEventHealth {
+	result := &lastEventHealth{healthCheck: healthCheck{Status: healthOK}, Window: h.eventWindow.String()}
+
+	since := h.started
+	if last := h.lastEvent(); !last.IsZero() {
+		result.ReceivedAt = &last
+		since = last
+	}
+	if time.Since(since) > h.eventWindow {
+		result.Status, result.Error = healthDegraded, "no event received within the window"
+	}
+	return result
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/issues"
+)
+
+// setupIssueLabelTags tags the time entries of events with the labels of the issues their
+// descriptions reference
+func setupIssueLabelTags(cfg *config.Config, registry *events.Registry, client *clockify.APIClient, workspace *clockify.Workspace) {
+	if !cfg.IssueLabelTags {
+		return
+	}
+	// Validate ensured a tracker is configured
+	issues.NewTagger(client, workspace.ID, app.NewIssueResolvers(cfg)).Subscribe(registry)
+	slog.Info("issue_label_tags_enabled")
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/budget"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/digest"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/notify"
+	"github.com/Hukyl/CCWS/internal/reminder"
+	"github.com/Hukyl/CCWS/internal/scheduler"
+	"github.com/Hukyl/CCWS/internal/timesheet"
+	"github.com/Hukyl/CCWS/internal/watchdog"
+)
+
+// setupJobs adds the configured recurring jobs to the scheduler
+func setupJobs(cfg *config.Config, sched *scheduler.Scheduler, registry *events.Registry, notifiers notifiers, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User) {
+	if cfg.WatchdogThreshold > 0 || cfg.WatchdogStopAfter > 0 {
+		threshold := cfg.WatchdogThreshold
+		if threshold == 0 {
+			// Only stopping is configured, report the timers when they are stopped
+			threshold = cfg.WatchdogStopAfter
+		}
+
+		opts := []watchdog.Option{watchdog.WithStopAfter(cfg.WatchdogStopAfter), watchdog.WithBreakThreshold(cfg.WatchdogBreakThreshold)}
+		if !cfg.WatchdogAllUsers {
+			opts = append(opts, watchdog.WithUsers(user.ID))
+		}
+
+		w := watchdog.New(client, registry, workspace.ID, threshold, opts...)
+		sched.Add("watchdog", jobSchedule(cfg.WatchdogSchedule, scheduler.Every(cfg.WatchdogInterval)), w.Check, jobOptions(cfg)...)
+		slog.Info("watchdog_enabled", "threshold", threshold, "stop_after", cfg.WatchdogStopAfter, "break_threshold", cfg.WatchdogBreakThreshold, "interval", cfg.WatchdogInterval, "schedule", cfg.WatchdogSchedule)
+	}
+
+	if cfg.ReminderDailyQuota > 0 || cfg.ReminderWeeklyQuota > 0 || cfg.ReminderCapacity {
+		setupReminder(cfg, sched, notifiers, client, workspace, user)
+	}
+
+	if cfg.TimesheetSubmit {
+		setupTimesheet(cfg, sched, notifiers, client, workspace, user)
+	}
+
+	if cfg.BudgetEstimates || len(cfg.Budgets) > 0 {
+		setupBudgets(cfg, sched, registry, client, workspace, user)
+	}
+
+	if len(cfg.DigestTo) > 0 {
+		hour, minute, _ := cfg.DigestClock() // validated on load
+		frequency := digest.Frequency(cfg.DigestFrequency)
+
+		schedule := scheduler.Daily(hour, minute)
+		if frequency == digest.Weekly {
+			schedule = scheduler.Weekly(time.Monday, hour, minute)
+		}
+		schedule = jobSchedule(cfg.DigestSchedule, schedule)
+
+		email := notify.NewEmail(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, cfg.DigestTo)
+		sched.Add("digest", schedule, func(ctx context.Context) error {
+			now := time.Now()
+			d, err := digest.Build(client.WithContext(ctx), workspace.ID, user.ID, frequency.Period(now), now)
+			if err != nil {
+				return err
+			}
+			return email.Notify(ctx, d.Message())
+		}, jobOptions(cfg)...)
+		slog.Info("digest_enabled", "frequency", frequency, "time", cfg.DigestTime, "schedule", cfg.DigestSchedule, "recipients", len(cfg.DigestTo))
+	}
+}
+
+// setupBudgets checks project budgets on time entry events and, unless BUDGET_INTERVAL is 0,
+// on a schedule
+func setupBudgets(cfg *config.Config, sched *scheduler.Scheduler, registry *events.Registry, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User) {
+	thresholds, _ := cfg.BudgetPercentages() // validated on load
+
+	caps := make([]budget.Cap, len(cfg.Budgets))
+	for i, b := range cfg.Budgets {
+		caps[i] = budget.Cap{Project: b.Project, Limit: b.Limit, Period: budget.Period(b.Period)}
+	}
+
+	opts := []budget.Option{budget.WithCaps(caps...)}
+	if !cfg.BudgetEstimates {
+		opts = append(opts, budget.WithoutEstimates())
+	}
+	if !cfg.BudgetAllUsers {
+		opts = append(opts, budget.WithUsers(user.ID))
+	}
+
+	monitor := budget.New(client, registry, workspace.ID, thresholds, opts...)
+	monitor.Subscribe(registry)
+	if cfg.BudgetInterval > 0 {
+		sched.Add("budgets", scheduler.Every(cfg.BudgetInterval), monitor.Check, jobOptions(cfg)...)
+	}
+	slog.Info("budgets_enabled", "thresholds", thresholds, "caps", len(caps), "estimates", cfg.BudgetEstimates, "interval", cfg.BudgetInterval)
+}
+
+// jobSchedule returns the cron schedule from the config, or fallback when none is set
+func jobSchedule(expr string, fallback scheduler.Schedule) scheduler.Schedule {
+	if expr == "" {
+		return fallback
+	}
+	schedule, _ := scheduler.ParseCron(expr) // validated on load
+	return schedule
+}
+
+// jobOptions returns the options shared by every job
+func jobOptions(cfg *config.Config) []scheduler.JobOption {
+	var opts []scheduler.JobOption
+	if cfg.SchedulerJitter > 0 {
+		opts = append(opts, scheduler.WithJitter(cfg.SchedulerJitter))
+	}
+	return opts
+}
+
+// reminderInterval is how often the quota reminder checks whose reminder time has passed
+const reminderInterval = 5 * time.Minute
+
+// setupReminder schedules the quota reminder. Users are emailed when SMTP is configured,
+// otherwise reminders go to the shared notification channels.
+func setupReminder(cfg *config.Config, sched *scheduler.Scheduler, notifiers notifiers, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User) {
+	var send reminder.SenderFunc
+	switch {
+	case cfg.SMTPAddr != "" && cfg.SMTPFrom != "":
+		send = func(ctx context.Context, user clockify.User, msg notify.Message) error {
+			if user.Email == "" {
+				return fmt.Errorf("user %s has no email address", user)
+			}
+			return notify.NewEmail(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, []string{user.Email}).Notify(ctx, msg)
+		}
+	case len(notifiers.channels) > 0:
+		send = func(ctx context.Context, _ clockify.User, msg notify.Message) error {
+			return notifiers.channels.Notify(ctx, msg)
+		}
+	default:
+		slog.Warn("reminder_disabled", "reason", "no SMTP server or notification channel configured")
+		return
+	}
+
+	hour, minute, _ := cfg.ReminderClock() // validated on load
+	quota := reminder.Quota{Daily: cfg.ReminderDailyQuota, Weekly: cfg.ReminderWeeklyQuota}
+
+	var opts []reminder.Option
+	if !cfg.ReminderAllUsers {
+		opts = append(opts, reminder.WithUsers(*user))
+	}
+	if cfg.ReminderCapacity {
+		opts = append(opts, reminder.WithCapacity(app.CapacityModel(cfg)))
+	}
+
+	r := reminder.New(client, workspace.ID, quota, hour, minute, send, opts...)
+	sched.Add("reminder", jobSchedule(cfg.ReminderSchedule, scheduler.Every(reminderInterval)), r.Check, jobOptions(cfg)...)
+	slog.Info("reminder_enabled", "daily_quota", quota.Daily, "weekly_quota", quota.Weekly, "capacity", cfg.ReminderCapacity, "time", cfg.ReminderTime, "schedule", cfg.ReminderSchedule)
+}
+
+// setupTimesheet schedules the weekly submission of your timesheet. The outcome is emailed to
+// you when SMTP is configured, otherwise it goes to the shared notification channels.
+func setupTimesheet(cfg *config.Config, sched *scheduler.Scheduler, notifiers notifiers, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User) {
+	var notifier notify.Notifier
+	switch {
+	case cfg.SMTPAddr != "" && cfg.SMTPFrom != "" && user.Email != "":
+		notifier = notify.NewEmail(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, []string{user.Email})
+	case len(notifiers.channels) > 0:
+		notifier = notifiers.channels
+	default:
+		slog.Warn("timesheet_disabled", "reason", "no SMTP server or notification channel configured")
+		return
+	}
+
+	hour, minute, _ := cfg.TimesheetClock() // validated on load
+	schedule := jobSchedule(cfg.TimesheetSchedule, scheduler.Weekly(timesheet.SubmitDay, hour, minute))
+	submitter := timesheet.New(client, workspace.ID, *user, cfg.TimesheetMaxGap, notifier)
+	sched.Add("timesheet", schedule, submitter.Submit, jobOptions(cfg)...)
+	slog.Info("timesheet_enabled", "time", cfg.TimesheetSubmitTime, "schedule", cfg.TimesheetSchedule, "max_gap", cfg.TimesheetMaxGap)
+}
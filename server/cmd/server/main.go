@@ -0,0 +1,240 @@
+// Command server registers Clockify webhooks for the configured workspace, or polls its mirror
+// with EVENT_SOURCE=polling, and dispatches the events to the notification handlers.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/bot"
+	"github.com/Hukyl/CCWS/internal/budget"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/deadletter"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/lifecycle"
+	"github.com/Hukyl/CCWS/internal/scheduler"
+	"github.com/Hukyl/CCWS/internal/telemetry"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error("server_failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(cfg.NewLogger(os.Stderr))
+
+	// Tears down the tunnel and webhooks, the webhooks first
+	lc := lifecycle.New()
+	defer func() {
+		if err := lc.Stop(context.Background()); err != nil {
+			slog.Error("failed_to_tear_down", "error", err)
+		}
+	}()
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), "ccws-server")
+	if err != nil {
+		return fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	auditLog, clientOpts, err := app.OpenAuditLog(cfg, "server")
+	if err != nil {
+		return err
+	}
+	if auditLog != nil {
+		defer auditLog.Close()
+	}
+	// Nil unless replicas share their state, a nil *redis.Client would not be a nil interface
+	var shared clockify.SharedState
+	redisClient, err := app.OpenSharedState(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	if redisClient != nil {
+		defer redisClient.Close()
+		shared = redisClient
+		clientOpts = append(clientOpts, clockify.WithSharedState(shared))
+		slog.Info("shared_state_enabled", "backend", "redis")
+	}
+	client, err := app.NewClient(cfg, clientOpts...)
+	if err != nil {
+		return err
+	}
+
+	// Fail fast with a readable message rather than on the first webhook or API call
+	identity, err := client.ValidateCredentials()
+	if err != nil {
+		return err
+	}
+	user := identity.User
+	workspace, err := app.ResolveWorkspace(cfg, identity)
+	if err != nil {
+		return err
+	}
+	slog.Info("workspace_resolved", "workspace_id", workspace.ID, "workspace_name", workspace.Name)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var registryOpts []events.RegistryOption
+	var deadLetters *deadletter.Store
+	if cfg.DeadLetterDSN != "" {
+		deadLetters, err = deadletter.Open(cfg.DeadLetterDSN, deadletter.WithPayloadType[*budget.Alert](events.BudgetThresholdEvent))
+		if err != nil {
+			return err
+		}
+		defer deadLetters.Close()
+		registryOpts = append(registryOpts, events.WithFailureHook(deadLetters.Record))
+	}
+	registry := events.NewRegistry(registryOpts...)
+	notifiers := setupNotifications(cfg, registry, client, workspace)
+	if notifiers.telegram != nil && cfg.TelegramBotCommands {
+		go bot.NewTelegramBot(notifiers.telegram, client, workspace, user).Run(ctx)
+	}
+
+	if err := setupBillableRules(cfg, registry, client, workspace); err != nil {
+		return err
+	}
+	setupIssueLabelTags(cfg, registry, client, workspace)
+
+	forwarder, err := setupForwarding(cfg, registry)
+	if err != nil {
+		return err
+	}
+	if forwarder != nil {
+		// Let the deliveries in flight finish before exiting
+		defer forwarder.Wait()
+	}
+	publisher, err := setupPublishing(cfg, registry)
+	if err != nil {
+		return err
+	}
+	if publisher != nil {
+		// Let the messages in flight be published before exiting
+		defer publisher.Close()
+	}
+	if exporter := setupEventExport(ctx, cfg, registry); exporter != nil {
+		// Writes the events received since the last flush
+		defer func() {
+			if err := exporter.Close(); err != nil {
+				slog.Error("event_export_failed", "dir", cfg.ExportDir, "error", err)
+			}
+		}()
+	}
+
+	var schedOpts []scheduler.Option
+	if cfg.LeaderElection {
+		schedOpts = append(schedOpts, scheduler.WithLeaderElection(app.LeaderLease(cfg, redisClient)))
+	}
+	sched := scheduler.New(schedOpts...)
+	store, watcher, err := setupMirror(ctx, cfg, sched, registry, client, workspace, user)
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		defer store.Close()
+	}
+
+	mux := http.NewServeMux()
+	var webhookService *clockify.WorkspaceWebhookService
+	archiver := setupArchive(ctx, cfg, workspace.ID)
+	if archiver != nil {
+		// Writes the payloads received since the last flush
+		defer func() {
+			if err := archiver.Close(context.Background()); err != nil {
+				slog.Error("webhook_archive_failed", "url", cfg.ArchiveURL, "error", err)
+			}
+		}()
+	}
+	if watcher == nil {
+		if webhookService, err = setupWebhooks(ctx, lc, cfg, sched, mux, registry, client, workspace, shared, archiver); err != nil {
+			return err
+		}
+	}
+
+	setupJobs(cfg, sched, registry, notifiers, client, workspace, user)
+	if err := setupWarehouse(cfg, sched, client, workspace); err != nil {
+		return err
+	}
+	setupMetrics(cfg, sched, mux, client, workspace, user)
+	if auditLog != nil && cfg.AuditRetention > 0 {
+		sched.Add("audit_prune", scheduler.Every(24*time.Hour), func(context.Context) error {
+			pruned, err := auditLog.Prune(time.Now().Add(-cfg.AuditRetention))
+			slog.Info("audit_pruned", "entries", pruned)
+			return err
+		}, jobOptions(cfg)...)
+	}
+	var lastEvent func() time.Time
+	if watcher != nil {
+		// Catches up on the changes made while the server was down, with every handler registered
+		if err := watcher.Poll(ctx); err != nil {
+			return fmt.Errorf("failed to poll the mirror: %w", err)
+		}
+		lastEvent = watcher.LastEvent
+	} else {
+		lastEvent = webhookService.LastEvent
+	}
+	go sched.Run(ctx)
+
+	mux.Handle("GET /healthz", makeHealthHandler(webhookService, lastEvent, cfg.HealthEventWindow))
+	apiServer := setupAPI(cfg, mux, registry, client, workspace, user, store, auditLog, deadLetters, archiver)
+
+	server := &http.Server{
+		Addr:         cfg.ListenAddr,
+		Handler:      mux,
+		ReadTimeout:  cfg.ServerReadTimeout,
+		WriteTimeout: cfg.ServerWriteTimeout,
+	}
+	if apiServer != nil {
+		server.RegisterOnShutdown(apiServer.Close)
+
+		if cfg.GRPCListenAddr != "" {
+			stopGRPC, err := startGRPC(cfg.GRPCListenAddr, apiServer)
+			if err != nil {
+				return err
+			}
+			// Runs after the HTTP shutdown has closed the event subscriptions
+			defer stopGRPC()
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server_started", "addr", cfg.ListenAddr, "event_source", cfg.EventSource)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ServerShutdownGrace)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown server: %w", err)
+	}
+	slog.Info("server_stopped")
+	return nil
+}
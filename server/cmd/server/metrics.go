@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/metrics"
+	"github.com/Hukyl/CCWS/internal/scheduler"
+)
+
+// setupMetrics serves GET /metrics, refreshing the tracking gauges as a job, unless
+// METRICS_ENABLED is false. With leader election only the leader reports them, so that their
+// sum across replicas stays right.
+func setupMetrics(cfg *config.Config, sched *scheduler.Scheduler, mux *http.ServeMux, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User) {
+	if !cfg.MetricsEnabled {
+		return
+	}
+
+	var opts []metrics.TrackingOption
+	if !cfg.MetricsAllUsers {
+		opts = append(opts, metrics.WithUsers(*user))
+	}
+	tracking := metrics.NewTracking(client, workspace.ID, opts...)
+	sched.Add("metrics_refresh", scheduler.Every(cfg.MetricsRefreshInterval), tracking.Refresh, jobOptions(cfg)...)
+
+	mux.Handle("GET /metrics", metrics.Handler(cfg.MetricsToken, metrics.Process(), tracking))
+	slog.Info("metrics_enabled", "refresh_interval", cfg.MetricsRefreshInterval, "all_users", cfg.MetricsAllUsers, "token", cfg.MetricsToken != "")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/mirror"
+	"github.com/Hukyl/CCWS/internal/scheduler"
+)
+
+// setupMirror opens the mirror when DATABASE_DSN is set, returning nil otherwise. It is synced
+// before returning, then kept current by webhook events and a sync every MIRROR_SYNC_INTERVAL.
+//
+// With EVENT_SOURCE=polling, the returned watcher diffs the mirror on every sync instead, and
+// its first poll is left to the caller, once every handler is registered.
+func setupMirror(ctx context.Context, cfg *config.Config, sched *scheduler.Scheduler, registry *events.Registry, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User) (*mirror.Store, *mirror.PollingWatcher, error) {
+	if cfg.DatabaseDSN == "" {
+		slog.Info("mirror_disabled", "reason", "DATABASE_DSN is not set")
+		return nil, nil, nil
+	}
+
+	store, err := mirror.Open(cfg.DatabaseDSN)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	syncer := mirror.NewSyncer(store, client, *workspace, []string{user.ID}, mirror.WithWindow(cfg.MirrorWindow))
+	if cfg.EventSource == "polling" {
+		// The changes are dispatched after the sync stored them
+		watcher := mirror.NewPollingWatcher(syncer, registry)
+		sched.Add("mirror_poll", scheduler.Every(cfg.MirrorSyncInterval), watcher.Poll, jobOptions(cfg)...)
+		slog.Info("mirror_polling_enabled", "interval", cfg.MirrorSyncInterval, "window", cfg.MirrorWindow)
+		return store, watcher, nil
+	}
+
+	if _, err := syncer.Sync(ctx); err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	registry.On("mirror", syncer.Handle, mirror.Events...)
+	sched.Add("mirror_sync", scheduler.Every(cfg.MirrorSyncInterval), func(ctx context.Context) error {
+		_, err := syncer.Sync(ctx)
+		return err
+	}, jobOptions(cfg)...)
+	slog.Info("mirror_enabled", "interval", cfg.MirrorSyncInterval, "window", cfg.MirrorWindow)
+	return store, nil, nil
+}
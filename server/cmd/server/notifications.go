@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/cache"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/notify"
+)
+
+// projectNameTTL bounds how long a renamed project shows its old name in notifications
+const projectNameTTL = 10 * time.Minute
+
+// notifiers are the configured notification backends
+type notifiers struct {
+	channels notify.Multi     // Shared destinations such as Slack and the Telegram chat
+	telegram *notify.Telegram // Nil unless Telegram is configured, reused by the bot
+}
+
+// setupNotifications subscribes the shared notification channels to the configured events
+func setupNotifications(cfg *config.Config, registry *events.Registry, client *clockify.APIClient, workspace *clockify.Workspace) notifiers {
+	var n notifiers
+	if cfg.SlackWebhookURL != "" {
+		n.channels = append(n.channels, notify.NewSlack(cfg.SlackWebhookURL))
+	}
+	if cfg.TelegramBotToken != "" {
+		n.telegram = notify.NewTelegram(cfg.TelegramBotToken, cfg.TelegramChatID)
+		n.channels = append(n.channels, n.telegram)
+	}
+	if len(n.channels) == 0 {
+		slog.Info("notifications_disabled")
+		return n
+	}
+
+	types := make([]clockify.WebhookEvent, len(cfg.NotifyEvents))
+	for i, name := range cfg.NotifyEvents {
+		types[i] = clockify.WebhookEvent(name)
+	}
+
+	notify.Subscribe(registry, n.channels, types, projectNames(client, workspace.ID))
+	slog.Info("notifications_enabled", "notifiers", len(n.channels), "events", cfg.NotifyEvents)
+	return n
+}
+
+// projectNames looks up project names through a cache, falling back to "" on errors
+func projectNames(client *clockify.APIClient, workspaceID string) notify.ProjectNameFunc {
+	names := cache.New[string, string](projectNameTTL)
+
+	return func(projectID string) string {
+		name, err := names.GetOrLoad(projectID, func() (string, error) {
+			project, err := client.GetProject(workspaceID, projectID)
+			if err != nil {
+				return "", err
+			}
+			return project.Name, nil
+		})
+		if err != nil {
+			slog.Warn("failed_to_get_project_name", "project_id", projectID, "error", err)
+		}
+		return name
+	}
+}
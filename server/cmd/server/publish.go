@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/publish"
+)
+
+// setupPublishing subscribes a publisher to the configured message broker.
+// It returns nil if publishing is disabled.
+func setupPublishing(cfg *config.Config, registry *events.Registry) (*publish.Publisher, error) {
+	if cfg.PublishBackend == "" {
+		return nil, nil
+	}
+
+	broker, err := publish.NewBroker(cfg.PublishBackend, cfg.PublishURL)
+	if err != nil {
+		return nil, fmt.Errorf("publish: %w", err)
+	}
+	types := make([]clockify.WebhookEvent, len(cfg.PublishEvents))
+	for i, name := range cfg.PublishEvents {
+		types[i] = clockify.WebhookEvent(name)
+	}
+
+	publisher := publish.New(broker, cfg.PublishTopic, publish.WithEncoding(cfg.PublishEncoding), publish.WithEvents(types...))
+	registry.OnAll("publish", publisher.Handle)
+	slog.Info("publishing_enabled", "backend", cfg.PublishBackend, "topic", cfg.PublishTopic, "encoding", cfg.PublishEncoding)
+	return publisher, nil
+}
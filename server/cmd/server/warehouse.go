@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/scheduler"
+	"github.com/Hukyl/CCWS/internal/warehouse"
+)
+
+// setupWarehouse schedules the sync of the workspace to BigQuery, unless BIGQUERY_PROJECT is empty
+func setupWarehouse(cfg *config.Config, sched *scheduler.Scheduler, client *clockify.APIClient, workspace *clockify.Workspace) error {
+	if cfg.BigQueryProject == "" {
+		return nil
+	}
+
+	bq, err := warehouse.NewBigQuery(cfg.BigQueryProject, cfg.BigQueryDataset, cfg.BigQueryCredentials)
+	if err != nil {
+		return fmt.Errorf("bigquery: %w", err)
+	}
+	syncer := warehouse.NewSyncer(bq, client, workspace.ID, warehouse.WithWindow(cfg.BigQueryWindow))
+	sched.Add("warehouse_sync", scheduler.Every(cfg.BigQueryInterval), syncer.Sync, jobOptions(cfg)...)
+	slog.Info("warehouse_sync_enabled", "project", cfg.BigQueryProject, "dataset", cfg.BigQueryDataset, "interval", cfg.BigQueryInterval, "window", cfg.BigQueryWindow)
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/Hukyl/CCWS/internal/app"
+	"github.com/Hukyl/CCWS/internal/archive"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/lifecycle"
+	"github.com/Hukyl/CCWS/internal/scheduler"
+	"github.com/Hukyl/CCWS/internal/tunnel"
+)
+
+// setupWebhooks opens the tunnel if configured, registers the webhooks with Clockify as stages
+// of lc and serves them on mux. The events over Clockify's webhook limit are polled for with
+// WEBHOOK_POLL_INTERVAL, and the tokens rotated with WEBHOOK_TOKEN_ROTATION. Redeliveries and
+// rate limits are shared with the other replicas through shared, unless it is nil. The raw
+// payloads are passed to the archiver, unless it is nil.
+func setupWebhooks(ctx context.Context, lc *lifecycle.Manager, cfg *config.Config, sched *scheduler.Scheduler, mux *http.ServeMux, registry *events.Registry, client *clockify.APIClient, workspace *clockify.Workspace, shared clockify.SharedState, archiver *archive.Archiver) (*clockify.WorkspaceWebhookService, error) {
+	publicURL := cfg.PublicWebhookURL
+	if cfg.Tunnel != "" {
+		var t *tunnel.Tunnel
+		err := lc.Setup(context.Background(), "tunnel", func(ctx context.Context) (err error) {
+			t, publicURL, err = app.StartTunnel(ctx, cfg)
+			return err
+		}, func(context.Context) error {
+			return t.Close()
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if publicURL == "" {
+		return nil, errors.New("PUBLIC_WEBHOOK_URL or TUNNEL is required to receive webhooks, or EVENT_SOURCE=polling")
+	}
+	webhookPath, err := webhookPath(publicURL)
+	if err != nil {
+		return nil, err
+	}
+	// Clockify is given the URL with the path token, the handler matches any last segment and
+	// checks it itself, so that requests with a wrong one are rate limited too
+	webhookURL := publicURL
+	if cfg.WebhookPathToken != "" {
+		if webhookURL, err = appendPath(publicURL, cfg.WebhookPathToken); err != nil {
+			return nil, err
+		}
+		webhookPath = path.Join(webhookPath, "{token}")
+	}
+
+	webhookOpts := append(webhookGuards(cfg, shared), clockify.WithReceiver(webhookReceiver(registry, workspace.ID)))
+	if archiver != nil {
+		webhookOpts = append(webhookOpts, clockify.WithBodyReceiver(archiver.Receive))
+	}
+	if cfg.WebhookPollInterval > 0 {
+		webhookOpts = append(webhookOpts, clockify.WithPollingFallback())
+	}
+	webhookService, err := app.RegisterWebhooks(context.Background(), lc, cfg, client, *workspace, webhookURL, "server", webhookOpts...)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("webhooks_registered", "url", publicURL, "path", webhookPath, "trigger", cfg.WebhookTrigger, "trigger_ids", cfg.WebhookTriggerIDs)
+
+	if unsubscribed := webhookService.Unsubscribed(); len(unsubscribed) > 0 {
+		// The events over Clockify's webhook limit are diffed through the API instead, against
+		// the state recorded by the first poll
+		if err := webhookService.Poll(ctx); err != nil {
+			slog.Warn("webhook_poll_failed", "error", err)
+		}
+		sched.Add("webhook_poll", scheduler.Every(cfg.WebhookPollInterval), webhookService.Poll, jobOptions(cfg)...)
+		slog.Info("webhook_polling_enabled", "events", unsubscribed, "interval", cfg.WebhookPollInterval)
+	}
+	if cfg.WebhookTokenRotation > 0 {
+		sched.Add("webhook_token_rotation", scheduler.Every(cfg.WebhookTokenRotation), func(context.Context) error {
+			return webhookService.RotateTokens()
+		}, jobOptions(cfg)...)
+		slog.Info("webhook_token_rotation_enabled", "interval", cfg.WebhookTokenRotation, "grace", cfg.WebhookTokenGrace)
+	}
+
+	// Methods are checked by the handler, answering Clockify like any other rejected delivery
+	mux.Handle(webhookPath, webhookService.Handler())
+	return webhookService, nil
+}
+
+// webhookPath returns the path of the public webhook URL, which the server listens on
+func webhookPath(publicURL string) (string, error) {
+	u, err := url.Parse(publicURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid PUBLIC_WEBHOOK_URL: %w", err)
+	}
+	if u.Path == "" {
+		return "/", nil
+	}
+	return u.Path, nil
+}
+
+// appendPath adds a segment to the path of the URL
+func appendPath(raw, segment string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid PUBLIC_WEBHOOK_URL: %w", err)
+	}
+	u.Path = path.Join("/", u.Path, segment)
+	return u.String(), nil
+}
@@ -0,0 +1,256 @@
+// Package analytics computes team utilization, project profitability and weekly trends
+// from the tracked time of a workspace.
+package analytics
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/capacity"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/money"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// DefaultWeeklyCapacity is the time a user is expected to be available in a week
+const DefaultWeeklyCapacity = 40 * time.Hour
+
+// Utilization is the time a user tracked against their capacity
+type Utilization struct {
+	UserID   string
+	User     string
+	Tracked  time.Duration
+	Billable time.Duration
+	Capacity time.Duration
+}
+
+// Rate is the billable share of the capacity, the usual utilization metric
+func (u Utilization) Rate() float64 {
+	return ratio(u.Billable, u.Capacity)
+}
+
+// TrackedRate is the tracked share of the capacity
+func (u Utilization) TrackedRate() float64 {
+	return ratio(u.Tracked, u.Capacity)
+}
+
+// Profitability is the revenue of a project's billable time compared to its budget
+type Profitability struct {
+	ProjectID string
+	Project   string
+	Tracked   time.Duration
+	Billable  time.Duration
+	Revenue   money.Money // Billable hours × hourly rate, without a currency when no rate is set
+	Budget    money.Money // Zero when the project has no active budget
+}
+
+// BudgetUsed is the share of the budget the revenue in the period amounts to, 0 without a budget
+func (p Profitability) BudgetUsed() float64 {
+	if p.Budget.Amount <= 0 {
+		return 0
+	}
+	return p.Revenue.Ratio(p.Budget)
+}
+
+// Week is the team total of a single week, oldest first in Report.Trend
+type Week struct {
+	Period   report.Period
+	Tracked  time.Duration
+	Billable time.Duration
+	Capacity time.Duration
+}
+
+// Rate is the billable share of the week's capacity
+func (w Week) Rate() float64 {
+	return ratio(w.Billable, w.Capacity)
+}
+
+// Report holds the analytics of a period of whole weeks
+type Report struct {
+	Period   report.Period
+	Users    []Utilization   // Highest utilization first
+	Projects []Profitability // Highest revenue first, then most tracked
+	Trend    []Week
+}
+
+// Options tune the computation
+type Options struct {
+	// Expected availability of each user per week, spread over the weekdays. Its default
+	// defaults to DefaultWeeklyCapacity.
+	Capacity capacity.Model
+	// Currency of the revenue and budgets, usually the workspace's. Rates in other currencies
+	// are converted by Rates. Empty keeps the currency of the rates.
+	Currency string
+	Rates    *money.Rates
+}
+
+// Build fetches the time entries of the users in the weeks ending with the current one and
+// computes the report. Capacity only counts weekdays up to today, so the current week is not
+// penalized for the days still ahead.
+func Build(client *clockify.APIClient, workspaceID string, users []clockify.User, weeks int, opts Options, now time.Time) (*Report, error) {
+	if weeks < 1 {
+		return nil, fmt.Errorf("weeks must be at least 1, got %d", weeks)
+	}
+	period := report.Period{Start: report.Week(now, 1-weeks).Start, End: report.Week(now, 0).End}
+
+	projects, err := projectsByID(client, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+
+	entries := make(map[string][]clockify.TimeEntry, len(users))
+	for _, user := range users {
+		userEntries, err := report.FetchEntries(client, workspaceID, user.ID, period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch time entries of %s: %w", user, err)
+		}
+		entries[user.ID] = userEntries
+	}
+
+	return Compute(period, users, entries, projects, opts, now)
+}
+
+// Compute builds the report from already fetched entries, keyed by user ID. It fails when a
+// rate cannot be converted into the currency.
+func Compute(period report.Period, users []clockify.User, entries map[string][]clockify.TimeEntry, projects map[string]clockify.Project, opts Options, now time.Time) (*Report, error) {
+	model := opts.Capacity
+	if model.Default <= 0 {
+		model.Default = DefaultWeeklyCapacity
+	}
+
+	r := &Report{Period: period}
+	for start := period.Start; start.Before(period.End); start = start.AddDate(0, 0, 7) {
+		week := Week{Period: report.Period{Start: start, End: start.AddDate(0, 0, 7)}}
+		for _, user := range users {
+			week.Capacity += model.For(user, week.Period, now)
+		}
+		r.Trend = append(r.Trend, week)
+	}
+
+	profit := make(map[string]*Profitability)
+	for _, user := range users {
+		u := Utilization{UserID: user.ID, User: user.Name, Capacity: model.For(user, period, now)}
+
+		for _, entry := range entries[user.ID] {
+			// Breaks are not work, they use up no capacity
+			if entry.TimeInterval == nil || entry.IsBreak() || !period.Contains(entry.TimeInterval.Start) {
+				continue
+			}
+			duration := report.EntryDuration(entry, now)
+
+			u.Tracked += duration
+			if entry.Billable {
+				u.Billable += duration
+			}
+
+			if week := weekOf(r.Trend, entry.TimeInterval.Start); week != nil {
+				week.Tracked += duration
+				if entry.Billable {
+					week.Billable += duration
+				}
+			}
+
+			if err := addProfit(profit, entry, projects, duration, opts); err != nil {
+				return nil, err
+			}
+		}
+
+		r.Users = append(r.Users, u)
+	}
+
+	for _, p := range profit {
+		r.Projects = append(r.Projects, *p)
+	}
+
+	slices.SortFunc(r.Users, func(a, b Utilization) int {
+		return cmp.Or(cmp.Compare(b.Rate(), a.Rate()), cmp.Compare(a.User, b.User))
+	})
+	slices.SortFunc(r.Projects, func(a, b Profitability) int {
+		return cmp.Or(b.Revenue.Compare(a.Revenue), cmp.Compare(b.Tracked, a.Tracked), cmp.Compare(a.Project, b.Project))
+	})
+	return r, nil
+}
+
+func addProfit(profit map[string]*Profitability, entry clockify.TimeEntry, projects map[string]clockify.Project, duration time.Duration, opts Options) error {
+	p, ok := profit[entry.ProjectID]
+	if !ok {
+		project := projects[entry.ProjectID]
+		p = &Profitability{ProjectID: entry.ProjectID, Project: project.Name}
+		if entry.ProjectID == "" {
+			p.Project = report.NoProject
+		} else if p.Project == "" {
+			p.Project = entry.ProjectID
+		}
+		if budget := project.BudgetEstimate; budget != nil && budget.Active {
+			p.Budget = money.New(budget.Estimate, opts.Currency)
+		}
+		profit[entry.ProjectID] = p
+	}
+
+	p.Tracked += duration
+	if !entry.Billable {
+		return nil
+	}
+	p.Billable += duration
+
+	rate := entry.HourlyRate
+	if rate == nil {
+		rate = projects[entry.ProjectID].HourlyRate
+	}
+	if rate == nil || rate.Amount <= 0 {
+		return nil
+	}
+
+	hourly, err := opts.Rates.Convert(money.New(rate.Amount, rate.Currency), opts.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to convert the rate of %s: %w", p.Project, err)
+	}
+	if p.Revenue, err = p.Revenue.Add(hourly.Over(duration)); err != nil {
+		return fmt.Errorf("failed to add up the revenue of %s: %w", p.Project, err)
+	}
+	return nil
+}
+
+// weekOf finds the week containing t. Weeks are not always 7×24h apart across DST changes.
+func weekOf(trend []Week, t time.Time) *Week {
+	for i := range trend {
+		if trend[i].Period.Contains(t) {
+			return &trend[i]
+		}
+	}
+	return nil
+}
+
+// Users lists every user of the workspace, for team-wide reports
+func Users(client *clockify.APIClient, workspaceID string) ([]clockify.User, error) {
+	var all []clockify.User
+	for users, err := range client.IterWorkspaceUsers(workspaceID) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch workspace users: %w", err)
+		}
+		all = append(all, users...)
+	}
+	return all, nil
+}
+
+func projectsByID(client *clockify.APIClient, workspaceID string) (map[string]clockify.Project, error) {
+	projects := make(map[string]clockify.Project)
+	for page, err := range client.IterProjects(workspaceID) {
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page {
+			projects[p.ID] = p
+		}
+	}
+	return projects, nil
+}
+
+func ratio(part, whole time.Duration) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
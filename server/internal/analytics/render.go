@@ -0,0 +1,152 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Render writes the report in the given format
+func Render(w io.Writer, r *Report, format report.Format) error {
+	switch format {
+	case report.FormatTable:
+		return WriteTable(w, r)
+	case report.FormatCSV:
+		return WriteCSV(w, r)
+	case report.FormatJSON:
+		return WriteJSON(w, r)
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv or json", format)
+	}
+}
+
+// WriteTable writes the utilization, profitability and trend as ASCII tables
+func WriteTable(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Period: %s\t\n\n", r.Period)
+
+	fmt.Fprintln(tw, "USER\tTRACKED\tBILLABLE\tCAPACITY\tUTILIZATION\t")
+	for _, u := range r.Users {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", u.User, report.FormatDuration(u.Tracked), report.FormatDuration(u.Billable), report.FormatDuration(u.Capacity), percent(u.Rate()))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "PROJECT\tTRACKED\tBILLABLE\tREVENUE\tBUDGET\tUSED\t")
+	for _, p := range r.Projects {
+		budget, used := "-", "-"
+		if p.Budget.Amount > 0 {
+			budget, used = p.Budget.String(), percent(p.BudgetUsed())
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", p.Project, report.FormatDuration(p.Tracked), report.FormatDuration(p.Billable), p.Revenue.String(), budget, used)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "WEEK\tTRACKED\tBILLABLE\tCAPACITY\tUTILIZATION\t")
+	for _, week := range r.Trend {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", week.Period.Start.Format("2006-01-02"), report.FormatDuration(week.Tracked), report.FormatDuration(week.Billable), report.FormatDuration(week.Capacity), percent(week.Rate()))
+	}
+
+	return tw.Flush()
+}
+
+// WriteCSV writes the weekly trend, one row per week
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"week", "tracked_hours", "billable_hours", "capacity_hours", "utilization"})
+	for _, week := range r.Trend {
+		cw.Write([]string{
+			week.Period.Start.Format("2006-01-02"),
+			strconv.FormatFloat(report.Hours(week.Tracked), 'f', 2, 64),
+			strconv.FormatFloat(report.Hours(week.Billable), 'f', 2, 64),
+			strconv.FormatFloat(report.Hours(week.Capacity), 'f', 2, 64),
+			strconv.FormatFloat(week.Rate(), 'f', 4, 64),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonUser struct {
+	UserID        string  `json:"userId"`
+	User          string  `json:"user"`
+	Hours         float64 `json:"hours"`
+	BillableHours float64 `json:"billableHours"`
+	CapacityHours float64 `json:"capacityHours"`
+	Utilization   float64 `json:"utilization"`
+}
+
+type jsonProject struct {
+	ProjectID     string  `json:"projectId,omitempty"`
+	Project       string  `json:"project"`
+	Hours         float64 `json:"hours"`
+	BillableHours float64 `json:"billableHours"`
+	Revenue       int64   `json:"revenue"`
+	Currency      string  `json:"currency,omitempty"`
+	Budget        int64   `json:"budget,omitempty"`
+	BudgetUsed    float64 `json:"budgetUsed,omitempty"`
+}
+
+type jsonWeek struct {
+	Week          string  `json:"week"`
+	Hours         float64 `json:"hours"`
+	BillableHours float64 `json:"billableHours"`
+	CapacityHours float64 `json:"capacityHours"`
+	Utilization   float64 `json:"utilization"`
+}
+
+type jsonReport struct {
+	Start    string        `json:"start"`
+	End      string        `json:"end"`
+	Users    []jsonUser    `json:"users"`
+	Projects []jsonProject `json:"projects"`
+	Trend    []jsonWeek    `json:"trend"`
+}
+
+// JSONDocument returns an empty value of the document WriteJSON writes, e.g. to describe the
+// report in a schema
+func JSONDocument() any {
+	return jsonReport{}
+}
+
+// WriteJSON writes the report as JSON with durations in hours and money in the smallest currency unit
+func WriteJSON(w io.Writer, r *Report) error {
+	out := jsonReport{
+		Start:    r.Period.Start.Format(time.RFC3339),
+		End:      r.Period.End.Format(time.RFC3339),
+		Users:    make([]jsonUser, 0, len(r.Users)),
+		Projects: make([]jsonProject, 0, len(r.Projects)),
+		Trend:    make([]jsonWeek, 0, len(r.Trend)),
+	}
+
+	for _, u := range r.Users {
+		out.Users = append(out.Users, jsonUser{u.UserID, u.User, report.Hours(u.Tracked), report.Hours(u.Billable), report.Hours(u.Capacity), round(u.Rate())})
+	}
+	for _, p := range r.Projects {
+		out.Projects = append(out.Projects, jsonProject{p.ProjectID, p.Project, report.Hours(p.Tracked), report.Hours(p.Billable), p.Revenue.Amount, p.Revenue.Currency, p.Budget.Amount, round(p.BudgetUsed())})
+	}
+	for _, week := range r.Trend {
+		out.Trend = append(out.Trend, jsonWeek{week.Period.Start.Format("2006-01-02"), report.Hours(week.Tracked), report.Hours(week.Billable), report.Hours(week.Capacity), round(week.Rate())})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// round keeps 4 decimals of a ratio, enough for a percentage with 2 decimals
+func round(f float64) float64 {
+	return float64(int64(f*10000+0.5)) / 10000
+}
+
+func percent(f float64) string {
+	return strconv.FormatFloat(f*100, 'f', 0, 64) + "%"
+}
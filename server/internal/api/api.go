@@ -0,0 +1,269 @@
+// Package api serves the CCWS HTTP API: report summaries, time entries and timer control for
+// the configured user, team analytics, a live event stream, workspace migrations, the approval
+// requests of the workspace, the audit log, the dead letters of failed event handlers and
+// restores of the webhook archive. Clockify data is cached so dashboards and scripts do not
+// hit the Clockify rate limits. An OpenAPI document describes the routes, to generate clients
+// from.
+//
+// Users are viewers, reading everything, or operators, also changing data in Clockify.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/analytics"
+	"github.com/Hukyl/CCWS/internal/archive"
+	"github.com/Hukyl/CCWS/internal/audit"
+	"github.com/Hukyl/CCWS/internal/cache"
+	"github.com/Hukyl/CCWS/internal/capacity"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/deadletter"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/mirror"
+	"github.com/Hukyl/CCWS/internal/money"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Prefix is the path all API routes are served under
+const Prefix = "/api/v1"
+
+// DefaultCacheTTL is how long Clockify responses are reused unless WithCacheTTL is given
+const DefaultCacheTTL = time.Minute
+
+// Option configures an API
+type Option func(*API)
+
+// WithToken requires requests to carry "Authorization: Bearer <token>". Browsers' EventSource
+// cannot set headers, so the token is also accepted as the access_token query parameter.
+// The token is an operator's, other users are added by WithUsers.
+func WithToken(token string) Option {
+	return func(a *API) {
+		if token != "" {
+			a.users = append(a.users, User{Token: token, Role: RoleOperator})
+		}
+	}
+}
+
+// WithCacheTTL sets how long entries and project names are cached
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(a *API) {
+		a.ttl = ttl
+	}
+}
+
+// WithCapacity sets the weekly availability of users the analytics measure utilization
+// against and approvals are flagged by
+func WithCapacity(model capacity.Model) Option {
+	return func(a *API) {
+		a.capacity = model
+	}
+}
+
+// WithRounding rounds the tracked time of summaries, e.g. every entry up to 15 minutes
+func WithRounding(rounding report.Rounding) Option {
+	return func(a *API) {
+		a.rounding = rounding
+	}
+}
+
+// WithExchangeRates converts the revenue of rates in other currencies into the workspace's
+func WithExchangeRates(provider money.Provider) Option {
+	return func(a *API) {
+		a.exchange = provider
+	}
+}
+
+// WithMigrationDeadline stops the migrations still running after d, 0 lets them run
+func WithMigrationDeadline(d time.Duration) Option {
+	return func(a *API) {
+		a.migrations.deadline = d
+	}
+}
+
+// TimerDefaults are what timers started without a project, task and tags track. Projects,
+// tasks and tags are given by name.
+type TimerDefaults struct {
+	Project  string
+	Task     string
+	Tags     []string
+	Billable bool
+}
+
+// WithTimerDefaults sets what StartTimer tracks when the request names nothing to track.
+// Without it, such timers are billable and track nothing.
+func WithTimerDefaults(defaults TimerDefaults) Option {
+	return func(a *API) {
+		a.defaults = defaults
+	}
+}
+
+// WithMirror reads the user's entries and the project names from the mirror instead of
+// Clockify. The mirror must be synced and kept current, e.g. by a mirror.Syncer.
+func WithMirror(store *mirror.Store) Option {
+	return func(a *API) {
+		a.mirror = store
+	}
+}
+
+// WithAudit serves the audit log of the Clockify writes at /audit
+func WithAudit(log *audit.Log) Option {
+	return func(a *API) {
+		a.audit = log
+	}
+}
+
+// WithDeadLetters serves the events the handlers of registry failed on at /deadletters, and
+// lets operators retry them
+func WithDeadLetters(store *deadletter.Store, registry *events.Registry) Option {
+	return func(a *API) {
+		a.deadLetters, a.registry = store, registry
+	}
+}
+
+// WithArchive lets operators restore the webhook payloads of the archive through the handlers
+// of registry at /archive/restore
+func WithArchive(archiver *archive.Archiver, registry *events.Registry) Option {
+	return func(a *API) {
+		a.archive, a.registry = archiver, registry
+	}
+}
+
+// API fronts the Clockify client for a single workspace and user
+type API struct {
+	client    *clockify.APIClient
+	workspace *clockify.Workspace
+	user      *clockify.User
+	users     []User
+	ttl       time.Duration
+	capacity  capacity.Model
+	exchange  money.Provider
+	rounding  report.Rounding
+	maxGap    time.Duration
+	defaults  TimerDefaults
+	mirror    *mirror.Store
+	audit     *audit.Log
+
+	deadLetters *deadletter.Store
+	archive     *archive.Archiver
+	registry    *events.Registry
+
+	entries  *cache.Cache[report.Period, []clockify.TimeEntry]
+	projects *cache.Cache[string, map[string]string]
+	reports  *cache.Cache[analyticsKey, *analytics.Report]
+	stream   *stream
+
+	migrations migrations
+
+	// Routes mounted by other packages, e.g. GraphQL
+	extra map[string]http.Handler
+}
+
+func New(client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User, opts ...Option) *API {
+	a := &API{client: client, workspace: workspace, user: user, ttl: DefaultCacheTTL, defaults: TimerDefaults{Billable: true}, stream: newStream()}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.entries = cache.New[report.Period, []clockify.TimeEntry](a.ttl)
+	a.projects = cache.New[string, map[string]string](a.ttl)
+	a.reports = cache.New[analyticsKey, *analytics.Report](a.ttl)
+	return a
+}
+
+// Handle mounts an additional route under Prefix, behind the same authentication. Every
+// role may use it, the handler checks UserFrom itself if needed. It must be called before
+// Handler.
+func (a *API) Handle(path string, handler http.Handler) {
+	if a.extra == nil {
+		a.extra = make(map[string]http.Handler)
+	}
+	a.extra[Prefix+path] = handler
+}
+
+// Handler returns the API routes, mounted under Prefix. The requests are validated against
+// the OpenAPI document, which is served at OpenAPIPath.
+func (a *API) Handler() http.Handler {
+	doc := a.OpenAPI()
+
+	mux := http.NewServeMux()
+	for _, route := range a.routes() {
+		mux.HandleFunc(route.method+" "+Prefix+route.path, validated(doc, route))
+	}
+	for pattern, handler := range a.extra {
+		mux.Handle(pattern, handler)
+	}
+	mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	})
+
+	public := http.NewServeMux()
+	public.HandleFunc("GET "+Prefix+OpenAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, doc)
+	})
+	public.Handle("/", a.authenticate(mux))
+	return public
+}
+
+// Invalidate drops the cached Clockify data, e.g. when a webhook reports a change
+func (a *API) Invalidate() {
+	a.entries.Clear()
+	a.projects.Clear()
+	a.reports.Clear()
+}
+
+// authenticate passes the requests on with their user, the Clockify writes they make are
+// audited as made through the API by the user
+func (a *API) authenticate(next http.Handler) http.Handler {
+	if a.Anonymous() {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), "api")))
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("access_token")
+		}
+		user, ok := a.Authenticate(token)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ccws"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid API token")
+			return
+		}
+
+		ctx := audit.WithActor(WithUser(r.Context(), user), user.Actor("api"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// errorResponse is the body of every failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("api_response_failed", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// writeClockifyError reports a failed Clockify call, hiding its details from the client
+func writeClockifyError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.Error("api_clockify_failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	if errors.Is(err, clockify.ErrCircuitOpen) {
+		writeError(w, http.StatusServiceUnavailable, "Clockify is unavailable, try again later")
+		return
+	}
+	writeError(w, http.StatusBadGateway, "Clockify request failed")
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/analytics"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+	"github.com/Hukyl/CCWS/internal/timesheet"
+)
+
+// Anomaly flags of an approval besides the kinds of timesheet issues
+const (
+	FlagOverCapacity  = "over_capacity"
+	FlagUnderCapacity = "under_capacity"
+)
+
+// ErrNoApproval is returned for approval requests that do not exist
+var ErrNoApproval = errors.New("no such approval request")
+
+// WithMaxGap flags the approvals whose entries leave gaps longer than maxGap within a day.
+// Without it, gaps are not flagged.
+func WithMaxGap(maxGap time.Duration) Option {
+	return func(a *API) {
+		a.maxGap = maxGap
+	}
+}
+
+// Approval is an approval request with the time its owner tracked over its period and what
+// a manager should look at before approving it
+type Approval struct {
+	Request       clockify.ApprovalRequest `json:"request"`
+	Hours         float64                  `json:"hours"`
+	BillableHours float64                  `json:"billableHours"`
+	CapacityHours float64                  `json:"capacityHours,omitempty"`
+	// Kinds of timesheet issues and capacity flags, e.g. ["gap", "over_capacity"]
+	Flags  []string `json:"flags"`
+	Issues []string `json:"issues"`
+}
+
+// approvalStates are the states Approvals filters on by the names taken by the API
+var approvalStates = map[string]string{
+	"pending":   clockify.ApprovalPending,
+	"approved":  clockify.ApprovalApproved,
+	"rejected":  clockify.ApprovalRejected,
+	"withdrawn": clockify.ApprovalWithdrawn,
+	"all":       "",
+}
+
+// Approvals returns the workspace approval requests in the state, pending when empty, along
+// with the totals and anomalies of their owners' entries. Periods over or under the capacity
+// of their owner are flagged when one is set. It is never cached, and requires the Clockify
+// user to manage the approvals.
+func (a *API) Approvals(ctx context.Context, state string) ([]Approval, error) {
+	clockifyState, ok := approvalStates[cmp.Or(state, "pending")]
+	if !ok {
+		return nil, InvalidRequestf("status: must be pending, approved, rejected, withdrawn or all, got %q", state)
+	}
+
+	client := a.client.WithContext(ctx)
+	var requests []clockify.ApprovalRequest
+	for page, err := range client.IterApprovalRequests(a.workspace.ID, clockifyState) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list approval requests: %w", err)
+		}
+		requests = append(requests, page...)
+	}
+
+	approvals := make([]Approval, 0, len(requests))
+	if len(requests) == 0 {
+		return approvals, nil
+	}
+
+	// The owners are looked up for their email, capacities may be set by it
+	users, err := analytics.Users(client, a.workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	model, err := a.capacity.Resolve(client, a.workspace.ID, users)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, request := range requests {
+		owner := clockify.User{ID: request.Owner.UserID, Name: request.Owner.UserName}
+		if i := slices.IndexFunc(users, func(u clockify.User) bool { return u.ID == owner.ID }); i >= 0 {
+			owner = users[i]
+		}
+
+		approval, err := a.approval(client, request, model.For(owner, approvalPeriod(request), time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, nil
+}
+
+// approval totals and checks the entries of the owner of the request over its period, the
+// owner being expected to log the capacity
+func (a *API) approval(client *clockify.APIClient, request clockify.ApprovalRequest, expected time.Duration) (Approval, error) {
+	period := approvalPeriod(request)
+	entries, err := report.FetchEntries(client, a.workspace.ID, request.Owner.UserID, period)
+	if err != nil {
+		return Approval{}, fmt.Errorf("failed to list time entries of %s: %w", request.Owner.UserID, err)
+	}
+
+	summary := report.Summarize(period, entries, nil, time.Now())
+	approval := Approval{
+		Request:       request,
+		Hours:         report.Hours(summary.Total),
+		BillableHours: report.Hours(summary.Billable),
+		Flags:         []string{},
+		Issues:        []string{},
+	}
+
+	for _, issue := range timesheet.Validate(entries, a.maxGap, request.Owner.Location(time.Local)) {
+		if !slices.Contains(approval.Flags, issue.Kind) {
+			approval.Flags = append(approval.Flags, issue.Kind)
+		}
+		approval.Issues = append(approval.Issues, issue.String())
+	}
+
+	if expected > 0 {
+		approval.CapacityHours = report.Hours(expected)
+		switch {
+		case summary.Total > expected:
+			approval.Flags = append(approval.Flags, FlagOverCapacity)
+		case summary.Total < expected:
+			approval.Flags = append(approval.Flags, FlagUnderCapacity)
+		}
+	}
+	return approval, nil
+}
+
+// approvalPeriod returns the period of the request, the end of its range being the last
+// instant of the period
+func approvalPeriod(request clockify.ApprovalRequest) report.Period {
+	return report.Period{Start: request.DateRange.Start, End: request.DateRange.End.Add(time.Millisecond)}
+}
+
+// ReviewApproval approves or rejects a pending approval request, with an optional note for
+// its owner
+func (a *API) ReviewApproval(ctx context.Context, id string, approve bool, note string) (*clockify.ApprovalRequest, error) {
+	if err := a.requireOperator(ctx); err != nil {
+		return nil, err
+	}
+
+	state := clockify.ApprovalRejected
+	if approve {
+		state = clockify.ApprovalApproved
+	}
+	request, err := a.client.WithContext(ctx).UpdateApprovalRequest(a.workspace.ID, id, state, note)
+
+	var apiErr *clockify.APIError
+	switch {
+	case errors.Is(err, clockify.ErrNotFound):
+		return nil, ErrNoApproval
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest:
+		// e.g. the request was already approved
+		return nil, InvalidRequestf("%s", cmp.Or(apiErr.Message, "Clockify refused the review"))
+	}
+	return request, err
+}
+
+// getApprovals serves the approval requests in the status of the query (default pending),
+// with their totals and anomaly flags
+func (a *API) getApprovals(w http.ResponseWriter, r *http.Request) {
+	approvals, err := a.Approvals(r.Context(), strings.ToLower(r.URL.Query().Get("status")))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, approvals)
+}
+
+// reviewRequest is the optional body of approving or rejecting
+type reviewRequest struct {
+	Note string `json:"note"`
+}
+
+// reviewApproval approves or rejects the approval request of the path, as the route ends in
+// approve or reject
+func (a *API) reviewApproval(approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request reviewRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request)
+		if err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %s", err))
+			return
+		}
+
+		approval, err := a.ReviewApproval(r.Context(), r.PathValue("id"), approve, request.Note)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, approval)
+	}
+}
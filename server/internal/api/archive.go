@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/archive"
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// RestoreRequest selects the archived webhook payloads to dispatch again
+type RestoreRequest struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"` // Exclusive
+	// Event types restored, all when empty
+	Events []clockify.WebhookEvent `json:"events,omitempty"`
+}
+
+// RestoreArchive dispatches the webhook payloads archived in the period to every handler
+// again, e.g. to rebuild what a handler keeps after losing it
+func (a *API) RestoreArchive(ctx context.Context, request RestoreRequest) (archive.Restored, error) {
+	if err := a.requireOperator(ctx); err != nil {
+		return archive.Restored{}, err
+	}
+	if !request.From.Before(request.To) {
+		return archive.Restored{}, fmt.Errorf("%w: from must be before to", ErrInvalidRequest)
+	}
+	return a.archive.Restore(ctx, a.registry, request.From, request.To, request.Events...)
+}
+
+// restoreArchive restores the payloads of the period of the body. Handlers failing are
+// counted in the result, the request itself succeeds.
+func (a *API) restoreArchive(w http.ResponseWriter, r *http.Request) {
+	var request RestoreRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %s", err))
+		return
+	}
+
+	restored, err := a.RestoreArchive(r.Context(), request)
+	if errors.Is(err, ErrInvalidRequest) || errors.Is(err, ErrForbidden) {
+		writeServiceError(w, r, err)
+		return
+	}
+	if err != nil {
+		// The events dispatched before the failure stay dispatched
+		slog.Error("api_archive_restore_failed", "from", request.From, "to", request.To, "error", err)
+		writeError(w, http.StatusBadGateway, "failed to read the webhook archive")
+		return
+	}
+	writeJSON(w, http.StatusOK, restored)
+}
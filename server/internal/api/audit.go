@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/audit"
+)
+
+// auditFilter reads the filter of an audit log request. since and until are RFC 3339 times or
+// YYYY-MM-DD dates, until being exclusive.
+func auditFilter(r *http.Request) (audit.Filter, error) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Actor:  query.Get("actor"),
+		Method: query.Get("method"),
+		URL:    query.Get("url"),
+	}
+
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			if parsed, err = time.ParseInLocation(dateLayout, raw, time.Local); err != nil {
+				return filter, InvalidRequestf("%s: must be an RFC 3339 time or YYYY-MM-DD, got %q", name, raw)
+			}
+		}
+		*t = parsed
+	}
+
+	if raw := query.Get("failed"); raw != "" {
+		failed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, InvalidRequestf("failed: must be a boolean, got %q", raw)
+		}
+		filter.Failed = failed
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return filter, InvalidRequestf("limit: must be a positive integer, got %q", raw)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// getAudit serves the audited Clockify writes matching the query, newest first
+func (a *API) getAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilter(r)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	entries, err := a.audit.Query(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query the audit log")
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Hukyl/CCWS/internal/deadletter"
+)
+
+// RetryResult is the outcome of retrying dead letters
+type RetryResult struct {
+	Retried []int64        `json:"retried"` // Deleted, their handler succeeded
+	Failed  []FailedLetter `json:"failed"`
+}
+
+// FailedLetter is a dead letter whose handler failed again
+type FailedLetter struct {
+	ID    int64  `json:"id"`
+	Error string `json:"error"`
+}
+
+// RetryDeadLetters runs the handlers of the dead letters with the given IDs again, of all of
+// them oldest first without IDs
+func (a *API) RetryDeadLetters(ctx context.Context, ids ...int64) (RetryResult, error) {
+	if err := a.requireOperator(ctx); err != nil {
+		return RetryResult{}, err
+	}
+	if len(ids) == 0 {
+		letters, err := a.deadLetters.List(ctx)
+		if err != nil {
+			return RetryResult{}, err
+		}
+		for _, letter := range slices.Backward(letters) {
+			ids = append(ids, letter.ID)
+		}
+	}
+
+	result := RetryResult{Retried: []int64{}, Failed: []FailedLetter{}}
+	for _, id := range ids {
+		err := a.deadLetters.Retry(ctx, a.registry, id)
+		if errors.Is(err, deadletter.ErrNotFound) && len(ids) == 1 {
+			return RetryResult{}, err
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, FailedLetter{ID: id, Error: err.Error()})
+			continue
+		}
+		result.Retried = append(result.Retried, id)
+	}
+	return result, nil
+}
+
+// getDeadLetters serves the events the handlers failed on, the most recently failed first
+func (a *API) getDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := a.deadLetters.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list the dead letters")
+		return
+	}
+	if letters == nil {
+		letters = []deadletter.Letter{}
+	}
+	writeJSON(w, http.StatusOK, letters)
+}
+
+// retryDeadLetters retries the dead letter of the path, or all of them. Handlers failing
+// again are reported in the result, the request itself succeeds.
+func (a *API) retryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	if raw := r.PathValue("id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no dead letter %q", raw))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	result, err := a.RetryDeadLetters(r.Context(), ids...)
+	if errors.Is(err, deadletter.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrForbidden) {
+		writeServiceError(w, r, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list the dead letters")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
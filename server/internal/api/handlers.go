@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/analytics"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// periodFromQuery reads the period of a request: either period=day|week|month with an optional
+// offset (-1 is the previous one), or from and to dates, both inclusive. Defaults to this week.
+func periodFromQuery(r *http.Request, now time.Time) (report.Period, error) {
+	query := r.URL.Query()
+
+	if from, to := query.Get("from"), query.Get("to"); from != "" || to != "" {
+		return DateRange(from, to, now.Location())
+	}
+
+	offset := 0
+	if raw := query.Get("offset"); raw != "" {
+		var err error
+		if offset, err = strconv.Atoi(raw); err != nil {
+			return report.Period{}, InvalidRequestf("offset: must be an integer, got %q", raw)
+		}
+	}
+
+	return PeriodOf(query.Get("period"), offset, now)
+}
+
+// writeServiceError maps the errors of the shared operations to statuses
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNoTimerRunning), errors.Is(err, ErrNoEntry), errors.Is(err, ErrNoApproval):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, clockify.ErrLocked):
+		writeError(w, http.StatusConflict, "time entry is locked")
+	default:
+		writeClockifyError(w, r, err)
+	}
+}
+
+// getSummary serves the report summary of a period, in the format of `ccws report -f json`
+func (a *API) getSummary(w http.ResponseWriter, r *http.Request) {
+	period, err := periodFromQuery(r, time.Now())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	summary, err := a.Summary(r.Context(), period)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := report.WriteJSON(w, summary); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to render the summary")
+	}
+}
+
+// getEntries serves the user's time entries in a period, newest first
+func (a *API) getEntries(w http.ResponseWriter, r *http.Request) {
+	period, err := periodFromQuery(r, time.Now())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	entries, err := a.Entries(r.Context(), period)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if entries == nil {
+		entries = []clockify.TimeEntry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (a *API) getProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := a.Projects(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// getAnalytics serves the analytics of the last weeks (default 4), of the whole team with
+// team=true, in the format of `ccws report utilization -f json`
+func (a *API) getAnalytics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	weeks := 4
+	if raw := query.Get("weeks"); raw != "" {
+		var err error
+		if weeks, err = strconv.Atoi(raw); err != nil {
+			writeServiceError(w, r, InvalidRequestf("weeks: must be an integer, got %q", raw))
+			return
+		}
+	}
+	team, _ := strconv.ParseBool(query.Get("team"))
+
+	result, err := a.Analytics(r.Context(), weeks, team)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := analytics.WriteJSON(w, result); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to render the analytics")
+	}
+}
+
+// getTimer serves the running time entry, 204 No Content when no timer is running
+func (a *API) getTimer(w http.ResponseWriter, r *http.Request) {
+	entry, err := a.RunningTimer(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if entry == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (a *API) startTimer(w http.ResponseWriter, r *http.Request) {
+	var request StartTimerRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %s", err))
+		return
+	}
+
+	entry, err := a.StartTimer(r.Context(), request)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// stopTimer stops the running timer, 404 Not Found when none is running. idle=15m drops the
+// last 15 minutes; split_at=<RFC 3339 time> stops there and records the rest as another entry,
+// then both entries are returned in order.
+func (a *API) stopTimer(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var request StopTimerRequest
+	if raw := query.Get("idle"); raw != "" {
+		idle, err := time.ParseDuration(raw)
+		if err != nil {
+			writeServiceError(w, r, InvalidRequestf("idle: must be a duration like 15m, got %q", raw))
+			return
+		}
+		request.Idle = idle
+	}
+	if raw := query.Get("split_at"); raw != "" {
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			writeServiceError(w, r, InvalidRequestf("split_at: must be an RFC 3339 time, got %q", raw))
+			return
+		}
+		request.SplitAt = at
+	}
+
+	stopped, split, err := a.StopTimer(r.Context(), request)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if split != nil {
+		writeJSON(w, http.StatusOK, []*clockify.TimeEntry{stopped, split})
+		return
+	}
+	writeJSON(w, http.StatusOK, stopped)
+}
+
+// deleteEntry deletes a time entry of the user, 204 No Content once deleted
+func (a *API) deleteEntry(w http.ResponseWriter, r *http.Request) {
+	if err := a.DeleteEntry(r.Context(), r.PathValue("id")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
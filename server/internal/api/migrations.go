@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// ErrMigrationRunning is returned when starting a migration while another one runs
+var ErrMigrationRunning = errors.New("a migration is already running")
+
+// Migration is a workspace migration started through the API
+type Migration struct {
+	ID        int                      `json:"id"`
+	Config    clockify.MigrationConfig `json:"config"`
+	StartedBy string                   `json:"startedBy,omitempty"` // Empty without API users
+	Started   time.Time                `json:"started"`
+	Finished  *time.Time               `json:"finished,omitempty"` // Nil while running
+	Stats     *clockify.MigrationStats `json:"stats,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// migrations are the migrations started since the server started, oldest first
+type migrations struct {
+	deadline time.Duration // Of every run, 0 for none
+
+	mu   sync.Mutex
+	runs []*Migration
+}
+
+// Migrations returns the migrations started since the server started, newest first
+func (a *API) Migrations() []Migration {
+	a.migrations.mu.Lock()
+	defer a.migrations.mu.Unlock()
+
+	runs := make([]Migration, 0, len(a.migrations.runs))
+	for _, run := range slices.Backward(a.migrations.runs) {
+		runs = append(runs, *run)
+	}
+	return runs
+}
+
+// StartMigration runs the migration in the background, returning it as started. Only one
+// runs at a time, ErrMigrationRunning is returned meanwhile. The Clockify writes it makes are
+// audited like those of the call starting it.
+func (a *API) StartMigration(ctx context.Context, config clockify.MigrationConfig) (Migration, error) {
+	if err := a.requireOperator(ctx); err != nil {
+		return Migration{}, err
+	}
+	switch {
+	case config.SourceWorkspaceName == "":
+		return Migration{}, InvalidRequestf("sourceWorkspaceName: must not be empty")
+	case config.SourceProjectName == "":
+		return Migration{}, InvalidRequestf("sourceProjectName: must not be empty")
+	case config.TargetWorkspaceName == "":
+		return Migration{}, InvalidRequestf("targetWorkspaceName: must not be empty")
+	}
+	if !slices.Contains([]clockify.LockedEntryPolicy{clockify.LockedMigrate, clockify.LockedSkip, clockify.LockedReport}, config.LockedEntries) {
+		return Migration{}, InvalidRequestf("lockedEntries: must be skip or report, got %q", config.LockedEntries)
+	}
+
+	a.migrations.mu.Lock()
+	defer a.migrations.mu.Unlock()
+	if n := len(a.migrations.runs); n > 0 && a.migrations.runs[n-1].Finished == nil {
+		return Migration{}, ErrMigrationRunning
+	}
+
+	run := &Migration{ID: len(a.migrations.runs) + 1, Config: config, Started: time.Now()}
+	if user, ok := UserFrom(ctx); ok {
+		run.StartedBy = user.Name
+	}
+	a.migrations.runs = append(a.migrations.runs, run)
+
+	// The migration outlives the call, but keeps its values, e.g. the audit actor
+	client := a.client.WithContext(context.WithoutCancel(ctx))
+	config.Deadline = a.migrations.deadline
+	go func() {
+		stats, err := clockify.NewMigrationService(client, &config).ExecuteMigration()
+		if err != nil {
+			slog.Error("api_migration_failed", "migration", run.ID, "error", err)
+		}
+
+		a.migrations.mu.Lock()
+		defer a.migrations.mu.Unlock()
+		finished := time.Now()
+		run.Finished, run.Stats = &finished, stats
+		if err != nil {
+			run.Error = err.Error()
+		}
+		a.entries.Clear()
+	}()
+
+	return *run, nil
+}
+
+// getMigrations serves the migrations started since the server started, newest first
+func (a *API) getMigrations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.Migrations())
+}
+
+// getMigration serves a migration, polled until it is finished
+func (a *API) getMigration(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err == nil {
+		for _, run := range a.Migrations() {
+			if run.ID == id {
+				writeJSON(w, http.StatusOK, run)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("no migration %q", r.PathValue("id")))
+}
+
+// runMigration starts the migration of the JSON body, 202 Accepted with the migration to poll
+func (a *API) runMigration(w http.ResponseWriter, r *http.Request) {
+	var config clockify.MigrationConfig
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&config); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %s", err))
+		return
+	}
+
+	run, err := a.StartMigration(r.Context(), config)
+	if errors.Is(err, ErrMigrationRunning) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s/migrations/%d", Prefix, run.ID))
+	writeJSON(w, http.StatusAccepted, run)
+}
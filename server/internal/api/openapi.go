@@ -0,0 +1,375 @@
+package api
+
+import (
+	"encoding/json"
+	"iter"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// OpenAPIPath is where the OpenAPI document of the API is served, under Prefix and without
+// authentication, as it holds no data
+const OpenAPIPath = "/openapi.json"
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers"`
+	Paths      map[string]map[string]Operation `json:"paths"` // Path -> lowercase method -> operation
+	Components Components                      `json:"components"`
+	Security   []map[string][]string           `json:"security,omitempty"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is the base URL the paths are relative to
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Components are the schemas and security schemes referred to by the operations
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way of passing the API token
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Operation is a route of the API
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a query or path parameter of an operation
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // query or path
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body an operation takes
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is what an operation answers with a status
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body of a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of the OpenAPI schema object describing the API's JSON
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Minimum     *int               `json:"minimum,omitempty"`
+	Maximum     *int               `json:"maximum,omitempty"`
+	Nullable    bool               `json:"nullable,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// A *Schema for maps, false for request bodies rejecting unknown fields
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPI describes the routes the API serves, those mounted by Handle excepted
+func (a *API) OpenAPI() *Document {
+	gen := newSchemas()
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "CCWS API",
+			Description: "Reports, time entries, timer control, analytics, events and workspace operations of a CCWS server",
+			Version:     "v1",
+		},
+		Servers: []Server{{URL: Prefix}},
+		Paths:   make(map[string]map[string]Operation),
+	}
+	if !a.Anonymous() {
+		doc.Security = []map[string][]string{{"bearer": {}}, {"accessToken": {}}}
+	}
+
+	for _, route := range a.routes() {
+		op := Operation{
+			OperationID: route.id,
+			Summary:     route.summary,
+			Description: route.description,
+			Parameters:  route.params,
+			Responses: map[string]Response{
+				"default": {Description: "The request failed", Content: jsonContent(&Schema{Ref: "#/components/schemas/Error"})},
+			},
+		}
+		if route.operator {
+			op.Description = strings.TrimSpace(op.Description + " Requires the operator role.")
+		}
+		for _, name := range pathParams(route.path) {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		if route.body != nil {
+			op.RequestBody = &RequestBody{Required: !route.optionalBody, Content: jsonContent(gen.requestBody(route.body, route.required))}
+		}
+
+		response := Response{Description: http.StatusText(route.status)}
+		switch {
+		case route.stream:
+			response.Content = map[string]MediaType{"text/event-stream": {Schema: &Schema{Type: "string", Description: "Server-Sent Events, each carrying an event envelope as JSON"}}}
+		case route.response != nil:
+			response.Content = jsonContent(gen.response(route.response))
+		}
+		op.Responses[strconv.Itoa(route.status)] = response
+		if route.maybeEmpty {
+			op.Responses[strconv.Itoa(http.StatusNoContent)] = Response{Description: http.StatusText(http.StatusNoContent)}
+		}
+
+		if doc.Paths[route.path] == nil {
+			doc.Paths[route.path] = make(map[string]Operation)
+		}
+		doc.Paths[route.path][strings.ToLower(route.method)] = op
+	}
+
+	gen.components["Error"] = gen.object(reflect.TypeOf(errorResponse{}))
+	doc.Components = Components{
+		Schemas: gen.components,
+		SecuritySchemes: map[string]SecurityScheme{
+			"bearer":      {Type: "http", Scheme: "bearer"},
+			"accessToken": {Type: "apiKey", In: "query", Name: "access_token"},
+		},
+	}
+	return doc
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// pathParams returns the names of the wildcards of a route path, e.g. id of /entries/{id}
+func pathParams(routePath string) []string {
+	var names []string
+	for _, segment := range strings.Split(routePath, "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			names = append(names, strings.TrimSuffix(name, "}"))
+		}
+	}
+	return names
+}
+
+func queryParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func stringSchema(format string, enum ...string) *Schema {
+	return &Schema{Type: "string", Format: format, Enum: enum}
+}
+
+// integerSchema describes an integer of at least minimum and, unless it is 0, at most maximum
+func integerSchema(minimum, maximum int) *Schema {
+	schema := &Schema{Type: "integer", Minimum: &minimum}
+	if maximum != 0 {
+		schema.Maximum = &maximum
+	}
+	return schema
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	rawType      = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schemas generates the schemas of Go types as encoding/json marshals them, named structs
+// becoming components referenced by their package and name, e.g. ClockifyTimeEntry
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	case rawType:
+		return &Schema{}
+	}
+	if values, ok := enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.of(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		// Handlers answer empty arrays rather than null
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name, ok := s.names[t]
+		if !ok {
+			name = componentName(t)
+			s.names[t] = name
+			// Registered before the fields, which may refer back to the type
+			s.components[name] = &Schema{}
+			*s.components[name] = *s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		// Interfaces hold any JSON
+		return &Schema{}
+	}
+}
+
+// response describes the JSON body of a response, v being a value of it or a oneOf of the
+// values of its forms
+func (s *schemas) response(v any) *Schema {
+	forms, ok := v.(oneOf)
+	if !ok {
+		return s.of(reflect.TypeOf(v))
+	}
+	schema := &Schema{}
+	for _, form := range forms {
+		schema.OneOf = append(schema.OneOf, s.of(reflect.TypeOf(form)))
+	}
+	return schema
+}
+
+// requestBody describes a JSON body decoded into a value of the type of v. Only the given
+// fields are required, and unknown fields are rejected.
+func (s *schemas) requestBody(v any, required []string) *Schema {
+	schema := s.object(reflect.TypeOf(v))
+	schema.Required = required
+	schema.AdditionalProperties = false
+	return schema
+}
+
+// object describes the fields of a struct, those without omitempty being required
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for field := range fields(t) {
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.of(field.Type)
+		if strings.Contains(opts, "string") {
+			property = &Schema{Type: "string"}
+		}
+		schema.Properties[name] = property
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// fields yields the fields encoding/json marshals, those of embedded structs included
+func fields(t reflect.Type) iter.Seq[reflect.StructField] {
+	return func(yield func(reflect.StructField) bool) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			if name, _, _ := strings.Cut(tag, ","); field.Anonymous && name == "" {
+				embedded := field.Type
+				if embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					for inner := range fields(embedded) {
+						if !yield(inner) {
+							return
+						}
+					}
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if !yield(field) {
+				return
+			}
+		}
+	}
+}
+
+// componentName names a struct by its package and name, the json prefix of the types only
+// rendering others dropped, e.g. ReportSummary for report.jsonSummary. Types of this package
+// go by their name alone.
+func componentName(t reflect.Type) string {
+	name := capitalize(strings.TrimPrefix(t.Name(), "json"))
+	if pkg := path.Base(t.PkgPath()); pkg != "api" {
+		name = capitalize(pkg) + name
+	}
+	return name
+}
+
+func capitalize(s string) string {
+	r := []rune(s)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/Hukyl/CCWS/internal/analytics"
+	"github.com/Hukyl/CCWS/internal/archive"
+	"github.com/Hukyl/CCWS/internal/audit"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/deadletter"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// route is an operation of the API, registered on the mux and described in the OpenAPI
+// document from the same definition
+type route struct {
+	method, path string // The path under Prefix, with the mux wildcards
+	id           string // The operationId
+	summary      string
+	description  string
+	operator     bool // Only documented, the operations check the role themselves
+	params       []Parameter
+	// The value a JSON body is decoded into, with the fields that must be set
+	body         any
+	required     []string
+	optionalBody bool
+	status       int  // Of success
+	response     any  // Encoded as the JSON body of success, nil for none
+	maybeEmpty   bool // Also answering 204 No Content
+	stream       bool
+	handler      http.HandlerFunc
+}
+
+// oneOf is a response body taking one of the forms of its values
+type oneOf []any
+
+// enums are the values of string types taking a fixed set of them
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(clockify.LockedEntryPolicy("")): {string(clockify.LockedMigrate), string(clockify.LockedSkip), string(clockify.LockedReport)},
+}
+
+// periodParams select the period of the reports and entries, see periodFromQuery
+var periodParams = []Parameter{
+	queryParam("period", "The day, week or month of now, week by default", stringSchema("", "day", "week", "month")),
+	queryParam("offset", "Periods before (negative) or after the current one", &Schema{Type: "integer"}),
+	queryParam("from", "The first day of a range of dates, instead of period", stringSchema("date")),
+	queryParam("to", "The last day of the range, inclusive", stringSchema("date")),
+}
+
+// routes returns the operations of the API, those enabled by options included when set
+func (a *API) routes() []route {
+	routes := []route{
+		{
+			method: "GET", path: "/reports/summary", id: "getSummary", handler: a.getSummary,
+			summary: "Summary of the user's tracked time in a period", params: periodParams,
+			status: http.StatusOK, response: report.JSONDocument(),
+		},
+		{
+			method: "GET", path: "/entries", id: "listEntries", handler: a.getEntries,
+			summary: "The user's time entries in a period, newest first", params: periodParams,
+			status: http.StatusOK, response: []clockify.TimeEntry{},
+		},
+		{
+			method: "GET", path: "/projects", id: "listProjects", handler: a.getProjects,
+			summary: "The workspace projects ordered by name",
+			status:  http.StatusOK, response: []Project{},
+		},
+		{
+			method: "GET", path: "/analytics", id: "getAnalytics", handler: a.getAnalytics,
+			summary: "Utilization, profitability and weekly trend of the last weeks",
+			params: []Parameter{
+				queryParam("weeks", "How many weeks, 4 by default", integerSchema(1, MaxAnalyticsWeeks)),
+				queryParam("team", "Of every workspace member instead of the user", &Schema{Type: "boolean"}),
+			},
+			status: http.StatusOK, response: analytics.JSONDocument(),
+		},
+		{
+			method: "GET", path: "/timer", id: "getTimer", handler: a.getTimer,
+			summary: "The running time entry", description: "No Content when no timer is running.",
+			status: http.StatusOK, response: clockify.TimeEntry{}, maybeEmpty: true,
+		},
+		{
+			method: "POST", path: "/timer", id: "startTimer", handler: a.startTimer, operator: true,
+			summary:     "Starts a timer",
+			description: "Projects, tasks and tags are given by name. A request naming none tracks the timer defaults.",
+			body:        StartTimerRequest{},
+			status:      http.StatusCreated, response: clockify.TimeEntry{},
+		},
+		{
+			method: "DELETE", path: "/timer", id: "stopTimer", handler: a.stopTimer, operator: true,
+			summary:     "Stops the running timer",
+			description: "With split_at, the stopped entry and the entry of the time since are returned in order.",
+			params: []Parameter{
+				queryParam("idle", "Drops this much idle time before now, e.g. 15m", stringSchema("duration")),
+				queryParam("split_at", "Stops there and records the rest as another entry", stringSchema("date-time")),
+			},
+			status: http.StatusOK, response: oneOf{clockify.TimeEntry{}, []clockify.TimeEntry{}},
+		},
+		{
+			method: "DELETE", path: "/entries/{id}", id: "deleteEntry", handler: a.deleteEntry, operator: true,
+			summary: "Deletes a time entry of the user",
+			status:  http.StatusNoContent,
+		},
+		{
+			method: "GET", path: "/migrations", id: "listMigrations", handler: a.getMigrations,
+			summary: "The migrations started since the server started, newest first",
+			status:  http.StatusOK, response: []Migration{},
+		},
+		{
+			method: "POST", path: "/migrations", id: "startMigration", handler: a.runMigration, operator: true,
+			summary:     "Starts a workspace migration",
+			description: "The migration runs in the background, poll its Location until it is finished.",
+			body:        clockify.MigrationConfig{},
+			required:    []string{"sourceWorkspaceName", "sourceProjectName", "targetWorkspaceName"},
+			status:      http.StatusAccepted, response: Migration{},
+		},
+		{
+			method: "GET", path: "/migrations/{id}", id: "getMigration", handler: a.getMigration,
+			summary: "A migration",
+			status:  http.StatusOK, response: Migration{},
+		},
+		{
+			method: "GET", path: "/events", id: "streamEvents", handler: a.streamEvents,
+			summary:     "Live stream of the webhook events",
+			description: "Server-Sent Events named by the event type, each carrying the event envelope as JSON.",
+			params: []Parameter{
+				queryParam("types", "Comma-separated event types, all by default", &Schema{Type: "string"}),
+			},
+			status: http.StatusOK, stream: true,
+		},
+		{
+			method: "GET", path: "/approvals", id: "listApprovals", handler: a.getApprovals,
+			summary: "The workspace approval requests with their totals and anomaly flags",
+			params: []Parameter{
+				queryParam("status", "pending by default", stringSchema("", "pending", "approved", "rejected", "withdrawn", "all")),
+			},
+			status: http.StatusOK, response: []Approval{},
+		},
+		{
+			method: "POST", path: "/approvals/{id}/approve", id: "approveApproval", handler: a.reviewApproval(true), operator: true,
+			summary: "Approves a pending approval request",
+			body:    reviewRequest{}, optionalBody: true,
+			status: http.StatusOK, response: clockify.ApprovalRequest{},
+		},
+		{
+			method: "POST", path: "/approvals/{id}/reject", id: "rejectApproval", handler: a.reviewApproval(false), operator: true,
+			summary: "Rejects a pending approval request",
+			body:    reviewRequest{}, optionalBody: true,
+			status: http.StatusOK, response: clockify.ApprovalRequest{},
+		},
+	}
+
+	if a.audit != nil {
+		routes = append(routes, route{
+			method: "GET", path: "/audit", id: "queryAudit", handler: a.getAudit,
+			summary: "The audited Clockify writes, newest first",
+			params: []Parameter{
+				queryParam("actor", "", &Schema{Type: "string"}),
+				queryParam("method", "", &Schema{Type: "string"}),
+				queryParam("url", "Part of the URL", &Schema{Type: "string"}),
+				queryParam("since", "RFC 3339 time or YYYY-MM-DD date", &Schema{Type: "string"}),
+				queryParam("until", "RFC 3339 time or YYYY-MM-DD date, exclusive", &Schema{Type: "string"}),
+				queryParam("failed", "Only the writes Clockify did not apply", &Schema{Type: "boolean"}),
+				queryParam("limit", "", integerSchema(1, 0)),
+			},
+			status: http.StatusOK, response: []audit.Entry{},
+		})
+	}
+	if a.deadLetters != nil {
+		routes = append(routes,
+			route{
+				method: "GET", path: "/deadletters", id: "listDeadLetters", handler: a.getDeadLetters,
+				summary: "The events the handlers failed on, the most recently failed first",
+				status:  http.StatusOK, response: []deadletter.Letter{},
+			},
+			route{
+				method: "POST", path: "/deadletters/retry", id: "retryDeadLetters", handler: a.retryDeadLetters, operator: true,
+				summary:     "Retries every dead letter, oldest first",
+				description: "Handlers failing again are reported in the result.",
+				status:      http.StatusOK, response: RetryResult{},
+			},
+			route{
+				method: "POST", path: "/deadletters/{id}/retry", id: "retryDeadLetter", handler: a.retryDeadLetters, operator: true,
+				summary: "Retries a dead letter",
+				status:  http.StatusOK, response: RetryResult{},
+			},
+		)
+	}
+	if a.archive != nil {
+		routes = append(routes, route{
+			method: "POST", path: "/archive/restore", id: "restoreArchive", handler: a.restoreArchive, operator: true,
+			summary:     "Dispatches the archived webhook payloads of a period again",
+			description: "Every handler runs again on the payloads, in the order they were received. Handlers failing are counted in the result.",
+			body:        RestoreRequest{},
+			required:    []string{"from", "to"},
+			status:      http.StatusOK, response: archive.Restored{},
+		})
+	}
+	return routes
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/analytics"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// The operations below are shared by the REST handlers and the gRPC service
+
+var (
+	// ErrInvalidRequest wraps errors caused by the caller, e.g. an unknown project name
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrNoTimerRunning is returned when stopping without a running timer
+	ErrNoTimerRunning = errors.New("no timer is running")
+	// ErrNoEntry is returned for time entries that do not exist or are another user's
+	ErrNoEntry = errors.New("no such time entry")
+	// ErrForbidden is returned when the user's role does not allow the operation
+	ErrForbidden = errors.New("requires the operator role")
+)
+
+// InvalidRequestf formats an error wrapping ErrInvalidRequest
+func InvalidRequestf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRequest, fmt.Sprintf(format, args...))
+}
+
+// PeriodOf returns the day, week or month containing now, shifted by offset periods
+// (-1 is the previous one). An empty kind means week.
+func PeriodOf(kind string, offset int, now time.Time) (report.Period, error) {
+	switch kind {
+	case "day":
+		return report.Day(now.AddDate(0, 0, offset)), nil
+	case "", "week":
+		return report.Week(now, offset), nil
+	case "month":
+		return report.Month(now, offset), nil
+	default:
+		return report.Period{}, InvalidRequestf("period: must be day, week or month, got %q", kind)
+	}
+}
+
+// dateLayout is the format of the dates taken by DateRange
+const dateLayout = "2006-01-02"
+
+// DateRange returns the period from the start of from to the end of to, YYYY-MM-DD dates in loc
+func DateRange(from, to string, loc *time.Location) (report.Period, error) {
+	start, err := time.ParseInLocation(dateLayout, from, loc)
+	if err != nil {
+		return report.Period{}, InvalidRequestf("from: must be YYYY-MM-DD, got %q", from)
+	}
+	end, err := time.ParseInLocation(dateLayout, to, loc)
+	if err != nil {
+		return report.Period{}, InvalidRequestf("to: must be YYYY-MM-DD, got %q", to)
+	}
+	if end.Before(start) {
+		return report.Period{}, InvalidRequestf("to: must not be before from")
+	}
+	return report.Period{Start: start, End: end.AddDate(0, 0, 1)}, nil
+}
+
+// Entries returns the user's time entries starting in the period, newest first
+func (a *API) Entries(ctx context.Context, period report.Period) ([]clockify.TimeEntry, error) {
+	if a.mirror != nil {
+		return a.mirror.TimeEntries(a.workspace.ID, a.user.ID, period.Start, period.End)
+	}
+	return a.entries.GetOrLoad(period, func() ([]clockify.TimeEntry, error) {
+		return report.FetchEntries(a.client.WithContext(ctx), a.workspace.ID, a.user.ID, period)
+	})
+}
+
+// ProjectNames returns the names of the workspace projects by ID
+func (a *API) ProjectNames(ctx context.Context) (map[string]string, error) {
+	if a.mirror != nil {
+		return a.mirror.ProjectNames(a.workspace.ID)
+	}
+	return a.projects.GetOrLoad(a.workspace.ID, func() (map[string]string, error) {
+		return report.ProjectNames(a.client.WithContext(ctx), a.workspace.ID)
+	})
+}
+
+// Project is a workspace project as listed by the API
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Projects returns the workspace projects ordered by name
+func (a *API) Projects(ctx context.Context) ([]Project, error) {
+	names, err := a.ProjectNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make([]Project, 0, len(names))
+	for id, name := range names {
+		projects = append(projects, Project{ID: id, Name: name})
+	}
+	slices.SortFunc(projects, func(a, b Project) int { return strings.Compare(a.Name, b.Name) })
+	return projects, nil
+}
+
+// Summary aggregates the user's time entries in the period
+func (a *API) Summary(ctx context.Context, period report.Period) (*report.Summary, error) {
+	entries, err := a.Entries(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	names, err := a.ProjectNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return report.Summarize(period, a.rounding.Apply(entries, period.Start.Location()), names, time.Now()), nil
+}
+
+// MaxAnalyticsWeeks bounds the trend length, each week is fetched for every user
+const MaxAnalyticsWeeks = 52
+
+type analyticsKey struct {
+	weeks int
+	team  bool
+}
+
+// Analytics computes utilization, profitability and the weekly trend over the last weeks,
+// for the user or, with team, for every workspace member
+func (a *API) Analytics(ctx context.Context, weeks int, team bool) (*analytics.Report, error) {
+	if weeks < 1 || weeks > MaxAnalyticsWeeks {
+		return nil, InvalidRequestf("weeks: must be between 1 and %d, got %d", MaxAnalyticsWeeks, weeks)
+	}
+
+	return a.reports.GetOrLoad(analyticsKey{weeks, team}, func() (*analytics.Report, error) {
+		client := a.client.WithContext(ctx)
+
+		users := []clockify.User{*a.user}
+		if team {
+			var err error
+			if users, err = analytics.Users(client, a.workspace.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		model, err := a.capacity.Resolve(client, a.workspace.ID, users)
+		if err != nil {
+			return nil, err
+		}
+		opts := analytics.Options{Capacity: model, Currency: a.workspace.DefaultCurrency()}
+		if a.exchange != nil {
+			if opts.Rates, err = a.exchange.Rates(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return analytics.Build(client, a.workspace.ID, users, weeks, opts, time.Now())
+	})
+}
+
+// RunningTimer returns the running time entry, nil when no timer is running. It is never cached.
+func (a *API) RunningTimer(ctx context.Context) (*clockify.TimeEntry, error) {
+	return a.client.WithContext(ctx).GetRunningTimeEntry(a.workspace.ID, a.user.ID)
+}
+
+// StartTimerRequest starts a timer, projects, tasks and tags are given by name
+type StartTimerRequest struct {
+	Description string   `json:"description"`
+	Project     string   `json:"project,omitempty"`
+	Task        string   `json:"task,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// StartTimer resolves the names of the request and starts the timer. A request naming no
+// project, task or tags tracks the timer defaults.
+func (a *API) StartTimer(ctx context.Context, request StartTimerRequest) (*clockify.TimeEntry, error) {
+	if err := a.requireOperator(ctx); err != nil {
+		return nil, err
+	}
+	if request.Task != "" && request.Project == "" {
+		return nil, InvalidRequestf("task: requires a project")
+	}
+	if request.Project == "" && len(request.Tags) == 0 {
+		request.Project, request.Task, request.Tags = a.defaults.Project, a.defaults.Task, a.defaults.Tags
+	}
+
+	client := a.client.WithContext(ctx)
+	tracked := clockify.TimerDefaults{TagIDs: make([]string, 0, len(request.Tags)), Billable: a.defaults.Billable}
+
+	if request.Project != "" {
+		project, err := client.FindProjectByName(a.workspace.ID, request.Project)
+		if err != nil {
+			return nil, unknownName(err)
+		}
+		tracked.ProjectID = project.ID
+
+		if request.Task != "" {
+			task, err := client.FindTaskByName(a.workspace.ID, project.ID, request.Task)
+			if err != nil {
+				return nil, unknownName(err)
+			}
+			tracked.TaskID = task.ID
+		}
+	}
+
+	for _, name := range request.Tags {
+		tag, err := client.FindTagByName(a.workspace.ID, name)
+		if err != nil {
+			return nil, unknownName(err)
+		}
+		tracked.TagIDs = append(tracked.TagIDs, tag.ID)
+	}
+
+	entry, err := client.StartDefaultTimer(a.workspace.ID, a.user.ID, request.Description, tracked)
+	if err != nil {
+		return nil, err
+	}
+	a.entries.Clear()
+	return entry, nil
+}
+
+// unknownName blames the caller for names that do not exist, other lookup failures are
+// Clockify's
+func unknownName(err error) error {
+	if errors.Is(err, clockify.ErrNotFound) {
+		return InvalidRequestf("%s", err)
+	}
+	return err
+}
+
+// StopTimerRequest drops idle time when stopping. At most one of the fields may be set.
+type StopTimerRequest struct {
+	// Stop this long before now, dropping the idle time
+	Idle time.Duration
+	// Stop at this boundary and record the time since as a second entry
+	SplitAt time.Time
+}
+
+// StopTimer stops the running timer, ErrNoTimerRunning when none is running. With SplitAt,
+// the entry recording the time since the boundary is returned as well.
+func (a *API) StopTimer(ctx context.Context, request StopTimerRequest) (stopped, split *clockify.TimeEntry, err error) {
+	if err := a.requireOperator(ctx); err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case request.Idle < 0:
+		return nil, nil, InvalidRequestf("idle: must not be negative")
+	case request.Idle > 0 && !request.SplitAt.IsZero():
+		return nil, nil, InvalidRequestf("idle and split_at are mutually exclusive")
+	}
+
+	client := a.client.WithContext(ctx)
+	defer a.entries.Clear()
+
+	switch {
+	case request.Idle > 0:
+		stopped, err = client.TrimRunningTimer(a.workspace.ID, a.user.ID, request.Idle)
+	case !request.SplitAt.IsZero():
+		stopped, split, err = client.SplitRunningTimer(a.workspace.ID, a.user.ID, request.SplitAt)
+	default:
+		stopped, err = stopRunning(client, a.workspace.ID, a.user.ID)
+	}
+
+	switch {
+	case errors.Is(err, clockify.ErrNoRunningTimeEntry):
+		return nil, nil, ErrNoTimerRunning
+	case errors.Is(err, clockify.ErrIdleBoundary):
+		return nil, nil, InvalidRequestf("%s", err)
+	}
+	return stopped, split, err
+}
+
+// DeleteEntry deletes a time entry of the user, ErrNoEntry if there is none with the ID
+func (a *API) DeleteEntry(ctx context.Context, id string) error {
+	if err := a.requireOperator(ctx); err != nil {
+		return err
+	}
+
+	client := a.client.WithContext(ctx)
+	entry, err := client.GetTimeEntry(a.workspace.ID, id)
+	switch {
+	case errors.Is(err, clockify.ErrNotFound):
+		return ErrNoEntry
+	case err != nil:
+		return err
+	case entry.UserID != a.user.ID:
+		return ErrNoEntry
+	}
+
+	defer a.entries.Clear()
+	return client.DeleteTimeEntry(a.workspace.ID, id)
+}
+
+func stopRunning(client *clockify.APIClient, workspaceID, userID string) (*clockify.TimeEntry, error) {
+	running, err := client.GetRunningTimeEntry(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if running == nil {
+		return nil, clockify.ErrNoRunningTimeEntry
+	}
+	return client.StopTimeEntry(workspaceID, userID, time.Now())
+}
+
+// Subscribe receives the published events of the given types, all when none are given.
+// The channel is closed by cancel or when the API is closed.
+func (a *API) Subscribe(types ...clockify.WebhookEvent) (envelopes <-chan events.Envelope, cancel func()) {
+	sub := a.stream.subscribe(types)
+	return sub.events, func() { a.stream.unsubscribe(sub) }
+}
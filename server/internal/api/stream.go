@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+const (
+	// streamBuffer is how many events a slow client may lag behind before events are dropped for it
+	streamBuffer = 64
+	// streamHeartbeat keeps idle connections open through proxies
+	streamHeartbeat = 25 * time.Second
+)
+
+// subscriber is a connected stream client
+type subscriber struct {
+	types  []clockify.WebhookEvent // Empty means all
+	events chan events.Envelope
+}
+
+// stream fans events out to the connected clients
+type stream struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+func newStream() *stream {
+	return &stream{subscribers: make(map[*subscriber]struct{})}
+}
+
+// subscribe registers a client, its channel is closed right away if the stream is closed
+func (s *stream) subscribe(types []clockify.WebhookEvent) *subscriber {
+	sub := &subscriber{types: types, events: make(chan events.Envelope, streamBuffer)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		close(sub.events)
+		return sub
+	}
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// unsubscribe removes the client and closes its channel, it is safe to call more than once
+func (s *stream) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (s *stream) publish(envelope events.Envelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers {
+		if len(sub.types) > 0 && !slices.Contains(sub.types, envelope.Type) {
+			continue
+		}
+		select {
+		case sub.events <- envelope:
+		default:
+			slog.Warn("stream_event_dropped", "event_id", envelope.ID, "event", envelope.Type, "reason", "client too slow")
+		}
+	}
+}
+
+// close disconnects every client and rejects new ones
+func (s *stream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Publish sends the event to the clients connected to the event stream, it is an events.HandlerFunc
+func (a *API) Publish(_ context.Context, event events.Event) error {
+	a.stream.publish(events.NewEnvelope(event))
+	return nil
+}
+
+// Close disconnects the event stream clients, so graceful shutdowns do not wait for them
+func (a *API) Close() {
+	a.stream.close()
+}
+
+// streamEvents serves events as Server-Sent Events, filtered by the comma-separated types parameter.
+// Every message carries the event envelope as JSON, with the event type as the SSE event name.
+func (a *API) streamEvents(w http.ResponseWriter, r *http.Request) {
+	var types []clockify.WebhookEvent
+	for _, name := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			types = append(types, clockify.WebhookEvent(name))
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	// The server's write timeout is meant for regular requests, not for long-lived streams
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Debug("stream_write_deadline_unsupported", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disables nginx buffering
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		slog.Error("stream_unsupported", "error", err)
+		return
+	}
+
+	envelopes, cancel := a.Subscribe(types...)
+	defer cancel()
+
+	slog.Info("stream_connected", "remote_addr", r.RemoteAddr, "types", types)
+	defer slog.Info("stream_disconnected", "remote_addr", r.RemoteAddr)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case envelope, ok := <-envelopes:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(envelope)
+			if err != nil {
+				slog.Error("stream_encode_failed", "event_id", envelope.ID, "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", envelope.ID, envelope.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
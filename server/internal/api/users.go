@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+)
+
+// Role is what a user of the API may do
+type Role string
+
+const (
+	// RoleViewer reads reports, entries, the timer, the event stream, the approvals, the audit
+	// log and the dead letters
+	RoleViewer Role = "viewer"
+	// RoleOperator also starts and stops the timer, deletes entries, runs migrations, approves
+	// and rejects approval requests and retries dead letters
+	RoleOperator Role = "operator"
+)
+
+// User is an account of the API, authenticated by its token
+type User struct {
+	Name  string // Empty for the token given by WithToken
+	Token string
+	Role  Role
+}
+
+// Actor returns what the Clockify writes of the user are audited as, via followed by the
+// name, e.g. "api:alice"
+func (u User) Actor(via string) string {
+	if u.Name == "" {
+		return via
+	}
+	return via + ":" + u.Name
+}
+
+// WithUsers requires requests to carry the token of one of the users, see WithToken. Each
+// may do what its role allows.
+func WithUsers(users ...User) Option {
+	return func(a *API) {
+		a.users = append(a.users, users...)
+	}
+}
+
+// Anonymous reports whether the API serves every request without a token, as an operator
+func (a *API) Anonymous() bool {
+	return len(a.users) == 0
+}
+
+// Authenticate returns the user with the token
+func (a *API) Authenticate(token string) (User, bool) {
+	var (
+		found User
+		ok    bool
+	)
+	if token == "" {
+		return found, false
+	}
+	// Compare against every token, not to reveal which one matched by the time taken
+	for _, user := range a.users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(user.Token)) == 1 {
+			found, ok = user, true
+		}
+	}
+	return found, ok
+}
+
+type userKey struct{}
+
+// WithUser returns a context of a call made by the user, e.g. once Authenticate found it
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom returns the user set by WithUser
+func UserFrom(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey{}).(User)
+	return user, ok
+}
+
+// requireOperator returns ErrForbidden unless the call is made by an operator
+func (a *API) requireOperator(ctx context.Context) error {
+	if a.Anonymous() {
+		return nil
+	}
+	if user, ok := UserFrom(ctx); ok && user.Role == RoleOperator {
+		return nil
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBodySize bounds the JSON bodies of requests
+const maxBodySize = 1 << 16
+
+// validated checks the query parameters and the JSON body of the requests of the route
+// against its operation in the document before passing them on, so the API enforces what it
+// documents
+func validated(doc *Document, r route) http.HandlerFunc {
+	op := doc.Paths[r.path][strings.ToLower(r.method)]
+	v := validator{components: doc.Components.Schemas}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := v.params(op.Parameters, req); err != nil {
+			writeServiceError(w, req, err)
+			return
+		}
+
+		if op.RequestBody != nil {
+			data, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodySize))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %s", err))
+				return
+			}
+			if len(bytes.TrimSpace(data)) == 0 {
+				if op.RequestBody.Required {
+					writeServiceError(w, req, InvalidRequestf("body: is required"))
+					return
+				}
+			} else {
+				var body any
+				dec := json.NewDecoder(bytes.NewReader(data))
+				dec.UseNumber()
+				if err := dec.Decode(&body); err != nil {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %s", err))
+					return
+				}
+				if err := v.value("", op.RequestBody.Content["application/json"].Schema, body); err != nil {
+					writeServiceError(w, req, err)
+					return
+				}
+			}
+			// The handler decodes the body itself
+			req.Body = io.NopCloser(bytes.NewReader(data))
+		}
+
+		r.handler(w, req)
+	}
+}
+
+// validator checks values against schemas, resolving the references to components
+type validator struct {
+	components map[string]*Schema
+}
+
+func (v validator) resolve(schema *Schema) *Schema {
+	if name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/"); ok {
+		if component, ok := v.components[name]; ok {
+			return component
+		}
+	}
+	return schema
+}
+
+// params checks the query parameters the request sets. Path parameters are left to the
+// handlers, which answer Not Found for those naming nothing.
+func (v validator) params(params []Parameter, req *http.Request) error {
+	query := req.URL.Query()
+	for _, param := range params {
+		if param.In != "query" {
+			continue
+		}
+		raw := query.Get(param.Name)
+		if raw == "" {
+			if param.Required {
+				return InvalidRequestf("%s: is required", param.Name)
+			}
+			continue
+		}
+		if err := v.raw(param.Name, v.resolve(param.Schema), raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// raw checks a parameter given as text, enums matching regardless of case
+func (v validator) raw(field string, schema *Schema, raw string) error {
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return InvalidRequestf("%s: must be an integer, got %q", field, raw)
+		}
+		return bounds(field, schema, n)
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return InvalidRequestf("%s: must be a number, got %q", field, raw)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return InvalidRequestf("%s: must be a boolean, got %q", field, raw)
+		}
+	case "string":
+		return str(field, schema, raw, strings.EqualFold)
+	}
+	return nil
+}
+
+// value checks a decoded JSON value
+func (v validator) value(field string, schema *Schema, value any) error {
+	schema = v.resolve(schema)
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return InvalidRequestf("%s: must not be null", fieldName(field))
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return InvalidRequestf("%s: must be an object", fieldName(field))
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return InvalidRequestf("%s: is required", join(field, name))
+			}
+		}
+		// Sorted, so that the same body always fails on the same field
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			property, ok := schema.Properties[name]
+			if !ok {
+				additional, isSchema := schema.AdditionalProperties.(*Schema)
+				if !isSchema {
+					if schema.AdditionalProperties == false {
+						return InvalidRequestf("%s: unknown field", join(field, name))
+					}
+					continue
+				}
+				property = additional
+			}
+			if err := v.value(join(field, name), property, object[name]); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return InvalidRequestf("%s: must be an array", fieldName(field))
+		}
+		for i, item := range items {
+			if err := v.value(fmt.Sprintf("%s[%d]", field, i), schema.Items, item); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return InvalidRequestf("%s: must be a string", fieldName(field))
+		}
+		return str(field, schema, s, func(a, b string) bool { return a == b })
+	case "integer":
+		number, ok := value.(json.Number)
+		n, err := number.Int64()
+		if !ok || err != nil {
+			return InvalidRequestf("%s: must be an integer, got %v", fieldName(field), value)
+		}
+		return bounds(field, schema, n)
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return InvalidRequestf("%s: must be a number", fieldName(field))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return InvalidRequestf("%s: must be a boolean", fieldName(field))
+		}
+	}
+	return nil
+}
+
+func bounds(field string, schema *Schema, n int64) error {
+	switch {
+	case schema.Minimum != nil && schema.Maximum != nil && (n < int64(*schema.Minimum) || n > int64(*schema.Maximum)):
+		return InvalidRequestf("%s: must be between %d and %d, got %d", fieldName(field), *schema.Minimum, *schema.Maximum, n)
+	case schema.Minimum != nil && n < int64(*schema.Minimum):
+		return InvalidRequestf("%s: must be at least %d, got %d", fieldName(field), *schema.Minimum, n)
+	case schema.Maximum != nil && n > int64(*schema.Maximum):
+		return InvalidRequestf("%s: must be at most %d, got %d", fieldName(field), *schema.Maximum, n)
+	}
+	return nil
+}
+
+// str checks the enum and format of a string, equal comparing it to the values of the enum
+func str(field string, schema *Schema, s string, equal func(a, b string) bool) error {
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(value string) bool { return equal(value, s) }) {
+		return InvalidRequestf("%s: must be %s, got %q", fieldName(field), listValues(schema.Enum), s)
+	}
+
+	switch schema.Format {
+	case "date":
+		if _, err := time.Parse(dateLayout, s); err != nil {
+			return InvalidRequestf("%s: must be YYYY-MM-DD, got %q", fieldName(field), s)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return InvalidRequestf("%s: must be an RFC 3339 time, got %q", fieldName(field), s)
+		}
+	case "duration":
+		if _, err := time.ParseDuration(s); err != nil {
+			return InvalidRequestf("%s: must be a duration like 15m, got %q", fieldName(field), s)
+		}
+	}
+	return nil
+}
+
+// listValues lists the values of an enum, e.g. "day, week or month"
+func listValues(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" {
+			value = `""`
+		}
+		quoted = append(quoted, value)
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+// fieldName names the body itself when the field is empty
+func fieldName(field string) string {
+	if field == "" {
+		return "body"
+	}
+	return field
+}
@@ -0,0 +1,410 @@
+// Package app wires the config and the Clockify client together for the commands.
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/audit"
+	"github.com/Hukyl/CCWS/internal/billable"
+	"github.com/Hukyl/CCWS/internal/capacity"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/config"
+	"github.com/Hukyl/CCWS/internal/issues"
+	"github.com/Hukyl/CCWS/internal/lifecycle"
+	"github.com/Hukyl/CCWS/internal/mirror"
+	"github.com/Hukyl/CCWS/internal/money"
+	"github.com/Hukyl/CCWS/internal/redis"
+	"github.com/Hukyl/CCWS/internal/report"
+	"github.com/Hukyl/CCWS/internal/tunnel"
+	"go.opentelemetry.io/otel"
+)
+
+// NewClient creates an API client configured from cfg. With an addon token, it authenticates
+// as the addon installation in the region of the token.
+func NewClient(cfg *config.Config, opts ...clockify.ClientOption) (*clockify.APIClient, error) {
+	opts = append([]clockify.ClientOption{
+		clockify.WithBaseURL(cfg.ClockifyBaseURL),
+		clockify.WithLogger(slog.Default()),
+		clockify.WithTracerProvider(otel.GetTracerProvider()),
+		clockify.WithRateLimit(cfg.ClockifyRateLimit),
+		clockify.WithRetry(cfg.ClockifyRetryAttempts),
+		clockify.WithTimeout(cfg.ClockifyTimeout),
+		clockify.WithCallTimeouts(clockify.CallTimeouts{
+			Write: cfg.ClockifyWriteTimeout,
+			Read:  cfg.ClockifyReadTimeout,
+			List:  cfg.ClockifyListTimeout,
+		}),
+		clockify.WithMaxIdleConnsPerHost(cfg.ClockifyMaxIdleConns),
+		clockify.WithIdleConnTimeout(cfg.ClockifyIdleConnTimeout),
+		clockify.WithETagCache(cfg.ClockifyETagCacheSize),
+		clockify.WithCircuitBreaker(cfg.ClockifyBreakerThreshold, cfg.ClockifyBreakerCooldown),
+		clockify.WithProjectDefaults(cfg.ProjectBillable, cfg.ProjectPublic),
+		clockify.WithBillingIncrements(clockify.BillingIncrements{
+			Increment:      cfg.BillingIncrement,
+			Minimum:        cfg.BillingMinimum,
+			Projects:       cfg.BillingProjects,
+			ExemptProjects: cfg.BillingExemptProjects,
+		}),
+	}, opts...)
+
+	versions, err := cfg.EndpointVersions()
+	if err != nil {
+		return nil, fmt.Errorf("CLOCKIFY_ENDPOINT_VERSIONS: %w", err)
+	}
+	for endpoint, version := range versions {
+		opts = append(opts, clockify.WithEndpointVersion(endpoint, clockify.APIVersion(version)))
+	}
+
+	if cfg.ClockifyAddonToken != "" {
+		token, err := newAddonToken(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, clockify.WithAddonToken(token))
+	}
+
+	return clockify.NewDefaultClient(cfg.ClockifyAPIKey, opts...), nil
+}
+
+// newAddonToken parses the addon token, verified if the key Clockify signs them with is configured
+func newAddonToken(cfg *config.Config) (*clockify.AddonToken, error) {
+	var key *rsa.PublicKey
+	if cfg.ClockifyAddonPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.ClockifyAddonPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("CLOCKIFY_ADDON_PUBLIC_KEY_FILE: %w", err)
+		}
+		if key, err = clockify.ParseAddonPublicKey(data); err != nil {
+			return nil, fmt.Errorf("CLOCKIFY_ADDON_PUBLIC_KEY_FILE: %w", err)
+		}
+	}
+
+	token, err := clockify.NewAddonToken(cfg.ClockifyAddonToken, key)
+	if err != nil {
+		return nil, fmt.Errorf("CLOCKIFY_ADDON_TOKEN: %w", err)
+	}
+	return token, nil
+}
+
+// OpenAuditLog opens the audit log when AUDIT_DSN is set, with the client options recording
+// the writes of a client as made by actor unless the context names another. The log is nil
+// otherwise.
+func OpenAuditLog(cfg *config.Config, actor string) (*audit.Log, []clockify.ClientOption, error) {
+	if cfg.AuditDSN == "" {
+		return nil, nil, nil
+	}
+
+	log, err := audit.Open(cfg.AuditDSN)
+	if err != nil {
+		return nil, nil, err
+	}
+	return log, []clockify.ClientOption{clockify.WithTransportMiddleware(log.Middleware(actor))}, nil
+}
+
+// OpenSharedState connects to the Redis of REDIS_URL, the state the replicas of the server
+// share. It is nil when REDIS_URL is not set.
+func OpenSharedState(ctx context.Context, cfg *config.Config) (*redis.Client, error) {
+	if cfg.RedisURL == "" {
+		return nil, nil
+	}
+
+	client, err := redis.New(cfg.RedisURL, redis.WithPrefix(cfg.RedisPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL: %w", err)
+	}
+	// Fail fast rather than have every replica fall back to its own state
+	if err := client.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to reach Redis: %w", err)
+	}
+	return client, nil
+}
+
+// LeaderLease returns the lease the replicas sharing the Redis contend for to run the jobs,
+// owned by INSTANCE_ID and a random suffix so that replicas sharing an ID still differ
+func LeaderLease(cfg *config.Config, client *redis.Client) *redis.Lease {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return client.NewLease("leader:scheduler", instanceID(cfg)+":"+hex.EncodeToString(suffix), cfg.LeaderLeaseTTL)
+}
+
+// NewIssueResolvers creates the issue trackers with a configured token, the set is empty
+// when there is none
+func NewIssueResolvers(cfg *config.Config) *issues.Set {
+	var resolvers []issues.Resolver
+	if cfg.GitLabToken != "" {
+		var opts []issues.GitLabOption
+		if cfg.GitLabURL != "" {
+			opts = append(opts, issues.WithGitLabURL(cfg.GitLabURL))
+		}
+		if cfg.GitLabProject != "" {
+			opts = append(opts, issues.WithDefaultProject(cfg.GitLabProject))
+		}
+		resolvers = append(resolvers, issues.NewGitLab(cfg.GitLabToken, opts...))
+	}
+	if cfg.LinearAPIKey != "" {
+		resolvers = append(resolvers, issues.NewLinear(cfg.LinearAPIKey, cfg.LinearTeams...))
+	}
+	return issues.NewSet(issues.DefaultCacheTTL, resolvers...)
+}
+
+// tunnelWebhookPath is the webhook path on a tunnel when PUBLIC_WEBHOOK_URL has none
+const tunnelWebhookPath = "/webhook"
+
+// StartTunnel opens the configured tunnel to LISTEN_ADDR and returns it with the webhook URL
+// on it. The caller closes the tunnel after deleting the webhooks.
+func StartTunnel(ctx context.Context, cfg *config.Config) (*tunnel.Tunnel, string, error) {
+	path := tunnelWebhookPath
+	if cfg.PublicWebhookURL != "" {
+		u, err := url.Parse(cfg.PublicWebhookURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid PUBLIC_WEBHOOK_URL: %w", err)
+		}
+		if u.Path != "" {
+			path = u.Path
+		}
+	}
+
+	t, err := tunnel.Start(ctx, tunnel.Provider(cfg.Tunnel), cfg.ListenAddr)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open tunnel: %w", err)
+	}
+	return t, t.URL() + path, nil
+}
+
+// NewWebhookService creates the webhook service for the workspace with the configured
+// events, trigger and token grace period, and the events the mirror follows when it is enabled
+func NewWebhookService(cfg *config.Config, client *clockify.APIClient, workspace clockify.Workspace, url string, opts ...clockify.WebhookServiceOption) *clockify.WorkspaceWebhookService {
+	opts = append([]clockify.WebhookServiceOption{
+		clockify.WithTokenGrace(cfg.WebhookTokenGrace),
+		clockify.WithTrigger(clockify.WebhookTriggerSourceType(cfg.WebhookTrigger), cfg.WebhookTriggerIDs...),
+	}, opts...)
+	if len(cfg.WebhookEvents) > 0 {
+		events := make([]clockify.WebhookEvent, len(cfg.WebhookEvents))
+		for i, event := range cfg.WebhookEvents {
+			events[i] = clockify.WebhookEvent(event)
+		}
+		opts = append(opts, clockify.WithEventSet(events...))
+	}
+	if cfg.DatabaseDSN != "" {
+		// The mirror also follows entry edits and deletions and tag changes
+		opts = append(opts, clockify.WithEvents(mirror.Events...))
+	}
+
+	return clockify.NewWorkspaceWebhookService(client, workspace, url, opts...)
+}
+
+// webhookMarker records the webhooks registered by a running command
+type webhookMarker struct {
+	WorkspaceID string   `json:"workspace_id"`
+	URL         string   `json:"url"`
+	Webhooks    []string `json:"webhooks"`
+}
+
+// RegisterWebhooks creates the webhook service and registers its webhooks as a stage of lc,
+// deleted again on Stop.
+//
+// While the webhooks exist, their IDs are kept in a marker file named after the command and
+// workspace, and their names are tagged with INSTANCE_ID and the command. Webhooks left behind
+// by a previous run that crashed or was killed, recorded or tagged, are deleted before
+// registering new ones.
+func RegisterWebhooks(ctx context.Context, lc *lifecycle.Manager, cfg *config.Config, client *clockify.APIClient, workspace clockify.Workspace, url, command string, opts ...clockify.WebhookServiceOption) (*clockify.WorkspaceWebhookService, error) {
+	marker := lifecycle.NewMarker(lifecycle.MarkerPath("webhooks-" + command + "-" + workspace.ID))
+
+	opts = append(opts, clockify.WithCreateHook(func(created []clockify.Webhook) error {
+		if len(created) == 0 {
+			return marker.Remove()
+		}
+		state := webhookMarker{WorkspaceID: workspace.ID, URL: url}
+		for _, webhook := range created {
+			state.Webhooks = append(state.Webhooks, webhook.ID)
+		}
+		return marker.Save(state)
+	}))
+	// Tells the webhooks of this instance apart from those of others sharing the workspace,
+	// also when the marker is lost, e.g. with a container
+	opts = append(opts, clockify.WithOwner(instanceID(cfg)+"/"+command))
+	service := NewWebhookService(cfg, client, workspace, url, opts...)
+
+	var leftover webhookMarker
+	found, err := marker.Load(&leftover)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := service.DeleteLeftovers(leftover.Webhooks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete webhooks left by a previous run: %w", err)
+	}
+	if found || deleted > 0 {
+		slog.Warn("leftover_webhooks_deleted", "marker", marker.Path(), "recorded", len(leftover.Webhooks), "deleted", deleted)
+	}
+	if found {
+		if err := marker.Remove(); err != nil {
+			return nil, err
+		}
+	}
+
+	err = lc.Setup(ctx, "webhooks", func(context.Context) error {
+		return service.Create()
+	}, func(context.Context) error {
+		// The marker stays when some deletions fail, for the next start to retry them
+		if err := service.Delete(); err != nil {
+			return err
+		}
+		return marker.Remove()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// instanceID returns INSTANCE_ID, the host name by default
+func instanceID(cfg *config.Config) string {
+	if cfg.InstanceID != "" {
+		return cfg.InstanceID
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		slog.Warn("hostname_unavailable", "error", err)
+	}
+	return hostname
+}
+
+// ResolveWorkspace finds the configured workspace among those of the identity: by ID if set,
+// then by name, then the workspace the addon is installed in, then the user's active workspace.
+func ResolveWorkspace(cfg *config.Config, identity *clockify.Identity) (*clockify.Workspace, error) {
+	if cfg.WorkspaceID == "" && cfg.WorkspaceName != "" {
+		for _, ws := range identity.Workspaces {
+			if ws.Name == cfg.WorkspaceName {
+				return &ws, nil
+			}
+		}
+		return nil, fmt.Errorf("workspace '%s' %w", cfg.WorkspaceName, clockify.ErrNotFound)
+	}
+
+	workspaceID := cfg.WorkspaceID
+	if workspaceID == "" && identity.Addon != nil {
+		workspaceID = identity.Addon.WorkspaceID
+	}
+	if workspaceID == "" && identity.User != nil {
+		workspaceID = identity.User.ActiveWorkspace
+		if workspaceID == "" {
+			workspaceID = identity.User.DefaultWorkspace
+		}
+	}
+	if workspaceID == "" {
+		return nil, fmt.Errorf("no workspace configured, set CLOCKIFY_WORKSPACE_NAME or CLOCKIFY_WORKSPACE_ID")
+	}
+
+	for _, ws := range identity.Workspaces {
+		if ws.ID == workspaceID {
+			return &ws, nil
+		}
+	}
+
+	return nil, fmt.Errorf("workspace '%s' not found", workspaceID)
+}
+
+// ResolveTimerDefaults looks up the configured default project, task and tags in the
+// workspace, the ones not configured are left empty
+func ResolveTimerDefaults(client *clockify.APIClient, cfg *config.Config, workspaceID string) (clockify.TimerDefaults, error) {
+	defaults := clockify.TimerDefaults{Billable: cfg.DefaultBillable}
+
+	if cfg.DefaultProject != "" {
+		project, err := client.FindProjectByName(workspaceID, cfg.DefaultProject)
+		if err != nil {
+			return defaults, fmt.Errorf("CLOCKIFY_DEFAULT_PROJECT: %w", err)
+		}
+		defaults.ProjectID = project.ID
+
+		if cfg.DefaultTask != "" {
+			task, err := client.FindTaskByName(workspaceID, project.ID, cfg.DefaultTask)
+			if err != nil {
+				return defaults, fmt.Errorf("CLOCKIFY_DEFAULT_TASK: %w", err)
+			}
+			defaults.TaskID = task.ID
+		}
+	}
+
+	for _, name := range cfg.DefaultTags {
+		tag, err := client.FindTagByName(workspaceID, name)
+		if err != nil {
+			return defaults, fmt.Errorf("CLOCKIFY_DEFAULT_TAGS: %w", err)
+		}
+		defaults.TagIDs = append(defaults.TagIDs, tag.ID)
+	}
+
+	return defaults, nil
+}
+
+// ResolveBillableRules looks up the projects and tags of the configured billable rules in the
+// workspace
+func ResolveBillableRules(client *clockify.APIClient, cfg *config.Config, workspaceID string) (billable.Rules, error) {
+	rules := make(billable.Rules, 0, len(cfg.BillableRules))
+	for i, r := range cfg.BillableRules {
+		rule := billable.Rule{Billable: r.Billable}
+		if r.Project != "" {
+			project, err := client.FindProjectByName(workspaceID, r.Project)
+			if err != nil {
+				return nil, fmt.Errorf("billable_rules[%d].project: %w", i, err)
+			}
+			rule.ProjectID = project.ID
+		}
+		if r.Tag != "" {
+			tag, err := client.FindTagByName(workspaceID, r.Tag)
+			if err != nil {
+				return nil, fmt.Errorf("billable_rules[%d].tag: %w", i, err)
+			}
+			rule.TagID = tag.ID
+		}
+		if r.Match != "" {
+			rule.Description = regexp.MustCompile(r.Match) // validated on load
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// CapacityModel returns the configured weekly capacity of users, reading the Clockify member
+// profiles once resolved when CAPACITY_SOURCE is clockify
+func CapacityModel(cfg *config.Config) capacity.Model {
+	model := capacity.Model{
+		Default:  cfg.WeeklyCapacity,
+		Users:    make(map[string]time.Duration, len(cfg.Capacities)),
+		Profiles: cfg.CapacitySource == config.CapacitySourceClockify,
+	}
+	for _, c := range cfg.Capacities {
+		model.Users[c.User] = c.Weekly
+	}
+	return model
+}
+
+// Rounding returns the rounding of tracked time in reports and invoices
+func Rounding(cfg *config.Config) report.Rounding {
+	return report.Rounding{Increment: cfg.RoundingIncrement, Mode: cfg.RoundingMode, Scope: cfg.RoundingScope}
+}
+
+// ExchangeRates returns the provider of the FX_RATES_URL exchange rates, nil when not configured
+func ExchangeRates(cfg *config.Config) money.Provider {
+	if cfg.FXRatesURL == "" {
+		return nil
+	}
+	return money.NewHTTPProvider(cfg.FXRatesURL, cfg.FXRatesTTL)
+}
+
+// FetchExchangeRates fetches the rates of the provider, nil without one
+func FetchExchangeRates(ctx context.Context, provider money.Provider) (*money.Rates, error) {
+	if provider == nil {
+		return nil, nil
+	}
+	return provider.Rates(ctx)
+}
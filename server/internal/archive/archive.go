@@ -0,0 +1,204 @@
+// Package archive keeps every raw webhook payload in object storage, S3 or Google Cloud
+// Storage, for compliance retention, and restores archived events through the dispatcher.
+//
+// Payloads are buffered and written on each flush as a gzipped JSON Lines object per day they
+// were received on, under webhooks/date=YYYY-MM-DD/ in UTC. How long objects are retained is
+// up to the lifecycle rules of the bucket.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+const (
+	// Dataset is the directory of the payloads under the prefix
+	Dataset = "webhooks"
+
+	dateLayout = "2006-01-02"
+	// maxBufferedRecords flushes the buffer early, bounding the memory of bursts
+	maxBufferedRecords = 10_000
+)
+
+// Record is an archived payload, a line of an object
+type Record struct {
+	Event       clockify.WebhookEvent `json:"event"`
+	WorkspaceID string                `json:"workspaceId"`
+	ReceivedAt  time.Time             `json:"receivedAt"`
+	// The body as Clockify sent it, byte for byte
+	Body string `json:"body"`
+}
+
+// Option configures an Archiver
+type Option func(*Archiver)
+
+// WithPrefix puts the objects under the prefix of the bucket, e.g. ccws/
+func WithPrefix(prefix string) Option {
+	return func(a *Archiver) {
+		a.prefix = prefix
+	}
+}
+
+// Archiver writes the payloads of a workspace to a store. Payloads are buffered in memory
+// between flushes, so those received since the last one are lost if the process is killed.
+type Archiver struct {
+	store       Store
+	workspaceID string
+	prefix      string
+
+	mu     sync.Mutex
+	buffer []Record
+}
+
+// New creates an archiver of the payloads of the workspace
+func New(store Store, workspaceID string, opts ...Option) *Archiver {
+	a := &Archiver{store: store, workspaceID: workspaceID}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Receive buffers the body of a delivery. It is a clockify.WebhookBodyReceiver.
+func (a *Archiver) Receive(ctx context.Context, event clockify.WebhookEvent, body []byte) {
+	record := Record{Event: event, WorkspaceID: a.workspaceID, ReceivedAt: time.Now().UTC(), Body: string(body)}
+
+	a.mu.Lock()
+	a.buffer = append(a.buffer, record)
+	full := len(a.buffer) >= maxBufferedRecords
+	a.mu.Unlock()
+
+	if full {
+		// Not canceled with the delivery it was received in
+		a.flushLogged(context.WithoutCancel(ctx))
+	}
+}
+
+// Flush writes the buffered payloads, returning the keys of the objects written
+func (a *Archiver) Flush(ctx context.Context) ([]string, error) {
+	a.mu.Lock()
+	buffer := a.buffer
+	a.buffer = nil
+	a.mu.Unlock()
+	if len(buffer) == 0 {
+		return nil, nil
+	}
+
+	// Records are buffered in the order received, objects keep it within each day
+	byDate := make(map[string][]Record)
+	var dates []string
+	for _, record := range buffer {
+		date := record.ReceivedAt.Format(dateLayout)
+		if _, ok := byDate[date]; !ok {
+			dates = append(dates, date)
+		}
+		byDate[date] = append(byDate[date], record)
+	}
+
+	// Named by the flush, so that the objects of every flush sort in order
+	name := fmt.Sprintf("%d-%s.jsonl.gz", time.Now().UnixNano(), randomSuffix())
+	var keys []string
+	for i, date := range dates {
+		key := a.datePrefix(date) + name
+		data, err := encode(byDate[date])
+		if err == nil {
+			err = a.store.Put(ctx, key, data)
+		}
+		if err != nil {
+			// Kept for the next flush rather than dropped
+			a.requeue(byDate, dates[i:])
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// requeue puts back the records of the days a flush did not write, ahead of those received
+// since
+func (a *Archiver) requeue(byDate map[string][]Record, dates []string) {
+	var records []Record
+	for _, date := range dates {
+		records = append(records, byDate[date]...)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.buffer = append(records, a.buffer...)
+}
+
+// datePrefix returns the prefix of the objects of a day
+func (a *Archiver) datePrefix(date string) string {
+	return a.prefix + Dataset + "/date=" + date + "/"
+}
+
+// encode writes the records as gzipped JSON Lines
+func encode(records []Record) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	encoder.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// randomSuffix tells apart the objects of replicas flushing at the same time
+func randomSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Run flushes the buffered payloads every interval until the context is done
+func (a *Archiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.flushLogged(ctx)
+		}
+	}
+}
+
+// Close writes the payloads buffered since the last flush, e.g. on shutdown
+func (a *Archiver) Close(ctx context.Context) error {
+	_, err := a.Flush(ctx)
+	return err
+}
+
+func (a *Archiver) flushLogged(ctx context.Context) {
+	keys, err := a.Flush(ctx)
+	if err != nil {
+		slog.Error("webhook_archive_failed", "prefix", a.prefix, "error", err)
+	}
+	for _, key := range keys {
+		slog.Debug("webhook_archive_written", "key", key)
+	}
+}
+
+// isArchiveKey reports whether the key is an object written by an Archiver
+func isArchiveKey(key string) bool {
+	return strings.HasSuffix(key, ".jsonl.gz")
+}
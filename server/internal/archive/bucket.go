@@ -0,0 +1,265 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+const (
+	// GCSEndpoint is the XML API of Google Cloud Storage, which takes S3 requests signed with
+	// HMAC keys
+	GCSEndpoint = "https://storage.googleapis.com"
+
+	bucketAttempts = 3
+	bucketTimeout  = time.Minute
+	amzDateLayout  = "20060102T150405Z"
+)
+
+// ErrNoObject is returned by Get for keys the bucket does not have
+var ErrNoObject = errors.New("no such object")
+
+// Store keeps the archive's objects
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// List returns the keys starting with the prefix, sorted
+	List(ctx context.Context, prefix string) ([]string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Bucket is an S3 bucket, or one of a compatible object storage such as Google Cloud Storage
+// or MinIO, addressed by path and signed with Signature Version 4
+type Bucket struct {
+	endpoint, region, name string
+	accessKey, secretKey   string
+	client                 *http.Client
+}
+
+// NewBucket creates a client of the named bucket at the endpoint, e.g.
+// https://s3.eu-west-1.amazonaws.com or GCSEndpoint with the region "auto"
+func NewBucket(endpoint, region, name, accessKey, secretKey string) *Bucket {
+	return &Bucket{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		region:    region,
+		name:      name,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    clockify.RetryingClient(nil, bucketAttempts, bucketTimeout),
+	}
+}
+
+// S3Endpoint returns the endpoint of Amazon S3 in the region
+func S3Endpoint(region string) string {
+	return fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+}
+
+// Put uploads the object under the key, replacing any
+func (b *Bucket) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := b.do(ctx, http.MethodPut, key, nil, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object under the key
+func (b *Bucket) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := b.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// listResult is the ListObjectsV2 response
+type listResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the keys of the objects starting with the prefix, a page at a time
+func (b *Bucket) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// do sends a signed request for the object under the key, or the bucket without one, failing
+// on statuses other than 200
+func (b *Bucket) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + uriEncode(b.name, false)
+	if key != "" {
+		path += "/" + uriEncode(key, true)
+	}
+	u := b.endpoint + path
+	if len(query) > 0 {
+		u += "?" + canonicalQuery(query)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/gzip")
+	}
+	b.sign(req, path, query, body, time.Now())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var apiErr struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if xml.Unmarshal(data, &apiErr) == nil && apiErr.Code == "NoSuchKey" {
+		return nil, ErrNoObject
+	}
+	if apiErr.Message != "" {
+		return nil, fmt.Errorf("%s: %s: %s", resp.Status, apiErr.Code, apiErr.Message)
+	}
+	return nil, fmt.Errorf("%s", resp.Status)
+}
+
+// sign adds the Authorization header of Signature Version 4 to the request
+func (b *Bucket) sign(req *http.Request, path string, query url.Values, body []byte, now time.Time) {
+	now = now.UTC()
+	date := now.Format(amzDateLayout)
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-date":           date,
+		"x-amz-content-sha256": hex.EncodeToString(payloadHash[:]),
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := now.Format("20060102") + "/" + b.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+b.secretKey), now.Format("20060102"))
+	for _, part := range []string{b.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes the query sorted by name, the way it is signed
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var pairs []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, uriEncode(name, false)+"="+uriEncode(value, false))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes all but the unreserved characters, and the slashes of keys
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
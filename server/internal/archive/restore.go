@@ -0,0 +1,112 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// Restored counts what a restore read and dispatched
+type Restored struct {
+	Objects    int `json:"objects"`
+	Dispatched int `json:"dispatched"`
+	// Events a handler failed on, or whose payload could not be decoded
+	Failed int `json:"failed"`
+}
+
+// Restore reads the payloads archived between from and to, in the order received, and
+// dispatches them again to every handler of the registry, as events of SourceArchive. Only
+// the given event types are restored, all of them without any. Handler failures are counted,
+// the registry logs them and passes them to its failure hook.
+func (a *Archiver) Restore(ctx context.Context, registry *events.Registry, from, to time.Time, types ...clockify.WebhookEvent) (Restored, error) {
+	var restored Restored
+	if !from.Before(to) {
+		return restored, fmt.Errorf("the restored period is empty: %s is not before %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	from, to = from.UTC(), to.UTC()
+	for day := from.Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+		keys, err := a.store.List(ctx, a.datePrefix(day.Format(dateLayout)))
+		if err != nil {
+			return restored, err
+		}
+
+		for _, key := range keys {
+			if !isArchiveKey(key) {
+				continue
+			}
+			records, err := a.read(ctx, key)
+			if err != nil {
+				return restored, err
+			}
+			restored.Objects++
+
+			for _, record := range records {
+				if record.ReceivedAt.Before(from) || !record.ReceivedAt.Before(to) || len(types) > 0 && !slices.Contains(types, record.Event) {
+					continue
+				}
+				if err := ctx.Err(); err != nil {
+					return restored, err
+				}
+
+				payload, err := clockify.DecodePayload(record.Event, []byte(record.Body))
+				if err == nil {
+					err = registry.Dispatch(ctx, events.Event{
+						Type:        record.Event,
+						Source:      events.SourceArchive,
+						WorkspaceID: record.WorkspaceID,
+						Payload:     payload,
+						ReceivedAt:  record.ReceivedAt,
+					})
+				} else {
+					slog.Warn("webhook_restore_skipped", "key", key, "event", record.Event, "error", err)
+				}
+				if err != nil {
+					restored.Failed++
+					continue
+				}
+				restored.Dispatched++
+			}
+		}
+	}
+	slog.Info("webhook_archive_restored", "from", from, "to", to, "objects", restored.Objects, "dispatched", restored.Dispatched, "failed", restored.Failed)
+	return restored, nil
+}
+
+// read decodes the records of an object
+func (a *Archiver) read(ctx context.Context, key string) ([]Record, error) {
+	data, err := a.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", key, err)
+	}
+	defer gz.Close()
+
+	var records []Record
+	decoder := json.NewDecoder(bufio.NewReader(gz))
+	for {
+		var record Record
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", key, err)
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,212 @@
+// Package audit keeps a persistent log of every write CCWS makes to Clockify: who made it,
+// the request and its result, so migrations, automated stops and bulk edits can be reviewed
+// afterwards.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS writes (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	time        INTEGER NOT NULL, -- Unix milliseconds
+	actor       TEXT NOT NULL,
+	method      TEXT NOT NULL,
+	url         TEXT NOT NULL,
+	request     TEXT NOT NULL,
+	status      INTEGER NOT NULL,
+	response    TEXT NOT NULL,
+	error       TEXT NOT NULL,
+	duration_ms INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS writes_time ON writes (time);
+`
+
+type actorKey struct{}
+
+// WithActor returns a context attributing the Clockify writes made with it to actor, e.g.
+// "api" or "job:watchdog"
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor set by WithActor, "" if none is
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Entry is a recorded write
+type Entry struct {
+	ID       int64         `json:"id"`
+	Time     time.Time     `json:"time"`
+	Actor    string        `json:"actor"`
+	Method   string        `json:"method"`
+	URL      string        `json:"url"`
+	Request  string        `json:"request,omitempty"`
+	Status   int           `json:"status"` // 0 if the request failed without a response
+	Response string        `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Failed reports whether Clockify did not apply the write
+func (e Entry) Failed() bool {
+	return e.Error != "" || e.Status == 0 || e.Status >= 400
+}
+
+// Log is the SQLite database of recorded writes. It is safe for concurrent use, also by
+// several processes sharing the file.
+type Log struct {
+	db *sql.DB
+}
+
+// Open opens the log at dsn, a file path or a "file:" URI, creating it if needed
+func Open(dsn string) (*Log, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	// SQLite allows a single writer, serialize on one connection instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode = WAL; PRAGMA busy_timeout = 5000;" + schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create audit log tables: %w", err)
+	}
+
+	return &Log{db: db}, nil
+}
+
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+// Record stores the entry, its ID is assigned by the log
+func (l *Log) Record(entry Entry) error {
+	_, err := l.db.Exec(`
+		INSERT INTO writes (time, actor, method, url, request, status, response, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Time.UnixMilli(), entry.Actor, entry.Method, entry.URL, entry.Request, entry.Status, entry.Response, entry.Error, entry.Duration.Milliseconds())
+	return err
+}
+
+// Middleware records the client's writes, attributed to the context's actor or defaultActor.
+// Failing to record a write is logged, the write itself is not failed.
+func (l *Log) Middleware(defaultActor string) clockify.TransportMiddleware {
+	return clockify.AuditMiddleware(func(ctx context.Context, write clockify.Write) {
+		actor := Actor(ctx)
+		if actor == "" {
+			actor = defaultActor
+		}
+
+		entry := Entry{
+			Time:     write.Started,
+			Actor:    actor,
+			Method:   write.Method,
+			URL:      write.URL,
+			Request:  write.Request,
+			Status:   write.Status,
+			Response: write.Response,
+			Duration: write.Duration,
+		}
+		if write.Err != nil {
+			entry.Error = write.Err.Error()
+		}
+
+		if err := l.Record(entry); err != nil {
+			slog.Warn("audit_record_failed", "method", write.Method, "url", write.URL, "error", err)
+		}
+	})
+}
+
+// Prune deletes the entries recorded before the time, returning how many were deleted
+func (l *Log) Prune(before time.Time) (int64, error) {
+	result, err := l.db.Exec("DELETE FROM writes WHERE time < ?", before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DefaultLimit is how many entries Query returns without a limit
+const DefaultLimit = 100
+
+// Filter selects the entries returned by Query, zero fields match everything
+type Filter struct {
+	Since, Until time.Time
+	Actor        string // Exact match, or a prefix ending with "*", e.g. "job:*"
+	Method       string
+	URL          string // Substring of the URL, e.g. a time entry ID
+	Failed       bool   // Only the writes Clockify did not apply
+	Limit        int    // DefaultLimit if 0
+}
+
+// Query returns the entries matching the filter, newest first
+func (l *Log) Query(filter Filter) ([]Entry, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if !filter.Since.IsZero() {
+		where, args = append(where, "time >= ?"), append(args, filter.Since.UnixMilli())
+	}
+	if !filter.Until.IsZero() {
+		where, args = append(where, "time < ?"), append(args, filter.Until.UnixMilli())
+	}
+	if prefix, ok := strings.CutSuffix(filter.Actor, "*"); ok {
+		where, args = append(where, "substr(actor, 1, ?) = ?"), append(args, len(prefix), prefix)
+	} else if filter.Actor != "" {
+		where, args = append(where, "actor = ?"), append(args, filter.Actor)
+	}
+	if filter.Method != "" {
+		where, args = append(where, "method = ?"), append(args, strings.ToUpper(filter.Method))
+	}
+	if filter.URL != "" {
+		where, args = append(where, "instr(url, ?) > 0"), append(args, filter.URL)
+	}
+	if filter.Failed {
+		where = append(where, "(error != '' OR status = 0 OR status >= 400)")
+	}
+
+	query := "SELECT id, time, actor, method, url, request, status, response, error, duration_ms FROM writes"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	query += " ORDER BY time DESC, id DESC LIMIT ?"
+
+	rows, err := l.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var (
+			e              Entry
+			at, durationMs int64
+		)
+		if err := rows.Scan(&e.ID, &at, &e.Actor, &e.Method, &e.URL, &e.Request, &e.Status, &e.Response, &e.Error, &durationMs); err != nil {
+			return nil, err
+		}
+		e.Time = time.UnixMilli(at)
+		e.Duration = time.Duration(durationMs) * time.Millisecond
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,48 @@
+// Package backup serializes a whole workspace to a versioned JSON archive and restores it
+// into the same or another workspace.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// FormatVersion is the archive format written by Write. Read rejects newer versions.
+const FormatVersion = 1
+
+// Archive is a snapshot of a workspace. IDs are those of the source workspace,
+// restoring maps them to the IDs created in the target.
+type Archive struct {
+	Version     int                  `json:"version"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	Workspace   clockify.Workspace   `json:"workspace"`
+	Clients     []clockify.Client    `json:"clients"`
+	Projects    []clockify.Project   `json:"projects"`
+	Tasks       []clockify.Task      `json:"tasks"`
+	Tags        []clockify.Tag       `json:"tags"`
+	TimeEntries []clockify.TimeEntry `json:"timeEntries"`
+	Webhooks    []clockify.Webhook   `json:"webhooks"`
+}
+
+// Write encodes the archive as indented JSON
+func Write(w io.Writer, archive *Archive) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+// Read decodes an archive, checking its format version
+func Read(r io.Reader) (*Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("failed to decode archive: %w", err)
+	}
+	if archive.Version < 1 || archive.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported archive version %d, expected at most %d", archive.Version, FormatVersion)
+	}
+	return &archive, nil
+}
@@ -0,0 +1,403 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// RestoreOption configures Restore
+type RestoreOption func(*restoreOptions)
+
+type restoreOptions struct {
+	timeEntries  bool
+	webhooks     bool
+	fallbackUser string
+}
+
+// WithoutTimeEntries restores only the clients, projects, tasks, tags and webhooks
+func WithoutTimeEntries() RestoreOption {
+	return func(o *restoreOptions) {
+		o.timeEntries = false
+	}
+}
+
+// WithoutWebhooks does not recreate the archived webhooks
+func WithoutWebhooks() RestoreOption {
+	return func(o *restoreOptions) {
+		o.webhooks = false
+	}
+}
+
+// WithFallbackUser assigns time entries of users missing from the target workspace to userID.
+// Without it such entries are skipped.
+func WithFallbackUser(userID string) RestoreOption {
+	return func(o *restoreOptions) {
+		o.fallbackUser = userID
+	}
+}
+
+// Counts tallies what happened to one kind of archived item
+type Counts struct {
+	Created int // Newly created in the target workspace
+	Reused  int // Already present in the target workspace, matched by name or content
+	Skipped int // Left out, e.g. running timers or entries without a target user
+}
+
+func (c Counts) String() string {
+	return fmt.Sprintf("%d created, %d reused, %d skipped", c.Created, c.Reused, c.Skipped)
+}
+
+// Result reports what a restore did
+type Result struct {
+	Clients     Counts
+	Projects    Counts
+	Tasks       Counts
+	Tags        Counts
+	TimeEntries Counts
+	Webhooks    Counts
+}
+
+// Restore recreates the archive in the target workspace. Items already present there
+// (clients, projects, tags and tasks by name, time entries and webhooks by content) are
+// reused, so restoring the same archive twice creates nothing new.
+//
+// Restore keeps going past individual failures and returns all of them joined.
+func Restore(client *clockify.APIClient, archive *Archive, workspaceID string, opts ...RestoreOption) (*Result, error) {
+	options := restoreOptions{timeEntries: true, webhooks: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	r := &restorer{
+		client:      client,
+		workspaceID: workspaceID,
+		archive:     archive,
+		options:     options,
+		clientIDs:   make(map[string]string),
+		projectIDs:  make(map[string]string),
+		taskIDs:     make(map[string]string),
+		tagIDs:      make(map[string]string),
+	}
+
+	// Order matters, each step maps the IDs the next ones refer to
+	steps := []func() error{r.restoreClients, r.restoreTags, r.restoreProjects, r.restoreTasks}
+	if options.timeEntries {
+		steps = append(steps, r.restoreTimeEntries)
+	}
+	if options.webhooks {
+		steps = append(steps, r.restoreWebhooks)
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			r.errs = append(r.errs, err)
+		}
+	}
+
+	return &r.result, errors.Join(r.errs...)
+}
+
+// restorer holds the state of a single restore
+type restorer struct {
+	client      *clockify.APIClient
+	workspaceID string
+	archive     *Archive
+	options     restoreOptions
+
+	// Archived ID -> target ID
+	clientIDs  map[string]string
+	projectIDs map[string]string
+	taskIDs    map[string]string
+	tagIDs     map[string]string
+
+	result Result
+	errs   []error
+}
+
+func (r *restorer) restoreClients() error {
+	existing, err := clockify.Collect(r.client.IterClients(r.workspaceID))
+	if err != nil {
+		return fmt.Errorf("failed to read target clients: %w", err)
+	}
+	byName := indexByName(existing, func(c clockify.Client) string { return c.Name }, func(c clockify.Client) string { return c.ID })
+
+	for _, archived := range r.archive.Clients {
+		if id, ok := byName[archived.Name]; ok {
+			r.clientIDs[archived.ID] = id
+			r.result.Clients.Reused++
+			continue
+		}
+
+		created, err := r.client.CreateClient(r.workspaceID, archived.Name)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("failed to create client '%s': %w", archived, err))
+			continue
+		}
+		r.clientIDs[archived.ID] = created.ID
+		r.result.Clients.Created++
+	}
+	return nil
+}
+
+func (r *restorer) restoreTags() error {
+	existing, err := clockify.Collect(r.client.IterTags(r.workspaceID))
+	if err != nil {
+		return fmt.Errorf("failed to read target tags: %w", err)
+	}
+	byName := indexByName(existing, func(t clockify.Tag) string { return t.Name }, func(t clockify.Tag) string { return t.ID })
+
+	for _, archived := range r.archive.Tags {
+		if id, ok := byName[archived.Name]; ok {
+			r.tagIDs[archived.ID] = id
+			r.result.Tags.Reused++
+			continue
+		}
+
+		created, err := r.client.CreateTag(r.workspaceID, archived.Name)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("failed to create tag '%s': %w", archived, err))
+			continue
+		}
+		r.tagIDs[archived.ID] = created.ID
+		r.result.Tags.Created++
+	}
+	return nil
+}
+
+func (r *restorer) restoreProjects() error {
+	existing, err := clockify.Collect(r.client.IterProjects(r.workspaceID))
+	if err != nil {
+		return fmt.Errorf("failed to read target projects: %w", err)
+	}
+	byName := indexByName(existing, func(p clockify.Project) string { return p.Name }, func(p clockify.Project) string { return p.ID })
+
+	for _, archived := range r.archive.Projects {
+		if id, ok := byName[archived.Name]; ok {
+			r.projectIDs[archived.ID] = id
+			r.result.Projects.Reused++
+			continue
+		}
+
+		created, err := r.client.CreateProjectFromRequest(r.workspaceID, clockify.ProjectCreateRequest{
+			Name:     archived.Name,
+			ClientID: r.clientIDs[archived.ClientID],
+			Billable: archived.Billable,
+			Public:   archived.Public,
+			Color:    archived.Color,
+			Note:     archived.Note,
+		})
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("failed to create project '%s': %w", archived, err))
+			continue
+		}
+		r.projectIDs[archived.ID] = created.ID
+		r.result.Projects.Created++
+	}
+	return nil
+}
+
+func (r *restorer) restoreTasks() error {
+	// Existing tasks of each target project, looked up lazily
+	existing := make(map[string]map[string]string)
+
+	for _, archived := range r.archive.Tasks {
+		projectID, ok := r.projectIDs[archived.ProjectID]
+		if !ok {
+			r.result.Tasks.Skipped++
+			continue
+		}
+
+		if _, ok := existing[projectID]; !ok {
+			tasks, err := clockify.Collect(r.client.IterProjectTasks(r.workspaceID, projectID))
+			if err != nil {
+				r.errs = append(r.errs, fmt.Errorf("failed to read target tasks of project %s: %w", projectID, err))
+				r.result.Tasks.Skipped++
+				continue
+			}
+			existing[projectID] = indexByName(tasks, func(t clockify.Task) string { return t.Name }, func(t clockify.Task) string { return t.ID })
+		}
+
+		if id, ok := existing[projectID][archived.Name]; ok {
+			r.taskIDs[archived.ID] = id
+			r.result.Tasks.Reused++
+			continue
+		}
+
+		created, err := r.client.CreateTask(r.workspaceID, projectID, archived.Name)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("failed to create task '%s': %w", archived, err))
+			continue
+		}
+		existing[projectID][created.Name] = created.ID
+		r.taskIDs[archived.ID] = created.ID
+		r.result.Tasks.Created++
+	}
+	return nil
+}
+
+// entryKey identifies a time entry by content, to recognize entries restored before
+type entryKey struct {
+	userID      string
+	start, end  time.Time
+	description string
+}
+
+func (r *restorer) restoreTimeEntries() error {
+	users, err := clockify.Collect(r.client.IterWorkspaceUsers(r.workspaceID))
+	if err != nil {
+		return fmt.Errorf("failed to read target users: %w", err)
+	}
+	members := make(map[string]bool, len(users))
+	for _, user := range users {
+		members[user.ID] = true
+	}
+
+	// Existing entries of each target user, looked up lazily
+	existing := make(map[string]map[entryKey]bool)
+
+	for _, archived := range r.archive.TimeEntries {
+		interval := archived.TimeInterval
+		if interval == nil || interval.End == nil {
+			// Running timers are not restored, they would stop the user's current one
+			r.result.TimeEntries.Skipped++
+			continue
+		}
+
+		userID := archived.UserID
+		if !members[userID] {
+			userID = r.options.fallbackUser
+		}
+		if userID == "" {
+			r.result.TimeEntries.Skipped++
+			continue
+		}
+
+		if _, ok := existing[userID]; !ok {
+			entries, err := clockify.Collect(r.client.IterTimeEntries(r.workspaceID, userID, nil, nil))
+			if err != nil {
+				r.errs = append(r.errs, fmt.Errorf("failed to read target time entries of user %s: %w", userID, err))
+				r.result.TimeEntries.Skipped++
+				continue
+			}
+			keys := make(map[entryKey]bool, len(entries))
+			for _, entry := range entries {
+				if entry.TimeInterval != nil && entry.TimeInterval.End != nil {
+					keys[keyOf(userID, entry)] = true
+				}
+			}
+			existing[userID] = keys
+		}
+
+		key := keyOf(userID, archived)
+		if existing[userID][key] {
+			r.result.TimeEntries.Reused++
+			continue
+		}
+
+		request := clockify.NewTimeEntryRequest{
+			Start:       interval.Start,
+			End:         interval.End,
+			Billable:    archived.Billable,
+			Description: archived.Description,
+			ProjectID:   r.projectIDs[archived.ProjectID],
+			TaskID:      r.taskIDs[archived.TaskID],
+		}
+		for _, tagID := range archived.TagIDs {
+			if id, ok := r.tagIDs[tagID]; ok {
+				request.TagIDs = append(request.TagIDs, id)
+			}
+		}
+
+		if _, err := r.client.CreateTimeEntryForUser(r.workspaceID, userID, request); err != nil {
+			r.errs = append(r.errs, fmt.Errorf("failed to create time entry '%s': %w", archived, err))
+			continue
+		}
+		existing[userID][key] = true
+		r.result.TimeEntries.Created++
+	}
+	return nil
+}
+
+func keyOf(userID string, entry clockify.TimeEntry) entryKey {
+	return entryKey{
+		userID:      userID,
+		start:       entry.TimeInterval.Start.UTC(),
+		end:         entry.TimeInterval.End.UTC(),
+		description: entry.Description,
+	}
+}
+
+// triggerID maps an archived webhook trigger source ID to the target workspace
+func (r *restorer) triggerID(sourceType clockify.WebhookTriggerSourceType, id string) string {
+	var ids map[string]string
+	switch sourceType {
+	case clockify.WorkspaceIDTrigger:
+		if id == r.archive.Workspace.ID {
+			return r.workspaceID
+		}
+	case clockify.ProjectIDTrigger:
+		ids = r.projectIDs
+	case clockify.TagIDTrigger:
+		ids = r.tagIDs
+	case clockify.TaskIDTrigger:
+		ids = r.taskIDs
+	}
+	if mapped, ok := ids[id]; ok {
+		return mapped
+	}
+	return id
+}
+
+func (r *restorer) restoreWebhooks() error {
+	existing, err := r.client.GetWebhooks(r.workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to read target webhooks: %w", err)
+	}
+	present := make(map[string]bool, len(existing))
+	for _, webhook := range existing {
+		present[string(webhook.Event)+" "+webhook.TargetURL] = true
+	}
+
+	for _, archived := range r.archive.Webhooks {
+		if present[string(archived.Event)+" "+archived.TargetURL] {
+			r.result.Webhooks.Reused++
+			continue
+		}
+
+		// Triggers referring to the archived workspace, projects or tags are rewritten
+		// to their counterparts in the target workspace
+		triggerSource := make([]string, len(archived.TriggerSource))
+		for i, source := range archived.TriggerSource {
+			triggerSource[i] = r.triggerID(archived.TriggerSourceType, source)
+		}
+
+		created, err := r.client.CreateWebhook(r.workspaceID, clockify.WebhookRequest{
+			Name:              archived.Name,
+			TriggerSource:     triggerSource,
+			TriggerSourceType: archived.TriggerSourceType,
+			TargetURL:         archived.TargetURL,
+			Event:             archived.Event,
+		})
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("failed to create webhook '%s': %w", archived.Name, err))
+			continue
+		}
+		slog.Info("webhook_restored", "webhook_id", created.ID, "event", created.Event, "url", created.TargetURL)
+		r.result.Webhooks.Created++
+	}
+	return nil
+}
+
+// indexByName maps names to IDs, keeping the first item of each name
+func indexByName[T any](items []T, name, id func(T) string) map[string]string {
+	index := make(map[string]string, len(items))
+	for _, item := range items {
+		if _, ok := index[name(item)]; !ok {
+			index[name(item)] = id(item)
+		}
+	}
+	return index
+}
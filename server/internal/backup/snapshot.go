@@ -0,0 +1,79 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// SnapshotOption configures Snapshot
+type SnapshotOption func(*snapshotOptions)
+
+type snapshotOptions struct {
+	userIDs []string
+}
+
+// WithUsers limits the time entries to those of the given users. By default the entries
+// of every workspace user are included, which requires admin rights.
+func WithUsers(userIDs ...string) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.userIDs = userIDs
+	}
+}
+
+// Snapshot reads the whole workspace into an archive
+func Snapshot(client *clockify.APIClient, workspace clockify.Workspace, opts ...SnapshotOption) (*Archive, error) {
+	var options snapshotOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	archive := &Archive{
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Workspace: workspace,
+	}
+
+	var err error
+	if archive.Clients, err = clockify.Collect(client.IterClients(workspace.ID)); err != nil {
+		return nil, fmt.Errorf("failed to read clients: %w", err)
+	}
+	if archive.Projects, err = clockify.Collect(client.IterProjects(workspace.ID)); err != nil {
+		return nil, fmt.Errorf("failed to read projects: %w", err)
+	}
+	for _, project := range archive.Projects {
+		tasks, err := clockify.Collect(client.IterProjectTasks(workspace.ID, project.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tasks of project '%s': %w", project, err)
+		}
+		archive.Tasks = append(archive.Tasks, tasks...)
+	}
+	if archive.Tags, err = clockify.Collect(client.IterTags(workspace.ID)); err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	userIDs := options.userIDs
+	if userIDs == nil {
+		users, err := clockify.Collect(client.IterWorkspaceUsers(workspace.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read users: %w", err)
+		}
+		for _, user := range users {
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+	for _, userID := range userIDs {
+		entries, err := clockify.Collect(client.IterTimeEntries(workspace.ID, userID, nil, nil))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read time entries of user %s: %w", userID, err)
+		}
+		archive.TimeEntries = append(archive.TimeEntries, entries...)
+	}
+
+	if archive.Webhooks, err = client.GetWebhooks(workspace.ID); err != nil {
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,116 @@
+// Package billable reclassifies time entries as billable or not by rules on their project,
+// tags and description, fixing the entries tracked with the wrong billable flag, e.g. by
+// timers started billable on internal projects.
+package billable
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// Rule sets the billable flag of the entries having every criterion set
+type Rule struct {
+	ProjectID   string
+	TagID       string
+	Description *regexp.Regexp
+	Billable    bool
+}
+
+// Matches reports whether the entry has every criterion of the rule
+func (r Rule) Matches(entry clockify.TimeEntry) bool {
+	if r.ProjectID != "" && entry.ProjectID != r.ProjectID {
+		return false
+	}
+	if r.TagID != "" && !slices.Contains(entry.TagIDs, r.TagID) {
+		return false
+	}
+	if r.Description != nil && !r.Description.MatchString(entry.Description) {
+		return false
+	}
+	return true
+}
+
+// Rules are checked in order, the first one matching an entry applies
+type Rules []Rule
+
+// Classify returns whether the entry is billable by the first rule matching it, ok is false
+// when none does
+func (rs Rules) Classify(entry clockify.TimeEntry) (billable, ok bool) {
+	for _, rule := range rs {
+		if rule.Matches(entry) {
+			return rule.Billable, true
+		}
+	}
+	return false, false
+}
+
+// Split returns the entries the rules flip to billable and those they flip to non-billable.
+// Entries no rule matches, or already classified as the rule says, are left out.
+func (rs Rules) Split(entries []clockify.TimeEntry) (toBillable, toNonBillable []clockify.TimeEntry) {
+	for _, entry := range entries {
+		billable, ok := rs.Classify(entry)
+		switch {
+		case !ok || billable == entry.Billable:
+		case billable:
+			toBillable = append(toBillable, entry)
+		default:
+			toNonBillable = append(toNonBillable, entry)
+		}
+	}
+	return toBillable, toNonBillable
+}
+
+// Apply reclassifies the entries by the rules, continuing past failures. It returns how many
+// were updated along with every error.
+func (rs Rules) Apply(client *clockify.APIClient, workspaceID string, entries []clockify.TimeEntry) (int, error) {
+	toBillable, toNonBillable := rs.Split(entries)
+	billable, errBillable := client.SetBillable(workspaceID, toBillable, true)
+	nonBillable, errNonBillable := client.SetBillable(workspaceID, toNonBillable, false)
+	return billable + nonBillable, errors.Join(errBillable, errNonBillable)
+}
+
+// Reclassifier applies the rules to the time entries of events as they are tracked
+type Reclassifier struct {
+	client      *clockify.APIClient
+	workspaceID string
+	rules       Rules
+}
+
+func NewReclassifier(client *clockify.APIClient, workspaceID string, rules Rules) *Reclassifier {
+	return &Reclassifier{client: client, workspaceID: workspaceID, rules: rules}
+}
+
+// Handle reclassifies the time entry of the event, it is an events.HandlerFunc. The update
+// it makes raises another event, which finds the entry classified already.
+func (r *Reclassifier) Handle(ctx context.Context, event events.Event) error {
+	entry, ok := event.Payload.(*clockify.TimeEntry)
+	if !ok {
+		return nil
+	}
+	billable, ok := r.rules.Classify(*entry)
+	if !ok || billable == entry.Billable {
+		return nil
+	}
+
+	if _, err := r.client.WithContext(ctx).SetBillable(r.workspaceID, []clockify.TimeEntry{*entry}, billable); err != nil {
+		return err
+	}
+	slog.Info("billable_reclassified", "entry_id", entry.ID, "user_id", entry.UserID, "billable", billable)
+	return nil
+}
+
+// Subscribe reclassifies time entries whenever they are started, tracked or changed
+func (r *Reclassifier) Subscribe(registry *events.Registry) {
+	registry.On("billable", r.Handle,
+		clockify.NewTimerStartedEvent,
+		clockify.TimerStoppedEvent,
+		clockify.NewTimeEntryEvent,
+		clockify.TimeEntryUpdatedEvent,
+	)
+}
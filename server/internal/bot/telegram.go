@@ -0,0 +1,180 @@
+// Package bot lets users control and monitor Clockify from a Telegram chat.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/audit"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/notify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+const (
+	pollTimeout = 30 * time.Second
+	// retryDelay is the pause after a failed poll, so outages do not turn into a busy loop
+	retryDelay = 5 * time.Second
+)
+
+const helpText = `/start_timer [description] - start a timer
+/stop - stop the running timer
+/today - time tracked today
+/status - the running timer`
+
+// TelegramBot answers commands sent to the bot. Only messages from the notification chat
+// are handled, since the bot acts with the configured user's API key.
+type TelegramBot struct {
+	telegram  *notify.Telegram
+	client    *clockify.APIClient
+	workspace *clockify.Workspace
+	user      *clockify.User
+}
+
+func NewTelegramBot(telegram *notify.Telegram, client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User) *TelegramBot {
+	return &TelegramBot{telegram: telegram, client: client, workspace: workspace, user: user}
+}
+
+// Run polls for commands until ctx is done
+func (b *TelegramBot) Run(ctx context.Context) {
+	slog.Info("telegram_bot_started", "chat_id", b.telegram.ChatID())
+
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := b.telegram.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			slog.Warn("telegram_poll_failed", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.ID + 1
+			if update.Message == nil {
+				continue
+			}
+
+			chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
+			if chatID != b.telegram.ChatID() {
+				slog.Warn("telegram_message_from_unknown_chat", "chat_id", chatID)
+				continue
+			}
+
+			reply := b.handle(ctx, update.Message.Text)
+			if reply == "" {
+				continue
+			}
+			if err := b.telegram.SendHTML(ctx, chatID, reply); err != nil {
+				slog.Error("telegram_reply_failed", "error", err)
+			}
+		}
+	}
+
+	slog.Info("telegram_bot_stopped")
+}
+
+// handle runs a command and returns the HTML reply, or "" for messages that are not commands
+func (b *TelegramBot) handle(ctx context.Context, text string) string {
+	command, args, _ := strings.Cut(strings.TrimSpace(text), " ")
+	if !strings.HasPrefix(command, "/") {
+		return ""
+	}
+	// In groups commands are addressed as /command@bot_name
+	command, _, _ = strings.Cut(command, "@")
+
+	client := b.client.WithContext(audit.WithActor(ctx, "telegram"))
+	var (
+		reply string
+		err   error
+	)
+	switch command {
+	case "/start_timer":
+		reply, err = b.startTimer(client, strings.TrimSpace(args))
+	case "/stop":
+		reply, err = b.stop(client)
+	case "/today":
+		reply, err = b.today(client)
+	case "/status":
+		reply, err = b.status(client)
+	case "/start", "/help":
+		reply = html.EscapeString(helpText)
+	default:
+		reply = "Unknown command\n" + html.EscapeString(helpText)
+	}
+
+	if err != nil {
+		slog.Error("telegram_command_failed", "command", command, "error", err)
+		return "Failed: " + html.EscapeString(err.Error())
+	}
+	return reply
+}
+
+func (b *TelegramBot) startTimer(client *clockify.APIClient, description string) (string, error) {
+	entry, err := client.StartTimer(b.workspace.ID, b.user.ID, description, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Started <b>%s</b>", html.EscapeString(entry.String())), nil
+}
+
+func (b *TelegramBot) stop(client *clockify.APIClient) (string, error) {
+	running, err := client.GetRunningTimeEntry(b.workspace.ID, b.user.ID)
+	if err != nil {
+		return "", err
+	}
+	if running == nil {
+		return "No timer is running", nil
+	}
+
+	now := time.Now()
+	if _, err := client.StopTimeEntry(b.workspace.ID, b.user.ID, now); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Stopped <b>%s</b> after %s",
+		html.EscapeString(running.String()), report.FormatDuration(now.Sub(running.TimeInterval.Start))), nil
+}
+
+func (b *TelegramBot) status(client *clockify.APIClient) (string, error) {
+	running, err := client.GetRunningTimeEntry(b.workspace.ID, b.user.ID)
+	if err != nil {
+		return "", err
+	}
+	if running == nil {
+		return "No timer is running", nil
+	}
+	return fmt.Sprintf("Running <b>%s</b> for %s",
+		html.EscapeString(running.String()), report.FormatDuration(time.Since(running.TimeInterval.Start))), nil
+}
+
+func (b *TelegramBot) today(client *clockify.APIClient) (string, error) {
+	now := time.Now()
+	period := report.Day(now)
+
+	entries, err := report.FetchEntries(client, b.workspace.ID, b.user.ID, period)
+	if err != nil {
+		return "", err
+	}
+	names, err := report.ProjectNames(client, b.workspace.ID)
+	if err != nil {
+		return "", err
+	}
+	summary := report.Summarize(period, entries, names, now)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>Today: %s</b>", report.FormatDuration(summary.Total))
+	for _, project := range summary.Projects {
+		fmt.Fprintf(&sb, "\n%s  %s", report.FormatDuration(project.Duration), html.EscapeString(project.Project))
+	}
+	return sb.String(), nil
+}
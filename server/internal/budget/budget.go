@@ -0,0 +1,349 @@
+// Package budget tracks the hours consumed by projects against their estimates or configured
+// caps and raises an event whenever a project crosses one of the alert thresholds.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Period is the span a cap applies to, hours are counted from the start of the current one
+type Period string
+
+const (
+	Total   Period = "total"
+	Monthly Period = "monthly"
+	Weekly  Period = "weekly"
+)
+
+// Start returns the start of the current period, nil for Total
+func (p Period) Start(now time.Time) *time.Time {
+	var start time.Time
+	switch p {
+	case Monthly:
+		start = report.Month(now, 0).Start
+	case Weekly:
+		start = report.Week(now, 0).Start
+	default:
+		return nil
+	}
+	return &start
+}
+
+// Cap limits the hours tracked on a project
+type Cap struct {
+	Project string // Project name
+	Limit   time.Duration
+	Period  Period
+}
+
+// Alert is the payload of events.BudgetThresholdEvent
+type Alert struct {
+	ProjectID string
+	Project   string
+	Consumed  time.Duration
+	Limit     time.Duration
+	Period    Period
+	Threshold int // The crossed threshold, in percent
+}
+
+// EventSubject names the project of the alert as the subject of its event
+func (a *Alert) EventSubject() events.Subject {
+	return events.Subject{Kind: events.SubjectProject, ID: a.ProjectID, ProjectID: a.ProjectID}
+}
+
+// Percent returns the share of the limit consumed
+func (a Alert) Percent() float64 {
+	return float64(a.Consumed) / float64(a.Limit) * 100
+}
+
+// Option configures a Monitor
+type Option func(*Monitor)
+
+// WithCaps adds caps by project name, they take precedence over the projects' estimates
+func WithCaps(caps ...Cap) Option {
+	return func(m *Monitor) {
+		m.caps = append(m.caps, caps...)
+	}
+}
+
+// WithoutEstimates ignores the time estimates set on projects in Clockify
+func WithoutEstimates() Option {
+	return func(m *Monitor) {
+		m.estimates = false
+	}
+}
+
+// WithUsers counts only the hours of the given users. By default the hours of every
+// workspace user are counted, which requires admin rights.
+func WithUsers(userIDs ...string) Option {
+	return func(m *Monitor) {
+		m.userIDs = userIDs
+	}
+}
+
+// Monitor checks project budgets on every Check and on time entry events
+type Monitor struct {
+	client      *clockify.APIClient
+	registry    *events.Registry
+	workspaceID string
+	thresholds  []int
+	caps        []Cap
+	estimates   bool
+	userIDs     []string
+
+	mu sync.Mutex
+	// Highest threshold alerted per project in its current period
+	alerted map[string]alertState
+}
+
+type alertState struct {
+	period    time.Time
+	threshold int
+}
+
+// project is a project with its resolved cap
+type project struct {
+	id, name string
+	cap      Cap
+}
+
+// New creates a monitor dispatching events.BudgetThresholdEvent when a project crosses one of
+// the thresholds, given in percent of its cap
+func New(client *clockify.APIClient, registry *events.Registry, workspaceID string, thresholds []int, opts ...Option) *Monitor {
+	m := &Monitor{
+		client:      client,
+		registry:    registry,
+		workspaceID: workspaceID,
+		thresholds:  slices.Sorted(slices.Values(thresholds)),
+		estimates:   true,
+		alerted:     make(map[string]alertState),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Check evaluates the budgets of every capped project. It is a scheduler.JobFunc.
+func (m *Monitor) Check(ctx context.Context) error {
+	return m.check(ctx, "")
+}
+
+// Handle evaluates the budget of the project of a time entry event, it is an events.HandlerFunc
+func (m *Monitor) Handle(ctx context.Context, event events.Event) error {
+	entry, ok := event.Payload.(*clockify.TimeEntry)
+	if !ok || entry.ProjectID == "" {
+		return nil
+	}
+	return m.check(ctx, entry.ProjectID)
+}
+
+// Subscribe evaluates budgets whenever time entries are tracked or changed
+func (m *Monitor) Subscribe(registry *events.Registry) {
+	registry.On("budget", m.Handle,
+		clockify.TimerStoppedEvent,
+		clockify.NewTimeEntryEvent,
+		clockify.TimeEntryUpdatedEvent,
+		clockify.TimeEntryDeletedEvent,
+	)
+}
+
+// check evaluates the capped projects, only projectID when it is not empty
+func (m *Monitor) check(ctx context.Context, projectID string) error {
+	client := m.client.WithContext(ctx)
+
+	projects, err := m.projects(client)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project budgets: %w", err)
+	}
+	if projectID != "" {
+		projects = slices.DeleteFunc(projects, func(p project) bool { return p.id != projectID })
+	}
+	if len(projects) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	consumed, err := m.consumed(client, projects, now)
+	if err != nil {
+		return fmt.Errorf("failed to count tracked hours: %w", err)
+	}
+
+	for _, p := range projects {
+		alert := Alert{
+			ProjectID: p.id,
+			Project:   p.name,
+			Consumed:  consumed[p.id],
+			Limit:     p.cap.Limit,
+			Period:    p.cap.Period,
+		}
+		if m.crossed(&alert, now) {
+			slog.Info("budget_threshold_reached", "project", p.name, "threshold", alert.Threshold, "consumed", alert.Consumed.Round(time.Minute), "limit", alert.Limit)
+			m.registry.Dispatch(ctx, events.Event{
+				Type:        events.BudgetThresholdEvent,
+				Source:      events.SourceCCWS,
+				WorkspaceID: m.workspaceID,
+				Payload:     &alert,
+				ReceivedAt:  now,
+			})
+		}
+	}
+	return nil
+}
+
+// projects resolves the caps of the workspace projects, configured caps win over estimates
+func (m *Monitor) projects(client *clockify.APIClient) ([]project, error) {
+	byName := make(map[string]Cap, len(m.caps))
+	for _, c := range m.caps {
+		byName[c.Project] = c
+	}
+
+	var projects []project
+	var errs []error
+	for page, err := range client.IterProjects(m.workspaceID) {
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page {
+			if c, ok := byName[p.Name]; ok {
+				delete(byName, p.Name)
+				projects = append(projects, project{id: p.ID, name: p.Name, cap: c})
+				continue
+			}
+			if !m.estimates || p.Archived {
+				continue
+			}
+			if c, ok, err := estimateCap(p); err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				projects = append(projects, project{id: p.ID, name: p.Name, cap: c})
+			}
+		}
+	}
+
+	for name := range byName {
+		slog.Warn("budget_project_not_found", "project", name)
+	}
+	if err := errors.Join(errs...); err != nil {
+		slog.Warn("budget_estimates_skipped", "error", err)
+	}
+	return projects, nil
+}
+
+// estimateCap converts an active Clockify time estimate into a cap
+func estimateCap(p clockify.Project) (Cap, bool, error) {
+	if p.TimeEstimate == nil || !p.TimeEstimate.Active {
+		return Cap{}, false, nil
+	}
+	limit, err := p.TimeEstimate.Duration()
+	if err != nil {
+		return Cap{}, false, fmt.Errorf("project %s: %w", p.Name, err)
+	}
+	if limit <= 0 {
+		return Cap{}, false, nil
+	}
+
+	period := Total
+	if p.TimeEstimate.ResetOption == clockify.EstimateResetMonthly {
+		period = Monthly
+	}
+	return Cap{Project: p.Name, Limit: limit, Period: period}, true, nil
+}
+
+// consumed sums the time tracked on each project in its current period
+func (m *Monitor) consumed(client *clockify.APIClient, projects []project, now time.Time) (map[string]time.Duration, error) {
+	starts := make(map[string]*time.Time, len(projects))
+	// Fetch once from the earliest period start, nil (everything) if any cap is a total
+	var since *time.Time
+	for i, p := range projects {
+		start := p.cap.Period.Start(now)
+		starts[p.id] = start
+		if i == 0 || since != nil && (start == nil || start.Before(*since)) {
+			since = start
+		}
+	}
+
+	userIDs, err := m.users(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	consumed := make(map[string]time.Duration, len(projects))
+	for _, userID := range userIDs {
+		for entries, err := range client.IterTimeEntries(m.workspaceID, userID, since, nil) {
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				start, capped := starts[entry.ProjectID]
+				if !capped || entry.TimeInterval == nil || entry.IsBreak() {
+					continue
+				}
+				if start != nil && entry.TimeInterval.Start.Before(*start) {
+					continue
+				}
+				consumed[entry.ProjectID] += report.EntryDuration(entry, now)
+			}
+		}
+	}
+	return consumed, nil
+}
+
+func (m *Monitor) users(client *clockify.APIClient) ([]string, error) {
+	if m.userIDs != nil {
+		return m.userIDs, nil
+	}
+
+	var ids []string
+	for users, err := range client.IterWorkspaceUsers(m.workspaceID) {
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+// crossed sets the highest threshold reached by the alert and reports whether it is newly
+// crossed in the current period. Dropping below a threshold, e.g. after deleting entries,
+// re-arms it.
+func (m *Monitor) crossed(alert *Alert, now time.Time) bool {
+	percent := alert.Percent()
+	reached := 0
+	for _, threshold := range m.thresholds {
+		if percent >= float64(threshold) {
+			reached = threshold
+		}
+	}
+
+	var period time.Time
+	if start := alert.Period.Start(now); start != nil {
+		period = *start
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.alerted[alert.ProjectID]
+	if !state.period.Equal(period) {
+		state = alertState{period: period}
+	}
+	newly := reached > state.threshold
+	state.threshold = reached
+	m.alerted[alert.ProjectID] = state
+
+	alert.Threshold = reached
+	return newly
+}
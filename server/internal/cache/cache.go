@@ -0,0 +1,80 @@
+// Package cache provides an in-memory, concurrency-safe cache for Clockify data that is
+// read far more often than it changes.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type item[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// Cache maps keys to values that expire after a fixed TTL
+type Cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[K]item[V]
+}
+
+// New creates a cache whose values expire ttl after being stored
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{ttl: ttl, items: make(map[K]item[V])}
+}
+
+// Get returns the value stored under key, if it has not expired yet
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	it, ok := c.items[key]
+	if !ok || time.Now().After(it.expires) {
+		delete(c.items, key)
+		var zero V
+		return zero, false
+	}
+	return it.value, true
+}
+
+// Set stores the value under key
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items[key] = item[V]{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+// Invalidate removes the value stored under key
+func (c *Cache[K, V]) Invalidate(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+}
+
+// Clear removes all values
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.items)
+}
+
+// GetOrLoad returns the cached value or loads and stores it. Errors are not cached.
+//
+// Concurrent misses for the same key may load it more than once.
+func (c *Cache[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.Set(key, value)
+	return value, nil
+}
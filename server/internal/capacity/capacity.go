@@ -0,0 +1,146 @@
+// Package capacity models the time users are contracted for per week and compares it with
+// the time they logged.
+package capacity
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Model is the weekly capacity of users, spread over the weekdays
+type Model struct {
+	// Capacity of the users without one of their own
+	Default time.Duration
+	// Capacity by user ID, email or name, e.g. from the config file
+	Users map[string]time.Duration
+	// Take the capacity of the users not in Users from their Clockify member profiles, once
+	// resolved by Resolve
+	Profiles bool
+}
+
+// Weekly returns the capacity of the user, looked up by ID, then email, then name
+func (m Model) Weekly(user clockify.User) time.Duration {
+	if weekly, ok := m.lookup(user); ok {
+		return weekly
+	}
+	return m.Default
+}
+
+func (m Model) lookup(user clockify.User) (time.Duration, bool) {
+	for _, key := range []string{user.ID, user.Email, user.Name} {
+		if weekly, ok := m.Users[key]; key != "" && ok {
+			return weekly, true
+		}
+	}
+	return 0, false
+}
+
+// For returns the capacity of the user in the period, counting its weekdays up to and
+// including today, so the current week is not penalized for the days still ahead
+func (m Model) For(user clockify.User, period report.Period, now time.Time) time.Duration {
+	return m.Weekly(user) / 5 * time.Duration(Workdays(period, now))
+}
+
+// Resolve returns the model with the work capacity of the Clockify member profile of each
+// user not in Users, the model itself unless Profiles is set. Users whose profile sets no
+// capacity keep the default.
+func (m Model) Resolve(client *clockify.APIClient, workspaceID string, users []clockify.User) (Model, error) {
+	if !m.Profiles {
+		return m, nil
+	}
+
+	resolved := Model{Default: m.Default, Users: make(map[string]time.Duration, len(m.Users)+len(users))}
+	for key, weekly := range m.Users {
+		resolved.Users[key] = weekly
+	}
+
+	for _, user := range users {
+		if _, ok := m.lookup(user); ok {
+			continue
+		}
+		profile, err := client.GetMemberProfile(workspaceID, user.ID)
+		if err != nil {
+			return m, fmt.Errorf("failed to fetch the member profile of %s: %w", user, err)
+		}
+		weekly, err := profile.WeeklyCapacity()
+		if err != nil {
+			return m, fmt.Errorf("member profile of %s: %w", user, err)
+		}
+		if weekly > 0 {
+			resolved.Users[user.ID] = weekly
+		}
+	}
+	return resolved, nil
+}
+
+// Workdays counts the weekdays of the period up to and including today
+func Workdays(period report.Period, now time.Time) int {
+	n := 0
+	for _, day := range period.Days() {
+		if day.After(now) {
+			break
+		}
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			n++
+		}
+	}
+	return n
+}
+
+// Comparison is the time a user logged in a period against their capacity
+type Comparison struct {
+	UserID   string
+	User     string
+	Logged   time.Duration
+	Billable time.Duration
+	Capacity time.Duration
+}
+
+// Delta is the time logged over the capacity, negative when under it
+func (c Comparison) Delta() time.Duration {
+	return c.Logged - c.Capacity
+}
+
+// Report compares the logged time of users in a period with their capacity
+type Report struct {
+	Period report.Period
+	Users  []Comparison // Furthest under capacity first
+}
+
+// Build fetches the time entries of the users in the period and compares them with the model
+func Build(client *clockify.APIClient, workspaceID string, users []clockify.User, period report.Period, model Model, now time.Time) (*Report, error) {
+	entries := make(map[string][]clockify.TimeEntry, len(users))
+	for _, user := range users {
+		userEntries, err := report.FetchEntries(client, workspaceID, user.ID, period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch time entries of %s: %w", user, err)
+		}
+		entries[user.ID] = userEntries
+	}
+	return Compare(period, users, entries, model, now), nil
+}
+
+// Compare builds the report from already fetched entries, keyed by user ID
+func Compare(period report.Period, users []clockify.User, entries map[string][]clockify.TimeEntry, model Model, now time.Time) *Report {
+	r := &Report{Period: period}
+	for _, user := range users {
+		summary := report.Summarize(period, entries[user.ID], nil, now)
+		r.Users = append(r.Users, Comparison{
+			UserID:   user.ID,
+			User:     user.Name,
+			Logged:   summary.Total,
+			Billable: summary.Billable,
+			Capacity: model.For(user, period, now),
+		})
+	}
+
+	slices.SortFunc(r.Users, func(a, b Comparison) int {
+		return cmp.Or(cmp.Compare(a.Delta(), b.Delta()), cmp.Compare(a.User, b.User))
+	})
+	return r
+}
@@ -0,0 +1,100 @@
+package capacity
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Render writes the report in the given format
+func Render(w io.Writer, r *Report, format report.Format) error {
+	switch format {
+	case report.FormatTable:
+		return WriteTable(w, r)
+	case report.FormatCSV:
+		return WriteCSV(w, r)
+	case report.FormatJSON:
+		return WriteJSON(w, r)
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv or json", format)
+	}
+}
+
+// WriteTable writes the comparison of every user as an ASCII table
+func WriteTable(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Period: %s\t\n\n", r.Period)
+
+	fmt.Fprintln(tw, "USER\tLOGGED\tBILLABLE\tCAPACITY\tDELTA\t")
+	for _, c := range r.Users {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", c.User, report.FormatDuration(c.Logged), report.FormatDuration(c.Billable), report.FormatDuration(c.Capacity), FormatDelta(c.Delta()))
+	}
+
+	return tw.Flush()
+}
+
+// WriteCSV writes one row per user
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"user_id", "user", "logged_hours", "billable_hours", "capacity_hours", "delta_hours"})
+	for _, c := range r.Users {
+		cw.Write([]string{
+			c.UserID,
+			c.User,
+			strconv.FormatFloat(report.Hours(c.Logged), 'f', 2, 64),
+			strconv.FormatFloat(report.Hours(c.Billable), 'f', 2, 64),
+			strconv.FormatFloat(report.Hours(c.Capacity), 'f', 2, 64),
+			strconv.FormatFloat(report.Hours(c.Delta()), 'f', 2, 64),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonUser struct {
+	UserID        string  `json:"userId"`
+	User          string  `json:"user"`
+	Hours         float64 `json:"hours"`
+	BillableHours float64 `json:"billableHours"`
+	CapacityHours float64 `json:"capacityHours"`
+	DeltaHours    float64 `json:"deltaHours"`
+}
+
+type jsonReport struct {
+	Start string     `json:"start"`
+	End   string     `json:"end"`
+	Users []jsonUser `json:"users"`
+}
+
+// WriteJSON writes the report as JSON with durations in hours
+func WriteJSON(w io.Writer, r *Report) error {
+	out := jsonReport{
+		Start: r.Period.Start.Format(time.RFC3339),
+		End:   r.Period.End.Format(time.RFC3339),
+		Users: make([]jsonUser, 0, len(r.Users)),
+	}
+	for _, c := range r.Users {
+		out.Users = append(out.Users, jsonUser{c.UserID, c.User, report.Hours(c.Logged), report.Hours(c.Billable), report.Hours(c.Capacity), report.Hours(c.Delta())})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// FormatDelta formats a delta as a signed H:MM, e.g. "+1:30" or "-2:00"
+func FormatDelta(d time.Duration) string {
+	if d < 0 {
+		return "-" + report.FormatDuration(-d)
+	}
+	return "+" + report.FormatDuration(d)
+}
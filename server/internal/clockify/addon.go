@@ -0,0 +1,165 @@
+package clockify
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAddonToken is returned for addon tokens that are malformed, expired, not issued
+// by Clockify or not signed with its key
+var ErrInvalidAddonToken = errors.New("invalid addon token")
+
+// addonIssuer is the issuer of the tokens Clockify gives addons
+const addonIssuer = "clockify"
+
+// AddonClaims are the claims of the JWT Clockify gives a marketplace addon: the installation
+// token sent with the INSTALLED lifecycle event, or the user tokens the addon's iframes get
+type AddonClaims struct {
+	Issuer      string `json:"iss"`
+	Subject     string `json:"sub"`  // Key of the addon, from its manifest
+	Type        string `json:"type"` // "addon" for installation tokens, "user" for user tokens
+	AddonID     string `json:"addonId"`
+	WorkspaceID string `json:"workspaceId"`
+	UserID      string `json:"user"`
+	// API URLs of the installation's region, without the version, e.g. https://api.clockify.me/api
+	BackendURL string `json:"backendUrl"`
+	ReportsURL string `json:"reportsUrl"`
+	PTOURL     string `json:"ptoUrl"`
+	ExpiresAt  int64  `json:"exp"` // Unix seconds, 0 for tokens that do not expire
+}
+
+// Endpoints returns the API URLs of the installation, the default ones for the URLs the token
+// does not carry
+func (c AddonClaims) Endpoints() Endpoints {
+	if c.BackendURL == "" {
+		return EndpointsFor(DefaultBaseURL)
+	}
+
+	endpoints := EndpointsFor(strings.TrimRight(c.BackendURL, "/") + "/" + path.Base(DefaultBaseURL))
+	if c.ReportsURL != "" {
+		endpoints.Reports = strings.TrimRight(c.ReportsURL, "/") + "/v1"
+	}
+	if c.PTOURL != "" {
+		endpoints.PTO = strings.TrimRight(c.PTOURL, "/") + "/v1"
+	}
+	return endpoints
+}
+
+// ParseAddonPublicKey reads the PEM-encoded RSA key Clockify signs addon tokens with, as
+// published in the addon documentation
+func ParseAddonPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA key, got %T", parsed)
+	}
+	return key, nil
+}
+
+// ParseAddonToken reads the claims of an addon token, verifying it was signed with key. A
+// nil key skips the signature check, for tokens from a trusted source such as the config.
+func ParseAddonToken(token string, key *rsa.PublicKey) (*AddonClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWT", ErrInvalidAddonToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrInvalidAddonToken, err)
+	}
+
+	if key != nil {
+		if header.Alg != "RS256" {
+			return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidAddonToken, header.Alg)
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: signature: %w", ErrInvalidAddonToken, err)
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, fmt.Errorf("%w: signature does not match", ErrInvalidAddonToken)
+		}
+	}
+
+	var claims AddonClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", ErrInvalidAddonToken, err)
+	}
+	switch {
+	case claims.Issuer != addonIssuer:
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidAddonToken, claims.Issuer)
+	case claims.ExpiresAt != 0 && time.Now().After(time.Unix(claims.ExpiresAt, 0)):
+		return nil, fmt.Errorf("%w: expired at %s", ErrInvalidAddonToken, time.Unix(claims.ExpiresAt, 0).Format(time.RFC3339))
+	}
+	return &claims, nil
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a JWT
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// AddonToken authenticates as a Clockify addon installed in a workspace, with the installation
+// token it was given. Calls are limited to the scopes of the addon's manifest.
+type AddonToken struct {
+	token  string
+	Claims AddonClaims
+}
+
+// NewAddonToken parses the installation token, see ParseAddonToken
+func NewAddonToken(token string, key *rsa.PublicKey) (*AddonToken, error) {
+	claims, err := ParseAddonToken(token, key)
+	if err != nil {
+		return nil, err
+	}
+	return &AddonToken{token: token, Claims: *claims}, nil
+}
+
+func (t *AddonToken) Authenticate(req *http.Request) error {
+	req.Header.Set(addonTokenHeader, t.token)
+	return nil
+}
+
+// WithAddonToken authenticates the client as the addon installation and sends the requests
+// to the API URLs of its region
+func WithAddonToken(token *AddonToken) ClientOption {
+	return func(c *APIClient) {
+		c.auth = token
+		c.endpoints = token.Claims.Endpoints()
+	}
+}
+
+// Addon returns the claims of the addon installation the client authenticates as, nil for
+// other authenticators
+func (c *APIClient) Addon() *AddonClaims {
+	if token, ok := c.auth.(*AddonToken); ok {
+		return &token.Claims
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package clockify
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// Approval request states
+const (
+	ApprovalPending   = "PENDING"
+	ApprovalApproved  = "APPROVED"
+	ApprovalRejected  = "REJECTED"
+	ApprovalWithdrawn = "WITHDRAWN_APPROVAL"
+)
+
+// ApprovalRequest asks a manager to approve the time a user tracked over a period
+type ApprovalRequest struct {
+	ID          string         `json:"id"`
+	WorkspaceID string         `json:"workspaceId"`
+	DateRange   DateRange      `json:"dateRange"`
+	Owner       ApprovalOwner  `json:"owner"`
+	Status      ApprovalStatus `json:"status"`
+}
+
+// DateRange is the period of an approval request or an assignment, its end is the last instant
+// of the period
+type DateRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ApprovalOwner is the user whose time an approval request covers
+type ApprovalOwner struct {
+	UserID   string `json:"userId"`
+	UserName string `json:"userName"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// Location returns the time zone of the owner, fallback when it is unset or unknown
+func (o ApprovalOwner) Location(fallback *time.Location) *time.Location {
+	if o.TimeZone == "" {
+		return fallback
+	}
+	loc, err := time.LoadLocation(o.TimeZone)
+	if err != nil {
+		return fallback
+	}
+	return loc
+}
+
+// ApprovalStatus is the state of an approval request and who set it
+type ApprovalStatus struct {
+	State             string     `json:"state"`
+	Note              string     `json:"note,omitempty"`
+	UpdatedBy         string     `json:"updatedBy,omitempty"`
+	UpdatedByUserName string     `json:"updatedByUserName,omitempty"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+}
+
+// submitApprovalRequest is the body submitting a week for approval
+type submitApprovalRequest struct {
+	Period      string `json:"period"`
+	PeriodStart string `json:"periodStart"`
+}
+
+// SubmitWeekForApproval submits the time the caller tracked in the week starting on the day
+// of weekStart for approval. The workspace plan must include approvals, otherwise
+// ErrFeatureUnavailable is returned.
+func (c *APIClient) SubmitWeekForApproval(workspaceID string, weekStart time.Time) (*ApprovalRequest, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/approval-requests", c.endpoints.API, workspaceID)
+
+	// Clockify reads the day of the period start, in the time zone of the user
+	day := time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), 0, 0, 0, 0, time.UTC)
+	resp, err := c.post(url, submitApprovalRequest{Period: "WEEKLY", PeriodStart: day.Format(time.RFC3339)})
+	if err != nil {
+		return nil, c.featureError(workspaceID, FeatureApproval, err)
+	}
+
+	defer resp.Body.Close()
+
+	var request ApprovalRequest
+	if err := json.NewDecoder(resp.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// approvalDetails is an approval request as listed, the totals along with it are left out
+type approvalDetails struct {
+	ApprovalRequest ApprovalRequest `json:"approvalRequest"`
+}
+
+// GetApprovalRequests retrieves a page of the workspace approval requests in the state, of
+// every state when empty. Listing them requires the workspace admin or manager role.
+func (c *APIClient) GetApprovalRequests(workspaceID, state string, page int) ([]ApprovalRequest, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/approval-requests?page=%d&page-size=%d", c.endpoints.API, workspaceID, page, c.pageSize)
+	if state != "" {
+		url += "&status=" + state
+	}
+
+	resp, err := c.get(url)
+	if err != nil {
+		return nil, c.featureError(workspaceID, FeatureApproval, err)
+	}
+
+	defer resp.Body.Close()
+
+	var details []approvalDetails
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, err
+	}
+
+	requests := make([]ApprovalRequest, len(details))
+	for i, d := range details {
+		requests[i] = d.ApprovalRequest
+	}
+	return requests, nil
+}
+
+// IterApprovalRequests iterates over the workspace approval requests in the state, page by page
+func (c *APIClient) IterApprovalRequests(workspaceID, state string) iter.Seq2[[]ApprovalRequest, error] {
+	return func(yield func([]ApprovalRequest, error) bool) {
+		page := 1
+		for {
+			requests, err := c.GetApprovalRequests(workspaceID, state, page)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if len(requests) == 0 {
+				return
+			}
+
+			if !yield(requests, nil) {
+				return
+			}
+
+			page++
+		}
+	}
+}
+
+// updateApprovalRequest is the body approving or rejecting an approval request
+type updateApprovalRequest struct {
+	State string `json:"state"`
+	Note  string `json:"note,omitempty"`
+}
+
+// UpdateApprovalRequest approves or rejects an approval request, state being ApprovalApproved
+// or ApprovalRejected, with an optional note for its owner
+func (c *APIClient) UpdateApprovalRequest(workspaceID, requestID, state, note string) (*ApprovalRequest, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/approval-requests/%s", c.endpoints.API, workspaceID, requestID)
+
+	resp, err := c.patch(url, updateApprovalRequest{State: state, Note: note})
+	if err != nil {
+		return nil, c.featureError(workspaceID, FeatureApproval, err)
+	}
+
+	defer resp.Body.Close()
+
+	var request ApprovalRequest
+	if err := json.NewDecoder(resp.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
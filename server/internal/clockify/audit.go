@@ -0,0 +1,77 @@
+package clockify
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Write is a request changing data in Clockify, as seen by AuditMiddleware. The bodies are
+// redacted and truncated like logged ones, see LoggingMiddleware.
+type Write struct {
+	Method   string
+	URL      string
+	Request  string
+	Started  time.Time
+	Duration time.Duration
+
+	// The response, Status is 0 when the request failed without one
+	Status   int
+	Response string
+	Err      error
+}
+
+// AuditMiddleware passes every write to record once it completed: all requests except GET and
+// HEAD ones and report queries, which are POSTed.
+func AuditMiddleware(record func(ctx context.Context, write Write)) TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !isWrite(req) {
+				return next.RoundTrip(req)
+			}
+
+			secret := credential(req)
+			write := Write{Method: req.Method, URL: redact(req.URL.String(), secret), Started: time.Now()}
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					reqBody, _ := io.ReadAll(body)
+					body.Close()
+					write.Request = redact(string(reqBody), secret)
+				}
+			}
+
+			resp, err := next.RoundTrip(req)
+			write.Duration = time.Since(write.Started)
+			if err != nil {
+				write.Err = err
+				record(req.Context(), write)
+				return nil, err
+			}
+
+			// Read the body for the record and put it back so the caller can still decode it
+			write.Status = resp.StatusCode
+			respBody, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+			if readErr != nil {
+				write.Err = readErr
+			}
+			write.Response = redact(string(respBody), secret)
+
+			record(req.Context(), write)
+			return resp, nil
+		})
+	}
+}
+
+// isWrite reports whether the request may change data
+func isWrite(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return !strings.Contains(req.URL.Path, "/reports/")
+}
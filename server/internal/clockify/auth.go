@@ -0,0 +1,39 @@
+package clockify
+
+import "net/http"
+
+// Authenticator attaches the credentials of the client to every request to Clockify. It may
+// be called concurrently, and once per retry attempt.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Headers the credentials are sent in
+const (
+	apiKeyHeader     = "X-Api-Key"
+	addonTokenHeader = "X-Addon-Token"
+)
+
+// APIKey authenticates with a personal API key, the default of NewDefaultClient
+type APIKey string
+
+func (k APIKey) Authenticate(req *http.Request) error {
+	req.Header.Set(apiKeyHeader, string(k))
+	return nil
+}
+
+// WithAuthenticator replaces the API key given to NewDefaultClient, e.g. with an AddonToken
+func WithAuthenticator(auth Authenticator) ClientOption {
+	return func(c *APIClient) {
+		c.auth = auth
+	}
+}
+
+// credential returns the secret a request is authenticated with, for the middlewares to
+// mask it and to tell the callers apart
+func credential(req *http.Request) string {
+	if key := req.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	return req.Header.Get(addonTokenHeader)
+}
@@ -0,0 +1,84 @@
+package clockify
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// BillingIncrements rounds the finished entries CreatePastTimeEntry and
+// CreateTimeEntryWithDates create up to a minimum increment, the way clients are billed, and
+// warns of billable entries shorter than a billable minimum. The zero value leaves the entries
+// as they are.
+type BillingIncrements struct {
+	Increment time.Duration // Entries are extended to a multiple of it, 0 does not round
+	Minimum   time.Duration // Billable entries shorter are warned of, 0 never warns
+	// Names of the projects the increments apply to, every project when empty
+	Projects []string
+	// Names of the projects they never apply to, e.g. internal ones
+	ExemptProjects []string
+}
+
+// enabled reports whether the increments round or warn of anything
+func (b BillingIncrements) enabled() bool {
+	return b.Increment > 0 || b.Minimum > 0
+}
+
+// applies reports whether the increments apply to the project, "" for entries without one
+func (b BillingIncrements) applies(project string) bool {
+	if slices.Contains(b.ExemptProjects, project) {
+		return false
+	}
+	return len(b.Projects) == 0 || slices.Contains(b.Projects, project)
+}
+
+// Round returns d rounded up to the increment
+func (b BillingIncrements) Round(d time.Duration) time.Duration {
+	if b.Increment <= 0 {
+		return d
+	}
+	if rounded := d.Truncate(b.Increment); rounded != d {
+		return rounded + b.Increment
+	}
+	return d
+}
+
+// WithBillingIncrements applies the increments to the past entries the create helpers create
+func WithBillingIncrements(increments BillingIncrements) ClientOption {
+	return func(c *APIClient) {
+		c.billing = increments
+	}
+}
+
+// ApplyBillingIncrements moves the end of a finished entry's request to round it up to the
+// increment, if they apply to its project, warning when it is billable and still shorter than
+// the minimum. The create helpers apply it, callers creating entries from a request of their
+// own, e.g. queued offline, do.
+func (c *APIClient) ApplyBillingIncrements(workspaceID string, request *NewTimeEntryRequest) error {
+	if !c.billing.enabled() || request.End == nil {
+		return nil
+	}
+
+	// Only looked up when the increments are toggled per project
+	var project string
+	if request.ProjectID != "" && len(c.billing.Projects)+len(c.billing.ExemptProjects) > 0 {
+		p, err := c.GetProject(workspaceID, request.ProjectID)
+		if err != nil {
+			return fmt.Errorf("failed to look up the project of the entry: %w", err)
+		}
+		project = p.Name
+	}
+	if !c.billing.applies(project) {
+		return nil
+	}
+
+	duration := c.billing.Round(request.End.Sub(request.Start))
+	end := request.Start.Add(duration)
+	request.End = &end
+
+	if request.Billable && c.billing.Minimum > 0 && duration < c.billing.Minimum {
+		slog.Warn("billing_minimum_not_met", "description", request.Description, "project_id", request.ProjectID, "duration", duration, "minimum", c.billing.Minimum)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package clockify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting Clockify while the circuit breaker is open
+var ErrCircuitOpen = errors.New("clockify circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker trips after threshold consecutive failures and lets a single probe through
+// once the cooldown has passed
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// allow reports whether a request may be sent
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+		return true
+	case breakerHalfOpen:
+		// The probe is still in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of an allowed request
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		if b.state != breakerClosed {
+			b.setState(breakerClosed)
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		if b.state != breakerOpen {
+			b.setState(breakerOpen)
+		}
+	}
+}
+
+// release gives up a probe that ended without an outcome, the next request probes instead
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state, b.openedAt = breakerOpen, time.Time{}
+	}
+}
+
+// setState changes the state, callers must hold b.mu
+func (b *circuitBreaker) setState(state breakerState) {
+	level := slog.LevelInfo
+	if state == breakerOpen {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "circuit_breaker_state_changed", "from", b.state, "to", state, "failures", b.failures)
+	b.state = state
+}
+
+// isBreakerFailure reports whether the outcome means Clockify is unavailable. Client errors
+// such as 404 are the caller's problem and do not count.
+func isBreakerFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// CircuitBreakerMiddleware fails requests fast with ErrCircuitOpen after threshold consecutive
+// network errors, 5xx or 429 responses. After the cooldown, one request is let through as a
+// probe: its success closes the circuit, its failure opens it for another cooldown.
+//
+// Requests canceled by their context do not count as failures.
+func CircuitBreakerMiddleware(threshold int, cooldown time.Duration) TransportMiddleware {
+	breaker := &circuitBreaker{threshold: threshold, cooldown: cooldown}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !breaker.allow() {
+				return nil, ErrCircuitOpen
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil && req.Context().Err() != nil {
+				breaker.release()
+				return resp, err
+			}
+
+			breaker.record(isBreakerFailure(resp, err))
+			return resp, err
+		})
+	}
+}
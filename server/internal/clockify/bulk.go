@@ -0,0 +1,296 @@
+package clockify
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// TimeEntryFilter selects time entries for bulk operations. Zero fields match everything.
+type TimeEntryFilter struct {
+	// Entries of the user. MoveTimeEntries moves those of every workspace user when empty,
+	// FindTimeEntries lists those of its user.
+	UserID      string
+	ProjectID   string
+	Start       *time.Time // Entries starting at or after
+	End         *time.Time // Entries starting before
+	Description *regexp.Regexp
+	// Any further criterion, e.g. a compiled filter expression
+	Match func(TimeEntry) bool
+}
+
+// Matches reports whether the entry passes every set criterion
+func (f TimeEntryFilter) Matches(entry TimeEntry) bool {
+	if f.UserID != "" && entry.UserID != f.UserID {
+		return false
+	}
+	if f.ProjectID != "" && entry.ProjectID != f.ProjectID {
+		return false
+	}
+	if entry.TimeInterval != nil {
+		if f.Start != nil && entry.TimeInterval.Start.Before(*f.Start) {
+			return false
+		}
+		if f.End != nil && !entry.TimeInterval.Start.Before(*f.End) {
+			return false
+		}
+	}
+	if f.Description != nil && !f.Description.MatchString(entry.Description) {
+		return false
+	}
+	if f.Match != nil && !f.Match(entry) {
+		return false
+	}
+	return true
+}
+
+// FindTimeEntries returns the user's time entries matching the filter. The date range is
+// passed to the API, the remaining criteria are applied locally.
+func (c *APIClient) FindTimeEntries(workspaceID, userID string, filter TimeEntryFilter) ([]TimeEntry, error) {
+	var matched []TimeEntry
+	for entries, err := range c.IterTimeEntries(workspaceID, userID, filter.Start, filter.End) {
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if filter.Matches(entry) {
+				matched = append(matched, entry)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// DeleteTimeEntries deletes the given entries, continuing past failures. Locked entries are
+// not sent to Clockify and fail with ErrLocked. It returns how many were deleted along with
+// every error.
+func (c *APIClient) DeleteTimeEntries(workspaceID string, entries []TimeEntry) (int, error) {
+	var (
+		deleted int
+		errs    []error
+	)
+	for _, entry := range entries {
+		if err := c.budgetSpent(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if entry.IsLocked {
+			errs = append(errs, fmt.Errorf("failed to delete entry '%s': %w", entry, ErrLocked))
+			continue
+		}
+		if err := c.DeleteTimeEntry(workspaceID, entry.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete entry '%s': %w", entry, err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// RetagTimeEntries adds and removes tags on the given entries, continuing past failures.
+// Entries whose tags would not change are skipped, locked ones fail with ErrLocked. It
+// returns how many were updated along with every error.
+func (c *APIClient) RetagTimeEntries(workspaceID string, entries []TimeEntry, addTagIDs, removeTagIDs []string) (int, error) {
+	var (
+		updated int
+		errs    []error
+	)
+	for _, entry := range entries {
+		if err := c.budgetSpent(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		tagIDs := RetaggedIDs(entry.TagIDs, addTagIDs, removeTagIDs)
+		if slices.Equal(tagIDs, entry.TagIDs) {
+			continue
+		}
+		if entry.IsLocked {
+			errs = append(errs, fmt.Errorf("failed to retag entry '%s': %w", entry, ErrLocked))
+			continue
+		}
+
+		if _, err := c.UpdateTimeEntry(workspaceID, entry.ID, updateRequestFor(entry, tagIDs)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to retag entry '%s': %w", entry, err))
+			continue
+		}
+		updated++
+	}
+	return updated, errors.Join(errs...)
+}
+
+// RewriteDescriptions replaces the description of every entry with what rewrite returns for
+// it, continuing past failures. Entries whose description would not change are skipped,
+// locked ones fail with ErrLocked. It returns how many were updated along with every error.
+func (c *APIClient) RewriteDescriptions(workspaceID string, entries []TimeEntry, rewrite func(TimeEntry) (string, error)) (int, error) {
+	var (
+		updated int
+		errs    []error
+	)
+	for _, entry := range entries {
+		if err := c.budgetSpent(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		description, err := rewrite(entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to rewrite entry '%s': %w", entry, err))
+			continue
+		}
+		if description == entry.Description {
+			continue
+		}
+		if entry.IsLocked {
+			errs = append(errs, fmt.Errorf("failed to rewrite entry '%s': %w", entry, ErrLocked))
+			continue
+		}
+
+		request := updateRequestFor(entry, entry.TagIDs)
+		request.Description = description
+		if _, err := c.UpdateTimeEntry(workspaceID, entry.ID, request); err != nil {
+			errs = append(errs, fmt.Errorf("failed to rewrite entry '%s': %w", entry, err))
+			continue
+		}
+		updated++
+	}
+	return updated, errors.Join(errs...)
+}
+
+// SetBillable sets the billable flag of the given entries, continuing past failures. Entries
+// already billable or not as asked are skipped, locked ones fail with ErrLocked. It returns
+// how many were updated along with every error.
+func (c *APIClient) SetBillable(workspaceID string, entries []TimeEntry, billable bool) (int, error) {
+	var (
+		updated int
+		errs    []error
+	)
+	for _, entry := range entries {
+		if err := c.budgetSpent(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if entry.Billable == billable {
+			continue
+		}
+		if entry.IsLocked {
+			errs = append(errs, fmt.Errorf("failed to reclassify entry '%s': %w", entry, ErrLocked))
+			continue
+		}
+
+		request := updateRequestFor(entry, entry.TagIDs)
+		request.Billable = billable
+		if _, err := c.UpdateTimeEntry(workspaceID, entry.ID, request); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reclassify entry '%s': %w", entry, err))
+			continue
+		}
+		updated++
+	}
+	return updated, errors.Join(errs...)
+}
+
+// BulkProgress is called by bulk operations after every entry, with how many of the total
+// were handled so far
+type BulkProgress func(done, total int)
+
+// MoveTimeEntries moves the entries matching the filter to the target project and task, e.g.
+// after weeks tracked under the wrong project, continuing past failures. Every matching entry
+// is listed before the first is moved. An empty task leaves the entries without one. Entries
+// already there are skipped, locked ones fail with ErrLocked. Progress may be nil. It returns
+// how many were moved along with every error.
+func (c *APIClient) MoveTimeEntries(workspaceID string, filter TimeEntryFilter, targetProjectID, targetTaskID string, progress BulkProgress) (int, error) {
+	if targetProjectID == "" {
+		return 0, errors.New("a target project is required")
+	}
+
+	userIDs := []string{filter.UserID}
+	if filter.UserID == "" {
+		userIDs = nil
+		for users, err := range c.IterWorkspaceUsers(workspaceID) {
+			if err != nil {
+				return 0, fmt.Errorf("failed to list users: %w", err)
+			}
+			for _, user := range users {
+				userIDs = append(userIDs, user.ID)
+			}
+		}
+	}
+
+	var entries []TimeEntry
+	for _, userID := range userIDs {
+		found, err := c.FindTimeEntries(workspaceID, userID, filter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list time entries of user %s: %w", userID, err)
+		}
+		entries = append(entries, found...)
+	}
+
+	var (
+		moved int
+		errs  []error
+	)
+	for i, entry := range entries {
+		if err := c.budgetSpent(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if err := c.moveTimeEntry(workspaceID, entry, targetProjectID, targetTaskID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to move entry '%s': %w", entry, err))
+		} else if entry.ProjectID != targetProjectID || entry.TaskID != targetTaskID {
+			moved++
+		}
+		if progress != nil {
+			progress(i+1, len(entries))
+		}
+	}
+	return moved, errors.Join(errs...)
+}
+
+// moveTimeEntry updates the project and task of the entry unless it already tracks them
+func (c *APIClient) moveTimeEntry(workspaceID string, entry TimeEntry, projectID, taskID string) error {
+	if entry.ProjectID == projectID && entry.TaskID == taskID {
+		return nil
+	}
+	if entry.IsLocked {
+		return ErrLocked
+	}
+
+	request := updateRequestFor(entry, entry.TagIDs)
+	request.ProjectID, request.TaskID = projectID, taskID
+	_, err := c.UpdateTimeEntry(workspaceID, entry.ID, request)
+	return err
+}
+
+// RetaggedIDs returns tagIDs without removeTagIDs and with addTagIDs appended, keeping the order
+func RetaggedIDs(tagIDs, addTagIDs, removeTagIDs []string) []string {
+	result := make([]string, 0, len(tagIDs)+len(addTagIDs))
+	for _, id := range tagIDs {
+		if !slices.Contains(removeTagIDs, id) {
+			result = append(result, id)
+		}
+	}
+	for _, id := range addTagIDs {
+		if !slices.Contains(result, id) && !slices.Contains(removeTagIDs, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// updateRequestFor builds an update request keeping every field of the entry but its tags,
+// since the API replaces the whole entry on update
+func updateRequestFor(entry TimeEntry, tagIDs []string) UpdateTimeEntryRequest {
+	request := UpdateTimeEntryRequest{
+		Billable:    entry.Billable,
+		Description: entry.Description,
+		ProjectID:   entry.ProjectID,
+		TaskID:      entry.TaskID,
+		TagIDs:      tagIDs,
+		Type:        entry.Type,
+	}
+	if entry.TimeInterval != nil {
+		request.Start = entry.TimeInterval.Start
+		request.End = entry.TimeInterval.End
+	}
+	return request
+}
@@ -2,170 +2,243 @@ package clockify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"iter"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+// APIClient calls the Clockify API. It is safe for concurrent use: the options are applied
+// once by NewDefaultClient and the client is never modified afterwards, WithContext returns
+// a copy. Shared state such as the rate limiter and the connection pool guards itself.
 type APIClient struct {
-	apiKey   string
+	auth     Authenticator
 	client   *http.Client
 	pageSize int
-}
 
-const baseURL = "https://api.clockify.me/api/v2"
+	// Transport chain settings, consumed by NewDefaultClient
+	middlewares   []TransportMiddleware
+	logger        *slog.Logger
+	retryAttempts int
+	rateLimit     int
+	etagCacheSize int
+	shared        SharedState // Of the rate limit and ETag cache, nil keeps them in memory
 
-func NewDefaultClient(apiKey string) *APIClient {
-	return &APIClient{
-		apiKey:   apiKey,
-		client:   &http.Client{},
-		pageSize: 5000, // max possible page size
-	}
-}
+	breakerThreshold int
+	breakerCooldown  time.Duration
 
-// * HTTP methods utilities
+	// Connection settings, consumed by NewDefaultClient
+	timeout             time.Duration
+	callTimeouts        CallTimeouts
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
 
-func isRespError(resp *http.Response) bool {
-	ok := resp.StatusCode < 400
-	if !ok {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			slog.Error("error_reading_response_body", "error", err)
-		}
-		slog.Error("request_failed", "method", resp.Request.Method, "status", resp.Status, "body", string(body))
-	}
-	return !ok
-}
+	tracerProvider trace.TracerProvider
 
-func (c *APIClient) get(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
+	endpoints Endpoints
+	// Versions of the ExperimentalClient endpoints overridden with WithEndpointVersion
+	endpointVersions map[string]APIVersion
 
-	req.Header.Set("X-Api-Key", c.apiKey)
+	expandDescription DescriptionExpander // Of the timers started, nil to keep them as they are
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
+	// Settings of the projects created by CreateProject
+	projectBillable bool
+	projectPublic   bool
 
-	if isRespError(resp) {
-		return nil, fmt.Errorf("failed to %s: %s", req.Method, resp.Status)
-	}
+	// Rounding of the past entries the create helpers create
+	billing BillingIncrements
 
-	return resp, nil
+	// ctx is attached to every outgoing request, see WithContext
+	ctx context.Context
 }
 
-func (c *APIClient) post(url string, data any) (*http.Response, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
+const (
+	defaultRetryAttempts = 3
+	defaultRateLimit     = 50 // Clockify allows 50 requests per second per user
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
+	// net/http keeps only 2 idle connections per host, parallel pagination would keep
+	// opening new ones
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
 
-	req.Header.Set("X-Api-Key", c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+func NewDefaultClient(apiKey string, opts ...ClientOption) *APIClient {
+	c := &APIClient{
+		auth:                APIKey(apiKey),
+		pageSize:            5000, // max possible page size
+		endpoints:           EndpointsFor(DefaultBaseURL),
+		retryAttempts:       defaultRetryAttempts,
+		rateLimit:           defaultRateLimit,
+		maxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		idleConnTimeout:     DefaultIdleConnTimeout,
+		projectBillable:     true,
+	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	if isRespError(resp) {
-		return nil, fmt.Errorf("failed to %s: %s", req.Method, resp.Status)
+	// Calls are bounded by their context instead, see callTimeout
+	c.client = &http.Client{
+		Transport: chainTransport(c.baseTransport(), c.transportChain()...),
 	}
 
-	return resp, nil
+	return c
 }
 
-func (c *APIClient) put(url string, data any) (*http.Response, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
+// baseTransport returns a connection pool of the client's own, so its tuning does not
+// affect other users of http.DefaultTransport
+func (c *APIClient) baseTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+	transport.MaxIdleConns = max(transport.MaxIdleConns, c.maxIdleConnsPerHost)
+	transport.IdleConnTimeout = c.idleConnTimeout
+	return transport
+}
 
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
+// transportChain returns the user middlewares followed by the built-in ones, outermost first
+func (c *APIClient) transportChain() []TransportMiddleware {
+	chain := append([]TransportMiddleware{}, c.middlewares...)
+	if c.tracerProvider != nil {
+		// Outside of retries, so that a span covers the whole logical call
+		chain = append(chain, TracingMiddleware(c.tracerProvider))
+	}
+	if c.breakerThreshold > 0 {
+		// Outside of retries, a request is one failure however often it was retried
+		chain = append(chain, CircuitBreakerMiddleware(c.breakerThreshold, c.breakerCooldown))
+	}
+	if c.etagCacheSize > 0 {
+		// Outside of retries, a retried request carries the same If-None-Match
+		if c.shared != nil {
+			chain = append(chain, SharedETagCacheMiddleware(c.shared))
+		} else {
+			chain = append(chain, ETagCacheMiddleware(c.etagCacheSize))
+		}
 	}
-
-	req.Header.Set("X-Api-Key", c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
+	if c.retryAttempts > 1 {
+		chain = append(chain, RetryMiddleware(c.retryAttempts, defaultRetryDelay))
 	}
-
-	if isRespError(resp) {
-		return nil, fmt.Errorf("failed to %s: %s", req.Method, resp.Status)
+	if c.rateLimit > 0 {
+		if c.shared != nil {
+			chain = append(chain, SharedRateLimitMiddleware(c.shared, c.rateLimit))
+		} else {
+			chain = append(chain, RateLimitMiddleware(c.rateLimit))
+		}
 	}
+	if c.logger != nil {
+		// Innermost, so that every retry attempt is logged separately
+		chain = append(chain, LoggingMiddleware(c.logger))
+	}
+	return chain
+}
 
-	return resp, nil
+// WithContext returns a shallow copy of the client sending all requests with ctx,
+// which carries cancellation and trace context to the Clockify calls.
+func (c *APIClient) WithContext(ctx context.Context) *APIClient {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
 }
 
-func (c *APIClient) delete(url string) (*http.Response, error) {
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return nil, err
+func (c *APIClient) context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
 	}
+	return context.Background()
+}
 
-	req.Header.Set("X-Api-Key", c.apiKey)
+// * HTTP methods utilities
 
+// do sends the request with the credentials attached and converts error statuses into an *APIError.
+func (c *APIClient) do(req *http.Request) (*http.Response, error) {
+	if err := c.auth.Authenticate(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	parent := req.Context()
+	req, timeout, cancel := c.withCallTimeout(req)
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		cancel()
+		return nil, callTimeoutError(req, parent, timeout, err)
 	}
 
-	if isRespError(resp) {
-		return nil, fmt.Errorf("failed to %s: %s", req.Method, resp.Status)
+	if resp.StatusCode >= 400 {
+		apiErr := newAPIError(resp)
+		cancel()
+		slog.Error("request_failed", "method", req.Method, "status", resp.Status, "message", apiErr.Message, "code", apiErr.Code)
+		return nil, apiErr
 	}
 
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
 	return resp, nil
 }
 
-func (c *APIClient) patch(url string, data any) (*http.Response, error) {
+func (c *APIClient) send(ctx context.Context, method, url string, data any) (*http.Response, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("X-Api-Key", c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	return c.do(req)
+}
 
-	resp, err := c.client.Do(req)
+func (c *APIClient) get(url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.context(), "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if isRespError(resp) {
-		return nil, fmt.Errorf("failed to %s: %s", req.Method, resp.Status)
+	return c.do(req)
+}
+
+func (c *APIClient) post(url string, data any) (*http.Response, error) {
+	return c.send(c.context(), "POST", url, data)
+}
+
+// query posts a request that only reads, e.g. a report, so that it is retried like a GET
+func (c *APIClient) query(url string, data any) (*http.Response, error) {
+	return c.send(Idempotent(c.context()), "POST", url, data)
+}
+
+func (c *APIClient) put(url string, data any) (*http.Response, error) {
+	return c.send(c.context(), "PUT", url, data)
+}
+
+func (c *APIClient) delete(url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.context(), "DELETE", url, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return resp, nil
+	return c.do(req)
+}
+
+func (c *APIClient) patch(url string, data any) (*http.Response, error) {
+	return c.send(c.context(), "PATCH", url, data)
 }
 
 // * Actual API methods
 
 // GetWorkspaces retrieves all workspaces for the authenticated user
 func (c *APIClient) GetWorkspaces() ([]Workspace, error) {
-	url := fmt.Sprintf("%s/workspaces", baseURL)
+	url := fmt.Sprintf("%s/workspaces", c.endpoints.API)
 
 	resp, err := c.get(url)
 	if err != nil {
@@ -184,7 +257,7 @@ func (c *APIClient) GetWorkspaces() ([]Workspace, error) {
 
 // GetCurrentUser retrieves the currently authenticated user
 func (c *APIClient) GetCurrentUser() (*User, error) {
-	url := fmt.Sprintf("%s/user", baseURL)
+	url := fmt.Sprintf("%s/user", c.endpoints.API)
 
 	resp, err := c.get(url)
 	if err != nil {
@@ -203,7 +276,7 @@ func (c *APIClient) GetCurrentUser() (*User, error) {
 
 // GetWorkspaceUsers retrieves a page of users in a workspace
 func (c *APIClient) GetWorkspaceUsers(workspaceID string, page int) ([]User, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/users", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/workspaces/%s/users", c.endpoints.API, workspaceID)
 
 	resp, err := c.get(url + "?page=" + strconv.Itoa(page) + "&page-size=" + strconv.Itoa(c.pageSize))
 	if err != nil {
@@ -222,7 +295,7 @@ func (c *APIClient) GetWorkspaceUsers(workspaceID string, page int) ([]User, err
 
 // GetProjects retrieves a page of projects in a workspace
 func (c *APIClient) GetProjects(workspaceID string, page int) ([]Project, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/projects", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/workspaces/%s/projects", c.endpoints.API, workspaceID)
 
 	resp, err := c.get(url + "?page=" + strconv.Itoa(page) + "&page-size=" + strconv.Itoa(c.pageSize))
 	if err != nil {
@@ -239,17 +312,47 @@ func (c *APIClient) GetProjects(workspaceID string, page int) ([]Project, error)
 	return projects, nil
 }
 
-// CreateProject creates a new project in a workspace
+// GetProject retrieves a project by ID
+func (c *APIClient) GetProject(workspaceID, projectID string) (*Project, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/projects/%s", c.endpoints.API, workspaceID, projectID)
+
+	resp, err := c.get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var project Project
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// CreateProject creates a new project in a workspace, billable and public as set with
+// WithProjectDefaults
 func (c *APIClient) CreateProject(workspaceID, name string) (*Project, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/projects", baseURL, workspaceID)
+	return c.CreateProjectFromRequest(workspaceID, c.NewProjectRequest(name))
+}
 
-	project := map[string]any{
-		"name":     name,
-		"billable": true,
-		"public":   false,
+// NewProjectRequest returns the request CreateProject sends for a project, colored by
+// ProjectColor, to be completed with its other settings, e.g. its client
+func (c *APIClient) NewProjectRequest(name string) ProjectCreateRequest {
+	return ProjectCreateRequest{
+		Name:     name,
+		Billable: c.projectBillable,
+		Public:   c.projectPublic,
+		Color:    ProjectColor("", name),
 	}
+}
 
-	resp, err := c.post(url, project)
+// CreateProjectFromRequest creates a new project in a workspace with all its settings
+func (c *APIClient) CreateProjectFromRequest(workspaceID string, request ProjectCreateRequest) (*Project, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/projects", c.endpoints.API, workspaceID)
+
+	resp, err := c.post(url, request)
 	if err != nil {
 		return nil, err
 	}
@@ -264,9 +367,28 @@ func (c *APIClient) CreateProject(workspaceID, name string) (*Project, error) {
 	return &createdProject, nil
 }
 
+// UpdateProject changes the project, e.g. archives it, and returns it as updated
+func (c *APIClient) UpdateProject(workspaceID, projectID string, update ProjectUpdate) (*Project, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/projects/%s", c.endpoints.API, workspaceID, projectID)
+
+	resp, err := c.put(url, update)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var project Project
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
 // GetClients retrieves a page of clients in a workspace
 func (c *APIClient) GetClients(workspaceID string, page int) ([]Client, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/clients", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/workspaces/%s/clients", c.endpoints.API, workspaceID)
 
 	resp, err := c.get(url + "?page=" + strconv.Itoa(page) + "&page-size=" + strconv.Itoa(c.pageSize))
 	if err != nil {
@@ -285,7 +407,7 @@ func (c *APIClient) GetClients(workspaceID string, page int) ([]Client, error) {
 
 // CreateClient creates a new client in a workspace
 func (c *APIClient) CreateClient(workspaceID, name string) (*Client, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/clients", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/workspaces/%s/clients", c.endpoints.API, workspaceID)
 
 	client := map[string]any{
 		"name": name,
@@ -306,9 +428,28 @@ func (c *APIClient) CreateClient(workspaceID, name string) (*Client, error) {
 	return &createdClient, nil
 }
 
+// UpdateClient renames or archives a client and returns it as updated
+func (c *APIClient) UpdateClient(workspaceID, clientID string, update ClientUpdate) (*Client, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/clients/%s", c.endpoints.API, workspaceID, clientID)
+
+	resp, err := c.put(url, update)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var client Client
+	if err := json.NewDecoder(resp.Body).Decode(&client); err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
 // GetTags retrieves a page of tags in a workspace
 func (c *APIClient) GetTags(workspaceID string, page int) ([]Tag, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/tags", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/workspaces/%s/tags", c.endpoints.API, workspaceID)
 
 	resp, err := c.get(url + "?page=" + strconv.Itoa(page) + "&page-size=" + strconv.Itoa(c.pageSize))
 	if err != nil {
@@ -327,7 +468,7 @@ func (c *APIClient) GetTags(workspaceID string, page int) ([]Tag, error) {
 
 // CreateTag creates a new tag in a workspace
 func (c *APIClient) CreateTag(workspaceID, name string) (*Tag, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/tags", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/workspaces/%s/tags", c.endpoints.API, workspaceID)
 
 	tag := map[string]any{
 		"name": name,
@@ -348,41 +489,71 @@ func (c *APIClient) CreateTag(workspaceID, name string) (*Tag, error) {
 	return &createdTag, nil
 }
 
+// UpdateTag renames or archives a tag and returns it as updated
+func (c *APIClient) UpdateTag(workspaceID, tagID string, update TagUpdate) (*Tag, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/tags/%s", c.endpoints.API, workspaceID, tagID)
+
+	resp, err := c.put(url, update)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var tag Tag
+	if err := json.NewDecoder(resp.Body).Decode(&tag); err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
 // GetTimeEntries retrieves a page of time entries for a user in a workspace with optional filters
 func (c *APIClient) GetTimeEntries(workspaceID, userID string, start, end *time.Time, page int) ([]TimeEntry, error) {
-	urlStr := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries", baseURL, workspaceID, userID)
+	timeEntries, _, err := c.GetTimeEntriesPage(workspaceID, userID, start, end, page)
+	return timeEntries, err
+}
 
-	// Add query parameters for filtering
+// GetTimeEntriesPage is GetTimeEntries that also returns the pagination metadata of the page
+func (c *APIClient) GetTimeEntriesPage(workspaceID, userID string, start, end *time.Time, page int) ([]TimeEntry, PageInfo, error) {
+	// Clockify expects UTC timestamps
 	params := url.Values{}
 	if start != nil {
-		params.Add("start", start.Format(time.RFC3339))
+		params.Add("start", start.UTC().Format(time.RFC3339))
 	}
 	if end != nil {
-		params.Add("end", end.Format(time.RFC3339))
+		params.Add("end", end.UTC().Format(time.RFC3339))
 	}
+	return c.getTimeEntriesPage(workspaceID, userID, params, page)
+}
 
-	if len(params) > 0 {
-		urlStr += "?" + params.Encode()
-	}
+// getTimeEntriesPage retrieves a page of a user's time entries matching the filter parameters
+func (c *APIClient) getTimeEntriesPage(workspaceID, userID string, filter url.Values, page int) ([]TimeEntry, PageInfo, error) {
+	urlStr := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries", c.endpoints.API, workspaceID, userID)
+
+	params := url.Values{}
+	maps.Copy(params, filter)
+	params.Set("page", strconv.Itoa(page))
+	params.Set("page-size", strconv.Itoa(c.pageSize))
 
-	resp, err := c.get(urlStr + "?page=" + strconv.Itoa(page) + "&page-size=" + strconv.Itoa(c.pageSize))
+	resp, err := c.get(urlStr + "?" + params.Encode())
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 
 	defer resp.Body.Close()
 
 	var timeEntries []TimeEntry
 	if err := json.NewDecoder(resp.Body).Decode(&timeEntries); err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 
-	return timeEntries, nil
+	return timeEntries, newPageInfo(resp, page, c.pageSize, len(timeEntries)), nil
 }
 
-// GetTimeEntry retrieves a specific time entry by ID
-func (c *APIClient) GetTimeEntry(workspaceID, timeEntryID string) (*TimeEntry, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/time-entries/%s", baseURL, workspaceID, timeEntryID)
+// GetRunningTimeEntry retrieves the currently running time entry of a user. Returns nil if no timer is running.
+func (c *APIClient) GetRunningTimeEntry(workspaceID, userID string) (*TimeEntry, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries?in-progress=true", c.endpoints.API, workspaceID, userID)
 
 	resp, err := c.get(url)
 	if err != nil {
@@ -391,19 +562,23 @@ func (c *APIClient) GetTimeEntry(workspaceID, timeEntryID string) (*TimeEntry, e
 
 	defer resp.Body.Close()
 
-	var timeEntry TimeEntry
-	if err := json.NewDecoder(resp.Body).Decode(&timeEntry); err != nil {
+	var timeEntries []TimeEntry
+	if err := json.NewDecoder(resp.Body).Decode(&timeEntries); err != nil {
 		return nil, err
 	}
 
-	return &timeEntry, nil
+	if len(timeEntries) == 0 {
+		return nil, nil
+	}
+
+	return &timeEntries[0], nil
 }
 
-// CreateTimeEntry creates a new time entry in a workspace
-func (c *APIClient) CreateTimeEntry(workspaceID string, request NewTimeEntryRequest) (*TimeEntry, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/time-entries", baseURL, workspaceID)
+// GetTimeEntry retrieves a specific time entry by ID
+func (c *APIClient) GetTimeEntry(workspaceID, timeEntryID string) (*TimeEntry, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/time-entries/%s", c.endpoints.API, workspaceID, timeEntryID)
 
-	resp, err := c.post(url, request)
+	resp, err := c.get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -418,28 +593,33 @@ func (c *APIClient) CreateTimeEntry(workspaceID string, request NewTimeEntryRequ
 	return &timeEntry, nil
 }
 
-// CreateTimeEntryForUser creates a new time entry for a specific user in a workspace
-func (c *APIClient) CreateTimeEntryForUser(workspaceID, userID string, request NewTimeEntryRequest) (*TimeEntry, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries", baseURL, workspaceID, userID)
-
-	resp, err := c.post(url, request)
-	if err != nil {
-		return nil, err
-	}
+// CreateTimeEntry creates a new time entry in a workspace
+//
+// A failed attempt is retried only once the entry is known not to have been created.
+func (c *APIClient) CreateTimeEntry(workspaceID string, request NewTimeEntryRequest) (*TimeEntry, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/time-entries", c.endpoints.API, workspaceID)
 
-	defer resp.Body.Close()
+	return c.createTimeEntry(url, workspaceID, "", request)
+}
 
-	var timeEntry TimeEntry
-	if err := json.NewDecoder(resp.Body).Decode(&timeEntry); err != nil {
-		return nil, err
-	}
+// CreateTimeEntryForUser creates a new time entry for a specific user in a workspace
+//
+// A failed attempt is retried only once the entry is known not to have been created.
+func (c *APIClient) CreateTimeEntryForUser(workspaceID, userID string, request NewTimeEntryRequest) (*TimeEntry, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries", c.endpoints.API, workspaceID, userID)
 
-	return &timeEntry, nil
+	return c.createTimeEntry(url, workspaceID, userID, request)
 }
 
 // UpdateTimeEntry updates an existing time entry
+//
+// Entries in a locked period fail with ErrLocked.
 func (c *APIClient) UpdateTimeEntry(workspaceID, timeEntryID string, request UpdateTimeEntryRequest) (*TimeEntry, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/time-entries/%s", baseURL, workspaceID, timeEntryID)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/workspaces/%s/time-entries/%s", c.endpoints.API, workspaceID, timeEntryID)
 
 	resp, err := c.put(url, request)
 	if err != nil {
@@ -458,7 +638,7 @@ func (c *APIClient) UpdateTimeEntry(workspaceID, timeEntryID string, request Upd
 
 // StopTimeEntry stops a currently running time entry for a user
 func (c *APIClient) StopTimeEntry(workspaceID, userID string, endTime time.Time) (*TimeEntry, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries", baseURL, workspaceID, userID)
+	url := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries", c.endpoints.API, workspaceID, userID)
 
 	request := map[string]any{
 		"end": endTime.Format(time.RFC3339),
@@ -480,8 +660,10 @@ func (c *APIClient) StopTimeEntry(workspaceID, userID string, endTime time.Time)
 }
 
 // DeleteTimeEntry deletes a time entry
+//
+// Entries in a locked period fail with ErrLocked.
 func (c *APIClient) DeleteTimeEntry(workspaceID, timeEntryID string) error {
-	url := fmt.Sprintf("%s/workspaces/%s/time-entries/%s", baseURL, workspaceID, timeEntryID)
+	url := fmt.Sprintf("%s/workspaces/%s/time-entries/%s", c.endpoints.API, workspaceID, timeEntryID)
 
 	resp, err := c.delete(url)
 	if err != nil {
@@ -499,7 +681,7 @@ func (c *APIClient) DeleteTimeEntry(workspaceID, timeEntryID string) error {
 
 // GetProjectTasks retrieves a page of tasks for a project
 func (c *APIClient) GetProjectTasks(workspaceID, projectID string, page int) ([]Task, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/projects/%s/tasks", baseURL, workspaceID, projectID)
+	url := fmt.Sprintf("%s/workspaces/%s/projects/%s/tasks", c.endpoints.API, workspaceID, projectID)
 
 	resp, err := c.get(url + "?page=" + strconv.Itoa(page) + "&page-size=" + strconv.Itoa(c.pageSize))
 	if err != nil {
@@ -540,16 +722,16 @@ func (c *APIClient) IterProjectTasks(workspaceID, projectID string) iter.Seq2[[]
 	}
 }
 
-// CreateTask creates a new task in a project
+// CreateTask creates a new active task in a project
 func (c *APIClient) CreateTask(workspaceID, projectID, name string) (*Task, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/projects/%s/tasks", baseURL, workspaceID, projectID)
+	return c.CreateTaskFromRequest(workspaceID, projectID, TaskCreateRequest{Name: name})
+}
 
-	task := map[string]any{
-		"name":   name,
-		"status": "ACTIVE",
-	}
+// CreateTaskFromRequest creates a new task in a project with all its settings
+func (c *APIClient) CreateTaskFromRequest(workspaceID, projectID string, request TaskCreateRequest) (*Task, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/projects/%s/tasks", c.endpoints.API, workspaceID, projectID)
 
-	resp, err := c.post(url, task)
+	resp, err := c.post(url, request)
 	if err != nil {
 		return nil, err
 	}
@@ -564,9 +746,48 @@ func (c *APIClient) CreateTask(workspaceID, projectID, name string) (*Task, erro
 	return &createdTask, nil
 }
 
+// SetTaskStatus marks the task TaskDone or TaskActive, keeping its other settings, and
+// returns it as updated
+func (c *APIClient) SetTaskStatus(workspaceID string, task Task, status string) (*Task, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/projects/%s/tasks/%s", c.endpoints.API, workspaceID, task.ProjectID, task.ID)
+
+	// The API replaces the whole task on update
+	assigneeIDs := task.AssigneeIDs
+	if assigneeIDs == nil {
+		assigneeIDs = make([]string, 0)
+	}
+	update := map[string]any{
+		"name":        task.Name,
+		"status":      status,
+		"assigneeIds": assigneeIDs,
+		"billable":    task.Billable,
+	}
+	if task.Estimate != "" {
+		update["estimate"] = task.Estimate
+	}
+
+	resp, err := c.put(url, update)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var updated Task
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
 // CreateWebhook creates a new webhook in a workspace
 func (c *APIClient) CreateWebhook(workspaceID string, request WebhookRequest) (*Webhook, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/webhooks", baseURL, workspaceID)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/workspaces/%s/webhooks", c.endpoints.API, workspaceID)
 
 	resp, err := c.post(url, request)
 	if err != nil {
@@ -585,7 +806,7 @@ func (c *APIClient) CreateWebhook(workspaceID string, request WebhookRequest) (*
 
 // DeleteWebhook deletes a webhook in a workspace
 func (c *APIClient) DeleteWebhook(workspaceID, webhookID string) error {
-	url := fmt.Sprintf("%s/workspaces/%s/webhooks/%s", baseURL, workspaceID, webhookID)
+	url := fmt.Sprintf("%s/workspaces/%s/webhooks/%s", c.endpoints.API, workspaceID, webhookID)
 
 	resp, err := c.delete(url)
 	if err != nil {
@@ -599,7 +820,7 @@ func (c *APIClient) DeleteWebhook(workspaceID, webhookID string) error {
 
 // GetWebhooks retrieves all webhooks in a workspace
 func (c *APIClient) GetWebhooks(workspaceID string) ([]Webhook, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/webhooks", baseURL, workspaceID)
+	url := fmt.Sprintf("%s/workspaces/%s/webhooks", c.endpoints.API, workspaceID)
 
 	resp, err := c.get(url)
 	if err != nil {
@@ -623,7 +844,7 @@ func (c *APIClient) GetWebhooks(workspaceID string) ([]Webhook, error) {
 
 // GenerateWebhookAuthToken generates a new auth token for a webhook
 func (c *APIClient) GenerateWebhookAuthToken(workspaceID, webhookID string) (*Webhook, error) {
-	url := fmt.Sprintf("%s/workspaces/%s/webhooks/%s/auth-token", baseURL, workspaceID, webhookID)
+	url := fmt.Sprintf("%s/workspaces/%s/webhooks/%s/auth-token", c.endpoints.API, workspaceID, webhookID)
 
 	resp, err := c.patch(url, nil)
 	if err != nil {
@@ -669,11 +890,24 @@ func (c *APIClient) IterWorkspaceUsers(workspaceID string) iter.Seq2[[]User, err
 // IterTimeEntries iterates over all time entries for a user in a workspace, page by page
 func (c *APIClient) IterTimeEntries(workspaceID, userID string, start, end *time.Time) iter.Seq2[[]TimeEntry, error] {
 	return func(yield func([]TimeEntry, error) bool) {
+		for page, err := range c.IterTimeEntryPages(workspaceID, userID, start, end) {
+			if !yield(page.Entries, err) {
+				return
+			}
+		}
+	}
+}
+
+// IterTimeEntryPages iterates over all time entries for a user in a workspace like
+// IterTimeEntries, with the pagination metadata of each page. It stops after the page
+// Clockify marks as the last one.
+func (c *APIClient) IterTimeEntryPages(workspaceID, userID string, start, end *time.Time) iter.Seq2[TimeEntryPage, error] {
+	return func(yield func(TimeEntryPage, error) bool) {
 		page := 1
 		for {
-			timeEntries, err := c.GetTimeEntries(workspaceID, userID, start, end, page)
+			timeEntries, info, err := c.GetTimeEntriesPage(workspaceID, userID, start, end, page)
 			if err != nil {
-				yield(nil, err)
+				yield(TimeEntryPage{}, err)
 				return
 			}
 
@@ -681,7 +915,7 @@ func (c *APIClient) IterTimeEntries(workspaceID, userID string, start, end *time
 				return
 			}
 
-			if !yield(timeEntries, nil) {
+			if !yield(TimeEntryPage{Entries: timeEntries, PageInfo: info}, nil) || info.Last {
 				return
 			}
 
@@ -764,6 +998,11 @@ func (c *APIClient) IterProjects(workspaceID string) iter.Seq2[[]Project, error]
 
 // StartTimer starts a new timer for a user (creates a time entry without end time)
 func (c *APIClient) StartTimer(workspaceID, userID, description string, projectID *string, taskID *string, tagIDs []string) (*TimeEntry, error) {
+	description, err := c.ExpandDescription(description)
+	if err != nil {
+		return nil, err
+	}
+
 	request := NewTimeEntryRequest{
 		Start:       time.Now(),
 		Billable:    true,
@@ -786,7 +1025,76 @@ func (c *APIClient) StartTimer(workspaceID, userID, description string, projectI
 	return c.CreateTimeEntryForUser(workspaceID, userID, request)
 }
 
-// CreatePastTimeEntry creates a completed time entry for a specific date and duration
+// StartBreak starts a break timer for a user, a non-billable entry without a project that
+// reports total apart from work. Clockify rejects it unless the workspace tracks breaks.
+func (c *APIClient) StartBreak(workspaceID, userID, description string) (*TimeEntry, error) {
+	request := NewTimeEntryRequest{
+		Start:       time.Now(),
+		Description: description,
+		TagIDs:      make([]string, 0),
+		Type:        TimeEntryBreak,
+	}
+	return c.CreateTimeEntryForUser(workspaceID, userID, request)
+}
+
+// DescriptionExpander rewrites the descriptions of the timers the client starts, e.g. expanding
+// their placeholders
+type DescriptionExpander func(ctx context.Context, description string) (string, error)
+
+// WithDescriptionExpander expands the descriptions of StartTimer and StartDefaultTimer, an
+// error fails the start
+func WithDescriptionExpander(expand DescriptionExpander) ClientOption {
+	return func(c *APIClient) {
+		c.expandDescription = expand
+	}
+}
+
+// ExpandDescription expands a timer description like StartTimer does, e.g. before queueing
+// the start while Clockify is unreachable
+func (c *APIClient) ExpandDescription(description string) (string, error) {
+	if c.expandDescription == nil {
+		return description, nil
+	}
+	expanded, err := c.expandDescription(c.context(), description)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand description %q: %w", description, err)
+	}
+	return expanded, nil
+}
+
+// TimerDefaults are what StartDefaultTimer tracks, e.g. a user's usual project
+type TimerDefaults struct {
+	ProjectID string
+	TaskID    string // Requires ProjectID
+	TagIDs    []string
+	Billable  bool
+}
+
+// StartDefaultTimer starts a new timer for a user tracking the defaults
+func (c *APIClient) StartDefaultTimer(workspaceID, userID, description string, defaults TimerDefaults) (*TimeEntry, error) {
+	description, err := c.ExpandDescription(description)
+	if err != nil {
+		return nil, err
+	}
+
+	request := NewTimeEntryRequest{
+		Start:       time.Now(),
+		Billable:    defaults.Billable,
+		Description: description,
+		ProjectID:   defaults.ProjectID,
+		TaskID:      defaults.TaskID,
+		TagIDs:      defaults.TagIDs,
+	}
+
+	if request.TagIDs == nil {
+		request.TagIDs = make([]string, 0)
+	}
+
+	return c.CreateTimeEntryForUser(workspaceID, userID, request)
+}
+
+// CreatePastTimeEntry creates a completed time entry for a specific date and duration,
+// rounded up by the WithBillingIncrements increments
 func (c *APIClient) CreatePastTimeEntry(workspaceID, userID string, startTime time.Time, duration time.Duration, description string, projectID *string, taskID *string, tagIDs []string, billable bool) (*TimeEntry, error) {
 	endTime := startTime.Add(duration)
 
@@ -810,10 +1118,15 @@ func (c *APIClient) CreatePastTimeEntry(workspaceID, userID string, startTime ti
 		request.TagIDs = make([]string, 0)
 	}
 
+	if err := c.ApplyBillingIncrements(workspaceID, &request); err != nil {
+		return nil, err
+	}
+
 	return c.CreateTimeEntryForUser(workspaceID, userID, request)
 }
 
-// CreateTimeEntryWithDates creates a time entry with specific start and end times
+// CreateTimeEntryWithDates creates a time entry with specific start and end times, the end
+// moved by the WithBillingIncrements increments
 func (c *APIClient) CreateTimeEntryWithDates(workspaceID, userID string, startTime, endTime time.Time, description string, projectID *string, taskID *string, tagIDs []string, billable bool) (*TimeEntry, error) {
 	request := NewTimeEntryRequest{
 		Start:       startTime,
@@ -835,6 +1148,10 @@ func (c *APIClient) CreateTimeEntryWithDates(workspaceID, userID string, startTi
 		request.TagIDs = make([]string, 0)
 	}
 
+	if err := c.ApplyBillingIncrements(workspaceID, &request); err != nil {
+		return nil, err
+	}
+
 	return c.CreateTimeEntryForUser(workspaceID, userID, request)
 }
 
@@ -844,6 +1161,11 @@ func (c *APIClient) CreateHistoricalWorkday(workspaceID, userID string, date tim
 	var errors []error
 
 	for _, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("failed to create entry '%s': %w", entry.Description, err))
+			continue
+		}
+
 		startTime := time.Date(date.Year(), date.Month(), date.Day(),
 			entry.StartHour, entry.StartMinute, 0, 0, date.Location())
 
@@ -888,7 +1210,7 @@ func (c *APIClient) FindWorkspaceByName(name string) (*Workspace, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("workspace '%s' not found", name)
+	return nil, fmt.Errorf("workspace '%s' %w", name, ErrNotFound)
 }
 
 // FindProjectByName finds a project by name in a workspace. Returns nil if not found.
@@ -905,25 +1227,73 @@ func (c *APIClient) FindProjectByName(workspaceID, name string) (*Project, error
 		}
 	}
 
-	return nil, fmt.Errorf("project '%s' not found in workspace", name)
+	return nil, fmt.Errorf("project '%s' %w in workspace", name, ErrNotFound)
 }
 
-// GetProjectTimeEntries retrieves all time entries from a project
-func (c *APIClient) GetProjectTimeEntries(workspaceID, projectID string, userID string) ([]TimeEntry, error) {
-	// TODO: make a generator (iter.Seq2)
-	var filteredEntries []TimeEntry
+// FindTaskByName finds a task by name in a project
+func (c *APIClient) FindTaskByName(workspaceID, projectID, name string) (*Task, error) {
+	for tasks, err := range c.IterProjectTasks(workspaceID, projectID) {
+		if err != nil {
+			return nil, err
+		}
+
+		for _, task := range tasks {
+			if task.Name == name {
+				return &task, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("task '%s' %w in project", name, ErrNotFound)
+}
 
-	for timeEntries, err := range c.IterTimeEntries(workspaceID, userID, nil, nil) {
+// FindTagByName finds a tag by name in a workspace
+func (c *APIClient) FindTagByName(workspaceID, name string) (*Tag, error) {
+	for tags, err := range c.IterTags(workspaceID) {
 		if err != nil {
 			return nil, err
 		}
 
-		for _, entry := range timeEntries {
-			if entry.ProjectID == projectID {
-				filteredEntries = append(filteredEntries, entry)
+		for _, tag := range tags {
+			if tag.Name == name {
+				return &tag, nil
 			}
 		}
 	}
 
-	return filteredEntries, nil
+	return nil, fmt.Errorf("tag '%s' %w in workspace", name, ErrNotFound)
+}
+
+// GetProjectTimeEntries iterates over a user's time entries in a project, newest first. The
+// entries are filtered by Clockify and fetched a page at a time.
+func (c *APIClient) GetProjectTimeEntries(workspaceID, projectID string, userID string) iter.Seq2[TimeEntry, error] {
+	return c.iterFilteredTimeEntries(workspaceID, userID, url.Values{"project": {projectID}})
+}
+
+// GetTagTimeEntries iterates over a user's time entries with a tag, newest first, like
+// GetProjectTimeEntries
+func (c *APIClient) GetTagTimeEntries(workspaceID, tagID string, userID string) iter.Seq2[TimeEntry, error] {
+	return c.iterFilteredTimeEntries(workspaceID, userID, url.Values{"tags": {tagID}})
+}
+
+func (c *APIClient) iterFilteredTimeEntries(workspaceID, userID string, filter url.Values) iter.Seq2[TimeEntry, error] {
+	return func(yield func(TimeEntry, error) bool) {
+		for page := 1; ; page++ {
+			timeEntries, info, err := c.getTimeEntriesPage(workspaceID, userID, filter, page)
+			if err != nil {
+				yield(TimeEntry{}, err)
+				return
+			}
+
+			for _, entry := range timeEntries {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+
+			if info.Last {
+				return
+			}
+		}
+	}
 }
@@ -0,0 +1,118 @@
+package clockify_test
+
+import (
+	"fmt"
+	"iter"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/clockify/clockifytest"
+)
+
+const (
+	benchUsers          = 8
+	benchEntriesPerUser = 500
+	benchPageSize       = 50
+)
+
+// benchWorkspace seeds a fake with users tracking benchEntriesPerUser entries each
+func benchWorkspace(b *testing.B) (*clockifytest.Server, clockify.Workspace, []clockify.User) {
+	b.Helper()
+
+	srv := clockifytest.NewServer()
+	b.Cleanup(srv.Close)
+	workspace := srv.AddWorkspace("Bench")
+	project := srv.AddProject(workspace.ID, "Bench")
+
+	users := []clockify.User{srv.CurrentUser()}
+	for i := 1; i < benchUsers; i++ {
+		users = append(users, srv.AddMember(workspace.ID, fmt.Sprintf("user%d@example.com", i), fmt.Sprintf("User %d", i)))
+	}
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	for _, user := range users {
+		for i := range benchEntriesPerUser {
+			entryStart := start.Add(time.Duration(i) * time.Hour)
+			entryEnd := entryStart.Add(30 * time.Minute)
+			srv.AddTimeEntry(clockify.TimeEntry{
+				WorkspaceID:  workspace.ID,
+				UserID:       user.ID,
+				ProjectID:    project.ID,
+				Description:  fmt.Sprintf("entry %d", i),
+				TimeInterval: &clockify.TimeInterval{Start: entryStart, End: &entryEnd},
+			})
+		}
+	}
+	return srv, workspace, users
+}
+
+// drain counts the items of a paginated iterator
+func drain[T any](b *testing.B, pages iter.Seq2[[]T, error]) int {
+	n := 0
+	for page, err := range pages {
+		if err != nil {
+			b.Error(err)
+			return n
+		}
+		n += len(page)
+	}
+	return n
+}
+
+// BenchmarkIterTimeEntries paginates the entries of every user one after the other, the
+// baseline of the parallel benchmarks
+func BenchmarkIterTimeEntries(b *testing.B) {
+	srv, workspace, users := benchWorkspace(b)
+	client := srv.Client(clockify.WithPageSize(benchPageSize))
+
+	b.ResetTimer()
+	for range b.N {
+		for _, user := range users {
+			drain(b, client.IterTimeEntries(workspace.ID, user.ID, nil, nil))
+		}
+	}
+	b.ReportMetric(float64(b.N*benchUsers*benchEntriesPerUser)/b.Elapsed().Seconds(), "entries/s")
+}
+
+// BenchmarkIterTimeEntriesParallel paginates the entries of every user from a goroutine each,
+// sharing one client, with the default pool and with the 2 idle connections of net/http
+func BenchmarkIterTimeEntriesParallel(b *testing.B) {
+	srv, workspace, users := benchWorkspace(b)
+
+	for _, idle := range []int{2, clockify.DefaultMaxIdleConnsPerHost} {
+		b.Run(fmt.Sprintf("idle=%d", idle), func(b *testing.B) {
+			client := srv.Client(clockify.WithPageSize(benchPageSize), clockify.WithMaxIdleConnsPerHost(idle))
+
+			b.ResetTimer()
+			for range b.N {
+				var wg sync.WaitGroup
+				for _, user := range users {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						drain(b, client.IterTimeEntries(workspace.ID, user.ID, nil, nil))
+					}()
+				}
+				wg.Wait()
+			}
+			b.ReportMetric(float64(b.N*benchUsers*benchEntriesPerUser)/b.Elapsed().Seconds(), "entries/s")
+		})
+	}
+}
+
+// BenchmarkIterProjectsParallel lists the projects from GOMAXPROCS goroutines sharing a client
+func BenchmarkIterProjectsParallel(b *testing.B) {
+	srv, workspace, _ := benchWorkspace(b)
+	for i := range 200 {
+		srv.AddProject(workspace.ID, fmt.Sprintf("Project %d", i))
+	}
+	client := srv.Client(clockify.WithPageSize(benchPageSize))
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			drain(b, client.IterProjects(workspace.ID))
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package clockifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// RecorderMode selects whether a Recorder talks to the real API or replays fixtures
+type RecorderMode int
+
+const (
+	// ModeReplay serves responses from the fixture file and never touches the network
+	ModeReplay RecorderMode = iota
+	// ModeRecord forwards requests to the real API and captures the responses
+	ModeRecord
+)
+
+// RecordEnvVar switches recorders created by RecorderModeFromEnv into record mode when set to "1"
+const RecordEnvVar = "CCWS_RECORD"
+
+// ErrNoInteraction is returned in replay mode for requests missing from the fixture
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// RecorderModeFromEnv returns ModeRecord if CCWS_RECORD=1 and ModeReplay otherwise
+func RecorderModeFromEnv() RecorderMode {
+	if os.Getenv(RecordEnvVar) == "1" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Interaction is a single recorded request/response pair
+type Interaction struct {
+	Method       string `json:"method"`
+	URL          string `json:"url"` // Path and query only, the host and headers are not recorded
+	RequestBody  string `json:"requestBody,omitempty"`
+	Status       int    `json:"status"`
+	ContentType  string `json:"contentType,omitempty"`
+	ResponseBody string `json:"responseBody"`
+}
+
+// cassette is the fixture file format
+type cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+const cassetteVersion = 1
+
+// sensitivePatterns are the parts of recorded payloads replaced before writing fixtures
+var sensitivePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`"(authToken|apiKey|password|token|secret)"\s*:\s*"[^"]*"`),
+	regexp.MustCompile(`"(email)"\s*:\s*"[^"]*"`),
+}
+
+// Recorder is a VCR-style transport middleware. In record mode it captures real API
+// responses, in replay mode it serves them back in order.
+type Recorder struct {
+	path string
+	mode RecorderMode
+
+	mu       sync.Mutex
+	cassette cassette
+	used     []bool
+}
+
+// NewRecorder creates a recorder backed by the fixture file at path.
+// In replay mode the file must exist.
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, cassette: cassette{Version: cassetteVersion}}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Middleware returns the transport middleware to pass to clockify.WithTransportMiddleware
+func (r *Recorder) Middleware() clockify.TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return clockify.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqBody, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			if r.mode == ModeReplay {
+				return r.replay(req, reqBody)
+			}
+			return r.record(next, req, reqBody)
+		})
+	}
+}
+
+// Save writes the recorded interactions to the fixture file. It is a no-op in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+func (r *Recorder) record(next http.RoundTripper, req *http.Request, reqBody string) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:       req.Method,
+		URL:          req.URL.RequestURI(),
+		RequestBody:  sanitize(reqBody),
+		Status:       resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ResponseBody: sanitize(string(respBody)),
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, reqBody string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	uri := req.URL.RequestURI()
+	body := sanitize(reqBody)
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Method != req.Method || interaction.URL != uri || interaction.RequestBody != body {
+			continue
+		}
+		r.used[i] = true
+
+		header := make(http.Header)
+		if interaction.ContentType != "" {
+			header.Set("Content-Type", interaction.ContentType)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+			StatusCode:    interaction.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewBufferString(interaction.ResponseBody)),
+			ContentLength: int64(len(interaction.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, uri)
+}
+
+func readRequestBody(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		return "", nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	return string(data), err
+}
+
+// sanitize replaces secrets and personal data so fixtures can be committed
+func sanitize(s string) string {
+	for _, pattern := range sensitivePatterns {
+		s = pattern.ReplaceAllString(s, `"$1":"REDACTED"`)
+	}
+	return s
+}
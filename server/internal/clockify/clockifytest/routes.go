@@ -0,0 +1,888 @@
+package clockifytest
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// MaxWebhooksPerWorkspace mirrors Clockify's limit on webhooks in a single workspace
+const MaxWebhooksPerWorkspace = clockify.MaxWebhooksPerWorkspace
+
+func (s *Server) routes(mux *http.ServeMux) {
+	p := apiPrefix
+
+	mux.HandleFunc("GET "+p+"/user", s.getUser)
+	mux.HandleFunc("GET "+p+"/workspaces", s.getWorkspaces)
+	mux.HandleFunc("POST "+p+"/workspaces", s.createWorkspace)
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}", s.getWorkspace)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/hourly-rate", s.setWorkspaceRate)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/cost-rate", s.setWorkspaceRate)
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/users", s.getWorkspaceUsers)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/users", s.inviteUser)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/users/{user}", s.updateUserStatus)
+	mux.HandleFunc("DELETE "+p+"/workspaces/{ws}/users/{user}", s.removeUser)
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/member-profile/{user}", s.getMemberProfile)
+
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/projects", s.getProjects)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/projects", s.createProject)
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/projects/{project}", s.getProject)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/projects/{project}", s.updateProject)
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/projects/{project}/tasks", s.getTasks)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/projects/{project}/tasks", s.createTask)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/projects/{project}/tasks/{task}", s.updateTask)
+
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/clients", s.getClients)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/clients", s.createClient)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/clients/{id}", s.updateClient)
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/tags", s.getTags)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/tags", s.createTag)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/tags/{id}", s.updateTag)
+
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/user/{user}/time-entries", s.getUserTimeEntries)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/user/{user}/time-entries", s.createTimeEntry)
+	mux.HandleFunc("PATCH "+p+"/workspaces/{ws}/user/{user}/time-entries", s.stopTimer)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/time-entries", s.createTimeEntry)
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/time-entries/{id}", s.getTimeEntry)
+	mux.HandleFunc("PUT "+p+"/workspaces/{ws}/time-entries/{id}", s.updateTimeEntry)
+	mux.HandleFunc("DELETE "+p+"/workspaces/{ws}/time-entries/{id}", s.deleteTimeEntry)
+
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/approval-requests", s.getApprovals)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/approval-requests", s.submitApproval)
+	mux.HandleFunc("PATCH "+p+"/workspaces/{ws}/approval-requests/{id}", s.updateApproval)
+
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/scheduling/assignments/all", s.getAssignments)
+
+	mux.HandleFunc("GET "+p+"/workspaces/{ws}/webhooks", s.getWebhooks)
+	mux.HandleFunc("POST "+p+"/workspaces/{ws}/webhooks", s.createWebhook)
+	mux.HandleFunc("DELETE "+p+"/workspaces/{ws}/webhooks/{id}", s.deleteWebhook)
+	mux.HandleFunc("PATCH "+p+"/workspaces/{ws}/webhooks/{id}/auth-token", s.regenerateWebhookToken)
+
+	mux.HandleFunc("POST "+reportsPrefix+"/workspaces/{ws}/reports/detailed", s.detailedReport)
+}
+
+// * Users and workspaces
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.CurrentUser())
+}
+
+func (s *Server) getWorkspaces(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.workspaces)
+}
+
+func (s *Server) createWorkspace(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.workspaces, func(ws clockify.Workspace) bool { return ws.Name == request.Name }) {
+		writeError(w, http.StatusBadRequest, "Workspace with that name already exists")
+		return
+	}
+	ws := clockify.Workspace{ID: s.newID(), Name: request.Name, FeatureSubscriptionType: "FREE"}
+	s.workspaces = append(s.workspaces, ws)
+	writeJSON(w, http.StatusCreated, ws)
+}
+
+// findWorkspace returns the index of the workspace or -1. Callers must hold s.mu.
+func (s *Server) findWorkspace(r *http.Request) int {
+	id := r.PathValue("ws")
+	return slices.IndexFunc(s.workspaces, func(ws clockify.Workspace) bool { return ws.ID == id })
+}
+
+func (s *Server) getWorkspace(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findWorkspace(r)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.workspaces[i])
+}
+
+func (s *Server) setWorkspaceRate(w http.ResponseWriter, r *http.Request) {
+	var rate clockify.Rate
+	if !decode(w, r, &rate) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findWorkspace(r)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/cost-rate") {
+		if !slices.Contains(s.workspaces[i].Features, clockify.FeatureLaborCost) {
+			writeError(w, http.StatusForbidden, "Access Denied")
+			return
+		}
+		s.workspaces[i].CostRate = &rate
+	} else {
+		s.workspaces[i].HourlyRate = &rate
+	}
+	writeJSON(w, http.StatusOK, s.workspaces[i])
+}
+
+func (s *Server) getWorkspaceUsers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, email := r.PathValue("ws"), r.URL.Query().Get("email")
+	users := []clockify.User{s.user}
+	for _, m := range s.members {
+		if m.workspaceID == ws {
+			users = append(users, m.user)
+		}
+	}
+	users = filter(users, func(u clockify.User) bool { return strings.Contains(u.Email, email) })
+	writeJSON(w, http.StatusOK, paginate(w, r, users))
+}
+
+func (s *Server) inviteUser(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := s.findWorkspace(r)
+	if ws < 0 {
+		writeError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+	if s.findMember(r.PathValue("ws"), func(u clockify.User) bool { return u.Email == request.Email }) >= 0 {
+		writeError(w, http.StatusBadRequest, "User is already a member of the workspace")
+		return
+	}
+
+	user := clockify.NewUser(s.newID(), request.Email, "")
+	user.Status = string(clockify.UserPending)
+	s.members = append(s.members, member{workspaceID: r.PathValue("ws"), user: user})
+	writeJSON(w, http.StatusOK, s.workspaces[ws])
+}
+
+// findMember returns the index of the matching member of a workspace or -1. Callers must
+// hold s.mu.
+func (s *Server) findMember(workspaceID string, match func(clockify.User) bool) int {
+	return slices.IndexFunc(s.members, func(m member) bool { return m.workspaceID == workspaceID && match(m.user) })
+}
+
+func (s *Server) updateUserStatus(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Status clockify.UserStatus `json:"status"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Status != clockify.UserActive && request.Status != clockify.UserInactive {
+		writeError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("user")
+	i := s.findMember(r.PathValue("ws"), func(u clockify.User) bool { return u.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	s.members[i].user.Status = string(request.Status)
+	writeJSON(w, http.StatusOK, s.members[i].user)
+}
+
+func (s *Server) removeUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("user")
+	i := s.findMember(r.PathValue("ws"), func(u clockify.User) bool { return u.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	s.members = slices.Delete(s.members, i, i+1)
+	w.WriteHeader(http.StatusOK)
+}
+
+// getMemberProfile serves the profile set by SetMemberProfile, or the default one
+func (s *Server) getMemberProfile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, id := r.PathValue("ws"), r.PathValue("user")
+	user := s.user
+	if id != s.user.ID {
+		i := s.findMember(ws, func(u clockify.User) bool { return u.ID == id })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		user = s.members[i].user
+	}
+
+	profile, ok := s.profiles[ws+"/"+id]
+	if !ok {
+		profile = clockify.MemberProfile{
+			WeekStart:    "MONDAY",
+			WorkCapacity: "PT8H",
+			WorkingDays:  []string{"MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY"},
+		}
+	}
+	profile.Email, profile.Name = user.Email, user.Name
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// * Projects, tasks, clients and tags
+
+func (s *Server) getProjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	projects := filter(s.projects, func(p clockify.Project) bool { return p.WorkspaceID == ws })
+	writeJSON(w, http.StatusOK, paginate(w, r, projects))
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, id := r.PathValue("ws"), r.PathValue("project")
+	i := slices.IndexFunc(s.projects, func(p clockify.Project) bool { return p.WorkspaceID == ws && p.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.projects[i])
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		clockify.Project
+		Estimate *clockify.TimeEstimate `json:"estimate"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project := request.Project
+	if request.Estimate != nil {
+		project.TimeEstimate = request.Estimate
+	}
+	project.ID = s.newID()
+	project.WorkspaceID = r.PathValue("ws")
+	s.projects = append(s.projects, project)
+	writeJSON(w, http.StatusCreated, project)
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	var update clockify.ProjectUpdate
+	if !decode(w, r, &update) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, id := r.PathValue("ws"), r.PathValue("project")
+	i := slices.IndexFunc(s.projects, func(p clockify.Project) bool { return p.WorkspaceID == ws && p.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	project := &s.projects[i]
+	if update.Name != nil {
+		project.Name = *update.Name
+	}
+	if update.ClientID != nil {
+		project.ClientID = *update.ClientID
+	}
+	if update.Color != nil {
+		project.Color = *update.Color
+	}
+	if update.Archived != nil {
+		project.Archived = *update.Archived
+	}
+	writeJSON(w, http.StatusOK, *project)
+}
+
+func (s *Server) getTasks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID := r.PathValue("project")
+	tasks := filter(s.tasks, func(t clockify.Task) bool { return t.ProjectID == projectID })
+	writeJSON(w, http.StatusOK, paginate(w, r, tasks))
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	var task clockify.Task
+	if !decode(w, r, &task) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task.ID = s.newID()
+	task.ProjectID = r.PathValue("project")
+	if task.Status == "" {
+		task.Status = "ACTIVE"
+	}
+	s.tasks = append(s.tasks, task)
+	writeJSON(w, http.StatusCreated, task)
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	var update clockify.Task
+	if !decode(w, r, &update) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID, id := r.PathValue("project"), r.PathValue("task")
+	i := slices.IndexFunc(s.tasks, func(t clockify.Task) bool { return t.ProjectID == projectID && t.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	update.ID, update.ProjectID = id, projectID
+	s.tasks[i] = update
+	writeJSON(w, http.StatusOK, update)
+}
+
+func (s *Server) getClients(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	clients := filter(s.clients, func(c clockify.Client) bool { return c.WorkspaceID == ws })
+	writeJSON(w, http.StatusOK, paginate(w, r, clients))
+}
+
+func (s *Server) createClient(w http.ResponseWriter, r *http.Request) {
+	var client clockify.Client
+	if !decode(w, r, &client) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client.ID = s.newID()
+	client.WorkspaceID = r.PathValue("ws")
+	s.clients = append(s.clients, client)
+	writeJSON(w, http.StatusCreated, client)
+}
+
+func (s *Server) updateClient(w http.ResponseWriter, r *http.Request) {
+	var update clockify.ClientUpdate
+	if !decode(w, r, &update) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, id := r.PathValue("ws"), r.PathValue("id")
+	i := slices.IndexFunc(s.clients, func(c clockify.Client) bool { return c.WorkspaceID == ws && c.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Client not found")
+		return
+	}
+
+	s.clients[i].Name, s.clients[i].Archived = update.Name, update.Archived
+	writeJSON(w, http.StatusOK, s.clients[i])
+}
+
+func (s *Server) getTags(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	tags := filter(s.tags, func(t clockify.Tag) bool { return t.WorkspaceID == ws })
+	writeJSON(w, http.StatusOK, paginate(w, r, tags))
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request) {
+	var tag clockify.Tag
+	if !decode(w, r, &tag) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag.ID = s.newID()
+	tag.WorkspaceID = r.PathValue("ws")
+	s.tags = append(s.tags, tag)
+	writeJSON(w, http.StatusCreated, tag)
+}
+
+func (s *Server) updateTag(w http.ResponseWriter, r *http.Request) {
+	var update clockify.TagUpdate
+	if !decode(w, r, &update) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, id := r.PathValue("ws"), r.PathValue("id")
+	i := slices.IndexFunc(s.tags, func(t clockify.Tag) bool { return t.WorkspaceID == ws && t.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	s.tags[i].Name, s.tags[i].Archived = update.Name, update.Archived
+	writeJSON(w, http.StatusOK, s.tags[i])
+}
+
+// * Time entries
+
+func (s *Server) getUserTimeEntries(w http.ResponseWriter, r *http.Request) {
+	start, err := parseTimeParam(r, "start")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	end, err := parseTimeParam(r, "end")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, user := r.PathValue("ws"), r.PathValue("user")
+	inProgress := r.URL.Query().Get("in-progress") == "true"
+	project := r.URL.Query().Get("project")
+	tags := r.URL.Query()["tags"]
+	entries := filter(s.timeEntries, func(te clockify.TimeEntry) bool {
+		if te.WorkspaceID != ws || te.UserID != user {
+			return false
+		}
+		if project != "" && te.ProjectID != project {
+			return false
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(te.TagIDs, func(id string) bool { return slices.Contains(tags, id) }) {
+			return false
+		}
+		if inProgress && te.TimeInterval.End != nil {
+			return false
+		}
+		if start != nil && te.TimeInterval.Start.Before(*start) {
+			return false
+		}
+		if end != nil && te.TimeInterval.Start.After(*end) {
+			return false
+		}
+		return true
+	})
+	sortNewestFirst(entries)
+
+	writeJSON(w, http.StatusOK, paginate(w, r, entries))
+}
+
+func (s *Server) createTimeEntry(w http.ResponseWriter, r *http.Request) {
+	var request clockify.NewTimeEntryRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userID := r.PathValue("user")
+	if userID == "" {
+		userID = s.user.ID
+	}
+
+	entry := clockify.TimeEntry{
+		ID:           s.newID(),
+		Description:  request.Description,
+		TagIDs:       request.TagIDs,
+		UserID:       userID,
+		Billable:     request.Billable,
+		TaskID:       request.TaskID,
+		ProjectID:    request.ProjectID,
+		TimeInterval: &clockify.TimeInterval{Start: request.Start, End: request.End},
+		WorkspaceID:  r.PathValue("ws"),
+		Type:         cmp.Or(request.Type, clockify.TimeEntryRegular),
+	}
+	s.timeEntries = append(s.timeEntries, entry)
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func (s *Server) stopTimer(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		End time.Time `json:"end"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, user := r.PathValue("ws"), r.PathValue("user")
+	for i, te := range s.timeEntries {
+		if te.WorkspaceID == ws && te.UserID == user && te.TimeInterval.End == nil {
+			end := request.End
+			s.timeEntries[i].TimeInterval.End = &end
+			writeJSON(w, http.StatusOK, s.timeEntries[i])
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "No running time entry")
+}
+
+// findTimeEntry returns the index of the entry or -1. Callers must hold s.mu.
+func (s *Server) findTimeEntry(r *http.Request) int {
+	ws, id := r.PathValue("ws"), r.PathValue("id")
+	return slices.IndexFunc(s.timeEntries, func(te clockify.TimeEntry) bool {
+		return te.WorkspaceID == ws && te.ID == id
+	})
+}
+
+func (s *Server) getTimeEntry(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findTimeEntry(r)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Time entry not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.timeEntries[i])
+}
+
+func (s *Server) updateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	var request clockify.UpdateTimeEntryRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findTimeEntry(r)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Time entry not found")
+		return
+	}
+
+	entry := &s.timeEntries[i]
+	entry.TimeInterval = &clockify.TimeInterval{Start: request.Start, End: request.End}
+	entry.Billable = request.Billable
+	entry.Description = request.Description
+	entry.ProjectID = request.ProjectID
+	entry.TaskID = request.TaskID
+	entry.TagIDs = request.TagIDs
+	entry.Type = cmp.Or(request.Type, clockify.TimeEntryRegular)
+
+	writeJSON(w, http.StatusOK, *entry)
+}
+
+func (s *Server) deleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findTimeEntry(r)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Time entry not found")
+		return
+	}
+	s.timeEntries = slices.Delete(s.timeEntries, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// * Approvals
+
+// getApprovals lists the approval requests in the status of the query, wrapped in their
+// details like Clockify does
+func (s *Server) getApprovals(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws, status := r.PathValue("ws"), r.URL.Query().Get("status")
+	approvals := filter(s.approvals, func(a clockify.ApprovalRequest) bool {
+		return a.WorkspaceID == ws && (status == "" || a.Status.State == status)
+	})
+
+	type details struct {
+		ApprovalRequest clockify.ApprovalRequest `json:"approvalRequest"`
+	}
+	page := paginate(w, r, approvals)
+	result := make([]details, len(page))
+	for i, approval := range page {
+		result[i] = details{ApprovalRequest: approval}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// submitApproval submits a week of the current user, weeks are submitted once
+func (s *Server) submitApproval(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Period      string    `json:"period"`
+		PeriodStart time.Time `json:"periodStart"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.Period != "WEEKLY" {
+		writeError(w, http.StatusBadRequest, "Only weekly approval periods are supported")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	for _, existing := range s.approvals {
+		if existing.WorkspaceID == ws && existing.Owner.UserID == s.user.ID && existing.DateRange.Start.Equal(request.PeriodStart) {
+			writeError(w, http.StatusBadRequest, "Approval request for this period already exists")
+			return
+		}
+	}
+
+	approval := clockify.ApprovalRequest{
+		ID:          s.newID(),
+		WorkspaceID: ws,
+		DateRange:   clockify.DateRange{Start: request.PeriodStart, End: request.PeriodStart.AddDate(0, 0, 7).Add(-time.Millisecond)},
+		Owner:       clockify.ApprovalOwner{UserID: s.user.ID, UserName: s.user.Name},
+		Status:      clockify.ApprovalStatus{State: clockify.ApprovalPending},
+	}
+	s.approvals = append(s.approvals, approval)
+	writeJSON(w, http.StatusCreated, approval)
+}
+
+// updateApproval approves or rejects a pending approval request as the current user
+func (s *Server) updateApproval(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		State string `json:"state"`
+		Note  string `json:"note"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+	if request.State != clockify.ApprovalApproved && request.State != clockify.ApprovalRejected {
+		writeError(w, http.StatusBadRequest, "State must be APPROVED or REJECTED")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.approvals, func(a clockify.ApprovalRequest) bool {
+		return a.ID == r.PathValue("id") && a.WorkspaceID == r.PathValue("ws")
+	})
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Approval request not found")
+		return
+	}
+	if s.approvals[i].Status.State != clockify.ApprovalPending {
+		writeError(w, http.StatusBadRequest, "Approval request is not pending")
+		return
+	}
+
+	now := time.Now().UTC()
+	s.approvals[i].Status = clockify.ApprovalStatus{
+		State:             request.State,
+		Note:              request.Note,
+		UpdatedBy:         s.user.ID,
+		UpdatedByUserName: s.user.Name,
+		UpdatedAt:         &now,
+	}
+	writeJSON(w, http.StatusOK, s.approvals[i])
+}
+
+// * Scheduling
+
+// getAssignments lists the assignments overlapping the required start and end of the query,
+// like Clockify does
+func (s *Server) getAssignments(w http.ResponseWriter, r *http.Request) {
+	start, err := parseTimeParam(r, "start")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	end, err := parseTimeParam(r, "end")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if start == nil || end == nil {
+		writeError(w, http.StatusBadRequest, "start and end are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	assignments := filter(s.assignments, func(a clockify.Assignment) bool {
+		return a.WorkspaceID == ws && !a.Period.End.Before(*start) && !a.Period.Start.After(*end)
+	})
+	writeJSON(w, http.StatusOK, paginate(w, r, assignments))
+}
+
+// * Webhooks
+
+func (s *Server) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	webhooks := filter(s.webhooks, func(wh clockify.Webhook) bool { return wh.WorkspaceID == ws })
+	writeJSON(w, http.StatusOK, map[string]any{
+		"webhooks":              webhooks,
+		"workspaceWebhookCount": len(webhooks),
+	})
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var request clockify.WebhookRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	count := len(filter(s.webhooks, func(wh clockify.Webhook) bool { return wh.WorkspaceID == ws }))
+	if count >= MaxWebhooksPerWorkspace {
+		writeError(w, http.StatusBadRequest, "Maximum number of webhooks reached")
+		return
+	}
+
+	webhook := clockify.Webhook{
+		AuthToken:         s.newID(),
+		Enabled:           true,
+		ID:                s.newID(),
+		Name:              request.Name,
+		TriggerSource:     request.TriggerSource,
+		TriggerSourceType: request.TriggerSourceType,
+		TargetURL:         request.TargetURL,
+		UserID:            s.user.ID,
+		Event:             request.Event,
+		WorkspaceID:       ws,
+	}
+	s.webhooks = append(s.webhooks, webhook)
+
+	writeJSON(w, http.StatusCreated, webhook)
+}
+
+// findWebhook returns the index of the webhook or -1. Callers must hold s.mu.
+func (s *Server) findWebhook(r *http.Request) int {
+	ws, id := r.PathValue("ws"), r.PathValue("id")
+	return slices.IndexFunc(s.webhooks, func(wh clockify.Webhook) bool {
+		return wh.WorkspaceID == ws && wh.ID == id
+	})
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findWebhook(r)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	s.webhooks = slices.Delete(s.webhooks, i, i+1)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) regenerateWebhookToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findWebhook(r)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	s.webhooks[i].AuthToken = s.newID()
+	writeJSON(w, http.StatusOK, s.webhooks[i])
+}
+
+// * Reports
+
+// detailedReport serves the totals of a detailed report, filtered by date range, users and
+// projects. The report page itself is always empty.
+func (s *Server) detailedReport(w http.ResponseWriter, r *http.Request) {
+	type idFilter struct {
+		IDs []string `json:"ids"`
+	}
+	var request struct {
+		DateRangeStart time.Time `json:"dateRangeStart"`
+		DateRangeEnd   time.Time `json:"dateRangeEnd"`
+		Users          *idFilter `json:"users"`
+		Projects       *idFilter `json:"projects"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := r.PathValue("ws")
+	entries := filter(s.timeEntries, func(te clockify.TimeEntry) bool {
+		if te.WorkspaceID != ws {
+			return false
+		}
+		if start := te.TimeInterval.Start; start.Before(request.DateRangeStart) || start.After(request.DateRangeEnd) {
+			return false
+		}
+		if request.Users != nil && !slices.Contains(request.Users.IDs, te.UserID) {
+			return false
+		}
+		if request.Projects != nil && !slices.Contains(request.Projects.IDs, te.ProjectID) {
+			return false
+		}
+		return true
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"totals":      []map[string]any{{"entriesCount": len(entries)}},
+		"timeentries": []any{},
+	})
+}
@@ -0,0 +1,382 @@
+// Package clockifytest provides an in-memory fake of the Clockify API for integration tests.
+//
+// The fake implements the workspaces, users, projects, clients, tags, tasks, time entries,
+// approval requests and webhooks endpoints used by the clockify package, keeping all state in
+// memory:
+//
+//	srv := clockifytest.NewServer()
+//	defer srv.Close()
+//
+//	ws := srv.AddWorkspace("Acme")
+//	client := srv.Client()
+package clockifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// APIKey is the only API key accepted by the fake
+const APIKey = "clockifytest-api-key"
+
+// apiPrefix mirrors the path of the real base URL
+const apiPrefix = "/api/v2"
+
+// reportsPrefix is where clockify.EndpointsFor places the Reports API of a self-hosted base URL
+const reportsPrefix = "/report/v1"
+
+// Server is a fake Clockify API backed by in-memory state
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	nextID      int
+	user        clockify.User
+	workspaces  []clockify.Workspace
+	projects    []clockify.Project
+	clients     []clockify.Client
+	tags        []clockify.Tag
+	tasks       []clockify.Task
+	timeEntries []clockify.TimeEntry
+	webhooks    []clockify.Webhook
+	approvals   []clockify.ApprovalRequest
+	assignments []clockify.Assignment
+	members     []member
+	// Workspace ID + "/" + user ID -> profile set by SetMemberProfile
+	profiles map[string]clockify.MemberProfile
+}
+
+// member is a user other than the current one in a workspace
+type member struct {
+	workspaceID string
+	user        clockify.User
+}
+
+// NewServer starts a fake server with a single current user and no workspaces
+func NewServer() *Server {
+	s := &Server{}
+	s.user = clockify.NewUser(s.newID(), "user@example.com", "Test User")
+
+	mux := http.NewServeMux()
+	s.routes(mux)
+	s.Server = httptest.NewServer(s.authenticate(mux))
+
+	return s
+}
+
+// BaseURL returns the URL to pass to clockify.WithBaseURL
+func (s *Server) BaseURL() string {
+	return s.URL + apiPrefix
+}
+
+// Client returns an API client talking to the fake, with retries and rate limiting disabled
+func (s *Server) Client(opts ...clockify.ClientOption) *clockify.APIClient {
+	opts = append([]clockify.ClientOption{
+		clockify.WithBaseURL(s.BaseURL()),
+		clockify.WithRetry(1),
+		clockify.WithRateLimit(0),
+	}, opts...)
+	return clockify.NewDefaultClient(APIKey, opts...)
+}
+
+// newID returns a unique ID shaped like Clockify's (24 hex characters). Callers must hold s.mu
+// or be in the constructor.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%024x", s.nextID)
+}
+
+// * Seeding and inspection helpers
+
+// CurrentUser returns the user owning the API key
+func (s *Server) CurrentUser() clockify.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.user
+}
+
+// AddWorkspace creates a workspace
+func (s *Server) AddWorkspace(name string) clockify.Workspace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ws := clockify.Workspace{ID: s.newID(), Name: name, FeatureSubscriptionType: "FREE"}
+	s.workspaces = append(s.workspaces, ws)
+	return ws
+}
+
+// AddMember adds an active user to a workspace
+func (s *Server) AddMember(workspaceID, email, name string) clockify.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := clockify.NewUser(s.newID(), email, name)
+	user.Status = string(clockify.UserActive)
+	s.members = append(s.members, member{workspaceID: workspaceID, user: user})
+	return user
+}
+
+// SetMemberProfile sets the profile of a user in a workspace. Users without one have the
+// Clockify default of 8 hours on weekdays.
+func (s *Server) SetMemberProfile(workspaceID, userID string, profile clockify.MemberProfile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.profiles == nil {
+		s.profiles = make(map[string]clockify.MemberProfile)
+	}
+	s.profiles[workspaceID+"/"+userID] = profile
+}
+
+// SetWorkspacePlan sets the plan of a workspace and the plan features it includes
+func (s *Server) SetWorkspacePlan(workspaceID, plan string, features ...clockify.Feature) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.workspaces {
+		if s.workspaces[i].ID == workspaceID {
+			s.workspaces[i].FeatureSubscriptionType = plan
+			s.workspaces[i].Features = features
+		}
+	}
+}
+
+// AddProject creates a project in a workspace
+func (s *Server) AddProject(workspaceID, name string) clockify.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project := clockify.NewProject(s.newID(), name, workspaceID)
+	s.projects = append(s.projects, project)
+	return project
+}
+
+// AddClient creates a client in a workspace
+func (s *Server) AddClient(workspaceID, name string) clockify.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client := clockify.NewClient(s.newID(), name, workspaceID)
+	s.clients = append(s.clients, client)
+	return client
+}
+
+// AddTag creates a tag in a workspace
+func (s *Server) AddTag(workspaceID, name string) clockify.Tag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag := clockify.NewTag(s.newID(), name, workspaceID)
+	s.tags = append(s.tags, tag)
+	return tag
+}
+
+// AddTask creates a task in a project
+func (s *Server) AddTask(projectID, name string) clockify.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task := clockify.NewTask(s.newID(), name, projectID)
+	s.tasks = append(s.tasks, task)
+	return task
+}
+
+// AddTimeEntry stores a time entry as is, assigning an ID if it has none
+func (s *Server) AddTimeEntry(entry clockify.TimeEntry) clockify.TimeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = s.newID()
+	}
+	s.timeEntries = append(s.timeEntries, entry)
+	return entry
+}
+
+// AddApprovalRequest stores an approval request, e.g. of a member's week, filling in its ID
+// and a pending state when missing
+func (s *Server) AddApprovalRequest(request clockify.ApprovalRequest) clockify.ApprovalRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request.ID == "" {
+		request.ID = s.newID()
+	}
+	if request.Status.State == "" {
+		request.Status.State = clockify.ApprovalPending
+	}
+	s.approvals = append(s.approvals, request)
+	return request
+}
+
+// AddAssignment schedules a user on a project, filling in the ID when missing
+func (s *Server) AddAssignment(assignment clockify.Assignment) clockify.Assignment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if assignment.ID == "" {
+		assignment.ID = s.newID()
+	}
+	s.assignments = append(s.assignments, assignment)
+	return assignment
+}
+
+// TimeEntries returns all time entries of a workspace
+func (s *Server) TimeEntries(workspaceID string) []clockify.TimeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.timeEntries, func(te clockify.TimeEntry) bool { return te.WorkspaceID == workspaceID })
+}
+
+// Projects returns all projects of a workspace
+func (s *Server) Projects(workspaceID string) []clockify.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.projects, func(p clockify.Project) bool { return p.WorkspaceID == workspaceID })
+}
+
+// Tasks returns all tasks of a project
+func (s *Server) Tasks(projectID string) []clockify.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.tasks, func(t clockify.Task) bool { return t.ProjectID == projectID })
+}
+
+// ApprovalRequests returns all approval requests submitted in a workspace
+func (s *Server) ApprovalRequests(workspaceID string) []clockify.ApprovalRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.approvals, func(a clockify.ApprovalRequest) bool { return a.WorkspaceID == workspaceID })
+}
+
+// Webhooks returns all webhooks registered in a workspace
+func (s *Server) Webhooks(workspaceID string) []clockify.Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.webhooks, func(w clockify.Webhook) bool { return w.WorkspaceID == workspaceID })
+}
+
+// TriggerWebhook delivers payload to every webhook of the workspace subscribed to event,
+// the same way Clockify does.
+func (s *Server) TriggerWebhook(workspaceID string, event clockify.WebhookEvent, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range s.Webhooks(workspaceID) {
+		if webhook.Event != event {
+			continue
+		}
+
+		req, err := http.NewRequest(http.MethodPost, webhook.TargetURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Clockify-Webhook-Event-Type", string(event))
+		req.Header.Set("Clockify-Signature", webhook.AuthToken)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to deliver webhook %s: %w", webhook.ID, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("webhook %s delivery failed: %s", webhook.ID, resp.Status)
+		}
+	}
+
+	return nil
+}
+
+// * HTTP plumbing
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Addon tokens are accepted as they are, claims are the client's business
+		if r.Header.Get("X-Api-Key") != APIKey && r.Header.Get("X-Addon-Token") == "" {
+			writeError(w, http.StatusUnauthorized, "Full authentication is required to access this resource")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"message": message, "code": status})
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// paginate applies the page and page-size query parameters the same way Clockify does,
+// setting the Last-Page header
+func paginate[T any](w http.ResponseWriter, r *http.Request, items []T) []T {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page-size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 50
+	}
+
+	start := (page - 1) * pageSize
+	end := min(start+pageSize, len(items))
+	w.Header().Set("Last-Page", strconv.FormatBool(end >= len(items)))
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:end]
+}
+
+func filter[T any](items []T, keep func(T) bool) []T {
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if keep(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// sortNewestFirst orders time entries like the Clockify API does
+func sortNewestFirst(entries []clockify.TimeEntry) {
+	slices.SortStableFunc(entries, func(a, b clockify.TimeEntry) int {
+		return b.TimeInterval.Start.Compare(a.TimeInterval.Start)
+	})
+}
+
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &t, nil
+}
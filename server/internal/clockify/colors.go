@@ -0,0 +1,25 @@
+package clockify
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// ProjectPalette are the colors the Clockify web app offers for projects. Tags have no color
+// in the API.
+var ProjectPalette = []string{
+	"#F44336", "#E91E63", "#9C27B0", "#673AB7", "#3F51B5", "#2196F3", "#03A9F4", "#00BCD4",
+	"#009688", "#4CAF50", "#8BC34A", "#CDDC39", "#FFC107", "#FF9800", "#FF5722", "#795548",
+	"#607D8B",
+}
+
+// ProjectColor picks the palette color of a project by a hash of its client's and its own
+// name, so the same project always gets the same color, e.g. in every migrated workspace. The
+// client name may be empty. Case and surrounding spaces do not change the color.
+func ProjectColor(clientName, projectName string) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(clientName))))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(projectName))))
+	return ProjectPalette[h.Sum32()%uint32(len(ProjectPalette))]
+}
@@ -0,0 +1,95 @@
+package clockify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CredentialsProblem is why Clockify did not accept the credentials of the client
+type CredentialsProblem string
+
+const (
+	// CredentialsInvalid means Clockify rejected the API key or addon token
+	CredentialsInvalid CredentialsProblem = "invalid"
+	// CredentialsExpired means the addon token expired
+	CredentialsExpired CredentialsProblem = "expired"
+	// CredentialsUnreachable means Clockify could not be reached to check the credentials
+	CredentialsUnreachable CredentialsProblem = "unreachable"
+	// CredentialsUnverified means checking the credentials failed otherwise, e.g. rate limited
+	CredentialsUnverified CredentialsProblem = "unverified"
+)
+
+// CredentialsError is returned by ValidateCredentials, with a message meant for the user. It
+// unwraps to the error of the failed call.
+type CredentialsError struct {
+	Problem CredentialsProblem
+	// What the client authenticates with, "API key" or "addon token"
+	Credential string
+	// Where the client sends its requests
+	URL string
+	Err error
+}
+
+func (e *CredentialsError) Error() string {
+	switch e.Problem {
+	case CredentialsInvalid:
+		return fmt.Sprintf("clockify rejected the %s, check that it is correct and not revoked: %v", e.Credential, e.Err)
+	case CredentialsExpired:
+		return fmt.Sprintf("the %s expired, reinstall the addon to get a new one: %v", e.Credential, e.Err)
+	case CredentialsUnreachable:
+		return fmt.Sprintf("cannot reach clockify at %s, check the network and the base URL: %v", e.URL, e.Err)
+	default:
+		return fmt.Sprintf("failed to verify the %s with clockify: %v", e.Credential, e.Err)
+	}
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
+
+// Identity is who the credentials of a client belong to
+type Identity struct {
+	User       *User
+	Workspaces []Workspace
+	Addon      *AddonClaims // Nil unless authenticated as an addon
+}
+
+// ValidateCredentials checks the credentials of the client with Clockify, returning the user
+// they belong to and the workspaces they can access. Failures are *CredentialsError, telling
+// rejected or expired credentials from an unreachable Clockify.
+func (c *APIClient) ValidateCredentials() (*Identity, error) {
+	identity := &Identity{Addon: c.Addon()}
+	credential := "API key"
+	if identity.Addon != nil {
+		credential = "addon token"
+		// Clockify rejects expired tokens like any invalid one, tell them apart beforehand
+		if exp := identity.Addon.ExpiresAt; exp != 0 && time.Now().Unix() >= exp {
+			err := fmt.Errorf("expired at %s", time.Unix(exp, 0).Format(time.RFC3339))
+			return nil, &CredentialsError{Problem: CredentialsExpired, Credential: credential, URL: c.endpoints.API, Err: err}
+		}
+	}
+
+	user, err := c.GetCurrentUser()
+	if err != nil {
+		return nil, c.credentialsError(credential, fmt.Errorf("failed to get current user: %w", err))
+	}
+	identity.User = user
+
+	if identity.Workspaces, err = c.GetWorkspaces(); err != nil {
+		return nil, c.credentialsError(credential, fmt.Errorf("failed to get workspaces: %w", err))
+	}
+	return identity, nil
+}
+
+// credentialsError classifies a failed call of ValidateCredentials
+func (c *APIClient) credentialsError(credential string, err error) *CredentialsError {
+	problem := CredentialsUnverified
+	switch {
+	case IsUnreachable(err):
+		problem = CredentialsUnreachable
+	case errors.Is(err, ErrUnauthorized):
+		problem = CredentialsInvalid
+	}
+	return &CredentialsError{Problem: problem, Credential: credential, URL: c.endpoints.API, Err: err}
+}
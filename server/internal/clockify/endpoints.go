@@ -0,0 +1,93 @@
+package clockify
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Base URLs of the known Clockify installations
+const (
+	DefaultBaseURL = "https://api.clockify.me/api/v2"
+	EUBaseURL      = "https://euc1.clockify.me/api/v2"
+)
+
+// Global installation sub-APIs live on their own subdomains
+const (
+	defaultReportsURL = "https://reports.api.clockify.me/v1"
+	defaultPTOURL     = "https://pto.api.clockify.me/v1"
+)
+
+// apiPathPattern matches the trailing API path of a base URL, e.g. "/api/v1"
+var apiPathPattern = regexp.MustCompile(`/api(/v\d+)?$`)
+
+// Endpoints holds the root URLs of the Clockify APIs used by the client
+type Endpoints struct {
+	API     string // Core REST API
+	Reports string // Reports API
+	PTO     string // Time off API
+}
+
+// EndpointsFor derives the sub-API URLs from a base API URL.
+//
+// The global installation hosts the sub-APIs on separate subdomains. Regional and
+// self-hosted installations serve them from the same host under /report and /pto.
+func EndpointsFor(baseURL string) Endpoints {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == DefaultBaseURL {
+		return Endpoints{API: DefaultBaseURL, Reports: defaultReportsURL, PTO: defaultPTOURL}
+	}
+
+	root := apiPathPattern.ReplaceAllString(baseURL, "")
+	return Endpoints{
+		API:     baseURL,
+		Reports: root + "/report/v1",
+		PTO:     root + "/pto/v1",
+	}
+}
+
+// Endpoints returns the API URLs the client talks to
+func (c *APIClient) Endpoints() Endpoints {
+	return c.endpoints
+}
+
+// APIVersion is the version segment of a Clockify API path, e.g. "v1" of /api/v1
+type APIVersion string
+
+const (
+	APIv1 APIVersion = "v1"
+	APIv2 APIVersion = "v2"
+)
+
+// apiVersionPattern matches the trailing version segment of an API URL
+var apiVersionPattern = regexp.MustCompile(`/v\d+$`)
+
+// AtVersion returns the API URL serving the given version: the version segment of base
+// replaced, or added when it has none. The other endpoints keep their own versions.
+func AtVersion(base string, version APIVersion) string {
+	base = strings.TrimRight(base, "/")
+	if apiVersionPattern.MatchString(base) {
+		return apiVersionPattern.ReplaceAllString(base, "/"+string(version))
+	}
+	return base + "/" + string(version)
+}
+
+// WithEndpointVersion sends the requests of an ExperimentalClient endpoint, e.g.
+// EndpointCustomFields, to another API version, for when Clockify moves it before a release
+// of the client follows
+func WithEndpointVersion(endpoint string, version APIVersion) ClientOption {
+	return func(c *APIClient) {
+		if c.endpointVersions == nil {
+			c.endpointVersions = make(map[string]APIVersion)
+		}
+		c.endpointVersions[endpoint] = version
+	}
+}
+
+// endpointURL returns the root URL of an endpoint served by base, at the version it was
+// overridden with or the given one
+func (c *APIClient) endpointURL(base, endpoint string, version APIVersion) string {
+	if override, ok := c.endpointVersions[endpoint]; ok {
+		version = override
+	}
+	return AtVersion(base, version)
+}
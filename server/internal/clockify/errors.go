@@ -0,0 +1,103 @@
+package clockify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Errors of failed Clockify calls, every client method wraps one of them when Clockify
+// rejects the request, so callers can branch with errors.Is
+var (
+	// The resource does not exist, also returned by the Find*ByName helpers
+	ErrNotFound = errors.New("not found")
+	// The API key is missing, invalid or lacks the permission
+	ErrUnauthorized = errors.New("unauthorized")
+	// Too many requests, even after the retries
+	ErrRateLimited = errors.New("rate limited")
+	// The time entry or period is locked by the workspace's lock settings
+	ErrLocked = errors.New("locked")
+	// Clockify rejected the request data
+	ErrValidation = errors.New("validation failed")
+)
+
+// APIError is a response Clockify sent with an error status. It unwraps to the matching
+// sentinel error, if there is one.
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Message and Code are taken from Clockify's JSON error body, if any
+	Message string
+	Code    int
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("failed to %s: %d %s", e.Method, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusLocked || strings.Contains(strings.ToLower(e.Message), "locked"):
+		// Clockify reports locked entries with 400 or 403 and a message
+		return ErrLocked
+	case e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	default:
+		return nil
+	}
+}
+
+// newAPIError reads the error body of the response, which is closed
+func newAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{Method: resp.Request.Method, URL: resp.Request.URL.String(), StatusCode: resp.StatusCode}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var payload struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		apiErr.Message, apiErr.Code = payload.Message, payload.Code
+	}
+	return apiErr
+}
+
+// IsUnreachable reports whether the call failed before Clockify could handle it: a network
+// error or timeout, an open circuit breaker, or a gateway error in front of Clockify. Such
+// calls may succeed when retried later.
+func IsUnreachable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode != http.StatusTooManyRequests && isRetryableStatus(apiErr.StatusCode)
+	}
+	return false
+}
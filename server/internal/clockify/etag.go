@@ -0,0 +1,193 @@
+package clockify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sharedETagTTL is how long listings are kept in shared state, revalidated on every use
+const sharedETagTTL = 24 * time.Hour
+
+// referenceDataPath matches the listings of projects, tags and clients, which are fetched
+// over and over but rarely change
+var referenceDataPath = regexp.MustCompile(`/workspaces/[^/]+/(projects|tags|clients)/?$`)
+
+// cachedResponse is a response body stored with the ETag it was served with
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// responseStore keeps the responses of ETagCacheMiddleware
+type responseStore interface {
+	get(ctx context.Context, key string) *cachedResponse
+	put(ctx context.Context, key string, entry *cachedResponse)
+	delete(ctx context.Context, key string)
+}
+
+// etagCache keeps at most size responses keyed by API key and URL
+type etagCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*cachedResponse
+}
+
+func (c *etagCache) get(_ context.Context, key string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key]
+}
+
+func (c *etagCache) put(_ context.Context, key string, entry *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		// Any entry will do, a dropped one costs a single full response
+		for evicted := range c.entries {
+			delete(c.entries, evicted)
+			break
+		}
+	}
+	c.entries[key] = entry
+}
+
+func (c *etagCache) delete(_ context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// sharedResponses keeps the responses in shared state, for every replica to revalidate, under
+// a hash of their key. A failing state misses, which costs a full response.
+type sharedResponses struct {
+	state SharedState
+}
+
+// storedResponse is the JSON form of a cachedResponse in shared state
+type storedResponse struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+func (s sharedResponses) get(ctx context.Context, key string) *cachedResponse {
+	data, ok, err := s.state.Get(ctx, "etag:"+hashKey(key))
+	if err != nil {
+		slog.Warn("shared_state_failed", "use", "etag_cache", "error", err)
+		return nil
+	}
+	var stored storedResponse
+	if !ok || json.Unmarshal(data, &stored) != nil {
+		return nil
+	}
+	return &cachedResponse{etag: stored.ETag, header: stored.Header, body: stored.Body}
+}
+
+func (s sharedResponses) put(ctx context.Context, key string, entry *cachedResponse) {
+	data, err := json.Marshal(storedResponse{ETag: entry.etag, Header: entry.header, Body: entry.body})
+	if err == nil {
+		err = s.state.Set(ctx, "etag:"+hashKey(key), data, sharedETagTTL)
+	}
+	if err != nil {
+		slog.Warn("shared_state_failed", "use", "etag_cache", "error", err)
+	}
+}
+
+func (s sharedResponses) delete(ctx context.Context, key string) {
+	if err := s.state.Delete(ctx, "etag:"+hashKey(key)); err != nil {
+		slog.Warn("shared_state_failed", "use", "etag_cache", "error", err)
+	}
+}
+
+// ETagCacheMiddleware sends GET requests for projects, tags and clients with the ETag of
+// the last response in If-None-Match, and serves the stored body when Clockify answers
+// 304 Not Modified. At most size responses are kept.
+//
+// Endpoints without an ETag pass through unchanged.
+func ETagCacheMiddleware(size int) TransportMiddleware {
+	return etagMiddleware(&etagCache{size: size, entries: make(map[string]*cachedResponse)})
+}
+
+// SharedETagCacheMiddleware is ETagCacheMiddleware keeping the responses in the state shared
+// by every replica for a day, unbounded by a size
+func SharedETagCacheMiddleware(state SharedState) TransportMiddleware {
+	return etagMiddleware(sharedResponses{state: state})
+}
+
+func etagMiddleware(cache responseStore) TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet || !referenceDataPath.MatchString(req.URL.Path) || req.Header.Get("If-None-Match") != "" {
+				return next.RoundTrip(req)
+			}
+
+			key := credential(req) + " " + req.URL.String()
+			cached := cache.get(req.Context(), key)
+			if cached != nil {
+				req = req.Clone(req.Context())
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case resp.StatusCode == http.StatusNotModified && cached != nil:
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				return cachedResult(req, resp, cached), nil
+			case resp.StatusCode != http.StatusOK:
+				return resp, nil
+			}
+
+			etag := resp.Header.Get("ETag")
+			if etag == "" {
+				cache.delete(req.Context(), key)
+				return resp, nil
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			cache.put(req.Context(), key, &cachedResponse{etag: etag, header: resp.Header.Clone(), body: body})
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return resp, nil
+		})
+	}
+}
+
+// cachedResult turns a 304 response into the stored 200 response
+func cachedResult(req *http.Request, notModified *http.Response, cached *cachedResponse) *http.Response {
+	header := cached.header.Clone()
+	// Headers of the 304 describe the current representation, e.g. a refreshed ETag
+	for name, values := range notModified.Header {
+		header[name] = values
+	}
+	header.Set("Content-Length", strconv.Itoa(len(cached.body)))
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cached.body)),
+		ContentLength: int64(len(cached.body)),
+		Request:       req,
+	}
+}
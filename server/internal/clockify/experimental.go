@@ -0,0 +1,86 @@
+package clockify
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Endpoints of ExperimentalClient, the names WithEndpointVersion takes
+const (
+	EndpointCustomFields = "custom-fields"
+	EndpointTimeOff      = "time-off"
+)
+
+// ExperimentalClient calls the Clockify endpoints that are served under another API version
+// than the stable methods of APIClient, each at its own version. Their models follow Clockify's
+// changes without the compatibility guarantees of APIClient.
+type ExperimentalClient struct {
+	c *APIClient
+}
+
+// Experimental returns the facade of the experimental endpoints, sharing the client's
+// transport, credentials and context
+func (c *APIClient) Experimental() *ExperimentalClient {
+	return &ExperimentalClient{c: c}
+}
+
+// CustomField is a custom field of time entries in a workspace
+type CustomField struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`   // e.g. TXT, NUMBER, DROPDOWN_SINGLE or CHECKBOX
+	Status        string   `json:"status"` // VISIBLE, INVISIBLE or INACTIVE
+	Required      bool     `json:"required"`
+	AllowedValues []string `json:"allowedValues,omitempty"`
+	WorkspaceID   string   `json:"workspaceId"`
+}
+
+// GetCustomFields retrieves the custom fields of a workspace, served by the v1 API. The
+// workspace plan must include custom fields, otherwise ErrFeatureUnavailable is returned.
+func (e *ExperimentalClient) GetCustomFields(workspaceID string) ([]CustomField, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/custom-fields", e.c.endpointURL(e.c.endpoints.API, EndpointCustomFields, APIv1), workspaceID)
+
+	resp, err := e.c.get(url)
+	if err != nil {
+		return nil, e.c.featureError(workspaceID, FeatureCustomFields, err)
+	}
+
+	defer resp.Body.Close()
+
+	var fields []CustomField
+	if err := json.NewDecoder(resp.Body).Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// TimeOffPolicy is a time off policy of a workspace, e.g. vacation or sick leave
+type TimeOffPolicy struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	TimeUnit        string `json:"timeUnit"` // DAYS or HOURS
+	Archived        bool   `json:"archived"`
+	ApprovalEnabled bool   `json:"approve"`
+	WorkspaceID     string `json:"workspaceId"`
+}
+
+// GetTimeOffPolicies retrieves the time off policies of a workspace from the time off API.
+// The workspace plan must include time off, otherwise ErrFeatureUnavailable is returned.
+func (e *ExperimentalClient) GetTimeOffPolicies(workspaceID string) ([]TimeOffPolicy, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/policies", e.c.endpointURL(e.c.endpoints.PTO, EndpointTimeOff, APIv1), workspaceID)
+
+	resp, err := e.c.get(url)
+	if err != nil {
+		return nil, e.c.featureError(workspaceID, FeatureTimeOff, err)
+	}
+
+	defer resp.Body.Close()
+
+	var policies []TimeOffPolicy
+	if err := json.NewDecoder(resp.Body).Decode(&policies); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
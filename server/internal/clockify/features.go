@@ -0,0 +1,79 @@
+package clockify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// ErrFeatureUnavailable is returned when the workspace plan does not include a feature the
+// call needs
+var ErrFeatureUnavailable = errors.New("feature unavailable on the workspace plan")
+
+// Feature is a plan feature as listed in the features of a workspace
+type Feature string
+
+const (
+	FeatureTasks          Feature = "TASKS" // Part of every plan, never listed by Clockify
+	FeatureCustomFields   Feature = "CUSTOM_FIELDS"
+	FeatureInvoicing      Feature = "INVOICING"
+	FeatureRequiredFields Feature = "REQUIRED_FIELDS"
+	FeatureLaborCost      Feature = "LABOR_COST"
+	FeatureTimeOff        Feature = "TIME_OFF"
+	FeatureApproval       Feature = "APPROVAL"
+	FeatureScheduling     Feature = "SCHEDULING"
+)
+
+// WorkspaceFeatures are the plan features available in a workspace
+type WorkspaceFeatures struct {
+	WorkspaceID string
+	Plan        string // e.g. FREE, BASIC, PRO or ENTERPRISE
+	Features    []Feature
+}
+
+// Has reports whether the workspace plan includes the feature
+func (f *WorkspaceFeatures) Has(feature Feature) bool {
+	return feature == FeatureTasks || slices.Contains(f.Features, feature)
+}
+
+// Require returns ErrFeatureUnavailable if the workspace plan lacks the feature
+func (f *WorkspaceFeatures) Require(feature Feature) error {
+	if f.Has(feature) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not included in the %s plan of workspace %s", ErrFeatureUnavailable, feature, f.Plan, f.WorkspaceID)
+}
+
+// GetWorkspaceFeatures retrieves the plan and the plan features of a workspace
+func (c *APIClient) GetWorkspaceFeatures(workspaceID string) (*WorkspaceFeatures, error) {
+	workspace, err := c.GetWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WorkspaceFeatures{
+		WorkspaceID: workspace.ID,
+		Plan:        workspace.FeatureSubscriptionType,
+		Features:    workspace.Features,
+	}, nil
+}
+
+// featureError explains a 403 from an endpoint that needs a plan feature: when the plan
+// lacks the feature, it returns ErrFeatureUnavailable instead. Other errors, and 403s of
+// workspaces that have the feature, are returned as they are.
+func (c *APIClient) featureError(workspaceID string, feature Feature, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		return err
+	}
+
+	features, lookupErr := c.GetWorkspaceFeatures(workspaceID)
+	if lookupErr != nil {
+		return err
+	}
+	if featureErr := features.Require(feature); featureErr != nil {
+		return fmt.Errorf("%w (%w)", featureErr, err)
+	}
+	return err
+}
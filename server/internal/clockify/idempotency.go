@@ -0,0 +1,155 @@
+package clockify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// verifyWindow is how far around the requested start the entries are searched for the one
+// a failed attempt may have created
+const verifyWindow = time.Minute
+
+// createTimeEntry posts the request to the URL creating an entry of the user, retrying it the
+// way RetryMiddleware does idempotent requests. Clockify has no idempotency keys, so after an
+// attempt whose outcome is unknown, a timeout or a gateway error, the user's entries are
+// searched for an exact match, the last attempt's included, and a match is returned instead
+// of logging the hours twice. An empty userID stands for the authenticated user.
+func (c *APIClient) createTimeEntry(url, workspaceID, userID string, request NewTimeEntryRequest) (*TimeEntry, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := c.context()
+	delay := defaultRetryDelay
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(withoutRetries(ctx), "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := c.decodeTimeEntry(c.do(req))
+		if err == nil || !isRetryableCreate(err) {
+			return entry, err
+		}
+
+		if createOutcomeUnknown(err) {
+			if userID == "" {
+				user, userErr := c.GetCurrentUser()
+				if userErr != nil {
+					return nil, fmt.Errorf("%w (could not check whether the entry was created: %w)", err, userErr)
+				}
+				userID = user.ID
+			}
+			created, findErr := c.FindCreatedEntry(workspaceID, userID, request)
+			if findErr != nil {
+				return nil, fmt.Errorf("%w (could not check whether the entry was created: %w)", err, findErr)
+			}
+			if created != nil {
+				slog.Info("time_entry_create_verified", "workspace_id", workspaceID, "user_id", userID, "time_entry_id", created.ID, "attempt", attempt, "error", err)
+				return created, nil
+			}
+		}
+		// Even the last attempt was checked, callers repeating the create duplicate nothing
+		if attempt >= c.retryAttempts {
+			return nil, err
+		}
+
+		slog.Debug("retrying_request", "method", "POST", "attempt", attempt, "wait", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+func (c *APIClient) decodeTimeEntry(resp *http.Response, err error) (*TimeEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var timeEntry TimeEntry
+	if err := json.NewDecoder(resp.Body).Decode(&timeEntry); err != nil {
+		return nil, err
+	}
+	return &timeEntry, nil
+}
+
+// isRetryableCreate reports whether a failed creation may be attempted again. Requests the
+// circuit breaker held back were never sent, and are not repeated while it is open.
+func isRetryableCreate(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	return errors.Is(err, ErrRateLimited) || IsUnreachable(err)
+}
+
+// createOutcomeUnknown reports whether Clockify may have created the entry despite the
+// error. A 429 is sent before the request is handled.
+func createOutcomeUnknown(err error) bool {
+	return !errors.Is(err, ErrRateLimited) && IsUnreachable(err)
+}
+
+// FindCreatedEntry returns the user's entry matching the request, or nil if there is none, to
+// tell whether a create whose outcome is unknown went through
+func (c *APIClient) FindCreatedEntry(workspaceID, userID string, request NewTimeEntryRequest) (*TimeEntry, error) {
+	start := request.Start.Add(-verifyWindow)
+	var end *time.Time
+	if request.End != nil {
+		until := request.End.Add(verifyWindow)
+		end = &until
+	}
+
+	for entries, err := range c.IterTimeEntries(workspaceID, userID, &start, end) {
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if MatchesRequest(entry, request) {
+				return &entry, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// MatchesRequest reports whether the entry is the one the request creates. Clockify stores
+// times to the second.
+func MatchesRequest(entry TimeEntry, request NewTimeEntryRequest) bool {
+	if entry.TimeInterval == nil || !sameSecond(entry.TimeInterval.Start, request.Start) {
+		return false
+	}
+	switch {
+	case request.End == nil && entry.TimeInterval.End != nil,
+		request.End != nil && (entry.TimeInterval.End == nil || !sameSecond(*entry.TimeInterval.End, *request.End)):
+		return false
+	}
+
+	tags, requested := slices.Clone(entry.TagIDs), slices.Clone(request.TagIDs)
+	slices.Sort(tags)
+	slices.Sort(requested)
+	return entry.Description == request.Description &&
+		entry.ProjectID == request.ProjectID &&
+		entry.TaskID == request.TaskID &&
+		entry.Billable == request.Billable &&
+		slices.Equal(tags, requested)
+}
+
+func sameSecond(a, b time.Time) bool {
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
@@ -0,0 +1,69 @@
+package clockify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNoRunningTimeEntry = errors.New("no time entry is running")
+	// ErrIdleBoundary is returned when the idle time or split point leaves no time in the entry
+	ErrIdleBoundary = errors.New("idle boundary is outside the running time entry")
+)
+
+// runningSince returns the user's running entry, checking that boundary falls within it
+func (c *APIClient) runningSince(workspaceID, userID string, boundary, now time.Time) (*TimeEntry, error) {
+	running, err := c.GetRunningTimeEntry(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if running == nil || running.TimeInterval == nil {
+		return nil, ErrNoRunningTimeEntry
+	}
+	if !boundary.After(running.TimeInterval.Start) || boundary.After(now) {
+		return nil, fmt.Errorf("%w: %s is not between its start at %s and now", ErrIdleBoundary,
+			boundary.Format(time.RFC3339), running.TimeInterval.Start.Format(time.RFC3339))
+	}
+	return running, nil
+}
+
+// TrimRunningTimer stops the user's running entry idle before now, dropping the idle time
+func (c *APIClient) TrimRunningTimer(workspaceID, userID string, idle time.Duration) (*TimeEntry, error) {
+	now := time.Now()
+	end := now.Add(-idle)
+	if _, err := c.runningSince(workspaceID, userID, end, now); err != nil {
+		return nil, err
+	}
+	return c.StopTimeEntry(workspaceID, userID, end)
+}
+
+// SplitRunningTimer stops the user's running entry at the idle boundary and records the time
+// since then as a second, stopped entry with the same description, project, task, tags and
+// billability, e.g. to move it to another project or delete it later.
+func (c *APIClient) SplitRunningTimer(workspaceID, userID string, at time.Time) (stopped, rest *TimeEntry, err error) {
+	now := time.Now()
+	running, err := c.runningSince(workspaceID, userID, at, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stopped, err = c.StopTimeEntry(workspaceID, userID, at)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rest, err = c.CreateTimeEntry(workspaceID, NewTimeEntryRequest{
+		Start:       at,
+		End:         &now,
+		Billable:    running.Billable,
+		Description: running.Description,
+		ProjectID:   running.ProjectID,
+		TaskID:      running.TaskID,
+		TagIDs:      running.TagIDs,
+	})
+	if err != nil {
+		return stopped, nil, fmt.Errorf("stopped at the boundary but failed to record the rest: %w", err)
+	}
+	return stopped, rest, nil
+}
@@ -0,0 +1,94 @@
+package clockify
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxLoggedBodyLength caps how much of a request/response body ends up in the logs
+const maxLoggedBodyLength = 2048
+
+const redactedValue = "***"
+
+// sensitiveFieldPattern matches JSON string fields that must never be logged verbatim
+var sensitiveFieldPattern = regexp.MustCompile(`"(authToken|apiKey|password|token|secret)"\s*:\s*"[^"]*"`)
+
+// LoggingMiddleware records method, URL, status, duration and redacted bodies of every request
+// at debug level. The API key or addon token is always masked.
+func LoggingMiddleware(logger *slog.Logger) TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !logger.Enabled(req.Context(), slog.LevelDebug) {
+				return next.RoundTrip(req)
+			}
+
+			secret := credential(req)
+			attrs := []any{
+				"method", req.Method,
+				"url", redact(req.URL.String(), secret),
+				"api_key", maskAPIKey(secret),
+			}
+
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					reqBody, _ := io.ReadAll(body)
+					body.Close()
+					attrs = append(attrs, "request_body", redact(string(reqBody), secret))
+				}
+			}
+
+			started := time.Now()
+			resp, err := next.RoundTrip(req)
+			attrs = append(attrs, "duration", time.Since(started))
+
+			if err != nil {
+				logger.DebugContext(req.Context(), "clockify_request_error", append(attrs, "error", err)...)
+				return nil, err
+			}
+
+			attrs = append(attrs, "status", resp.StatusCode)
+
+			// Read the body for logging and put it back so the caller can still decode it
+			respBody, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+			if readErr != nil {
+				attrs = append(attrs, "body_error", readErr)
+			} else {
+				attrs = append(attrs, "response_body", redact(string(respBody), secret))
+			}
+
+			logger.DebugContext(req.Context(), "clockify_request", attrs...)
+
+			return resp, nil
+		})
+	}
+}
+
+// redact masks the API key and known secret fields, and truncates overly long payloads
+func redact(s, apiKey string) string {
+	if apiKey != "" {
+		s = strings.ReplaceAll(s, apiKey, redactedValue)
+	}
+
+	s = sensitiveFieldPattern.ReplaceAllString(s, `"$1":"`+redactedValue+`"`)
+
+	if len(s) > maxLoggedBodyLength {
+		s = s[:maxLoggedBodyLength] + "...(truncated)"
+	}
+
+	return s
+}
+
+// maskAPIKey keeps only the last 4 characters of the key, enough to tell keys apart
+func maskAPIKey(apiKey string) string {
+	if len(apiKey) <= 4 {
+		return redactedValue
+	}
+	return redactedValue + apiKey[len(apiKey)-4:]
+}
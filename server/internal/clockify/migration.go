@@ -7,7 +7,9 @@ package clockify
 // and should not be used for other Clockify migration scenarios without significant modifications.
 
 import (
+	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
 	"regexp"
 	"strings"
@@ -34,8 +36,27 @@ type MigrationConfig struct {
 	BatchSize     int  `json:"batchSize"`     // Number of time entries to process at once
 	SkipExisting  bool `json:"skipExisting"`  // Skip if target already has time entries
 	CreateClients bool `json:"createClients"` // Whether to create new clients automatically
+
+	// What to do with source entries in a locked period, copied as usual by default
+	LockedEntries LockedEntryPolicy `json:"lockedEntries,omitempty"`
+
+	// Budget of the whole migration, after which it stops where it is. 0 lets it run as long
+	// as it takes.
+	Deadline time.Duration `json:"-"`
 }
 
+// LockedEntryPolicy decides how the migration treats locked source entries
+type LockedEntryPolicy string
+
+const (
+	// LockedMigrate copies locked entries like any other, the target workspace has its own lock
+	LockedMigrate LockedEntryPolicy = ""
+	// LockedSkip leaves locked entries out and lists them in the stats
+	LockedSkip LockedEntryPolicy = "skip"
+	// LockedReport copies locked entries and lists them in the stats
+	LockedReport LockedEntryPolicy = "report"
+)
+
 // MigrationStats tracks progress and results
 type MigrationStats struct {
 	TimeEntriesProcessed int
@@ -43,6 +64,8 @@ type MigrationStats struct {
 	ProjectsCreated      int
 	TasksCreated         int
 	ClientsCreated       int
+	LockedSkipped        int
+	LockedEntries        []string // Locked source entries, with LockedSkip or LockedReport
 	Errors               []string
 	StartTime            time.Time
 	EndTime              time.Time
@@ -71,6 +94,7 @@ type MigrationService struct {
 	targetTasks     map[string]*Task    // projectName/taskName -> Task
 	targetClients   map[string]*Client  // clientName -> Client
 	currentUser     *User
+	total           int // Source entries to migrate, -1 when the count is unavailable
 }
 
 // NewMigrationService creates a new migration service with dependency injection
@@ -90,6 +114,7 @@ func NewMigrationService(client *APIClient, config *MigrationConfig) *MigrationS
 		targetProjects: make(map[string]*Project),
 		targetTasks:    make(map[string]*Task),
 		targetClients:  make(map[string]*Client),
+		total:          -1,
 	}
 }
 
@@ -97,20 +122,22 @@ func NewMigrationService(client *APIClient, config *MigrationConfig) *MigrationS
 func (m *MigrationService) ExecuteMigration() (*MigrationStats, error) {
 	slog.Info("starting_migration", "source_workspace", m.config.SourceWorkspaceName, "source_project", m.config.SourceProjectName, "target_workspace", m.config.TargetWorkspaceName)
 
+	if m.config.Deadline > 0 {
+		client, cancel := m.client.WithDeadline(m.config.Deadline)
+		defer cancel()
+		m.client = client
+	}
+
 	// Step 1: Initialize workspaces and cache data
 	if err := m.initializeWorkspaces(); err != nil {
 		return m.stats, fmt.Errorf("failed to initialize workspaces: %w", err)
 	}
 
-	// Step 2: Get source time entries
-	timeEntries, err := m.client.GetProjectTimeEntries(m.sourceWorkspace.ID, m.sourceProject.ID, m.currentUser.ID)
-	if err != nil {
-		return m.stats, fmt.Errorf("failed to get source time entries: %w", err)
-	}
-
-	slog.Info("found_time_entries_to_migrate", "count", len(timeEntries))
+	// Step 2: Count source time entries for the progress
+	m.total = m.countSourceEntries()
 
-	// Step 3: Process time entries in batches
+	// Step 3: Stream source time entries and process them in batches
+	timeEntries := m.client.GetProjectTimeEntries(m.sourceWorkspace.ID, m.sourceProject.ID, m.currentUser.ID)
 	if err := m.processTimeEntries(timeEntries); err != nil {
 		return m.stats, fmt.Errorf("failed to process time entries: %w", err)
 	}
@@ -167,10 +194,31 @@ func (m *MigrationService) getOrCreateTargetWorkspace() (*Workspace, error) {
 		slog.Info("using_existing_target_workspace", "workspace", ws.Name)
 		return ws, nil
 	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
 
-	// Note: Workspace creation might not be available in free tier
-	// For now, we'll require the target workspace to exist
-	return nil, fmt.Errorf("target workspace '%s' not found - please create it manually first", m.config.TargetWorkspaceName)
+	canCreate, err := m.client.CanCreateWorkspaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check workspace creation: %w", err)
+	}
+	if !canCreate {
+		return nil, fmt.Errorf("target workspace '%s' %w and the account cannot create workspaces - please create it manually first", m.config.TargetWorkspaceName, ErrNotFound)
+	}
+
+	if m.config.DryRun {
+		// Nothing could be looked up in a workspace that does not exist yet
+		slog.Info("would_create_target_workspace", "workspace", m.config.TargetWorkspaceName, "mode", "dry_run")
+		return nil, fmt.Errorf("target workspace '%s' %w, a run without dry run creates it", m.config.TargetWorkspaceName, ErrNotFound)
+	}
+
+	ws, err = m.client.CreateWorkspace(m.config.TargetWorkspaceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create target workspace '%s': %w", m.config.TargetWorkspaceName, err)
+	}
+
+	slog.Info("created_target_workspace", "workspace", ws.Name)
+	return ws, nil
 }
 
 // cacheTargetClients loads existing clients in target workspace
@@ -190,26 +238,82 @@ func (m *MigrationService) cacheTargetClients() error {
 	return nil
 }
 
-// processTimeEntries processes all time entries in batches
-func (m *MigrationService) processTimeEntries(timeEntries []TimeEntry) error {
-	for i := 0; i < len(timeEntries); i += m.config.BatchSize {
-		end := i + m.config.BatchSize
-		end = min(end, len(timeEntries))
+// countSourceEntries counts the entries to migrate through the Reports API, so progress can
+// be reported against the total before the entries are fetched. Returns -1 when the count is
+// unavailable.
+func (m *MigrationService) countSourceEntries() int {
+	total, err := m.client.CountTimeEntries(m.sourceWorkspace.ID, TimeEntryCountFilter{
+		UserID:    m.currentUser.ID,
+		ProjectID: m.sourceProject.ID,
+	})
+	if err != nil {
+		slog.Warn("time_entry_count_unavailable", "error", err)
+		return -1
+	}
 
-		batch := timeEntries[i:end]
-		slog.Info("processing_batch", "batch_start", i+1, "batch_end", end, "total_entries", len(timeEntries))
+	slog.Info("counted_time_entries_to_migrate", "count", total)
+	return total
+}
+
+// processTimeEntries processes the time entries in batches as they are fetched
+func (m *MigrationService) processTimeEntries(timeEntries iter.Seq2[TimeEntry, error]) error {
+	batch := make([]TimeEntry, 0, m.config.BatchSize)
+	processed := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		start, end := processed+1, processed+len(batch)
+		if m.total >= 0 {
+			slog.Info("processing_batch", "batch_start", start, "batch_end", end, "total_entries", max(m.total, end))
+		} else {
+			slog.Info("processing_batch", "batch_start", start, "batch_end", end)
+		}
 
 		if err := m.processBatch(batch); err != nil {
-			return fmt.Errorf("failed to process batch %d-%d: %w", i+1, end, err)
+			return fmt.Errorf("failed to process batch %d-%d: %w", start, end, err)
 		}
+		processed, batch = end, batch[:0]
+		return nil
 	}
 
+	for entry, err := range timeEntries {
+		if err != nil {
+			return fmt.Errorf("failed to get source time entries: %w", err)
+		}
+
+		batch = append(batch, entry)
+		if len(batch) == m.config.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	slog.Info("source_time_entries_fetched", "count", processed)
 	return nil
 }
 
 // processBatch processes a batch of time entries
 func (m *MigrationService) processBatch(timeEntries []TimeEntry) error {
 	for _, entry := range timeEntries {
+		if err := m.client.budgetSpent(); err != nil {
+			return err
+		}
+		if entry.IsLocked && m.config.LockedEntries != LockedMigrate {
+			m.stats.LockedEntries = append(m.stats.LockedEntries, entry.String())
+			if m.config.LockedEntries == LockedSkip {
+				slog.Info("skipped_locked_time_entry", "entry_id", entry.ID)
+				m.stats.LockedSkipped++
+				continue
+			}
+		}
+
 		if err := m.processTimeEntry(&entry); err != nil {
 			m.stats.Errors = append(m.stats.Errors, fmt.Sprintf("Failed to process entry %s: %v", entry.ID, err))
 			slog.Error("error_processing_time_entry", "entry_id", entry.ID, "error", err)
@@ -242,7 +346,7 @@ func (m *MigrationService) processTimeEntry(entry *TimeEntry) error {
 	}
 
 	// Get or create target project
-	targetProject, err := m.getOrCreateProject(mapping.ProjectName, targetClient.ID)
+	targetProject, err := m.getOrCreateProject(mapping.ProjectName, targetClient)
 	if err != nil {
 		return fmt.Errorf("failed to get/create project '%s': %w", mapping.ProjectName, err)
 	}
@@ -314,7 +418,7 @@ func (m *MigrationService) getSourceTask(taskID string) (*Task, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("task with ID %s not found", taskID)
+	return nil, fmt.Errorf("task with ID %s %w", taskID, ErrNotFound)
 }
 
 // getOrCreateClient gets existing or creates new client
@@ -344,11 +448,11 @@ func (m *MigrationService) getOrCreateClient(clientName string) (*Client, error)
 		return dummyClient, nil
 	}
 
-	return nil, fmt.Errorf("client '%s' not found and auto-creation disabled", clientName)
+	return nil, fmt.Errorf("client '%s' %w and auto-creation disabled", clientName, ErrNotFound)
 }
 
-// getOrCreateProject gets existing or creates new project
-func (m *MigrationService) getOrCreateProject(projectName, clientID string) (*Project, error) {
+// getOrCreateProject gets existing or creates new project, colored by its client and name
+func (m *MigrationService) getOrCreateProject(projectName string, client *Client) (*Project, error) {
 	// Check cache first
 	if project, exists := m.targetProjects[projectName]; exists {
 		return project, nil
@@ -371,12 +475,15 @@ func (m *MigrationService) getOrCreateProject(projectName, clientID string) (*Pr
 	// Create new project
 	if m.config.DryRun {
 		slog.Info("would_create_project", "project_name", projectName, "mode", "dry_run")
-		dummyProject := &Project{ID: "dummy", Name: projectName, ClientID: clientID}
+		dummyProject := &Project{ID: "dummy", Name: projectName, ClientID: client.ID}
 		m.targetProjects[projectName] = dummyProject
 		return dummyProject, nil
 	}
 
-	project, err := m.client.CreateProject(m.targetWorkspace.ID, projectName)
+	request := m.client.NewProjectRequest(projectName)
+	request.ClientID = client.ID
+	request.Color = ProjectColor(client.Name, projectName)
+	project, err := m.client.CreateProjectFromRequest(m.targetWorkspace.ID, request)
 	if err != nil {
 		return nil, err
 	}
@@ -466,6 +573,12 @@ func (m *MigrationService) logMigrationSummary() {
 	slog.Info("projects_created", "count", m.stats.ProjectsCreated)
 	slog.Info("tasks_created", "count", m.stats.TasksCreated)
 	slog.Info("clients_created", "count", m.stats.ClientsCreated)
+	if len(m.stats.LockedEntries) > 0 {
+		slog.Info("locked_time_entries", "count", len(m.stats.LockedEntries), "skipped", m.stats.LockedSkipped)
+		for _, entry := range m.stats.LockedEntries {
+			slog.Info("locked_time_entry", "entry", entry)
+		}
+	}
 	slog.Info("errors", "count", len(m.stats.Errors))
 
 	if len(m.stats.Errors) > 0 {
@@ -1,14 +1,49 @@
 package clockify
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
 
 // Workspace represents a Clockify workspace
 type Workspace struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	HourlyRate *Rate  `json:"hourlyRate,omitempty"`
+	CostRate   *Rate  `json:"costRate,omitempty"`
+	// Plan of the workspace, e.g. FREE, BASIC, PRO or ENTERPRISE
+	FeatureSubscriptionType string              `json:"featureSubscriptionType,omitempty"`
+	Features                []Feature           `json:"features,omitempty"`
+	Subdomain               *WorkspaceSubdomain `json:"subdomain,omitempty"`
+	Currencies              []WorkspaceCurrency `json:"currencies,omitempty"`
+}
+
+// WorkspaceCurrency is a currency amounts of the workspace can be in
+type WorkspaceCurrency struct {
+	ID        string `json:"id"`
+	Code      string `json:"code"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// DefaultCurrency returns the code of the workspace's default currency, that of its hourly
+// rate when none is marked as the default, empty when neither is known
+func (w Workspace) DefaultCurrency() string {
+	for _, c := range w.Currencies {
+		if c.IsDefault {
+			return c.Code
+		}
+	}
+	if w.HourlyRate != nil {
+		return w.HourlyRate.Currency
+	}
+	return ""
+}
+
+// WorkspaceSubdomain is the custom login subdomain of an enterprise workspace
+type WorkspaceSubdomain struct {
+	Name    string `json:"name,omitempty"`
+	Enabled bool   `json:"enabled"`
 }
 
 func (w Workspace) String() string {
@@ -39,13 +74,31 @@ func NewClient(id, name, workspaceId string) Client {
 
 // User represents a user in Clockify
 type User struct {
-	ID               string `json:"id"`
-	Email            string `json:"email"`
-	Name             string `json:"name"`
-	ProfilePicture   string `json:"profilePicture,omitempty"`
-	ActiveWorkspace  string `json:"activeWorkspace,omitempty"`
-	DefaultWorkspace string `json:"defaultWorkspace,omitempty"`
-	Status           string `json:"status,omitempty"`
+	ID               string        `json:"id"`
+	Email            string        `json:"email"`
+	Name             string        `json:"name"`
+	ProfilePicture   string        `json:"profilePicture,omitempty"`
+	ActiveWorkspace  string        `json:"activeWorkspace,omitempty"`
+	DefaultWorkspace string        `json:"defaultWorkspace,omitempty"`
+	Status           string        `json:"status,omitempty"`
+	Settings         *UserSettings `json:"settings,omitempty"`
+}
+
+// UserSettings holds a user's preferences, only the ones CCWS uses are decoded
+type UserSettings struct {
+	TimeZone string `json:"timeZone,omitempty"` // IANA name, e.g. Europe/Kyiv
+}
+
+// Location returns the user's time zone, or fallback if it is unset or unknown
+func (u User) Location(fallback *time.Location) *time.Location {
+	if u.Settings == nil || u.Settings.TimeZone == "" {
+		return fallback
+	}
+	loc, err := time.LoadLocation(u.Settings.TimeZone)
+	if err != nil {
+		return fallback
+	}
+	return loc
 }
 
 func (u User) String() string {
@@ -96,7 +149,40 @@ type Project struct {
 	Archived    bool   `json:"archived"`
 	Color       string `json:"color,omitempty"`
 	Note        string `json:"note,omitempty"`
-	// Simplified for free plan - avoiding complex memberships and estimates
+	// Set when the workspace plan supports estimates, simplified otherwise - no memberships
+	TimeEstimate   *TimeEstimate   `json:"timeEstimate,omitempty"`
+	BudgetEstimate *BudgetEstimate `json:"budgetEstimate,omitempty"`
+	HourlyRate     *Rate           `json:"hourlyRate,omitempty"`
+}
+
+// Rate is an amount per hour in the smallest currency unit, e.g. cents
+type Rate struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// BudgetEstimate is the amount of money planned for a project, in the smallest currency unit
+type BudgetEstimate struct {
+	Estimate    int64  `json:"estimate"`
+	Type        string `json:"type"`
+	ResetOption string `json:"resetOption,omitempty"`
+	Active      bool   `json:"active"`
+}
+
+// TimeEstimate is the number of hours planned for a project
+type TimeEstimate struct {
+	Estimate    string `json:"estimate"` // ISO 8601 duration, e.g. PT40H
+	Type        string `json:"type"`     // MANUAL or AUTO (sum of task estimates)
+	ResetOption string `json:"resetOption,omitempty"`
+	Active      bool   `json:"active"`
+}
+
+// Estimate resets, without a reset the estimate covers the whole project lifetime
+const EstimateResetMonthly = "MONTHLY"
+
+// Duration parses the estimate
+func (e TimeEstimate) Duration() (time.Duration, error) {
+	return parseISODuration(e.Estimate)
 }
 
 func (p Project) String() string {
@@ -116,11 +202,45 @@ func NewProject(id, name, workspaceId string) Project {
 
 // Task represents a task within a project
 type Task struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	ProjectID string `json:"projectId"`
-	Status    string `json:"status"`
-	Estimate  string `json:"estimate,omitempty"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	ProjectID   string   `json:"projectId"`
+	Status      string   `json:"status"`
+	Estimate    string   `json:"estimate,omitempty"`
+	AssigneeIDs []string `json:"assigneeIds,omitempty"`
+	Billable    bool     `json:"billable"`
+}
+
+// Task statuses, done tasks are hidden when tracking time
+const (
+	TaskActive = "ACTIVE"
+	TaskDone   = "DONE"
+)
+
+// TaskCreateRequest represents the structure for creating a new task
+type TaskCreateRequest struct {
+	Name        string   `json:"name"`
+	AssigneeIDs []string `json:"assigneeIds,omitempty"`
+	// Time estimate of the task, none when 0
+	Estimate time.Duration `json:"-"`
+	Billable *bool         `json:"billable,omitempty"` // Nil inherits the project's
+	Status   string        `json:"status"`             // TaskActive when empty
+}
+
+// MarshalJSON sends the estimate in the shape Clockify expects
+func (r TaskCreateRequest) MarshalJSON() ([]byte, error) {
+	type request TaskCreateRequest // Without the method, not to recurse
+	body := struct {
+		request
+		Estimate string `json:"estimate,omitempty"`
+	}{request: request(r)}
+	if r.Estimate > 0 {
+		body.Estimate = formatISODuration(r.Estimate)
+	}
+	if body.Status == "" {
+		body.Status = TaskActive
+	}
+	return json.Marshal(body)
 }
 
 func (t Task) String() string {
@@ -132,7 +252,7 @@ func NewTask(id, name, projectId string) Task {
 		ID:        id,
 		Name:      name,
 		ProjectID: projectId,
-		Status:    "ACTIVE",
+		Status:    TaskActive,
 	}
 }
 
@@ -143,6 +263,17 @@ type TimeInterval struct {
 	Duration string     `json:"duration,omitempty"`
 }
 
+// TimeEntryType tells work apart from breaks and the entries Clockify adds for time off and
+// holidays
+type TimeEntryType string
+
+const (
+	TimeEntryRegular TimeEntryType = "REGULAR"
+	TimeEntryBreak   TimeEntryType = "BREAK"
+	TimeEntryHoliday TimeEntryType = "HOLIDAY"
+	TimeEntryTimeOff TimeEntryType = "TIME_OFF"
+)
+
 // TimeEntry represents a time log entry in Clockify
 type TimeEntry struct {
 	ID           string        `json:"id"`
@@ -155,6 +286,14 @@ type TimeEntry struct {
 	TimeInterval *TimeInterval `json:"timeInterval"`
 	WorkspaceID  string        `json:"workspaceId"`
 	IsLocked     bool          `json:"isLocked,omitempty"`
+	HourlyRate   *Rate         `json:"hourlyRate,omitempty"` // Effective billable rate, when known
+	Type         TimeEntryType `json:"type,omitempty"`       // Empty for entries of older payloads, regular
+	KioskID      string        `json:"kioskId,omitempty"`    // The kiosk the entry was clocked on, if any
+}
+
+// IsBreak reports whether the entry is a break rather than work, e.g. one taken at a kiosk
+func (te TimeEntry) IsBreak() bool {
+	return te.Type == TimeEntryBreak
 }
 
 func (te TimeEntry) String() string {
@@ -176,26 +315,73 @@ func NewTimeEntry(userID, workspaceID string, start time.Time) TimeEntry {
 	}
 }
 
+// ProjectCreateRequest represents the structure for creating a new project
+type ProjectCreateRequest struct {
+	Name     string `json:"name"`
+	ClientID string `json:"clientId,omitempty"`
+	Billable bool   `json:"billable"`
+	Public   bool   `json:"public"`
+	Color    string `json:"color,omitempty"`
+	Note     string `json:"note,omitempty"`
+	// Manual time estimate of the whole project, none when 0
+	Estimate time.Duration `json:"-"`
+}
+
+// MarshalJSON sends the estimate in the shape Clockify expects
+func (r ProjectCreateRequest) MarshalJSON() ([]byte, error) {
+	type request ProjectCreateRequest // Without the method, not to recurse
+	body := struct {
+		request
+		Estimate *TimeEstimate `json:"estimate,omitempty"`
+	}{request: request(r)}
+	if r.Estimate > 0 {
+		body.Estimate = &TimeEstimate{Estimate: formatISODuration(r.Estimate), Type: "MANUAL", Active: true}
+	}
+	return json.Marshal(body)
+}
+
+// ProjectUpdate holds the project fields to change, nil fields are left as they are
+type ProjectUpdate struct {
+	Name     *string `json:"name,omitempty"`
+	ClientID *string `json:"clientId,omitempty"` // Empty removes the client
+	Color    *string `json:"color,omitempty"`
+	Archived *bool   `json:"archived,omitempty"`
+}
+
+// ClientUpdate replaces the name and archived state of a client
+type ClientUpdate struct {
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+}
+
+// TagUpdate replaces the name and archived state of a tag
+type TagUpdate struct {
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+}
+
 // NewTimeEntryRequest represents the structure for creating a new time entry
 type NewTimeEntryRequest struct {
-	Start       time.Time  `json:"start"`
-	End         *time.Time `json:"end,omitempty"`
-	Billable    bool       `json:"billable"`
-	Description string     `json:"description,omitempty"`
-	ProjectID   string     `json:"projectId,omitempty"`
-	TaskID      string     `json:"taskId,omitempty"`
-	TagIDs      []string   `json:"tagIds,omitempty"`
+	Start       time.Time     `json:"start"`
+	End         *time.Time    `json:"end,omitempty"`
+	Billable    bool          `json:"billable"`
+	Description string        `json:"description,omitempty"`
+	ProjectID   string        `json:"projectId,omitempty"`
+	TaskID      string        `json:"taskId,omitempty"`
+	TagIDs      []string      `json:"tagIds,omitempty"`
+	Type        TimeEntryType `json:"type,omitempty"` // REGULAR or BREAK, regular when empty
 }
 
 // UpdateTimeEntryRequest represents the structure for updating a time entry
 type UpdateTimeEntryRequest struct {
-	Start       time.Time  `json:"start"`
-	End         *time.Time `json:"end,omitempty"`
-	Billable    bool       `json:"billable"`
-	Description string     `json:"description,omitempty"`
-	ProjectID   string     `json:"projectId,omitempty"`
-	TaskID      string     `json:"taskId,omitempty"`
-	TagIDs      []string   `json:"tagIds,omitempty"`
+	Start       time.Time     `json:"start"`
+	End         *time.Time    `json:"end,omitempty"`
+	Billable    bool          `json:"billable"`
+	Description string        `json:"description,omitempty"`
+	ProjectID   string        `json:"projectId,omitempty"`
+	TaskID      string        `json:"taskId,omitempty"`
+	TagIDs      []string      `json:"tagIds,omitempty"`
+	Type        TimeEntryType `json:"type,omitempty"` // REGULAR or BREAK, regular when empty
 }
 
 // HistoricalEntry represents a time entry for bulk historical creation
@@ -277,25 +463,26 @@ const (
 
 // WebhookRequest represents the structure for creating a new webhook
 type WebhookRequest struct {
-	Name              string                     `json:"name"`
-	TriggerSource     []WebhookTriggerSourceType `json:"triggerSource"`
-	TriggerSourceType WebhookTriggerSourceType   `json:"triggerSourceType"`
-	TargetURL         string                     `json:"url"`
-	Event             WebhookEvent               `json:"webhookEvent"`
+	Name string `json:"name"`
+	// IDs of the workspace, projects, users or tags the events are delivered for
+	TriggerSource     []string                 `json:"triggerSource"`
+	TriggerSourceType WebhookTriggerSourceType `json:"triggerSourceType"`
+	TargetURL         string                   `json:"url"`
+	Event             WebhookEvent             `json:"webhookEvent"`
 }
 
 // Webhook represents a webhook in Clockify
 type Webhook struct {
-	AuthToken         string                     `json:"authToken"`
-	Enabled           bool                       `json:"enabled"`
-	ID                string                     `json:"id"`
-	Name              string                     `json:"name"`
-	TriggerSource     []WebhookTriggerSourceType `json:"triggerSource"`
-	TriggerSourceType WebhookTriggerSourceType   `json:"triggerSourceType"`
-	TargetURL         string                     `json:"url"`
-	UserID            string                     `json:"userId"`
-	Event             WebhookEvent               `json:"webhookEvent"`
-	WorkspaceID       string                     `json:"workspaceId"`
+	AuthToken         string                   `json:"authToken"`
+	Enabled           bool                     `json:"enabled"`
+	ID                string                   `json:"id"`
+	Name              string                   `json:"name"`
+	TriggerSource     []string                 `json:"triggerSource"`
+	TriggerSourceType WebhookTriggerSourceType `json:"triggerSourceType"`
+	TargetURL         string                   `json:"url"`
+	UserID            string                   `json:"userId"`
+	Event             WebhookEvent             `json:"webhookEvent"`
+	WorkspaceID       string                   `json:"workspaceId"`
 }
 
 func (w Webhook) String() string {
@@ -0,0 +1,130 @@
+package clockify
+
+import (
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ClientOption configures an APIClient created by NewDefaultClient.
+type ClientOption func(*APIClient)
+
+// WithLogger enables request/response logging at debug level through the given logger.
+//
+// The API key or addon token is always masked, and bodies are redacted and truncated before logging.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *APIClient) {
+		c.logger = logger
+	}
+}
+
+// WithTransportMiddleware adds middlewares to the client's transport chain.
+//
+// They are applied in the given order, outside of the built-in retry, rate limiting
+// and logging middlewares.
+func WithTransportMiddleware(middlewares ...TransportMiddleware) ClientOption {
+	return func(c *APIClient) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithRetry sets the total number of attempts for retryable failures. 1 disables retries.
+func WithRetry(maxAttempts int) ClientOption {
+	return func(c *APIClient) {
+		c.retryAttempts = maxAttempts
+	}
+}
+
+// WithRateLimit caps the number of requests sent per second. 0 disables the limit.
+func WithRateLimit(perSecond int) ClientOption {
+	return func(c *APIClient) {
+		c.rateLimit = perSecond
+	}
+}
+
+// WithETagCache keeps up to size project, tag and client listings and revalidates them with
+// If-None-Match, see ETagCacheMiddleware. 0 disables the cache.
+func WithETagCache(size int) ClientOption {
+	return func(c *APIClient) {
+		c.etagCacheSize = size
+	}
+}
+
+// WithSharedState shares the rate limit and the ETag cache with the other replicas of a server
+// through the state, see SharedRateLimitMiddleware and SharedETagCacheMiddleware. Nil keeps
+// them in memory.
+func WithSharedState(state SharedState) ClientOption {
+	return func(c *APIClient) {
+		c.shared = state
+	}
+}
+
+// WithCircuitBreaker fails requests fast after threshold consecutive failures until a probe
+// sent after the cooldown succeeds, see CircuitBreakerMiddleware. 0 disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *APIClient) {
+		c.breakerThreshold = threshold
+		c.breakerCooldown = cooldown
+	}
+}
+
+// WithTracerProvider enables OpenTelemetry client spans around every request.
+func WithTracerProvider(tp trace.TracerProvider) ClientOption {
+	return func(c *APIClient) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithBaseURL points the client at a regional or self-hosted Clockify installation,
+// e.g. EUBaseURL. The reports and time off API URLs are derived from it. Empty keeps the default.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *APIClient) {
+		if baseURL != "" {
+			c.endpoints = EndpointsFor(baseURL)
+		}
+	}
+}
+
+// WithTimeout limits the duration of every request, including reading the response body and
+// retries, unless WithCallTimeouts sets one for its kind. 0 means no limit.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *APIClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to Clockify are kept for reuse.
+// Raise it when many goroutines share the client, e.g. paginating in parallel.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(c *APIClient) {
+		if n > 0 {
+			c.maxIdleConnsPerHost = n
+		}
+	}
+}
+
+// WithPageSize sets how many items each page of a listing holds, the maximum of 5000 by
+// default. Smaller pages return sooner at the cost of more requests.
+func WithPageSize(size int) ClientOption {
+	return func(c *APIClient) {
+		if size > 0 {
+			c.pageSize = min(size, 5000)
+		}
+	}
+}
+
+// WithProjectDefaults sets whether the projects CreateProject creates are billable and
+// public, billable and private by default
+func WithProjectDefaults(billable, public bool) ClientOption {
+	return func(c *APIClient) {
+		c.projectBillable, c.projectPublic = billable, public
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept before it is closed. 0 keeps them open.
+func WithIdleConnTimeout(timeout time.Duration) ClientOption {
+	return func(c *APIClient) {
+		c.idleConnTimeout = timeout
+	}
+}
@@ -0,0 +1,130 @@
+package clockify
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// lastPageHeader is set by Clockify on paginated listings, "true" on the last page
+const lastPageHeader = "Last-Page"
+
+// PageInfo describes a page of a paginated listing
+type PageInfo struct {
+	Page     int  // 1-based page number
+	PageSize int  // Requested page size
+	Items    int  // Items on this page
+	Last     bool // No pages follow, known from the Last-Page header or an empty page
+}
+
+// newPageInfo reads the pagination metadata of a listing response
+func newPageInfo(resp *http.Response, page, pageSize, items int) PageInfo {
+	last, err := strconv.ParseBool(resp.Header.Get(lastPageHeader))
+	return PageInfo{
+		Page:     page,
+		PageSize: pageSize,
+		Items:    items,
+		Last:     (err == nil && last) || items == 0,
+	}
+}
+
+// Pages returns the number of pages needed for total items, as counted by e.g. CountTimeEntries
+func (p PageInfo) Pages(total int) int {
+	if p.PageSize <= 0 || total <= 0 {
+		return 0
+	}
+	return (total + p.PageSize - 1) / p.PageSize
+}
+
+// Describe formats the position of the page, e.g. "page 3 of 17". A negative total means it
+// is unknown and only the page number is shown.
+func (p PageInfo) Describe(total int) string {
+	if total < 0 {
+		return fmt.Sprintf("page %d", p.Page)
+	}
+	return fmt.Sprintf("page %d of %d", p.Page, max(p.Pages(total), p.Page))
+}
+
+// Collect gathers every page of a paginated listing, e.g. IterProjects, stopping at the
+// first error
+func Collect[T any](pages iter.Seq2[[]T, error]) ([]T, error) {
+	var all []T
+	for page, err := range pages {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+	}
+	return all, nil
+}
+
+// TimeEntryPage is a page of time entries with its pagination metadata
+type TimeEntryPage struct {
+	Entries []TimeEntry
+	PageInfo
+}
+
+// TimeEntryCountFilter narrows the time entries counted by CountTimeEntries, zero fields
+// match everything
+type TimeEntryCountFilter struct {
+	UserID    string
+	ProjectID string
+	Start     time.Time
+	End       time.Time
+}
+
+// countRangeStart is used when the filter has no start, Clockify requires a date range
+var countRangeStart = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// CountTimeEntries returns the number of time entries matching the filter without listing
+// them, using the totals of a one-entry detailed report. It requires the Reports API, which
+// self-hosted installations may not serve.
+func (c *APIClient) CountTimeEntries(workspaceID string, filter TimeEntryCountFilter) (int, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/reports/detailed", c.endpoints.Reports, workspaceID)
+
+	start, end := filter.Start, filter.End
+	if start.IsZero() {
+		start = countRangeStart
+	}
+	if end.IsZero() {
+		end = time.Now().Add(24 * time.Hour)
+	}
+
+	request := map[string]any{
+		"dateRangeStart": start.UTC().Format(time.RFC3339),
+		"dateRangeEnd":   end.UTC().Format(time.RFC3339),
+		"detailedFilter": map[string]any{"page": 1, "pageSize": 1},
+		"exportType":     "JSON",
+	}
+	if filter.UserID != "" {
+		request["users"] = map[string]any{"ids": []string{filter.UserID}, "contains": "CONTAINS", "status": "ALL"}
+	}
+	if filter.ProjectID != "" {
+		request["projects"] = map[string]any{"ids": []string{filter.ProjectID}, "contains": "CONTAINS", "status": "ALL"}
+	}
+
+	resp, err := c.query(url, request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	var report struct {
+		Totals []struct {
+			EntriesCount int `json:"entriesCount"`
+		} `json:"totals"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return 0, err
+	}
+
+	// Clockify omits the totals, or sends a null one, when nothing matches
+	if len(report.Totals) == 0 {
+		return 0, nil
+	}
+	return report.Totals[0].EntriesCount, nil
+}
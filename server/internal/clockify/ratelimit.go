@@ -0,0 +1,130 @@
+package clockify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a minimal token bucket limiter allowing bursts of up to `capacity` requests
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+func newTokenBucket(perSecond int) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perSecond),
+		tokens:   float64(perSecond),
+		rate:     float64(perSecond),
+		last:     time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// take takes a token if one is left, otherwise it returns how long until one is
+func (b *tokenBucket) take(now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second)), false
+}
+
+// RateLimitMiddleware delays requests so that no more than perSecond requests are sent per second.
+func RateLimitMiddleware(perSecond int) TransportMiddleware {
+	bucket := newTokenBucket(perSecond)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if wait := bucket.reserve(); wait > 0 {
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(wait):
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// SharedRateLimitMiddleware is RateLimitMiddleware with the budget of each API key or addon
+// token shared by every replica through the state, counted in one-second windows. While the
+// state fails, each replica limits itself alone.
+func SharedRateLimitMiddleware(state SharedState, perSecond int) TransportMiddleware {
+	local := newTokenBucket(perSecond)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			key := "ratelimit:clockify:" + hashKey(credential(req))
+			for {
+				wait, ok, err := takeShared(req.Context(), state, key, perSecond, time.Now())
+				if err != nil {
+					slog.Warn("shared_state_failed", "use", "rate_limit", "error", err)
+					wait, ok = local.reserve(), true
+				}
+				if wait > 0 {
+					select {
+					case <-req.Context().Done():
+						return nil, req.Context().Err()
+					case <-time.After(wait):
+					}
+				}
+				if ok {
+					return next.RoundTrip(req)
+				}
+			}
+		})
+	}
+}
+
+// takeShared counts a request against the shared one-second window of the key. Over the limit,
+// it returns how long until the next window.
+func takeShared(ctx context.Context, state SharedState, key string, perSecond int, now time.Time) (time.Duration, bool, error) {
+	window := now.Truncate(time.Second)
+	// Kept a little past the window, clocks of the replicas drift apart
+	n, err := state.Incr(ctx, key+":"+strconv.FormatInt(window.Unix(), 10), 2*time.Second)
+	if err != nil {
+		return 0, false, err
+	}
+	if n > int64(perSecond) {
+		return window.Add(time.Second).Sub(now), false, nil
+	}
+	return 0, true, nil
+}
+
+// hashKey keeps credentials and other secrets out of shared state keys
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
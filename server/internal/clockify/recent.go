@@ -0,0 +1,92 @@
+package clockify
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRecentEntries is how many combinations GetRecentEntries returns for a limit of 0
+const DefaultRecentEntries = 10
+
+// maxRecentScan bounds how many past entries GetRecentEntries looks through
+const maxRecentScan = 1000
+
+// RecentEntry is a distinct description, project and task combination a user tracked. The
+// tags and billable flag are those of its latest entry.
+type RecentEntry struct {
+	Description string
+	ProjectID   string
+	TaskID      string
+	TagIDs      []string
+	Billable    bool
+	LastStarted time.Time
+}
+
+type recentKey struct {
+	description, projectID, taskID string
+}
+
+// GetRecentEntries returns the distinct combinations of the user's latest time entries,
+// the most recently started first. At most limit are returned, DefaultRecentEntries for 0.
+func (c *APIClient) GetRecentEntries(workspaceID, userID string, limit int) ([]RecentEntry, error) {
+	if limit <= 0 {
+		limit = DefaultRecentEntries
+	}
+
+	var recent []RecentEntry
+	seen := make(map[recentKey]bool)
+	scanned := 0
+
+	for timeEntries, err := range c.IterTimeEntries(workspaceID, userID, nil, nil) {
+		if err != nil {
+			return nil, err
+		}
+
+		// Clockify lists the newest entries first
+		for _, entry := range timeEntries {
+			key := recentKey{entry.Description, entry.ProjectID, entry.TaskID}
+			if !seen[key] {
+				seen[key] = true
+				recent = append(recent, RecentEntry{
+					Description: entry.Description,
+					ProjectID:   entry.ProjectID,
+					TaskID:      entry.TaskID,
+					TagIDs:      entry.TagIDs,
+					Billable:    entry.Billable,
+					LastStarted: entry.TimeInterval.Start,
+				})
+			}
+
+			scanned++
+			if len(recent) == limit || scanned == maxRecentScan {
+				return recent, nil
+			}
+		}
+	}
+
+	return recent, nil
+}
+
+// StartFromRecent starts a new timer tracking the n-th entry of GetRecentEntries, 1 being
+// the most recent
+func (c *APIClient) StartFromRecent(workspaceID, userID string, n int) (*TimeEntry, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("recent entry %d: must be 1 or more", n)
+	}
+
+	recent, err := c.GetRecentEntries(workspaceID, userID, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(recent) < n {
+		return nil, fmt.Errorf("recent entry %d %w, there are %d", n, ErrNotFound, len(recent))
+	}
+
+	entry := recent[n-1]
+	return c.StartDefaultTimer(workspaceID, userID, entry.Description, TimerDefaults{
+		ProjectID: entry.ProjectID,
+		TaskID:    entry.TaskID,
+		TagIDs:    entry.TagIDs,
+		Billable:  entry.Billable,
+	})
+}
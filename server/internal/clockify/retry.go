@@ -0,0 +1,145 @@
+package clockify
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
+)
+
+// isRetryableStatus reports whether the status means the request may succeed if repeated
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+type noRetryKey struct{}
+
+type idempotentKey struct{}
+
+// Idempotent marks the requests sent with the context as safe to repeat whatever their
+// method, e.g. POSTs that only query, or whose receiver drops the duplicates
+func Idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent reports whether sending the request twice has the effect of sending it once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	default:
+		return req.Context().Value(idempotentKey{}) != nil
+	}
+}
+
+// shouldRetry reports whether the request may be repeated after the response or error. A
+// network error or a gateway status leaves unknown whether the request was handled, so only
+// idempotent requests are repeated then, while a 429 is sent before handling any.
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return (err != nil || isRetryableStatus(resp.StatusCode)) && isIdempotent(req)
+}
+
+// withoutRetries marks the requests sent with the context as not to be repeated by
+// RetryMiddleware, for calls that retry on their own terms
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// RetryMiddleware repeats requests failing with network errors, 429 or 5xx gateway statuses
+// up to maxAttempts times in total, with exponential backoff starting at baseDelay.
+//
+// Requests of other methods than GET, HEAD, OPTIONS, PUT, DELETE and PATCH, e.g. POSTs
+// creating something, are only repeated after a 429, unless their context was marked
+// Idempotent: a network error or gateway status may follow a request that was handled.
+//
+// A Retry-After header sent by Clockify takes precedence over the backoff.
+func RetryMiddleware(maxAttempts int, baseDelay time.Duration) TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			delay := baseDelay
+			// Requests with a body can only be repeated when the body can be re-read
+			replayable := req.Body == nil || req.GetBody != nil
+			if req.Context().Value(noRetryKey{}) != nil {
+				replayable = false
+			}
+
+			for attempt := 1; ; attempt++ {
+				attemptReq := req
+				if attempt > 1 && req.Body != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					attemptReq = req.Clone(req.Context())
+					attemptReq.Body = body
+				}
+
+				resp, err := next.RoundTrip(attemptReq)
+				if !replayable || attempt >= maxAttempts || !shouldRetry(req, resp, err) {
+					return resp, err
+				}
+
+				wait := delay
+				if err == nil {
+					if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+						wait = retryAfter
+					}
+					// Drain so the connection can be reused
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+
+				slog.Debug("retrying_request", "method", req.Method, "attempt", attempt, "wait", wait, "error", err)
+
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(wait):
+				}
+
+				delay = min(delay*2, maxRetryDelay)
+			}
+		})
+	}
+}
+
+// RetryingClient returns a client of other services than Clockify that retries with the
+// policy of the Clockify client: see RetryMiddleware, with backoff starting at a second. The
+// timeout bounds each call across all its attempts, and a nil transport stands for
+// http.DefaultTransport.
+func RetryingClient(transport http.RoundTripper, attempts int, timeout time.Duration) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: RetryMiddleware(attempts, time.Second)(transport),
+	}
+}
+
+// parseRetryAfter parses the delay-seconds form of the Retry-After header
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return min(time.Duration(seconds)*time.Second, maxRetryDelay), true
+}
@@ -0,0 +1,99 @@
+package clockify
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Assignment schedules a user on a project for some hours on each day of a period
+type Assignment struct {
+	ID          string  `json:"id"`
+	WorkspaceID string  `json:"workspaceId"`
+	UserID      string  `json:"userId"`
+	UserName    string  `json:"userName,omitempty"`
+	ProjectID   string  `json:"projectId"`
+	ProjectName string  `json:"projectName,omitempty"`
+	ClientName  string  `json:"clientName,omitempty"`
+	HoursPerDay float64 `json:"hoursPerDay"`
+	// Also scheduled on Saturdays and Sundays
+	IncludeNonWorkingDays bool      `json:"includeNonWorkingDays"`
+	Period                DateRange `json:"period"`
+	Billable              bool      `json:"billable"`
+	Note                  string    `json:"note,omitempty"`
+}
+
+// Daily returns the time scheduled each day
+func (a Assignment) Daily() time.Duration {
+	return time.Duration(a.HoursPerDay * float64(time.Hour))
+}
+
+// ScheduledOn reports whether the assignment schedules time on the day, a date of the period
+// whatever its time of day and location
+func (a Assignment) ScheduledOn(day time.Time) bool {
+	if !a.IncludeNonWorkingDays && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+		return false
+	}
+	// Clockify stores the period as days in UTC
+	date := dateOf(day)
+	return !date.Before(dateOf(a.Period.Start.UTC())) && !date.After(dateOf(a.Period.End.UTC()))
+}
+
+// dateOf returns the date of t as midnight in UTC
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetAssignments retrieves a page of the workspace assignments overlapping the range, those of
+// every user. The workspace plan must include scheduling, otherwise ErrFeatureUnavailable is
+// returned.
+func (c *APIClient) GetAssignments(workspaceID string, start, end time.Time, page int) ([]Assignment, error) {
+	params := url.Values{
+		"start":     {start.UTC().Format(time.RFC3339)},
+		"end":       {end.UTC().Format(time.RFC3339)},
+		"page":      {strconv.Itoa(page)},
+		"page-size": {strconv.Itoa(c.pageSize)},
+	}
+	urlStr := fmt.Sprintf("%s/workspaces/%s/scheduling/assignments/all?%s", c.endpoints.API, workspaceID, params.Encode())
+
+	resp, err := c.get(urlStr)
+	if err != nil {
+		return nil, c.featureError(workspaceID, FeatureScheduling, err)
+	}
+
+	defer resp.Body.Close()
+
+	var assignments []Assignment
+	if err := json.NewDecoder(resp.Body).Decode(&assignments); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// IterAssignments iterates over the workspace assignments overlapping the range, page by page
+func (c *APIClient) IterAssignments(workspaceID string, start, end time.Time) iter.Seq2[[]Assignment, error] {
+	return func(yield func([]Assignment, error) bool) {
+		page := 1
+		for {
+			assignments, err := c.GetAssignments(workspaceID, start, end, page)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if len(assignments) == 0 {
+				return
+			}
+
+			if !yield(assignments, nil) {
+				return
+			}
+
+			page++
+		}
+	}
+}
@@ -1,18 +1,33 @@
 package clockify
 
 import (
+	"cmp"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"mime"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WorkspaceWebhookService is a service for managing webhooks for a workspace.
 //
 // It is responsible for managing the lifecycle of a webhook. By default, it
-// accepts all events regarding the workspace.
+// accepts all events regarding the workspace, WithTrigger narrows the time entry events
+// down to some projects, users or tags.
 //
 // *Note*: Clockify allows only one event per webhook. Therefore, to capture different events,
 // the service creates multiple webhooks.
@@ -22,58 +37,510 @@ type WorkspaceWebhookService struct {
 	workspace Workspace
 	url       string
 
-	webhooks map[WebhookEvent]Webhook
+	tokenGrace  time.Duration
+	maxBodySize int64
+
+	triggerType WebhookTriggerSourceType
+	triggerIDs  []string
+
+	// events maps the events webhooks are created for to the decoder of their payload,
+	// resolved from the event options by the constructor
+	events      map[WebhookEvent]payloadDecoder
+	eventSet    []WebhookEvent // Nil for the default events
+	extraEvents []WebhookEvent
+	payloads    map[WebhookEvent]payloadDecoder
+	subscribers map[WebhookEvent][]subscriber
+
+	onCreate     func(created []Webhook) error
+	ownerTag     string // Tags the webhook names, empty without WithOwner
+	pollFallback bool
+
+	// Handler settings
+	receive     WebhookReceiver
+	receiveBody WebhookBodyReceiver
+	middlewares []WebhookMiddleware
+	dedupe      deliveries
+
+	// mu guards the webhooks, the tokens they replaced and the events left without one
+	mu           sync.RWMutex
+	webhooks     map[WebhookEvent]Webhook
+	previous     map[WebhookEvent]retiredToken
+	unsubscribed []WebhookEvent
+
+	poll poller
+
+	// lastEvent is the Unix time in nanoseconds the last valid delivery was processed at
+	lastEvent atomic.Int64
+}
+
+// retiredToken is an auth token replaced by a rotation, still accepted until the deadline
+type retiredToken struct {
+	token string
+	until time.Time
+}
+
+// DefaultTokenGrace is how long a rotated webhook token keeps being accepted
+const DefaultTokenGrace = 10 * time.Minute
+
+// WebhookServiceOption configures a WorkspaceWebhookService
+type WebhookServiceOption func(*WorkspaceWebhookService)
+
+// WithTokenGrace sets how long the previous auth token is accepted after RotateTokens,
+// covering deliveries Clockify signed before the rotation
+func WithTokenGrace(d time.Duration) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.tokenGrace = d
+	}
 }
 
-func NewWorkspaceWebhookService(apiClient *APIClient, workspace Workspace, url string) *WorkspaceWebhookService {
-	return &WorkspaceWebhookService{apiClient: apiClient, workspace: workspace, url: url}
+// WithMaxBodySize sets the largest delivery body accepted, DefaultMaxWebhookBodySize by default
+func WithMaxBodySize(n int64) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		if n > 0 {
+			s.maxBodySize = n
+		}
+	}
 }
 
+// WithTrigger delivers the time entry events only for the given projects, users or tags.
+// Events about new projects, clients and tags cannot be narrowed down and stay
+// workspace-wide. WORKSPACE_ID or no IDs keep the default.
+func WithTrigger(sourceType WebhookTriggerSourceType, ids ...string) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		if sourceType == WorkspaceIDTrigger || len(ids) == 0 {
+			s.triggerType, s.triggerIDs = "", nil
+			return
+		}
+		s.triggerType, s.triggerIDs = sourceType, ids
+	}
+}
+
+// WithEvents also creates webhooks for the given events, on top of the default ones. Events
+// that are default anyway or have no known payload type are ignored.
+func WithEvents(events ...WebhookEvent) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.extraEvents = append(s.extraEvents, events...)
+	}
+}
+
+// WithEventSet creates webhooks for exactly the given events instead of the default ones.
+// Deliveries of events without a known or registered payload type are passed on as
+// *json.RawMessage. Events of WithEvents are still added.
+func WithEventSet(events ...WebhookEvent) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.eventSet = append([]WebhookEvent{}, events...)
+	}
+}
+
+// WithPayloadType decodes the deliveries of the event passed to the receiver into T, e.g.
+// *Task. It does not subscribe to the event by itself.
+func WithPayloadType[T any](event WebhookEvent) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		if s.payloads == nil {
+			s.payloads = make(map[WebhookEvent]payloadDecoder)
+		}
+		s.payloads[event] = decodeAs[T]
+	}
+}
+
+// subscriber decodes a delivery into the type of its handler and calls it
+type subscriber func(ctx context.Context, body []byte) error
+
+// Subscribe calls handler with the deliveries of the event Handler accepts, decoded into T,
+// e.g. *TimeEntry or Project, after the receiver. The webhook for the event is created even
+// without a known payload type. Like the receiver's, a handler's failure is logged and does
+// not fail the delivery.
+func Subscribe[T any](event WebhookEvent, handler func(ctx context.Context, payload T) error) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		if s.subscribers == nil {
+			s.subscribers = make(map[WebhookEvent][]subscriber)
+		}
+		s.subscribers[event] = append(s.subscribers[event], func(ctx context.Context, body []byte) error {
+			var payload T
+			if err := json.Unmarshal(body, &payload); err != nil {
+				return fmt.Errorf("%w: %w", ErrWebhookBody, err)
+			}
+			return handler(ctx, payload)
+		})
+	}
+}
+
+// WithOwner tags the names of the webhooks Create makes with a hash of owner, a stable
+// identifier of the deployment, e.g. its host name and command. Instances sharing a workspace
+// then tell their webhooks apart, see Owns and DeleteLeftovers.
+func WithOwner(owner string) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.ownerTag = ownerTag(owner)
+	}
+}
+
+// WithPollingFallback keeps the webhooks Create made when Clockify's limit of webhooks per
+// workspace is reached, instead of failing and deleting them. The events left without a
+// webhook are reported by Unsubscribed, and Poll delivers those it can tell from the API.
+func WithPollingFallback() WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.pollFallback = true
+	}
+}
+
+// WithCreateHook calls fn with every webhook that exists so far after each one Create
+// registers, and after a failed Create with those the rollback could not delete. An error
+// from fn fails Create.
+func WithCreateHook(fn func(created []Webhook) error) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.onCreate = fn
+	}
+}
+
+func NewWorkspaceWebhookService(apiClient *APIClient, workspace Workspace, url string, opts ...WebhookServiceOption) *WorkspaceWebhookService {
+	s := &WorkspaceWebhookService{apiClient: apiClient, workspace: workspace, url: url, tokenGrace: DefaultTokenGrace, maxBodySize: DefaultMaxWebhookBodySize}
+	s.dedupe.window = DefaultDedupeWindow
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.events = s.resolveEvents()
+	return s
+}
+
+// resolveEvents returns the events of the options with the decoder of their payload
+func (s *WorkspaceWebhookService) resolveEvents() map[WebhookEvent]payloadDecoder {
+	events := make(map[WebhookEvent]payloadDecoder)
+	if s.eventSet == nil {
+		for event := range eventToObject {
+			events[event], _ = s.payloadType(event)
+		}
+	}
+	// Events that are asked for by name are created without a known payload type too
+	for _, event := range slices.Concat(s.eventSet, slices.Collect(maps.Keys(s.subscribers))) {
+		decode, ok := s.payloadType(event)
+		if !ok {
+			decode = decodeAs[*json.RawMessage]
+		}
+		events[event] = decode
+	}
+	for _, event := range s.extraEvents {
+		if decode, ok := s.payloadType(event); ok {
+			events[event] = decode
+		}
+	}
+	return events
+}
+
+// payloadType returns the decoder of the deliveries of the event
+func (s *WorkspaceWebhookService) payloadType(event WebhookEvent) (payloadDecoder, bool) {
+	if decode, ok := s.payloads[event]; ok {
+		return decode, true
+	}
+	if decode, ok := eventToObject[event]; ok {
+		return decode, true
+	}
+	decode, ok := optionalEvents[event]
+	return decode, ok
+}
+
+// Events returns the events webhooks are created for
+func (s *WorkspaceWebhookService) Events() []WebhookEvent {
+	return slices.Sorted(maps.Keys(s.events))
+}
+
+// MaxWebhooksPerWorkspace is Clockify's limit on webhooks in a single workspace
+const MaxWebhooksPerWorkspace = 10
+
 var (
 	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrTooManyWebhooks = errors.New("too many webhooks")
 	ErrDeleteWebhook   = errors.New("failed to delete webhook")
+	ErrRotateTokens    = errors.New("failed to rotate webhook tokens")
 )
 
-var eventToObject = map[WebhookEvent]any{
-	NewTimerStartedEvent: &TimeEntry{},
-	TimerStoppedEvent:    &TimeEntry{},
-	NewClientEvent:       &Client{},
-	NewProjectEvent:      &Project{},
-	NewTagEvent:          &Tag{},
+// filterableEvents can be triggered by the projects, users or tags of their time entry
+var filterableEvents = map[WebhookEvent]bool{
+	NewTimerStartedEvent:  true,
+	TimerStoppedEvent:     true,
+	NewTimeEntryEvent:     true,
+	TimeEntryUpdatedEvent: true,
+	TimeEntryDeletedEvent: true,
+}
+
+// payloadDecoder decodes the body of a delivery into its payload
+type payloadDecoder func(body []byte) (any, error)
+
+// decodeAs is the payloadDecoder of payloads of type T
+func decodeAs[T any](body []byte) (any, error) {
+	var payload T
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodePayload decodes the body of a delivery of the event the way a WorkspaceWebhookService
+// without WithPayloadType does, into *json.RawMessage for events without a known payload type
+func DecodePayload(event WebhookEvent, body []byte) (any, error) {
+	decode, ok := eventToObject[event]
+	if !ok {
+		decode, ok = optionalEvents[event]
+	}
+	if !ok {
+		decode = decodeAs[*json.RawMessage]
+	}
+	return decode(body)
+}
+
+var eventToObject = map[WebhookEvent]payloadDecoder{
+	NewTimerStartedEvent: decodeAs[*TimeEntry],
+	TimerStoppedEvent:    decodeAs[*TimeEntry],
+	NewClientEvent:       decodeAs[*Client],
+	NewProjectEvent:      decodeAs[*Project],
+	NewTagEvent:          decodeAs[*Tag],
+}
+
+// optionalEvents are the events WithEvents can add, Clockify limits the webhooks per workspace
+var optionalEvents = map[WebhookEvent]payloadDecoder{
+	NewTimeEntryEvent:     decodeAs[*TimeEntry],
+	TimeEntryUpdatedEvent: decodeAs[*TimeEntry],
+	TimeEntryDeletedEvent: decodeAs[*TimeEntry],
+	TagUpdatedEvent:       decodeAs[*Tag],
+	TagDeletedEvent:       decodeAs[*Tag],
+}
+
+// WebhookEventError is the failure to create or delete the webhook of an event
+type WebhookEventError struct {
+	Event WebhookEvent
+	Err   error
+}
+
+func (e *WebhookEventError) Error() string {
+	return fmt.Sprintf("webhook for %s: %v", e.Event, e.Err)
+}
+
+func (e *WebhookEventError) Unwrap() error {
+	return e.Err
 }
 
 // Create creates a new webhook for the workspace.
+//
+// It fails with ErrTooManyWebhooks before creating any when the events would exceed
+// MaxWebhooksPerWorkspace, counting the webhooks already in the workspace. Every event is
+// attempted, the failures are returned as *WebhookEventError, one per event. When any fails,
+// the webhooks created are deleted again, so a failed Create leaves no partial set behind.
+//
+// With WithPollingFallback, the events over the limit, or over Clockify's own count when it
+// rejects a webhook for the limit, are left unsubscribed instead. The webhooks go to the
+// events Poll cannot cover first.
 func (s *WorkspaceWebhookService) Create() error {
+	existing, err := s.apiClient.GetWebhooks(s.workspace.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	if err := s.checkLimit(len(existing)); err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(existing)+len(s.events))
+	for _, webhook := range existing {
+		names[webhook.Name] = true
+	}
+
+	events := slices.Sorted(maps.Keys(s.events))
+	if s.pollFallback {
+		slices.SortStableFunc(events, func(a, b WebhookEvent) int {
+			return cmp.Compare(pollRank(a), pollRank(b))
+		})
+	}
+	room := MaxWebhooksPerWorkspace - len(existing)
+
 	webhooks := make(map[WebhookEvent]Webhook)
+	var created []Webhook
+	var unsubscribed []WebhookEvent
+	var errs []error
+
+	for i, event := range events {
+		if s.pollFallback && len(webhooks) >= room {
+			unsubscribed = events[i:]
+			break
+		}
+		name, err := makeWebhookName(s.workspace.Name, s.ownerTag, names)
+		if err != nil {
+			errs = append(errs, &WebhookEventError{Event: event, Err: err})
+			continue
+		}
+		names[name] = true
 
-	for event := range eventToObject {
+		sourceType, sources := s.trigger(event)
 		webhook, err := s.apiClient.CreateWebhook(s.workspace.ID, WebhookRequest{
-			Name:              makeWebhookName(s.workspace.Name),
+			Name:              name,
 			Event:             event,
-			TriggerSource:     []WebhookTriggerSourceType{WorkspaceIDTrigger},
-			TriggerSourceType: WorkspaceIDTrigger,
+			TriggerSource:     sources,
+			TriggerSourceType: sourceType,
 			TargetURL:         s.url,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create webhook: %w", err)
+			if isWebhookLimit(err) {
+				// Another client may have made webhooks since they were listed
+				if s.pollFallback {
+					unsubscribed = events[i:]
+					break
+				}
+				err = fmt.Errorf("%w: %w", ErrTooManyWebhooks, err)
+			}
+			errs = append(errs, &WebhookEventError{Event: event, Err: err})
+			continue
 		}
 		webhooks[event] = *webhook
+		created = append(created, *webhook)
+		// Without a record of the webhooks, those created next could be left behind
+		if err := s.created(created); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record webhooks: %w", err))
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(fmt.Errorf("failed to create webhooks: %w", errors.Join(errs...)), s.rollback(created))
 	}
 
+	s.mu.Lock()
 	s.webhooks = webhooks
+	s.previous = nil
+	s.unsubscribed = slices.Sorted(slices.Values(unsubscribed))
+	s.mu.Unlock()
+
+	if len(unsubscribed) > 0 {
+		var polled, lost []WebhookEvent
+		for _, event := range s.unsubscribed {
+			if _, ok := pollableEvents[event]; ok {
+				polled = append(polled, event)
+			} else {
+				lost = append(lost, event)
+			}
+		}
+		slog.Warn("webhook_limit_reached", "limit", MaxWebhooksPerWorkspace, "existing", len(existing),
+			"subscribed", len(webhooks), "polled", polled, "not_polled", lost)
+	}
+	return nil
+}
+
+// Unsubscribed returns the events Create left without a webhook for Clockify's limit, see
+// WithPollingFallback
+func (s *WorkspaceWebhookService) Unsubscribed() []WebhookEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.unsubscribed)
+}
+
+// isWebhookLimit reports whether Clockify rejected a new webhook for its limit per workspace
+func isWebhookLimit(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && strings.Contains(strings.ToLower(apiErr.Message), "maximum number of webhooks")
+}
+
+// checkLimit fails when the events cannot be subscribed to next to the existing webhooks, only
+// when there are none to subscribe to with WithPollingFallback
+func (s *WorkspaceWebhookService) checkLimit(existing int) error {
+	if len(s.events) == 0 {
+		return errors.New("no webhook events to subscribe to")
+	}
+	if s.pollFallback {
+		return nil
+	}
+	if len(s.events) > MaxWebhooksPerWorkspace {
+		return fmt.Errorf("%w: %d events, Clockify allows %d webhooks per workspace", ErrTooManyWebhooks, len(s.events), MaxWebhooksPerWorkspace)
+	}
+	if existing+len(s.events) > MaxWebhooksPerWorkspace {
+		return fmt.Errorf("%w: %d events and %d existing webhooks, Clockify allows %d per workspace",
+			ErrTooManyWebhooks, len(s.events), existing, MaxWebhooksPerWorkspace)
+	}
+	return nil
+}
 
+// rollback deletes the webhooks of a failed Create
+func (s *WorkspaceWebhookService) rollback(created []Webhook) error {
+	var remaining []Webhook
+	var errs []error
+	for _, webhook := range created {
+		if err := s.apiClient.DeleteWebhook(s.workspace.ID, webhook.ID); err != nil {
+			remaining = append(remaining, webhook)
+			errs = append(errs, &WebhookEventError{Event: webhook.Event, Err: err})
+		}
+	}
+	if err := s.created(remaining); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(append([]error{ErrDeleteWebhook}, errs...)...)
+	}
 	return nil
 }
 
+func (s *WorkspaceWebhookService) created(webhooks []Webhook) error {
+	if s.onCreate == nil {
+		return nil
+	}
+	return s.onCreate(webhooks)
+}
+
+// Owns reports whether the webhook was created by a service with the same owner, see WithOwner
+func (s *WorkspaceWebhookService) Owns(webhook Webhook) bool {
+	return s.ownerTag != "" && webhookOwnerTag(webhook.Name) == s.ownerTag
+}
+
+// DeleteLeftovers deletes the webhooks with the given IDs that still exist in the workspace,
+// e.g. those of a previous run that was killed before deleting them, and those it Owns but
+// did not create itself. It returns how many were deleted.
+func (s *WorkspaceWebhookService) DeleteLeftovers(ids []string) (int, error) {
+	existing, err := s.apiClient.GetWebhooks(s.workspace.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	leftover := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		leftover[id] = true
+	}
+	s.mu.RLock()
+	current := make(map[string]bool, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		current[webhook.ID] = true
+	}
+	s.mu.RUnlock()
+
+	deleted := 0
+	var errs []error
+	for _, webhook := range existing {
+		if current[webhook.ID] || !leftover[webhook.ID] && !s.Owns(webhook) {
+			continue
+		}
+		if err := s.apiClient.DeleteWebhook(s.workspace.ID, webhook.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted++
+	}
+	if len(errs) > 0 {
+		return deleted, errors.Join(append([]error{ErrDeleteWebhook}, errs...)...)
+	}
+	return deleted, nil
+}
+
+// trigger returns the trigger source the webhook for the event is created with
+func (s *WorkspaceWebhookService) trigger(event WebhookEvent) (WebhookTriggerSourceType, []string) {
+	if s.triggerType != "" && filterableEvents[event] {
+		return s.triggerType, s.triggerIDs
+	}
+	return WorkspaceIDTrigger, []string{s.workspace.ID}
+}
+
 // Delete deletes the webhook for the workspace.
 func (s *WorkspaceWebhookService) Delete() error {
 	totalErr := ErrDeleteWebhook
 	ok := true
 
-	for _, webhook := range s.webhooks {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for event, webhook := range s.webhooks {
 		err := s.apiClient.DeleteWebhook(s.workspace.ID, webhook.ID)
 		if err != nil {
-			totalErr = errors.Join(totalErr, err)
+			totalErr = errors.Join(totalErr, &WebhookEventError{Event: event, Err: err})
 			ok = false
 		}
 	}
@@ -85,69 +552,175 @@ func (s *WorkspaceWebhookService) Delete() error {
 	return nil
 }
 
+// Missing returns the registered webhooks that no longer exist in Clockify, e.g. because
+// they were deleted in the UI or disabled after failed deliveries
+func (s *WorkspaceWebhookService) Missing(ctx context.Context) ([]Webhook, error) {
+	existing, err := s.apiClient.WithContext(ctx).GetWebhooks(s.workspace.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	enabled := make(map[string]bool, len(existing))
+	for _, webhook := range existing {
+		enabled[webhook.ID] = webhook.Enabled
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var missing []Webhook
+	for _, webhook := range s.webhooks {
+		if !enabled[webhook.ID] {
+			missing = append(missing, webhook)
+		}
+	}
+	return missing, nil
+}
+
+// LastEvent returns when the last valid delivery was processed, zero if there was none
+func (s *WorkspaceWebhookService) LastEvent() time.Time {
+	nanos := s.lastEvent.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// RotateTokens regenerates the auth token of every registered webhook.
+//
+// The new tokens replace the stored ones at once, so deliveries are never checked against a
+// mix of old and new tokens. The replaced tokens stay valid for the grace period. Webhooks
+// whose regeneration failed keep their token and are reported in the returned error.
+func (s *WorkspaceWebhookService) RotateTokens() error {
+	s.mu.RLock()
+	current := make([]Webhook, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		current = append(current, webhook)
+	}
+	s.mu.RUnlock()
+
+	rotated := make(map[WebhookEvent]Webhook, len(current))
+	var errs []error
+	for _, webhook := range current {
+		updated, err := s.apiClient.GenerateWebhookAuthToken(s.workspace.ID, webhook.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", webhook.ID, err))
+			continue
+		}
+		rotated[webhook.Event] = *updated
+	}
+
+	until := time.Now().Add(s.tokenGrace)
+
+	s.mu.Lock()
+	if s.previous == nil {
+		s.previous = make(map[WebhookEvent]retiredToken)
+	}
+	for event, webhook := range rotated {
+		s.previous[event] = retiredToken{token: s.webhooks[event].AuthToken, until: until}
+		s.webhooks[event] = webhook
+	}
+	s.mu.Unlock()
+
+	slog.Info("webhook_tokens_rotated", "rotated", len(rotated), "failed", len(errs), "grace", s.tokenGrace)
+
+	if len(errs) > 0 {
+		return errors.Join(append([]error{ErrRotateTokens}, errs...)...)
+	}
+	return nil
+}
+
+// verifySignature reports whether the signature is the auth token of the event's webhook,
+// or the token it replaced while that is within the grace period
+func (s *WorkspaceWebhookService) verifySignature(event WebhookEvent, signature string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if webhook, ok := s.webhooks[event]; ok && tokenEqual(webhook.AuthToken, signature) {
+		return true
+	}
+	if retired, ok := s.previous[event]; ok && time.Now().Before(retired.until) {
+		return tokenEqual(retired.token, signature)
+	}
+	return false
+}
+
+// tokenEqual compares tokens in constant time, an empty token never matches
+func tokenEqual(token, signature string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(signature)) == 1
+}
+
+// process verifies and decodes a delivery, returning its event, payload and body
+//
 // TODO: webhook returns different schema than the API client uses. Create new models/adapt existing.
-func (s *WorkspaceWebhookService) ProcessWebhook(r *http.Request) (WebhookEvent, any, error) {
+func (s *WorkspaceWebhookService) process(r *http.Request) (WebhookEvent, any, []byte, error) {
+	ctx, span := s.apiClient.tracer().Start(r.Context(), "webhook.process", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
+	event, obj, body, err := s.processWebhook(ctx, r)
+	span.SetAttributes(attribute.String("clockify.webhook.event", string(event)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		s.lastEvent.Store(time.Now().UnixNano())
+	}
+
+	return event, obj, body, err
+}
+
+func (s *WorkspaceWebhookService) processWebhook(ctx context.Context, r *http.Request) (WebhookEvent, any, []byte, error) {
+	if r.Method != http.MethodPost {
+		return "", nil, nil, fmt.Errorf("%w: %s", ErrWebhookMethod, r.Method)
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return "", nil, nil, fmt.Errorf("%w: %q", ErrWebhookContentType, r.Header.Get("Content-Type"))
+	}
+
 	eventType := r.Header.Get("Clockify-Webhook-Event-Type")
 	if eventType == "" {
-		slog.Error("missing_event_type_header")
-		return "", nil, errors.New("missing Clockify-Webhook-Event-Type header")
+		return "", nil, nil, fmt.Errorf("%w: missing Clockify-Webhook-Event-Type header", ErrWebhookEvent)
 	}
 
 	event := WebhookEvent(eventType)
 	slog.Debug("processing_webhook", "event", event)
 
-	objTemplate, ok := eventToObject[event]
+	decode, ok := s.events[event]
 	if !ok {
-		slog.Error("unsupported_event_type", "event", event)
-		return event, nil, fmt.Errorf("unsupported event type: %s", eventType)
+		return event, nil, nil, fmt.Errorf("%w: unsupported event type %s", ErrWebhookEvent, eventType)
 	}
 
-	// Signature verification (stub)
+	// Clockify signs deliveries with the auth token of the webhook
+	_, verifySpan := s.apiClient.tracer().Start(ctx, "webhook.verify_signature")
 	signature := r.Header.Get("Clockify-Signature")
 	if signature == "" {
-		slog.Error("missing_signature_header")
-		return event, nil, errors.New("missing Clockify-Signature header")
+		verifySpan.End()
+		return event, nil, nil, fmt.Errorf("%w: missing Clockify-Signature header", ErrWebhookSignature)
 	}
-	if !verifyClockifySignature(signature, r) {
-		slog.Error("invalid_signature")
-		return event, nil, errors.New("invalid signature")
+	valid := s.verifySignature(event, signature)
+	verifySpan.End()
+	if !valid {
+		return event, nil, nil, ErrWebhookSignature
 	}
 
 	// Read and decode body
-	body, err := io.ReadAll(r.Body)
+	_, decodeSpan := s.apiClient.tracer().Start(ctx, "webhook.decode")
+	defer decodeSpan.End()
+
+	defer r.Body.Close()
+	// One byte more than allowed tells a body at the limit from a longer one
+	body, err := io.ReadAll(io.LimitReader(r.Body, s.maxBodySize+1))
 	if err != nil {
-		slog.Error("failed_to_read_body", "error", err)
-		return event, nil, fmt.Errorf("failed to read body: %w", err)
+		return event, nil, nil, fmt.Errorf("failed to read body: %w", err)
 	}
-	defer r.Body.Close()
-
-	obj := cloneObject(objTemplate)
-	if err := json.Unmarshal(body, obj); err != nil {
-		slog.Error("failed_to_unmarshal_body", "error", err, "obj", obj)
-		return event, nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	if int64(len(body)) > s.maxBodySize {
+		return event, nil, nil, fmt.Errorf("%w: over %d bytes", ErrWebhookTooLarge, s.maxBodySize)
 	}
 
-	return event, obj, nil
-}
-
-// cloneObject returns a new instance of the same type as the template (pointer to struct)
-func cloneObject(template any) any {
-	switch template.(type) {
-	case *TimeEntry:
-		return &TimeEntry{}
-	case *Client:
-		return &Client{}
-	case *Project:
-		return &Project{}
-	case *Tag:
-		return &Tag{}
-	default:
-		return nil
+	obj, err := decode(body)
+	if err != nil {
+		return event, nil, nil, fmt.Errorf("%w: %w", ErrWebhookBody, err)
 	}
-}
 
-// verifyClockifySignature is a stub for signature verification
-func verifyClockifySignature(signature string, r *http.Request) bool {
-	// TODO: Implement signature verification using webhook secret
-	return true // Always valid for now
+	return event, obj, body, nil
 }
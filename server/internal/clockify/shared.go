@@ -0,0 +1,21 @@
+package clockify
+
+import (
+	"context"
+	"time"
+)
+
+// SharedState is state the replicas of a server behind a load balancer share, e.g. in Redis,
+// so that they drop the same redeliveries, reuse each other's cached listings and stay within
+// one rate limit. Callers namespace their keys.
+type SharedState interface {
+	// Add stores the key for ttl unless it is stored already, reporting whether it was added
+	Add(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Get returns the value stored under key, ok is false when there is none
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores the value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	// Incr increments the counter under key, created to expire ttl later, and returns its value
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
@@ -0,0 +1,94 @@
+package clockify
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CallTimeouts bound every Clockify call, its retries included, by what it does. Zero fields
+// fall back to WithTimeout.
+type CallTimeouts struct {
+	Write time.Duration // Creating, updating and deleting, which Clockify answers quickly
+	Read  time.Duration // Fetching single objects
+	List  time.Duration // Pages of listings and reports, which may take a while to compute
+}
+
+// WithCallTimeouts bounds the calls by kind, e.g. short for writes and longer for reports
+func WithCallTimeouts(timeouts CallTimeouts) ClientOption {
+	return func(c *APIClient) {
+		c.callTimeouts = timeouts
+	}
+}
+
+// WithDeadline returns a copy of the client whose calls fail once the budget from now is spent,
+// for composite operations to give up as a whole rather than let hung calls stall them. The
+// migrations and bulk operations of the copy stop at the first entry after it is spent. Cancel
+// releases the budget.
+func (c *APIClient) WithDeadline(budget time.Duration) (*APIClient, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.context(), budget)
+	return c.WithContext(ctx), cancel
+}
+
+// budgetSpent returns the error of the client's context once it is done, after which the
+// calls of a composite operation would only fail one by one
+func (c *APIClient) budgetSpent() error {
+	if err := c.context().Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("deadline budget spent: %w", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// callTimeout returns how long the request may take
+func (c *APIClient) callTimeout(req *http.Request) time.Duration {
+	var timeout time.Duration
+	switch {
+	case c.endpoints.Reports != "" && strings.HasPrefix(req.URL.String(), c.endpoints.Reports),
+		req.Method == http.MethodGet && req.URL.Query().Has("page"):
+		timeout = c.callTimeouts.List
+	case req.Method == http.MethodGet:
+		timeout = c.callTimeouts.Read
+	default:
+		timeout = c.callTimeouts.Write
+	}
+	return cmp.Or(timeout, c.timeout)
+}
+
+// withCallTimeout bounds the request by its timeout. The returned cancel must be called once
+// the response body is read, see cancelOnClose.
+func (c *APIClient) withCallTimeout(req *http.Request) (*http.Request, time.Duration, context.CancelFunc) {
+	timeout := c.callTimeout(req)
+	if timeout <= 0 {
+		return req, 0, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return req.WithContext(ctx), timeout, cancel
+}
+
+// callTimeoutError tells a call that ran out of its own timeout from one whose caller gave up
+func callTimeoutError(req *http.Request, parent context.Context, timeout time.Duration, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		return fmt.Errorf("%s %s: no answer within %s: %w", req.Method, req.URL.Path, timeout, err)
+	}
+	return err
+}
+
+// cancelOnClose releases the timeout of a call once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,69 @@
+package clockify
+
+import (
+	"net/http"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Hukyl/CCWS/internal/clockify"
+
+// idSegmentPattern matches Clockify object IDs (Mongo ObjectIDs) inside URL paths
+var idSegmentPattern = regexp.MustCompile(`/[0-9a-f]{24}(/|$)`)
+
+// spanRoute replaces object IDs in the path with a placeholder to keep span names low-cardinality
+func spanRoute(path string) string {
+	// Applied twice since adjacent IDs share the separating slash
+	path = idSegmentPattern.ReplaceAllString(path, "/{id}$1")
+	return idSegmentPattern.ReplaceAllString(path, "/{id}$1")
+}
+
+// TracingMiddleware wraps every request in a client span and propagates the trace context
+// of the request to Clockify.
+func TracingMiddleware(tp trace.TracerProvider) TransportMiddleware {
+	tracer := tp.Tracer(tracerName)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(req.Context(), "clockify "+req.Method+" "+spanRoute(req.URL.Path),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("server.address", req.URL.Host),
+					attribute.String("url.path", req.URL.Path),
+				),
+			)
+			defer span.End()
+
+			req = req.Clone(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, err
+			}
+
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// tracer returns the tracer configured for the client, falling back to the global provider
+func (c *APIClient) tracer() trace.Tracer {
+	if c.tracerProvider != nil {
+		return c.tracerProvider.Tracer(tracerName)
+	}
+	return otel.Tracer(tracerName)
+}
@@ -0,0 +1,24 @@
+package clockify
+
+import "net/http"
+
+// TransportMiddleware wraps an http.RoundTripper with additional behaviour
+// (tracing, caching, recording, ...).
+type TransportMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts an ordinary function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// chainTransport wraps base with the middlewares. The first middleware is the outermost one,
+// i.e. it sees the request first and the response last.
+func chainTransport(base http.RoundTripper, middlewares ...TransportMiddleware) http.RoundTripper {
+	rt := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+	return rt
+}
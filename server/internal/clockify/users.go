@@ -0,0 +1,136 @@
+package clockify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// UserStatus is the membership status of a user in a workspace
+type UserStatus string
+
+const (
+	UserActive   UserStatus = "ACTIVE"
+	UserInactive UserStatus = "INACTIVE"
+	// The user was invited and has not joined yet
+	UserPending UserStatus = "PENDING_EMAIL_VERIFICATION"
+)
+
+// InviteUser adds a user to a workspace by email and sends them an invitation. Clockify
+// fires UserJoinedWorkspaceEvent once they accept.
+func (c *APIClient) InviteUser(workspaceID, email string) (*User, error) {
+	urlStr := fmt.Sprintf("%s/workspaces/%s/users?sendEmail=true", c.endpoints.API, workspaceID)
+
+	resp, err := c.post(urlStr, map[string]any{"email": email})
+	if err != nil {
+		return nil, err
+	}
+	// Clockify answers with the workspace, the invited user has to be looked up
+	resp.Body.Close()
+
+	return c.FindWorkspaceUserByEmail(workspaceID, email)
+}
+
+// FindWorkspaceUserByEmail finds a member of a workspace, invited ones included
+func (c *APIClient) FindWorkspaceUserByEmail(workspaceID, email string) (*User, error) {
+	urlStr := fmt.Sprintf("%s/workspaces/%s/users?%s", c.endpoints.API, workspaceID, url.Values{"email": {email}}.Encode())
+
+	resp, err := c.get(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var users []User
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return nil, err
+	}
+
+	// The filter matches substrings, so look for the exact address
+	for _, user := range users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+
+	return nil, fmt.Errorf("user '%s' %w in workspace", email, ErrNotFound)
+}
+
+// RemoveUser removes a user from a workspace, firing UserDeletedFromWorkspaceEvent. Their
+// time entries stay in the workspace.
+func (c *APIClient) RemoveUser(workspaceID, userID string) error {
+	url := fmt.Sprintf("%s/workspaces/%s/users/%s", c.endpoints.API, workspaceID, userID)
+
+	resp, err := c.delete(url)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+	return nil
+}
+
+// UpdateUserStatus activates or deactivates a user in a workspace, firing
+// UserActivatedOnWorkspaceEvent or UserDeactivatedOnWorkspaceEvent. Deactivated users keep
+// their data but cannot track time.
+func (c *APIClient) UpdateUserStatus(workspaceID, userID string, status UserStatus) (*User, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/users/%s", c.endpoints.API, workspaceID, userID)
+
+	resp, err := c.put(url, map[string]any{"status": status})
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var user User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// MemberProfile is the profile of a user in a workspace, with the capacity the scheduling
+// features plan with
+type MemberProfile struct {
+	Email        string   `json:"email"`
+	Name         string   `json:"name"`
+	WeekStart    string   `json:"weekStart,omitempty"`
+	WorkCapacity string   `json:"workCapacity,omitempty"` // Per working day, e.g. PT8H
+	WorkingDays  []string `json:"workingDays,omitempty"`  // e.g. MONDAY
+}
+
+// WeeklyCapacity returns the work capacity per day times the working days, 0 when either is
+// unset
+func (p MemberProfile) WeeklyCapacity() (time.Duration, error) {
+	if p.WorkCapacity == "" {
+		return 0, nil
+	}
+	daily, err := parseISODuration(p.WorkCapacity)
+	if err != nil {
+		return 0, err
+	}
+	return daily * time.Duration(len(p.WorkingDays)), nil
+}
+
+// GetMemberProfile retrieves the profile of a user in a workspace
+func (c *APIClient) GetMemberProfile(workspaceID, userID string) (*MemberProfile, error) {
+	url := fmt.Sprintf("%s/workspaces/%s/member-profile/%s", c.endpoints.API, workspaceID, userID)
+
+	resp, err := c.get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var profile MemberProfile
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
@@ -1,60 +1,154 @@
 package clockify
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"log/slog"
-	"math/rand"
+	"math/big"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// parseISODuration parses the time part of an ISO 8601 duration as used by Clockify, e.g. PT1H30M
+func parseISODuration(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(s, "PT")
+	if !ok {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+
+	var total time.Duration
+	for rest != "" {
+		i := strings.IndexAny(rest, "HMS")
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		value, err := strconv.ParseFloat(rest[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+
+		unit := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}[rest[i]]
+		total += time.Duration(value * float64(unit))
+		rest = rest[i+1:]
+	}
+	return total, nil
+}
+
+// formatISODuration formats the duration as the time part of an ISO 8601 duration, e.g. PT1H30M
+func formatISODuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d <= 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m := d % time.Hour / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if s := d % time.Minute / time.Second; s > 0 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	return b.String()
+}
+
 // kebabify converts a string to kebab-case
 func kebabify(s string) string {
 	return strings.ToLower(strings.ReplaceAll(s, " ", "-"))
 }
 
-func makeWebhookName(workspaceName string) string {
-	const maxWebhookNameLength = 30
-	const randomPartLength = 6
-	const suffix = "-wh"
-	const allowedRunes = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+// maxNameAttempts is how many random webhook names are tried before giving up on a unique one
+const maxNameAttempts = 10
 
-	// 1. Cut the workspace name up to 20 chars
-	if len(workspaceName) > 20 {
-		workspaceName = workspaceName[:20]
-	}
+// webhookNameSuffix ends the names of the webhooks created by CCWS
+const webhookNameSuffix = "-wh"
 
-	// 2. Strip whitespace and control chars
-	stripped := make([]rune, 0, len(workspaceName))
-	for _, r := range workspaceName {
-		if r > 31 && r != 127 && r != ' ' && r != '\t' && r != '\n' && r != '\r' {
-			stripped = append(stripped, r)
-		}
+// ownerTagLength is the length of the owner tag in webhook names
+const ownerTagLength = 6
+
+// Lengths of the random part of webhook names, shorter after an owner tag to fit Clockify's
+// 30 characters. Only the tagged length is followed, so a random part is never read as a tag.
+const (
+	randomPartLength       = 6
+	taggedRandomPartLength = 4
+)
+
+// ownerTag returns the tag of the owner in the names of its webhooks, "" without an owner
+func ownerTag(owner string) string {
+	if owner == "" {
+		return ""
 	}
+	sum := sha256.Sum256([]byte(owner))
+	return hex.EncodeToString(sum[:])[:ownerTagLength]
+}
 
-	// 3. Kebabify it
-	kebabified := kebabify(string(stripped))
+// webhookOwnerTag returns the owner tag of a webhook name made by makeWebhookName, "" for
+// names without one. A tag is the hex segment before a random part of the tagged length,
+// untagged names end in a longer random part.
+func webhookOwnerTag(name string) string {
+	rest, ok := strings.CutSuffix(name, webhookNameSuffix)
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(rest, "-")
+	if len(parts) < 3 || len(parts[len(parts)-1]) != taggedRandomPartLength {
+		return ""
+	}
+	tag := parts[len(parts)-2]
+	if _, err := hex.DecodeString(tag); err != nil || len(tag) != ownerTagLength {
+		return ""
+	}
+	return tag
+}
 
-	// 4. Add a hyphen and 6 random symbols (A-Z, a-z, 0-9)
-	randomPart := make([]rune, randomPartLength)
-	for i := range randomPart {
-		randomPart[i] = rune(allowedRunes[seededRandInt(len(allowedRunes))])
+// makeWebhookName returns a name for a webhook of the workspace that is not in taken: the
+// kebab-cased workspace name followed by the owner tag if any and a random part, e.g.
+// "my-team-x7Gk2Q-wh" or "my-team-3fa9c1-x7Gk-wh" for "My Team"
+func makeWebhookName(workspaceName, tag string, taken map[string]bool) (string, error) {
+	const allowedRunes = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// Clockify allows 30 characters, the owner tag takes from the random part, as the names
+	// are checked for uniqueness anyway
+	randomLength := randomPartLength
+	if tag != "" {
+		randomLength = taggedRandomPartLength
+		tag = "-" + tag
 	}
+	maxWorkspacePartLength := 30 - len(tag) - 1 - randomLength - len(webhookNameSuffix)
 
-	// 5. Add a hyphen and 'wh'
-	name := fmt.Sprintf("%s-%s%s", kebabified, string(randomPart), suffix)
+	// 1. Kebabify it, then strip the remaining whitespace and control chars
+	kebabified := make([]rune, 0, len(workspaceName))
+	for _, r := range kebabify(workspaceName) {
+		if r > 31 && r != 127 && r != ' ' && r != '\t' && r != '\n' && r != '\r' && r != utf8.RuneError {
+			kebabified = append(kebabified, r)
+		}
+	}
 
-	// 6. Ensure total length <= 30
-	if len(name) > maxWebhookNameLength {
-		slog.Warn("webhook_name_too_long", "name", name, "max_length", maxWebhookNameLength)
-		name = name[:maxWebhookNameLength]
+	// 2. Cut it by characters, not splitting multi-byte ones
+	if len(kebabified) > maxWorkspacePartLength {
+		kebabified = kebabified[:maxWorkspacePartLength]
 	}
 
-	return name
-}
+	// 3. Add the tag, a hyphen, random symbols (A-Z, a-z, 0-9), a hyphen and 'wh', until unique
+	for range maxNameAttempts {
+		randomPart := make([]byte, randomLength)
+		for i := range randomPart {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(allowedRunes))))
+			if err != nil {
+				return "", fmt.Errorf("failed to generate webhook name: %w", err)
+			}
+			randomPart[i] = allowedRunes[n.Int64()]
+		}
 
-// seededRandInt returns a random int in [0, n) using math/rand with a seeded source.
-func seededRandInt(n int) int {
-	// Use a package-level seeded rand for thread safety in real code
-	// Here, for simplicity, use rand.Intn
-	return rand.Intn(n)
+		name := fmt.Sprintf("%s%s-%s%s", string(kebabified), tag, randomPart, webhookNameSuffix)
+		if !taken[name] {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a unique webhook name in %d attempts", maxNameAttempts)
 }
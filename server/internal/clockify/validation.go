@@ -0,0 +1,136 @@
+package clockify
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// FieldError is an invalid field of a request, named as in its JSON form
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors are the invalid fields of a request, returned by the Validate methods and by
+// client methods refusing to send the request. It matches ErrValidation with errors.Is.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+func (e FieldErrors) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// add records an invalid field, formatting the message like fmt.Sprintf
+func (e *FieldErrors) add(field, format string, args ...any) {
+	*e = append(*e, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the errors, nil if there are none
+func (e FieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// timeEntryFields validates the fields shared by the requests creating and updating entries
+func (e *FieldErrors) timeEntryFields(request NewTimeEntryRequest) {
+	if request.Start.IsZero() {
+		e.add("start", "is required")
+	} else if request.End != nil && request.End.Before(request.Start) {
+		e.add("end", "must not be before start")
+	}
+	if request.TaskID != "" && request.ProjectID == "" {
+		e.add("taskId", "requires projectId")
+	}
+	if slices.Contains(request.TagIDs, "") {
+		e.add("tagIds", "must not contain empty IDs")
+	}
+	// Holiday and time off entries are only created by Clockify
+	if request.Type != "" && request.Type != TimeEntryRegular && request.Type != TimeEntryBreak {
+		e.add("type", "must be REGULAR or BREAK, got %q", request.Type)
+	}
+}
+
+// Validate reports the invalid fields of the request: a missing start, an end before the start,
+// a task without a project, empty tag IDs or a type other than REGULAR or BREAK
+func (r NewTimeEntryRequest) Validate() error {
+	var errs FieldErrors
+	errs.timeEntryFields(r)
+	return errs.err()
+}
+
+// Validate reports the invalid fields of the request, like NewTimeEntryRequest.Validate
+func (r UpdateTimeEntryRequest) Validate() error {
+	var errs FieldErrors
+	errs.timeEntryFields(NewTimeEntryRequest(r))
+	return errs.err()
+}
+
+// Validate reports the invalid fields of the request: a missing name, event or trigger, empty
+// trigger source IDs or a target URL that is not an absolute http(s) URL
+func (r WebhookRequest) Validate() error {
+	var errs FieldErrors
+	if strings.TrimSpace(r.Name) == "" {
+		errs.add("name", "is required")
+	}
+	if r.Event == "" {
+		errs.add("webhookEvent", "is required")
+	}
+	if r.TriggerSourceType == "" {
+		errs.add("triggerSourceType", "is required")
+	}
+	if len(r.TriggerSource) == 0 {
+		errs.add("triggerSource", "is required")
+	} else if slices.Contains(r.TriggerSource, "") {
+		errs.add("triggerSource", "must not contain empty IDs")
+	}
+	if u, err := url.Parse(r.TargetURL); r.TargetURL == "" {
+		errs.add("url", "is required")
+	} else if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		errs.add("url", "must be an absolute http or https URL, got %q", r.TargetURL)
+	}
+	return errs.err()
+}
+
+// Validate reports the invalid fields of the entry: a start hour outside 0-23 or minute
+// outside 0-59, a duration that is not positive, empty IDs or a task without a project
+func (e HistoricalEntry) Validate() error {
+	var errs FieldErrors
+	if e.StartHour < 0 || e.StartHour > 23 {
+		errs.add("startHour", "must be between 0 and 23, got %d", e.StartHour)
+	}
+	if e.StartMinute < 0 || e.StartMinute > 59 {
+		errs.add("startMinute", "must be between 0 and 59, got %d", e.StartMinute)
+	}
+	if e.Duration <= 0 {
+		errs.add("duration", "must be positive, got %s", e.Duration)
+	}
+	if e.ProjectID != nil && *e.ProjectID == "" {
+		errs.add("projectId", "must not be empty when set")
+	}
+	if e.TaskID != nil {
+		if *e.TaskID == "" {
+			errs.add("taskId", "must not be empty when set")
+		} else if e.ProjectID == nil {
+			errs.add("taskId", "requires projectId")
+		}
+	}
+	if slices.Contains(e.TagIDs, "") {
+		errs.add("tagIds", "must not contain empty IDs")
+	}
+	return errs.err()
+}
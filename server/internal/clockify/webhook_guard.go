@@ -0,0 +1,124 @@
+package clockify
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientIdle is how long the rate limit of a client is kept after its last request
+const clientIdle = time.Minute
+
+// LimitWebhookRate answers 429 Too Many Requests to clients sending more than perSecond
+// requests per second, each client IP with its own budget allowing bursts of perSecond. With
+// trustProxy the client IP is the last X-Forwarded-For address, as added by a reverse proxy or
+// tunnel in front of the server.
+func LimitWebhookRate(perSecond int, trustProxy bool) WebhookMiddleware {
+	var (
+		mu        sync.Mutex
+		clients   = make(map[string]*tokenBucket)
+		lastSweep = time.Now()
+	)
+	bucket := func(ip string, now time.Time) *tokenBucket {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if now.Sub(lastSweep) >= clientIdle {
+			for ip, b := range clients {
+				b.mu.Lock()
+				idle := now.Sub(b.last) >= clientIdle
+				b.mu.Unlock()
+				if idle {
+					delete(clients, ip)
+				}
+			}
+			lastSweep = now
+		}
+
+		b, ok := clients[ip]
+		if !ok {
+			b = newTokenBucket(perSecond)
+			clients[ip] = b
+		}
+		return b
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r, trustProxy)
+			now := time.Now()
+			if wait, ok := bucket(ip, now).take(now); !ok {
+				slog.Debug("webhook_rate_limited", "client_ip", ip)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitSharedWebhookRate is LimitWebhookRate with the budget of each client IP shared by every
+// replica through the state, counted in one-second windows. While the state fails, each
+// replica limits clients alone.
+func LimitSharedWebhookRate(state SharedState, perSecond int, trustProxy bool) WebhookMiddleware {
+	local := LimitWebhookRate(perSecond, trustProxy)
+
+	return func(next http.Handler) http.Handler {
+		fallback := local(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r, trustProxy)
+			wait, ok, err := takeShared(r.Context(), state, "ratelimit:webhook:"+ip, perSecond, time.Now())
+			if err != nil {
+				slog.Warn("shared_state_failed", "use", "webhook_rate_limit", "error", err)
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			if !ok {
+				slog.Debug("webhook_rate_limited", "client_ip", ip)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the address a request came from
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RequirePathToken answers 404 Not Found to requests whose last path segment is not token, so
+// the endpoint cannot be found, let alone probed, without the URL the webhooks were created with
+func RequirePathToken(token string) WebhookMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(path.Base(r.URL.Path)), []byte(token)) != 1 {
+				http.NotFound(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
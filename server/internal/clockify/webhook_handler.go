@@ -0,0 +1,223 @@
+package clockify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultMaxWebhookBodySize is the largest delivery body accepted unless WithMaxBodySize is
+// given, Clockify's payloads are a few kilobytes
+const DefaultMaxWebhookBodySize = 1 << 20
+
+// DefaultDedupeWindow is how long Handler remembers deliveries to drop Clockify's redeliveries,
+// unless WithDedupeWindow is given
+const DefaultDedupeWindow = 10 * time.Minute
+
+// Errors of rejected deliveries, see WebhookStatus for the matching HTTP statuses
+var (
+	ErrWebhookMethod      = errors.New("method not allowed")
+	ErrWebhookContentType = errors.New("content type must be application/json")
+	ErrWebhookTooLarge    = errors.New("body too large")
+	ErrWebhookEvent       = errors.New("unsupported event")
+	ErrWebhookSignature   = errors.New("invalid signature")
+	ErrWebhookBody        = errors.New("invalid body")
+)
+
+// WebhookReceiver is passed every delivery Handler accepted. A failing receiver does not fail
+// the delivery, otherwise Clockify would keep redelivering an event that was received fine, so
+// it reports its failures itself.
+type WebhookReceiver func(ctx context.Context, event WebhookEvent, payload any)
+
+// WebhookBodyReceiver is passed the body of every delivery Handler accepted, and of every
+// polled change, as received, before the receiver and subscribers. It must not keep the body.
+type WebhookBodyReceiver func(ctx context.Context, event WebhookEvent, body []byte)
+
+// WebhookMiddleware wraps the webhook handler, e.g. to log, count or authenticate deliveries
+type WebhookMiddleware func(next http.Handler) http.Handler
+
+// WithReceiver sets what Handler passes the accepted deliveries to
+func WithReceiver(receive WebhookReceiver) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.receive = receive
+	}
+}
+
+// WithBodyReceiver sets what Handler passes the raw bodies of the accepted deliveries to, e.g.
+// to archive them
+func WithBodyReceiver(receive WebhookBodyReceiver) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.receiveBody = receive
+	}
+}
+
+// WithHandlerMiddleware wraps Handler in the middlewares, the first one outermost
+func WithHandlerMiddleware(middlewares ...WebhookMiddleware) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.middlewares = append(s.middlewares, middlewares...)
+	}
+}
+
+// WithDedupeWindow sets how long Handler remembers deliveries, a delivery with the same event
+// and body within the window is acknowledged without being passed on again. 0 disables it.
+func WithDedupeWindow(window time.Duration) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.dedupe.window = window
+	}
+}
+
+// WithSharedDedupe remembers deliveries in the state shared by the replicas of a server, so
+// that a redelivery landing on another replica is dropped too. While the state fails, each
+// replica remembers its own deliveries.
+func WithSharedDedupe(state SharedState) WebhookServiceOption {
+	return func(s *WorkspaceWebhookService) {
+		s.dedupe.shared = state
+	}
+}
+
+// WebhookStatus returns the HTTP status a delivery rejected with err is answered with
+func WebhookStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrWebhookMethod):
+		return http.StatusMethodNotAllowed
+	case errors.Is(err, ErrWebhookContentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrWebhookTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrWebhookSignature):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// Handler serves Clockify's deliveries: it verifies and decodes them, drops redeliveries and
+// passes the rest to the receiver and the handlers of Subscribe. Rejected deliveries are
+// logged and answered with WebhookStatus.
+func (s *WorkspaceWebhookService) Handler() http.Handler {
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, payload, body, err := s.process(r)
+		if err != nil {
+			slog.Warn("webhook_rejected", "event", event, "error", err)
+			if errors.Is(err, ErrWebhookMethod) {
+				w.Header().Set("Allow", http.MethodPost)
+			}
+			http.Error(w, err.Error(), WebhookStatus(err))
+			return
+		}
+
+		if s.dedupe.seen(r.Context(), event, body, time.Now()) {
+			slog.Debug("webhook_duplicate", "event", event)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		slog.Debug("webhook_received", "event", event)
+
+		s.deliver(r.Context(), event, payload, body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, middleware := range slices.Backward(s.middlewares) {
+		handler = middleware(handler)
+	}
+	return handler
+}
+
+// deliver passes an accepted delivery or polled change to the receiver and the handlers of
+// Subscribe
+func (s *WorkspaceWebhookService) deliver(ctx context.Context, event WebhookEvent, payload any, body []byte) {
+	if s.receiveBody != nil {
+		s.receiveBody(ctx, event, body)
+	}
+	if s.receive != nil {
+		s.receive(ctx, event, payload)
+	}
+	for _, handle := range s.subscribers[event] {
+		if err := handle(ctx, body); err != nil {
+			slog.Error("webhook_subscriber_failed", "event", event, "error", err)
+		}
+	}
+}
+
+// deliveries remembers the deliveries of the dedupe window by a hash of their event and body
+type deliveries struct {
+	window time.Duration
+	shared SharedState // Nil remembers them in memory only
+
+	mu     sync.Mutex
+	seenAt map[[sha256.Size]byte]time.Time
+}
+
+// seen records the delivery, reporting whether it was already received within the window
+func (d *deliveries) seen(ctx context.Context, event WebhookEvent, body []byte, now time.Time) bool {
+	if d.window <= 0 {
+		return false
+	}
+	key := sha256.Sum256(append([]byte(event+"\n"), body...))
+
+	if d.shared != nil {
+		added, err := d.shared.Add(ctx, "dedupe:"+hex.EncodeToString(key[:]), d.window)
+		if err == nil {
+			return !added
+		}
+		slog.Warn("shared_state_failed", "use", "webhook_dedupe", "error", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seenAt == nil {
+		d.seenAt = make(map[[sha256.Size]byte]time.Time)
+	}
+	for k, at := range d.seenAt {
+		if now.Sub(at) >= d.window {
+			delete(d.seenAt, k)
+		}
+	}
+
+	if _, ok := d.seenAt[key]; ok {
+		return true
+	}
+	d.seenAt[key] = now
+	return false
+}
+
+// LogWebhooks logs the method, headers and body of every delivery at info level before it is
+// processed, for debugging. The signature is masked.
+func LogWebhooks(logger *slog.Logger) WebhookMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attrs := []any{"method", r.Method, "path", r.URL.Path}
+			for name, values := range r.Header {
+				for _, value := range values {
+					if name == "Clockify-Signature" {
+						value = maskAPIKey(value)
+					}
+					attrs = append(attrs, slog.String("header."+name, value))
+				}
+			}
+
+			// Read at most what is accepted, the rest is left for the size check
+			body, err := io.ReadAll(io.LimitReader(r.Body, DefaultMaxWebhookBodySize))
+			if err != nil {
+				attrs = append(attrs, "body_error", err)
+			} else {
+				attrs = append(attrs, "body", string(body))
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+			logger.InfoContext(r.Context(), "webhook_delivery", attrs...)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package clockify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PollWindow is how long after they start Poll follows time entries, changes to older ones
+// are not delivered
+const PollWindow = 24 * time.Hour
+
+// pollResource is what Poll lists to tell the events of its kind
+type pollResource string
+
+const (
+	pollTimeEntries pollResource = "time entries"
+	pollProjects    pollResource = "projects"
+	pollClients     pollResource = "clients"
+	pollTags        pollResource = "tags"
+)
+
+// pollableEvents are the events Poll can tell from listing their resource
+var pollableEvents = map[WebhookEvent]pollResource{
+	NewTimerStartedEvent:  pollTimeEntries,
+	TimerStoppedEvent:     pollTimeEntries,
+	NewTimeEntryEvent:     pollTimeEntries,
+	TimeEntryUpdatedEvent: pollTimeEntries,
+	TimeEntryDeletedEvent: pollTimeEntries,
+	NewProjectEvent:       pollProjects,
+	NewClientEvent:        pollClients,
+	NewTagEvent:           pollTags,
+	TagUpdatedEvent:       pollTags,
+	TagDeletedEvent:       pollTags,
+}
+
+// pollRank orders the events that cannot be polled before those that can
+func pollRank(event WebhookEvent) int {
+	if _, ok := pollableEvents[event]; ok {
+		return 1
+	}
+	return 0
+}
+
+// polledItem is a resource as Poll listed it last
+type polledItem struct {
+	body    []byte // JSON, delivered as the payload
+	running bool   // Time entries without an end
+	start   time.Time
+}
+
+// poller keeps the resources Poll listed last by ID, a resource without a listing yet is
+// listed to record its state only
+type poller struct {
+	mu   sync.Mutex
+	seen map[pollResource]map[string]polledItem
+}
+
+// Poll lists the resources of the Unsubscribed events through the API and delivers what
+// changed since the previous Poll to the receiver and the handlers of Subscribe, like Handler
+// does with deliveries. The first Poll records the current state only.
+//
+// Time entries are followed for PollWindow after they start, those of the users of a USER_ID
+// trigger or of every user in the workspace. Events that cannot be told from the API are not
+// delivered, see the not_polled events Create logs.
+func (s *WorkspaceWebhookService) Poll(ctx context.Context) error {
+	wanted := make(map[pollResource][]WebhookEvent)
+	for _, event := range s.Unsubscribed() {
+		if resource, ok := pollableEvents[event]; ok {
+			wanted[resource] = append(wanted[resource], event)
+		}
+	}
+
+	s.poll.mu.Lock()
+	defer s.poll.mu.Unlock()
+	if s.poll.seen == nil {
+		s.poll.seen = make(map[pollResource]map[string]polledItem)
+	}
+
+	since := time.Now().Add(-PollWindow)
+	var errs []error
+	for _, resource := range slices.Sorted(maps.Keys(wanted)) {
+		items, err := s.listPolled(ctx, resource, since)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to poll %s: %w", resource, err))
+			continue
+		}
+		previous, ok := s.poll.seen[resource]
+		s.poll.seen[resource] = items
+		if !ok {
+			continue
+		}
+
+		for _, change := range diffPolled(resource, previous, items, since) {
+			if !slices.Contains(wanted[resource], change.event) {
+				continue
+			}
+			payload, err := s.events[change.event](change.body)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to decode polled %s: %w", change.event, err))
+				continue
+			}
+			slog.Debug("webhook_polled", "event", change.event)
+			s.lastEvent.Store(time.Now().UnixNano())
+			s.deliver(ctx, change.event, payload, change.body)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listPolled lists the resource by ID, the time entries started since the given time
+func (s *WorkspaceWebhookService) listPolled(ctx context.Context, resource pollResource, since time.Time) (map[string]polledItem, error) {
+	client := s.apiClient.WithContext(ctx)
+	items := make(map[string]polledItem)
+
+	switch resource {
+	case pollProjects:
+		return items, collectPolled(items, client.IterProjects(s.workspace.ID), func(p Project) (string, polledItem, bool) {
+			return p.ID, polledItem{}, true
+		})
+	case pollClients:
+		return items, collectPolled(items, client.IterClients(s.workspace.ID), func(c Client) (string, polledItem, bool) {
+			return c.ID, polledItem{}, true
+		})
+	case pollTags:
+		return items, collectPolled(items, client.IterTags(s.workspace.ID), func(t Tag) (string, polledItem, bool) {
+			return t.ID, polledItem{}, true
+		})
+	}
+
+	users := s.triggerIDs
+	if s.triggerType != UserIDTrigger {
+		users = nil
+		for page, err := range client.IterWorkspaceUsers(s.workspace.ID) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to list users: %w", err)
+			}
+			for _, user := range page {
+				users = append(users, user.ID)
+			}
+		}
+	}
+	for _, user := range users {
+		err := collectPolled(items, client.IterTimeEntries(s.workspace.ID, user, &since, nil), func(e TimeEntry) (string, polledItem, bool) {
+			if e.TimeInterval == nil || !s.triggers(e) {
+				return "", polledItem{}, false
+			}
+			return e.ID, polledItem{running: e.TimeInterval.End == nil, start: e.TimeInterval.Start}, true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// collectPolled adds the listed resources to items, describe returns the ID and state of a
+// resource or false to leave it out
+func collectPolled[T any](items map[string]polledItem, pages iter.Seq2[[]T, error], describe func(T) (string, polledItem, bool)) error {
+	for page, err := range pages {
+		if err != nil {
+			return err
+		}
+		for _, v := range page {
+			id, item, ok := describe(v)
+			if !ok {
+				continue
+			}
+			if item.body, err = json.Marshal(v); err != nil {
+				return err
+			}
+			items[id] = item
+		}
+	}
+	return nil
+}
+
+// triggers reports whether a webhook with the trigger of the service would be delivered the
+// time entry's events. USER_ID triggers are applied by listing only the entries of the users.
+func (s *WorkspaceWebhookService) triggers(entry TimeEntry) bool {
+	switch s.triggerType {
+	case ProjectIDTrigger:
+		return slices.Contains(s.triggerIDs, entry.ProjectID)
+	case TaskIDTrigger:
+		return slices.Contains(s.triggerIDs, entry.TaskID)
+	case TagIDTrigger:
+		return slices.ContainsFunc(entry.TagIDs, func(id string) bool { return slices.Contains(s.triggerIDs, id) })
+	default:
+		return true
+	}
+}
+
+// pollChange is an event told from two listings of a resource, with the resource as its body
+type pollChange struct {
+	event WebhookEvent
+	body  []byte
+}
+
+// diffPolled returns the events of the resources added, changed and removed between the
+// listings. Time entries that started before since are not removed, only no longer listed.
+func diffPolled(resource pollResource, previous, current map[string]polledItem, since time.Time) []pollChange {
+	var changes []pollChange
+	for _, id := range slices.Sorted(maps.Keys(current)) {
+		item := current[id]
+		before, ok := previous[id]
+		switch {
+		case !ok:
+			changes = append(changes, pollChange{event: createdEvent(resource, item), body: item.body})
+		case !bytes.Equal(before.body, item.body):
+			changes = append(changes, pollChange{event: updatedEvent(resource, before, item), body: item.body})
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(previous)) {
+		before := previous[id]
+		if _, ok := current[id]; ok || resource == pollTimeEntries && before.start.Before(since) {
+			continue
+		}
+		changes = append(changes, pollChange{event: deletedEvent(resource), body: before.body})
+	}
+	return changes
+}
+
+func createdEvent(resource pollResource, item polledItem) WebhookEvent {
+	switch resource {
+	case pollTimeEntries:
+		if item.running {
+			return NewTimerStartedEvent
+		}
+		return NewTimeEntryEvent
+	case pollProjects:
+		return NewProjectEvent
+	case pollClients:
+		return NewClientEvent
+	default:
+		return NewTagEvent
+	}
+}
+
+// updatedEvent returns the event of a changed resource, empty for projects and clients,
+// whose changes have no event Poll delivers
+func updatedEvent(resource pollResource, before, after polledItem) WebhookEvent {
+	switch resource {
+	case pollTimeEntries:
+		if before.running && !after.running {
+			return TimerStoppedEvent
+		}
+		return TimeEntryUpdatedEvent
+	case pollTags:
+		return TagUpdatedEvent
+	default:
+		return ""
+	}
+}
+
+// deletedEvent returns the event of a removed resource, empty for projects and clients
+func deletedEvent(resource pollResource) WebhookEvent {
+	switch resource {
+	case pollTimeEntries:
+		return TimeEntryDeletedEvent
+	case pollTags:
+		return TagDeletedEvent
+	default:
+		return ""
+	}
+}
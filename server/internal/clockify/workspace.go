@@ -0,0 +1,120 @@
+package clockify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrWorkspaceCreationUnavailable is returned by CreateWorkspace when the account is not
+// allowed to create workspaces
+var ErrWorkspaceCreationUnavailable = errors.New("workspace creation is not available for this account")
+
+// WorkspaceUpdate holds the workspace settings to change, nil fields are left as they are.
+// Clockify does not allow renaming a workspace through the API.
+type WorkspaceUpdate struct {
+	HourlyRate *Rate // Default billable rate
+	CostRate   *Rate // Default cost rate, requires FeatureLaborCost
+}
+
+// CreateWorkspace creates a workspace owned by the current user
+func (c *APIClient) CreateWorkspace(name string) (*Workspace, error) {
+	url := fmt.Sprintf("%s/workspaces", c.endpoints.API)
+
+	resp, err := c.post(url, map[string]any{"name": name})
+	if errors.Is(err, ErrUnauthorized) {
+		return nil, fmt.Errorf("%w: %w", ErrWorkspaceCreationUnavailable, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var workspace Workspace
+	if err := json.NewDecoder(resp.Body).Decode(&workspace); err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// UpdateWorkspace changes the settings of a workspace and returns it as updated. The
+// workspace plan must support each changed setting, otherwise ErrFeatureUnavailable is
+// returned.
+func (c *APIClient) UpdateWorkspace(workspaceID string, update WorkspaceUpdate) (*Workspace, error) {
+	var workspace *Workspace
+	rates := []struct {
+		path    string
+		rate    *Rate
+		feature Feature
+	}{
+		{"hourly-rate", update.HourlyRate, ""},
+		{"cost-rate", update.CostRate, FeatureLaborCost},
+	}
+
+	for _, r := range rates {
+		if r.rate == nil {
+			continue
+		}
+
+		url := fmt.Sprintf("%s/workspaces/%s/%s", c.endpoints.API, workspaceID, r.path)
+		resp, err := c.put(url, r.rate)
+		if err != nil && r.feature != "" {
+			err = c.featureError(workspaceID, r.feature, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", r.path, err)
+		}
+
+		workspace = &Workspace{}
+		err = json.NewDecoder(resp.Body).Decode(workspace)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if workspace == nil {
+		return c.GetWorkspace(workspaceID)
+	}
+	return workspace, nil
+}
+
+// GetWorkspace retrieves a workspace the current user is a member of
+func (c *APIClient) GetWorkspace(workspaceID string) (*Workspace, error) {
+	url := fmt.Sprintf("%s/workspaces/%s", c.endpoints.API, workspaceID)
+
+	resp, err := c.get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var workspace Workspace
+	if err := json.NewDecoder(resp.Body).Decode(&workspace); err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// CanCreateWorkspaces reports whether the current account may create workspaces.
+//
+// Clockify has no endpoint for this, so it is inferred from the user's workspaces: accounts
+// of an enterprise workspace with its own login subdomain are confined to it, every other
+// account may create workspaces on any plan.
+func (c *APIClient) CanCreateWorkspaces() (bool, error) {
+	workspaces, err := c.GetWorkspaces()
+	if err != nil {
+		return false, err
+	}
+
+	for _, ws := range workspaces {
+		if ws.Subdomain != nil && ws.Subdomain.Enabled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+)
+
+// billableRulesKey is the config file section reclassifying time entries as billable or not
+const billableRulesKey = "billable_rules"
+
+// BillableRule sets the billable flag of the time entries it matches. An entry matches when
+// it has every criterion set, the first rule matching an entry applies.
+//
+// Rules are only read from the config file:
+//
+//	billable_rules:
+//	  - project: Internal
+//	    billable: false
+//	  - tag: non-billable
+//	    billable: false
+//	  - project: Website
+//	    match: "(?i)^(meeting|standup)"
+//	    billable: false
+type BillableRule struct {
+	Project  string // Project name
+	Tag      string // Tag name
+	Match    string // Regular expression of the description
+	Billable bool
+}
+
+// decodeBillableRules reads the `billable_rules` section of the config file
+func decodeBillableRules(raw any) ([]BillableRule, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("billable_rules: must be a list of rules")
+	}
+
+	var errs []error
+	rules := make([]BillableRule, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("billable_rules[%d]: must be a mapping of settings", i))
+			continue
+		}
+
+		var rule BillableRule
+		if _, ok := values["billable"]; !ok {
+			errs = append(errs, fmt.Errorf("billable_rules[%d].billable: is required", i))
+		}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			value := values[key]
+			switch key {
+			case "project":
+				rule.Project = fmt.Sprint(value)
+			case "tag":
+				rule.Tag = fmt.Sprint(value)
+			case "match":
+				rule.Match = fmt.Sprint(value)
+			case "billable":
+				flag, ok := value.(bool)
+				if !ok {
+					errs = append(errs, fmt.Errorf("billable_rules[%d].billable: must be true or false", i))
+					continue
+				}
+				rule.Billable = flag
+			default:
+				errs = append(errs, fmt.Errorf("billable_rules[%d].%s: unknown key", i, key))
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, errors.Join(errs...)
+}
+
+// validateBillableRules checks every rule has a criterion and a valid description pattern
+func validateBillableRules(rules []BillableRule) error {
+	var errs []error
+	for i, rule := range rules {
+		if rule.Project == "" && rule.Tag == "" && rule.Match == "" {
+			errs = append(errs, fmt.Errorf("billable_rules[%d]: must set a project, tag or match", i))
+		}
+		if _, err := regexp.Compile(rule.Match); err != nil {
+			errs = append(errs, fmt.Errorf("billable_rules[%d].match: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// budgetsKey is the config file section listing the project hour caps
+const budgetsKey = "budgets"
+
+// Budget periods, the consumed hours are counted from the start of the current period
+const (
+	BudgetTotal   = "total"
+	BudgetMonthly = "monthly"
+	BudgetWeekly  = "weekly"
+)
+
+// Budget caps the hours tracked on a project. It overrides the project's Clockify estimate.
+//
+// Budgets are only read from the config file:
+//
+//	budgets:
+//	  - project: Website
+//	    limit: 40h
+//	  - project: Support
+//	    limit: 20h
+//	    period: monthly
+type Budget struct {
+	Project string // Project name
+	Limit   time.Duration
+	Period  string // total (default), monthly or weekly
+}
+
+// decodeBudgets reads the `budgets` section of the config file
+func decodeBudgets(raw any) ([]Budget, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("budgets: must be a list of budgets")
+	}
+
+	var errs []error
+	budgets := make([]Budget, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("budgets[%d]: must be a mapping of settings", i))
+			continue
+		}
+
+		budget := Budget{Period: BudgetTotal}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			value := values[key]
+			switch key {
+			case "project":
+				budget.Project = fmt.Sprint(value)
+			case "limit":
+				limit, err := time.ParseDuration(fmt.Sprint(value))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("budgets[%d].limit: %w", i, err))
+					continue
+				}
+				budget.Limit = limit
+			case "period":
+				budget.Period = fmt.Sprint(value)
+			default:
+				errs = append(errs, fmt.Errorf("budgets[%d].%s: unknown key", i, key))
+			}
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, errors.Join(errs...)
+}
+
+// validateBudgets checks every budget names a project, has a positive limit and a known period
+func validateBudgets(budgets []Budget) error {
+	var errs []error
+	for i, budget := range budgets {
+		if budget.Project == "" {
+			errs = append(errs, fmt.Errorf("budgets[%d].project: is required", i))
+		}
+		if budget.Limit <= 0 {
+			errs = append(errs, fmt.Errorf("budgets[%d].limit: must be positive", i))
+		}
+		switch budget.Period {
+		case BudgetTotal, BudgetMonthly, BudgetWeekly:
+		default:
+			errs = append(errs, fmt.Errorf("budgets[%d].period: must be total, monthly or weekly, got %q", i, budget.Period))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// capacitiesKey is the config file section of the weekly capacity of single users
+const capacitiesKey = "capacities"
+
+// Capacity sources
+const (
+	CapacitySourceConfig   = "config"
+	CapacitySourceClockify = "clockify"
+)
+
+// Capacity is the time a user is contracted for per week, overriding WEEKLY_CAPACITY and the
+// work capacity of their Clockify member profile.
+//
+// Capacities are only read from the config file, weekly being a duration or hours:
+//
+//	capacities:
+//	  - user: alice@example.com
+//	    weekly: 32h
+//	  - user: Bob
+//	    weekly: 20
+type Capacity struct {
+	User   string // User email or name
+	Weekly time.Duration
+}
+
+// decodeCapacities reads the `capacities` section of the config file
+func decodeCapacities(raw any) ([]Capacity, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("capacities: must be a list of capacities")
+	}
+
+	var errs []error
+	capacities := make([]Capacity, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("capacities[%d]: must be a mapping of settings", i))
+			continue
+		}
+
+		var capacity Capacity
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			value := values[key]
+			switch key {
+			case "user":
+				capacity.User = fmt.Sprint(value)
+			case "weekly":
+				weekly, err := parseWeekly(fmt.Sprint(value))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("capacities[%d].weekly: must be a duration like 32h or hours, got %v", i, value))
+					continue
+				}
+				capacity.Weekly = weekly
+			default:
+				errs = append(errs, fmt.Errorf("capacities[%d].%s: unknown key", i, key))
+			}
+		}
+		capacities = append(capacities, capacity)
+	}
+
+	return capacities, errors.Join(errs...)
+}
+
+// parseWeekly reads a duration, or a number of hours
+func parseWeekly(s string) (time.Duration, error) {
+	if hours, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(hours * float64(time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// validateCapacities checks every capacity names a user and is positive
+func validateCapacities(capacities []Capacity) error {
+	var errs []error
+	for i, capacity := range capacities {
+		if capacity.User == "" {
+			errs = append(errs, fmt.Errorf("capacities[%d].user: is required", i))
+		}
+		if capacity.Weekly <= 0 {
+			errs = append(errs, fmt.Errorf("capacities[%d].weekly: must be positive", i))
+		}
+	}
+	return errors.Join(errs...)
+}
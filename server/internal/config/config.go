@@ -1,15 +1,391 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
+
+	"github.com/Hukyl/CCWS/internal/scheduler"
 )
 
+// Config holds the settings shared by all commands.
+//
+// Every setting is read from its environment variable, from the config file (ccws.yaml or
+// ccws.toml, using the lower-cased variable name as the key) or from its default, in that order.
 type Config struct {
-	ClockifyAPIKey string `envconfig:"CLOCKIFY_API_KEY" required:"true"`
+	ClockifyAPIKey string `envconfig:"CLOCKIFY_API_KEY"`
+	// File containing the API key, used when CLOCKIFY_API_KEY is not set
+	ClockifyAPIKeyFile string `envconfig:"CLOCKIFY_API_KEY_FILE"`
+	// Read the API key from the OS keyring when it is neither set nor in a file
+	UseKeyring bool `envconfig:"CCWS_KEYRING"`
+	// Installation token of CCWS as a Clockify addon, sent as X-Addon-Token instead of the API
+	// key. Its claims set the region and the workspace.
+	ClockifyAddonToken string `envconfig:"CLOCKIFY_ADDON_TOKEN"`
+	// File containing the addon token, used when CLOCKIFY_ADDON_TOKEN is not set
+	ClockifyAddonTokenFile string `envconfig:"CLOCKIFY_ADDON_TOKEN_FILE"`
+	// PEM file of the key Clockify signs addon tokens with, the token is verified against it if set
+	ClockifyAddonPublicKeyFile string `envconfig:"CLOCKIFY_ADDON_PUBLIC_KEY_FILE"`
+	// Base API URL, e.g. https://euc1.clockify.me/api/v2 for the EU region. Empty means global.
+	ClockifyBaseURL string `envconfig:"CLOCKIFY_BASE_URL"`
+
+	// Workspace to operate on. The ID takes precedence over the name when both are set.
+	WorkspaceName string `envconfig:"CLOCKIFY_WORKSPACE_NAME"`
+	WorkspaceID   string `envconfig:"CLOCKIFY_WORKSPACE_ID"`
+
+	// Address the HTTP server listens on
+	ListenAddr string `envconfig:"LISTEN_ADDR" default:":8080"`
+	// Publicly reachable URL Clockify delivers webhooks to
+	PublicWebhookURL string `envconfig:"PUBLIC_WEBHOOK_URL"`
+	// Open an ngrok or cloudflared tunnel to LISTEN_ADDR and register the webhooks on its
+	// public URL, for local development. The path of PUBLIC_WEBHOOK_URL is kept if set.
+	Tunnel string `envconfig:"TUNNEL"`
+	// Identifies this deployment in the names of the webhooks it creates, so that instances
+	// sharing a workspace only delete their own leftovers. Defaults to the host name, which
+	// containers should override with something stable.
+	InstanceID string `envconfig:"INSTANCE_ID"`
+	// Redis the replicas of the server behind a load balancer share their webhook dedupe, ETag
+	// cache and rate limits through, e.g. redis://:password@redis:6379/0. Empty keeps them in
+	// memory. REDIS_PREFIX namespaces the keys, for deployments sharing a Redis.
+	RedisURL    string `envconfig:"REDIS_URL"`
+	RedisPrefix string `envconfig:"REDIS_PREFIX" default:"ccws:"`
+	// How often the webhook auth tokens are regenerated, 0 never rotates them
+	WebhookTokenRotation time.Duration `envconfig:"WEBHOOK_TOKEN_ROTATION" default:"0"`
+	// How long the previous token of a webhook is still accepted after a rotation
+	WebhookTokenGrace time.Duration `envconfig:"WEBHOOK_TOKEN_GRACE" default:"10m"`
+	// Deliver timer events only for these projects, users or tags: WORKSPACE_ID, PROJECT_ID,
+	// USER_ID or TAG_ID with the comma-separated IDs in WEBHOOK_TRIGGER_IDS
+	WebhookTrigger    string   `envconfig:"WEBHOOK_TRIGGER" default:"WORKSPACE_ID"`
+	WebhookTriggerIDs []string `envconfig:"WEBHOOK_TRIGGER_IDS"`
+	// Comma-separated events to create webhooks for instead of the default new timer, stopped
+	// timer, new project, new client and new tag events, e.g. NEW_TIME_ENTRY,TIME_ENTRY_UPDATED
+	WebhookEvents []string `envconfig:"WEBHOOK_EVENTS"`
+	// How often the events left without a webhook by Clockify's limit of 10 per workspace are
+	// polled for through the API instead. 0 fails the start when the limit is reached.
+	WebhookPollInterval time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" default:"0"`
+	// Requests per second accepted on the webhook endpoint from a single IP, with bursts of as
+	// many, the excess is answered 429. 0 disables the limit.
+	WebhookRateLimit int `envconfig:"WEBHOOK_RATE_LIMIT" default:"20"`
+	// Rate limit by the last X-Forwarded-For address, for a reverse proxy in front of the
+	// server. Always on with TUNNEL.
+	WebhookTrustProxy bool `envconfig:"WEBHOOK_TRUST_PROXY" default:"false"`
+	// Secret appended to the webhook URL as its last path segment, requests without it are
+	// answered 404 before their signature is checked
+	WebhookPathToken string `envconfig:"WEBHOOK_PATH_TOKEN"`
+	// /healthz reports degraded when no webhook event arrived for this long, 0 skips the check
+	HealthEventWindow time.Duration `envconfig:"HEALTH_EVENT_WINDOW" default:"24h"`
+	// Whether /metrics serves Prometheus metrics, those of the tracking activity read from
+	// Clockify every METRICS_REFRESH_INTERVAL. METRICS_TOKEN, when set, is required as a bearer
+	// token. Without METRICS_ALL_USERS only the time of the configured user is measured.
+	MetricsEnabled         bool          `envconfig:"METRICS_ENABLED" default:"false"`
+	MetricsToken           string        `envconfig:"METRICS_TOKEN"`
+	MetricsRefreshInterval time.Duration `envconfig:"METRICS_REFRESH_INTERVAL" default:"5m"`
+	MetricsAllUsers        bool          `envconfig:"METRICS_ALL_USERS" default:"false"`
+	// Bearer token of an operator of the HTTP API under /api/v1. The API is disabled without
+	// it and without api_users.
+	APIToken string `envconfig:"API_TOKEN"`
+	// Accounts of the API with their own tokens and roles, from the `api_users` section of the config file
+	APIUsers []APIUser `ignored:"true"`
+	// How long the API reuses time entries and projects fetched from Clockify
+	APICacheTTL time.Duration `envconfig:"API_CACHE_TTL" default:"1m"`
+	// Address the gRPC API listens on, e.g. :9090. Empty disables it, it requires API_TOKEN or api_users.
+	GRPCListenAddr string `envconfig:"GRPC_LISTEN_ADDR"`
+
+	LogLevel  string `envconfig:"LOG_LEVEL" default:"info"`  // debug, info, warn or error
+	LogFormat string `envconfig:"LOG_FORMAT" default:"text"` // text or json
+
+	// Outgoing Clockify requests per second, 0 disables the limit
+	ClockifyRateLimit int `envconfig:"CLOCKIFY_RATE_LIMIT" default:"50"`
+	// Total attempts for retryable Clockify failures, 1 disables retries
+	ClockifyRetryAttempts int `envconfig:"CLOCKIFY_RETRY_ATTEMPTS" default:"3"`
+	// Idle connections to Clockify kept for reuse, raise for heavily parallel workloads
+	ClockifyMaxIdleConns int `envconfig:"CLOCKIFY_MAX_IDLE_CONNS" default:"16"`
+	// How long an idle connection is kept, 0 keeps them open
+	ClockifyIdleConnTimeout time.Duration `envconfig:"CLOCKIFY_IDLE_CONN_TIMEOUT" default:"90s"`
+	// Consecutive failed Clockify calls that open the circuit breaker, 0 disables it. While
+	// open, calls fail without reaching Clockify until a probe after the cooldown succeeds.
+	ClockifyBreakerThreshold int           `envconfig:"CLOCKIFY_BREAKER_THRESHOLD" default:"5"`
+	ClockifyBreakerCooldown  time.Duration `envconfig:"CLOCKIFY_BREAKER_COOLDOWN" default:"30s"`
+	// Project, tag and client listings revalidated with their ETag instead of re-fetched, 0 disables it
+	ClockifyETagCacheSize int `envconfig:"CLOCKIFY_ETAG_CACHE_SIZE" default:"256"`
+	// API versions of the experimental endpoints, comma-separated endpoint=version pairs, e.g.
+	// custom-fields=v2, for when Clockify moves an endpoint before CCWS follows
+	ClockifyEndpointVersions []string `envconfig:"CLOCKIFY_ENDPOINT_VERSIONS"`
+
+	// SQLite database mirroring the workspace (a path or a "file:" URI), empty disables the
+	// mirror. The server syncs it and serves the API from it, `ccws sync` fills it for offline
+	// reports.
+	DatabaseDSN string `envconfig:"DATABASE_DSN"`
+	// How often the server syncs the mirror in between webhooks
+	MirrorSyncInterval time.Duration `envconfig:"MIRROR_SYNC_INTERVAL" default:"1h"`
+	// How far before the last sync entries are fetched again, catching missed webhooks
+	MirrorWindow time.Duration `envconfig:"MIRROR_WINDOW" default:"168h"`
+	// Where the server gets its events from: "webhooks" registered with Clockify, or "polling"
+	// the mirror, diffing it on every sync, for hosts Clockify cannot deliver webhooks to.
+	// Polling requires DATABASE_DSN and usually a shorter MIRROR_SYNC_INTERVAL.
+	EventSource string `envconfig:"EVENT_SOURCE" default:"webhooks"`
+	// Whether the CLI queues timer and entry writes while Clockify is unreachable, replaying
+	// them once it is back
+	OfflineQueue bool `envconfig:"OFFLINE_QUEUE" default:"true"`
+
+	// SQLite database recording every write made to Clockify by the server and the CLI, empty
+	// disables the audit log
+	AuditDSN string `envconfig:"AUDIT_DSN"`
+	// How long the server keeps audit log entries, 0 keeps them forever
+	AuditRetention time.Duration `envconfig:"AUDIT_RETENTION" default:"0"`
+	// SQLite database keeping the events a server handler failed on, for retrying them through
+	// the API, empty only logs the failures
+	DeadLetterDSN string `envconfig:"DEAD_LETTER_DSN"`
+
+	ClockifyTimeout     time.Duration `envconfig:"CLOCKIFY_TIMEOUT" default:"30s"`
+	ServerReadTimeout   time.Duration `envconfig:"SERVER_READ_TIMEOUT" default:"10s"`
+	ServerWriteTimeout  time.Duration `envconfig:"SERVER_WRITE_TIMEOUT" default:"10s"`
+	ServerShutdownGrace time.Duration `envconfig:"SERVER_SHUTDOWN_GRACE" default:"10s"`
+	// Bounds of Clockify calls by kind, retries included, 0 falls back to CLOCKIFY_TIMEOUT:
+	// writes, single reads, and pages of listings and reports
+	ClockifyWriteTimeout time.Duration `envconfig:"CLOCKIFY_WRITE_TIMEOUT" default:"10s"`
+	ClockifyReadTimeout  time.Duration `envconfig:"CLOCKIFY_READ_TIMEOUT" default:"0"`
+	ClockifyListTimeout  time.Duration `envconfig:"CLOCKIFY_LIST_TIMEOUT" default:"2m"`
+	// Budget of a whole migration started through the API, after which it stops where it is.
+	// 0 lets it run as long as it takes.
+	MigrationDeadline time.Duration `envconfig:"MIGRATION_DEADLINE" default:"2h"`
+
+	// Slack incoming webhook URL notifications are posted to, empty disables Slack
+	SlackWebhookURL string `envconfig:"SLACK_WEBHOOK_URL"`
+	// Telegram bot posting notifications to the chat, both are required to enable Telegram
+	TelegramBotToken string `envconfig:"TELEGRAM_BOT_TOKEN"`
+	TelegramChatID   string `envconfig:"TELEGRAM_CHAT_ID"`
+	// Answer /start_timer, /stop and /today sent to the bot from the chat
+	TelegramBotCommands bool `envconfig:"TELEGRAM_BOT_COMMANDS" default:"true"`
+	// SMTP server digests are sent through, host:port. Without a username it is used unauthenticated.
+	SMTPAddr     string `envconfig:"SMTP_ADDR"`
+	SMTPUsername string `envconfig:"SMTP_USERNAME"`
+	SMTPPassword string `envconfig:"SMTP_PASSWORD"`
+	SMTPFrom     string `envconfig:"SMTP_FROM"`
+	// Recipients of the digest email, comma-separated. Empty disables the digest.
+	DigestTo        []string `envconfig:"DIGEST_TO"`
+	DigestFrequency string   `envconfig:"DIGEST_FREQUENCY" default:"daily"` // daily or weekly (sent on Mondays)
+	DigestTime      string   `envconfig:"DIGEST_TIME" default:"08:00"`      // Local time the digest is sent at, HH:MM
+
+	// Report timers running longer than this, 0 disables the watchdog
+	WatchdogThreshold time.Duration `envconfig:"WATCHDOG_THRESHOLD" default:"10h"`
+	// Stop timers running longer than this, 0 never stops them
+	WatchdogStopAfter time.Duration `envconfig:"WATCHDOG_STOP_AFTER" default:"0"`
+	// Report break timers running longer than this instead, 0 never reports breaks
+	WatchdogBreakThreshold time.Duration `envconfig:"WATCHDOG_BREAK_THRESHOLD" default:"0"`
+	// How often running timers are checked
+	WatchdogInterval time.Duration `envconfig:"WATCHDOG_INTERVAL" default:"15m"`
+	// Check the timers of every workspace user (requires admin rights), not only your own
+	WatchdogAllUsers bool `envconfig:"WATCHDOG_ALL_USERS"`
+
+	// Time users are expected to log, 0 disables the reminder for the period
+	ReminderDailyQuota  time.Duration `envconfig:"REMINDER_DAILY_QUOTA" default:"0"`
+	ReminderWeeklyQuota time.Duration `envconfig:"REMINDER_WEEKLY_QUOTA" default:"0"` // Checked on Fridays
+	// Local time of each user the quota is checked at, HH:MM
+	ReminderTime string `envconfig:"REMINDER_TIME" default:"17:00"`
+	// Remind every workspace user (requires admin rights), not only yourself
+	ReminderAllUsers bool `envconfig:"REMINDER_ALL_USERS"`
+	// Remind users who logged less than their weekly capacity on Fridays, instead of checking
+	// REMINDER_WEEKLY_QUOTA
+	ReminderCapacity bool `envconfig:"REMINDER_CAPACITY"`
+
+	// Submit your week for approval on Fridays at TIMESHEET_SUBMIT_TIME, unless it has running
+	// timers, overlapping entries or gaps longer than TIMESHEET_MAX_GAP (0 allows any gap)
+	TimesheetSubmit     bool          `envconfig:"TIMESHEET_SUBMIT"`
+	TimesheetSubmitTime string        `envconfig:"TIMESHEET_SUBMIT_TIME" default:"18:00"` // Local time, HH:MM
+	TimesheetMaxGap     time.Duration `envconfig:"TIMESHEET_MAX_GAP" default:"1h"`
+
+	// Cron expressions overriding when jobs run, e.g. "0 8 * * mon-fri" or "@every 30m".
+	// Empty keeps the schedule derived from DIGEST_FREQUENCY/DIGEST_TIME, WATCHDOG_INTERVAL,
+	// the reminder's 5-minute check and TIMESHEET_SUBMIT_TIME.
+	DigestSchedule    string `envconfig:"DIGEST_SCHEDULE"`
+	WatchdogSchedule  string `envconfig:"WATCHDOG_SCHEDULE"`
+	ReminderSchedule  string `envconfig:"REMINDER_SCHEDULE"`
+	TimesheetSchedule string `envconfig:"TIMESHEET_SCHEDULE"`
+	// Random delay up to this added to every job run, spreads out API calls of several instances
+	SchedulerJitter time.Duration `envconfig:"SCHEDULER_JITTER" default:"0"`
+	// Whether replicas sharing REDIS_URL elect a leader, the only one running the jobs, so that
+	// digests and reminders are not sent once per replica. The leader holds a lease of
+	// LEADER_LEASE_TTL, another replica takes over within it when the leader goes away.
+	LeaderElection bool          `envconfig:"LEADER_ELECTION"`
+	LeaderLeaseTTL time.Duration `envconfig:"LEADER_LEASE_TTL" default:"30s"`
+
+	// Events notifications are sent for, comma-separated webhook event names
+	NotifyEvents []string `envconfig:"NOTIFY_EVENTS" default:"NEW_TIMER_STARTED,TIMER_STOPPED,NEW_PROJECT,LONG_RUNNING_TIMER,BUDGET_THRESHOLD_REACHED"`
+
+	// Alert when a project has consumed these percentages of its hours, comma-separated
+	BudgetThresholds []string `envconfig:"BUDGET_THRESHOLDS" default:"80,100"`
+	// Use the time estimates set on projects in Clockify as budgets
+	BudgetEstimates bool `envconfig:"BUDGET_ESTIMATES" default:"true"`
+	// How often budgets are checked besides on time entry events, 0 only checks on events
+	BudgetInterval time.Duration `envconfig:"BUDGET_INTERVAL" default:"1h"`
+	// Count the hours of every workspace user (requires admin rights), not only your own
+	BudgetAllUsers bool `envconfig:"BUDGET_ALL_USERS"`
+	// Project hour caps from the `budgets` section of the config file
+	Budgets []Budget `ignored:"true"`
+
+	// Rules setting the billable flag of time entries, from the `billable_rules` section of the
+	// config file. The server applies them to the entries tracked, `ccws cleanup --billable-rules`
+	// to past ones.
+	BillableRules []BillableRule `ignored:"true"`
+
+	// Time each user is expected to be available per week, utilization is billable time against it
+	WeeklyCapacity time.Duration `envconfig:"WEEKLY_CAPACITY" default:"40h"`
+	// Where the capacity of users comes from besides the `capacities` section of the config
+	// file: config (WEEKLY_CAPACITY) or clockify (the work capacity of their member profiles)
+	CapacitySource string `envconfig:"CAPACITY_SOURCE" default:"config"`
+	// Weekly capacity of single users from the `capacities` section of the config file
+	Capacities []Capacity `ignored:"true"`
+
+	// Increment tracked time is rounded to in reports and invoices, e.g. 6m, 15m or 30m. 0
+	// reports the time as tracked. The entries in Clockify are left as they are.
+	RoundingIncrement time.Duration `envconfig:"ROUNDING_INCREMENT" default:"0"`
+	// Direction of the rounding: nearest, up or down
+	RoundingMode string `envconfig:"ROUNDING_MODE" default:"nearest"`
+	// What is rounded: every entry, or the time of a user on a project in a day
+	RoundingScope string `envconfig:"ROUNDING_SCOPE" default:"entry"`
+
+	// Hourly rates from the `rates` section of the config file, overriding the Clockify rates
+	Rates []Rate `ignored:"true"`
+	// Currency of invoices, the workspace's default currency when empty. Clockify rates in
+	// other currencies are converted by the FX_RATES_URL exchange rates.
+	InvoiceCurrency string `envconfig:"INVOICE_CURRENCY"`
+	// Percentages applied to invoices, the discount before the tax
+	InvoiceDiscount float64 `envconfig:"INVOICE_DISCOUNT" default:"0"`
+	InvoiceTax      float64 `envconfig:"INVOICE_TAX" default:"0"`
+	// Name printed as the issuer of invoices
+	InvoiceIssuer string `envconfig:"INVOICE_ISSUER"`
+	// Days after the issue date invoices are due
+	InvoiceDueDays int `envconfig:"INVOICE_DUE_DAYS" default:"30"`
+	// JSON API of exchange rates against a base currency, e.g.
+	// https://api.frankfurter.app/latest?from=EUR. Empty disables currency conversion.
+	FXRatesURL string `envconfig:"FX_RATES_URL"`
+	// How long fetched exchange rates are used before being fetched again
+	FXRatesTTL time.Duration `envconfig:"FX_RATES_TTL" default:"24h"`
+
+	// Personal access token `ccws suggest github` reads commits and reviews with
+	GitHubToken string `envconfig:"GITHUB_TOKEN"`
+	// Login whose activity is read, the token's owner by default
+	GitHubUser string `envconfig:"GITHUB_USER"`
+	// REST API of GitHub Enterprise, e.g. https://github.example.com/api/v3. Empty means github.com.
+	GitHubAPIURL string `envconfig:"GITHUB_API_URL"`
+	// Time credited before the first commit of a session, and the gap between commits ending one
+	GitHubCommitLead time.Duration `envconfig:"GITHUB_COMMIT_LEAD" default:"30m"`
+	GitHubSessionGap time.Duration `envconfig:"GITHUB_SESSION_GAP" default:"2h"`
+	// Repository to project names from the `github_projects` section of the config file
+	GitHubProjects map[string]string `ignored:"true"`
+
+	// Issue trackers references in descriptions are resolved with, each is enabled by its token.
+	// GITLAB_PROJECT is the path bare "#12" references belong to.
+	GitLabToken   string `envconfig:"GITLAB_TOKEN"`
+	GitLabURL     string `envconfig:"GITLAB_URL"`
+	GitLabProject string `envconfig:"GITLAB_PROJECT"`
+	LinearAPIKey  string `envconfig:"LINEAR_API_KEY"`
+	// Linear team keys, e.g. ENG. Empty looks up anything shaped like ENG-42.
+	LinearTeams []string `envconfig:"LINEAR_TEAMS"`
+	// Tag tracked entries with the workspace tags named like the labels of their issues
+	IssueLabelTags bool `envconfig:"ISSUE_LABEL_TAGS" default:"false"`
+
+	// Downstream webhooks events are forwarded to, from the `forward` section of the config file
+	ForwardTargets []ForwardTarget `ignored:"true"`
+	// Message broker events are published to, nats or kafka, empty disables publishing.
+	// PUBLISH_URL is the NATS server, e.g. nats://localhost:4222, or the Kafka REST proxy.
+	PublishBackend string `envconfig:"PUBLISH_BACKEND"`
+	PublishURL     string `envconfig:"PUBLISH_URL"`
+	// Subject or topic of the events, {type} is replaced by the event type, e.g. ccws.{type}
+	PublishTopic string `envconfig:"PUBLISH_TOPIC" default:"ccws.events"`
+	// json for the event envelope, protobuf for the ccws.v1.Event message
+	PublishEncoding string `envconfig:"PUBLISH_ENCODING" default:"json"`
+	// Event types published, all when empty
+	PublishEvents []string `envconfig:"PUBLISH_EVENTS"`
+	// Directory the events are exported to as a Parquet dataset, empty disables the export.
+	// Time entries are exported by ccws export.
+	ExportDir           string        `envconfig:"EXPORT_DIR"`
+	ExportFlushInterval time.Duration `envconfig:"EXPORT_FLUSH_INTERVAL" default:"5m"`
+	// Google Cloud project and dataset the time entries and projects are synced to, empty
+	// disables the sync. BIGQUERY_CREDENTIALS is a service account key file, the instance's
+	// service account is used without one.
+	BigQueryProject     string        `envconfig:"BIGQUERY_PROJECT"`
+	BigQueryDataset     string        `envconfig:"BIGQUERY_DATASET"`
+	BigQueryCredentials string        `envconfig:"BIGQUERY_CREDENTIALS"`
+	BigQueryInterval    time.Duration `envconfig:"BIGQUERY_SYNC_INTERVAL" default:"1h"`
+	// How far back each sync reaches, 0 syncing the whole history every time
+	BigQueryWindow time.Duration `envconfig:"BIGQUERY_SYNC_WINDOW" default:"168h"`
+	// Bucket every raw webhook payload is archived to, s3://bucket/prefix or gs://bucket/prefix,
+	// empty disables the archive. Google Cloud Storage takes HMAC keys through its XML API.
+	// ARCHIVE_ENDPOINT overrides the endpoint of the scheme, e.g. for MinIO, and ARCHIVE_REGION
+	// defaults to us-east-1 for S3 and auto for GCS.
+	ArchiveURL             string        `envconfig:"ARCHIVE_URL"`
+	ArchiveEndpoint        string        `envconfig:"ARCHIVE_ENDPOINT"`
+	ArchiveRegion          string        `envconfig:"ARCHIVE_REGION"`
+	ArchiveAccessKeyID     string        `envconfig:"ARCHIVE_ACCESS_KEY_ID"`
+	ArchiveSecretAccessKey string        `envconfig:"ARCHIVE_SECRET_ACCESS_KEY"`
+	ArchiveFlushInterval   time.Duration `envconfig:"ARCHIVE_FLUSH_INTERVAL" default:"1m"`
+
+	// Project used by commands when none is given explicitly. Timers started without a
+	// project also track the default task of the project and the default tags.
+	DefaultProject string   `envconfig:"CLOCKIFY_DEFAULT_PROJECT"`
+	DefaultTask    string   `envconfig:"CLOCKIFY_DEFAULT_TASK"`
+	DefaultTags    []string `envconfig:"CLOCKIFY_DEFAULT_TAGS"`
+	// Whether started timers are billable
+	DefaultBillable bool `envconfig:"CLOCKIFY_DEFAULT_BILLABLE" default:"true"`
+	// Whether the projects created, e.g. by migrations, are billable and public
+	ProjectBillable bool `envconfig:"CLOCKIFY_PROJECT_BILLABLE" default:"true"`
+	ProjectPublic   bool `envconfig:"CLOCKIFY_PROJECT_PUBLIC"`
+	// Increment the past entries logged, e.g. by `ccws log`, are rounded up to, e.g. 15m. 0
+	// logs them as given.
+	BillingIncrement time.Duration `envconfig:"BILLING_INCREMENT" default:"0"`
+	// Billable entries logged shorter than it are warned of, 0 never warns
+	BillingMinimum time.Duration `envconfig:"BILLING_MINIMUM" default:"0"`
+	// Names of the projects the increment and minimum apply to, every project when empty, and
+	// of the projects they never apply to
+	BillingProjects       []string `envconfig:"BILLING_PROJECTS"`
+	BillingExemptProjects []string `envconfig:"BILLING_EXEMPT_PROJECTS"`
+	// Finds the {ticket} of the timer descriptions the CLI starts in the git branch, unless
+	// CCWS_TICKET is set
+	TicketPattern string `envconfig:"TICKET_PATTERN" default:"[A-Z][A-Z0-9]+-[0-9]+"`
+	// Shell command `ccws pomo` shows notifications with, the title and message are in
+	// CCWS_POMO_TITLE and CCWS_POMO_MESSAGE. Empty uses notify-send or osascript.
+	PomoNotifyCommand string `envconfig:"POMO_NOTIFY_COMMAND"`
+	// What `ccws hook` does on checkouts and commits, from the `git_hooks` section of the config file
+	GitHooks []GitHook `ignored:"true"`
+
+	// Profile selected on load, its settings override the top-level ones
+	Profile string `envconfig:"CCWS_PROFILE"`
+	// Profiles defined in the `profiles` section of the config file
+	Profiles map[string]Profile `ignored:"true"`
 }
 
+// Load reads the configuration from the environment, the .env file and the config file.
+//
+// The config file is taken from CCWS_CONFIG or looked up in the working directory.
 func Load() (*Config, error) {
+	return LoadFrom(findConfigFile())
+}
+
+// LoadProfile is Load with the named profile selected, e.g. by a --profile flag, in place of
+// the one of CCWS_PROFILE. Empty keeps CCWS_PROFILE.
+func LoadProfile(name string) (*Config, error) {
+	return load(findConfigFile(), name)
+}
+
+// LoadFrom is Load with an explicit config file path. Empty path means no config file.
+func LoadFrom(path string) (*Config, error) {
+	return load(path, "")
+}
+
+// load reads the configuration with the profile selected before its secrets are resolved and
+// it is validated, so that the key of the profile is the one read and checked
+func load(path, profile string) (*Config, error) {
 	// Load .env file if it exists (ignore error if file doesn't exist)
 	godotenv.Load()
 
@@ -17,5 +393,538 @@ func Load() (*Config, error) {
 	if err := envconfig.Process("", &cfg); err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+
+	var errs []error
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if rawProfiles, ok := values[profilesKey]; ok {
+			delete(values, profilesKey)
+			cfg.Profiles, err = decodeProfiles(rawProfiles)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawTargets, ok := values[forwardKey]; ok {
+			delete(values, forwardKey)
+			cfg.ForwardTargets, err = decodeForwardTargets(rawTargets)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawUsers, ok := values[apiUsersKey]; ok {
+			delete(values, apiUsersKey)
+			cfg.APIUsers, err = decodeAPIUsers(rawUsers)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawBudgets, ok := values[budgetsKey]; ok {
+			delete(values, budgetsKey)
+			cfg.Budgets, err = decodeBudgets(rawBudgets)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawRules, ok := values[billableRulesKey]; ok {
+			delete(values, billableRulesKey)
+			cfg.BillableRules, err = decodeBillableRules(rawRules)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawProjects, ok := values[githubProjectsKey]; ok {
+			delete(values, githubProjectsKey)
+			cfg.GitHubProjects, err = decodeGitHubProjects(rawProjects)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawHooks, ok := values[gitHooksKey]; ok {
+			delete(values, gitHooksKey)
+			cfg.GitHooks, err = decodeGitHooks(rawHooks)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawRates, ok := values[ratesKey]; ok {
+			delete(values, ratesKey)
+			cfg.Rates, err = decodeRates(rawRates)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if rawCapacities, ok := values[capacitiesKey]; ok {
+			delete(values, capacitiesKey)
+			cfg.Capacities, err = decodeCapacities(rawCapacities)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if err := applyFileValues(&cfg, values); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if profile != "" {
+		cfg.Profile = profile
+	}
+
+	result := &cfg
+	if cfg.Profile != "" {
+		profiled, err := cfg.WithProfile(cfg.Profile)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			result = profiled
+		}
+	}
+
+	if err := result.resolveSecrets(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(append(errs, result.Validate())...); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return result, nil
+}
+
+// Validate reports every missing or invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.ClockifyAPIKey == "" && c.ClockifyAddonToken == "" {
+		errs = append(errs, errors.New("CLOCKIFY_API_KEY: is required (or CLOCKIFY_API_KEY_FILE, CCWS_KEYRING or CLOCKIFY_ADDON_TOKEN)"))
+	}
+	if c.ClockifyBaseURL != "" {
+		if err := validateHTTPURL(c.ClockifyBaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("CLOCKIFY_BASE_URL: %w", err))
+		}
+	}
+	if c.DefaultTask != "" && c.DefaultProject == "" {
+		errs = append(errs, errors.New("CLOCKIFY_DEFAULT_TASK: requires CLOCKIFY_DEFAULT_PROJECT"))
+	}
+	if c.PublicWebhookURL != "" {
+		if err := validateHTTPURL(c.PublicWebhookURL); err != nil {
+			errs = append(errs, fmt.Errorf("PUBLIC_WEBHOOK_URL: %w", err))
+		}
+	}
+	if c.SlackWebhookURL != "" {
+		if err := validateHTTPURL(c.SlackWebhookURL); err != nil {
+			errs = append(errs, fmt.Errorf("SLACK_WEBHOOK_URL: %w", err))
+		}
+	}
+	if (c.TelegramBotToken == "") != (c.TelegramChatID == "") {
+		errs = append(errs, errors.New("TELEGRAM_BOT_TOKEN, TELEGRAM_CHAT_ID: must be set together"))
+	}
+	if len(c.DigestTo) > 0 && (c.SMTPAddr == "" || c.SMTPFrom == "") {
+		errs = append(errs, errors.New("SMTP_ADDR, SMTP_FROM: are required to send the digest to DIGEST_TO"))
+	}
+	if c.DigestFrequency != "daily" && c.DigestFrequency != "weekly" {
+		errs = append(errs, fmt.Errorf("DIGEST_FREQUENCY: must be daily or weekly, got %q", c.DigestFrequency))
+	}
+	if _, _, err := c.DigestClock(); err != nil {
+		errs = append(errs, fmt.Errorf("DIGEST_TIME: %w", err))
+	}
+	if c.ReminderDailyQuota < 0 || c.ReminderWeeklyQuota < 0 {
+		errs = append(errs, errors.New("REMINDER_DAILY_QUOTA, REMINDER_WEEKLY_QUOTA: must not be negative"))
+	}
+	if c.WeeklyCapacity <= 0 {
+		errs = append(errs, errors.New("WEEKLY_CAPACITY: must be positive"))
+	}
+	if c.CapacitySource != CapacitySourceConfig && c.CapacitySource != CapacitySourceClockify {
+		errs = append(errs, fmt.Errorf("CAPACITY_SOURCE: must be config or clockify, got %q", c.CapacitySource))
+	}
+	if c.BillingIncrement < 0 || c.BillingMinimum < 0 {
+		errs = append(errs, errors.New("BILLING_INCREMENT, BILLING_MINIMUM: must not be negative"))
+	}
+	if c.RoundingIncrement < 0 {
+		errs = append(errs, errors.New("ROUNDING_INCREMENT: must not be negative"))
+	}
+	if c.RoundingMode != "nearest" && c.RoundingMode != "up" && c.RoundingMode != "down" {
+		errs = append(errs, fmt.Errorf("ROUNDING_MODE: must be nearest, up or down, got %q", c.RoundingMode))
+	}
+	if c.RoundingScope != "entry" && c.RoundingScope != "day" {
+		errs = append(errs, fmt.Errorf("ROUNDING_SCOPE: must be entry or day, got %q", c.RoundingScope))
+	}
+	if _, _, err := c.TimesheetClock(); err != nil {
+		errs = append(errs, fmt.Errorf("TIMESHEET_SUBMIT_TIME: %w", err))
+	}
+	if c.TimesheetMaxGap < 0 {
+		errs = append(errs, errors.New("TIMESHEET_MAX_GAP: must not be negative"))
+	}
+	if _, _, err := c.ReminderClock(); err != nil {
+		errs = append(errs, fmt.Errorf("REMINDER_TIME: %w", err))
+	}
+	if c.WatchdogThreshold < 0 || c.WatchdogStopAfter < 0 || c.WatchdogBreakThreshold < 0 {
+		errs = append(errs, errors.New("WATCHDOG_THRESHOLD, WATCHDOG_STOP_AFTER, WATCHDOG_BREAK_THRESHOLD: must not be negative"))
+	}
+	if c.Tunnel != "" && c.Tunnel != "ngrok" && c.Tunnel != "cloudflared" {
+		errs = append(errs, fmt.Errorf("TUNNEL: must be ngrok or cloudflared, got %q", c.Tunnel))
+	}
+	switch c.WebhookTrigger {
+	case "WORKSPACE_ID":
+	case "PROJECT_ID", "USER_ID", "TAG_ID":
+		if len(c.WebhookTriggerIDs) == 0 {
+			errs = append(errs, fmt.Errorf("WEBHOOK_TRIGGER_IDS: required with WEBHOOK_TRIGGER=%s", c.WebhookTrigger))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("WEBHOOK_TRIGGER: must be WORKSPACE_ID, PROJECT_ID, USER_ID or TAG_ID, got %q", c.WebhookTrigger))
+	}
+	if c.WebhookRateLimit < 0 {
+		errs = append(errs, errors.New("WEBHOOK_RATE_LIMIT: must not be negative"))
+	}
+	if c.WebhookPathToken != "" {
+		if len(c.WebhookPathToken) < 16 {
+			errs = append(errs, errors.New("WEBHOOK_PATH_TOKEN: must be at least 16 characters long"))
+		}
+		if strings.ContainsFunc(c.WebhookPathToken, func(r rune) bool { return !isUnreserved(r) }) {
+			errs = append(errs, errors.New("WEBHOOK_PATH_TOKEN: must contain only letters, digits and -._~"))
+		}
+	}
+	// Clockify allows 10 webhooks per workspace, one per event, the rest can only be polled
+	if len(c.WebhookEvents) > 10 && c.WebhookPollInterval == 0 {
+		errs = append(errs, fmt.Errorf("WEBHOOK_EVENTS: at most 10 events without WEBHOOK_POLL_INTERVAL, Clockify's limit of webhooks per workspace, got %d", len(c.WebhookEvents)))
+	}
+	if slices.Contains(c.WebhookEvents, "") {
+		errs = append(errs, errors.New("WEBHOOK_EVENTS: must not contain empty event names"))
+	}
+	if c.WebhookTokenRotation < 0 || c.WebhookTokenGrace < 0 || c.WebhookPollInterval < 0 || c.HealthEventWindow < 0 {
+		errs = append(errs, errors.New("WEBHOOK_TOKEN_ROTATION, WEBHOOK_TOKEN_GRACE, WEBHOOK_POLL_INTERVAL, HEALTH_EVENT_WINDOW: must not be negative"))
+	}
+	if c.WatchdogInterval <= 0 {
+		errs = append(errs, errors.New("WATCHDOG_INTERVAL: must be positive"))
+	}
+	schedules := []struct {
+		name  string
+		value string
+	}{
+		{"DIGEST_SCHEDULE", c.DigestSchedule},
+		{"WATCHDOG_SCHEDULE", c.WatchdogSchedule},
+		{"REMINDER_SCHEDULE", c.ReminderSchedule},
+		{"TIMESHEET_SCHEDULE", c.TimesheetSchedule},
+	}
+	for _, schedule := range schedules {
+		if schedule.value == "" {
+			continue
+		}
+		if _, err := scheduler.ParseCron(schedule.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", schedule.name, err))
+		}
+	}
+	if c.SchedulerJitter < 0 {
+		errs = append(errs, errors.New("SCHEDULER_JITTER: must not be negative"))
+	}
+	if c.IssueLabelTags && c.GitLabToken == "" && c.LinearAPIKey == "" {
+		errs = append(errs, errors.New("ISSUE_LABEL_TAGS: requires GITLAB_TOKEN or LINEAR_API_KEY"))
+	}
+	if c.LeaderElection && c.RedisURL == "" {
+		errs = append(errs, errors.New("LEADER_ELECTION: requires REDIS_URL"))
+	}
+	if c.LeaderLeaseTTL < 3*time.Second {
+		errs = append(errs, errors.New("LEADER_LEASE_TTL: must be at least 3s"))
+	}
+	if _, err := c.BudgetPercentages(); err != nil {
+		errs = append(errs, fmt.Errorf("BUDGET_THRESHOLDS: %w", err))
+	}
+	if c.MirrorSyncInterval <= 0 {
+		errs = append(errs, errors.New("MIRROR_SYNC_INTERVAL: must be positive"))
+	}
+	if c.MirrorWindow < 0 {
+		errs = append(errs, errors.New("MIRROR_WINDOW: must not be negative"))
+	}
+	switch c.EventSource {
+	case "webhooks":
+	case "polling":
+		if c.DatabaseDSN == "" {
+			errs = append(errs, errors.New("EVENT_SOURCE: polling requires DATABASE_DSN, the mirror it diffs"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("EVENT_SOURCE: must be webhooks or polling, got %q", c.EventSource))
+	}
+	if c.AuditRetention < 0 {
+		errs = append(errs, errors.New("AUDIT_RETENTION: must not be negative"))
+	}
+	if c.BudgetInterval < 0 {
+		errs = append(errs, errors.New("BUDGET_INTERVAL: must not be negative"))
+	}
+	if err := validateBudgets(c.Budgets); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateBillableRules(c.BillableRules); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateCapacities(c.Capacities); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateRates(c.Rates); err != nil {
+		errs = append(errs, err)
+	}
+	if !(c.InvoiceDiscount >= 0 && c.InvoiceDiscount <= 100) || !(c.InvoiceTax >= 0 && c.InvoiceTax <= 100) { // Also rejects NaN
+		errs = append(errs, errors.New("INVOICE_DISCOUNT, INVOICE_TAX: must be percentages between 0 and 100"))
+	}
+	if c.GitHubCommitLead < 0 {
+		errs = append(errs, errors.New("GITHUB_COMMIT_LEAD: must not be negative"))
+	}
+	if c.GitHubSessionGap <= 0 {
+		errs = append(errs, errors.New("GITHUB_SESSION_GAP: must be positive"))
+	}
+	if c.InvoiceDueDays < 0 {
+		errs = append(errs, errors.New("INVOICE_DUE_DAYS: must not be negative"))
+	}
+	if c.FXRatesURL != "" {
+		if err := validateHTTPURL(c.FXRatesURL); err != nil {
+			errs = append(errs, fmt.Errorf("FX_RATES_URL: %w", err))
+		}
+	}
+	if c.FXRatesTTL <= 0 {
+		errs = append(errs, errors.New("FX_RATES_TTL: must be positive"))
+	}
+	if err := validateForwardTargets(c.ForwardTargets); err != nil {
+		errs = append(errs, err)
+	}
+	if c.RedisURL != "" {
+		if u, err := url.Parse(c.RedisURL); err != nil || u.Scheme != "redis" && u.Scheme != "rediss" || u.Host == "" {
+			errs = append(errs, errors.New("REDIS_URL: must be a redis:// or rediss:// URL"))
+		}
+	}
+	if c.PublishBackend != "" {
+		if !slices.Contains([]string{"nats", "kafka"}, c.PublishBackend) {
+			errs = append(errs, fmt.Errorf("PUBLISH_BACKEND: must be nats or kafka, got %q", c.PublishBackend))
+		}
+		if c.PublishURL == "" {
+			errs = append(errs, fmt.Errorf("PUBLISH_URL: required with PUBLISH_BACKEND=%s", c.PublishBackend))
+		}
+		if c.PublishTopic == "" {
+			errs = append(errs, errors.New("PUBLISH_TOPIC: must not be empty"))
+		}
+	}
+	if !slices.Contains([]string{"json", "protobuf"}, c.PublishEncoding) {
+		errs = append(errs, fmt.Errorf("PUBLISH_ENCODING: must be json or protobuf, got %q", c.PublishEncoding))
+	}
+	if slices.Contains(c.PublishEvents, "") {
+		errs = append(errs, errors.New("PUBLISH_EVENTS: must not contain empty event names"))
+	}
+	if c.MetricsEnabled && c.MetricsRefreshInterval <= 0 {
+		errs = append(errs, errors.New("METRICS_REFRESH_INTERVAL: must be positive"))
+	}
+	if c.ExportDir != "" && c.ExportFlushInterval <= 0 {
+		errs = append(errs, errors.New("EXPORT_FLUSH_INTERVAL: must be positive"))
+	}
+	if c.BigQueryProject != "" {
+		if !bigQueryDatasetPattern.MatchString(c.BigQueryDataset) || len(c.BigQueryDataset) > 1024 {
+			errs = append(errs, fmt.Errorf("BIGQUERY_DATASET: must be 1-1024 letters, digits and underscores, got %q", c.BigQueryDataset))
+		}
+		if strings.ContainsAny(c.BigQueryProject, "`/ ") {
+			errs = append(errs, fmt.Errorf("BIGQUERY_PROJECT: must be a project ID, got %q", c.BigQueryProject))
+		}
+		if c.BigQueryInterval <= 0 {
+			errs = append(errs, errors.New("BIGQUERY_SYNC_INTERVAL: must be positive"))
+		}
+		if c.BigQueryWindow < 0 {
+			errs = append(errs, errors.New("BIGQUERY_SYNC_WINDOW: must not be negative"))
+		}
+	}
+	if c.ArchiveURL != "" {
+		if u, err := url.Parse(c.ArchiveURL); err != nil || u.Scheme != "s3" && u.Scheme != "gs" || u.Host == "" {
+			errs = append(errs, errors.New("ARCHIVE_URL: must be an s3://bucket or gs://bucket URL"))
+		}
+		if c.ArchiveAccessKeyID == "" || c.ArchiveSecretAccessKey == "" {
+			errs = append(errs, errors.New("ARCHIVE_ACCESS_KEY_ID, ARCHIVE_SECRET_ACCESS_KEY: required with ARCHIVE_URL"))
+		}
+		if c.ArchiveFlushInterval <= 0 {
+			errs = append(errs, errors.New("ARCHIVE_FLUSH_INTERVAL: must be positive"))
+		}
+	}
+	if err := validateGitHooks(c.GitHooks, c.Profiles); err != nil {
+		errs = append(errs, err)
+	}
+	if slices.Contains(c.NotifyEvents, "") {
+		errs = append(errs, errors.New("NOTIFY_EVENTS: must not contain empty event names"))
+	}
+	if err := validateAPIUsers(c.APIUsers, c.APIToken); err != nil {
+		errs = append(errs, err)
+	}
+	if c.GRPCListenAddr != "" && !c.APIEnabled() {
+		errs = append(errs, errors.New("GRPC_LISTEN_ADDR: requires API_TOKEN or api_users"))
+	}
+	if c.ListenAddr == "" {
+		errs = append(errs, errors.New("LISTEN_ADDR: must not be empty"))
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: must be text or json, got %q", c.LogFormat))
+	}
+	if c.ClockifyRateLimit < 0 {
+		errs = append(errs, errors.New("CLOCKIFY_RATE_LIMIT: must not be negative"))
+	}
+	if c.ClockifyRetryAttempts < 1 {
+		errs = append(errs, errors.New("CLOCKIFY_RETRY_ATTEMPTS: must be at least 1"))
+	}
+	if c.ClockifyMaxIdleConns < 1 {
+		errs = append(errs, errors.New("CLOCKIFY_MAX_IDLE_CONNS: must be at least 1"))
+	}
+	if c.ClockifyBreakerThreshold < 0 {
+		errs = append(errs, errors.New("CLOCKIFY_BREAKER_THRESHOLD: must not be negative"))
+	}
+	if c.ClockifyBreakerThreshold > 0 && c.ClockifyBreakerCooldown <= 0 {
+		errs = append(errs, errors.New("CLOCKIFY_BREAKER_COOLDOWN: must be positive"))
+	}
+	if c.ClockifyETagCacheSize < 0 {
+		errs = append(errs, errors.New("CLOCKIFY_ETAG_CACHE_SIZE: must not be negative"))
+	}
+	if _, err := regexp.Compile(c.TicketPattern); err != nil {
+		errs = append(errs, fmt.Errorf("TICKET_PATTERN: %w", err))
+	}
+	if _, err := c.EndpointVersions(); err != nil {
+		errs = append(errs, fmt.Errorf("CLOCKIFY_ENDPOINT_VERSIONS: %w", err))
+	}
+	if c.ClockifyIdleConnTimeout < 0 {
+		errs = append(errs, errors.New("CLOCKIFY_IDLE_CONN_TIMEOUT: must not be negative"))
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"CLOCKIFY_WRITE_TIMEOUT", c.ClockifyWriteTimeout},
+		{"CLOCKIFY_READ_TIMEOUT", c.ClockifyReadTimeout},
+		{"CLOCKIFY_LIST_TIMEOUT", c.ClockifyListTimeout},
+		{"MIGRATION_DEADLINE", c.MigrationDeadline},
+	} {
+		if timeout.value < 0 {
+			errs = append(errs, fmt.Errorf("%s: must not be negative", timeout.name))
+		}
+	}
+
+	// Checked in a fixed order so the report is stable
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"CLOCKIFY_TIMEOUT", c.ClockifyTimeout},
+		{"SERVER_READ_TIMEOUT", c.ServerReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout},
+		{"SERVER_SHUTDOWN_GRACE", c.ServerShutdownGrace},
+		{"API_CACHE_TTL", c.APICacheTTL},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive", timeout.name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// DigestClock returns the hour and minute of DIGEST_TIME
+func (c *Config) DigestClock() (hour, minute int, err error) {
+	return parseClock(c.DigestTime)
+}
+
+// BudgetPercentages returns BUDGET_THRESHOLDS as ascending percentages
+func (c *Config) BudgetPercentages() ([]int, error) {
+	percentages := make([]int, 0, len(c.BudgetThresholds))
+	for _, value := range c.BudgetThresholds {
+		percentage, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || percentage <= 0 {
+			return nil, fmt.Errorf("must be positive percentages, got %q", value)
+		}
+		percentages = append(percentages, percentage)
+	}
+	slices.Sort(percentages)
+	return slices.Compact(percentages), nil
+}
+
+// experimentalEndpoints are the endpoints CLOCKIFY_ENDPOINT_VERSIONS can retarget
+var experimentalEndpoints = []string{"custom-fields", "time-off"}
+
+// bigQueryDatasetPattern matches the characters of a BigQuery dataset name, whose length of
+// at most 1024 is checked apart, Go regexps repeating at most 1000 times
+var bigQueryDatasetPattern = regexp.MustCompile(`^\w+$`)
+
+// apiVersionPattern matches the version segment of a Clockify API path
+var apiVersionPattern = regexp.MustCompile(`^v\d+$`)
+
+// EndpointVersions returns CLOCKIFY_ENDPOINT_VERSIONS as the version of each endpoint
+func (c *Config) EndpointVersions() (map[string]string, error) {
+	versions := make(map[string]string, len(c.ClockifyEndpointVersions))
+	for _, pair := range c.ClockifyEndpointVersions {
+		endpoint, version, ok := strings.Cut(pair, "=")
+		if !ok || !apiVersionPattern.MatchString(version) {
+			return nil, fmt.Errorf("must be endpoint=version pairs like custom-fields=v2, got %q", pair)
+		}
+		if !slices.Contains(experimentalEndpoints, endpoint) {
+			return nil, fmt.Errorf("unknown endpoint %q, must be one of %s", endpoint, strings.Join(experimentalEndpoints, ", "))
+		}
+		versions[endpoint] = version
+	}
+	return versions, nil
+}
+
+// TimesheetClock returns the hour and minute of TIMESHEET_SUBMIT_TIME
+func (c *Config) TimesheetClock() (hour, minute int, err error) {
+	return parseClock(c.TimesheetSubmitTime)
+}
+
+// ReminderClock returns the hour and minute of REMINDER_TIME
+func (c *Config) ReminderClock() (hour, minute int, err error) {
+	return parseClock(c.ReminderTime)
+}
+
+func parseClock(value string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("must be HH:MM, got %q", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// isUnreserved reports whether r may appear in a URL path segment as is
+func isUnreserved(r rune) bool {
+	return r < 0x80 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-._~", r))
+}
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL, got %q", raw)
+	}
+	return nil
+}
+
+func parseLogLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return 0, fmt.Errorf("must be debug, info, warn or error, got %q", level)
+	}
+	return l, nil
+}
+
+// NewLogger builds a logger writing to w with the configured level and format
+func (c *Config) NewLogger(w io.Writer) *slog.Logger {
+	level, _ := parseLogLevel(c.LogLevel) // validated on load
+	opts := &slog.HandlerOptions{Level: level}
+
+	if c.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
 }
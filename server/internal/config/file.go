@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnvVar points to the config file explicitly, skipping the lookup of default file names
+const ConfigFileEnvVar = "CCWS_CONFIG"
+
+// defaultConfigFiles are looked up in the working directory, in order
+var defaultConfigFiles = []string{"ccws.yaml", "ccws.yml", "ccws.toml"}
+
+// findConfigFile returns the config file to load, or "" if there is none
+func findConfigFile() string {
+	if path := os.Getenv(ConfigFileEnvVar); path != "" {
+		return path
+	}
+
+	for _, name := range defaultConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// readConfigFile decodes a YAML or TOML config file, chosen by extension, into its top-level keys.
+//
+// Keys are the lower-cased environment variable names, e.g. `listen_addr: ":9090"`.
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// applyFileValues sets the fields of spec found in the file, unless their environment
+// variable is set, since the environment takes precedence. All problems are reported at once.
+func applyFileValues(spec any, values map[string]any) error {
+	return applyValues(spec, values, true)
+}
+
+// applyValues sets the fields of spec from values keyed by the lower-cased envconfig tag.
+// With respectEnv, fields whose environment variable is set are left untouched.
+func applyValues(spec any, values map[string]any, respectEnv bool) error {
+	var errs []error
+	known := make(map[string]bool)
+
+	v := reflect.ValueOf(spec).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		envName := t.Field(i).Tag.Get("envconfig")
+		if envName == "" {
+			continue
+		}
+
+		key := strings.ToLower(envName)
+		known[key] = true
+
+		raw, ok := values[key]
+		if !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(envName); set && respectEnv {
+			continue
+		}
+
+		if err := setField(v.Field(i), rawString(raw)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	// Sorted, so the errors come in the same order on every load
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("%s: unknown config key", key))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// decodeProfiles reads the `profiles` section of the config file
+func decodeProfiles(raw any) (map[string]Profile, error) {
+	sections, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("profiles: must be a mapping of profile names to settings")
+	}
+
+	var errs []error
+	profiles := make(map[string]Profile, len(sections))
+	for _, name := range slices.Sorted(maps.Keys(sections)) {
+		section := sections[name]
+		values, ok := section.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("profiles.%s: must be a mapping of settings", name))
+			continue
+		}
+
+		profile := Profile{Name: name}
+		if err := applyValues(&profile, values, false); err != nil {
+			errs = append(errs, fmt.Errorf("profiles.%s: %w", name, err))
+			continue
+		}
+		profiles[name] = profile
+	}
+
+	return profiles, errors.Join(errs...)
+}
+
+// rawString flattens a decoded file value, lists become comma-separated like in the environment
+func rawString(raw any) string {
+	items, ok := raw.([]any)
+	if !ok {
+		return fmt.Sprint(raw)
+	}
+
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = fmt.Sprint(item)
+	}
+	return strings.Join(parts, ",")
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField parses raw into the field according to its type
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported setting type %s", field.Type())
+		}
+		var items []string
+		if raw != "" {
+			items = strings.Split(raw, ",")
+			for i := range items {
+				items[i] = strings.TrimSpace(items[i])
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
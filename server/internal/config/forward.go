@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// forwardKey is the config file section listing the downstream webhooks
+const forwardKey = "forward"
+
+// ForwardTarget is a downstream webhook events are re-published to.
+//
+// Targets are only read from the config file:
+//
+//	forward:
+//	  - name: billing
+//	    url: https://billing.example.com/hooks/ccws
+//	    secret: s3cr3t
+//	    events: [TIMER_STOPPED]
+//	    template: '{"text": "{{.Type}} in {{.WorkspaceID}}"}'
+type ForwardTarget struct {
+	Name string
+	URL  string
+	// Key the payload is signed with (HMAC-SHA256), empty sends unsigned payloads
+	Secret string
+	// Events forwarded to the target, empty means all
+	Events []string
+	// Go text/template rendering the payload from the event envelope, empty sends the envelope as JSON
+	Template    string
+	ContentType string // Defaults to application/json
+}
+
+// decodeForwardTargets reads the `forward` section of the config file
+func decodeForwardTargets(raw any) ([]ForwardTarget, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("forward: must be a list of targets")
+	}
+
+	var errs []error
+	targets := make([]ForwardTarget, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("forward[%d]: must be a mapping of settings", i))
+			continue
+		}
+
+		target := ForwardTarget{Name: fmt.Sprintf("forward-%d", i+1), ContentType: "application/json"}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			value := values[key]
+			switch key {
+			case "name":
+				target.Name = fmt.Sprint(value)
+			case "url":
+				target.URL = fmt.Sprint(value)
+			case "secret":
+				target.Secret = fmt.Sprint(value)
+			case "events":
+				list, ok := value.([]any)
+				if !ok {
+					errs = append(errs, fmt.Errorf("forward[%d].events: must be a list", i))
+					continue
+				}
+				for _, event := range list {
+					target.Events = append(target.Events, fmt.Sprint(event))
+				}
+			case "template":
+				target.Template = fmt.Sprint(value)
+			case "content_type":
+				target.ContentType = fmt.Sprint(value)
+			default:
+				errs = append(errs, fmt.Errorf("forward[%d].%s: unknown key", i, key))
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, errors.Join(errs...)
+}
+
+// validateForwardTargets checks the URL of every target, templates are parsed by the forwarder
+func validateForwardTargets(targets []ForwardTarget) error {
+	var errs []error
+	for _, target := range targets {
+		if err := validateHTTPURL(target.URL); err != nil {
+			errs = append(errs, fmt.Errorf("forward %s: url: %w", target.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
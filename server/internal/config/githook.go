@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+)
+
+// gitHooksKey is the config file section mapping repositories and branches to timers
+const gitHooksKey = "git_hooks"
+
+// GitHook is what `ccws hook` does in the repositories and branches it matches. The first
+// rule matching a checkout or commit applies.
+//
+// Rules are only read from the config file:
+//
+//	git_hooks:
+//	  - repo: website             # Directory name, or the path when it has a slash
+//	    branch: "feature/*"       # Any branch when empty
+//	    project: Website
+//	    description: "{ticket} {branch}"
+//	    annotate: true
+//	  - repo: "*"
+//	    branch: main
+//	    stop: true
+type GitHook struct {
+	Repo    string // Glob of the repository's directory name, or of its path if it contains a slash
+	Branch  string // Glob of the branch, empty matches every branch
+	Profile string // Profile whose credentials and workspace the timer is started with, empty for the selected one
+	Project string
+	Task    string
+	Tags    []string
+	// Description template of the timers started on checkout, {branch} when empty
+	Description string
+	// Stop the running timer on checkout instead of starting one
+	Stop bool
+	// Append the subjects of commits to the description of the running timer
+	Annotate bool
+}
+
+// decodeGitHooks reads the `git_hooks` section of the config file
+func decodeGitHooks(raw any) ([]GitHook, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("git_hooks: must be a list of rules")
+	}
+
+	var errs []error
+	hooks := make([]GitHook, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("git_hooks[%d]: must be a mapping of settings", i))
+			continue
+		}
+
+		var hook GitHook
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			value := values[key]
+			switch key {
+			case "repo":
+				hook.Repo = fmt.Sprint(value)
+			case "branch":
+				hook.Branch = fmt.Sprint(value)
+			case "profile":
+				hook.Profile = fmt.Sprint(value)
+			case "project":
+				hook.Project = fmt.Sprint(value)
+			case "task":
+				hook.Task = fmt.Sprint(value)
+			case "tags":
+				list, ok := value.([]any)
+				if !ok {
+					errs = append(errs, fmt.Errorf("git_hooks[%d].tags: must be a list", i))
+					continue
+				}
+				for _, tag := range list {
+					hook.Tags = append(hook.Tags, fmt.Sprint(tag))
+				}
+			case "description":
+				hook.Description = fmt.Sprint(value)
+			case "stop", "annotate":
+				flag, ok := value.(bool)
+				if !ok {
+					errs = append(errs, fmt.Errorf("git_hooks[%d].%s: must be true or false", i, key))
+					continue
+				}
+				if key == "stop" {
+					hook.Stop = flag
+				} else {
+					hook.Annotate = flag
+				}
+			default:
+				errs = append(errs, fmt.Errorf("git_hooks[%d].%s: unknown key", i, key))
+			}
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, errors.Join(errs...)
+}
+
+// validateGitHooks checks every rule has valid globs and either starts a timer or stops one
+func validateGitHooks(hooks []GitHook, profiles map[string]Profile) error {
+	var errs []error
+	for i, hook := range hooks {
+		if hook.Repo == "" {
+			errs = append(errs, fmt.Errorf("git_hooks[%d].repo: is required", i))
+		} else if _, err := path.Match(hook.Repo, ""); err != nil {
+			errs = append(errs, fmt.Errorf("git_hooks[%d].repo: %w", i, err))
+		}
+		if _, err := path.Match(hook.Branch, ""); err != nil {
+			errs = append(errs, fmt.Errorf("git_hooks[%d].branch: %w", i, err))
+		}
+		if _, ok := profiles[hook.Profile]; hook.Profile != "" && !ok {
+			errs = append(errs, fmt.Errorf("git_hooks[%d].profile: unknown profile %q", i, hook.Profile))
+		}
+		if hook.Stop && (hook.Project != "" || hook.Task != "" || len(hook.Tags) > 0 || hook.Description != "") {
+			errs = append(errs, fmt.Errorf("git_hooks[%d]: stop rules start no timer, remove project, task, tags and description", i))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// githubProjectsKey is the config file section mapping GitHub repositories to projects
+const githubProjectsKey = "github_projects"
+
+// decodeGitHubProjects reads the `github_projects` section of the config file:
+//
+//	github_projects:
+//	  Hukyl/CCWS: CCWS
+//	  website: Website # Any owner's repository named website
+func decodeGitHubProjects(raw any) (map[string]string, error) {
+	values, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("github_projects: must be a mapping of repositories to project names")
+	}
+
+	var errs []error
+	projects := make(map[string]string, len(values))
+	for repo, value := range values {
+		project, ok := value.(string)
+		if !ok || project == "" {
+			errs = append(errs, fmt.Errorf("github_projects.%s: must be a project name", repo))
+			continue
+		}
+		projects[repo] = project
+	}
+
+	return projects, errors.Join(errs...)
+}
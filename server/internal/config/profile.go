@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// profilesKey is the config file section holding the profiles
+const profilesKey = "profiles"
+
+// Profile is a named set of Clockify credentials and defaults, e.g. one per client account.
+//
+// Keys in the config file are the same as the top-level ones:
+//
+//	profiles:
+//	  acme:
+//	    clockify_api_key: ...
+//	    clockify_workspace_name: Acme
+//	    clockify_default_project: Support
+type Profile struct {
+	Name string
+
+	ClockifyAPIKey     string `envconfig:"CLOCKIFY_API_KEY"`
+	ClockifyAPIKeyFile string `envconfig:"CLOCKIFY_API_KEY_FILE"`
+	ClockifyBaseURL    string `envconfig:"CLOCKIFY_BASE_URL"`
+	WorkspaceName      string `envconfig:"CLOCKIFY_WORKSPACE_NAME"`
+	WorkspaceID        string `envconfig:"CLOCKIFY_WORKSPACE_ID"`
+	DefaultProject     string `envconfig:"CLOCKIFY_DEFAULT_PROJECT"`
+	DefaultTask        string `envconfig:"CLOCKIFY_DEFAULT_TASK"`
+	// Replace the top-level default tags when set
+	DefaultTags []string `envconfig:"CLOCKIFY_DEFAULT_TAGS"`
+}
+
+// ProfileNames returns the names of the configured profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// WithProfile returns a copy of the config with the settings of the named profile applied.
+//
+// Settings left empty in the profile keep their top-level values. Since a profile is selected
+// explicitly, its settings take precedence over the environment.
+func (c *Config) WithProfile(name string) (*Config, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("CCWS_PROFILE: unknown profile %q, available: %s", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	cfg := *c
+	cfg.Profile = name
+
+	if profile.ClockifyAPIKey != "" || profile.ClockifyAPIKeyFile != "" {
+		// Whichever secret source the profile uses replaces the top-level one, addon token included
+		cfg.ClockifyAPIKey = profile.ClockifyAPIKey
+		cfg.ClockifyAPIKeyFile = profile.ClockifyAPIKeyFile
+		cfg.ClockifyAddonToken, cfg.ClockifyAddonTokenFile = "", ""
+	}
+	if profile.ClockifyBaseURL != "" {
+		cfg.ClockifyBaseURL = profile.ClockifyBaseURL
+	}
+	if profile.WorkspaceName != "" || profile.WorkspaceID != "" {
+		// The workspace is a single setting, so a profile never mixes its own name with another ID
+		cfg.WorkspaceName = profile.WorkspaceName
+		cfg.WorkspaceID = profile.WorkspaceID
+	}
+	if profile.DefaultProject != "" {
+		// The task belongs to the project, so it is replaced along with it
+		cfg.DefaultProject = profile.DefaultProject
+		cfg.DefaultTask = profile.DefaultTask
+	}
+	if len(profile.DefaultTags) > 0 {
+		cfg.DefaultTags = profile.DefaultTags
+	}
+
+	return &cfg, nil
+}
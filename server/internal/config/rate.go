@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// ratesKey is the config file section listing the hourly rates invoices bill
+const ratesKey = "rates"
+
+// Rate is an hourly rate for a project, a user, or a user on a project. It overrides the
+// rates set in Clockify, the most specific rate matching an entry wins.
+//
+// Rates are only read from the config file:
+//
+//	rates:
+//	  - project: Website
+//	    rate: 90
+//	  - user: alice@example.com
+//	    rate: 120.50
+//	  - project: Website
+//	    user: alice@example.com
+//	    rate: 150
+type Rate struct {
+	Project string // Project name, empty for every project
+	User    string // User email or name, empty for every user
+	Amount  int64  // Per hour in the smallest currency unit, e.g. cents
+}
+
+// decodeRates reads the `rates` section of the config file
+func decodeRates(raw any) ([]Rate, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("rates: must be a list of rates")
+	}
+
+	var errs []error
+	rates := make([]Rate, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("rates[%d]: must be a mapping of settings", i))
+			continue
+		}
+
+		var rate Rate
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			value := values[key]
+			switch key {
+			case "project":
+				rate.Project = fmt.Sprint(value)
+			case "user":
+				rate.User = fmt.Sprint(value)
+			case "rate":
+				amount, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("rates[%d].rate: must be a number, got %v", i, value))
+					continue
+				}
+				rate.Amount = int64(math.Round(amount * 100))
+			default:
+				errs = append(errs, fmt.Errorf("rates[%d].%s: unknown key", i, key))
+			}
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, errors.Join(errs...)
+}
+
+// validateRates checks every rate names a project or a user and has a positive amount
+func validateRates(rates []Rate) error {
+	var errs []error
+	for i, rate := range rates {
+		if rate.Project == "" && rate.User == "" {
+			errs = append(errs, fmt.Errorf("rates[%d]: project or user is required", i))
+		}
+		if rate.Amount <= 0 {
+			errs = append(errs, fmt.Errorf("rates[%d].rate: must be positive", i))
+		}
+	}
+	return errors.Join(errs...)
+}
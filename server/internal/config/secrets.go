@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService is the service name the API keys are stored under in the OS keyring
+const keyringService = "ccws"
+
+// defaultKeyringAccount holds the API key when no profile is selected
+const defaultKeyringAccount = "default"
+
+// ErrNoKeyringSecret is returned when the keyring holds no API key for the account
+var ErrNoKeyringSecret = errors.New("no API key stored in the OS keyring")
+
+// keyringAccount returns the keyring account of the selected profile
+func (c *Config) keyringAccount() string {
+	if c.Profile != "" {
+		return c.Profile
+	}
+	return defaultKeyringAccount
+}
+
+// resolveSecrets fills in the API key when it is not given directly: from the file in
+// CLOCKIFY_API_KEY_FILE (e.g. a Kubernetes or systemd secret), then from the OS keyring
+// if CCWS_KEYRING is enabled. The addon token is read from CLOCKIFY_ADDON_TOKEN_FILE the
+// same way, the API key is not needed with it.
+func (c *Config) resolveSecrets() error {
+	if c.ClockifyAddonToken == "" && c.ClockifyAddonTokenFile != "" {
+		data, err := os.ReadFile(c.ClockifyAddonTokenFile)
+		if err != nil {
+			return fmt.Errorf("CLOCKIFY_ADDON_TOKEN_FILE: %w", err)
+		}
+		c.ClockifyAddonToken = strings.TrimSpace(string(data))
+	}
+	if c.ClockifyAPIKey != "" || c.ClockifyAddonToken != "" {
+		return nil
+	}
+
+	if c.ClockifyAPIKeyFile != "" {
+		data, err := os.ReadFile(c.ClockifyAPIKeyFile)
+		if err != nil {
+			return fmt.Errorf("CLOCKIFY_API_KEY_FILE: %w", err)
+		}
+		c.ClockifyAPIKey = strings.TrimSpace(string(data))
+		return nil
+	}
+
+	if c.UseKeyring {
+		key, err := keyring.Get(keyringService, c.keyringAccount())
+		if errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("CCWS_KEYRING: %w for %q", ErrNoKeyringSecret, c.keyringAccount())
+		}
+		if err != nil {
+			return fmt.Errorf("CCWS_KEYRING: %w", err)
+		}
+		c.ClockifyAPIKey = key
+	}
+
+	return nil
+}
+
+// StoreAPIKey saves the API key of the selected profile in the OS keyring
+func (c *Config) StoreAPIKey(apiKey string) error {
+	return keyring.Set(keyringService, c.keyringAccount(), apiKey)
+}
+
+// DeleteAPIKey removes the API key of the selected profile from the OS keyring
+func (c *Config) DeleteAPIKey() error {
+	err := keyring.Delete(keyringService, c.keyringAccount())
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNoKeyringSecret
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// apiUsersKey is the config file section listing the accounts of the server's API
+const apiUsersKey = "api_users"
+
+// Roles of the API accounts
+const (
+	// RoleViewer reads reports, entries, the timer, the event stream and the audit log
+	RoleViewer = "viewer"
+	// RoleOperator also starts and stops the timer, deletes entries and runs migrations
+	RoleOperator = "operator"
+)
+
+// APIUser is an account of the HTTP and gRPC APIs, letting a team share one server.
+// API_TOKEN, when set, is an additional operator.
+//
+// Accounts are only read from the config file:
+//
+//	api_users:
+//	  - name: alice
+//	    token: 0b5e1c...
+//	    role: operator
+//	  - name: bob
+//	    token: 9f3a77...
+//	    role: viewer
+type APIUser struct {
+	Name  string
+	Token string
+	Role  string // RoleViewer or RoleOperator, defaults to RoleViewer
+}
+
+// decodeAPIUsers reads the `api_users` section of the config file
+func decodeAPIUsers(raw any) ([]APIUser, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("api_users: must be a list of accounts")
+	}
+
+	var errs []error
+	users := make([]APIUser, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("api_users[%d]: must be a mapping of settings", i))
+			continue
+		}
+
+		user := APIUser{Role: RoleViewer}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			value := values[key]
+			switch key {
+			case "name":
+				user.Name = fmt.Sprint(value)
+			case "token":
+				user.Token = fmt.Sprint(value)
+			case "role":
+				user.Role = fmt.Sprint(value)
+			default:
+				errs = append(errs, fmt.Errorf("api_users[%d].%s: unknown key", i, key))
+			}
+		}
+		users = append(users, user)
+	}
+
+	return users, errors.Join(errs...)
+}
+
+// APIEnabled reports whether the API has any account, API_TOKEN or api_users
+func (c *Config) APIEnabled() bool {
+	return c.APIToken != "" || len(c.APIUsers) > 0
+}
+
+// validateAPIUsers checks every account has a unique name and token and a known role
+func validateAPIUsers(users []APIUser, apiToken string) error {
+	var errs []error
+	names := make(map[string]bool, len(users))
+	tokens := map[string]bool{apiToken: apiToken != ""}
+	for i, user := range users {
+		if user.Name == "" {
+			errs = append(errs, fmt.Errorf("api_users[%d]: name: must not be empty", i))
+		} else if names[user.Name] {
+			errs = append(errs, fmt.Errorf("api_users %s: name: must be unique", user.Name))
+		}
+		names[user.Name] = true
+
+		switch {
+		case user.Token == "":
+			errs = append(errs, fmt.Errorf("api_users %s: token: must not be empty", user.Name))
+		case tokens[user.Token]:
+			errs = append(errs, fmt.Errorf("api_users %s: token: must differ from the other tokens and API_TOKEN", user.Name))
+		}
+		tokens[user.Token] = true
+
+		if !slices.Contains([]string{RoleViewer, RoleOperator}, user.Role) {
+			errs = append(errs, fmt.Errorf("api_users %s: role: must be viewer or operator, got %q", user.Name, user.Role))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,186 @@
+// Package deadletter keeps the events a handler failed on, with the error and how often they
+// were attempted, so they can be run through the handler again once it is fixed.
+package deadletter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS dead_letters (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	handler      TEXT NOT NULL,
+	event        TEXT NOT NULL,
+	workspace_id TEXT NOT NULL,
+	payload      TEXT NOT NULL, -- JSON
+	received_at  INTEGER NOT NULL, -- Unix milliseconds
+	error        TEXT NOT NULL,
+	attempts     INTEGER NOT NULL,
+	failed_at    INTEGER NOT NULL -- Unix milliseconds, of the last attempt
+);
+`
+
+// ErrNotFound is returned for letters that do not exist, e.g. already retried successfully
+var ErrNotFound = errors.New("dead letter not found")
+
+// Letter is an event a handler failed on
+type Letter struct {
+	ID          int64                 `json:"id"`
+	Handler     string                `json:"handler"`
+	Event       clockify.WebhookEvent `json:"event"`
+	WorkspaceID string                `json:"workspaceId"`
+	Payload     json.RawMessage       `json:"payload"`
+	ReceivedAt  time.Time             `json:"receivedAt"`
+	Error       string                `json:"error"` // Of the last attempt
+	Attempts    int                   `json:"attempts"`
+	FailedAt    time.Time             `json:"failedAt"`
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithPayloadType decodes the payloads of the event into T before retrying them, for events
+// raised by CCWS itself, e.g. *budget.Alert
+func WithPayloadType[T any](event clockify.WebhookEvent) Option {
+	return func(s *Store) {
+		s.payloads[event] = func(data []byte) (any, error) {
+			var payload T
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return nil, err
+			}
+			return payload, nil
+		}
+	}
+}
+
+// Store is the SQLite database of dead letters. It is safe for concurrent use.
+type Store struct {
+	db       *sql.DB
+	payloads map[clockify.WebhookEvent]func(data []byte) (any, error)
+}
+
+// Open opens the store at dsn, a file path or a "file:" URI, creating it if needed
+func Open(dsn string, opts ...Option) (*Store, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead letters: %w", err)
+	}
+	// SQLite allows a single writer, serialize on one connection instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode = WAL; PRAGMA busy_timeout = 5000;" + schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create dead letter tables: %w", err)
+	}
+
+	s := &Store{db: db, payloads: make(map[clockify.WebhookEvent]func([]byte) (any, error))}
+	WithPayloadType[*clockify.TimeEntry](events.LongRunningTimerEvent)(s)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record stores the event the handler failed on, it is an events.FailureHook. Failing to
+// store it is logged.
+func (s *Store) Record(ctx context.Context, event events.Event, handler string, handlerErr error) {
+	payload, err := json.Marshal(event.Payload)
+	if err == nil {
+		_, err = s.db.ExecContext(ctx, `
+			INSERT INTO dead_letters (handler, event, workspace_id, payload, received_at, error, attempts, failed_at)
+			VALUES (?, ?, ?, ?, ?, ?, 1, ?)`,
+			handler, event.Type, event.WorkspaceID, string(payload), event.ReceivedAt.UnixMilli(), handlerErr.Error(), time.Now().UnixMilli())
+	}
+	if err != nil {
+		slog.Warn("dead_letter_record_failed", "event", event.Type, "handler", handler, "error", err)
+	}
+}
+
+// List returns the letters, the most recently failed first
+func (s *Store) List(ctx context.Context) ([]Letter, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, handler, event, workspace_id, payload, received_at, error, attempts, failed_at
+		FROM dead_letters ORDER BY failed_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var letters []Letter
+	for rows.Next() {
+		var (
+			l                    Letter
+			payload              string
+			receivedAt, failedAt int64
+		)
+		if err := rows.Scan(&l.ID, &l.Handler, &l.Event, &l.WorkspaceID, &payload, &receivedAt, &l.Error, &l.Attempts, &failedAt); err != nil {
+			return nil, err
+		}
+		l.Payload = json.RawMessage(payload)
+		l.ReceivedAt, l.FailedAt = time.UnixMilli(receivedAt), time.UnixMilli(failedAt)
+		letters = append(letters, l)
+	}
+	return letters, rows.Err()
+}
+
+// Retry runs the letter's handler on its event again. The letter is deleted when the handler
+// succeeds, otherwise its error and attempts are updated and the error is returned.
+func (s *Store) Retry(ctx context.Context, registry *events.Registry, id int64) error {
+	var (
+		l          Letter
+		payload    string
+		receivedAt int64
+	)
+	err := s.db.QueryRowContext(ctx, "SELECT handler, event, workspace_id, payload, received_at FROM dead_letters WHERE id = ?", id).
+		Scan(&l.Handler, &l.Event, &l.WorkspaceID, &payload, &receivedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	decoded, err := s.decode(l.Event, []byte(payload))
+	if err == nil {
+		err = registry.Retry(ctx, events.Event{
+			Type:        l.Event,
+			Source:      events.SourceDeadLetter,
+			WorkspaceID: l.WorkspaceID,
+			Payload:     decoded,
+			ReceivedAt:  time.UnixMilli(receivedAt),
+		}, l.Handler)
+	}
+	if err != nil {
+		if _, updateErr := s.db.ExecContext(ctx, "UPDATE dead_letters SET error = ?, attempts = attempts + 1, failed_at = ? WHERE id = ?",
+			err.Error(), time.Now().UnixMilli(), id); updateErr != nil {
+			return errors.Join(err, updateErr)
+		}
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM dead_letters WHERE id = ?", id)
+	return err
+}
+
+// decode turns a stored payload back into the type the handlers expect
+func (s *Store) decode(event clockify.WebhookEvent, data []byte) (any, error) {
+	if decode, ok := s.payloads[event]; ok {
+		return decode(data)
+	}
+	return clockify.DecodePayload(event, data)
+}
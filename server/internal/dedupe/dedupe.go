@@ -0,0 +1,228 @@
+// Package dedupe finds the projects, clients and tags of a workspace whose names differ only
+// by case, spacing, punctuation or a typo, e.g. "Web site" and "website", and merges each
+// duplicate into the object it duplicates.
+package dedupe
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// Kind is what a Group holds
+type Kind string
+
+const (
+	Projects Kind = "projects"
+	Clients  Kind = "clients"
+	Tags     Kind = "tags"
+)
+
+// Object is a project, client or tag as compared by name
+type Object struct {
+	ID   string
+	Name string
+	// Objects of different scopes are never duplicates, e.g. projects of different clients
+	Scope string
+}
+
+// Group is an object and its duplicates, which are merged into it
+type Group struct {
+	Kind       Kind
+	Canonical  Object
+	Duplicates []Object
+}
+
+// Options tune what names are duplicates
+type Options struct {
+	// Also match names a typo apart, otherwise only case, spacing and punctuation differ
+	Typos bool
+}
+
+// Scan lists the projects, clients or tags of the workspace, as the kinds ask, and groups
+// their duplicates, kind by kind
+func Scan(client *clockify.APIClient, workspaceID string, kinds []Kind, opts Options) ([]Group, error) {
+	var groups []Group
+	for _, kind := range kinds {
+		switch kind {
+		case Projects:
+			projects, err := clockify.Collect(client.IterProjects(workspaceID))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list projects: %w", err)
+			}
+			groups = append(groups, FindProjects(projects, opts)...)
+		case Clients:
+			clients, err := clockify.Collect(client.IterClients(workspaceID))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list clients: %w", err)
+			}
+			groups = append(groups, FindClients(clients, opts)...)
+		case Tags:
+			tags, err := clockify.Collect(client.IterTags(workspaceID))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tags: %w", err)
+			}
+			groups = append(groups, FindTags(tags, opts)...)
+		default:
+			return nil, fmt.Errorf("unknown kind %q", kind)
+		}
+	}
+	return groups, nil
+}
+
+// FindProjects groups the active projects, within each client
+func FindProjects(projects []clockify.Project, opts Options) []Group {
+	var objects []Object
+	for _, p := range projects {
+		if !p.Archived {
+			objects = append(objects, Object{ID: p.ID, Name: p.Name, Scope: p.ClientID})
+		}
+	}
+	return Find(Projects, objects, opts)
+}
+
+// FindClients groups the active clients
+func FindClients(clients []clockify.Client, opts Options) []Group {
+	var objects []Object
+	for _, c := range clients {
+		if !c.Archived {
+			objects = append(objects, Object{ID: c.ID, Name: c.Name})
+		}
+	}
+	return Find(Clients, objects, opts)
+}
+
+// FindTags groups the active tags
+func FindTags(tags []clockify.Tag, opts Options) []Group {
+	var objects []Object
+	for _, t := range tags {
+		if !t.Archived {
+			objects = append(objects, Object{ID: t.ID, Name: t.Name})
+		}
+	}
+	return Find(Tags, objects, opts)
+}
+
+// Find groups the objects of a scope whose names are duplicates, directly or through another
+// one. The oldest object of a group is kept as its canonical one, Clockify IDs start with
+// their creation time. Groups are ordered by the canonical name.
+func Find(kind Kind, objects []Object, opts Options) []Group {
+	objects = slices.Clone(objects)
+	slices.SortFunc(objects, func(a, b Object) int { return cmp.Compare(a.ID, b.ID) })
+
+	keys := make([]string, len(objects))
+	for i, o := range objects {
+		keys[i] = Normalize(o.Name)
+	}
+
+	// Union-find over the pairs of duplicates, rooted at the oldest object
+	parent := make([]int, len(objects))
+	for i := range parent {
+		parent[i] = i
+	}
+	root := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for i := range objects {
+		for j := i + 1; j < len(objects); j++ {
+			if objects[i].Scope == objects[j].Scope && duplicates(keys[i], keys[j], opts) {
+				a, b := root(i), root(j)
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	byRoot := make(map[int]*Group)
+	var groups []*Group
+	for i, o := range objects {
+		r := root(i)
+		if r == i {
+			continue
+		}
+		group, ok := byRoot[r]
+		if !ok {
+			group = &Group{Kind: kind, Canonical: objects[r]}
+			byRoot[r] = group
+			groups = append(groups, group)
+		}
+		group.Duplicates = append(group.Duplicates, o)
+	}
+
+	result := make([]Group, len(groups))
+	for i, group := range groups {
+		result[i] = *group
+	}
+	slices.SortStableFunc(result, func(a, b Group) int {
+		return cmp.Compare(strings.ToLower(a.Canonical.Name), strings.ToLower(b.Canonical.Name))
+	})
+	return result
+}
+
+// Normalize returns the name as compared: lower case letters and digits only
+func Normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// duplicates reports whether two normalized names are the same, or a typo apart. Short names
+// allow no typo, "qa" and "ux" are different tags.
+func duplicates(a, b string, opts Options) bool {
+	if a == b {
+		return a != ""
+	}
+	if !opts.Typos {
+		return false
+	}
+	return distance(a, b) <= allowedTypos(min(len([]rune(a)), len([]rune(b))))
+}
+
+// allowedTypos is how many edits apart names of the length are duplicates
+func allowedTypos(length int) int {
+	switch {
+	case length < 5:
+		return 0
+	case length < 12:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance is the number of single character insertions, deletions, substitutions and
+// transpositions between the strings
+func distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Three rows of the optimal string alignment matrix
+	prev2, prev, cur := make([]int, len(t)+1), make([]int, len(t)+1), make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
@@ -0,0 +1,195 @@
+package dedupe
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// Merger moves what refers to duplicates over to their canonical object, then archives them
+type Merger struct {
+	client      *clockify.APIClient
+	workspaceID string
+	userIDs     []string // Whose time entries are re-pointed
+}
+
+// NewMerger merges in the workspace, re-pointing the time entries of the users. Entries of
+// other users keep referring to the archived duplicates.
+func NewMerger(client *clockify.APIClient, workspaceID string, userIDs []string) *Merger {
+	return &Merger{client: client, workspaceID: workspaceID, userIDs: userIDs}
+}
+
+// Merge merges every duplicate of the group into the canonical object, continuing past
+// failures. Time entries of projects and tags are re-pointed, projects of clients. A duplicate
+// is archived only once nothing refers to it anymore. It returns how many time entries or
+// projects were re-pointed along with every error.
+func (m *Merger) Merge(group Group) (int, error) {
+	var (
+		moved int
+		errs  []error
+	)
+	for _, duplicate := range group.Duplicates {
+		var (
+			n   int
+			err error
+		)
+		switch group.Kind {
+		case Projects:
+			n, err = m.mergeProject(group.Canonical, duplicate)
+		case Clients:
+			n, err = m.mergeClient(group.Canonical, duplicate)
+		case Tags:
+			n, err = m.mergeTag(group.Canonical, duplicate)
+		default:
+			err = fmt.Errorf("unknown kind %q", group.Kind)
+		}
+		moved += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to merge %s into %s: %w", duplicate.Name, group.Canonical.Name, err))
+		}
+	}
+	return moved, errors.Join(errs...)
+}
+
+// mergeProject moves the duplicate's entries to the canonical project, with the canonical
+// task of the same name, none if it has no such task
+func (m *Merger) mergeProject(canonical, duplicate Object) (int, error) {
+	tasks, err := m.taskMapping(canonical.ID, duplicate.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	moved, err := m.repoint(func(userID string) iter.Seq2[clockify.TimeEntry, error] {
+		return m.client.GetProjectTimeEntries(m.workspaceID, duplicate.ID, userID)
+	}, func(request *clockify.UpdateTimeEntryRequest) {
+		request.ProjectID, request.TaskID = canonical.ID, tasks[request.TaskID]
+	})
+	if err != nil {
+		return moved, err
+	}
+
+	archived := true
+	_, err = m.client.UpdateProject(m.workspaceID, duplicate.ID, clockify.ProjectUpdate{Archived: &archived})
+	return moved, err
+}
+
+// taskMapping maps the tasks of the duplicate project to those of the canonical one by name
+func (m *Merger) taskMapping(canonicalID, duplicateID string) (map[string]string, error) {
+	byName := make(map[string]string)
+	for tasks, err := range m.client.IterProjectTasks(m.workspaceID, canonicalID) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks: %w", err)
+		}
+		for _, task := range tasks {
+			byName[Normalize(task.Name)] = task.ID
+		}
+	}
+
+	mapping := make(map[string]string)
+	for tasks, err := range m.client.IterProjectTasks(m.workspaceID, duplicateID) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks: %w", err)
+		}
+		for _, task := range tasks {
+			if id, ok := byName[Normalize(task.Name)]; ok {
+				mapping[task.ID] = id
+			}
+		}
+	}
+	return mapping, nil
+}
+
+// mergeTag replaces the duplicate tag with the canonical one on its entries
+func (m *Merger) mergeTag(canonical, duplicate Object) (int, error) {
+	moved, err := m.repoint(func(userID string) iter.Seq2[clockify.TimeEntry, error] {
+		return m.client.GetTagTimeEntries(m.workspaceID, duplicate.ID, userID)
+	}, func(request *clockify.UpdateTimeEntryRequest) {
+		request.TagIDs = clockify.RetaggedIDs(request.TagIDs, []string{canonical.ID}, []string{duplicate.ID})
+	})
+	if err != nil {
+		return moved, err
+	}
+
+	_, err = m.client.UpdateTag(m.workspaceID, duplicate.ID, clockify.TagUpdate{Name: duplicate.Name, Archived: true})
+	return moved, err
+}
+
+// mergeClient moves the duplicate's projects to the canonical client
+func (m *Merger) mergeClient(canonical, duplicate Object) (int, error) {
+	var (
+		moved int
+		errs  []error
+	)
+	// Listed in full first, as moving them shifts the pages
+	var projects []clockify.Project
+	for page, err := range m.client.IterProjects(m.workspaceID) {
+		if err != nil {
+			return 0, fmt.Errorf("failed to list projects: %w", err)
+		}
+		for _, p := range page {
+			if p.ClientID == duplicate.ID {
+				projects = append(projects, p)
+			}
+		}
+	}
+	for _, p := range projects {
+		if _, err := m.client.UpdateProject(m.workspaceID, p.ID, clockify.ProjectUpdate{ClientID: &canonical.ID}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to move project %s: %w", p.Name, err))
+			continue
+		}
+		moved++
+	}
+	if len(errs) > 0 {
+		return moved, errors.Join(errs...)
+	}
+
+	_, err := m.client.UpdateClient(m.workspaceID, duplicate.ID, clockify.ClientUpdate{Name: duplicate.Name, Archived: true})
+	return moved, err
+}
+
+// repoint updates the entries listed for every user with modify, continuing past failures.
+// Locked entries fail with clockify.ErrLocked.
+func (m *Merger) repoint(list func(userID string) iter.Seq2[clockify.TimeEntry, error], modify func(*clockify.UpdateTimeEntryRequest)) (int, error) {
+	var (
+		moved int
+		errs  []error
+	)
+	for _, userID := range m.userIDs {
+		// Listed in full first, as the updated entries no longer match the filter
+		var entries []clockify.TimeEntry
+		for entry, err := range list(userID) {
+			if err != nil {
+				return moved, fmt.Errorf("failed to list time entries of user %s: %w", userID, err)
+			}
+			entries = append(entries, entry)
+		}
+
+		for _, entry := range entries {
+			if entry.IsLocked {
+				errs = append(errs, fmt.Errorf("failed to update entry '%s': %w", entry, clockify.ErrLocked))
+				continue
+			}
+			request := clockify.UpdateTimeEntryRequest{
+				Billable:    entry.Billable,
+				Description: entry.Description,
+				ProjectID:   entry.ProjectID,
+				TaskID:      entry.TaskID,
+				TagIDs:      entry.TagIDs,
+				Type:        entry.Type,
+			}
+			if entry.TimeInterval != nil {
+				request.Start, request.End = entry.TimeInterval.Start, entry.TimeInterval.End
+			}
+			modify(&request)
+
+			if _, err := m.client.UpdateTimeEntry(m.workspaceID, entry.ID, request); err != nil {
+				errs = append(errs, fmt.Errorf("failed to update entry '%s': %w", entry, err))
+				continue
+			}
+			moved++
+		}
+	}
+	return moved, errors.Join(errs...)
+}
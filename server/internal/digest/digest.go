@@ -0,0 +1,104 @@
+// Package digest builds periodic summaries of tracked time for notifications.
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/notify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Frequency selects the period a digest covers
+type Frequency string
+
+const (
+	Daily  Frequency = "daily"  // The previous day
+	Weekly Frequency = "weekly" // The previous Monday-based week
+)
+
+// Period returns the period the digest sent at now covers
+func (f Frequency) Period(now time.Time) report.Period {
+	if f == Weekly {
+		return report.Week(now, -1)
+	}
+	return report.Day(now.AddDate(0, 0, -1))
+}
+
+// Digest summarizes a user's time in a period
+type Digest struct {
+	Summary *report.Summary
+	// Weekdays of the period without any tracked time
+	MissingDays []time.Time
+	// The timer running when the digest was built, if any
+	Running *clockify.TimeEntry
+}
+
+// Build fetches the user's entries in the period and summarizes them
+func Build(client *clockify.APIClient, workspaceID, userID string, period report.Period, now time.Time) (*Digest, error) {
+	entries, err := report.FetchEntries(client, workspaceID, userID, period)
+	if err != nil {
+		return nil, err
+	}
+	names, err := report.ProjectNames(client, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	running, err := client.GetRunningTimeEntry(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Digest{Summary: report.Summarize(period, entries, names, now), Running: running}
+	for _, day := range d.Summary.Days {
+		if day.Duration == 0 && isWeekday(day.Day) && day.Day.Before(now) {
+			d.MissingDays = append(d.MissingDays, day.Day)
+		}
+	}
+	return d, nil
+}
+
+func isWeekday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// Message renders the digest as a notification
+func (d *Digest) Message() notify.Message {
+	summary := d.Summary
+
+	var text strings.Builder
+	if len(summary.Projects) == 0 {
+		text.WriteString("No time tracked.\n")
+	}
+	for _, project := range summary.Projects {
+		fmt.Fprintf(&text, "%7s  %s\n", report.FormatDuration(project.Duration), project.Project)
+	}
+
+	msg := notify.Message{
+		Title: fmt.Sprintf("Time digest %s", summary.Period),
+		Text:  strings.TrimRight(text.String(), "\n"),
+		Fields: []notify.Field{
+			{Name: "Total", Value: report.FormatDuration(summary.Total)},
+			{Name: "Billable", Value: report.FormatDuration(summary.Billable)},
+		},
+	}
+
+	if len(d.MissingDays) > 0 {
+		days := make([]string, len(d.MissingDays))
+		for i, day := range d.MissingDays {
+			days[i] = day.Format("Mon 2006-01-02")
+		}
+		msg.Fields = append(msg.Fields, notify.Field{Name: "Missing days", Value: strings.Join(days, ", ")})
+	}
+
+	if d.Running != nil {
+		msg.Fields = append(msg.Fields, notify.Field{
+			Name:  "Running timer",
+			Value: fmt.Sprintf("%s since %s", d.Running, d.Running.TimeInterval.Start.Local().Format("Mon 15:04")),
+		})
+	}
+
+	return msg
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// Envelope is the JSON form of the Event schema, which events are published in to forwarding
+// targets and stream clients
+type Envelope struct {
+	ID          string                `json:"id"`
+	Version     int                   `json:"version"`
+	Type        clockify.WebhookEvent `json:"type"`
+	Source      string                `json:"source,omitempty"`
+	WorkspaceID string                `json:"workspaceId"`
+	ActorID     string                `json:"actorId,omitempty"`
+	Subject     Subject               `json:"subject"`
+	OccurredAt  time.Time             `json:"occurredAt"`
+	ReceivedAt  time.Time             `json:"receivedAt"`
+	Data        any                   `json:"data"`
+}
+
+// NewEnvelope wraps the event, normalized first when it was not dispatched
+func NewEnvelope(event Event) Envelope {
+	event = event.Normalize()
+	return Envelope{
+		ID:          event.ID,
+		Version:     event.Version,
+		Type:        event.Type,
+		Source:      event.Source,
+		WorkspaceID: event.WorkspaceID,
+		ActorID:     event.ActorID,
+		Subject:     event.Subject,
+		OccurredAt:  event.OccurredAt.UTC(),
+		ReceivedAt:  event.ReceivedAt.UTC(),
+		Data:        event.Payload,
+	}
+}
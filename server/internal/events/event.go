@@ -0,0 +1,120 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// SchemaVersion is the version of the Event schema, raised on incompatible changes so that
+// consumers of stored and forwarded events can tell them apart
+const SchemaVersion = 1
+
+// Sources of events
+const (
+	SourceWebhook    = "webhook"     // Delivered by Clockify, or found by its polling fallback
+	SourcePoll       = "poll"        // Found by the mirror's polling watcher
+	SourceCCWS       = "ccws"        // Raised by CCWS itself, e.g. budget alerts
+	SourceDeadLetter = "dead_letter" // Replayed from the dead letters
+	SourceArchive    = "archive"     // Restored from the webhook archive
+)
+
+// Subject kinds
+const (
+	SubjectTimeEntry = "time_entry"
+	SubjectProject   = "project"
+	SubjectClient    = "client"
+	SubjectTag       = "tag"
+)
+
+// Subject is what an event is about, by the IDs its payload refers to
+type Subject struct {
+	Kind      string `json:"kind,omitempty"` // e.g. SubjectTimeEntry, empty when unknown
+	ID        string `json:"id,omitempty"`   // Of the kind
+	ProjectID string `json:"projectId,omitempty"`
+	TaskID    string `json:"taskId,omitempty"`
+	UserID    string `json:"userId,omitempty"`
+}
+
+// Subjecter is implemented by the payloads of the events raised by CCWS, to name their subject
+type Subjecter interface {
+	EventSubject() Subject
+}
+
+// Event is a single occurrence dispatched to handlers, whatever raised it. Dispatch fills the
+// fields its source left empty with Normalize.
+type Event struct {
+	ID          string // Unique, random
+	Version     int    // SchemaVersion
+	Type        clockify.WebhookEvent
+	Source      string // e.g. SourceWebhook
+	WorkspaceID string
+	ActorID     string // User whose action raised the event, empty when unknown
+	Subject     Subject
+	Payload     any       // The object as Clockify sent it, e.g. *clockify.TimeEntry
+	OccurredAt  time.Time // When it happened, ReceivedAt when the payload does not tell
+	ReceivedAt  time.Time
+}
+
+// Normalize returns the event with an ID, the schema version, the timestamps and the subject
+// and actor read from its payload, keeping the fields already set
+func (e Event) Normalize() Event {
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.Version == 0 {
+		e.Version = SchemaVersion
+	}
+	if e.ReceivedAt.IsZero() {
+		e.ReceivedAt = time.Now()
+	}
+
+	subject, actor, occurred := describe(e.Type, e.Payload)
+	if e.Subject.Kind == "" {
+		e.Subject = subject
+	}
+	if e.ActorID == "" {
+		e.ActorID = actor
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = occurred
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = e.ReceivedAt
+	}
+	return e
+}
+
+// describe reads the subject, actor and time of the event from its payload
+func describe(event clockify.WebhookEvent, payload any) (subject Subject, actor string, occurred time.Time) {
+	switch p := payload.(type) {
+	case *clockify.TimeEntry:
+		subject = Subject{Kind: SubjectTimeEntry, ID: p.ID, ProjectID: p.ProjectID, TaskID: p.TaskID, UserID: p.UserID}
+		if p.TimeInterval != nil {
+			switch {
+			case event == clockify.NewTimerStartedEvent:
+				occurred = p.TimeInterval.Start
+			case event == clockify.TimerStoppedEvent && p.TimeInterval.End != nil:
+				occurred = *p.TimeInterval.End
+			}
+		}
+		return subject, p.UserID, occurred
+	case *clockify.Project:
+		return Subject{Kind: SubjectProject, ID: p.ID, ProjectID: p.ID}, "", time.Time{}
+	case *clockify.Client:
+		return Subject{Kind: SubjectClient, ID: p.ID}, "", time.Time{}
+	case *clockify.Tag:
+		return Subject{Kind: SubjectTag, ID: p.ID}, "", time.Time{}
+	case Subjecter:
+		return p.EventSubject(), "", time.Time{}
+	}
+	return Subject{}, "", time.Time{}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,145 @@
+// Package events dispatches processed webhook events, and events raised by CCWS itself,
+// to the handlers subscribed to them.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// Events raised by CCWS itself rather than delivered by Clockify
+const (
+	// LongRunningTimerEvent fires when a timer runs longer than the configured threshold.
+	// Its payload is the running *clockify.TimeEntry.
+	LongRunningTimerEvent clockify.WebhookEvent = "LONG_RUNNING_TIMER"
+	// BudgetThresholdEvent fires when a project's tracked hours cross a budget threshold.
+	// Its payload is a *budget.Alert.
+	BudgetThresholdEvent clockify.WebhookEvent = "BUDGET_THRESHOLD_REACHED"
+)
+
+// HandlerFunc handles a dispatched event
+type HandlerFunc func(ctx context.Context, event Event) error
+
+// FailureHook is called with every event a handler failed on, and the name of the handler
+type FailureHook func(ctx context.Context, event Event, handler string, err error)
+
+// subscription is a handler with the name it was subscribed under
+type subscription struct {
+	name   string
+	handle HandlerFunc
+}
+
+// Registry maps event types to their handlers. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	handlers  map[clockify.WebhookEvent][]subscription
+	catchAll  []subscription
+	onFailure FailureHook
+}
+
+// RegistryOption configures a Registry
+type RegistryOption func(*Registry)
+
+// WithFailureHook calls hook with every failure of a handler, e.g. to keep the event for a
+// later Retry
+func WithFailureHook(hook FailureHook) RegistryOption {
+	return func(r *Registry) {
+		r.onFailure = hook
+	}
+}
+
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{handlers: make(map[clockify.WebhookEvent][]subscription)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// On subscribes handler to the given event types. The name tells the handler apart in logs
+// and for Retry, e.g. "mirror".
+func (r *Registry) On(name string, handler HandlerFunc, types ...clockify.WebhookEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range types {
+		r.handlers[t] = append(r.handlers[t], subscription{name: name, handle: handler})
+	}
+}
+
+// OnAll subscribes handler to every event, see On for the name
+func (r *Registry) OnAll(name string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.catchAll = append(r.catchAll, subscription{name: name, handle: handler})
+}
+
+// Types returns the event types with at least one dedicated handler, sorted
+func (r *Registry) Types() []clockify.WebhookEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]clockify.WebhookEvent, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// Dispatch runs every handler subscribed to the event, in subscription order, once normalized.
+// A failing or panicking handler does not stop the others, all failures are returned joined.
+func (r *Registry) Dispatch(ctx context.Context, event Event) error {
+	event = event.Normalize()
+
+	r.mu.RLock()
+	handlers := append(slices.Clone(r.handlers[event.Type]), r.catchAll...)
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := safeHandle(ctx, handler.handle, event); err != nil {
+			slog.Error("event_handler_failed", "event", event.Type, "handler", handler.name, "error", err)
+			if r.onFailure != nil {
+				r.onFailure(ctx, event, handler.name, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ErrNoHandler is returned by Retry for handlers not subscribed to the event
+var ErrNoHandler = errors.New("no such handler")
+
+// Retry runs the named handler on the event again, e.g. one that failed before being fixed.
+// The failure hook is not called, the caller handles the returned error.
+func (r *Registry) Retry(ctx context.Context, event Event, handler string) error {
+	event = event.Normalize()
+
+	r.mu.RLock()
+	handlers := append(slices.Clone(r.handlers[event.Type]), r.catchAll...)
+	r.mu.RUnlock()
+
+	i := slices.IndexFunc(handlers, func(s subscription) bool { return s.name == handler })
+	if i < 0 {
+		return fmt.Errorf("%w %q for %s", ErrNoHandler, handler, event.Type)
+	}
+	return safeHandle(ctx, handlers[i].handle, event)
+}
+
+func safeHandle(ctx context.Context, handler HandlerFunc, event Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panicked: %v", p)
+		}
+	}()
+	return handler(ctx, event)
+}
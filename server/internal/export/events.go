@@ -0,0 +1,166 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/parquet"
+)
+
+// eventColumns is the schema of the events dataset, the event envelope flattened and its
+// data kept as JSON
+var eventColumns = []parquet.Column{
+	{Name: "id", Kind: parquet.String},
+	{Name: "version", Kind: parquet.Int32},
+	{Name: "type", Kind: parquet.String},
+	{Name: "source", Kind: parquet.String, Optional: true},
+	{Name: "workspace_id", Kind: parquet.String},
+	{Name: "actor_id", Kind: parquet.String, Optional: true},
+	{Name: "subject_kind", Kind: parquet.String, Optional: true},
+	{Name: "subject_id", Kind: parquet.String, Optional: true},
+	{Name: "subject_project_id", Kind: parquet.String, Optional: true},
+	{Name: "subject_task_id", Kind: parquet.String, Optional: true},
+	{Name: "subject_user_id", Kind: parquet.String, Optional: true},
+	{Name: "occurred_at", Kind: parquet.Timestamp},
+	{Name: "received_at", Kind: parquet.Timestamp},
+	{Name: "data", Kind: parquet.String},
+}
+
+// maxBufferedEvents flushes the buffer early, bounding the memory of bursts
+const maxBufferedEvents = 10_000
+
+// EventWriter appends the dispatched events to the events dataset, one part file per month of
+// their occurrence each time it flushes. Events are buffered in memory in between, so those
+// received since the last flush are lost if the process is killed.
+type EventWriter struct {
+	dir string
+
+	mu     sync.Mutex
+	buffer [][]any
+	months []time.Time // The month of each buffered row
+}
+
+// NewEventWriter creates a writer exporting to the events dataset under dir
+func NewEventWriter(dir string) *EventWriter {
+	return &EventWriter{dir: dir}
+}
+
+// Handle buffers the event. It is an events.HandlerFunc.
+func (w *EventWriter) Handle(_ context.Context, event events.Event) error {
+	envelope := events.NewEnvelope(event)
+	data, err := json.Marshal(envelope.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", event.Type, err)
+	}
+
+	row := []any{
+		envelope.ID,
+		int32(envelope.Version),
+		string(envelope.Type),
+		optional(envelope.Source),
+		envelope.WorkspaceID,
+		optional(envelope.ActorID),
+		optional(envelope.Subject.Kind),
+		optional(envelope.Subject.ID),
+		optional(envelope.Subject.ProjectID),
+		optional(envelope.Subject.TaskID),
+		optional(envelope.Subject.UserID),
+		envelope.OccurredAt,
+		envelope.ReceivedAt,
+		string(data),
+	}
+
+	w.mu.Lock()
+	w.buffer = append(w.buffer, row)
+	w.months = append(w.months, monthOf(envelope.OccurredAt))
+	full := len(w.buffer) >= maxBufferedEvents
+	w.mu.Unlock()
+
+	if full {
+		_, err := w.Flush()
+		return err
+	}
+	return nil
+}
+
+// Flush writes the buffered events, returning the part files written
+func (w *EventWriter) Flush() ([]Partition, error) {
+	w.mu.Lock()
+	buffer, months := w.buffer, w.months
+	w.buffer, w.months = nil, nil
+	w.mu.Unlock()
+	if len(buffer) == 0 {
+		return nil, nil
+	}
+
+	byMonth := make(map[time.Time][][]any)
+	for i, row := range buffer {
+		byMonth[months[i]] = append(byMonth[months[i]], row)
+	}
+
+	// Named by the flush, so that the parts of every flush sort in order
+	name := fmt.Sprintf("part-%d.parquet", time.Now().UnixNano())
+	var partitions []Partition
+	for month, rows := range byMonth {
+		partition := Partition{Month: month, Path: filepath.Join(partitionDir(w.dir, EventsDataset, month), name), Rows: len(rows)}
+		if err := writeFile(partition.Path, eventColumns, rows); err != nil {
+			// Kept for the next flush rather than dropped
+			w.requeue(byMonth)
+			return partitions, err
+		}
+		partitions = append(partitions, partition)
+		delete(byMonth, month)
+	}
+	slices.SortFunc(partitions, func(a, b Partition) int { return a.Month.Compare(b.Month) })
+	return partitions, nil
+}
+
+// requeue puts back the rows of the months a flush did not write
+func (w *EventWriter) requeue(byMonth map[time.Time][][]any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for month, rows := range byMonth {
+		for _, row := range rows {
+			w.buffer = append(w.buffer, row)
+			w.months = append(w.months, month)
+		}
+	}
+}
+
+// Run flushes the buffered events every interval until the context is done
+func (w *EventWriter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.flushLogged()
+		}
+	}
+}
+
+// Close writes the events buffered since the last flush, e.g. on shutdown
+func (w *EventWriter) Close() error {
+	_, err := w.Flush()
+	return err
+}
+
+func (w *EventWriter) flushLogged() {
+	partitions, err := w.Flush()
+	if err != nil {
+		slog.Error("event_export_failed", "dir", w.dir, "error", err)
+	}
+	for _, partition := range partitions {
+		slog.Debug("event_export_written", "path", partition.Path, "rows", partition.Rows)
+	}
+}
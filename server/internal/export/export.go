@@ -0,0 +1,88 @@
+// Package export writes Clockify time entries and CCWS events as Parquet datasets, for data
+// teams loading the history into DuckDB, Spark or a warehouse.
+//
+// Datasets are directories of files partitioned by month in the Hive layout, e.g.
+// time_entries/month=2024-05/data.parquet, which the engines read as a month column:
+//
+//	SELECT month, sum(duration_ms) / 3.6e6 AS hours
+//	FROM read_parquet('export/time_entries/*/*.parquet', hive_partitioning = true)
+//	GROUP BY month
+//
+// Times are UTC timestamps and durations integer milliseconds.
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/parquet"
+)
+
+// Datasets, the directories under the export directory
+const (
+	TimeEntriesDataset = "time_entries"
+	EventsDataset      = "events"
+)
+
+// monthLayout formats the month of a partition
+const monthLayout = "2006-01"
+
+// Partition is a file written to a dataset
+type Partition struct {
+	Month time.Time // The first instant of the month, UTC
+	Path  string
+	Rows  int
+}
+
+// monthOf returns the first instant of the UTC month of t
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// partitionDir returns the directory of the month's partition of the dataset
+func partitionDir(dir, dataset string, month time.Time) string {
+	return filepath.Join(dir, dataset, "month="+month.Format(monthLayout))
+}
+
+// writeFile writes the rows to a Parquet file at path, replacing it only once complete so
+// readers never see a partial file
+func writeFile(path string, columns []parquet.Column, rows [][]any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*.parquet")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w, err := parquet.NewWriter(f, columns)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.Write(row...); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// optional returns nil for the zero value, a null of an optional column
+func optional[T comparable](v T) any {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,248 @@
+package export
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/parquet"
+)
+
+// TimeEntryColumns is the schema of the time entries dataset, the names of the projects, tags
+// and users denormalized next to their IDs
+var TimeEntryColumns = []parquet.Column{
+	{Name: "id", Kind: parquet.String},
+	{Name: "workspace_id", Kind: parquet.String},
+	{Name: "user_id", Kind: parquet.String},
+	{Name: "user_name", Kind: parquet.String, Optional: true},
+	{Name: "project_id", Kind: parquet.String, Optional: true},
+	{Name: "project_name", Kind: parquet.String, Optional: true},
+	{Name: "client_id", Kind: parquet.String, Optional: true},
+	{Name: "client_name", Kind: parquet.String, Optional: true},
+	{Name: "task_id", Kind: parquet.String, Optional: true},
+	{Name: "description", Kind: parquet.String},
+	{Name: "tag_ids", Kind: parquet.StringList},
+	{Name: "tag_names", Kind: parquet.StringList},
+	{Name: "billable", Kind: parquet.Bool},
+	{Name: "locked", Kind: parquet.Bool},
+	{Name: "start", Kind: parquet.Timestamp},
+	{Name: "end", Kind: parquet.Timestamp, Optional: true}, // Null while the timer runs
+	{Name: "duration_ms", Kind: parquet.Int64, Optional: true},
+	// Effective hourly rate in the smallest currency unit, when Clockify reports it
+	{Name: "hourly_rate", Kind: parquet.Int64, Optional: true},
+	{Name: "currency", Kind: parquet.String, Optional: true},
+}
+
+// Option configures TimeEntries
+type Option func(*options)
+
+type options struct {
+	users      []clockify.User
+	start, end *time.Time
+}
+
+// WithUsers limits the export to the entries of the given users. By default the entries of
+// every workspace user are exported, which requires admin rights.
+func WithUsers(users ...clockify.User) Option {
+	return func(o *options) {
+		o.users = users
+	}
+}
+
+// WithPeriod limits the export to the months from the one of start to the one of end,
+// exclusive. Partitions are only ever written whole, so a period is rounded out to months.
+func WithPeriod(start, end time.Time) Option {
+	return func(o *options) {
+		start, end = monthOf(start), monthOf(end.Add(-time.Nanosecond)).AddDate(0, 1, 0)
+		o.start, o.end = &start, &end
+	}
+}
+
+// TimeEntries exports the time entries of the workspace to the time entries dataset under
+// dir, one partition per month of their start. The partitions of the exported months are
+// replaced, and those of months left without entries removed.
+func TimeEntries(client *clockify.APIClient, workspaceID, dir string, opts ...Option) ([]Partition, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	users := o.users
+	if users == nil {
+		var err error
+		if users, err = clockify.Collect(client.IterWorkspaceUsers(workspaceID)); err != nil {
+			return nil, fmt.Errorf("failed to read users: %w", err)
+		}
+	}
+	lookup, err := NewLookup(client, workspaceID, users)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make(map[time.Time][]clockify.TimeEntry)
+	for _, user := range users {
+		entries, err := clockify.Collect(client.IterTimeEntries(workspaceID, user.ID, o.start, o.end))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read time entries of user %s: %w", user.ID, err)
+		}
+		for _, entry := range entries {
+			// Entries starting right on the end would fill a partition of a month not exported whole
+			if entry.TimeInterval == nil || o.end != nil && !entry.TimeInterval.Start.Before(*o.end) {
+				continue
+			}
+			month := monthOf(entry.TimeInterval.Start)
+			months[month] = append(months[month], entry)
+		}
+	}
+
+	var partitions []Partition
+	for month, rows := range months {
+		partitions = append(partitions, Partition{
+			Month: month,
+			Path:  filepath.Join(partitionDir(dir, TimeEntriesDataset, month), "data.parquet"),
+			Rows:  len(rows),
+		})
+	}
+	slices.SortFunc(partitions, func(a, b Partition) int { return a.Month.Compare(b.Month) })
+
+	for _, partition := range partitions {
+		entries := months[partition.Month]
+		// Sorted by start, so that readers filtering on it skip most row groups
+		slices.SortFunc(entries, func(a, b clockify.TimeEntry) int {
+			return cmp.Or(a.TimeInterval.Start.Compare(b.TimeInterval.Start), strings.Compare(a.ID, b.ID))
+		})
+		rows := make([][]any, len(entries))
+		for i, entry := range entries {
+			rows[i] = lookup.TimeEntryRow(entry)
+		}
+		if err := writeFile(partition.Path, TimeEntryColumns, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := removeStale(filepath.Join(dir, TimeEntriesDataset), months, o.start, o.end); err != nil {
+		return nil, err
+	}
+	return partitions, nil
+}
+
+// Lookup resolves the users, projects and tags time entries refer to, to denormalize their
+// names into the rows
+type Lookup struct {
+	users    map[string]clockify.User
+	projects []clockify.Project
+	byID     map[string]clockify.Project
+	tags     map[string]string
+}
+
+// NewLookup reads the projects and tags of the workspace, those of the given users resolved too
+func NewLookup(client *clockify.APIClient, workspaceID string, users []clockify.User) (*Lookup, error) {
+	projects, err := clockify.Collect(client.IterProjects(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projects: %w", err)
+	}
+	tags, err := clockify.Collect(client.IterTags(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	l := &Lookup{
+		users:    make(map[string]clockify.User, len(users)),
+		projects: projects,
+		byID:     make(map[string]clockify.Project, len(projects)),
+		tags:     make(map[string]string, len(tags)),
+	}
+	for _, user := range users {
+		l.users[user.ID] = user
+	}
+	for _, project := range projects {
+		l.byID[project.ID] = project
+	}
+	for _, tag := range tags {
+		l.tags[tag.ID] = tag.Name
+	}
+	return l, nil
+}
+
+// Projects returns the projects of the workspace
+func (l *Lookup) Projects() []clockify.Project {
+	return l.projects
+}
+
+// TimeEntryRow returns the values of the entry in the order of TimeEntryColumns
+func (l *Lookup) TimeEntryRow(entry clockify.TimeEntry) []any {
+	project := l.byID[entry.ProjectID]
+	tagIDs := entry.TagIDs
+	if tagIDs == nil {
+		tagIDs = []string{}
+	}
+	names := make([]string, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		// Tags deleted since keep their ID alone
+		if name, ok := l.tags[id]; ok {
+			names = append(names, name)
+		}
+	}
+
+	var end, duration, rate, currency any
+	if entry.TimeInterval.End != nil {
+		end = *entry.TimeInterval.End
+		duration = entry.TimeInterval.End.Sub(entry.TimeInterval.Start).Milliseconds()
+	}
+	if entry.HourlyRate != nil {
+		rate, currency = entry.HourlyRate.Amount, optional(entry.HourlyRate.Currency)
+	}
+
+	return []any{
+		entry.ID,
+		entry.WorkspaceID,
+		entry.UserID,
+		optional(l.users[entry.UserID].Name),
+		optional(entry.ProjectID),
+		optional(project.Name),
+		optional(project.ClientID),
+		optional(project.ClientName),
+		optional(entry.TaskID),
+		entry.Description,
+		tagIDs,
+		names,
+		entry.Billable,
+		entry.IsLocked,
+		entry.TimeInterval.Start,
+		end,
+		duration,
+		rate,
+		currency,
+	}
+}
+
+// removeStale removes the partitions of the dataset within the period, all when unbounded,
+// which were not just written
+func removeStale[T any](datasetDir string, written map[time.Time]T, start, end *time.Time) error {
+	dirs, err := os.ReadDir(datasetDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, d := range dirs {
+		name, ok := strings.CutPrefix(d.Name(), "month=")
+		if !ok || !d.IsDir() {
+			continue
+		}
+		month, err := time.Parse(monthLayout, name)
+		if err != nil {
+			continue
+		}
+		if _, ok := written[month]; ok || start != nil && month.Before(*start) || end != nil && !month.Before(*end) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(datasetDir, d.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
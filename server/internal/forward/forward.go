@@ -0,0 +1,190 @@
+// Package forward re-publishes events to downstream webhooks, turning the server into a
+// Clockify event router for other systems.
+//
+// Every delivery carries the headers
+//
+//	X-CCWS-Event:     the event type, e.g. TIMER_STOPPED
+//	X-CCWS-Delivery:  a unique delivery ID, the same across retries
+//	X-CCWS-Timestamp: Unix seconds the delivery was created at
+//	X-CCWS-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">, when the target has a secret
+package forward
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+const (
+	defaultAttempts = 5
+	defaultTimeout  = 10 * time.Second
+)
+
+// Target is a downstream webhook
+type Target struct {
+	Name        string
+	URL         string
+	Secret      string
+	Events      []clockify.WebhookEvent // Empty means all
+	Template    *template.Template      // Nil sends the envelope as JSON
+	ContentType string
+}
+
+// accepts reports whether the target subscribed to the event type
+func (t Target) accepts(eventType clockify.WebhookEvent) bool {
+	return len(t.Events) == 0 || slices.Contains(t.Events, eventType)
+}
+
+// Option configures a Forwarder
+type Option func(*Forwarder)
+
+// WithAttempts sets the total delivery attempts for retryable failures, 1 disables retries
+func WithAttempts(attempts int) Option {
+	return func(f *Forwarder) {
+		f.attempts = attempts
+	}
+}
+
+// WithTransport replaces the transport deliveries are sent with, retries wrap it
+func WithTransport(transport http.RoundTripper) Option {
+	return func(f *Forwarder) {
+		f.transport = transport
+	}
+}
+
+// Forwarder delivers events to its targets in the background
+type Forwarder struct {
+	targets   []Target
+	attempts  int
+	transport http.RoundTripper
+	client    *http.Client
+
+	wg sync.WaitGroup
+}
+
+func New(targets []Target, opts ...Option) *Forwarder {
+	f := &Forwarder{targets: targets, attempts: defaultAttempts, transport: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	f.client = clockify.RetryingClient(f.transport, f.attempts, defaultTimeout*time.Duration(f.attempts))
+	return f
+}
+
+// Handle starts delivering the event to every subscribed target and returns without waiting,
+// so slow targets do not hold up the webhook response. It is an events.HandlerFunc.
+func (f *Forwarder) Handle(ctx context.Context, event events.Event) error {
+	envelope := events.NewEnvelope(event)
+
+	var errs []error
+	for _, target := range f.targets {
+		if !target.accepts(event.Type) {
+			continue
+		}
+
+		body, err := render(target, envelope)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to render payload for %s: %w", target.Name, err))
+			continue
+		}
+
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			// The delivery outlives the incoming request
+			f.deliver(context.WithoutCancel(ctx), target, envelope, body)
+		}()
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("forwarding %s: %w", event.Type, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Wait blocks until the deliveries in flight have finished, e.g. on shutdown
+func (f *Forwarder) Wait() {
+	f.wg.Wait()
+}
+
+func (f *Forwarder) deliver(ctx context.Context, target Target, envelope events.Envelope, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// Receivers drop repeated deliveries by their ID, so failures of unknown outcome are retried
+	req, err := http.NewRequestWithContext(clockify.Idempotent(ctx), http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("forward_failed", "target", target.Name, "delivery_id", envelope.ID, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", target.ContentType)
+	req.Header.Set("X-CCWS-Event", string(envelope.Type))
+	req.Header.Set("X-CCWS-Delivery", envelope.ID)
+	req.Header.Set("X-CCWS-Timestamp", timestamp)
+	if target.Secret != "" {
+		req.Header.Set("X-CCWS-Signature", "sha256="+Sign(target.Secret, timestamp, body))
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		slog.Error("forward_failed", "target", target.Name, "delivery_id", envelope.ID, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		slog.Error("forward_rejected", "target", target.Name, "delivery_id", envelope.ID, "status", resp.StatusCode)
+		return
+	}
+	slog.Debug("forward_delivered", "target", target.Name, "delivery_id", envelope.ID, "event", envelope.Type)
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>", for receivers to verify deliveries
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func render(target Target, envelope events.Envelope) ([]byte, error) {
+	if target.Template == nil {
+		return json.Marshal(envelope)
+	}
+
+	var b bytes.Buffer
+	if err := target.Template.Execute(&b, envelope); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ParseTemplate parses a payload template with TemplateFuncs available
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs).Parse(text)
+}
+
+// TemplateFuncs are available in payload templates, e.g. {{json .Data}}
+var TemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
@@ -0,0 +1,159 @@
+// Package githook lets git hooks drive timers: it reads the repository a hook runs in, matches
+// it against the configured repositories and branches, and installs the hooks calling ccws.
+package githook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Hooks are the git hooks Install writes
+var Hooks = []string{"post-checkout", "post-commit"}
+
+// marker tells the hooks Install wrote from those of the user
+const marker = "# Installed by ccws hook install"
+
+// ErrHookExists is returned by Install for hooks it did not write, unless forced
+var ErrHookExists = errors.New("hook exists")
+
+// Repository is the working tree a hook runs in
+type Repository struct {
+	Path   string // Top-level directory, with forward slashes
+	Branch string // Empty for a detached HEAD
+}
+
+// Name is the repository's directory name, e.g. website of /home/me/src/website
+func (r Repository) Name() string {
+	return path.Base(r.Path)
+}
+
+// Inspect reads the repository of the directory and the branch checked out
+func Inspect(ctx context.Context, dir string) (Repository, error) {
+	top, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return Repository{}, fmt.Errorf("not in a git repository: %w", err)
+	}
+	repo := Repository{Path: filepath.ToSlash(top)}
+
+	// Fails quietly with status 1 for a detached HEAD
+	branch, err := git(ctx, dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return Repository{}, fmt.Errorf("failed to read the branch: %w", err)
+	}
+	repo.Branch = branch
+	return repo, nil
+}
+
+// Matches reports whether the repository and its branch match the globs. A repository glob
+// with a slash matches the path, one without the directory name. An empty branch glob
+// matches every branch, detached HEADs included.
+func Matches(repoGlob, branchGlob string, repo Repository) bool {
+	target := repo.Name()
+	if strings.Contains(repoGlob, "/") {
+		target = repo.Path
+	}
+	if ok, _ := path.Match(repoGlob, target); !ok {
+		return false
+	}
+	if branchGlob == "" {
+		return true
+	}
+	ok, _ := path.Match(branchGlob, repo.Branch)
+	return ok && repo.Branch != ""
+}
+
+// CommitSubject returns the subject of the commit checked out, i.e. the one just made in a
+// post-commit hook
+func CommitSubject(ctx context.Context, dir string) (string, error) {
+	subject, err := git(ctx, dir, "log", "-1", "--format=%s")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the commit: %w", err)
+	}
+	return subject, nil
+}
+
+// Annotate appends the commit subject to a description, unless it already mentions it
+func Annotate(description, subject string) string {
+	switch {
+	case subject == "" || strings.Contains(description, subject):
+		return description
+	case description == "":
+		return subject
+	default:
+		return description + "; " + subject
+	}
+}
+
+// Install writes the Hooks of the repository of the directory, each running the command with
+// the hook's name and arguments, e.g. ["/usr/local/bin/ccws", "hook"]. Hooks written by the
+// user are kept with ErrHookExists unless forced. A failing command never fails the git
+// command. It returns the paths of the hooks written.
+func Install(ctx context.Context, dir string, command []string, force bool) ([]string, error) {
+	hooksDir, err := git(ctx, dir, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+
+	var (
+		written []string
+		errs    []error
+	)
+	for _, hook := range Hooks {
+		file := filepath.Join(hooksDir, hook)
+		existing, err := os.ReadFile(file)
+		if err == nil && !bytes.Contains(existing, []byte(marker)) && !force {
+			errs = append(errs, fmt.Errorf("%w: %s, pass --force to replace it", ErrHookExists, file))
+			continue
+		}
+
+		script := fmt.Sprintf("#!/bin/sh\n%s\n%s %s \"$@\" || true\n", marker, strings.Join(quoted, " "), hook)
+		if err := os.WriteFile(file, []byte(script), 0o755); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// WriteFile keeps the mode of a replaced hook
+		if err := os.Chmod(file, 0o755); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		written = append(written, file)
+	}
+	return written, errors.Join(errs...)
+}
+
+// shellQuote quotes the argument for sh
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// git runs git in the directory and returns its trimmed output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,139 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Activity kinds
+const (
+	KindCommit = "commit"
+	KindReview = "review"
+)
+
+// Activity is a single commit or pull request review
+type Activity struct {
+	Kind    string
+	Repo    string // owner/name
+	Time    time.Time
+	Summary string // Commit subject or pull request title
+	URL     string
+}
+
+// Activity returns the login's commits and pull request reviews on the day, oldest first.
+// Commits come from the search API, which only indexes default branches; reviews from the
+// events API, which covers the last 90 days.
+func (c *Client) Activity(ctx context.Context, login string, day time.Time) ([]Activity, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	commits, err := c.commits(ctx, login, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search commits: %w", err)
+	}
+	reviews, err := c.reviews(ctx, login, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	activities := append(commits, reviews...)
+	slices.SortFunc(activities, func(a, b Activity) int { return a.Time.Compare(b.Time) })
+	return activities, nil
+}
+
+type searchCommits struct {
+	Items []struct {
+		HTMLURL string `json:"html_url"`
+		Commit  struct {
+			Message string `json:"message"`
+			Author  struct {
+				Date time.Time `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	} `json:"items"`
+}
+
+func (c *Client) commits(ctx context.Context, login string, start, end time.Time) ([]Activity, error) {
+	// The search is by UTC date, so both days the local day spans are searched and filtered below
+	q := fmt.Sprintf("author:%s author-date:%s..%s", login, start.UTC().Format(time.DateOnly), end.UTC().Format(time.DateOnly))
+
+	var activities []Activity
+	seen := make(map[string]bool)
+	for page := 1; page <= maxPages; page++ {
+		var result searchCommits
+		query := url.Values{"q": {q}, "sort": {"author-date"}, "per_page": {"100"}, "page": {strconv.Itoa(page)}}
+		if err := c.get(ctx, "/search/commits", query, &result); err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			at := item.Commit.Author.Date
+			// The same commit shows up once per fork or branch it was pushed to
+			if at.Before(start) || !at.Before(end) || seen[item.HTMLURL] {
+				continue
+			}
+			seen[item.HTMLURL] = true
+
+			subject, _, _ := strings.Cut(item.Commit.Message, "\n")
+			activities = append(activities, Activity{Kind: KindCommit, Repo: item.Repository.FullName, Time: at, Summary: strings.TrimSpace(subject), URL: item.HTMLURL})
+		}
+		if len(result.Items) < 100 {
+			break
+		}
+	}
+	return activities, nil
+}
+
+type event struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Repo      struct {
+		Name string `json:"name"`
+	} `json:"repo"`
+	Payload struct {
+		Review *struct {
+			HTMLURL string `json:"html_url"`
+		} `json:"review"`
+		PullRequest *struct {
+			Number int    `json:"number"`
+			Title  string `json:"title"`
+		} `json:"pull_request"`
+	} `json:"payload"`
+}
+
+func (c *Client) reviews(ctx context.Context, login string, start, end time.Time) ([]Activity, error) {
+	var activities []Activity
+	for page := 1; page <= maxPages; page++ {
+		var events []event
+		query := url.Values{"per_page": {"100"}, "page": {strconv.Itoa(page)}}
+		if err := c.get(ctx, "/users/"+url.PathEscape(login)+"/events", query, &events); err != nil {
+			return nil, err
+		}
+
+		for _, e := range events {
+			if e.Type != "PullRequestReviewEvent" || e.Payload.PullRequest == nil || e.CreatedAt.Before(start) || !e.CreatedAt.Before(end) {
+				continue
+			}
+			summary := fmt.Sprintf("%s (#%d)", e.Payload.PullRequest.Title, e.Payload.PullRequest.Number)
+			activity := Activity{Kind: KindReview, Repo: e.Repo.Name, Time: e.CreatedAt, Summary: summary}
+			if e.Payload.Review != nil {
+				activity.URL = e.Payload.Review.HTMLURL
+			}
+			activities = append(activities, activity)
+		}
+
+		// Events are newest first, older pages cannot contain the day anymore
+		if len(events) < 100 || events[len(events)-1].CreatedAt.Before(start) {
+			break
+		}
+	}
+	return activities, nil
+}
@@ -0,0 +1,94 @@
+// Package github reads a user's GitHub activity, commits and pull request reviews, and turns
+// it into draft time entries.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the REST API of github.com, GitHub Enterprise serves it under /api/v3
+const DefaultBaseURL = "https://api.github.com"
+
+// maxPages bounds the pagination, the events API returns at most 300 events anyway
+const maxPages = 10
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL points the client at a GitHub Enterprise installation
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, e.g. to set timeouts
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// Client calls the GitHub REST API with a personal access token
+type Client struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+func NewClient(token string, opts ...Option) *Client {
+	c := &Client{token: token, baseURL: DefaultBaseURL, client: &http.Client{Timeout: 30 * time.Second}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// get decodes the JSON response of a GET request into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GitHub %s: unexpected status %s: %s", path, resp.Status, bytes.TrimSpace(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Login returns the login of the token's owner
+func (c *Client) Login(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.get(ctx, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
@@ -0,0 +1,128 @@
+package github
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Defaults of SuggestOptions
+const (
+	DefaultLead           = 30 * time.Minute
+	DefaultMaxGap         = 2 * time.Hour
+	DefaultReviewDuration = 30 * time.Minute
+)
+
+// maxDescription keeps descriptions readable, Clockify accepts far longer ones
+const maxDescription = 200
+
+// Suggestion is a draft time entry covering a session of work on a repository
+type Suggestion struct {
+	Repo        string
+	Project     string // Mapped Clockify project name, empty when the repository is not mapped
+	Start       time.Time
+	End         time.Time
+	Description string
+	Activities  []Activity
+}
+
+// Duration is the length of the draft entry
+func (s Suggestion) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SuggestOptions tune how activity is turned into entries. Zero values use the defaults.
+type SuggestOptions struct {
+	// Repository owner/name to Clockify project name, matched case-insensitively.
+	// A bare repository name matches that repository of every owner.
+	Projects map[string]string
+	// Time credited before the first commit of a session
+	Lead time.Duration
+	// Commits further apart than this start a new session
+	MaxGap time.Duration
+	// Time credited for a review, ending when it was submitted
+	ReviewDuration time.Duration
+}
+
+// Suggest groups the activities into draft entries: consecutive commits to a repository form
+// a session lasting from Lead before the first one to the last one, each review is an entry of
+// its own. The suggestions are ordered by start.
+func Suggest(activities []Activity, opts SuggestOptions) []Suggestion {
+	lead := cmp.Or(opts.Lead, DefaultLead)
+	maxGap := cmp.Or(opts.MaxGap, DefaultMaxGap)
+	review := cmp.Or(opts.ReviewDuration, DefaultReviewDuration)
+
+	var suggestions []Suggestion
+	open := make(map[string]int) // Repository to the index of its latest commit session
+
+	for _, activity := range activities {
+		switch activity.Kind {
+		case KindReview:
+			suggestions = append(suggestions, Suggestion{
+				Repo:        activity.Repo,
+				Project:     projectOf(opts.Projects, activity.Repo),
+				Start:       activity.Time.Add(-review),
+				End:         activity.Time,
+				Description: truncate("Review: " + activity.Summary),
+				Activities:  []Activity{activity},
+			})
+		case KindCommit:
+			if i, ok := open[activity.Repo]; ok && activity.Time.Sub(suggestions[i].End) <= maxGap {
+				suggestions[i].End = activity.Time
+				suggestions[i].Activities = append(suggestions[i].Activities, activity)
+				continue
+			}
+			open[activity.Repo] = len(suggestions)
+			suggestions = append(suggestions, Suggestion{
+				Repo:       activity.Repo,
+				Project:    projectOf(opts.Projects, activity.Repo),
+				Start:      activity.Time.Add(-lead),
+				End:        activity.Time,
+				Activities: []Activity{activity},
+			})
+		}
+	}
+
+	for i := range suggestions {
+		if suggestions[i].Description == "" {
+			suggestions[i].Description = describeCommits(suggestions[i].Activities)
+		}
+	}
+	slices.SortStableFunc(suggestions, func(a, b Suggestion) int { return a.Start.Compare(b.Start) })
+	return suggestions
+}
+
+// describeCommits joins the distinct commit subjects, e.g. "Fix login; Add tests"
+func describeCommits(commits []Activity) string {
+	var subjects []string
+	for _, commit := range commits {
+		if commit.Summary != "" && !slices.Contains(subjects, commit.Summary) {
+			subjects = append(subjects, commit.Summary)
+		}
+	}
+	return truncate(strings.Join(subjects, "; "))
+}
+
+func projectOf(projects map[string]string, repo string) string {
+	_, name, _ := strings.Cut(repo, "/")
+	for key, project := range projects {
+		if strings.EqualFold(key, repo) {
+			return project
+		}
+	}
+	for key, project := range projects {
+		if strings.EqualFold(key, name) {
+			return project
+		}
+	}
+	return ""
+}
+
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxDescription {
+		return s
+	}
+	return string(runes[:maxDescription-1]) + "…"
+}
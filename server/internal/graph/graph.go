@@ -0,0 +1,59 @@
+// Package graph serves a GraphQL schema over workspaces, projects, tasks and time entries,
+// resolved through caches so nested queries do not multiply Clockify requests.
+package graph
+
+import (
+	_ "embed"
+	"net/http"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/mirror"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxDepth bounds the nesting of queries, e.g. project > client > projects > tasks
+const maxDepth = 8
+
+// Handler serves GraphQL queries over HTTP (POST with {"query", "variables"})
+type Handler struct {
+	loader *loader
+	relay  *relay.Handler
+}
+
+// Option configures a Handler
+type Option func(*loader)
+
+// WithMirror resolves the projects, tags and the user's entries of the mirrored workspace from
+// the mirror instead of Clockify. Other workspaces are still fetched.
+func WithMirror(store *mirror.Store, workspaceID string) Option {
+	return func(l *loader) {
+		l.mirror, l.mirrored = store, workspaceID
+	}
+}
+
+// NewHandler parses the schema, it panics if the resolvers do not match it
+func NewHandler(client *clockify.APIClient, workspace *clockify.Workspace, user *clockify.User, ttl time.Duration, opts ...Option) *Handler {
+	l := newLoader(client, user.ID, ttl)
+	for _, opt := range opts {
+		opt(l)
+	}
+	root := &rootResolver{loader: l, workspace: workspace, user: user}
+
+	parsed := graphql.MustParseSchema(schema, root, graphql.MaxDepth(maxDepth))
+	return &Handler{loader: l, relay: &relay.Handler{Schema: parsed}}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.relay.ServeHTTP(w, r)
+}
+
+// Invalidate drops the cached Clockify data, e.g. when a webhook reports a change
+func (h *Handler) Invalidate() {
+	h.loader.invalidate()
+}
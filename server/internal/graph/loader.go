@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/cache"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/mirror"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// entriesKey identifies the cached time entries of a workspace period
+type entriesKey struct {
+	workspaceID string
+	period      report.Period
+}
+
+// tasksKey identifies the cached tasks of a project
+type tasksKey struct {
+	workspaceID, projectID string
+}
+
+// loader fetches Clockify data through caches, so nested fields resolve without repeating
+// requests within and across queries
+type loader struct {
+	client *clockify.APIClient
+	userID string
+
+	// mirror serves the data of the mirrored workspace when set
+	mirror   *mirror.Store
+	mirrored string
+
+	workspaces *cache.Cache[string, []clockify.Workspace]
+	projects   *cache.Cache[string, []clockify.Project]
+	clients    *cache.Cache[string, []clockify.Client]
+	tags       *cache.Cache[string, []clockify.Tag]
+	tasks      *cache.Cache[tasksKey, []clockify.Task]
+	entries    *cache.Cache[entriesKey, []clockify.TimeEntry]
+}
+
+func newLoader(client *clockify.APIClient, userID string, ttl time.Duration) *loader {
+	return &loader{
+		client:     client,
+		userID:     userID,
+		workspaces: cache.New[string, []clockify.Workspace](ttl),
+		projects:   cache.New[string, []clockify.Project](ttl),
+		clients:    cache.New[string, []clockify.Client](ttl),
+		tags:       cache.New[string, []clockify.Tag](ttl),
+		tasks:      cache.New[tasksKey, []clockify.Task](ttl),
+		entries:    cache.New[entriesKey, []clockify.TimeEntry](ttl),
+	}
+}
+
+// invalidate drops every cached value
+func (l *loader) invalidate() {
+	l.workspaces.Clear()
+	l.projects.Clear()
+	l.clients.Clear()
+	l.tags.Clear()
+	l.tasks.Clear()
+	l.entries.Clear()
+}
+
+// fromMirror reports whether the workspace data is read from the mirror
+func (l *loader) fromMirror(workspaceID string) bool {
+	return l.mirror != nil && workspaceID == l.mirrored
+}
+
+func (l *loader) Workspaces(ctx context.Context) ([]clockify.Workspace, error) {
+	return l.workspaces.GetOrLoad("", func() ([]clockify.Workspace, error) {
+		return l.client.WithContext(ctx).GetWorkspaces()
+	})
+}
+
+func (l *loader) Projects(ctx context.Context, workspaceID string) ([]clockify.Project, error) {
+	if l.fromMirror(workspaceID) {
+		return l.mirror.Projects(workspaceID)
+	}
+	return l.projects.GetOrLoad(workspaceID, func() ([]clockify.Project, error) {
+		return clockify.Collect(l.client.WithContext(ctx).IterProjects(workspaceID))
+	})
+}
+
+func (l *loader) Clients(ctx context.Context, workspaceID string) ([]clockify.Client, error) {
+	return l.clients.GetOrLoad(workspaceID, func() ([]clockify.Client, error) {
+		return clockify.Collect(l.client.WithContext(ctx).IterClients(workspaceID))
+	})
+}
+
+func (l *loader) Tags(ctx context.Context, workspaceID string) ([]clockify.Tag, error) {
+	if l.fromMirror(workspaceID) {
+		return l.mirror.Tags(workspaceID)
+	}
+	return l.tags.GetOrLoad(workspaceID, func() ([]clockify.Tag, error) {
+		return clockify.Collect(l.client.WithContext(ctx).IterTags(workspaceID))
+	})
+}
+
+func (l *loader) Tasks(ctx context.Context, workspaceID, projectID string) ([]clockify.Task, error) {
+	return l.tasks.GetOrLoad(tasksKey{workspaceID, projectID}, func() ([]clockify.Task, error) {
+		return clockify.Collect(l.client.WithContext(ctx).IterProjectTasks(workspaceID, projectID))
+	})
+}
+
+// TimeEntries returns the user's entries in the period, newest first
+func (l *loader) TimeEntries(ctx context.Context, workspaceID string, period report.Period) ([]clockify.TimeEntry, error) {
+	if l.fromMirror(workspaceID) {
+		return l.mirror.TimeEntries(workspaceID, l.userID, period.Start, period.End)
+	}
+	return l.entries.GetOrLoad(entriesKey{workspaceID, period}, func() ([]clockify.TimeEntry, error) {
+		return report.FetchEntries(l.client.WithContext(ctx), workspaceID, l.userID, period)
+	})
+}
+
+// RunningTimer is never cached
+func (l *loader) RunningTimer(ctx context.Context, workspaceID string) (*clockify.TimeEntry, error) {
+	return l.client.WithContext(ctx).GetRunningTimeEntry(workspaceID, l.userID)
+}
+
+// find returns the first item with the given ID
+func find[T any](items []T, id func(T) string, want string) (T, bool) {
+	for _, item := range items {
+		if id(item) == want {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
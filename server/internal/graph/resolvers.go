@@ -0,0 +1,435 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/Hukyl/CCWS/internal/api"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// rootResolver resolves the Query type
+type rootResolver struct {
+	loader    *loader
+	workspace *clockify.Workspace
+	user      *clockify.User
+}
+
+func (r *rootResolver) Me() *userResolver {
+	return &userResolver{r.user}
+}
+
+func (r *rootResolver) Workspaces(ctx context.Context) ([]*workspaceResolver, error) {
+	workspaces, err := r.loader.Workspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*workspaceResolver, len(workspaces))
+	for i, w := range workspaces {
+		out[i] = &workspaceResolver{loader: r.loader, workspace: w}
+	}
+	return out, nil
+}
+
+func (r *rootResolver) Workspace(ctx context.Context, args struct {
+	ID   *graphql.ID
+	Name *string
+}) (*workspaceResolver, error) {
+	if args.ID == nil && args.Name == nil {
+		return &workspaceResolver{loader: r.loader, workspace: *r.workspace}, nil
+	}
+
+	workspaces, err := r.loader.Workspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range workspaces {
+		if args.ID != nil && w.ID == string(*args.ID) || args.Name != nil && w.Name == *args.Name {
+			return &workspaceResolver{loader: r.loader, workspace: w}, nil
+		}
+	}
+	return nil, nil
+}
+
+type userResolver struct {
+	user *clockify.User
+}
+
+func (r *userResolver) ID() graphql.ID { return graphql.ID(r.user.ID) }
+func (r *userResolver) Name() string   { return r.user.Name }
+func (r *userResolver) Email() string  { return r.user.Email }
+
+func (r *userResolver) TimeZone() *string {
+	if r.user.Settings == nil || r.user.Settings.TimeZone == "" {
+		return nil
+	}
+	return &r.user.Settings.TimeZone
+}
+
+// periodArgs are the arguments of fields taking a period
+type periodArgs struct {
+	Period string
+	Offset int32
+	From   *string
+	To     *string
+}
+
+func (args periodArgs) resolve() (report.Period, error) {
+	now := time.Now()
+	if args.From != nil || args.To != nil {
+		var from, to string
+		if args.From != nil {
+			from = *args.From
+		}
+		if args.To != nil {
+			to = *args.To
+		}
+		return api.DateRange(from, to, now.Location())
+	}
+	return api.PeriodOf(strings.ToLower(args.Period), int(args.Offset), now)
+}
+
+type workspaceResolver struct {
+	loader    *loader
+	workspace clockify.Workspace
+}
+
+func (r *workspaceResolver) ID() graphql.ID { return graphql.ID(r.workspace.ID) }
+func (r *workspaceResolver) Name() string   { return r.workspace.Name }
+
+func (r *workspaceResolver) Projects(ctx context.Context, args struct{ Archived bool }) ([]*projectResolver, error) {
+	projects, err := r.loader.Projects(ctx, r.workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*projectResolver
+	for _, p := range projects {
+		if p.Archived == args.Archived {
+			out = append(out, r.project(p))
+		}
+	}
+	return out, nil
+}
+
+func (r *workspaceResolver) Project(ctx context.Context, args struct {
+	ID   *graphql.ID
+	Name *string
+}) (*projectResolver, error) {
+	projects, err := r.loader.Projects(ctx, r.workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if args.ID != nil && p.ID == string(*args.ID) || args.Name != nil && p.Name == *args.Name {
+			return r.project(p), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *workspaceResolver) project(p clockify.Project) *projectResolver {
+	return &projectResolver{loader: r.loader, workspaceID: r.workspace.ID, project: p}
+}
+
+func (r *workspaceResolver) Clients(ctx context.Context) ([]*clientResolver, error) {
+	clients, err := r.loader.Clients(ctx, r.workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*clientResolver, len(clients))
+	for i, c := range clients {
+		out[i] = &clientResolver{loader: r.loader, workspaceID: r.workspace.ID, client: c}
+	}
+	return out, nil
+}
+
+func (r *workspaceResolver) Tags(ctx context.Context) ([]*tagResolver, error) {
+	tags, err := r.loader.Tags(ctx, r.workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*tagResolver, len(tags))
+	for i, t := range tags {
+		out[i] = &tagResolver{t}
+	}
+	return out, nil
+}
+
+func (r *workspaceResolver) TimeEntries(ctx context.Context, args periodArgs) ([]*timeEntryResolver, error) {
+	return timeEntries(ctx, r.loader, r.workspace.ID, args, "")
+}
+
+func (r *workspaceResolver) RunningTimer(ctx context.Context) (*timeEntryResolver, error) {
+	entry, err := r.loader.RunningTimer(ctx, r.workspace.ID)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	return &timeEntryResolver{loader: r.loader, entry: *entry, now: time.Now()}, nil
+}
+
+func (r *workspaceResolver) Summary(ctx context.Context, args periodArgs) (*summaryResolver, error) {
+	period, err := args.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := r.loader.TimeEntries(ctx, r.workspace.ID, period)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := r.loader.Projects(ctx, r.workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(projects))
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+	summary := report.Summarize(period, entries, names, time.Now())
+	return &summaryResolver{workspace: r, summary: summary}, nil
+}
+
+// timeEntries resolves the user's entries in a period, only those of projectID when it is set
+func timeEntries(ctx context.Context, l *loader, workspaceID string, args periodArgs, projectID string) ([]*timeEntryResolver, error) {
+	period, err := args.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := l.TimeEntries(ctx, workspaceID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	out := make([]*timeEntryResolver, 0, len(entries))
+	for _, e := range entries {
+		if projectID == "" || e.ProjectID == projectID {
+			out = append(out, &timeEntryResolver{loader: l, entry: e, now: now})
+		}
+	}
+	return out, nil
+}
+
+type projectResolver struct {
+	loader      *loader
+	workspaceID string
+	project     clockify.Project
+}
+
+func (r *projectResolver) ID() graphql.ID { return graphql.ID(r.project.ID) }
+func (r *projectResolver) Name() string   { return r.project.Name }
+func (r *projectResolver) Color() string  { return r.project.Color }
+func (r *projectResolver) Note() string   { return r.project.Note }
+func (r *projectResolver) Billable() bool { return r.project.Billable }
+func (r *projectResolver) Archived() bool { return r.project.Archived }
+
+func (r *projectResolver) Client(ctx context.Context) (*clientResolver, error) {
+	if r.project.ClientID == "" {
+		return nil, nil
+	}
+
+	clients, err := r.loader.Clients(ctx, r.workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	client, ok := find(clients, func(c clockify.Client) string { return c.ID }, r.project.ClientID)
+	if !ok {
+		return nil, nil
+	}
+	return &clientResolver{loader: r.loader, workspaceID: r.workspaceID, client: client}, nil
+}
+
+func (r *projectResolver) Tasks(ctx context.Context) ([]*taskResolver, error) {
+	tasks, err := r.loader.Tasks(ctx, r.workspaceID, r.project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*taskResolver, len(tasks))
+	for i, t := range tasks {
+		out[i] = &taskResolver{loader: r.loader, workspaceID: r.workspaceID, task: t}
+	}
+	return out, nil
+}
+
+func (r *projectResolver) TimeEntries(ctx context.Context, args periodArgs) ([]*timeEntryResolver, error) {
+	return timeEntries(ctx, r.loader, r.workspaceID, args, r.project.ID)
+}
+
+// projectByID resolves a project of the workspace, nil when it is unknown
+func projectByID(ctx context.Context, l *loader, workspaceID, projectID string) (*projectResolver, error) {
+	if projectID == "" {
+		return nil, nil
+	}
+
+	projects, err := l.Projects(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	project, ok := find(projects, func(p clockify.Project) string { return p.ID }, projectID)
+	if !ok {
+		return nil, nil
+	}
+	return &projectResolver{loader: l, workspaceID: workspaceID, project: project}, nil
+}
+
+type taskResolver struct {
+	loader      *loader
+	workspaceID string
+	task        clockify.Task
+}
+
+func (r *taskResolver) ID() graphql.ID { return graphql.ID(r.task.ID) }
+func (r *taskResolver) Name() string   { return r.task.Name }
+func (r *taskResolver) Status() string { return r.task.Status }
+
+func (r *taskResolver) Project(ctx context.Context) (*projectResolver, error) {
+	return projectByID(ctx, r.loader, r.workspaceID, r.task.ProjectID)
+}
+
+type clientResolver struct {
+	loader      *loader
+	workspaceID string
+	client      clockify.Client
+}
+
+func (r *clientResolver) ID() graphql.ID { return graphql.ID(r.client.ID) }
+func (r *clientResolver) Name() string   { return r.client.Name }
+func (r *clientResolver) Archived() bool { return r.client.Archived }
+
+func (r *clientResolver) Projects(ctx context.Context) ([]*projectResolver, error) {
+	projects, err := r.loader.Projects(ctx, r.workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*projectResolver
+	for _, p := range projects {
+		if p.ClientID == r.client.ID {
+			out = append(out, &projectResolver{loader: r.loader, workspaceID: r.workspaceID, project: p})
+		}
+	}
+	return out, nil
+}
+
+type tagResolver struct {
+	tag clockify.Tag
+}
+
+func (r *tagResolver) ID() graphql.ID { return graphql.ID(r.tag.ID) }
+func (r *tagResolver) Name() string   { return r.tag.Name }
+func (r *tagResolver) Archived() bool { return r.tag.Archived }
+
+type timeEntryResolver struct {
+	loader *loader
+	entry  clockify.TimeEntry
+	now    time.Time // Running entries count up to now
+}
+
+func (r *timeEntryResolver) ID() graphql.ID      { return graphql.ID(r.entry.ID) }
+func (r *timeEntryResolver) Description() string { return r.entry.Description }
+func (r *timeEntryResolver) Billable() bool      { return r.entry.Billable }
+
+func (r *timeEntryResolver) Start() string {
+	if r.entry.TimeInterval == nil {
+		return ""
+	}
+	return r.entry.TimeInterval.Start.Format(time.RFC3339)
+}
+
+func (r *timeEntryResolver) End() *string {
+	if r.entry.TimeInterval == nil || r.entry.TimeInterval.End == nil {
+		return nil
+	}
+	end := r.entry.TimeInterval.End.Format(time.RFC3339)
+	return &end
+}
+
+func (r *timeEntryResolver) Running() bool {
+	return r.entry.TimeInterval != nil && r.entry.TimeInterval.End == nil
+}
+
+func (r *timeEntryResolver) Hours() float64 {
+	return report.EntryDuration(r.entry, r.now).Hours()
+}
+
+func (r *timeEntryResolver) Project(ctx context.Context) (*projectResolver, error) {
+	return projectByID(ctx, r.loader, r.entry.WorkspaceID, r.entry.ProjectID)
+}
+
+func (r *timeEntryResolver) Task(ctx context.Context) (*taskResolver, error) {
+	if r.entry.ProjectID == "" || r.entry.TaskID == "" {
+		return nil, nil
+	}
+
+	tasks, err := r.loader.Tasks(ctx, r.entry.WorkspaceID, r.entry.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	task, ok := find(tasks, func(t clockify.Task) string { return t.ID }, r.entry.TaskID)
+	if !ok {
+		return nil, nil
+	}
+	return &taskResolver{loader: r.loader, workspaceID: r.entry.WorkspaceID, task: task}, nil
+}
+
+func (r *timeEntryResolver) Tags(ctx context.Context) ([]*tagResolver, error) {
+	if len(r.entry.TagIDs) == 0 {
+		return []*tagResolver{}, nil
+	}
+
+	tags, err := r.loader.Tags(ctx, r.entry.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*tagResolver, 0, len(r.entry.TagIDs))
+	for _, id := range r.entry.TagIDs {
+		if tag, ok := find(tags, func(t clockify.Tag) string { return t.ID }, id); ok {
+			out = append(out, &tagResolver{tag})
+		}
+	}
+	return out, nil
+}
+
+type summaryResolver struct {
+	workspace *workspaceResolver
+	summary   *report.Summary
+}
+
+func (r *summaryResolver) Start() string          { return r.summary.Period.Start.Format(time.RFC3339) }
+func (r *summaryResolver) End() string            { return r.summary.Period.End.Format(time.RFC3339) }
+func (r *summaryResolver) Hours() float64         { return r.summary.Total.Hours() }
+func (r *summaryResolver) BillableHours() float64 { return r.summary.Billable.Hours() }
+
+func (r *summaryResolver) Projects() []*projectTotalResolver {
+	out := make([]*projectTotalResolver, len(r.summary.Projects))
+	for i := range r.summary.Projects {
+		out[i] = &projectTotalResolver{workspace: r.workspace, total: r.summary.Projects[i]}
+	}
+	return out
+}
+
+type projectTotalResolver struct {
+	workspace *workspaceResolver
+	total     report.ProjectTotal
+}
+
+func (r *projectTotalResolver) Name() string           { return r.total.Project }
+func (r *projectTotalResolver) Hours() float64         { return r.total.Duration.Hours() }
+func (r *projectTotalResolver) BillableHours() float64 { return r.total.Billable.Hours() }
+func (r *projectTotalResolver) Entries() int32         { return int32(r.total.Entries) }
+
+func (r *projectTotalResolver) Project(ctx context.Context) (*projectResolver, error) {
+	return projectByID(ctx, r.workspace.loader, r.workspace.workspace.ID, r.total.ProjectID)
+}
@@ -0,0 +1,124 @@
+// Package humantime parses the loose durations, dates and times of day people type on the command line.
+package humantime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidDuration = errors.New("invalid duration")
+	ErrInvalidDate     = errors.New("invalid date")
+	ErrInvalidClock    = errors.New("invalid time of day")
+)
+
+// durationUnits maps spelled-out units to the ones time.ParseDuration understands
+var durationUnits = strings.NewReplacer(
+	"hours", "h", "hour", "h", "hrs", "h", "hr", "h",
+	"minutes", "m", "minute", "m", "mins", "m", "min", "m",
+)
+
+// clockDurationPattern matches durations written as H:MM, e.g. 1:30
+var clockDurationPattern = regexp.MustCompile(`^(\d+):([0-5]\d)$`)
+
+// ParseDuration parses durations like "2h", "1h30m", "1.5h", "90min", "2 hours" or "1:30".
+// Only positive durations are accepted.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if m := clockDurationPattern.FindStringSubmatch(s); m != nil {
+		hours, _ := strconv.Atoi(m[1])
+		minutes, _ := strconv.Atoi(m[2])
+		return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+	}
+
+	normalized := durationUnits.Replace(strings.ReplaceAll(s, " ", ""))
+	d, err := time.ParseDuration(normalized)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+	}
+	return d, nil
+}
+
+// ParseDate parses a day relative to now: "today", "yesterday", a weekday name meaning its most
+// recent occurrence (today included), "2024-05-02" or "05-02" in the current year.
+// The result is midnight in now's location.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch s {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if weekday, ok := parseWeekday(s); ok {
+		back := (int(today.Weekday()) - int(weekday) + 7) % 7
+		return today.AddDate(0, 0, -back), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("01-02", s, now.Location()); err == nil {
+		return time.Date(now.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location()), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// clockPattern matches "14:00", "9:30", "2pm", "2:30pm" and "14h"
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::([0-5]\d))?\s*(am|pm|h)?$`)
+
+// ParseClock parses a time of day and returns the hour and minute
+func ParseClock(s string) (hour, minute int, err error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	m := clockPattern.FindStringSubmatch(s)
+	// A bare number is too ambiguous (could be a duration), so require ":" or a suffix
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidClock, s)
+	}
+
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("%w: %q", ErrInvalidClock, s)
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidClock, s)
+	}
+
+	return hour, minute, nil
+}
+
+// At returns the time of day on the given date
+func At(date time.Time, hour, minute int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location())
+}
@@ -0,0 +1,296 @@
+// Package invoice bills the billable time tracked for a client: entries are grouped per project
+// and user, priced at hourly rates, and discounted and taxed into a total.
+package invoice
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/money"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Rate is an hourly rate configured for a project, a user, or a user on a project.
+// Configured rates take precedence over the rates set in Clockify.
+type Rate struct {
+	Project string // Project name, empty for every project
+	User    string // User email or name, empty for every user
+	Amount  int64  // Per hour in the smallest unit of the invoice currency, e.g. cents
+}
+
+// Line is the billable time of a user on a project at one rate
+type Line struct {
+	ProjectID string
+	Project   string
+	UserID    string
+	User      string
+	Duration  time.Duration
+	Rate      money.Money
+	Amount    money.Money
+}
+
+// Invoice is the bill of a client over a period. Every amount is in its currency.
+type Invoice struct {
+	Number   string
+	Issuer   string
+	Client   clockify.Client
+	Period   report.Period
+	Issued   time.Time
+	Due      time.Time
+	Currency string
+	Lines    []Line // Ordered by project, then user
+
+	Subtotal        money.Money
+	DiscountPercent float64
+	Discount        money.Money
+	TaxPercent      float64
+	Tax             money.Money // Applied after the discount
+	Total           money.Money
+
+	// Billable time no rate was found for, it is not invoiced
+	Unrated time.Duration
+}
+
+// Option configures an invoice
+type Option func(*options)
+
+type options struct {
+	rates    []Rate
+	currency string
+	exchange *money.Rates
+	rounding report.Rounding
+	discount float64
+	tax      float64
+	issuer   string
+	number   string
+	dueDays  int
+}
+
+// WithRates overrides the Clockify rates. A rate for a user on a project wins over a user rate,
+// which wins over a project rate.
+func WithRates(rates ...Rate) Option {
+	return func(o *options) {
+		o.rates = append(o.rates, rates...)
+	}
+}
+
+// WithCurrency sets the currency of the amounts, the one of the first Clockify rate by default
+func WithCurrency(currency string) Option {
+	return func(o *options) {
+		o.currency = currency
+	}
+}
+
+// WithExchangeRates converts the Clockify rates in other currencies into the invoice currency.
+// Without them, such rates fail the invoice.
+func WithExchangeRates(rates *money.Rates) Option {
+	return func(o *options) {
+		o.exchange = rates
+	}
+}
+
+// WithRounding bills the tracked time rounded, e.g. every entry up to 15 minutes
+func WithRounding(rounding report.Rounding) Option {
+	return func(o *options) {
+		o.rounding = rounding
+	}
+}
+
+// WithDiscount applies a discount percentage to the subtotal
+func WithDiscount(percent float64) Option {
+	return func(o *options) {
+		o.discount = percent
+	}
+}
+
+// WithTax applies a tax percentage to the discounted subtotal
+func WithTax(percent float64) Option {
+	return func(o *options) {
+		o.tax = percent
+	}
+}
+
+// WithIssuer sets the name printed as the issuer
+func WithIssuer(issuer string) Option {
+	return func(o *options) {
+		o.issuer = issuer
+	}
+}
+
+// WithNumber sets the invoice number, derived from the issue date by default
+func WithNumber(number string) Option {
+	return func(o *options) {
+		o.number = number
+	}
+}
+
+// WithDueDays sets how many days after the issue date the invoice is due, 30 by default
+func WithDueDays(days int) Option {
+	return func(o *options) {
+		o.dueDays = days
+	}
+}
+
+// Build fetches the billable entries of the users on the client's projects in the period and
+// prices them. Users other than the current one require admin rights.
+func Build(client *clockify.APIClient, workspaceID string, customer clockify.Client, users []clockify.User, period report.Period, now time.Time, opts ...Option) (*Invoice, error) {
+	var projects []clockify.Project
+	for page, err := range client.IterProjects(workspaceID) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch projects: %w", err)
+		}
+		for _, project := range page {
+			if project.ClientID == customer.ID {
+				projects = append(projects, project)
+			}
+		}
+	}
+
+	var entries []clockify.TimeEntry
+	for _, user := range users {
+		userEntries, err := report.FetchEntries(client, workspaceID, user.ID, period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch time entries of %s: %w", user, err)
+		}
+		entries = append(entries, userEntries...)
+	}
+
+	return Compute(customer, period, projects, users, entries, now, opts...)
+}
+
+// Compute prices the billable entries on the given projects. Entries on other projects,
+// non-billable and running ones are left out. Rates in another currency than the invoice's
+// are converted by the exchange rates.
+func Compute(customer clockify.Client, period report.Period, projects []clockify.Project, users []clockify.User, entries []clockify.TimeEntry, now time.Time, opts ...Option) (*Invoice, error) {
+	o := options{dueDays: 30}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	byProject := make(map[string]clockify.Project, len(projects))
+	for _, project := range projects {
+		byProject[project.ID] = project
+	}
+	byUser := make(map[string]clockify.User, len(users))
+	for _, user := range users {
+		byUser[user.ID] = user
+	}
+
+	entries = o.rounding.Apply(entries, period.Start.Location())
+
+	issued := report.Day(now).Start
+	inv := &Invoice{
+		Number:          cmp.Or(o.number, issued.Format("20060102")),
+		Issuer:          o.issuer,
+		Client:          customer,
+		Period:          period,
+		Issued:          issued,
+		Due:             issued.AddDate(0, 0, o.dueDays),
+		Currency:        o.currency,
+		DiscountPercent: o.discount,
+		TaxPercent:      o.tax,
+	}
+
+	type lineKey struct {
+		project, user string
+		rate          int64
+	}
+	lines := make(map[lineKey]*Line)
+
+	for _, entry := range entries {
+		project, ok := byProject[entry.ProjectID]
+		if !ok || !entry.Billable || entry.IsBreak() || entry.TimeInterval == nil || entry.TimeInterval.End == nil {
+			continue
+		}
+		if !period.Contains(entry.TimeInterval.Start) {
+			continue
+		}
+		user := byUser[entry.UserID]
+		duration := report.EntryDuration(entry, now)
+
+		rate := o.rateOf(project, user, entry)
+		if rate.Amount <= 0 {
+			inv.Unrated += duration
+			continue
+		}
+		inv.Currency = cmp.Or(inv.Currency, rate.Currency)
+		rate, err := o.exchange.Convert(rate, inv.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price the time of %s on %s: %w", cmp.Or(user.Name, user.Email, entry.UserID), project.Name, err)
+		}
+
+		key := lineKey{project.ID, entry.UserID, rate.Amount}
+		line, ok := lines[key]
+		if !ok {
+			line = &Line{ProjectID: project.ID, Project: project.Name, UserID: entry.UserID, User: cmp.Or(user.Name, user.Email, entry.UserID), Rate: rate}
+			lines[key] = line
+		}
+		line.Duration += duration
+	}
+
+	amounts := make([]money.Money, 0, len(lines))
+	for _, line := range lines {
+		// Configured rates met before a Clockify rate set the currency have none yet
+		line.Rate = line.Rate.In(inv.Currency)
+		line.Amount = line.Rate.Over(line.Duration)
+		inv.Lines = append(inv.Lines, *line)
+		amounts = append(amounts, line.Amount)
+	}
+	slices.SortFunc(inv.Lines, func(a, b Line) int {
+		return cmp.Or(cmp.Compare(a.Project, b.Project), cmp.Compare(a.User, b.User), b.Rate.Compare(a.Rate))
+	})
+
+	subtotal, err := money.Sum(amounts...)
+	if err != nil {
+		return nil, err
+	}
+	inv.Subtotal = subtotal.In(inv.Currency) // Without lines, the sum has no currency
+	if inv.Discount, err = inv.Subtotal.Percent(o.discount); err != nil {
+		return nil, fmt.Errorf("discount: %w", err)
+	}
+	discounted, err := inv.Subtotal.Sub(inv.Discount)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Tax, err = discounted.Percent(o.tax); err != nil {
+		return nil, fmt.Errorf("tax: %w", err)
+	}
+	if inv.Total, err = discounted.Add(inv.Tax); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// rateOf finds the hourly rate of an entry: the configured rates from the most specific,
+// then the entry's effective Clockify rate, then the project's. Configured rates have no
+// currency, they are in the invoice's.
+func (o *options) rateOf(project clockify.Project, user clockify.User, entry clockify.TimeEntry) money.Money {
+	matchesUser := func(name string) bool {
+		return name != "" && (name == user.Email || name == user.Name)
+	}
+
+	var projectRate, userRate int64
+	for _, rate := range o.rates {
+		switch {
+		case rate.Project == project.Name && matchesUser(rate.User):
+			return money.New(rate.Amount, o.currency)
+		case rate.Project == "" && matchesUser(rate.User):
+			userRate = cmp.Or(userRate, rate.Amount)
+		case rate.Project == project.Name && rate.User == "":
+			projectRate = cmp.Or(projectRate, rate.Amount)
+		}
+	}
+	if rate := cmp.Or(userRate, projectRate); rate > 0 {
+		return money.New(rate, o.currency)
+	}
+
+	for _, rate := range []*clockify.Rate{entry.HourlyRate, project.HourlyRate} {
+		if rate != nil && rate.Amount > 0 {
+			return money.New(rate.Amount, rate.Currency)
+		}
+	}
+	return money.Money{}
+}
@@ -0,0 +1,96 @@
+package invoice
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Column widths of the lines table in mm, the A4 body is 180mm wide
+var pdfColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Project", 60, "L"},
+	{"User", 45, "L"},
+	{"Hours", 20, "R"},
+	{"Rate", 25, "R"},
+	{"Amount", 30, "R"},
+}
+
+// WritePDF writes a printable A4 invoice
+func WritePDF(w io.Writer, inv *Invoice) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 20, 15)
+	pdf.SetTitle("Invoice "+inv.Number, true)
+	pdf.SetCreator("ccws", true)
+	pdf.AddPage()
+
+	// The core fonts are cp1252, names and the period dash are UTF-8
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 10, tr("Invoice "+inv.Number), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "", 10)
+	details := [][2]string{
+		{"Client", inv.Client.Name},
+		{"Period", inv.Period.String()},
+		{"Issued", inv.Issued.Format("2006-01-02")},
+		{"Due", inv.Due.Format("2006-01-02")},
+	}
+	if inv.Issuer != "" {
+		details = append([][2]string{{"From", inv.Issuer}}, details...)
+	}
+	for _, detail := range details {
+		pdf.CellFormat(25, 6, tr(detail[0]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, tr(detail[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	for _, column := range pdfColumns {
+		pdf.CellFormat(column.width, 8, column.title, "B", 0, column.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range inv.Lines {
+		cells := []string{line.Project, line.User, fmt.Sprintf("%.2f", report.Hours(line.Duration)), line.Rate.Decimal(), line.Amount.Decimal()}
+		for i, column := range pdfColumns {
+			pdf.CellFormat(column.width, 7, tr(cells[i]), "", 0, column.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(2)
+
+	label := pdfColumns[0].width + pdfColumns[1].width + pdfColumns[2].width + pdfColumns[3].width
+	amount := pdfColumns[4].width
+	total := func(name, value, style string) {
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(label, 7, tr(name), "", 0, "R", false, 0, "")
+		pdf.CellFormat(amount, 7, tr(value), "", 1, "R", false, 0, "")
+	}
+	total("Subtotal", inv.Subtotal.Decimal(), "")
+	if !inv.Discount.IsZero() {
+		total(fmt.Sprintf("Discount %s%%", percent(inv.DiscountPercent)), "-"+inv.Discount.Decimal(), "")
+	}
+	if !inv.Tax.IsZero() {
+		total(fmt.Sprintf("Tax %s%%", percent(inv.TaxPercent)), inv.Tax.Decimal(), "")
+	}
+	total("Total", inv.Total.String(), "B")
+
+	if inv.Unrated > 0 {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.MultiCell(0, 5, tr(fmt.Sprintf("%s billable hours without a rate are not invoiced.", report.FormatDuration(inv.Unrated))), "", "L", false)
+	}
+
+	return pdf.Output(w)
+}
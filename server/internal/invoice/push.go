@@ -0,0 +1,49 @@
+package invoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Push creates the invoice in Clockify's invoicing, as a draft with the discount and tax
+// applied. The lines are listed in the note, Clockify's own items are left for the user to import.
+func Push(client *clockify.APIClient, workspaceID string, inv *Invoice) (*clockify.Invoice, error) {
+	created, err := client.CreateInvoice(workspaceID, clockify.InvoiceRequest{
+		ClientID:   inv.Client.ID,
+		Currency:   inv.Currency,
+		Number:     inv.Number,
+		IssuedDate: inv.Issued,
+		DueDate:    inv.Due,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	updated, err := client.UpdateInvoice(workspaceID, created.ID, clockify.UpdateInvoiceRequest{
+		Currency:        inv.Currency,
+		Number:          inv.Number,
+		IssuedDate:      inv.Issued,
+		DueDate:         inv.Due,
+		Subject:         fmt.Sprintf("%s, %s", inv.Client.Name, inv.Period),
+		Note:            note(inv),
+		DiscountPercent: inv.DiscountPercent,
+		TaxPercent:      inv.TaxPercent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update invoice %s: %w", created.ID, err)
+	}
+
+	return updated, nil
+}
+
+// note summarizes the lines, one per row
+func note(inv *Invoice) string {
+	var b strings.Builder
+	for _, line := range inv.Lines {
+		fmt.Fprintf(&b, "%s, %s: %.2f h × %s = %s\n", line.Project, line.User, report.Hours(line.Duration), line.Rate.Decimal(), line.Amount.String())
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
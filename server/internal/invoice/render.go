@@ -0,0 +1,148 @@
+package invoice
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// FormatPDF renders a printable invoice, besides the report formats
+const FormatPDF report.Format = "pdf"
+
+// Render writes the invoice in the given format
+func Render(w io.Writer, inv *Invoice, format report.Format) error {
+	switch format {
+	case report.FormatTable:
+		return WriteTable(w, inv)
+	case report.FormatCSV:
+		return WriteCSV(w, inv)
+	case report.FormatJSON:
+		return WriteJSON(w, inv)
+	case FormatPDF:
+		return WritePDF(w, inv)
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv, json or pdf", format)
+	}
+}
+
+// WriteTable writes the lines and totals as an ASCII table
+func WriteTable(w io.Writer, inv *Invoice) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Invoice %s\t\n", inv.Number)
+	fmt.Fprintf(tw, "Client: %s\t\n", inv.Client.Name)
+	fmt.Fprintf(tw, "Period: %s\t\n", inv.Period)
+	fmt.Fprintf(tw, "Issued: %s, due %s\t\n\n", inv.Issued.Format("2006-01-02"), inv.Due.Format("2006-01-02"))
+
+	fmt.Fprintln(tw, "PROJECT\tUSER\tHOURS\tRATE\tAMOUNT\t")
+	for _, line := range inv.Lines {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", line.Project, line.User, report.FormatDuration(line.Duration), line.Rate.Decimal(), line.Amount.Decimal())
+	}
+	fmt.Fprintf(tw, "SUBTOTAL\t\t\t\t%s\t\n", inv.Subtotal.Decimal())
+	if !inv.Discount.IsZero() {
+		fmt.Fprintf(tw, "DISCOUNT %s%%\t\t\t\t-%s\t\n", percent(inv.DiscountPercent), inv.Discount.Decimal())
+	}
+	if !inv.Tax.IsZero() {
+		fmt.Fprintf(tw, "TAX %s%%\t\t\t\t%s\t\n", percent(inv.TaxPercent), inv.Tax.Decimal())
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t\t%s\t\n", inv.Total.String())
+
+	if inv.Unrated > 0 {
+		fmt.Fprintf(tw, "\n%s billable hours without a rate are not invoiced\t\n", report.FormatDuration(inv.Unrated))
+	}
+
+	return tw.Flush()
+}
+
+// WriteCSV writes one row per line, for importing into accounting tools
+func WriteCSV(w io.Writer, inv *Invoice) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"invoice", "client", "project", "user", "hours", "rate", "amount", "currency"})
+	for _, line := range inv.Lines {
+		cw.Write([]string{
+			inv.Number,
+			inv.Client.Name,
+			line.Project,
+			line.User,
+			strconv.FormatFloat(report.Hours(line.Duration), 'f', 2, 64),
+			line.Rate.Decimal(),
+			line.Amount.Decimal(),
+			inv.Currency,
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonLine struct {
+	ProjectID string  `json:"projectId"`
+	Project   string  `json:"project"`
+	UserID    string  `json:"userId"`
+	User      string  `json:"user"`
+	Hours     float64 `json:"hours"`
+	Rate      int64   `json:"rate"`
+	Amount    int64   `json:"amount"`
+}
+
+type jsonInvoice struct {
+	Number          string     `json:"number"`
+	Issuer          string     `json:"issuer,omitempty"`
+	ClientID        string     `json:"clientId"`
+	Client          string     `json:"client"`
+	Start           string     `json:"start"`
+	End             string     `json:"end"`
+	Issued          string     `json:"issued"`
+	Due             string     `json:"due"`
+	Currency        string     `json:"currency"`
+	Lines           []jsonLine `json:"lines"`
+	Subtotal        int64      `json:"subtotal"`
+	DiscountPercent float64    `json:"discountPercent"`
+	Discount        int64      `json:"discount"`
+	TaxPercent      float64    `json:"taxPercent"`
+	Tax             int64      `json:"tax"`
+	Total           int64      `json:"total"`
+	UnratedHours    float64    `json:"unratedHours"`
+}
+
+// WriteJSON writes the invoice as JSON with durations in hours and money in the smallest currency unit
+func WriteJSON(w io.Writer, inv *Invoice) error {
+	out := jsonInvoice{
+		Number:          inv.Number,
+		Issuer:          inv.Issuer,
+		ClientID:        inv.Client.ID,
+		Client:          inv.Client.Name,
+		Start:           inv.Period.Start.Format(time.RFC3339),
+		End:             inv.Period.End.Format(time.RFC3339),
+		Issued:          inv.Issued.Format("2006-01-02"),
+		Due:             inv.Due.Format("2006-01-02"),
+		Currency:        inv.Currency,
+		Lines:           make([]jsonLine, 0, len(inv.Lines)),
+		Subtotal:        inv.Subtotal.Amount,
+		DiscountPercent: inv.DiscountPercent,
+		Discount:        inv.Discount.Amount,
+		TaxPercent:      inv.TaxPercent,
+		Tax:             inv.Tax.Amount,
+		Total:           inv.Total.Amount,
+		UnratedHours:    report.Hours(inv.Unrated),
+	}
+
+	for _, line := range inv.Lines {
+		out.Lines = append(out.Lines, jsonLine{line.ProjectID, line.Project, line.UserID, line.User, report.Hours(line.Duration), line.Rate.Amount, line.Amount.Amount})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func percent(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
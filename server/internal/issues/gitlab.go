@@ -0,0 +1,116 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGitLabURL is gitlab.com, self-managed instances are set with WithGitLabURL
+const DefaultGitLabURL = "https://gitlab.com"
+
+// gitlabRef matches "#12" and "group/sub/project#12", not "abc#12" within a word or URL fragments
+var gitlabRef = regexp.MustCompile(`(?:^|[\s(\[,;])((?:[\w.-]+/)+[\w.-]+)?#(\d+)\b`)
+
+// GitLabOption configures a GitLab resolver
+type GitLabOption func(*GitLab)
+
+// WithGitLabURL points the resolver at a self-managed instance
+func WithGitLabURL(baseURL string) GitLabOption {
+	return func(g *GitLab) {
+		g.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithDefaultProject resolves bare "#12" references in the project at this path
+func WithDefaultProject(path string) GitLabOption {
+	return func(g *GitLab) {
+		g.project = path
+	}
+}
+
+// GitLab resolves references to GitLab issues with a personal access token
+type GitLab struct {
+	token   string
+	baseURL string
+	project string
+	client  *http.Client
+}
+
+func NewGitLab(token string, opts ...GitLabOption) *GitLab {
+	g := &GitLab{token: token, baseURL: DefaultGitLabURL, client: &http.Client{Timeout: 15 * time.Second}}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+func (g *GitLab) Name() string {
+	return "gitlab"
+}
+
+func (g *GitLab) Refs(text string) []string {
+	var refs []string
+	for _, match := range gitlabRef.FindAllStringSubmatch(text, -1) {
+		path := match[1]
+		if path == "" {
+			if g.project == "" {
+				continue
+			}
+			path = g.project
+		}
+		ref := path + "#" + match[2]
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+type gitlabIssue struct {
+	IID    int      `json:"iid"`
+	Title  string   `json:"title"`
+	WebURL string   `json:"web_url"`
+	State  string   `json:"state"`
+	Labels []string `json:"labels"`
+}
+
+func (g *GitLab) Resolve(ctx context.Context, ref string) (*Issue, error) {
+	path, iid, _ := strings.Cut(ref, "#")
+	if _, err := strconv.Atoi(iid); err != nil {
+		return nil, ErrNotFound
+	}
+
+	u := fmt.Sprintf("%s/api/v4/projects/%s/issues/%s", g.baseURL, url.PathEscape(path), iid)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var issue gitlabIssue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, err
+	}
+	return &Issue{Tracker: g.Name(), Ref: ref, Title: issue.Title, URL: issue.WebURL, State: issue.State, Labels: issue.Labels}, nil
+}
@@ -0,0 +1,90 @@
+// Package issues resolves issue tracker references in time entry descriptions, e.g. "ENG-42"
+// for Linear or "group/project#12" for GitLab, to the issues' titles and links.
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/cache"
+)
+
+// ErrNotFound is returned by resolvers for references that match no issue. Text that merely
+// looks like a reference, e.g. "UTF-8" for Linear, ends up here and is skipped.
+var ErrNotFound = errors.New("issue not found")
+
+// DefaultCacheTTL is how long resolved issues are reused, titles rarely change
+const DefaultCacheTTL = time.Hour
+
+// Issue is an issue of a tracker
+type Issue struct {
+	Tracker string // Name of the resolver, e.g. "gitlab"
+	Ref     string // Canonical reference, e.g. "group/project#12"
+	Title   string
+	URL     string
+	State   string   // Tracker specific, e.g. "opened" or "In Progress"
+	Labels  []string // Become tags when entries are tagged by their issues
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s %s", i.Ref, i.Title)
+}
+
+// Resolver looks up the issues of one tracker
+type Resolver interface {
+	// Name identifies the tracker, e.g. "linear"
+	Name() string
+	// Refs returns the canonical references found in the text, without duplicates
+	Refs(text string) []string
+	// Resolve looks up a reference found by Refs, ErrNotFound when there is no such issue
+	Resolve(ctx context.Context, ref string) (*Issue, error)
+}
+
+// Set resolves references with every configured tracker, caching the issues
+type Set struct {
+	resolvers []Resolver
+	cache     *cache.Cache[string, *Issue]
+}
+
+// NewSet combines the resolvers, the first ones take precedence when several find issues in a text
+func NewSet(ttl time.Duration, resolvers ...Resolver) *Set {
+	return &Set{resolvers: resolvers, cache: cache.New[string, *Issue](ttl)}
+}
+
+// Empty reports whether no tracker is configured
+func (s *Set) Empty() bool {
+	return len(s.resolvers) == 0
+}
+
+// Find resolves every reference in the text. Lookup failures are returned along with the
+// issues that did resolve; references without an issue are skipped.
+func (s *Set) Find(ctx context.Context, text string) ([]Issue, error) {
+	var (
+		found []Issue
+		errs  []error
+	)
+
+	for _, resolver := range s.resolvers {
+		for _, ref := range resolver.Refs(text) {
+			issue, err := s.cache.GetOrLoad(resolver.Name()+":"+ref, func() (*Issue, error) {
+				issue, err := resolver.Resolve(ctx, ref)
+				if errors.Is(err, ErrNotFound) {
+					// Remember the miss, whatever looked like a reference will look like one again
+					return nil, nil
+				}
+				return issue, err
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", resolver.Name(), ref, err))
+				continue
+			}
+			if issue != nil {
+				found = append(found, *issue)
+			}
+		}
+	}
+
+	return found, errors.Join(errs...)
+}
@@ -0,0 +1,129 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LinearAPIURL is the GraphQL endpoint of Linear
+const LinearAPIURL = "https://api.linear.app/graphql"
+
+// linearRef matches issue identifiers like "ENG-42"
+var linearRef = regexp.MustCompile(`\b([A-Z][A-Z0-9]{0,9})-(\d+)\b`)
+
+const linearIssueQuery = `query Issue($id: String!) {
+  issue(id: $id) { identifier title url state { name } labels { nodes { name } } }
+}`
+
+// Linear resolves issue identifiers with a Linear API key
+type Linear struct {
+	apiKey string
+	teams  []string
+	url    string
+	client *http.Client
+}
+
+// NewLinear creates a resolver. With teams, only identifiers of these team keys are looked up,
+// which avoids queries for text like "UTF-8".
+func NewLinear(apiKey string, teams ...string) *Linear {
+	upper := make([]string, len(teams))
+	for i, team := range teams {
+		upper[i] = strings.ToUpper(team)
+	}
+	return &Linear{apiKey: apiKey, teams: upper, url: LinearAPIURL, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (l *Linear) Name() string {
+	return "linear"
+}
+
+func (l *Linear) Refs(text string) []string {
+	var refs []string
+	for _, match := range linearRef.FindAllStringSubmatch(text, -1) {
+		if len(l.teams) > 0 && !slices.Contains(l.teams, match[1]) {
+			continue
+		}
+		if !slices.Contains(refs, match[0]) {
+			refs = append(refs, match[0])
+		}
+	}
+	return refs
+}
+
+type linearResponse struct {
+	Data struct {
+		Issue *struct {
+			Identifier string `json:"identifier"`
+			Title      string `json:"title"`
+			URL        string `json:"url"`
+			State      struct {
+				Name string `json:"name"`
+			} `json:"state"`
+			Labels struct {
+				Nodes []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"labels"`
+		} `json:"issue"`
+	} `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func (l *Linear) Resolve(ctx context.Context, ref string) (*Issue, error) {
+	body, err := json.Marshal(map[string]any{"query": linearIssueQuery, "variables": map[string]string{"id": ref}})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", l.apiKey)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var result linearResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		// Unknown identifiers are reported as an entity not found error
+		if result.Errors[0].Extensions.Code == "ENTITY_NOT_FOUND" || strings.Contains(strings.ToLower(result.Errors[0].Message), "not found") {
+			return nil, ErrNotFound
+		}
+		return nil, errors.New(result.Errors[0].Message)
+	}
+	if result.Data.Issue == nil {
+		return nil, ErrNotFound
+	}
+
+	issue := result.Data.Issue
+	labels := make([]string, 0, len(issue.Labels.Nodes))
+	for _, label := range issue.Labels.Nodes {
+		labels = append(labels, label.Name)
+	}
+	return &Issue{Tracker: l.Name(), Ref: issue.Identifier, Title: issue.Title, URL: issue.URL, State: issue.State.Name, Labels: labels}, nil
+}
@@ -0,0 +1,152 @@
+package issues
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Enrich resolves the issues referenced by each entry's description, keyed by entry ID.
+// Entries without references are left out; failures are returned with what did resolve.
+func (s *Set) Enrich(ctx context.Context, entries []clockify.TimeEntry) (map[string][]Issue, error) {
+	enriched := make(map[string][]Issue)
+	var errs []error
+	for _, entry := range entries {
+		found, err := s.Find(ctx, entry.Description)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(found) > 0 {
+			enriched[entry.ID] = found
+		}
+	}
+	return enriched, errors.Join(errs...)
+}
+
+// Total is the time tracked on an issue
+type Total struct {
+	Issue    Issue
+	Duration time.Duration
+	Entries  int
+}
+
+// Totals sums the time of the enriched entries per issue, longest first. An entry referencing
+// several issues is split evenly between them, so the totals add up to the tracked time.
+func Totals(entries []clockify.TimeEntry, enriched map[string][]Issue, now time.Time) []Total {
+	totals := make(map[string]*Total)
+	for _, entry := range entries {
+		found := enriched[entry.ID]
+		if len(found) == 0 {
+			continue
+		}
+		share := report.EntryDuration(entry, now) / time.Duration(len(found))
+		for _, issue := range found {
+			key := issue.Tracker + ":" + issue.Ref
+			total, ok := totals[key]
+			if !ok {
+				total = &Total{Issue: issue}
+				totals[key] = total
+			}
+			total.Duration += share
+			total.Entries++
+		}
+	}
+
+	result := make([]Total, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	slices.SortFunc(result, func(a, b Total) int {
+		return cmp.Or(cmp.Compare(b.Duration, a.Duration), cmp.Compare(a.Issue.Ref, b.Issue.Ref))
+	})
+	return result
+}
+
+// Render writes the per-issue totals of a period in the given format
+func Render(w io.Writer, period report.Period, totals []Total, format report.Format) error {
+	switch format {
+	case report.FormatTable:
+		return writeTable(w, period, totals)
+	case report.FormatCSV:
+		return writeCSV(w, totals)
+	case report.FormatJSON:
+		return writeJSON(w, period, totals)
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv or json", format)
+	}
+}
+
+func writeTable(w io.Writer, period report.Period, totals []Total) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Period: %s\t\n\n", period)
+	fmt.Fprintln(tw, "ISSUE\tTITLE\tSTATE\tHOURS\tENTRIES\tURL\t")
+	for _, total := range totals {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t\n", total.Issue.Ref, total.Issue.Title, total.Issue.State, report.FormatDuration(total.Duration), total.Entries, total.Issue.URL)
+	}
+
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, totals []Total) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"tracker", "issue", "title", "state", "hours", "entries", "url"})
+	for _, total := range totals {
+		hours := report.Hours(total.Duration)
+		cw.Write([]string{total.Issue.Tracker, total.Issue.Ref, total.Issue.Title, total.Issue.State, strconv.FormatFloat(hours, 'f', 2, 64), strconv.Itoa(total.Entries), total.Issue.URL})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonTotal struct {
+	Tracker string   `json:"tracker"`
+	Issue   string   `json:"issue"`
+	Title   string   `json:"title"`
+	State   string   `json:"state,omitempty"`
+	URL     string   `json:"url"`
+	Labels  []string `json:"labels,omitempty"`
+	Hours   float64  `json:"hours"`
+	Entries int      `json:"entries"`
+}
+
+func writeJSON(w io.Writer, period report.Period, totals []Total) error {
+	out := struct {
+		Start  string      `json:"start"`
+		End    string      `json:"end"`
+		Issues []jsonTotal `json:"issues"`
+	}{
+		Start:  period.Start.Format(time.RFC3339),
+		End:    period.End.Format(time.RFC3339),
+		Issues: make([]jsonTotal, 0, len(totals)),
+	}
+	for _, total := range totals {
+		out.Issues = append(out.Issues, jsonTotal{
+			Tracker: total.Issue.Tracker,
+			Issue:   total.Issue.Ref,
+			Title:   total.Issue.Title,
+			State:   total.Issue.State,
+			URL:     total.Issue.URL,
+			Labels:  total.Issue.Labels,
+			Hours:   report.Hours(total.Duration),
+			Entries: total.Entries,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,106 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/cache"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// tagsTTL is how long the workspace tags are reused before labels are looked up again
+const tagsTTL = 10 * time.Minute
+
+// Tagger tags time entries with the labels of the issues their descriptions reference. A
+// label applies the workspace tag of the same name, whatever its case, labels without a tag
+// are skipped rather than creating one for every label of the trackers.
+type Tagger struct {
+	client      *clockify.APIClient
+	workspaceID string
+	issues      *Set
+	tags        *cache.Cache[string, map[string]string]
+}
+
+func NewTagger(client *clockify.APIClient, workspaceID string, issues *Set) *Tagger {
+	return &Tagger{
+		client:      client,
+		workspaceID: workspaceID,
+		issues:      issues,
+		tags:        cache.New[string, map[string]string](tagsTTL),
+	}
+}
+
+// tagIDs returns the IDs of the tags the labels of the issues apply, sorted
+func (t *Tagger) tagIDs(client *clockify.APIClient, found []Issue) ([]string, error) {
+	byName, err := t.tags.GetOrLoad(t.workspaceID, func() (map[string]string, error) {
+		tags, err := clockify.Collect(client.IterTags(t.workspaceID))
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]string, len(tags))
+		for _, tag := range tags {
+			if !tag.Archived {
+				byName[strings.ToLower(tag.Name)] = tag.ID
+			}
+		}
+		return byName, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, issue := range found {
+		for _, label := range issue.Labels {
+			if id, ok := byName[strings.ToLower(label)]; ok && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Handle tags the time entry of the event by its issues, it is an events.HandlerFunc. The
+// update it makes raises another event, which finds the entry tagged already.
+func (t *Tagger) Handle(ctx context.Context, event events.Event) error {
+	entry, ok := event.Payload.(*clockify.TimeEntry)
+	if !ok || entry.Description == "" {
+		return nil
+	}
+
+	// Some references may fail to resolve, the labels of the others still apply
+	found, findErr := t.issues.Find(ctx, entry.Description)
+	if len(found) == 0 {
+		return findErr
+	}
+
+	client := t.client.WithContext(ctx)
+	tagIDs, err := t.tagIDs(client, found)
+	if err != nil {
+		return errors.Join(findErr, err)
+	}
+	updated, err := client.RetagTimeEntries(t.workspaceID, []clockify.TimeEntry{*entry}, tagIDs, nil)
+	if err != nil {
+		return errors.Join(findErr, err)
+	}
+	if updated > 0 {
+		slog.Info("issue_labels_tagged", "entry_id", entry.ID, "user_id", entry.UserID, "tag_ids", tagIDs)
+	}
+	return findErr
+}
+
+// Subscribe tags time entries whenever they are started, tracked or changed
+func (t *Tagger) Subscribe(registry *events.Registry) {
+	registry.On("issue_labels", t.Handle,
+		clockify.NewTimerStartedEvent,
+		clockify.TimerStoppedEvent,
+		clockify.NewTimeEntryEvent,
+		clockify.TimeEntryUpdatedEvent,
+	)
+}
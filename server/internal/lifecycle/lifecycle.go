@@ -0,0 +1,66 @@
+// Package lifecycle sets up the resources of a command in stages and tears them down in
+// reverse, keeping track of what must be cleaned up after a crash.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// StageFunc sets up or tears down a stage
+type StageFunc func(ctx context.Context) error
+
+type stage struct {
+	name     string
+	teardown StageFunc
+}
+
+// Manager tears down the stages set up through it, the last one first
+type Manager struct {
+	mu     sync.Mutex
+	stages []stage
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+// Setup runs the setup of a stage and, when it succeeds, registers its teardown for Stop.
+// A nil teardown means there is nothing to undo.
+func (m *Manager) Setup(ctx context.Context, name string, setup, teardown StageFunc) error {
+	if err := setup(ctx); err != nil {
+		return fmt.Errorf("failed to set up %s: %w", name, err)
+	}
+	slog.Debug("stage_set_up", "stage", name)
+
+	if teardown != nil {
+		m.mu.Lock()
+		m.stages = append(m.stages, stage{name: name, teardown: teardown})
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop tears down every stage set up so far in reverse order, carrying on past failures.
+// Stages are torn down once, later calls only handle the stages set up since.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	stages := m.stages
+	m.stages = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(stages) - 1; i >= 0; i-- {
+		s := stages[i]
+		if err := s.teardown(ctx); err != nil {
+			slog.Error("stage_teardown_failed", "stage", s.name, "error", err)
+			errs = append(errs, fmt.Errorf("failed to tear down %s: %w", s.name, err))
+			continue
+		}
+		slog.Debug("stage_torn_down", "stage", s.name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Marker is a file recording resources that exist only while the command runs. It outlives
+// a crash or SIGKILL, so the next start finds and cleans up what was left behind.
+type Marker struct {
+	path string
+}
+
+func NewMarker(path string) *Marker {
+	return &Marker{path: path}
+}
+
+// MarkerPath returns the path of the named marker in the user's cache directory, or the
+// temporary directory when there is none
+func MarkerPath(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ccws", name+".json")
+}
+
+// Path returns where the marker is stored
+func (m *Marker) Path() string {
+	return m.path
+}
+
+// Save replaces the marker content with v. The file is replaced by a rename, so a crash
+// while saving leaves the previous content.
+func (m *Marker) Save(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create marker directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), filepath.Base(m.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save marker: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save marker: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save marker: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save marker: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("failed to save marker: %w", err)
+	}
+	return nil
+}
+
+// Load decodes the marker into v, reporting false when there is no marker
+func (m *Marker) Load(v any) (bool, error) {
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read marker: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to decode marker %s: %w", m.path, err)
+	}
+	return true, nil
+}
+
+// Remove deletes the marker once its resources are cleaned up
+func (m *Marker) Remove() error {
+	if err := os.Remove(m.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove marker: %w", err)
+	}
+	return nil
+}
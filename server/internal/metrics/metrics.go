@@ -0,0 +1,157 @@
+// Package metrics serves metrics in the Prometheus text exposition format: those of the Go
+// process, and gauges of the tracking activity of the workspace for Grafana dashboards.
+package metrics
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types
+const (
+	TypeGauge   = "gauge"
+	TypeCounter = "counter"
+)
+
+// Family is a metric and its samples, one per set of labels
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is a value of a family, labeled by name-value pairs
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Label is a dimension of a sample
+type Label struct {
+	Name, Value string
+}
+
+// Collector returns the families it currently measures
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc is a function used as a Collector
+type CollectorFunc func() []Family
+
+// Collect calls f
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+// Handler serves the families of the collectors, requiring the token as a bearer token
+// unless it is empty
+func Handler(token string, collectors ...Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var families []Family
+		for _, collector := range collectors {
+			families = append(families, collector.Collect()...)
+		}
+		w.Header().Set("Content-Type", ContentType)
+		Write(w, families)
+	})
+}
+
+// Write encodes the families in the text format, sorted by name so scrapes diff cleanly
+func Write(w io.Writer, families []Family) error {
+	families = slices.Clone(families)
+	slices.SortStableFunc(families, func(a, b Family) int { return cmp.Compare(a.Name, b.Name) })
+
+	buf := bufio.NewWriter(w)
+	for _, family := range families {
+		fmt.Fprintf(buf, "# HELP %s %s\n", family.Name, escapeHelp(family.Help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, sample := range family.Samples {
+			buf.WriteString(family.Name)
+			if len(sample.Labels) > 0 {
+				buf.WriteByte('{')
+				for i, label := range sample.Labels {
+					if i > 0 {
+						buf.WriteByte(',')
+					}
+					fmt.Fprintf(buf, "%s=\"%s\"", label.Name, escapeLabel(label.Value))
+				}
+				buf.WriteByte('}')
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(formatValue(sample.Value))
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Flush()
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// Gauge returns a family of a single unlabeled value
+func Gauge(name, help string, value float64) Family {
+	return Family{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Value: value}}}
+}
+
+// Process measures the Go runtime of the process
+func Process() Collector {
+	started := time.Now()
+	return CollectorFunc(func() []Family {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		return []Family{
+			Gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())),
+			Gauge("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.", float64(mem.HeapAlloc)),
+			Gauge("go_memstats_sys_bytes", "Number of bytes obtained from the system.", float64(mem.Sys)),
+			{Name: "go_gc_cycles_total", Help: "Number of completed GC cycles.", Type: TypeCounter, Samples: []Sample{{Value: float64(mem.NumGC)}}},
+			Gauge("process_start_time_seconds", "Start time of the process since the Unix epoch in seconds.", float64(started.UnixNano())/1e9),
+		}
+	})
+}
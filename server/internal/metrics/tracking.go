@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// TrackingOption configures a Tracking collector
+type TrackingOption func(*Tracking)
+
+// WithUsers limits the gauges to the time of the given users. By default every workspace user
+// is measured, which requires admin rights.
+func WithUsers(users ...clockify.User) TrackingOption {
+	return func(t *Tracking) {
+		t.users = users
+	}
+}
+
+// Tracking measures the time tracked in the workspace: the time tracked today per user and
+// project, the running timers and the non-billable time of the month. Clockify is read by
+// Refresh, scrapes serve the last refresh.
+type Tracking struct {
+	client      *clockify.APIClient
+	workspaceID string
+	users       []clockify.User
+	now         func() time.Time
+
+	mu          sync.Mutex
+	families    []Family
+	refreshedAt time.Time
+}
+
+// NewTracking creates a collector of the workspace's tracking activity
+func NewTracking(client *clockify.APIClient, workspaceID string, opts ...TrackingOption) *Tracking {
+	t := &Tracking{client: client, workspaceID: workspaceID, now: time.Now}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// trackedKey groups the time of a user on a project
+type trackedKey struct {
+	user, project string
+	billable      bool
+}
+
+// Refresh reads the time entries of the month of every user. It is a scheduler.JobFunc.
+func (t *Tracking) Refresh(ctx context.Context) error {
+	client := t.client.WithContext(ctx)
+
+	users := t.users
+	if users == nil {
+		var err error
+		if users, err = clockify.Collect(client.IterWorkspaceUsers(t.workspaceID)); err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+	}
+	projects := make(map[string]string)
+	for page, err := range client.IterProjects(t.workspaceID) {
+		if err != nil {
+			return fmt.Errorf("failed to list projects: %w", err)
+		}
+		for _, project := range page {
+			projects[project.ID] = project.Name
+		}
+	}
+
+	now := t.now()
+	today, month := report.Day(now), report.Month(now, 0)
+	trackedToday := make(map[trackedKey]time.Duration)
+	unbilled := make(map[trackedKey]time.Duration)
+	running := 0
+	for _, user := range users {
+		entries, err := clockify.Collect(client.IterTimeEntries(t.workspaceID, user.ID, &month.Start, nil))
+		if err != nil {
+			return fmt.Errorf("failed to read time entries of user %s: %w", user.ID, err)
+		}
+		for _, entry := range entries {
+			if entry.TimeInterval == nil {
+				continue
+			}
+			end := now
+			if entry.TimeInterval.End != nil {
+				end = *entry.TimeInterval.End
+			} else {
+				running++
+			}
+			duration := max(end.Sub(entry.TimeInterval.Start), 0)
+
+			// Breaks are neither tracked work nor unbilled
+			if entry.IsBreak() {
+				continue
+			}
+			key := trackedKey{user: user.Name, project: projects[entry.ProjectID], billable: entry.Billable}
+			if !entry.TimeInterval.Start.Before(today.Start) {
+				trackedToday[key] += duration
+			}
+			if !entry.Billable {
+				unbilled[key] += duration
+			}
+		}
+	}
+
+	families := []Family{
+		{
+			Name:    "ccws_tracked_today_seconds",
+			Help:    "Time tracked on entries started today, running timers up to now, per user and project.",
+			Type:    TypeGauge,
+			Samples: samples(trackedToday, true),
+		},
+		Gauge("ccws_running_timers", "Number of timers running, started this month.", float64(running)),
+		{
+			Name:    "ccws_unbilled_month_seconds",
+			Help:    "Time tracked this month on non-billable entries, per user and project.",
+			Type:    TypeGauge,
+			Samples: samples(unbilled, false),
+		},
+		Gauge("ccws_tracking_users", "Number of users whose time is measured.", float64(len(users))),
+	}
+
+	t.mu.Lock()
+	t.families = families
+	t.refreshedAt = now
+	t.mu.Unlock()
+	return nil
+}
+
+// samples sorts the durations by user and project, in seconds
+func samples(durations map[trackedKey]time.Duration, billableLabel bool) []Sample {
+	keys := make([]trackedKey, 0, len(durations))
+	for key := range durations {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b trackedKey) int {
+		return cmp.Or(cmp.Compare(a.user, b.user), cmp.Compare(a.project, b.project), compareBool(a.billable, b.billable))
+	})
+
+	result := make([]Sample, len(keys))
+	for i, key := range keys {
+		labels := []Label{{"user", key.user}, {"project", key.project}}
+		if billableLabel {
+			labels = append(labels, Label{"billable", strconv.FormatBool(key.billable)})
+		}
+		result[i] = Sample{Labels: labels, Value: durations[key].Seconds()}
+	}
+	return result
+}
+
+// compareBool orders false before true
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Collect returns the gauges of the last refresh, none before the first
+func (t *Tracking) Collect() []Family {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.refreshedAt.IsZero() {
+		return nil
+	}
+	return append(slices.Clone(t.families),
+		Gauge("ccws_tracking_refreshed_timestamp_seconds", "When the tracking gauges were last read from Clockify, since the Unix epoch.", float64(t.refreshedAt.Unix())))
+}
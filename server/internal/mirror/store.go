@@ -0,0 +1,350 @@
+// Package mirror keeps a local SQLite copy of a workspace's time entries, projects and tags,
+// backfilled from Clockify and kept current by webhooks, so reports and the APIs can be
+// served without a Clockify request each.
+package mirror
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// ErrNotMirrored is returned when a workspace has not been synced into the store yet
+var ErrNotMirrored = errors.New("workspace is not mirrored")
+
+// Objects are stored as their Clockify JSON, with the columns needed to query them
+const schema = `
+CREATE TABLE IF NOT EXISTS workspaces (
+	id   TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS time_entries (
+	id           TEXT PRIMARY KEY,
+	workspace_id TEXT NOT NULL,
+	user_id      TEXT NOT NULL,
+	start        INTEGER NOT NULL, -- Unix milliseconds
+	data         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS time_entries_user_start ON time_entries (workspace_id, user_id, start);
+CREATE TABLE IF NOT EXISTS projects (
+	id           TEXT PRIMARY KEY,
+	workspace_id TEXT NOT NULL,
+	data         TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS tags (
+	id           TEXT PRIMARY KEY,
+	workspace_id TEXT NOT NULL,
+	data         TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS syncs (
+	workspace_id TEXT NOT NULL,
+	user_id      TEXT NOT NULL,
+	synced_at    INTEGER NOT NULL, -- Unix milliseconds
+	PRIMARY KEY (workspace_id, user_id)
+);
+`
+
+// Store is the SQLite database holding the mirrored data. It is safe for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database at dsn, a file path or a "file:" URI, creating it and its tables
+// if needed
+func Open(dsn string) (*Store, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mirror: %w", err)
+	}
+	// SQLite allows a single writer, serialize on one connection instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode = WAL; PRAGMA busy_timeout = 5000;" + schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create mirror tables: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Mirror is a user's time entries in a workspace as last synced
+type Mirror struct {
+	Workspace clockify.Workspace
+	UserID    string
+	SyncedAt  time.Time
+}
+
+// Mirrors returns every synced workspace and user, the most recently synced first
+func (s *Store) Mirrors() ([]Mirror, error) {
+	rows, err := s.db.Query(`
+		SELECT w.data, s.user_id, s.synced_at FROM syncs s JOIN workspaces w ON w.id = s.workspace_id
+		ORDER BY s.synced_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mirrors []Mirror
+	for rows.Next() {
+		var (
+			m        Mirror
+			data     []byte
+			syncedAt int64
+		)
+		if err := rows.Scan(&data, &m.UserID, &syncedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &m.Workspace); err != nil {
+			return nil, err
+		}
+		m.SyncedAt = time.UnixMilli(syncedAt)
+		mirrors = append(mirrors, m)
+	}
+	return mirrors, rows.Err()
+}
+
+// SyncedAt returns when the user's entries in the workspace were last synced, zero if never
+func (s *Store) SyncedAt(workspaceID, userID string) (time.Time, error) {
+	var syncedAt int64
+	err := s.db.QueryRow("SELECT synced_at FROM syncs WHERE workspace_id = ? AND user_id = ?", workspaceID, userID).Scan(&syncedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(syncedAt), nil
+}
+
+// SaveWorkspace stores the workspace, replacing its previous copy
+func (s *Store) SaveWorkspace(workspace clockify.Workspace) error {
+	data, err := json.Marshal(workspace)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("INSERT OR REPLACE INTO workspaces (id, name, data) VALUES (?, ?, ?)", workspace.ID, workspace.Name, data)
+	return err
+}
+
+// ReplaceTimeEntries replaces the user's entries started since the given time, all of them
+// for a zero time, with the pages of entries, and records the sync time. Entries missing from
+// the pages are deleted, so the replaced range must be fetched in full. It returns how many
+// entries were stored.
+func (s *Store) ReplaceTimeEntries(workspaceID, userID string, since time.Time, pages iter.Seq2[[]clockify.TimeEntry, error], syncedAt time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query, args := "DELETE FROM time_entries WHERE workspace_id = ? AND user_id = ?", []any{workspaceID, userID}
+	if !since.IsZero() {
+		query, args = query+" AND start >= ?", append(args, since.UnixMilli())
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return 0, err
+	}
+
+	stored := 0
+	for entries, err := range pages {
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			if entry.UserID == "" {
+				entry.UserID = userID
+			}
+			if err := saveTimeEntry(tx, entry); err != nil {
+				return 0, err
+			}
+		}
+		stored += len(entries)
+	}
+
+	_, err = tx.Exec("INSERT OR REPLACE INTO syncs (workspace_id, user_id, synced_at) VALUES (?, ?, ?)", workspaceID, userID, syncedAt.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return stored, tx.Commit()
+}
+
+// SaveTimeEntry stores the entry, replacing its previous copy
+func (s *Store) SaveTimeEntry(entry clockify.TimeEntry) error {
+	return saveTimeEntry(s.db, entry)
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func saveTimeEntry(db execer, entry clockify.TimeEntry) error {
+	if entry.TimeInterval == nil {
+		return fmt.Errorf("time entry %s has no time interval", entry.ID)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT OR REPLACE INTO time_entries (id, workspace_id, user_id, start, data) VALUES (?, ?, ?, ?, ?)",
+		entry.ID, entry.WorkspaceID, entry.UserID, entry.TimeInterval.Start.UnixMilli(), data)
+	return err
+}
+
+// DeleteTimeEntry deletes the entry, if it is stored
+func (s *Store) DeleteTimeEntry(id string) error {
+	_, err := s.db.Exec("DELETE FROM time_entries WHERE id = ?", id)
+	return err
+}
+
+// TimeEntries returns the user's entries starting in [start, end), newest first like Clockify
+// lists them. ErrNotMirrored is returned if the user's entries were never synced.
+func (s *Store) TimeEntries(workspaceID, userID string, start, end time.Time) ([]clockify.TimeEntry, error) {
+	syncedAt, err := s.SyncedAt(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if syncedAt.IsZero() {
+		return nil, fmt.Errorf("time entries of %s in %s: %w", userID, workspaceID, ErrNotMirrored)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT data FROM time_entries WHERE workspace_id = ? AND user_id = ? AND start >= ? AND start < ?
+		ORDER BY start DESC`, workspaceID, userID, start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	return scanAll[clockify.TimeEntry](rows)
+}
+
+// ReplaceProjects replaces every project of the workspace
+func (s *Store) ReplaceProjects(workspaceID string, projects []clockify.Project) error {
+	return replaceAll(s.db, "projects", workspaceID, projects, func(p clockify.Project) string { return p.ID })
+}
+
+// SaveProject stores the project, replacing its previous copy
+func (s *Store) SaveProject(project clockify.Project) error {
+	return save(s.db, "projects", project.ID, project.WorkspaceID, project)
+}
+
+// Projects returns the workspace projects
+func (s *Store) Projects(workspaceID string) ([]clockify.Project, error) {
+	rows, err := s.db.Query("SELECT data FROM projects WHERE workspace_id = ?", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll[clockify.Project](rows)
+}
+
+// ProjectNames returns the names of the workspace projects by ID, like report.ProjectNames
+func (s *Store) ProjectNames(workspaceID string) (map[string]string, error) {
+	projects, err := s.Projects(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(projects))
+	for _, project := range projects {
+		names[project.ID] = project.Name
+	}
+	return names, nil
+}
+
+// ReplaceTags replaces every tag of the workspace
+func (s *Store) ReplaceTags(workspaceID string, tags []clockify.Tag) error {
+	return replaceAll(s.db, "tags", workspaceID, tags, func(t clockify.Tag) string { return t.ID })
+}
+
+// SaveTag stores the tag, replacing its previous copy
+func (s *Store) SaveTag(tag clockify.Tag) error {
+	return save(s.db, "tags", tag.ID, tag.WorkspaceID, tag)
+}
+
+// DeleteTag deletes the tag, if it is stored
+func (s *Store) DeleteTag(id string) error {
+	_, err := s.db.Exec("DELETE FROM tags WHERE id = ?", id)
+	return err
+}
+
+// Tags returns the workspace tags
+func (s *Store) Tags(workspaceID string) ([]clockify.Tag, error) {
+	rows, err := s.db.Query("SELECT data FROM tags WHERE workspace_id = ?", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll[clockify.Tag](rows)
+}
+
+// TagNames returns the names of the workspace tags by ID, like report.TagNames
+func (s *Store) TagNames(workspaceID string) (map[string]string, error) {
+	tags, err := s.Tags(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		names[tag.ID] = tag.Name
+	}
+	return names, nil
+}
+
+// save stores an object in one of the tables keyed by ID and workspace
+func save(db execer, table, id, workspaceID string, object any) error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT OR REPLACE INTO "+table+" (id, workspace_id, data) VALUES (?, ?, ?)", id, workspaceID, data)
+	return err
+}
+
+// replaceAll replaces the objects of a workspace in one of the tables keyed by ID and workspace
+func replaceAll[T any](db *sql.DB, table, workspaceID string, objects []T, id func(T) string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM "+table+" WHERE workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := save(tx, table, id(object), workspaceID, object); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// scanAll decodes the JSON data column of every row
+func scanAll[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+
+	var all []T
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var object T
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, err
+		}
+		all = append(all, object)
+	}
+	return all, rows.Err()
+}
@@ -0,0 +1,164 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// DefaultWindow is how far before the last sync an incremental sync fetches entries again
+const DefaultWindow = 7 * 24 * time.Hour
+
+// Events are the webhook events the syncer applies to the store. Besides the default ones,
+// they have to be registered with clockify.WithEvents.
+var Events = []clockify.WebhookEvent{
+	clockify.NewTimerStartedEvent,
+	clockify.TimerStoppedEvent,
+	clockify.NewTimeEntryEvent,
+	clockify.TimeEntryUpdatedEvent,
+	clockify.TimeEntryDeletedEvent,
+	clockify.NewProjectEvent,
+	clockify.NewTagEvent,
+	clockify.TagUpdatedEvent,
+	clockify.TagDeletedEvent,
+}
+
+// Option configures a Syncer
+type Option func(*Syncer)
+
+// WithWindow sets how far before the last sync the entries are fetched again, catching the
+// changes whose webhooks were missed, e.g. while the server was down
+func WithWindow(window time.Duration) Option {
+	return func(s *Syncer) {
+		s.window = window
+	}
+}
+
+// Syncer mirrors a workspace and the time entries of some of its users into a Store
+type Syncer struct {
+	store     *Store
+	client    *clockify.APIClient
+	workspace clockify.Workspace
+	userIDs   []string
+	window    time.Duration
+}
+
+func NewSyncer(store *Store, client *clockify.APIClient, workspace clockify.Workspace, userIDs []string, opts ...Option) *Syncer {
+	s := &Syncer{store: store, client: client, workspace: workspace, userIDs: userIDs, window: DefaultWindow}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Stats are the number of objects a sync stored
+type Stats struct {
+	Entries  int
+	Projects int
+	Tags     int
+	// Users whose entries were backfilled in full, having never been synced before
+	Backfilled int
+}
+
+// Sync refreshes the projects and tags of the workspace and the users' entries. Entries of
+// users synced before are fetched from the window before the last sync on, the others are
+// backfilled in full.
+func (s *Syncer) Sync(ctx context.Context) (Stats, error) {
+	var stats Stats
+	client := s.client.WithContext(ctx)
+
+	if err := s.store.SaveWorkspace(s.workspace); err != nil {
+		return stats, err
+	}
+
+	projects, err := clockify.Collect(client.IterProjects(s.workspace.ID))
+	if err != nil {
+		return stats, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+	if err := s.store.ReplaceProjects(s.workspace.ID, projects); err != nil {
+		return stats, err
+	}
+	stats.Projects = len(projects)
+
+	tags, err := clockify.Collect(client.IterTags(s.workspace.ID))
+	if err != nil {
+		return stats, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	if err := s.store.ReplaceTags(s.workspace.ID, tags); err != nil {
+		return stats, err
+	}
+	stats.Tags = len(tags)
+
+	for _, userID := range s.userIDs {
+		syncedAt, err := s.store.SyncedAt(s.workspace.ID, userID)
+		if err != nil {
+			return stats, err
+		}
+
+		// Taken before fetching, so entries changed meanwhile are within the next window
+		now := time.Now()
+		var since time.Time
+		var start *time.Time
+		if syncedAt.IsZero() {
+			stats.Backfilled++
+		} else {
+			since = syncedAt.Add(-s.window)
+			start = &since
+		}
+
+		stored, err := s.store.ReplaceTimeEntries(s.workspace.ID, userID, since, client.IterTimeEntries(s.workspace.ID, userID, start, nil), now)
+		if err != nil {
+			return stats, fmt.Errorf("failed to sync time entries of user %s: %w", userID, err)
+		}
+		stats.Entries += stored
+	}
+
+	slog.Info("mirror_synced", "workspace_id", s.workspace.ID, "entries", stats.Entries, "projects", stats.Projects, "tags", stats.Tags, "backfilled_users", stats.Backfilled)
+	return stats, nil
+}
+
+// Handle applies a webhook event to the store, it is an events.HandlerFunc. Entries of users
+// not being mirrored and events of other workspaces are ignored.
+func (s *Syncer) Handle(ctx context.Context, event events.Event) error {
+	if event.WorkspaceID != "" && event.WorkspaceID != s.workspace.ID {
+		return nil
+	}
+
+	switch payload := event.Payload.(type) {
+	case *clockify.TimeEntry:
+		if !slices.Contains(s.userIDs, payload.UserID) {
+			return nil
+		}
+		if event.Type == clockify.TimeEntryDeletedEvent {
+			return s.store.DeleteTimeEntry(payload.ID)
+		}
+
+		entry := *payload
+		if entry.WorkspaceID == "" {
+			entry.WorkspaceID = s.workspace.ID
+		}
+		return s.store.SaveTimeEntry(entry)
+	case *clockify.Project:
+		project := *payload
+		if project.WorkspaceID == "" {
+			project.WorkspaceID = s.workspace.ID
+		}
+		return s.store.SaveProject(project)
+	case *clockify.Tag:
+		if event.Type == clockify.TagDeletedEvent {
+			return s.store.DeleteTag(payload.ID)
+		}
+
+		tag := *payload
+		if tag.WorkspaceID == "" {
+			tag.WorkspaceID = s.workspace.ID
+		}
+		return s.store.SaveTag(tag)
+	}
+	return nil
+}
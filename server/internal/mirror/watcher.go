@@ -0,0 +1,213 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// endOfTime is after the start of any stored entry
+var endOfTime = time.UnixMilli(math.MaxInt64)
+
+// PollingWatcher is a change feed for deployments that cannot receive webhooks. Every Poll
+// syncs the store and dispatches what the sync changed to the registry, with the events and
+// payloads Clockify's webhooks deliver, e.g. TIMER_STOPPED with a *clockify.TimeEntry.
+//
+// Project changes other than new projects have no event, and clients are not mirrored.
+type PollingWatcher struct {
+	syncer   *Syncer
+	registry *events.Registry
+
+	mu sync.Mutex // Serializes the polls, each diffs against the store the previous one left
+
+	// lastEvent is the Unix time in nanoseconds the last change was dispatched at
+	lastEvent atomic.Int64
+}
+
+func NewPollingWatcher(syncer *Syncer, registry *events.Registry) *PollingWatcher {
+	return &PollingWatcher{syncer: syncer, registry: registry}
+}
+
+// snapshot is the part of the store a sync replaces
+type snapshot struct {
+	entries  map[string][]clockify.TimeEntry // By user, those within the sync window
+	projects []clockify.Project
+	tags     []clockify.Tag
+}
+
+// Poll syncs the store and dispatches the changes. The first Poll after a restart dispatches
+// those made meanwhile, within the sync window. Entries of users who were never synced are
+// backfilled without events, and so are the projects and tags when no user was.
+func (w *PollingWatcher) Poll(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	store, workspaceID := w.syncer.store, w.syncer.workspace.ID
+
+	// The entries a sync replaces start after the window before the previous sync
+	since := make(map[string]time.Time)
+	for _, userID := range w.syncer.userIDs {
+		syncedAt, err := store.SyncedAt(workspaceID, userID)
+		if err != nil {
+			return err
+		}
+		if !syncedAt.IsZero() {
+			since[userID] = syncedAt.Add(-w.syncer.window)
+		}
+	}
+
+	before, err := w.snapshot(since)
+	if err != nil {
+		return err
+	}
+	if _, err := w.syncer.Sync(ctx); err != nil {
+		return err
+	}
+	after, err := w.snapshot(since)
+	if err != nil {
+		return err
+	}
+
+	var changes []events.Event
+	for _, userID := range w.syncer.userIDs {
+		if _, ok := since[userID]; ok {
+			changes = append(changes, entryChanges(before.entries[userID], after.entries[userID])...)
+		}
+	}
+	if len(since) > 0 {
+		changes = append(changes, projectChanges(before.projects, after.projects)...)
+		changes = append(changes, tagChanges(before.tags, after.tags)...)
+	}
+
+	now := time.Now()
+	for _, event := range changes {
+		event.Source, event.WorkspaceID, event.ReceivedAt = events.SourcePoll, workspaceID, now
+		w.registry.Dispatch(ctx, event)
+	}
+	if len(changes) > 0 {
+		w.lastEvent.Store(now.UnixNano())
+	}
+	slog.Debug("mirror_polled", "workspace_id", workspaceID, "changes", len(changes))
+	return nil
+}
+
+// LastEvent returns when the last change was dispatched, zero if there was none
+func (w *PollingWatcher) LastEvent() time.Time {
+	nanos := w.lastEvent.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// snapshot reads the entries of the users since the given times, and the projects and tags
+func (w *PollingWatcher) snapshot(since map[string]time.Time) (snapshot, error) {
+	store, workspaceID := w.syncer.store, w.syncer.workspace.ID
+	snap := snapshot{entries: make(map[string][]clockify.TimeEntry, len(since))}
+
+	for userID, start := range since {
+		entries, err := store.TimeEntries(workspaceID, userID, start, endOfTime)
+		if err != nil {
+			return snap, fmt.Errorf("failed to read time entries of user %s: %w", userID, err)
+		}
+		snap.entries[userID] = entries
+	}
+
+	var err error
+	if snap.projects, err = store.Projects(workspaceID); err != nil {
+		return snap, fmt.Errorf("failed to read projects: %w", err)
+	}
+	if snap.tags, err = store.Tags(workspaceID); err != nil {
+		return snap, fmt.Errorf("failed to read tags: %w", err)
+	}
+	return snap, nil
+}
+
+// entryChanges returns the events of the entries the sync added, changed or deleted
+func entryChanges(before, after []clockify.TimeEntry) []events.Event {
+	var changes []events.Event
+	diff(before, after, func(e clockify.TimeEntry) string { return e.ID }, func(prev, cur *clockify.TimeEntry) {
+		switch {
+		case prev == nil && running(*cur):
+			changes = append(changes, events.Event{Type: clockify.NewTimerStartedEvent, Payload: cur})
+		case prev == nil:
+			changes = append(changes, events.Event{Type: clockify.NewTimeEntryEvent, Payload: cur})
+		case cur == nil:
+			changes = append(changes, events.Event{Type: clockify.TimeEntryDeletedEvent, Payload: prev})
+		case running(*prev) && !running(*cur):
+			changes = append(changes, events.Event{Type: clockify.TimerStoppedEvent, Payload: cur})
+		default:
+			changes = append(changes, events.Event{Type: clockify.TimeEntryUpdatedEvent, Payload: cur})
+		}
+	})
+	return changes
+}
+
+func running(entry clockify.TimeEntry) bool {
+	return entry.TimeInterval != nil && entry.TimeInterval.End == nil
+}
+
+// projectChanges returns the events of the projects the sync added
+func projectChanges(before, after []clockify.Project) []events.Event {
+	var changes []events.Event
+	diff(before, after, func(p clockify.Project) string { return p.ID }, func(prev, cur *clockify.Project) {
+		if prev == nil {
+			changes = append(changes, events.Event{Type: clockify.NewProjectEvent, Payload: cur})
+		}
+	})
+	return changes
+}
+
+// tagChanges returns the events of the tags the sync added, changed or deleted
+func tagChanges(before, after []clockify.Tag) []events.Event {
+	var changes []events.Event
+	diff(before, after, func(t clockify.Tag) string { return t.ID }, func(prev, cur *clockify.Tag) {
+		switch {
+		case prev == nil:
+			changes = append(changes, events.Event{Type: clockify.NewTagEvent, Payload: cur})
+		case cur == nil:
+			changes = append(changes, events.Event{Type: clockify.TagDeletedEvent, Payload: prev})
+		default:
+			changes = append(changes, events.Event{Type: clockify.TagUpdatedEvent, Payload: cur})
+		}
+	})
+	return changes
+}
+
+// diff calls changed with every object that was added, changed or removed between the lists,
+// nil for the side it is missing from. Objects are compared by their JSON, as they are stored.
+func diff[T any](before, after []T, id func(T) string, changed func(prev, cur *T)) {
+	previous := make(map[string]*T, len(before))
+	for i := range before {
+		previous[id(before[i])] = &before[i]
+	}
+
+	for i := range after {
+		key := id(after[i])
+		prev, ok := previous[key]
+		delete(previous, key)
+		if !ok || !sameJSON(*prev, after[i]) {
+			changed(prev, &after[i])
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(previous)) {
+		changed(previous[key], nil)
+	}
+}
+
+func sameJSON(a, b any) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
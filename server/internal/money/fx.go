@@ -0,0 +1,142 @@
+package money
+
+import (
+	stdcmp "cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNoRate is returned when converting from or to a currency without an exchange rate
+var ErrNoRate = errors.New("no exchange rate")
+
+// Rates are exchange rates against a base currency: how much of each currency one unit of
+// the base buys. A nil *Rates converts nothing but unknown and equal currencies.
+type Rates struct {
+	Base  string
+	Rates map[string]*big.Rat
+}
+
+// rate returns how much of the currency one unit of the base buys
+func (r *Rates) rate(currency string) (*big.Rat, bool) {
+	if currency == r.Base {
+		return big.NewRat(1, 1), true
+	}
+	rate, ok := r.Rates[currency]
+	return rate, ok && rate.Sign() > 0
+}
+
+// Convert returns the amount in the currency, rounded half away from zero. Amounts of an
+// unknown currency are taken to be in it already.
+func (r *Rates) Convert(m Money, to string) (Money, error) {
+	if m.Currency == to || m.Currency == "" || to == "" {
+		return m.In(stdcmp.Or(to, m.Currency)), nil
+	}
+	if r == nil {
+		return Money{}, fmt.Errorf("%w from %s to %s, none are configured", ErrNoRate, m.Currency, to)
+	}
+
+	from, ok := r.rate(m.Currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w for %s", ErrNoRate, m.Currency)
+	}
+	target, ok := r.rate(to)
+	if !ok {
+		return Money{}, fmt.Errorf("%w for %s", ErrNoRate, to)
+	}
+
+	amount := new(big.Rat).SetInt64(m.Amount)
+	amount.Mul(amount, target).Quo(amount, from)
+	return Money{Amount: round(amount), Currency: to}, nil
+}
+
+// Provider supplies the current exchange rates
+type Provider interface {
+	Rates(ctx context.Context) (*Rates, error)
+}
+
+// HTTPProvider fetches exchange rates from a JSON API answering with the base currency and
+// the rates against it, like {"base": "EUR", "rates": {"USD": 1.0842}}, the format of
+// Frankfurter and of the ExchangeRate-API open access ("base_code"). Rates are cached.
+type HTTPProvider struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu      sync.Mutex
+	rates   *Rates
+	fetched time.Time
+}
+
+// NewHTTPProvider fetches the rates from url at most once per ttl
+func NewHTTPProvider(url string, ttl time.Duration) *HTTPProvider {
+	return &HTTPProvider{url: url, ttl: ttl, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// ratesResponse is the body of the APIs HTTPProvider supports
+type ratesResponse struct {
+	Base     string                 `json:"base"`
+	BaseCode string                 `json:"base_code"`
+	Rates    map[string]json.Number `json:"rates"`
+}
+
+// Rates returns the cached rates, fetching them when older than the TTL. The stale rates
+// are returned if fetching fails.
+func (p *HTTPProvider) Rates(ctx context.Context) (*Rates, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rates != nil && time.Since(p.fetched) < p.ttl {
+		return p.rates, nil
+	}
+
+	rates, err := p.fetch(ctx)
+	if err != nil {
+		if p.rates != nil {
+			return p.rates, nil
+		}
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	p.rates, p.fetched = rates, time.Now()
+	return rates, nil
+}
+
+func (p *HTTPProvider) fetch(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var body ratesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	rates := &Rates{Base: stdcmp.Or(body.Base, body.BaseCode), Rates: make(map[string]*big.Rat, len(body.Rates))}
+	if rates.Base == "" {
+		return nil, errors.New("the response has no base currency")
+	}
+	for currency, raw := range body.Rates {
+		rate, ok := new(big.Rat).SetString(raw.String())
+		if !ok {
+			return nil, fmt.Errorf("invalid rate %q for %s", raw, currency)
+		}
+		rates.Rates[currency] = rate
+	}
+	return rates, nil
+}
@@ -0,0 +1,135 @@
+// Package money holds amounts of a currency and converts them between currencies, so that
+// rates, revenue and invoice totals are computed without float rounding drift.
+package money
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts of different currencies
+var ErrCurrencyMismatch = errors.New("currencies differ")
+
+// Money is an amount in hundredths of its currency, e.g. cents, the way Clockify stores
+// rates and budgets. An empty currency is unknown, it combines with any other.
+type Money struct {
+	Amount   int64
+	Currency string // ISO 4217 code, e.g. USD
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// IsZero reports whether the amount is zero, whatever the currency
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// In returns the same amount in the currency, for amounts of an unknown currency
+func (m Money) In(currency string) Money {
+	return Money{Amount: m.Amount, Currency: currency}
+}
+
+// Add returns the sum of both amounts, ErrCurrencyMismatch when their currencies differ
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := common(m, other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub returns the difference of both amounts, ErrCurrencyMismatch when their currencies differ
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Compare compares the amounts of the same currency like cmp.Compare
+func (m Money) Compare(other Money) int {
+	return cmp.Compare(m.Amount, other.Amount)
+}
+
+// Percent returns percent of the amount, rounded half away from zero. A percent that isn't
+// finite, NaN or an infinity, is rejected.
+func (m Money) Percent(percent float64) (Money, error) {
+	rat := new(big.Rat).SetFloat64(percent)
+	if rat == nil {
+		return Money{}, fmt.Errorf("percent %v isn't finite", percent)
+	}
+	share := rat.Mul(rat, new(big.Rat).SetInt64(m.Amount))
+	return Money{Amount: round(share.Quo(share, big.NewRat(100, 1))), Currency: m.Currency}, nil
+}
+
+// Over returns the amount an hourly rate of m comes to over d, rounded half away from zero
+func (m Money) Over(d time.Duration) Money {
+	amount := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(int64(d))),
+		big.NewInt(int64(time.Hour)),
+	)
+	return Money{Amount: round(amount), Currency: m.Currency}
+}
+
+// Ratio returns the share of whole the amount is, 0 for a zero whole
+func (m Money) Ratio(whole Money) float64 {
+	if whole.Amount == 0 {
+		return 0
+	}
+	return float64(m.Amount) / float64(whole.Amount)
+}
+
+// Decimal formats the amount without the currency, e.g. "1250.00"
+func (m Money) Decimal() string {
+	amount, sign := m.Amount, ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// String formats the amount with its currency, e.g. "1250.00 USD"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+// Sum adds up the amounts, ErrCurrencyMismatch when their currencies differ
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// common returns the currency of both amounts, the known one when the other is unknown
+func common(a, b Money) (string, error) {
+	switch {
+	case a.Currency == b.Currency, b.Currency == "":
+		return a.Currency, nil
+	case a.Currency == "":
+		return b.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+}
+
+// round rounds half away from zero to an integer
+func round(r *big.Rat) int64 {
+	quo, rem := new(big.Int).QuoRem(new(big.Int).Abs(r.Num()), r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
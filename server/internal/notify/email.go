@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email sends messages as plain text mails through an SMTP server
+type Email struct {
+	addr string // host:port
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmail creates a notifier mailing from one address to the recipients. Without a username
+// the server is used unauthenticated, e.g. a local relay.
+func NewEmail(addr, username, password, from string, to []string) *Email {
+	e := &Email{addr: addr, from: from, to: to}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e
+}
+
+// Notify sends the message. SMTP has no cancellation, ctx is only checked before sending.
+func (e *Email) Notify(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if msg.Text != "" {
+		body.WriteString(msg.Text + "\n")
+	}
+	if len(msg.Fields) > 0 {
+		body.WriteString("\n")
+		for _, field := range msg.Fields {
+			fmt.Fprintf(&body, "%s: %s\n", field.Name, field.Value)
+		}
+	}
+
+	if err := smtp.SendMail(e.addr, e.auth, e.from, e.to, e.compose(msg.Title, body.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// compose builds the RFC 5322 message with CRLF line endings
+func (e *Email) compose(subject, body string) []byte {
+	headers := []string{
+		"From: " + e.from,
+		"To: " + strings.Join(e.to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+
+	var b bytes.Buffer
+	for _, header := range headers {
+		b.WriteString(header + "\r\n")
+	}
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
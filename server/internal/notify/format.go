@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hukyl/CCWS/internal/budget"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// ProjectNameFunc returns the name of a project, or "" if it is unknown
+type ProjectNameFunc func(projectID string) string
+
+// FormatEvent builds the message for an event. It returns false for events
+// without a message format.
+func FormatEvent(event events.Event, projectName ProjectNameFunc) (Message, bool) {
+	switch payload := event.Payload.(type) {
+	case *clockify.TimeEntry:
+		return formatTimeEntryEvent(event, payload, projectName)
+	case *clockify.Project:
+		if event.Type != clockify.NewProjectEvent {
+			return Message{}, false
+		}
+		msg := Message{Title: "New project: " + payload.Name}
+		if payload.ClientName != "" {
+			msg.Fields = append(msg.Fields, Field{Name: "Client", Value: payload.ClientName})
+		}
+		return msg, true
+	case *clockify.Client:
+		if event.Type != clockify.NewClientEvent {
+			return Message{}, false
+		}
+		return Message{Title: "New client: " + payload.Name}, true
+	case *clockify.Tag:
+		if event.Type != clockify.NewTagEvent {
+			return Message{}, false
+		}
+		return Message{Title: "New tag: " + payload.Name}, true
+	case *budget.Alert:
+		return formatBudgetAlert(payload), true
+	}
+	return Message{}, false
+}
+
+func formatTimeEntryEvent(event events.Event, entry *clockify.TimeEntry, projectName ProjectNameFunc) (Message, bool) {
+	var msg Message
+	switch event.Type {
+	case clockify.NewTimerStartedEvent:
+		msg.Title = "Timer started"
+	case clockify.TimerStoppedEvent:
+		msg.Title = "Timer stopped"
+	case clockify.NewTimeEntryEvent:
+		msg.Title = "Time entry added"
+	case events.LongRunningTimerEvent:
+		msg.Title = "Timer running for a long time"
+	default:
+		return Message{}, false
+	}
+
+	msg.Text = entry.String()
+
+	if entry.ProjectID != "" {
+		name := entry.ProjectID
+		if projectName != nil {
+			if n := projectName(entry.ProjectID); n != "" {
+				name = n
+			}
+		}
+		msg.Fields = append(msg.Fields, Field{Name: "Project", Value: name})
+	}
+
+	if interval := entry.TimeInterval; interval != nil {
+		msg.Fields = append(msg.Fields, Field{Name: "Started", Value: interval.Start.Local().Format("2006-01-02 15:04")})
+
+		duration := report.EntryDuration(*entry, event.ReceivedAt)
+		if interval.End != nil || event.Type == events.LongRunningTimerEvent {
+			msg.Fields = append(msg.Fields, Field{Name: "Duration", Value: report.FormatDuration(duration)})
+		}
+	}
+
+	return msg, true
+}
+
+func formatBudgetAlert(alert *budget.Alert) Message {
+	title := fmt.Sprintf("%s reached %d%% of its budget", alert.Project, alert.Threshold)
+	if alert.Threshold >= 100 {
+		title = fmt.Sprintf("%s is over budget", alert.Project)
+	}
+
+	return Message{
+		Title: title,
+		Fields: []Field{
+			{Name: "Tracked", Value: fmt.Sprintf("%s (%.0f%%)", report.FormatDuration(alert.Consumed), alert.Percent())},
+			{Name: "Budget", Value: report.FormatDuration(alert.Limit)},
+			{Name: "Period", Value: string(alert.Period)},
+		},
+	}
+}
+
+// Subscribe sends a message to notifier for every event of the given types that has a
+// message format. Delivery failures are returned to the registry, which logs them.
+func Subscribe(registry *events.Registry, notifier Notifier, types []clockify.WebhookEvent, projectName ProjectNameFunc) {
+	registry.On("notify", func(ctx context.Context, event events.Event) error {
+		msg, ok := FormatEvent(event, projectName)
+		if !ok {
+			return nil
+		}
+		return notifier.Notify(ctx, msg)
+	}, types...)
+}
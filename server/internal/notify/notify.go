@@ -0,0 +1,118 @@
+// Package notify delivers human readable messages about events to chat and mail backends.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Message is a backend independent notification
+type Message struct {
+	Title  string
+	Text   string
+	Fields []Field
+}
+
+// Field is a labelled value shown alongside the message text
+type Field struct {
+	Name  string
+	Value string
+}
+
+// String renders the message as plain text, for backends without formatting
+func (m Message) String() string {
+	var b bytes.Buffer
+	b.WriteString(m.Title)
+	if m.Text != "" {
+		b.WriteString("\n" + m.Text)
+	}
+	for _, field := range m.Fields {
+		fmt.Fprintf(&b, "\n%s: %s", field.Name, field.Value)
+	}
+	return b.String()
+}
+
+// Notifier sends messages to a single destination
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Multi sends every message to all notifiers, continuing past failures
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Option configures the HTTP based notifiers
+type Option func(*options)
+
+type options struct {
+	client *http.Client
+}
+
+// WithHTTPClient replaces the client used to reach the backend
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+const defaultTimeout = 10 * time.Second
+
+func applyOptions(opts []Option) options {
+	o := options{client: &http.Client{Timeout: defaultTimeout}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// postJSON posts payload and fails on non-2xx responses, including the response body.
+// The URL is left out of errors since webhook URLs embed their secret.
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return unwrapURLError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+	return nil
+}
+
+// unwrapURLError drops the request URL from client errors, for backends with secrets in the URL
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
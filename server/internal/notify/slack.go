@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Slack posts messages to a Slack incoming webhook
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewSlack(webhookURL string, opts ...Option) *Slack {
+	o := applyOptions(opts)
+	return &Slack{webhookURL: webhookURL, client: o.client}
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+// slackMaxFields is the most fields Slack accepts in a single section block
+const slackMaxFields = 10
+
+type slackPayload struct {
+	Text   string       `json:"text"` // Shown in notifications and clients without blocks
+	Blocks []slackBlock `json:"blocks"`
+}
+
+func (s *Slack) Notify(ctx context.Context, msg Message) error {
+	payload := slackPayload{
+		Text: msg.Title,
+		Blocks: []slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: msg.Title}},
+		},
+	}
+	if msg.Text != "" {
+		payload.Blocks = append(payload.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: msg.Text}})
+	}
+	if len(msg.Fields) > 0 {
+		fields := make([]slackText, 0, len(msg.Fields))
+		for _, field := range msg.Fields[:min(len(msg.Fields), slackMaxFields)] {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", field.Name, field.Value)})
+		}
+		payload.Blocks = append(payload.Blocks, slackBlock{Type: "section", Fields: fields})
+	}
+
+	if err := postJSON(ctx, s.client, s.webhookURL, payload); err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	return nil
+}
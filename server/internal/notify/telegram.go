@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"time"
+)
+
+// TelegramAPIURL is the Telegram Bot API endpoint, the bot token is appended to it
+const TelegramAPIURL = "https://api.telegram.org/bot"
+
+// Telegram sends messages through a Telegram bot and receives the messages sent to it
+type Telegram struct {
+	apiURL string // Including the token
+	chatID string
+	client *http.Client
+}
+
+// NewTelegram creates a notifier posting to chatID as the bot identified by token
+func NewTelegram(token, chatID string, opts ...Option) *Telegram {
+	o := applyOptions(opts)
+	return &Telegram{apiURL: TelegramAPIURL + token, chatID: chatID, client: o.client}
+}
+
+// ChatID returns the chat notifications are sent to
+func (t *Telegram) ChatID() string {
+	return t.chatID
+}
+
+func (t *Telegram) Notify(ctx context.Context, msg Message) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<b>%s</b>", html.EscapeString(msg.Title))
+	if msg.Text != "" {
+		b.WriteString("\n" + html.EscapeString(msg.Text))
+	}
+	for _, field := range msg.Fields {
+		fmt.Fprintf(&b, "\n<i>%s:</i> %s", html.EscapeString(field.Name), html.EscapeString(field.Value))
+	}
+
+	return t.SendHTML(ctx, t.chatID, b.String())
+}
+
+// SendHTML sends a message formatted with Telegram's HTML subset to a chat
+func (t *Telegram) SendHTML(ctx context.Context, chatID, text string) error {
+	params := map[string]any{"chat_id": chatID, "text": text, "parse_mode": "HTML"}
+	if err := t.call(ctx, t.client, "sendMessage", params, nil); err != nil {
+		return fmt.Errorf("failed to send Telegram message: %w", err)
+	}
+	return nil
+}
+
+// TelegramUpdate is an incoming update, only text messages are decoded
+type TelegramUpdate struct {
+	ID      int64 `json:"update_id"`
+	Message *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// GetUpdates long-polls for updates after offset, waiting up to timeout for new ones
+func (t *Telegram) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]TelegramUpdate, error) {
+	// The poll outlives the client timeout meant for regular calls
+	poll := *t.client
+	poll.Timeout = timeout + defaultTimeout
+
+	params := map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}
+
+	var updates []TelegramUpdate
+	if err := t.call(ctx, &poll, "getUpdates", params, &updates); err != nil {
+		return nil, fmt.Errorf("failed to get Telegram updates: %w", err)
+	}
+	return updates, nil
+}
+
+// telegramResponse is the envelope of every Bot API response
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+func (t *Telegram) call(ctx context.Context, client *http.Client, method string, params, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL holds the token, keep it out of the error
+		return fmt.Errorf("%s request failed: %w", method, unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	var response telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("unexpected %s response (%s): %w", method, resp.Status, err)
+	}
+	if !response.OK {
+		return fmt.Errorf("%s failed: %s", method, response.Description)
+	}
+
+	if result != nil {
+		return json.Unmarshal(response.Result, result)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package outbox queues the time entry writes made while Clockify is unreachable and replays
+// them in order once it is back, skipping those whose entry was changed on the server meanwhile.
+package outbox
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/lifecycle"
+)
+
+// Kind is what a queued operation does
+type Kind string
+
+const (
+	KindCreate Kind = "create" // Starts a timer or logs a finished entry
+	KindUpdate Kind = "update"
+	KindStop   Kind = "stop" // Stops the running timer
+)
+
+// localPrefix starts the IDs of queued operations, which stand in for the entries of queued
+// creates until they are replayed
+const localPrefix = "local-"
+
+// Target names what a queued entry tracks when its IDs could not be looked up offline. The
+// names are resolved when the operation is replayed.
+type Target struct {
+	Project string   `json:"project,omitempty"`
+	Task    string   `json:"task,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// Operation is a queued write
+type Operation struct {
+	ID          string    `json:"id"`
+	Kind        Kind      `json:"kind"`
+	WorkspaceID string    `json:"workspaceId"`
+	UserID      string    `json:"userId"`
+	QueuedAt    time.Time `json:"queuedAt"`
+
+	// Create: the entry, with the names in Target still to be resolved
+	Entry  *clockify.NewTimeEntryRequest `json:"entry,omitempty"`
+	Target *Target                       `json:"target,omitempty"`
+
+	// Update and stop: the entry, the ID of a queued create for entries not created yet. A stop
+	// without one stops whatever timer runs.
+	EntryID string `json:"entryId,omitempty"`
+	// Update: the changed entry, and the entry as it was when changed
+	Update *clockify.UpdateTimeEntryRequest `json:"update,omitempty"`
+	Base   *clockify.TimeEntry              `json:"base,omitempty"`
+	// Stop: when the timer was stopped
+	End *time.Time `json:"end,omitempty"`
+
+	// Why the last replay skipped the operation, empty if it was not replayed yet
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// Local reports whether id is the stand-in ID of an entry still to be created
+func Local(id string) bool {
+	return strings.HasPrefix(id, localPrefix)
+}
+
+// Describe returns a one-line description of the operation
+func (op Operation) Describe() string {
+	switch op.Kind {
+	case KindCreate:
+		what := "timer"
+		if op.Entry.End != nil {
+			what = "entry"
+		}
+		description := op.Entry.Description
+		if description == "" {
+			description = "(no description)"
+		}
+		return fmt.Sprintf("create %s %q from %s", what, description, op.Entry.Start.Local().Format("Jan 2 15:04"))
+	case KindUpdate:
+		return fmt.Sprintf("update entry %s to %q", op.EntryID, op.Update.Description)
+	case KindStop:
+		return fmt.Sprintf("stop timer at %s", op.End.Local().Format("Jan 2 15:04"))
+	default:
+		return string(op.Kind)
+	}
+}
+
+// state is the content of the queue file
+type state struct {
+	Next       int         `json:"next"`
+	Operations []Operation `json:"operations"`
+}
+
+// Queue is a list of operations persisted to a file, created when the first one is queued. It
+// is safe for concurrent use within a process.
+type Queue struct {
+	mu   sync.Mutex
+	file *lifecycle.Marker
+}
+
+// DefaultPath is the queue file in the user's cache directory
+func DefaultPath() string {
+	return lifecycle.MarkerPath("outbox")
+}
+
+func Open(path string) *Queue {
+	return &Queue{file: lifecycle.NewMarker(path)}
+}
+
+func (q *Queue) load() (state, error) {
+	var st state
+	_, err := q.file.Load(&st)
+	return st, err
+}
+
+func (q *Queue) save(st state) error {
+	if len(st.Operations) == 0 {
+		return q.file.Remove()
+	}
+	return q.file.Save(st)
+}
+
+// Operations returns the queued operations in the order they are replayed
+func (q *Queue) Operations() ([]Operation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	st, err := q.load()
+	return st.Operations, err
+}
+
+func (q *Queue) add(op Operation) (Operation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	st, err := q.load()
+	if err != nil {
+		return Operation{}, err
+	}
+
+	st.Next++
+	op.ID = localPrefix + strconv.Itoa(st.Next)
+	op.QueuedAt = time.Now()
+	st.Operations = append(st.Operations, op)
+
+	if err := q.save(st); err != nil {
+		return Operation{}, err
+	}
+	return op, nil
+}
+
+// Create queues an entry to be created for the user, a timer if it has no end. Names in target
+// are resolved into the entry's project, task and tags when replayed. The ID of the returned
+// operation stands for the entry in later updates and stops.
+func (q *Queue) Create(workspaceID, userID string, entry clockify.NewTimeEntryRequest, target *Target) (Operation, error) {
+	return q.add(Operation{Kind: KindCreate, WorkspaceID: workspaceID, UserID: userID, Entry: &entry, Target: target})
+}
+
+// Update queues a change of the entry. The entry is the copy the change was made on, the update
+// is skipped as a conflict if the server copy differs from it when replayed.
+func (q *Queue) Update(workspaceID string, entry clockify.TimeEntry, update clockify.UpdateTimeEntryRequest) (Operation, error) {
+	return q.add(Operation{Kind: KindUpdate, WorkspaceID: workspaceID, UserID: entry.UserID, EntryID: entry.ID, Update: &update, Base: &entry})
+}
+
+// Stop queues stopping the user's timer at end. With an entry ID, the stop is skipped as a
+// conflict if another timer runs when replayed.
+func (q *Queue) Stop(workspaceID, userID, entryID string, end time.Time) (Operation, error) {
+	return q.add(Operation{Kind: KindStop, WorkspaceID: workspaceID, UserID: userID, EntryID: entryID, End: &end})
+}
+
+// Drop removes a queued operation, e.g. a conflict that should not be forced
+func (q *Queue) Drop(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	st, err := q.load()
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(st.Operations, func(op Operation) bool { return op.ID == id })
+	if i < 0 {
+		return fmt.Errorf("operation %s %w", id, clockify.ErrNotFound)
+	}
+	st.Operations = slices.Delete(st.Operations, i, i+1)
+	return q.save(st)
+}
+
+// RunningTimer returns the stand-in ID of the last timer queued for the user, if no stop was
+// queued after it
+func (q *Queue) RunningTimer(workspaceID, userID string) (string, error) {
+	ops, err := q.Operations()
+	if err != nil {
+		return "", err
+	}
+
+	for _, op := range slices.Backward(ops) {
+		if op.WorkspaceID != workspaceID || op.UserID != userID {
+			continue
+		}
+		switch {
+		case op.Kind == KindStop:
+			return "", nil
+		case op.Kind == KindCreate && op.Entry.End == nil:
+			return op.ID, nil
+		}
+	}
+	return "", nil
+}
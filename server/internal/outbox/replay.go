@@ -0,0 +1,262 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// ErrUnreachable is returned by Replay when Clockify became unreachable again. The operations
+// not replayed yet stay queued.
+var ErrUnreachable = errors.New("clockify is unreachable")
+
+// Applied is an operation the replay performed
+type Applied struct {
+	Operation
+	Entry *clockify.TimeEntry // The entry as created, updated or stopped
+}
+
+// Result is what a replay did
+type Result struct {
+	Applied []Applied
+	// Operations kept queued, with the reason in Conflict
+	Conflicts []Operation
+}
+
+// conflict is why an operation cannot be replayed as it was queued
+type conflict string
+
+func (c conflict) Error() string {
+	return string(c)
+}
+
+// Replay performs the queued operations in order, removing each once Clockify accepted it.
+//
+// Operations whose entry changed on the server since they were queued are kept as conflicts:
+// updates of entries that were edited or deleted, stops when no timer or another timer runs,
+// and timers started before one that was started on the server. With force, conflicts are
+// replayed anyway, as far as Clockify allows. Operations Clockify rejects, and those depending
+// on an entry that could not be created, are kept as conflicts as well. Creates that reached
+// Clockify before failing are not repeated, the entry found instead.
+func (q *Queue) Replay(client *clockify.APIClient, force bool) (Result, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var result Result
+	st, err := q.load()
+	if err != nil {
+		return result, err
+	}
+
+	var remaining []Operation
+	// Entries of queued creates by stand-in ID, empty for those that could not be created
+	created := make(map[string]string)
+
+	pending := st.Operations
+	for i, op := range pending {
+		op.Conflict = ""
+
+		if Local(op.EntryID) {
+			id, ok := created[op.EntryID]
+			if !ok || id == "" {
+				op.Conflict = fmt.Sprintf("depends on %s, which was not replayed", op.EntryID)
+				remaining = append(remaining, op)
+				result.Conflicts = append(result.Conflicts, op)
+				continue
+			}
+			op.EntryID = id
+		}
+
+		entry, err := replay(client, op, force)
+		var c conflict
+		switch {
+		case clockify.IsUnreachable(err):
+			// Keep this and the following operations for the next replay
+			st.Operations = append(slices.Clone(remaining), pending[i:]...)
+			if saveErr := q.save(st); saveErr != nil {
+				return result, saveErr
+			}
+			return result, fmt.Errorf("%w: %w", ErrUnreachable, err)
+		case errors.As(err, &c):
+			op.Conflict = c.Error()
+		case err != nil:
+			op.Conflict = err.Error()
+		}
+
+		if op.Conflict != "" {
+			if op.Kind == KindCreate {
+				created[op.ID] = ""
+			}
+			slog.Info("outbox_conflict", "operation", op.ID, "kind", op.Kind, "conflict", op.Conflict)
+			remaining = append(remaining, op)
+			result.Conflicts = append(result.Conflicts, op)
+			continue
+		}
+
+		if op.Kind == KindCreate {
+			created[op.ID] = entry.ID
+		}
+		result.Applied = append(result.Applied, Applied{Operation: op, Entry: entry})
+
+		// Save after every operation, so one Clockify accepted is never replayed twice
+		st.Operations = append(slices.Clone(remaining), pending[i+1:]...)
+		if err := q.save(st); err != nil {
+			return result, err
+		}
+	}
+
+	// Conflicts depending on a create that was replayed now refer to its entry
+	for i, op := range remaining {
+		if id := created[op.EntryID]; id != "" {
+			remaining[i].EntryID = id
+		}
+	}
+	st.Operations = remaining
+	return result, q.save(st)
+}
+
+// replay performs a single operation, returning a conflict error if the server state changed
+// in a way the operation does not expect
+func replay(client *clockify.APIClient, op Operation, force bool) (*clockify.TimeEntry, error) {
+	switch op.Kind {
+	case KindCreate:
+		return replayCreate(client, op, force)
+	case KindUpdate:
+		return replayUpdate(client, op, force)
+	case KindStop:
+		return replayStop(client, op, force)
+	default:
+		return nil, conflict(fmt.Sprintf("unknown operation %q", op.Kind))
+	}
+}
+
+func replayCreate(client *clockify.APIClient, op Operation, force bool) (*clockify.TimeEntry, error) {
+	entry := *op.Entry
+	if err := resolveTarget(client, op.WorkspaceID, op.Target, &entry); err != nil {
+		return nil, err
+	}
+
+	if entry.End == nil && !force {
+		running, err := client.GetRunningTimeEntry(op.WorkspaceID, op.UserID)
+		if err != nil {
+			return nil, err
+		}
+		// The timer was started after all, the request timing out after reaching Clockify
+		if running != nil && running.TimeInterval.Start.Sub(entry.Start).Abs() < time.Second && running.Description == entry.Description {
+			return running, nil
+		}
+		// Starting the queued timer would stop one started later on another device
+		if running != nil && running.TimeInterval.Start.After(entry.Start.Add(time.Second)) {
+			return nil, conflict(fmt.Sprintf("timer %q was started on the server at %s", running.Description, running.TimeInterval.Start.Local().Format("Jan 2 15:04")))
+		}
+	}
+
+	if entry.End != nil {
+		// Queued unrounded, the increments may need the project Clockify was unreachable for
+		if err := client.ApplyBillingIncrements(op.WorkspaceID, &entry); err != nil {
+			return nil, err
+		}
+		// The entry was created after all, the request timing out after reaching Clockify
+		existing, err := client.FindCreatedEntry(op.WorkspaceID, op.UserID, entry)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	if entry.TagIDs == nil {
+		entry.TagIDs = make([]string, 0)
+	}
+	return client.CreateTimeEntryForUser(op.WorkspaceID, op.UserID, entry)
+}
+
+// resolveTarget looks up the names of the target into the entry
+func resolveTarget(client *clockify.APIClient, workspaceID string, target *Target, entry *clockify.NewTimeEntryRequest) error {
+	if target == nil {
+		return nil
+	}
+
+	if target.Project != "" {
+		project, err := client.FindProjectByName(workspaceID, target.Project)
+		if err != nil {
+			return err
+		}
+		entry.ProjectID = project.ID
+	}
+	if target.Task != "" {
+		if entry.ProjectID == "" {
+			return conflict("a task requires a project")
+		}
+		task, err := client.FindTaskByName(workspaceID, entry.ProjectID, target.Task)
+		if err != nil {
+			return err
+		}
+		entry.TaskID = task.ID
+	}
+	for _, name := range target.Tags {
+		tag, err := client.FindTagByName(workspaceID, name)
+		if err != nil {
+			return err
+		}
+		entry.TagIDs = append(entry.TagIDs, tag.ID)
+	}
+	return nil
+}
+
+func replayUpdate(client *clockify.APIClient, op Operation, force bool) (*clockify.TimeEntry, error) {
+	current, err := client.GetTimeEntry(op.WorkspaceID, op.EntryID)
+	if errors.Is(err, clockify.ErrNotFound) {
+		return nil, conflict("the entry was deleted on the server")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Base is missing for updates of entries created by the queue, nobody else knew them
+	if op.Base != nil && !force && !sameEntry(*op.Base, *current) {
+		return nil, conflict("the entry was changed on the server")
+	}
+	return client.UpdateTimeEntry(op.WorkspaceID, op.EntryID, *op.Update)
+}
+
+func replayStop(client *clockify.APIClient, op Operation, force bool) (*clockify.TimeEntry, error) {
+	running, err := client.GetRunningTimeEntry(op.WorkspaceID, op.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case running == nil:
+		return nil, conflict("no timer is running on the server")
+	case op.EntryID != "" && running.ID != op.EntryID && !force:
+		return nil, conflict(fmt.Sprintf("timer %q was started on the server meanwhile", running.Description))
+	case op.End.Before(running.TimeInterval.Start):
+		return nil, conflict("the running timer started after the stop")
+	}
+	return client.StopTimeEntry(op.WorkspaceID, op.UserID, *op.End)
+}
+
+// sameEntry reports whether the fields an update sets are equal in both copies of an entry
+func sameEntry(a, b clockify.TimeEntry) bool {
+	if a.Description != b.Description || a.ProjectID != b.ProjectID || a.TaskID != b.TaskID || a.Billable != b.Billable {
+		return false
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(a.TagIDs)), slices.Sorted(slices.Values(b.TagIDs))) {
+		return false
+	}
+	if a.TimeInterval == nil || b.TimeInterval == nil {
+		return a.TimeInterval == b.TimeInterval
+	}
+
+	endA, endB := a.TimeInterval.End, b.TimeInterval.End
+	if (endA == nil) != (endB == nil) || endA != nil && !endA.Equal(*endB) {
+		return false
+	}
+	return a.TimeInterval.Start.Equal(b.TimeInterval.Start)
+}
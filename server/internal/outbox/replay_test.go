@@ -0,0 +1,55 @@
+package outbox_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/clockify/clockifytest"
+	"github.com/Hukyl/CCWS/internal/outbox"
+)
+
+// TestReplayCreateAppliesBillingIncrements replays an entry queued while Clockify was
+// unreachable, which must be rounded like one logged online
+func TestReplayCreateAppliesBillingIncrements(t *testing.T) {
+	srv := clockifytest.NewServer()
+	t.Cleanup(srv.Close)
+	workspace := srv.AddWorkspace("Replay")
+	project := srv.AddProject(workspace.ID, "Client work")
+	user := srv.CurrentUser()
+
+	queue := outbox.Open(filepath.Join(t.TempDir(), "outbox.json"))
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	end := start.Add(20 * time.Minute)
+	request := clockify.NewTimeEntryRequest{Start: start, End: &end, Description: "Review", Billable: true}
+	if _, err := queue.Create(workspace.ID, user.ID, request, &outbox.Target{Project: project.Name}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	client := srv.Client(clockify.WithBillingIncrements(clockify.BillingIncrements{Increment: 15 * time.Minute}))
+	result, err := queue.Replay(client, false)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(result.Applied) != 1 || len(result.Conflicts) != 0 {
+		t.Fatalf("Replay applied %d and kept %d conflicts, want 1 and 0", len(result.Applied), len(result.Conflicts))
+	}
+
+	entries := srv.TimeEntries(workspace.ID)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	interval := entries[0].TimeInterval
+	if got, want := interval.End.Sub(interval.Start), 30*time.Minute; got != want {
+		t.Errorf("replayed entry lasts %s, want %s", got, want)
+	}
+
+	// Replaying again creates nothing, the operation was removed
+	if _, err := queue.Replay(client, false); err != nil {
+		t.Fatalf("second Replay: %v", err)
+	}
+	if got := len(srv.TimeEntries(workspace.ID)); got != 1 {
+		t.Errorf("got %d entries after the second replay, want 1", got)
+	}
+}
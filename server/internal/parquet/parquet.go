@@ -0,0 +1,536 @@
+// Package parquet writes Apache Parquet files of flat rows, for loading into DuckDB, Spark or
+// a warehouse.
+//
+// It covers what the exports need: required and optional columns of strings, integers,
+// floats, booleans and UTC timestamps, and lists of strings. Values are PLAIN encoded into a
+// single gzip-compressed data page per column and row group.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"time"
+)
+
+const magic = "PAR1"
+
+// DefaultRowGroupSize is how many rows a row group holds unless WithRowGroupSize is given
+const DefaultRowGroupSize = 64 * 1024
+
+// Kind is the type of the values of a column
+type Kind int
+
+const (
+	String     Kind = iota // string
+	Int32                  // int32 or int
+	Int64                  // int64, int or time.Duration
+	Float64                // float64
+	Bool                   // bool
+	Timestamp              // time.Time, stored as UTC milliseconds
+	StringList             // []string
+)
+
+// Column is a column of the schema. Optional columns take nil values, a nil list being null
+// and an empty one written as such.
+type Column struct {
+	Name     string
+	Kind     Kind
+	Optional bool
+}
+
+// Physical types, repetitions, encodings and codecs of the Parquet format
+const (
+	typeBoolean   = 0
+	typeInt32     = 1
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+	repetitionRepeated = 2
+
+	convertedUTF8            = 0
+	convertedList            = 3
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecGzip = 2
+
+	pageData = 0
+)
+
+func (k Kind) physical() int32 {
+	switch k {
+	case Int32:
+		return typeInt32
+	case Int64, Timestamp:
+		return typeInt64
+	case Float64:
+		return typeDouble
+	case Bool:
+		return typeBoolean
+	default:
+		return typeByteArray
+	}
+}
+
+// Option configures a Writer
+type Option func(*Writer)
+
+// WithRowGroupSize sets how many rows are buffered into a row group before it is written
+func WithRowGroupSize(rows int) Option {
+	return func(w *Writer) {
+		w.rowGroupSize = rows
+	}
+}
+
+// Writer writes rows to a Parquet file. Rows are buffered in memory until their row group is
+// full, Close writes the rest and the footer.
+type Writer struct {
+	w            *countingWriter
+	columns      []Column
+	rowGroupSize int
+
+	buffered []column
+	rows     int
+	groups   []rowGroup
+	err      error
+}
+
+// column is the buffered data of a column in the current row group
+type column struct {
+	values    []any
+	defLevels []int32
+	repLevels []int32
+}
+
+type rowGroup struct {
+	chunks []chunk
+	size   int64
+	rows   int
+}
+
+// chunk is the metadata of a written column chunk
+type chunk struct {
+	offset             int64
+	values             int
+	uncompressed, size int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewWriter starts a file of the columns on w
+func NewWriter(w io.Writer, columns []Column, opts ...Option) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	pw := &Writer{
+		w:            &countingWriter{w: w},
+		columns:      columns,
+		rowGroupSize: DefaultRowGroupSize,
+		buffered:     make([]column, len(columns)),
+	}
+	for _, opt := range opts {
+		opt(pw)
+	}
+	if _, err := io.WriteString(pw.w, magic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// Write adds a row of one value per column, in the order of the columns
+func (w *Writer) Write(values ...any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("parquet: %d values for %d columns", len(values), len(w.columns))
+	}
+
+	// Checked in full first, so that a bad row leaves the buffers as they were
+	normalized := make([]any, len(values))
+	for i, value := range values {
+		v, err := normalize(w.columns[i], value)
+		if err != nil {
+			return err
+		}
+		normalized[i] = v
+	}
+
+	for i, value := range normalized {
+		w.buffered[i].add(w.columns[i], value)
+	}
+	w.rows++
+	if w.rows >= w.rowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close writes the buffered rows and the footer. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	footer := w.footer()
+	if _, err := w.w.Write(footer); err != nil {
+		return err
+	}
+	if err := binary.Write(w.w, binary.LittleEndian, uint32(len(footer))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, magic)
+	w.err = errors.New("parquet: writer closed")
+	return err
+}
+
+// normalize checks that the value suits the column, converting it to the type it is encoded from
+func normalize(col Column, value any) (any, error) {
+	if value == nil {
+		if !col.Optional {
+			return nil, fmt.Errorf("parquet: column %s is required", col.Name)
+		}
+		return nil, nil
+	}
+
+	var ok bool
+	switch col.Kind {
+	case String:
+		_, ok = value.(string)
+	case Int32:
+		switch v := value.(type) {
+		case int32:
+			ok = true
+		case int:
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int32(v), nil
+			}
+		}
+	case Int64:
+		switch v := value.(type) {
+		case int64:
+			ok = true
+		case int:
+			return int64(v), nil
+		case time.Duration:
+			return int64(v), nil
+		}
+	case Float64:
+		_, ok = value.(float64)
+	case Bool:
+		_, ok = value.(bool)
+	case Timestamp:
+		if t, isTime := value.(time.Time); isTime {
+			return t.UnixMilli(), nil
+		}
+	case StringList:
+		var list []string
+		if list, ok = value.([]string); ok && list == nil && !col.Optional {
+			return []string{}, nil
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("parquet: invalid value %v (%T) for column %s", value, value, col.Name)
+	}
+	return value, nil
+}
+
+// maxLevels returns the maximum definition and repetition levels of the column
+func (col Column) maxLevels() (def, rep int32) {
+	if col.Optional {
+		def++
+	}
+	if col.Kind == StringList {
+		def++
+		rep++
+	}
+	return def, rep
+}
+
+func (c *column) add(col Column, value any) {
+	maxDef, _ := col.maxLevels()
+	if col.Kind != StringList {
+		if value == nil {
+			c.defLevels = append(c.defLevels, 0)
+			return
+		}
+		c.defLevels = append(c.defLevels, maxDef)
+		c.values = append(c.values, value)
+		return
+	}
+
+	list, _ := value.([]string)
+	switch {
+	case value == nil || list == nil && col.Optional:
+		c.defLevels = append(c.defLevels, 0)
+		c.repLevels = append(c.repLevels, 0)
+	case len(list) == 0:
+		c.defLevels = append(c.defLevels, maxDef-1)
+		c.repLevels = append(c.repLevels, 0)
+	default:
+		for i, s := range list {
+			c.defLevels = append(c.defLevels, maxDef)
+			c.repLevels = append(c.repLevels, min(int32(i), 1))
+			c.values = append(c.values, s)
+		}
+	}
+}
+
+// flush writes the buffered rows as a row group
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+
+	group := rowGroup{rows: w.rows}
+	for i, col := range w.columns {
+		c, err := w.writeChunk(col, w.buffered[i])
+		if err != nil {
+			w.err = err
+			return err
+		}
+		group.chunks = append(group.chunks, c)
+		group.size += c.uncompressed
+		w.buffered[i] = column{}
+	}
+	w.groups = append(w.groups, group)
+	w.rows = 0
+	return nil
+}
+
+// writeChunk writes the column chunk as a single data page
+func (w *Writer) writeChunk(col Column, c column) (chunk, error) {
+	maxDef, maxRep := col.maxLevels()
+
+	var page bytes.Buffer
+	if maxRep > 0 {
+		writeLevels(&page, c.repLevels, maxRep)
+	}
+	if maxDef > 0 {
+		writeLevels(&page, c.defLevels, maxDef)
+	}
+	writePlain(&page, col.Kind, c.values)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(page.Bytes()); err != nil {
+		return chunk{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return chunk{}, err
+	}
+
+	header := newThrift()
+	header.i32(1, pageData)
+	header.i32(2, int32(page.Len()))
+	header.i32(3, int32(compressed.Len()))
+	header.structField(5)
+	header.i32(1, int32(len(c.defLevels)))
+	header.i32(2, encodingPlain)
+	header.i32(3, encodingRLE)
+	header.i32(4, encodingRLE)
+	header.end()
+	header.buf.WriteByte(0)
+
+	written := chunk{offset: w.w.n, values: len(c.defLevels)}
+	if _, err := w.w.Write(header.buf.Bytes()); err != nil {
+		return chunk{}, err
+	}
+	if _, err := w.w.Write(compressed.Bytes()); err != nil {
+		return chunk{}, err
+	}
+	written.uncompressed = int64(header.buf.Len() + page.Len())
+	written.size = int64(header.buf.Len() + compressed.Len())
+	return written, nil
+}
+
+// writeLevels writes levels in the RLE hybrid encoding, prefixed by their length, as runs of
+// equal levels
+func writeLevels(buf *bytes.Buffer, levels []int32, max int32) {
+	width := (bits.Len32(uint32(max)) + 7) / 8
+
+	var encoded []byte
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		encoded = binary.AppendUvarint(encoded, uint64(j-i)<<1)
+		for b := range width {
+			encoded = append(encoded, byte(levels[i]>>(8*b)))
+		}
+		i = j
+	}
+
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(encoded))))
+	buf.Write(encoded)
+}
+
+// writePlain writes the non-null values in the PLAIN encoding
+func writePlain(buf *bytes.Buffer, kind Kind, values []any) {
+	if kind == Bool {
+		packed := make([]byte, (len(values)+7)/8)
+		for i, v := range values {
+			if v.(bool) {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		buf.Write(packed)
+		return
+	}
+
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			buf.WriteString(v)
+		case int32:
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(v)))
+		case int64:
+			buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+		case float64:
+			buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+		}
+	}
+}
+
+// footer encodes the FileMetaData of the file
+func (w *Writer) footer() []byte {
+	t := newThrift()
+	t.i32(1, 1) // Version
+
+	// The schema is flattened depth first, lists being three levels deep
+	elements := 1
+	for _, col := range w.columns {
+		if col.Kind == StringList {
+			elements += 3
+		} else {
+			elements++
+		}
+	}
+	t.list(2, thriftStruct, elements)
+	t.begin()
+	t.string(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.end()
+	for _, col := range w.columns {
+		repetition := int32(repetitionRequired)
+		if col.Optional {
+			repetition = repetitionOptional
+		}
+		if col.Kind != StringList {
+			schemaElement(t, col.Name, col.Kind, repetition)
+			continue
+		}
+
+		t.begin()
+		t.i32(3, repetition)
+		t.string(4, col.Name)
+		t.i32(5, 1)
+		t.i32(6, convertedList)
+		t.structField(10)
+		t.structField(3) // LIST
+		t.end()
+		t.end()
+		t.end()
+
+		t.begin()
+		t.i32(3, repetitionRepeated)
+		t.string(4, "list")
+		t.i32(5, 1)
+		t.end()
+
+		schemaElement(t, "element", String, repetitionRequired)
+	}
+
+	var rows int64
+	for _, group := range w.groups {
+		rows += int64(group.rows)
+	}
+	t.i64(3, rows)
+
+	t.list(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		t.begin()
+		t.list(1, thriftStruct, len(group.chunks))
+		for i, c := range group.chunks {
+			col := w.columns[i]
+			path := []string{col.Name}
+			if col.Kind == StringList {
+				path = append(path, "list", "element")
+			}
+
+			t.begin()
+			t.i64(2, c.offset)
+			t.structField(3)
+			t.i32(1, col.Kind.physical())
+			t.listI32(2, []int32{encodingPlain, encodingRLE})
+			t.listString(3, path)
+			t.i32(4, codecGzip)
+			t.i64(5, int64(c.values))
+			t.i64(6, c.uncompressed)
+			t.i64(7, c.size)
+			t.i64(9, c.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, group.size)
+		t.i64(3, int64(group.rows))
+		t.end()
+	}
+
+	t.string(6, "ccws")
+	t.buf.WriteByte(0)
+	return t.buf.Bytes()
+}
+
+// schemaElement writes the element of a leaf column
+func schemaElement(t *thrift, name string, kind Kind, repetition int32) {
+	t.begin()
+	t.i32(1, kind.physical())
+	t.i32(3, repetition)
+	t.string(4, name)
+	switch kind {
+	case String, StringList:
+		t.i32(6, convertedUTF8)
+		t.structField(10)
+		t.structField(1) // STRING
+		t.end()
+		t.end()
+	case Timestamp:
+		t.i32(6, convertedTimestampMillis)
+		t.structField(10)
+		t.structField(8) // TIMESTAMP
+		t.bool(1, true)  // isAdjustedToUTC
+		t.structField(2) // unit
+		t.structField(1) // MILLIS
+		t.end()
+		t.end()
+		t.end()
+		t.end()
+	}
+	t.end()
+}
@@ -0,0 +1,145 @@
+// Package placeholders expands the {name} placeholders of timer descriptions, e.g. "Review
+// {ticket} on {branch}" started from a git hook, with the values of pluggable providers.
+package placeholders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNoValue is returned by providers that have no value for the placeholder, e.g. the branch
+// outside a git repository
+var ErrNoValue = errors.New("no value")
+
+// Provider resolves the placeholders of one name
+type Provider interface {
+	// Name is what the placeholder is written with, e.g. "branch" for {branch}
+	Name() string
+	// Value resolves a placeholder, arg is what follows a colon, e.g. "HOME" of {env:HOME}
+	Value(ctx context.Context, arg string) (string, error)
+}
+
+// placeholderPattern matches {name} and {name:arg}
+var placeholderPattern = regexp.MustCompile(`\{([a-z]+)(?::([^{}]*))?\}`)
+
+// Expander replaces the placeholders of its providers
+type Expander struct {
+	providers map[string]Provider
+}
+
+// New combines the providers, a later one replaces an earlier one of the same name
+func New(providers ...Provider) *Expander {
+	e := &Expander{providers: make(map[string]Provider, len(providers))}
+	for _, provider := range providers {
+		e.providers[provider.Name()] = provider
+	}
+	return e
+}
+
+// Expand replaces every placeholder with a provider in the text. Text in braces without a
+// provider is kept as it is. Failures to resolve a placeholder fail the expansion.
+func (e *Expander) Expand(ctx context.Context, text string) (string, error) {
+	var errs []error
+	expanded := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		provider, ok := e.providers[match[1]]
+		if !ok {
+			return placeholder
+		}
+		value, err := provider.Value(ctx, match[2])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", placeholder, err))
+			return placeholder
+		}
+		return value
+	})
+	if err := errors.Join(errs...); err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// providerFunc is a Provider of a function
+type providerFunc struct {
+	name  string
+	value func(ctx context.Context, arg string) (string, error)
+}
+
+func (p providerFunc) Name() string {
+	return p.name
+}
+
+func (p providerFunc) Value(ctx context.Context, arg string) (string, error) {
+	return p.value(ctx, arg)
+}
+
+// Func makes a Provider of a function
+func Func(name string, value func(ctx context.Context, arg string) (string, error)) Provider {
+	return providerFunc{name: name, value: value}
+}
+
+// Date provides {date}, today as 2006-01-02, or in the layout of the argument, e.g. {date:Jan 2}
+func Date() Provider {
+	return Func("date", func(_ context.Context, layout string) (string, error) {
+		if layout == "" {
+			layout = time.DateOnly
+		}
+		return time.Now().Format(layout), nil
+	})
+}
+
+// Env provides {env:NAME}, the value of the environment variable
+func Env() Provider {
+	return Func("env", func(_ context.Context, name string) (string, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok || name == "" {
+			return "", fmt.Errorf("%w: %s is not set", ErrNoValue, name)
+		}
+		return value, nil
+	})
+}
+
+// GitBranch provides {branch}, the branch checked out in the working directory
+func GitBranch() Provider {
+	return Func("branch", func(ctx context.Context, _ string) (string, error) {
+		return gitBranch(ctx)
+	})
+}
+
+func gitBranch(ctx context.Context) (string, error) {
+	// Unlike rev-parse, symbolic-ref also reads the branch of a repository without commits
+	out, err := exec.CommandContext(ctx, "git", "symbolic-ref", "--quiet", "--short", "HEAD").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", fmt.Errorf("%w: detached HEAD", ErrNoValue)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: not in a git repository: %w", ErrNoValue, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Ticket provides {ticket}, the value of the environment variable if set, otherwise the first
+// match of the pattern in the git branch, e.g. ENG-42 of feature/ENG-42-login
+func Ticket(variable string, pattern *regexp.Regexp) Provider {
+	return Func("ticket", func(ctx context.Context, _ string) (string, error) {
+		if ticket := os.Getenv(variable); ticket != "" {
+			return ticket, nil
+		}
+		branch, err := gitBranch(ctx)
+		if err != nil {
+			return "", fmt.Errorf("%w, and %s is not set", err, variable)
+		}
+		ticket := pattern.FindString(branch)
+		if ticket == "" {
+			return "", fmt.Errorf("%w: no ticket in branch %s", ErrNoValue, branch)
+		}
+		return ticket, nil
+	})
+}
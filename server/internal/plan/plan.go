@@ -0,0 +1,175 @@
+// Package plan compares the time scheduled by assignments, from the scheduling of Clockify,
+// with the time tracked per user, project and week, flagging the large deviations for
+// planned-versus-actual reviews.
+package plan
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Tolerance decides which deviations from the plan are flagged
+type Tolerance struct {
+	// Share of the planned time tracked over or under it, e.g. 0.2
+	Share float64
+	// Smallest deviation flagged whatever its share, e.g. an hour, so short plans are not
+	// flagged for minutes
+	Min time.Duration
+}
+
+// Flags reports whether the deviation of the row is beyond the tolerance. Time tracked on a
+// project the user was not scheduled on is flagged once over the minimum.
+func (t Tolerance) Flags(r Row) bool {
+	deviation := r.Delta().Abs()
+	if deviation == 0 || deviation < t.Min {
+		return false
+	}
+	return r.Planned == 0 || float64(deviation) >= t.Share*float64(r.Planned)
+}
+
+// Row is the time planned and tracked by a user on a project in a week
+type Row struct {
+	Week      report.Period
+	UserID    string
+	User      string
+	ProjectID string
+	Project   string
+	Planned   time.Duration
+	Tracked   time.Duration
+	Flagged   bool
+}
+
+// Delta is the time tracked over the plan, negative when under it
+func (r Row) Delta() time.Duration {
+	return r.Tracked - r.Planned
+}
+
+// Report compares the plan of a period of whole weeks with the time tracked
+type Report struct {
+	Period    report.Period
+	Tolerance Tolerance
+	Rows      []Row // By week, then user and project
+}
+
+// Flagged returns the rows deviating beyond the tolerance
+func (r *Report) Flagged() []Row {
+	var flagged []Row
+	for _, row := range r.Rows {
+		if row.Flagged {
+			flagged = append(flagged, row)
+		}
+	}
+	return flagged
+}
+
+// Build fetches the assignments and the time entries of the users in the period, made of whole
+// weeks, and compares them. The plan only counts the days up to today, so the current week is
+// not flagged for the days still ahead.
+func Build(client *clockify.APIClient, workspaceID string, users []clockify.User, period report.Period, tolerance Tolerance, now time.Time) (*Report, error) {
+	var assignments []clockify.Assignment
+	for page, err := range client.IterAssignments(workspaceID, period.Start, period.End.Add(-1)) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch assignments: %w", err)
+		}
+		assignments = append(assignments, page...)
+	}
+
+	projectNames, err := report.ProjectNames(client, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+
+	entries := make(map[string][]clockify.TimeEntry, len(users))
+	for _, user := range users {
+		userEntries, err := report.FetchEntries(client, workspaceID, user.ID, period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch time entries of %s: %w", user, err)
+		}
+		entries[user.ID] = userEntries
+	}
+
+	return Compare(period, users, assignments, entries, projectNames, tolerance, now), nil
+}
+
+// rowKey groups the time of a user on a project in a week
+type rowKey struct {
+	week            int
+	userID, project string
+}
+
+// Compare builds the report from already fetched assignments and entries, the entries keyed by
+// user ID. Assignments of other users are left out, and so are breaks.
+func Compare(period report.Period, users []clockify.User, assignments []clockify.Assignment, entries map[string][]clockify.TimeEntry, projectNames map[string]string, tolerance Tolerance, now time.Time) *Report {
+	var weeks []report.Period
+	for start := period.Start; start.Before(period.End); start = start.AddDate(0, 0, 7) {
+		weeks = append(weeks, report.Period{Start: start, End: start.AddDate(0, 0, 7)})
+	}
+	weekOf := func(t time.Time) int {
+		return slices.IndexFunc(weeks, func(week report.Period) bool { return week.Contains(t) })
+	}
+
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+
+	rows := make(map[rowKey]*Row)
+	row := func(week int, userID, projectID, project string) *Row {
+		key := rowKey{week, userID, projectID}
+		if rows[key] == nil {
+			rows[key] = &Row{Week: weeks[week], UserID: userID, User: names[userID], ProjectID: projectID, Project: project}
+		}
+		return rows[key]
+	}
+	projectName := func(id, fallback string) string {
+		if id == "" {
+			return report.NoProject
+		}
+		if name, ok := projectNames[id]; ok {
+			return name
+		}
+		return cmp.Or(fallback, id)
+	}
+
+	for _, assignment := range assignments {
+		if _, ok := names[assignment.UserID]; !ok {
+			continue
+		}
+		for _, day := range period.Days() {
+			if day.After(now) {
+				break
+			}
+			if assignment.ScheduledOn(day) {
+				row(weekOf(day), assignment.UserID, assignment.ProjectID, projectName(assignment.ProjectID, assignment.ProjectName)).Planned += assignment.Daily()
+			}
+		}
+	}
+
+	for _, user := range users {
+		for _, entry := range entries[user.ID] {
+			if entry.TimeInterval == nil || entry.IsBreak() {
+				continue
+			}
+			week := weekOf(entry.TimeInterval.Start)
+			if week < 0 {
+				continue
+			}
+			row(week, user.ID, entry.ProjectID, projectName(entry.ProjectID, "")).Tracked += report.EntryDuration(entry, now)
+		}
+	}
+
+	r := &Report{Period: period, Tolerance: tolerance}
+	for _, row := range rows {
+		row.Flagged = tolerance.Flags(*row)
+		r.Rows = append(r.Rows, *row)
+	}
+	slices.SortFunc(r.Rows, func(a, b Row) int {
+		return cmp.Or(a.Week.Start.Compare(b.Week.Start), cmp.Compare(a.User, b.User), cmp.Compare(a.UserID, b.UserID), cmp.Compare(a.Project, b.Project))
+	})
+	return r
+}
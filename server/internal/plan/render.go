@@ -0,0 +1,125 @@
+package plan
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/capacity"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Render writes the report in the given format
+func Render(w io.Writer, r *Report, format report.Format) error {
+	switch format {
+	case report.FormatTable:
+		return WriteTable(w, r)
+	case report.FormatCSV:
+		return WriteCSV(w, r)
+	case report.FormatJSON:
+		return WriteJSON(w, r)
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv or json", format)
+	}
+}
+
+// WriteTable writes a row per week, user and project as an ASCII table, the flagged ones
+// marked with a !
+func WriteTable(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Period: %s\t\n\n", r.Period)
+
+	fmt.Fprintln(tw, "WEEK\tUSER\tPROJECT\tPLANNED\tTRACKED\tDELTA\t\t")
+	flagged := 0
+	for _, row := range r.Rows {
+		mark := ""
+		if row.Flagged {
+			mark = "!"
+			flagged++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", row.Week.Start.Format("2006-01-02"), row.User, row.Project,
+			report.FormatDuration(row.Planned), report.FormatDuration(row.Tracked), capacity.FormatDelta(row.Delta()), mark)
+	}
+
+	fmt.Fprintf(tw, "\n%d of %d deviating by %.0f%% of the plan and %s or more\t\n", flagged, len(r.Rows), r.Tolerance.Share*100, report.FormatDuration(r.Tolerance.Min))
+	return tw.Flush()
+}
+
+// WriteCSV writes one row per week, user and project
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"week", "user_id", "user", "project_id", "project", "planned_hours", "tracked_hours", "delta_hours", "flagged"})
+	for _, row := range r.Rows {
+		cw.Write([]string{
+			row.Week.Start.Format("2006-01-02"),
+			row.UserID,
+			row.User,
+			row.ProjectID,
+			row.Project,
+			strconv.FormatFloat(report.Hours(row.Planned), 'f', 2, 64),
+			strconv.FormatFloat(report.Hours(row.Tracked), 'f', 2, 64),
+			strconv.FormatFloat(report.Hours(row.Delta()), 'f', 2, 64),
+			strconv.FormatBool(row.Flagged),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonRow struct {
+	Week         string  `json:"week"`
+	UserID       string  `json:"userId"`
+	User         string  `json:"user"`
+	ProjectID    string  `json:"projectId,omitempty"`
+	Project      string  `json:"project"`
+	PlannedHours float64 `json:"plannedHours"`
+	TrackedHours float64 `json:"trackedHours"`
+	DeltaHours   float64 `json:"deltaHours"`
+	Flagged      bool    `json:"flagged"`
+}
+
+type jsonTolerance struct {
+	Share    float64 `json:"share"`
+	MinHours float64 `json:"minHours"`
+}
+
+type jsonReport struct {
+	Start     string        `json:"start"`
+	End       string        `json:"end"`
+	Tolerance jsonTolerance `json:"tolerance"`
+	Rows      []jsonRow     `json:"rows"`
+}
+
+// WriteJSON writes the report as JSON with durations in hours
+func WriteJSON(w io.Writer, r *Report) error {
+	out := jsonReport{
+		Start:     r.Period.Start.Format(time.RFC3339),
+		End:       r.Period.End.Format(time.RFC3339),
+		Tolerance: jsonTolerance{Share: r.Tolerance.Share, MinHours: report.Hours(r.Tolerance.Min)},
+		Rows:      make([]jsonRow, 0, len(r.Rows)),
+	}
+	for _, row := range r.Rows {
+		out.Rows = append(out.Rows, jsonRow{
+			Week:         row.Week.Start.Format("2006-01-02"),
+			UserID:       row.UserID,
+			User:         row.User,
+			ProjectID:    row.ProjectID,
+			Project:      row.Project,
+			PlannedHours: report.Hours(row.Planned),
+			TrackedHours: report.Hours(row.Tracked),
+			DeltaHours:   report.Hours(row.Delta()),
+			Flagged:      row.Flagged,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,53 @@
+package pomodoro
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// ErrNoNotifier is returned by Notify when no command is configured and the platform has no
+// known notification tool
+var ErrNoNotifier = errors.New("no desktop notification command found")
+
+// Notifier shows desktop notifications
+type Notifier struct {
+	command string
+}
+
+// NewNotifier runs the shell command for every notification, with the title and message in
+// CCWS_POMO_TITLE and CCWS_POMO_MESSAGE, e.g. `notify-send "$CCWS_POMO_TITLE" "$CCWS_POMO_MESSAGE"`.
+// Without a command, notify-send is used on Linux and osascript on macOS.
+func NewNotifier(command string) *Notifier {
+	return &Notifier{command: command}
+}
+
+// Notify shows a notification and waits for the command to finish
+func (n *Notifier) Notify(ctx context.Context, title, message string) error {
+	cmd, err := n.commandFor(ctx, title, message)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(os.Environ(), "CCWS_POMO_TITLE="+title, "CCWS_POMO_MESSAGE="+message)
+	return cmd.Run()
+}
+
+func (n *Notifier) commandFor(ctx context.Context, title, message string) (*exec.Cmd, error) {
+	if n.command != "" {
+		return exec.CommandContext(ctx, "sh", "-c", n.command), nil
+	}
+
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		if path, err := exec.LookPath("notify-send"); err == nil {
+			return exec.CommandContext(ctx, path, "--app-name=ccws", title, message), nil
+		}
+	case "darwin":
+		script := "display notification " + strconv.Quote(message) + " with title " + strconv.Quote(title)
+		return exec.CommandContext(ctx, "osascript", "-e", script), nil
+	}
+	return nil, ErrNoNotifier
+}
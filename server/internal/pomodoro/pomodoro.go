@@ -0,0 +1,137 @@
+// Package pomodoro runs work/break cycles and reports their boundaries to hooks, which start
+// and stop timers and notify the user.
+package pomodoro
+
+import (
+	"cmp"
+	"context"
+	"time"
+)
+
+// Phase is a work or break interval of a cycle
+type Phase string
+
+const (
+	Work       Phase = "work"
+	ShortBreak Phase = "short_break"
+	LongBreak  Phase = "long_break"
+)
+
+func (p Phase) String() string {
+	switch p {
+	case ShortBreak:
+		return "short break"
+	case LongBreak:
+		return "long break"
+	}
+	return string(p)
+}
+
+// Defaults of Settings
+const (
+	DefaultWork       = 25 * time.Minute
+	DefaultShortBreak = 5 * time.Minute
+	DefaultLongBreak  = 15 * time.Minute
+	DefaultLongEvery  = 4
+)
+
+// Settings are the lengths of the phases. Zero values use the defaults.
+type Settings struct {
+	Work       time.Duration
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	LongEvery  int // Every n-th break is a long one
+	Cycles     int // Work phases to run, 0 runs until the context is done
+}
+
+func (s Settings) withDefaults() Settings {
+	s.Work = cmp.Or(s.Work, DefaultWork)
+	s.ShortBreak = cmp.Or(s.ShortBreak, DefaultShortBreak)
+	s.LongBreak = cmp.Or(s.LongBreak, DefaultLongBreak)
+	s.LongEvery = cmp.Or(s.LongEvery, DefaultLongEvery)
+	return s
+}
+
+// Interval is a phase of a cycle, cycles count from 1
+type Interval struct {
+	Phase Phase
+	Cycle int
+	Start time.Time
+	End   time.Time
+}
+
+// Hooks are called at every boundary. An error from a hook ends the run.
+type Hooks struct {
+	// Start is called when an interval begins
+	Start func(ctx context.Context, interval Interval) error
+	// End is called when an interval ends, at or before interval.End when the run is interrupted.
+	// The context is no longer done then, so a running timer can still be stopped.
+	End func(ctx context.Context, interval Interval, completed bool) error
+}
+
+// Stats counts what a run completed
+type Stats struct {
+	Cycles  int           // Completed work phases
+	Focused time.Duration // Time spent in work phases, including an interrupted one
+}
+
+// Run alternates work and break phases until Settings.Cycles work phases are done or the
+// context is done. Interrupting is not an error, the stats of the run so far are returned.
+// No break follows the last cycle.
+func Run(ctx context.Context, settings Settings, hooks Hooks) (Stats, error) {
+	settings = settings.withDefaults()
+	var stats Stats
+
+	for cycle := 1; settings.Cycles == 0 || cycle <= settings.Cycles; cycle++ {
+		completed, err := runInterval(ctx, Interval{Phase: Work, Cycle: cycle}, settings.Work, hooks, &stats)
+		if err != nil || !completed {
+			return stats, err
+		}
+		stats.Cycles++
+
+		if cycle == settings.Cycles {
+			break
+		}
+		phase, length := ShortBreak, settings.ShortBreak
+		if cycle%settings.LongEvery == 0 {
+			phase, length = LongBreak, settings.LongBreak
+		}
+		completed, err = runInterval(ctx, Interval{Phase: phase, Cycle: cycle}, length, hooks, &stats)
+		if err != nil || !completed {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func runInterval(ctx context.Context, interval Interval, length time.Duration, hooks Hooks, stats *Stats) (bool, error) {
+	interval.Start = time.Now()
+	interval.End = interval.Start.Add(length)
+
+	if hooks.Start != nil {
+		if err := hooks.Start(ctx, interval); err != nil {
+			return false, err
+		}
+	}
+
+	timer := time.NewTimer(length)
+	defer timer.Stop()
+
+	completed := true
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		completed = false
+		interval.End = time.Now()
+	}
+
+	if interval.Phase == Work {
+		stats.Focused += interval.End.Sub(interval.Start)
+	}
+	if hooks.End != nil {
+		if err := hooks.End(context.WithoutCancel(ctx), interval, completed); err != nil {
+			return false, err
+		}
+	}
+	return completed, nil
+}
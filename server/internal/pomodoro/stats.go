@@ -0,0 +1,58 @@
+package pomodoro
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Daily is the day's tally of all runs, kept in a file between runs
+type Daily struct {
+	Date    string        `json:"date"` // YYYY-MM-DD in local time
+	Cycles  int           `json:"cycles"`
+	Focused time.Duration `json:"focused"`
+}
+
+// DefaultStatsPath is the tally file in the user cache directory
+func DefaultStatsPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ccws", "pomodoro.json"), nil
+}
+
+// Record adds the stats of a run to the day's tally at path and returns the new tally.
+// A tally of an earlier day is started over.
+func Record(path string, stats Stats, now time.Time) (Daily, error) {
+	today := now.Format(time.DateOnly)
+
+	var daily Daily
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return Daily{}, err
+	default:
+		// A corrupt tally is started over rather than failing the run
+		_ = json.Unmarshal(data, &daily)
+	}
+	if daily.Date != today {
+		daily = Daily{Date: today}
+	}
+
+	daily.Cycles += stats.Cycles
+	daily.Focused += stats.Focused
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return daily, err
+	}
+	data, err = json.Marshal(daily)
+	if err != nil {
+		return daily, err
+	}
+	return daily, os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,102 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+const (
+	kafkaContentType = "application/vnd.kafka.binary.v2+json"
+	kafkaAttempts    = 3
+	kafkaTimeout     = 10 * time.Second
+)
+
+// Kafka produces to Kafka topics through a Confluent REST Proxy (API v2), sending records
+// with binary keys and values. Credentials in the URL are sent as basic auth.
+//
+// The proxy cannot tell a repeated record from a new one, so a record is only produced
+// again after a 429: after a timeout or a gateway error it may or may not have been
+// produced, and Publish fails rather than risk producing it twice.
+type Kafka struct {
+	url    string
+	client *http.Client
+}
+
+// NewKafka creates a producer through the REST proxy at the URL, e.g. http://localhost:8082
+func NewKafka(proxyURL string) *Kafka {
+	return &Kafka{
+		url:    strings.TrimSuffix(proxyURL, "/"),
+		client: clockify.RetryingClient(nil, kafkaAttempts, kafkaTimeout*kafkaAttempts),
+	}
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish produces a record of the key and body to the topic
+func (k *Kafka) Publish(ctx context.Context, topic, key string, body []byte) error {
+	record := kafkaRecord{Value: base64.StdEncoding.EncodeToString(body)}
+	if key != "" {
+		record.Key = base64.StdEncoding.EncodeToString([]byte(key))
+	}
+	payload, err := json.Marshal(map[string][]kafkaRecord{"records": {record}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url+"/topics/"+url.PathEscape(topic), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce to Kafka, the record may have been produced: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read the Kafka REST proxy response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Kafka REST proxy rejected the record: %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.Unmarshal(data, &produced); err != nil {
+		return fmt.Errorf("invalid Kafka REST proxy response: %w", err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil && *offset.ErrorCode != 0 {
+			return fmt.Errorf("Kafka refused the record: %s (code %d)", offset.Error, *offset.ErrorCode)
+		}
+	}
+	return nil
+}
+
+// Close is a no-op, the REST proxy holds no connection
+func (k *Kafka) Close() error {
+	return nil
+}
@@ -0,0 +1,263 @@
+package publish
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	natsDefaultPort = "4222"
+	natsDialTimeout = 10 * time.Second
+	// natsFlushTimeout bounds the wait for the server to acknowledge a message
+	natsFlushTimeout = 10 * time.Second
+)
+
+// NATS publishes to the subjects of a NATS server over its client protocol. Every message is
+// followed by a PING, and Publish returns once the server answered it, so that messages the
+// server rejected are reported. The connection is redialed after failures.
+type NATS struct {
+	url *url.URL
+
+	mu   sync.Mutex
+	conn *natsConn
+}
+
+// NewNATS creates a publisher to the server at the URL, nats://[user:password@]host[:port],
+// tls:// for TLS or nats://token@host for token authentication. It connects on the first
+// message.
+func NewNATS(rawURL string) (*NATS, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q: must be nats://host[:port] or tls://host[:port]", rawURL)
+	}
+	return &NATS{url: u}, nil
+}
+
+// Publish sends the body to the subject. NATS has no message keys, the key is ignored.
+func (n *NATS) Publish(ctx context.Context, subject, _ string, body []byte) error {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return fmt.Errorf("invalid NATS subject %q", subject)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		conn, err := dialNATS(ctx, n.url)
+		if err != nil {
+			return err
+		}
+		n.conn = conn
+	}
+	if err := n.conn.publish(ctx, subject, body); err != nil {
+		n.conn.close()
+		n.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.close()
+	n.conn = nil
+	return err
+}
+
+// natsConn is a connection whose reader answers the server's PINGs and passes on the PONGs
+// and errors it sends
+type natsConn struct {
+	conn       net.Conn
+	w          *bufio.Writer
+	maxPayload int
+	// wmu guards w, written by publish and by the reader answering PINGs
+	wmu sync.Mutex
+
+	pongs chan struct{}
+	errs  chan error
+	done  chan struct{}
+}
+
+// natsInfo is the part of the server's INFO message the client needs
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	MaxPayload  int  `json:"max_payload"`
+}
+
+func dialNATS(ctx context.Context, u *url.URL) (*natsConn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+
+	dialer := &net.Dialer{Timeout: natsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(natsDialTimeout))
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read NATS INFO: %w", err)
+	}
+	payload, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected NATS greeting %q", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(payload), &info); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("invalid NATS INFO: %w", err)
+	}
+
+	if u.Scheme == "tls" || info.TLSRequired {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("NATS TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+		r = bufio.NewReader(conn)
+	}
+
+	connect := map[string]any{"verbose": false, "pedantic": false, "lang": "go", "name": "ccws", "protocol": 1}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			connect["user"], connect["pass"] = u.User.Username(), password
+		} else {
+			connect["auth_token"] = u.User.Username()
+		}
+	}
+	options, err := json.Marshal(connect)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := &natsConn{
+		conn:       conn,
+		w:          bufio.NewWriter(conn),
+		maxPayload: info.MaxPayload,
+		pongs:      make(chan struct{}, 1),
+		errs:       make(chan error, 1),
+		done:       make(chan struct{}),
+	}
+	fmt.Fprintf(c.w, "CONNECT %s\r\nPING\r\n", options)
+	if err := c.w.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send NATS CONNECT: %w", err)
+	}
+	// The server answers PONG once it accepted the CONNECT, or -ERR and closes
+	line, err = r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if line = strings.TrimSpace(line); line != "PONG" {
+		conn.Close()
+		return nil, fmt.Errorf("NATS refused the connection: %s", cmp.Or(strings.TrimPrefix(line, "-ERR "), line))
+	}
+	conn.SetDeadline(time.Time{})
+
+	go c.read(r)
+	return c, nil
+}
+
+// read handles the server's messages until the connection closes
+func (c *natsConn) read(r *bufio.Reader) {
+	defer close(c.done)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			c.fail(fmt.Errorf("NATS connection lost: %w", err))
+			return
+		}
+		op, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(op) {
+		case "PING":
+			c.wmu.Lock()
+			c.w.WriteString("PONG\r\n")
+			c.w.Flush()
+			c.wmu.Unlock()
+		case "PONG":
+			select {
+			case c.pongs <- struct{}{}:
+			default:
+			}
+		case "-ERR":
+			c.fail(fmt.Errorf("NATS error: %s", strings.Trim(args, "'")))
+		}
+		// +OK and INFO updates need no answer
+	}
+}
+
+func (c *natsConn) fail(err error) {
+	select {
+	case c.errs <- err:
+	default:
+	}
+}
+
+func (c *natsConn) publish(ctx context.Context, subject string, body []byte) error {
+	if c.maxPayload > 0 && len(body) > c.maxPayload {
+		return fmt.Errorf("message of %d bytes exceeds the NATS maximum payload of %d", len(body), c.maxPayload)
+	}
+
+	c.wmu.Lock()
+	fmt.Fprintf(c.w, "PUB %s %d\r\n", subject, len(body))
+	c.w.Write(body)
+	c.w.WriteString("\r\nPING\r\n")
+	err := c.w.Flush()
+	c.wmu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+
+	timer := time.NewTimer(natsFlushTimeout)
+	defer timer.Stop()
+	select {
+	case <-c.pongs:
+		// The server handles a connection's messages in order, an error for the PUB would
+		// have come before the PONG
+		select {
+		case err := <-c.errs:
+			return err
+		default:
+			return nil
+		}
+	case err := <-c.errs:
+		return err
+	case <-timer.C:
+		return errors.New("NATS did not acknowledge the message in time")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *natsConn) close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
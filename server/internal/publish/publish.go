@@ -0,0 +1,158 @@
+// Package publish pushes events to message broker topics, NATS subjects or Kafka topics,
+// for systems consuming them as a stream rather than as webhook deliveries.
+//
+// Messages carry the event envelope as JSON or its ccws.v1.Event protobuf form, keyed by the
+// ID of the event's subject so that the changes of one entry stay in order on partitioned
+// topics.
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/rpc"
+)
+
+// Brokers
+const (
+	BackendNATS  = "nats"
+	BackendKafka = "kafka"
+)
+
+// Encodings of the messages
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+// TypePlaceholder in a topic is replaced by the event type, e.g. "ccws.{type}" publishes
+// TIMER_STOPPED events to ccws.timer_stopped
+const TypePlaceholder = "{type}"
+
+// Broker sends messages to topics
+type Broker interface {
+	Publish(ctx context.Context, topic, key string, body []byte) error
+	Close() error
+}
+
+// NewBroker connects to the backend at the URL, e.g. nats://localhost:4222 or the URL of a
+// Kafka REST proxy
+func NewBroker(backend, url string) (Broker, error) {
+	switch backend {
+	case BackendNATS:
+		return NewNATS(url)
+	case BackendKafka:
+		return NewKafka(url), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", backend)
+	}
+}
+
+// Encode serializes the envelope for a message
+func Encode(envelope events.Envelope, encoding string) ([]byte, error) {
+	switch encoding {
+	case EncodingJSON, "":
+		return json.Marshal(envelope)
+	case EncodingProtobuf:
+		message, err := rpc.EventToProto(envelope)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(message)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+// Option configures a Publisher
+type Option func(*Publisher)
+
+// WithEncoding sets how events are serialized, EncodingJSON by default
+func WithEncoding(encoding string) Option {
+	return func(p *Publisher) {
+		p.encoding = encoding
+	}
+}
+
+// WithEvents restricts the events published to the given types, all by default
+func WithEvents(types ...clockify.WebhookEvent) Option {
+	return func(p *Publisher) {
+		p.events = types
+	}
+}
+
+// Publisher publishes events to a broker in the background
+type Publisher struct {
+	broker   Broker
+	topic    string
+	encoding string
+	events   []clockify.WebhookEvent
+
+	wg sync.WaitGroup
+}
+
+// New creates a publisher sending events to the topic, which may hold TypePlaceholder
+func New(broker Broker, topic string, opts ...Option) *Publisher {
+	p := &Publisher{broker: broker, topic: topic, encoding: EncodingJSON}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Handle starts publishing the event and returns without waiting, so a slow broker does not
+// hold up the webhook response. It is an events.HandlerFunc.
+func (p *Publisher) Handle(ctx context.Context, event events.Event) error {
+	if len(p.events) > 0 && !slices.Contains(p.events, event.Type) {
+		return nil
+	}
+
+	envelope := events.NewEnvelope(event)
+	body, err := Encode(envelope, p.encoding)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", event.Type, err)
+	}
+
+	topic := p.Topic(event.Type)
+	key := envelope.Subject.ID
+	if key == "" {
+		key = envelope.ID
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		// The message outlives the incoming request
+		if err := p.broker.Publish(context.WithoutCancel(ctx), topic, key, body); err != nil {
+			slog.Error("publish_failed", "topic", topic, "event_id", envelope.ID, "error", err)
+			return
+		}
+		slog.Debug("publish_delivered", "topic", topic, "event_id", envelope.ID, "event", envelope.Type)
+	}()
+	return nil
+}
+
+// Topic returns the topic events of the type are published to
+func (p *Publisher) Topic(eventType clockify.WebhookEvent) string {
+	return strings.ReplaceAll(p.topic, TypePlaceholder, strings.ToLower(string(eventType)))
+}
+
+// Wait blocks until the messages in flight have been published, e.g. on shutdown
+func (p *Publisher) Wait() {
+	p.wg.Wait()
+}
+
+// Close waits for the messages in flight and closes the broker
+func (p *Publisher) Close() error {
+	p.Wait()
+	return p.broker.Close()
+}
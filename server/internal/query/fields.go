@@ -0,0 +1,72 @@
+package query
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindTags
+	kindBool
+	kindDuration
+	kindTime
+)
+
+// field reads a value of an entry, the getter of its kind set. Durations and times are read
+// by the comparisons themselves.
+type field struct {
+	kind    fieldKind
+	string  func(entry clockify.TimeEntry, e *env) string
+	strings func(entry clockify.TimeEntry, e *env) []string
+	boolean func(entry clockify.TimeEntry, e *env) bool
+}
+
+var fields = map[string]field{
+	"description": {kind: kindString, string: func(entry clockify.TimeEntry, e *env) string {
+		return entry.Description
+	}},
+	"project": {kind: kindString, string: func(entry clockify.TimeEntry, e *env) string {
+		return lookup(e.names.Projects, entry.ProjectID)
+	}},
+	"type": {kind: kindString, string: func(entry clockify.TimeEntry, e *env) string {
+		return string(cmp.Or(entry.Type, clockify.TimeEntryRegular))
+	}},
+	"tag": {kind: kindTags, strings: func(entry clockify.TimeEntry, e *env) []string {
+		names := make([]string, len(entry.TagIDs))
+		for i, id := range entry.TagIDs {
+			names[i] = lookup(e.names.Tags, id)
+		}
+		return names
+	}},
+	"billable": {kind: kindBool, boolean: func(entry clockify.TimeEntry, e *env) bool {
+		return entry.Billable
+	}},
+	"running": {kind: kindBool, boolean: func(entry clockify.TimeEntry, e *env) bool {
+		return entry.TimeInterval != nil && entry.TimeInterval.End == nil
+	}},
+	"duration": {kind: kindDuration},
+	"start":    {kind: kindTime},
+}
+
+// lookup returns the name of the ID, the ID if it is unknown and nothing without an ID
+func lookup(names map[string]string, id string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return id
+}
+
+// fieldNames returns the names of the fields, sorted
+func fieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,105 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	// Field names, keywords and bare values, e.g. project, in, 30m or 2024-05-01
+	tokenWord
+	// Quoted values, unquoted
+	tokenString
+	// Operators and punctuation
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	col  int // From 1
+}
+
+// symbols are the operators and punctuation, two-character ones first
+var symbols = []string{"==", "!=", "=~", "!~", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+// lex splits the expression into tokens, ending with a tokenEOF one
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			end, text, err := lexString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, col: i + 1})
+			i = end
+		default:
+			if symbol := matchSymbol(expr[i:]); symbol != "" {
+				tokens = append(tokens, token{kind: tokenSymbol, text: symbol, col: i + 1})
+				i += len(symbol)
+				continue
+			}
+			end := i
+			for end < len(expr) && isWordByte(expr[end]) {
+				end++
+			}
+			if end == i {
+				return nil, &Error{Column: i + 1, Msg: "unexpected " + strconv.QuoteRune(rune(c))}
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: expr[i:end], col: i + 1})
+			i = end
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, col: len(expr) + 1}), nil
+}
+
+// lexString reads the string starting at the quote at start. Double-quoted strings take Go
+// escapes, single-quoted ones are raw, e.g. for regular expressions.
+func lexString(expr string, start int) (end int, text string, err error) {
+	quote := expr[start]
+	for i := start + 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			if quote == '\'' {
+				return i + 1, expr[start+1 : i], nil
+			}
+			text, err := strconv.Unquote(expr[start : i+1])
+			if err != nil {
+				return 0, "", &Error{Column: start + 1, Msg: "invalid string " + expr[start:i+1]}
+			}
+			return i + 1, text, nil
+		}
+	}
+	return 0, "", &Error{Column: start + 1, Msg: "unterminated string"}
+}
+
+func matchSymbol(s string) string {
+	for _, symbol := range symbols {
+		if strings.HasPrefix(s, symbol) {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// isWordByte reports whether the byte may be part of a bare word: anything but spaces, quotes,
+// operators and punctuation
+func isWordByte(c byte) bool {
+	if c >= 0x80 {
+		return true
+	}
+	return !unicode.IsSpace(rune(c)) && !strings.ContainsRune(`"'()[],=!<>&|`, rune(c))
+}
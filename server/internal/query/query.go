@@ -0,0 +1,385 @@
+// Package query compiles filter expressions selecting time entries, e.g.
+//
+//	project == "Acme" && tag in ["dev", "review"] && duration > 30m
+//
+// Comparisons of fields to values are combined with &&, || and !, and grouped with
+// parentheses. Fields are:
+//
+//   - description, project and type (REGULAR, BREAK, HOLIDAY or TIME_OFF), compared with ==
+//     and !=, =~ and !~ to a regular expression, and in or not in a list
+//   - tag, the names of the entry's tags: == and != a tag it has or not, in and not in a list
+//     of which it has any or none, =~ and !~ a regular expression any of them matches or none
+//   - duration, running timers counting up to now, compared to durations like 30m or 1h30m
+//   - start, compared to days like 2024-05-01 or monday, == meaning on that day
+//   - billable and running, alone or compared to true or false
+//
+// Values are quoted with double quotes, taking Go escapes, or single quotes, taken as is.
+// Words without spaces, quotes or operators may be left bare.
+package query
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/humantime"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Error is a syntax or type error of an expression
+type Error struct {
+	Column int // Of the offending token, from 1
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// Names resolves the IDs of entries to the names expressions compare. Unknown IDs are
+// compared as is.
+type Names struct {
+	Projects map[string]string
+	Tags     map[string]string
+}
+
+// predicate is a compiled expression
+type predicate func(entry clockify.TimeEntry, env *env) bool
+
+type env struct {
+	names Names
+	now   time.Time
+}
+
+// Query is a compiled filter expression
+type Query struct {
+	expr  string
+	now   time.Time
+	match predicate
+}
+
+// Parse compiles the expression. Relative days, such as monday, and the duration of running
+// timers are relative to now.
+func Parse(expr string, now time.Time) (*Query, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	if tokens[0].kind == tokenEOF {
+		return nil, &Error{Column: 1, Msg: "empty expression"}
+	}
+
+	p := &parser{tokens: tokens, now: now}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t, "&& or ||")
+	}
+	return &Query{expr: expr, now: now, match: match}, nil
+}
+
+// String returns the expression as parsed
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Predicate returns whether an entry matches, resolving its project and tags by the names.
+// It fits clockify.TimeEntryFilter.Match.
+func (q *Query) Predicate(names Names) func(clockify.TimeEntry) bool {
+	e := &env{names: names, now: q.now}
+	return func(entry clockify.TimeEntry) bool {
+		return q.match(entry, e)
+	}
+}
+
+// Apply returns the entries matching the query
+func (q *Query) Apply(entries []clockify.TimeEntry, names Names) []clockify.TimeEntry {
+	match := q.Predicate(names)
+	return slices.DeleteFunc(slices.Clone(entries), func(entry clockify.TimeEntry) bool {
+		return !match(entry)
+	})
+}
+
+type parser struct {
+	tokens []token
+	i      int
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the symbol
+func (p *parser) accept(symbol string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == symbol {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	if t.kind == tokenEOF {
+		return &Error{Column: t.col, Msg: "unexpected end, expected " + expected}
+	}
+	return &Error{Column: t.col, Msg: fmt.Sprintf("unexpected %q, expected %s", t.text, expected)}
+}
+
+// parseOr parses a || b || ...
+func (p *parser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(entry clockify.TimeEntry, e *env) bool { return l(entry, e) || right(entry, e) }
+	}
+	return left, nil
+}
+
+// parseAnd parses a && b && ...
+func (p *parser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(entry clockify.TimeEntry, e *env) bool { return l(entry, e) && right(entry, e) }
+	}
+	return left, nil
+}
+
+// parseUnary parses !a, (a) and comparisons
+func (p *parser) parseUnary() (predicate, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(entry clockify.TimeEntry, e *env) bool { return !operand(entry, e) }, nil
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.peek(); !p.accept(")") {
+			return nil, p.unexpected(t, ")")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+// operand is the value a field is compared to
+type operand struct {
+	token
+	list []token // Set for lists
+}
+
+// parseComparison parses a field, alone or compared to a value
+func (p *parser) parseComparison() (predicate, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, p.unexpected(t, "a field")
+	}
+	f, ok := fields[t.text]
+	if !ok {
+		return nil, &Error{Column: t.col, Msg: fmt.Sprintf("unknown field %q, expected one of %s", t.text, strings.Join(fieldNames(), ", "))}
+	}
+
+	op := p.peek()
+	switch {
+	case op.kind == tokenSymbol && slices.Contains([]string{"==", "!=", "=~", "!~", "<", "<=", ">", ">="}, op.text):
+		p.i++
+	case op.kind == tokenWord && op.text == "in":
+		p.i++
+	case op.kind == tokenWord && op.text == "not":
+		p.i++
+		if in := p.next(); in.kind != tokenWord || in.text != "in" {
+			return nil, p.unexpected(in, "in")
+		}
+		op.text = "not in"
+	default:
+		// A field alone, only booleans
+		if f.kind != kindBool {
+			return nil, p.unexpected(op, "an operator after "+t.text)
+		}
+		return func(entry clockify.TimeEntry, e *env) bool { return f.boolean(entry, e) }, nil
+	}
+
+	value, err := p.parseOperand(op.text == "in" || op.text == "not in")
+	if err != nil {
+		return nil, err
+	}
+	return p.compare(t.text, f, op, value)
+}
+
+// parseOperand parses a value, or a list of them
+func (p *parser) parseOperand(list bool) (operand, error) {
+	t := p.next()
+	if !list {
+		if t.kind != tokenWord && t.kind != tokenString {
+			return operand{}, p.unexpected(t, "a value")
+		}
+		return operand{token: t}, nil
+	}
+
+	if t.kind != tokenSymbol || t.text != "[" {
+		return operand{}, p.unexpected(t, "a list such as [\"a\", \"b\"]")
+	}
+	value := operand{token: t}
+	for !p.accept("]") {
+		if len(value.list) > 0 {
+			if sep := p.peek(); !p.accept(",") {
+				return operand{}, p.unexpected(sep, ", or ]")
+			}
+		}
+		item := p.next()
+		if item.kind != tokenWord && item.kind != tokenString {
+			return operand{}, p.unexpected(item, "a value")
+		}
+		value.list = append(value.list, item)
+	}
+	return value, nil
+}
+
+// compare compiles the comparison of the field to the value by the operator
+func (p *parser) compare(name string, f field, op token, value operand) (predicate, error) {
+	invalid := func() error {
+		return &Error{Column: op.col, Msg: fmt.Sprintf("%s cannot be compared with %s", name, op.text)}
+	}
+
+	switch f.kind {
+	case kindString, kindTags:
+		values := func(entry clockify.TimeEntry, e *env) []string {
+			if f.kind == kindTags {
+				return f.strings(entry, e)
+			}
+			return []string{f.string(entry, e)}
+		}
+		var matches func(entry clockify.TimeEntry, e *env) bool
+		switch op.text {
+		case "==", "!=":
+			matches = func(entry clockify.TimeEntry, e *env) bool { return slices.Contains(values(entry, e), value.text) }
+		case "in", "not in":
+			texts := make([]string, len(value.list))
+			for i, item := range value.list {
+				texts[i] = item.text
+			}
+			matches = func(entry clockify.TimeEntry, e *env) bool {
+				return slices.ContainsFunc(values(entry, e), func(v string) bool { return slices.Contains(texts, v) })
+			}
+		case "=~", "!~":
+			pattern, err := regexp.Compile(value.text)
+			if err != nil {
+				return nil, &Error{Column: value.col, Msg: fmt.Sprintf("invalid regular expression: %s", err)}
+			}
+			matches = func(entry clockify.TimeEntry, e *env) bool {
+				return slices.ContainsFunc(values(entry, e), pattern.MatchString)
+			}
+		default:
+			return nil, invalid()
+		}
+		if op.text == "!=" || op.text == "not in" || op.text == "!~" {
+			return func(entry clockify.TimeEntry, e *env) bool { return !matches(entry, e) }, nil
+		}
+		return matches, nil
+
+	case kindBool:
+		if op.text != "==" && op.text != "!=" {
+			return nil, invalid()
+		}
+		if value.text != "true" && value.text != "false" {
+			return nil, &Error{Column: value.col, Msg: fmt.Sprintf("%s is compared with true or false, not %q", name, value.text)}
+		}
+		want := (value.text == "true") == (op.text == "==")
+		return func(entry clockify.TimeEntry, e *env) bool { return f.boolean(entry, e) == want }, nil
+
+	case kindDuration:
+		satisfies, ok := ordered(op.text)
+		if !ok {
+			return nil, invalid()
+		}
+		d, err := humantime.ParseDuration(value.text)
+		if err != nil {
+			return nil, &Error{Column: value.col, Msg: err.Error()}
+		}
+		return func(entry clockify.TimeEntry, e *env) bool {
+			return satisfies(cmp.Compare(report.EntryDuration(entry, e.now), d))
+		}, nil
+
+	case kindTime:
+		if _, ok := ordered(op.text); !ok {
+			return nil, invalid()
+		}
+		day, err := humantime.ParseDate(value.text, p.now)
+		if err != nil {
+			return nil, &Error{Column: value.col, Msg: err.Error()}
+		}
+		next := day.AddDate(0, 0, 1)
+		var in func(t time.Time) bool
+		switch op.text {
+		case "==":
+			in = func(t time.Time) bool { return !t.Before(day) && t.Before(next) }
+		case "!=":
+			in = func(t time.Time) bool { return t.Before(day) || !t.Before(next) }
+		case "<":
+			in = func(t time.Time) bool { return t.Before(day) }
+		case "<=":
+			in = func(t time.Time) bool { return t.Before(next) }
+		case ">":
+			in = func(t time.Time) bool { return !t.Before(next) }
+		case ">=":
+			in = func(t time.Time) bool { return !t.Before(day) }
+		}
+		return func(entry clockify.TimeEntry, e *env) bool {
+			return entry.TimeInterval != nil && in(entry.TimeInterval.Start)
+		}, nil
+	}
+	return nil, invalid()
+}
+
+// ordered returns whether a comparison result satisfies the operator
+func ordered(op string) (func(int) bool, bool) {
+	switch op {
+	case "==":
+		return func(c int) bool { return c == 0 }, true
+	case "!=":
+		return func(c int) bool { return c != 0 }, true
+	case "<":
+		return func(c int) bool { return c < 0 }, true
+	case "<=":
+		return func(c int) bool { return c <= 0 }, true
+	case ">":
+		return func(c int) bool { return c > 0 }, true
+	case ">=":
+		return func(c int) bool { return c >= 0 }, true
+	}
+	return nil, false
+}
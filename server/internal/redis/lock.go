@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// acquireScript takes the lease when it is free and extends it when the owner holds it
+// already, in one step so that it is never extended after passing to another owner
+const acquireScript = `if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then return 1 end
+if redis.call('GET', KEYS[1]) == ARGV[1] then redis.call('PEXPIRE', KEYS[1], ARGV[2]) return 1 end
+return 0`
+
+// releaseScript frees the lease only when the owner still holds it
+const releaseScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('DEL', KEYS[1]) end
+return 0`
+
+// Lease is a lock held by one owner at a time, which expires unless renewed, e.g. the
+// leadership of the replicas running scheduled jobs
+type Lease struct {
+	client *Client
+	key    string
+	owner  string
+	ttl    time.Duration
+}
+
+// NewLease creates a lease of the key for the owner, unique among the contenders, held for ttl
+// after every Acquire
+func (c *Client) NewLease(key, owner string, ttl time.Duration) *Lease {
+	return &Lease{client: c, key: c.prefix + key, owner: owner, ttl: ttl}
+}
+
+// Acquire takes the lease when it is free, or renews it when the owner holds it, reporting
+// whether the owner holds it now
+func (l *Lease) Acquire(ctx context.Context) (bool, error) {
+	reply, err := l.client.Do(ctx, "EVAL", acquireScript, "1", l.key, l.owner, millis(l.ttl))
+	if err != nil {
+		return false, err
+	}
+	held, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("redis: unexpected lease reply %T", reply)
+	}
+	return held == 1, nil
+}
+
+// Release frees the lease if the owner holds it, for another contender to take over without
+// waiting for it to expire
+func (l *Lease) Release(ctx context.Context) error {
+	_, err := l.client.Do(ctx, "EVAL", releaseScript, "1", l.key, l.owner)
+	return err
+}
+
+// TTL is how long the lease is held after Acquire
+func (l *Lease) TTL() time.Duration {
+	return l.ttl
+}
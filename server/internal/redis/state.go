@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// The methods below implement clockify.SharedState, their keys prefixed with WithPrefix
+
+// Add stores the key for ttl unless it is stored already, reporting whether it was added
+func (c *Client) Add(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	_, err := c.Do(ctx, "SET", c.prefix+key, "1", "NX", "PX", millis(ttl))
+	if errors.Is(err, Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get returns the value stored under key, ok is false when there is none
+func (c *Client) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.Do(ctx, "GET", c.prefix+key)
+	if errors.Is(err, Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return []byte(value), true, nil
+}
+
+// Set stores the value under key for ttl
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := c.Do(ctx, "SET", c.prefix+key, string(value), "PX", millis(ttl))
+	return err
+}
+
+// Delete removes the value stored under key
+func (c *Client) Delete(ctx context.Context, key string) error {
+	_, err := c.Do(ctx, "DEL", c.prefix+key)
+	return err
+}
+
+// incrScript increments a counter, setting its expiry when it is created in the same step so
+// that a failure in between cannot leave a counter that never expires
+const incrScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return n`
+
+// Incr increments the counter under key, created to expire ttl later, and returns its value
+func (c *Client) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	reply, err := c.Do(ctx, "EVAL", incrScript, "1", c.prefix+key, millis(ttl))
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %T", reply)
+	}
+	return n, nil
+}
+
+// millis formats a TTL for PX, at least a millisecond since Redis refuses 0
+func millis(ttl time.Duration) string {
+	return strconv.FormatInt(max(ttl.Milliseconds(), 1), 10)
+}
@@ -0,0 +1,214 @@
+// Package reminder tells users who logged less time than their expected quota.
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/capacity"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/notify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// WeeklyCheckDay is the day the weekly quota is checked, the last workday of the week
+const WeeklyCheckDay = time.Friday
+
+// Quota is the time a user is expected to log. Zero durations are not checked.
+type Quota struct {
+	Daily  time.Duration // Per workday
+	Weekly time.Duration // Per Monday-based week
+}
+
+// SenderFunc delivers a reminder to a user
+type SenderFunc func(ctx context.Context, user clockify.User, msg notify.Message) error
+
+// Option configures a Reminder
+type Option func(*Reminder)
+
+// WithUsers limits the check to the given users. By default every workspace user is checked,
+// which requires admin rights.
+func WithUsers(users ...clockify.User) Option {
+	return func(r *Reminder) {
+		r.users = users
+	}
+}
+
+// WithFallbackLocation sets the time zone of users without one. Defaults to time.Local.
+func WithFallbackLocation(loc *time.Location) Option {
+	return func(r *Reminder) {
+		r.fallback = loc
+	}
+}
+
+// WithCapacity checks the weekly quota of each user against their capacity in the model
+// instead of Quota.Weekly
+func WithCapacity(model capacity.Model) Option {
+	return func(r *Reminder) {
+		r.capacity = &model
+	}
+}
+
+// Reminder compares each user's logged time against the quota once their local day reaches
+// the reminder time. It is meant to be checked frequently, every user is reminded at most
+// once per day and once per week.
+type Reminder struct {
+	client       *clockify.APIClient
+	workspaceID  string
+	quota        Quota
+	hour, minute int
+	send         SenderFunc
+	users        []clockify.User
+	fallback     *time.Location
+	capacity     *capacity.Model
+
+	mu sync.Mutex
+	// User ID -> start of the last period the user was checked for
+	lastDaily  map[string]time.Time
+	lastWeekly map[string]time.Time
+}
+
+// New creates a reminder checking at hour:minute in each user's time zone
+func New(client *clockify.APIClient, workspaceID string, quota Quota, hour, minute int, send SenderFunc, opts ...Option) *Reminder {
+	r := &Reminder{
+		client:      client,
+		workspaceID: workspaceID,
+		quota:       quota,
+		hour:        hour,
+		minute:      minute,
+		send:        send,
+		fallback:    time.Local,
+		lastDaily:   make(map[string]time.Time),
+		lastWeekly:  make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Check reminds the users whose reminder time has passed. It is a scheduler.JobFunc.
+func (r *Reminder) Check(ctx context.Context) error {
+	client := r.client.WithContext(ctx)
+
+	users, err := r.listUsers(client)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	now := time.Now()
+	var errs []error
+	for _, user := range users {
+		if err := r.checkUser(ctx, client, user, now); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", user, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Reminder) checkUser(ctx context.Context, client *clockify.APIClient, user clockify.User, now time.Time) error {
+	local := now.In(user.Location(r.fallback))
+	remindAt := time.Date(local.Year(), local.Month(), local.Day(), r.hour, r.minute, 0, 0, local.Location())
+	if local.Before(remindAt) || isWeekend(local) {
+		return nil
+	}
+
+	var errs []error
+
+	day := report.Day(local)
+	if r.quota.Daily > 0 {
+		err := r.once(r.lastDaily, user.ID, day.Start, func() error {
+			return r.remind(ctx, client, user, day, r.quota.Daily, "today", now)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	week := report.Week(local, 0)
+	if (r.quota.Weekly > 0 || r.capacity != nil) && local.Weekday() == WeeklyCheckDay {
+		err := r.once(r.lastWeekly, user.ID, week.Start, func() error {
+			quota, err := r.weeklyQuota(client, user)
+			if err != nil || quota == 0 {
+				return err
+			}
+			return r.remind(ctx, client, user, week, quota, "this week", now)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// weeklyQuota returns the capacity of the user with WithCapacity, Quota.Weekly otherwise
+func (r *Reminder) weeklyQuota(client *clockify.APIClient, user clockify.User) (time.Duration, error) {
+	if r.capacity == nil {
+		return r.quota.Weekly, nil
+	}
+	model, err := r.capacity.Resolve(client, r.workspaceID, []clockify.User{user})
+	if err != nil {
+		return 0, err
+	}
+	return model.Weekly(user), nil
+}
+
+// once runs check unless it already succeeded for the user and period
+func (r *Reminder) once(last map[string]time.Time, userID string, periodStart time.Time, check func() error) error {
+	r.mu.Lock()
+	done := last[userID].Equal(periodStart)
+	r.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	if err := check(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	last[userID] = periodStart
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Reminder) remind(ctx context.Context, client *clockify.APIClient, user clockify.User, period report.Period, quota time.Duration, label string, now time.Time) error {
+	entries, err := report.FetchEntries(client, r.workspaceID, user.ID, period)
+	if err != nil {
+		return err
+	}
+	logged := report.Summarize(period, entries, nil, now).Total
+	if logged >= quota {
+		return nil
+	}
+
+	slog.Info("quota_reminder", "user_id", user.ID, "period", period.String(), "logged", logged, "quota", quota)
+	return r.send(ctx, user, notify.Message{
+		Title: fmt.Sprintf("%s, you logged %s of %s %s", user, report.FormatDuration(logged), report.FormatDuration(quota), label),
+		Text:  fmt.Sprintf("%s missing for %s.", report.FormatDuration(quota-logged), period),
+	})
+}
+
+func (r *Reminder) listUsers(client *clockify.APIClient) ([]clockify.User, error) {
+	if r.users != nil {
+		return r.users, nil
+	}
+
+	var all []clockify.User
+	for users, err := range client.IterWorkspaceUsers(r.workspaceID) {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, users...)
+	}
+	return all, nil
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
@@ -0,0 +1,228 @@
+package report
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/money"
+)
+
+// Source is a workspace, possibly of another account, whose entries go into a combined summary
+type Source struct {
+	// Label tells the workspace apart, e.g. a profile name. The workspace name is used if empty.
+	Label     string
+	Client    *clockify.APIClient
+	Workspace clockify.Workspace
+	UserID    string
+}
+
+func (s Source) label() string {
+	return cmp.Or(s.Label, s.Workspace.Name, s.Workspace.ID)
+}
+
+// WorkspaceEntries are the entries and projects fetched from a source
+type WorkspaceEntries struct {
+	Source   Source
+	Entries  []clockify.TimeEntry
+	Projects []clockify.Project
+}
+
+// WorkspaceTotal is the time tracked and the amount billed in a single workspace
+type WorkspaceTotal struct {
+	Label    string
+	Duration time.Duration
+	Billable time.Duration
+	// Billable amount per currency in the smallest currency unit, from the entry, project or
+	// workspace rate, "" for amounts without one
+	Revenue map[string]int64
+}
+
+// Combined is a summary over several workspaces with the totals of each
+type Combined struct {
+	// Projects sharing a name across workspaces are told apart by the workspace label,
+	// e.g. "Support (acme)"
+	*Summary
+	Workspaces []WorkspaceTotal // In the order of the sources
+	Revenue    map[string]int64 // Revenue per currency, "" for amounts without one
+}
+
+// BuildCombined fetches the entries of every source in the period and combines them
+func BuildCombined(sources []Source, period Period, now time.Time) (*Combined, error) {
+	workspaces := make([]WorkspaceEntries, 0, len(sources))
+	for _, source := range sources {
+		entries, err := FetchEntries(source.Client, source.Workspace.ID, source.UserID, period)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch time entries of %s: %w", source.label(), err)
+		}
+
+		var projects []clockify.Project
+		for page, err := range source.Client.IterProjects(source.Workspace.ID) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch projects of %s: %w", source.label(), err)
+			}
+			projects = append(projects, page...)
+		}
+
+		workspaces = append(workspaces, WorkspaceEntries{Source: source, Entries: entries, Projects: projects})
+	}
+
+	return Combine(period, workspaces, now), nil
+}
+
+// Combine aggregates the entries of several workspaces starting within the period into one
+// summary, like Summarize, and totals each workspace
+func Combine(period Period, workspaces []WorkspaceEntries, now time.Time) *Combined {
+	combined := &Combined{Revenue: make(map[string]int64)}
+
+	// Count the workspaces using each name to find the duplicates
+	workspacesOf := make(map[string]int)
+	for _, ws := range workspaces {
+		names := make(map[string]bool)
+		for _, project := range ws.Projects {
+			names[project.Name] = true
+		}
+		for name := range names {
+			workspacesOf[name]++
+		}
+	}
+
+	var entries []clockify.TimeEntry
+	projectNames := make(map[string]string)
+	for _, ws := range workspaces {
+		projects := make(map[string]clockify.Project, len(ws.Projects))
+		for _, project := range ws.Projects {
+			projects[project.ID] = project
+			projectNames[project.ID] = project.Name
+			if workspacesOf[project.Name] > 1 {
+				projectNames[project.ID] = fmt.Sprintf("%s (%s)", project.Name, ws.Source.label())
+			}
+		}
+
+		total := WorkspaceTotal{Label: ws.Source.label(), Revenue: make(map[string]int64)}
+		for _, entry := range ws.Entries {
+			if entry.TimeInterval == nil || !period.Contains(entry.TimeInterval.Start) {
+				continue
+			}
+			entries = append(entries, entry)
+			if entry.IsBreak() {
+				continue
+			}
+
+			duration := EntryDuration(entry, now)
+			total.Duration += duration
+			if !entry.Billable {
+				continue
+			}
+			total.Billable += duration
+
+			if rate := rateOf(entry, projects[entry.ProjectID], ws.Source.Workspace); rate != nil {
+				amount := money.New(rate.Amount, rate.Currency).Over(duration).Amount
+				total.Revenue[rate.Currency] += amount
+				combined.Revenue[rate.Currency] += amount
+			}
+		}
+		combined.Workspaces = append(combined.Workspaces, total)
+	}
+
+	// Entries without a project share the NoProject row across workspaces
+	combined.Summary = Summarize(period, entries, projectNames, now)
+	return combined
+}
+
+// Currencies returns the currencies revenue was billed in, sorted
+func (c *Combined) Currencies() []string {
+	return slices.Sorted(maps.Keys(c.Revenue))
+}
+
+// rateOf returns the billable rate of an entry: its effective Clockify rate, then the
+// project's, then the workspace default. Nil when none is set.
+func rateOf(entry clockify.TimeEntry, project clockify.Project, workspace clockify.Workspace) *clockify.Rate {
+	for _, rate := range []*clockify.Rate{entry.HourlyRate, project.HourlyRate, workspace.HourlyRate} {
+		if rate != nil && rate.Amount > 0 {
+			return rate
+		}
+	}
+	return nil
+}
+
+// RenderCombined writes the combined summary in the given format, followed by the totals of each
+// workspace
+func RenderCombined(w io.Writer, combined *Combined, format Format) error {
+	switch format {
+	case FormatTable:
+		if err := WriteTable(w, combined.Summary); err != nil {
+			return err
+		}
+		return writeWorkspaceTable(w, combined)
+	case FormatCSV:
+		// The breakdown is the same as for a single workspace, the labels are in the project names
+		return WriteCSV(w, combined.Summary)
+	case FormatJSON:
+		return writeCombinedJSON(w, combined)
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv or json", format)
+	}
+}
+
+// formatRevenue formats the amounts per currency, e.g. "1250.00 USD, 300.00 EUR"
+func formatRevenue(revenue map[string]int64) string {
+	if len(revenue) == 0 {
+		return money.Money{}.String()
+	}
+	amounts := make([]string, 0, len(revenue))
+	for _, currency := range slices.Sorted(maps.Keys(revenue)) {
+		amounts = append(amounts, money.New(revenue[currency], currency).String())
+	}
+	return strings.Join(amounts, ", ")
+}
+
+func writeWorkspaceTable(w io.Writer, combined *Combined) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "\nWORKSPACE\tHOURS\tBILLABLE\tREVENUE\t")
+	for _, ws := range combined.Workspaces {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", ws.Label, FormatDuration(ws.Duration), FormatDuration(ws.Billable), formatRevenue(ws.Revenue))
+	}
+	// Amounts in different currencies are not added up
+	for _, currency := range combined.Currencies() {
+		fmt.Fprintf(tw, "TOTAL\t\t\t%s\t\n", money.New(combined.Revenue[currency], currency))
+	}
+
+	return tw.Flush()
+}
+
+type jsonWorkspace struct {
+	Workspace     string           `json:"workspace"`
+	Hours         float64          `json:"hours"`
+	BillableHours float64          `json:"billableHours"`
+	Revenue       map[string]int64 `json:"revenue"`
+}
+
+type jsonCombined struct {
+	jsonSummary
+	Workspaces []jsonWorkspace  `json:"workspaces"`
+	Revenue    map[string]int64 `json:"revenue"`
+}
+
+func writeCombinedJSON(w io.Writer, combined *Combined) error {
+	out := jsonCombined{
+		jsonSummary: newJSONSummary(combined.Summary),
+		Workspaces:  make([]jsonWorkspace, 0, len(combined.Workspaces)),
+		Revenue:     combined.Revenue,
+	}
+	for _, ws := range combined.Workspaces {
+		out.Workspaces = append(out.Workspaces, jsonWorkspace{ws.Label, Hours(ws.Duration), Hours(ws.Billable), ws.Revenue})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// FetchEntries retrieves the user's time entries starting within the period
+func FetchEntries(client *clockify.APIClient, workspaceID, userID string, period Period) ([]clockify.TimeEntry, error) {
+	var entries []clockify.TimeEntry
+
+	// Clockify's end filter is inclusive, the period's end is not
+	end := period.End.Add(-1)
+	for page, err := range client.IterTimeEntries(workspaceID, userID, &period.Start, &end) {
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page...)
+	}
+
+	return entries, nil
+}
+
+// ProjectNames maps the IDs of all projects in the workspace to their names
+func ProjectNames(client *clockify.APIClient, workspaceID string) (map[string]string, error) {
+	names := make(map[string]string)
+
+	for projects, err := range client.IterProjects(workspaceID) {
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			names[project.ID] = project.Name
+		}
+	}
+
+	return names, nil
+}
+
+// TagNames maps the IDs of all tags in the workspace to their names
+func TagNames(client *clockify.APIClient, workspaceID string) (map[string]string, error) {
+	names := make(map[string]string)
+
+	for tags, err := range client.IterTags(workspaceID) {
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			names[tag.ID] = tag.Name
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,56 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a half-open time range [Start, End)
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%s – %s", p.Start.Format("2006-01-02"), p.End.AddDate(0, 0, -1).Format("2006-01-02"))
+}
+
+// Contains reports whether t falls into the period
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Days returns the start of every day in the period
+func (p Period) Days() []time.Time {
+	var days []time.Time
+	for day := p.Start; day.Before(p.End); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// startOfDay truncates t to midnight in its location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Day returns the day containing t
+func Day(t time.Time) Period {
+	start := startOfDay(t)
+	return Period{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+// Week returns the Monday-based week containing t, shifted by offset weeks (-1 is the previous week)
+func Week(t time.Time, offset int) Period {
+	start := startOfDay(t)
+	// Go's weeks start on Sunday, ISO weeks on Monday
+	weekday := (int(start.Weekday()) + 6) % 7
+	start = start.AddDate(0, 0, -weekday+7*offset)
+	return Period{Start: start, End: start.AddDate(0, 0, 7)}
+}
+
+// Month returns the calendar month containing t, shifted by offset months (-1 is the previous month)
+func Month(t time.Time, offset int) Period {
+	start := time.Date(t.Year(), t.Month()+time.Month(offset), 1, 0, 0, 0, 0, t.Location())
+	return Period{Start: start, End: start.AddDate(0, 1, 0)}
+}
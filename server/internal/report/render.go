@@ -0,0 +1,161 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Format is an output format of a summary
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatCSV   Format = "csv"
+	FormatJSON  Format = "json"
+)
+
+// Render writes the summary in the given format
+func Render(w io.Writer, summary *Summary, format Format) error {
+	switch format {
+	case FormatTable:
+		return WriteTable(w, summary)
+	case FormatCSV:
+		return WriteCSV(w, summary)
+	case FormatJSON:
+		return WriteJSON(w, summary)
+	default:
+		return fmt.Errorf("unknown format %q, expected table, csv or json", format)
+	}
+}
+
+// FormatDuration formats a duration as hours and minutes, e.g. "12:05"
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+// Hours converts a duration to fractional hours rounded to 2 decimals
+func Hours(d time.Duration) float64 {
+	return float64(d.Round(36*time.Second)) / float64(time.Hour)
+}
+
+// WriteTable writes the per-project and per-day breakdowns as ASCII tables
+func WriteTable(w io.Writer, summary *Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Period: %s\t\n\n", summary.Period)
+
+	fmt.Fprintln(tw, "PROJECT\tHOURS\tBILLABLE\tENTRIES\t")
+	for _, project := range summary.Projects {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t\n", project.Project, FormatDuration(project.Duration), FormatDuration(project.Billable), project.Entries)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%s\t%s\t\t\n", FormatDuration(summary.Total), FormatDuration(summary.Billable))
+	// Periods without breaks keep the layout of workspaces that do not track them
+	breaks := summary.Breaks > 0
+	if breaks {
+		fmt.Fprintf(tw, "BREAKS\t%s\t\t\t\n", FormatDuration(summary.Breaks))
+	}
+	fmt.Fprintln(tw)
+
+	if breaks {
+		fmt.Fprintln(tw, "DAY\tHOURS\tBREAKS\tENTRIES\t")
+	} else {
+		fmt.Fprintln(tw, "DAY\tHOURS\tENTRIES\t")
+	}
+	for _, day := range summary.Days {
+		fmt.Fprintf(tw, "%s\t%s\t", day.Day.Format("Mon 2006-01-02"), FormatDuration(day.Duration))
+		if breaks {
+			fmt.Fprintf(tw, "%s\t", FormatDuration(day.Breaks))
+		}
+		fmt.Fprintf(tw, "%d\t\n", day.Entries)
+	}
+
+	return tw.Flush()
+}
+
+// WriteCSV writes one row per project and day
+func WriteCSV(w io.Writer, summary *Summary) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"project", "day", "hours"})
+	for _, cell := range summary.Cells {
+		cw.Write([]string{cell.Project, cell.Day.Format("2006-01-02"), strconv.FormatFloat(Hours(cell.Duration), 'f', 2, 64)})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonProject struct {
+	ProjectID     string  `json:"projectId,omitempty"`
+	Project       string  `json:"project"`
+	Hours         float64 `json:"hours"`
+	BillableHours float64 `json:"billableHours"`
+	Entries       int     `json:"entries"`
+}
+
+type jsonDay struct {
+	Day        string  `json:"day"`
+	Hours      float64 `json:"hours"`
+	BreakHours float64 `json:"breakHours"`
+	Entries    int     `json:"entries"`
+}
+
+type jsonCell struct {
+	Project string  `json:"project"`
+	Day     string  `json:"day"`
+	Hours   float64 `json:"hours"`
+}
+
+type jsonSummary struct {
+	Start         string        `json:"start"`
+	End           string        `json:"end"`
+	Hours         float64       `json:"hours"`
+	BillableHours float64       `json:"billableHours"`
+	BreakHours    float64       `json:"breakHours"`
+	Projects      []jsonProject `json:"projects"`
+	Days          []jsonDay     `json:"days"`
+	Breakdown     []jsonCell    `json:"breakdown"`
+}
+
+// JSONDocument returns an empty value of the document WriteJSON writes, e.g. to describe the
+// summary in a schema
+func JSONDocument() any {
+	return jsonSummary{}
+}
+
+// WriteJSON writes the summary as JSON with durations in hours
+func WriteJSON(w io.Writer, summary *Summary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newJSONSummary(summary))
+}
+
+func newJSONSummary(summary *Summary) jsonSummary {
+	out := jsonSummary{
+		Start:         summary.Period.Start.Format(time.RFC3339),
+		End:           summary.Period.End.Format(time.RFC3339),
+		Hours:         Hours(summary.Total),
+		BillableHours: Hours(summary.Billable),
+		BreakHours:    Hours(summary.Breaks),
+		Projects:      make([]jsonProject, 0, len(summary.Projects)),
+		Days:          make([]jsonDay, 0, len(summary.Days)),
+		Breakdown:     make([]jsonCell, 0, len(summary.Cells)),
+	}
+
+	for _, p := range summary.Projects {
+		out.Projects = append(out.Projects, jsonProject{p.ProjectID, p.Project, Hours(p.Duration), Hours(p.Billable), p.Entries})
+	}
+	for _, d := range summary.Days {
+		out.Days = append(out.Days, jsonDay{d.Day.Format("2006-01-02"), Hours(d.Duration), Hours(d.Breaks), d.Entries})
+	}
+	for _, c := range summary.Cells {
+		out.Breakdown = append(out.Breakdown, jsonCell{c.Project, c.Day.Format("2006-01-02"), Hours(c.Duration)})
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// Rounding modes
+const (
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// Rounding scopes
+const (
+	RoundEntry = "entry" // Every entry on its own
+	RoundDay   = "day"   // The billable or non-billable time of a user on a project in a day
+)
+
+// Rounding rounds tracked time to an increment the way client billing agreements do, e.g.
+// every entry up to the next 15 minutes. The zero value does not round.
+type Rounding struct {
+	Increment time.Duration
+	Mode      string // RoundNearest by default
+	Scope     string // RoundEntry by default
+}
+
+// Round rounds d to the increment
+func (r Rounding) Round(d time.Duration) time.Duration {
+	if r.Increment <= 0 {
+		return d
+	}
+	switch r.Mode {
+	case RoundUp:
+		if rounded := d.Truncate(r.Increment); rounded != d {
+			return rounded + r.Increment
+		}
+		return d
+	case RoundDown:
+		return d.Truncate(r.Increment)
+	default:
+		return d.Round(r.Increment)
+	}
+}
+
+// Apply returns copies of the entries whose ends are moved so that they last the rounded
+// time, leaving the entries themselves untouched. By day, the last entries of each user,
+// project, billable flag and day in loc absorb the difference. Running entries are not rounded.
+func (r Rounding) Apply(entries []clockify.TimeEntry, loc *time.Location) []clockify.TimeEntry {
+	if r.Increment <= 0 {
+		return entries
+	}
+	rounded := slices.Clone(entries)
+
+	if r.Scope != RoundDay {
+		for i, entry := range rounded {
+			if finished(entry) {
+				rounded[i] = withDuration(entry, r.Round(EntryDuration(entry, time.Time{})))
+			}
+		}
+		return rounded
+	}
+
+	type dayKey struct {
+		user, project string
+		billable      bool
+		day           time.Time
+	}
+	days := make(map[dayKey][]int)
+	var keys []dayKey
+	for i, entry := range rounded {
+		if !finished(entry) {
+			continue
+		}
+		key := dayKey{entry.UserID, entry.ProjectID, entry.Billable, startOfDay(entry.TimeInterval.Start.In(loc))}
+		if _, ok := days[key]; !ok {
+			keys = append(keys, key)
+		}
+		days[key] = append(days[key], i)
+	}
+
+	for _, key := range keys {
+		indices := days[key]
+		slices.SortFunc(indices, func(a, b int) int {
+			return rounded[a].TimeInterval.Start.Compare(rounded[b].TimeInterval.Start)
+		})
+
+		var total time.Duration
+		for _, i := range indices {
+			total += EntryDuration(rounded[i], time.Time{})
+		}
+		delta := r.Round(total) - total
+		if delta > 0 {
+			last := indices[len(indices)-1]
+			rounded[last] = withDuration(rounded[last], EntryDuration(rounded[last], time.Time{})+delta)
+			continue
+		}
+		// Rounding down may take more than the last entry lasted
+		for j := len(indices) - 1; j >= 0 && delta < 0; j-- {
+			i := indices[j]
+			duration := EntryDuration(rounded[i], time.Time{})
+			taken := min(duration, -delta)
+			rounded[i] = withDuration(rounded[i], duration-taken)
+			delta += taken
+		}
+	}
+	return rounded
+}
+
+func finished(entry clockify.TimeEntry) bool {
+	return entry.TimeInterval != nil && entry.TimeInterval.End != nil
+}
+
+// withDuration returns a copy of the finished entry ending d after its start
+func withDuration(entry clockify.TimeEntry, d time.Duration) clockify.TimeEntry {
+	interval := *entry.TimeInterval
+	end := interval.Start.Add(d)
+	interval.End, interval.Duration = &end, ""
+	entry.TimeInterval = &interval
+	return entry
+}
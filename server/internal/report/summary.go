@@ -0,0 +1,145 @@
+// Package report aggregates time entries into per-project and per-day breakdowns.
+package report
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+// NoProject is the name used for entries tracked without a project
+const NoProject = "(no project)"
+
+// ProjectTotal is the time tracked on a single project
+type ProjectTotal struct {
+	ProjectID string
+	Project   string
+	Duration  time.Duration
+	Billable  time.Duration
+	Entries   int
+}
+
+// DayTotal is the time tracked on a single day
+type DayTotal struct {
+	Day      time.Time
+	Duration time.Duration
+	Breaks   time.Duration
+	Entries  int
+}
+
+// Cell is the time tracked on a project on a single day
+type Cell struct {
+	ProjectID string
+	Project   string
+	Day       time.Time
+	Duration  time.Duration
+}
+
+// Summary is the breakdown of the time tracked in a period. Breaks are totaled apart from the
+// work, they are in no project.
+type Summary struct {
+	Period   Period
+	Total    time.Duration
+	Billable time.Duration
+	Breaks   time.Duration
+	Projects []ProjectTotal // Longest first
+	Days     []DayTotal     // Every day of the period, in order
+	Cells    []Cell         // Non-empty project/day combinations, ordered by project and day
+}
+
+// EntryDuration returns how long the entry lasted, counting running entries up to now
+func EntryDuration(entry clockify.TimeEntry, now time.Time) time.Duration {
+	if entry.TimeInterval == nil {
+		return 0
+	}
+	end := now
+	if entry.TimeInterval.End != nil {
+		end = *entry.TimeInterval.End
+	}
+	return max(end.Sub(entry.TimeInterval.Start), 0)
+}
+
+// Summarize aggregates the entries starting within the period. Entries are attributed to
+// the day they started on, in the location of the period.
+//
+// projectNames maps project IDs to names; unknown IDs are shown as is.
+func Summarize(period Period, entries []clockify.TimeEntry, projectNames map[string]string, now time.Time) *Summary {
+	summary := &Summary{Period: period}
+
+	projects := make(map[string]*ProjectTotal)
+	days := make(map[time.Time]*DayTotal)
+	cells := make(map[string]map[time.Time]time.Duration)
+
+	for _, day := range period.Days() {
+		days[day] = &DayTotal{Day: day}
+	}
+
+	for _, entry := range entries {
+		if entry.TimeInterval == nil {
+			continue
+		}
+		start := entry.TimeInterval.Start.In(period.Start.Location())
+		if !period.Contains(start) {
+			continue
+		}
+
+		duration := EntryDuration(entry, now)
+		day := startOfDay(start)
+		if entry.IsBreak() {
+			days[day].Breaks += duration
+			summary.Breaks += duration
+			continue
+		}
+
+		project, ok := projects[entry.ProjectID]
+		if !ok {
+			project = &ProjectTotal{ProjectID: entry.ProjectID, Project: projectName(entry.ProjectID, projectNames)}
+			projects[entry.ProjectID] = project
+			cells[entry.ProjectID] = make(map[time.Time]time.Duration)
+		}
+		project.Duration += duration
+		project.Entries++
+
+		days[day].Duration += duration
+		days[day].Entries++
+
+		cells[entry.ProjectID][day] += duration
+
+		summary.Total += duration
+		if entry.Billable {
+			project.Billable += duration
+			summary.Billable += duration
+		}
+	}
+
+	for _, project := range projects {
+		summary.Projects = append(summary.Projects, *project)
+		for day, duration := range cells[project.ProjectID] {
+			summary.Cells = append(summary.Cells, Cell{ProjectID: project.ProjectID, Project: project.Project, Day: day, Duration: duration})
+		}
+	}
+	slices.SortFunc(summary.Projects, func(a, b ProjectTotal) int {
+		return cmp.Or(cmp.Compare(b.Duration, a.Duration), cmp.Compare(a.Project, b.Project))
+	})
+	slices.SortFunc(summary.Cells, func(a, b Cell) int {
+		return cmp.Or(cmp.Compare(a.Project, b.Project), a.Day.Compare(b.Day))
+	})
+
+	for _, day := range period.Days() {
+		summary.Days = append(summary.Days, *days[day])
+	}
+
+	return summary
+}
+
+func projectName(projectID string, projectNames map[string]string) string {
+	if projectID == "" {
+		return NoProject
+	}
+	if name, ok := projectNames[projectID]; ok {
+		return name
+	}
+	return projectID
+}
@@ -0,0 +1,334 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ccws/v1/ccws.proto
+
+// CCWS exposes the core time tracking operations of the server to internal services.
+// It mirrors the REST API under /api/v1 and acts on behalf of the configured Clockify user.
+
+package ccwsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CCWS_GetTimer_FullMethodName        = "/ccws.v1.CCWS/GetTimer"
+	CCWS_StartTimer_FullMethodName      = "/ccws.v1.CCWS/StartTimer"
+	CCWS_StopTimer_FullMethodName       = "/ccws.v1.CCWS/StopTimer"
+	CCWS_ListEntries_FullMethodName     = "/ccws.v1.CCWS/ListEntries"
+	CCWS_GetSummary_FullMethodName      = "/ccws.v1.CCWS/GetSummary"
+	CCWS_SubscribeEvents_FullMethodName = "/ccws.v1.CCWS/SubscribeEvents"
+)
+
+// CCWSClient is the client API for CCWS service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CCWSClient interface {
+	// GetTimer returns the running timer, if any
+	GetTimer(ctx context.Context, in *GetTimerRequest, opts ...grpc.CallOption) (*GetTimerResponse, error)
+	// StartTimer starts a timer, resolving the project, task and tags by name. Without any of
+	// them, the configured default project, task and tags are tracked.
+	StartTimer(ctx context.Context, in *StartTimerRequest, opts ...grpc.CallOption) (*TimeEntry, error)
+	// StopTimer stops the running timer, NOT_FOUND when none is running. INVALID_ARGUMENT when
+	// the idle time or split point is outside the entry.
+	StopTimer(ctx context.Context, in *StopTimerRequest, opts ...grpc.CallOption) (*StopTimerResponse, error)
+	// ListEntries returns the time entries starting in a period, newest first
+	ListEntries(ctx context.Context, in *ListEntriesRequest, opts ...grpc.CallOption) (*ListEntriesResponse, error)
+	// GetSummary aggregates the time entries of a period per project and per day
+	GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Summary, error)
+	// SubscribeEvents streams processed webhook events and events raised by CCWS
+	SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type cCWSClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCCWSClient(cc grpc.ClientConnInterface) CCWSClient {
+	return &cCWSClient{cc}
+}
+
+func (c *cCWSClient) GetTimer(ctx context.Context, in *GetTimerRequest, opts ...grpc.CallOption) (*GetTimerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTimerResponse)
+	err := c.cc.Invoke(ctx, CCWS_GetTimer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cCWSClient) StartTimer(ctx context.Context, in *StartTimerRequest, opts ...grpc.CallOption) (*TimeEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TimeEntry)
+	err := c.cc.Invoke(ctx, CCWS_StartTimer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cCWSClient) StopTimer(ctx context.Context, in *StopTimerRequest, opts ...grpc.CallOption) (*StopTimerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StopTimerResponse)
+	err := c.cc.Invoke(ctx, CCWS_StopTimer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cCWSClient) ListEntries(ctx context.Context, in *ListEntriesRequest, opts ...grpc.CallOption) (*ListEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEntriesResponse)
+	err := c.cc.Invoke(ctx, CCWS_ListEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cCWSClient) GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Summary, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Summary)
+	err := c.cc.Invoke(ctx, CCWS_GetSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cCWSClient) SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CCWS_ServiceDesc.Streams[0], CCWS_SubscribeEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CCWS_SubscribeEventsClient = grpc.ServerStreamingClient[Event]
+
+// CCWSServer is the server API for CCWS service.
+// All implementations must embed UnimplementedCCWSServer
+// for forward compatibility.
+type CCWSServer interface {
+	// GetTimer returns the running timer, if any
+	GetTimer(context.Context, *GetTimerRequest) (*GetTimerResponse, error)
+	// StartTimer starts a timer, resolving the project, task and tags by name. Without any of
+	// them, the configured default project, task and tags are tracked.
+	StartTimer(context.Context, *StartTimerRequest) (*TimeEntry, error)
+	// StopTimer stops the running timer, NOT_FOUND when none is running. INVALID_ARGUMENT when
+	// the idle time or split point is outside the entry.
+	StopTimer(context.Context, *StopTimerRequest) (*StopTimerResponse, error)
+	// ListEntries returns the time entries starting in a period, newest first
+	ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error)
+	// GetSummary aggregates the time entries of a period per project and per day
+	GetSummary(context.Context, *GetSummaryRequest) (*Summary, error)
+	// SubscribeEvents streams processed webhook events and events raised by CCWS
+	SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedCCWSServer()
+}
+
+// UnimplementedCCWSServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCCWSServer struct{}
+
+func (UnimplementedCCWSServer) GetTimer(context.Context, *GetTimerRequest) (*GetTimerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTimer not implemented")
+}
+func (UnimplementedCCWSServer) StartTimer(context.Context, *StartTimerRequest) (*TimeEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartTimer not implemented")
+}
+func (UnimplementedCCWSServer) StopTimer(context.Context, *StopTimerRequest) (*StopTimerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopTimer not implemented")
+}
+func (UnimplementedCCWSServer) ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEntries not implemented")
+}
+func (UnimplementedCCWSServer) GetSummary(context.Context, *GetSummaryRequest) (*Summary, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSummary not implemented")
+}
+func (UnimplementedCCWSServer) SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeEvents not implemented")
+}
+func (UnimplementedCCWSServer) mustEmbedUnimplementedCCWSServer() {}
+func (UnimplementedCCWSServer) testEmbeddedByValue()              {}
+
+// UnsafeCCWSServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CCWSServer will
+// result in compilation errors.
+type UnsafeCCWSServer interface {
+	mustEmbedUnimplementedCCWSServer()
+}
+
+func RegisterCCWSServer(s grpc.ServiceRegistrar, srv CCWSServer) {
+	// If the following call pancis, it indicates UnimplementedCCWSServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CCWS_ServiceDesc, srv)
+}
+
+func _CCWS_GetTimer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTimerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CCWSServer).GetTimer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CCWS_GetTimer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CCWSServer).GetTimer(ctx, req.(*GetTimerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CCWS_StartTimer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartTimerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CCWSServer).StartTimer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CCWS_StartTimer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CCWSServer).StartTimer(ctx, req.(*StartTimerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CCWS_StopTimer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopTimerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CCWSServer).StopTimer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CCWS_StopTimer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CCWSServer).StopTimer(ctx, req.(*StopTimerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CCWS_ListEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CCWSServer).ListEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CCWS_ListEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CCWSServer).ListEntries(ctx, req.(*ListEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CCWS_GetSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CCWSServer).GetSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CCWS_GetSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CCWSServer).GetSummary(ctx, req.(*GetSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CCWS_SubscribeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CCWSServer).SubscribeEvents(m, &grpc.GenericServerStream[SubscribeEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CCWS_SubscribeEventsServer = grpc.ServerStreamingServer[Event]
+
+// CCWS_ServiceDesc is the grpc.ServiceDesc for CCWS service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CCWS_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ccws.v1.CCWS",
+	HandlerType: (*CCWSServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTimer",
+			Handler:    _CCWS_GetTimer_Handler,
+		},
+		{
+			MethodName: "StartTimer",
+			Handler:    _CCWS_StartTimer_Handler,
+		},
+		{
+			MethodName: "StopTimer",
+			Handler:    _CCWS_StopTimer_Handler,
+		},
+		{
+			MethodName: "ListEntries",
+			Handler:    _CCWS_ListEntries_Handler,
+		},
+		{
+			MethodName: "GetSummary",
+			Handler:    _CCWS_GetSummary_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeEvents",
+			Handler:       _CCWS_SubscribeEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ccws/v1/ccws.proto",
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Hukyl/CCWS/internal/api"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/report"
+	"github.com/Hukyl/CCWS/internal/rpc/ccwsv1"
+)
+
+var periodKinds = map[ccwsv1.Period_Kind]string{
+	ccwsv1.Period_KIND_UNSPECIFIED: "week",
+	ccwsv1.Period_KIND_DAY:         "day",
+	ccwsv1.Period_KIND_WEEK:        "week",
+	ccwsv1.Period_KIND_MONTH:       "month",
+}
+
+func periodFromProto(p *ccwsv1.Period) (report.Period, error) {
+	if p.GetStart() != nil || p.GetEnd() != nil {
+		if p.GetStart() == nil || p.GetEnd() == nil {
+			return report.Period{}, api.InvalidRequestf("period: start and end must be set together")
+		}
+		start, end := p.GetStart().AsTime().Local(), p.GetEnd().AsTime().Local()
+		if !end.After(start) {
+			return report.Period{}, api.InvalidRequestf("period: end must be after start")
+		}
+		return report.Period{Start: start, End: end}, nil
+	}
+
+	kind, ok := periodKinds[p.GetKind()]
+	if !ok {
+		return report.Period{}, api.InvalidRequestf("period: unknown kind %d", p.GetKind())
+	}
+	return api.PeriodOf(kind, int(p.GetOffset()), time.Now())
+}
+
+func timestampOf(t time.Time) *timestamppb.Timestamp {
+	return timestamppb.New(t)
+}
+
+func timeEntryToProto(entry *clockify.TimeEntry) *ccwsv1.TimeEntry {
+	if entry == nil {
+		return nil
+	}
+
+	out := &ccwsv1.TimeEntry{
+		Id:          entry.ID,
+		Description: entry.Description,
+		UserId:      entry.UserID,
+		WorkspaceId: entry.WorkspaceID,
+		ProjectId:   entry.ProjectID,
+		TaskId:      entry.TaskID,
+		TagIds:      entry.TagIDs,
+		Billable:    entry.Billable,
+	}
+	if interval := entry.TimeInterval; interval != nil {
+		out.Start = timestampOf(interval.Start)
+		if interval.End != nil {
+			out.End = timestampOf(*interval.End)
+		}
+	}
+	return out
+}
+
+func summaryToProto(summary *report.Summary) *ccwsv1.Summary {
+	out := &ccwsv1.Summary{
+		Start:    timestampOf(summary.Period.Start),
+		End:      timestampOf(summary.Period.End),
+		Total:    durationpb.New(summary.Total),
+		Billable: durationpb.New(summary.Billable),
+		Projects: make([]*ccwsv1.Summary_ProjectTotal, len(summary.Projects)),
+		Days:     make([]*ccwsv1.Summary_DayTotal, len(summary.Days)),
+	}
+	for i, p := range summary.Projects {
+		out.Projects[i] = &ccwsv1.Summary_ProjectTotal{
+			ProjectId: p.ProjectID,
+			Project:   p.Project,
+			Duration:  durationpb.New(p.Duration),
+			Billable:  durationpb.New(p.Billable),
+			Entries:   int32(p.Entries),
+		}
+	}
+	for i, d := range summary.Days {
+		out.Days[i] = &ccwsv1.Summary_DayTotal{
+			Day:      timestampOf(d.Day),
+			Duration: durationpb.New(d.Duration),
+			Entries:  int32(d.Entries),
+		}
+	}
+	return out
+}
@@ -0,0 +1,233 @@
+// Package rpc serves the CCWS gRPC service defined in proto/ccws/v1/ccws.proto. It shares
+// the operations, caches and event stream of the REST API.
+package rpc
+
+//go:generate buf generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/Hukyl/CCWS/internal/api"
+	"github.com/Hukyl/CCWS/internal/audit"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+	"github.com/Hukyl/CCWS/internal/rpc/ccwsv1"
+)
+
+// Service implements ccwsv1.CCWSServer on top of the REST API operations
+type Service struct {
+	ccwsv1.UnimplementedCCWSServer
+
+	api *api.API
+}
+
+func NewService(a *api.API) *Service {
+	return &Service{api: a}
+}
+
+// NewServer creates a gRPC server with the service registered. Unless the API is anonymous,
+// the token of one of its users is required as "authorization: Bearer <token>" metadata on
+// every call, and the user's role decides which calls it may make.
+func NewServer(a *api.API, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := authorize(ctx, a)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if _, err := authorize(ss.Context(), a); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+
+	server := grpc.NewServer(opts...)
+	ccwsv1.RegisterCCWSServer(server, NewService(a))
+	return server
+}
+
+// authorize returns the context of a call made by the user with the call's token. Clockify
+// writes of the calls are audited as made through gRPC by the user.
+func authorize(ctx context.Context, a *api.API) (context.Context, error) {
+	if a.Anonymous() {
+		return audit.WithActor(ctx, "grpc"), nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if !ok {
+			continue
+		}
+		if user, ok := a.Authenticate(token); ok {
+			return audit.WithActor(api.WithUser(ctx, user), user.Actor("grpc")), nil
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing or invalid API token")
+}
+
+// toStatus maps the errors of the API operations to gRPC statuses
+func toStatus(method string, err error) error {
+	switch {
+	case errors.Is(err, api.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, api.ErrNoTimerRunning):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, api.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, clockify.ErrLocked):
+		return status.Error(codes.FailedPrecondition, "time entry is locked")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		slog.Error("rpc_clockify_failed", "method", method, "error", err)
+		return status.Error(codes.Unavailable, "Clockify request failed")
+	}
+}
+
+func (s *Service) GetTimer(ctx context.Context, _ *ccwsv1.GetTimerRequest) (*ccwsv1.GetTimerResponse, error) {
+	entry, err := s.api.RunningTimer(ctx)
+	if err != nil {
+		return nil, toStatus("GetTimer", err)
+	}
+	return &ccwsv1.GetTimerResponse{Entry: timeEntryToProto(entry)}, nil
+}
+
+func (s *Service) StartTimer(ctx context.Context, req *ccwsv1.StartTimerRequest) (*ccwsv1.TimeEntry, error) {
+	entry, err := s.api.StartTimer(ctx, api.StartTimerRequest{
+		Description: req.GetDescription(),
+		Project:     req.GetProject(),
+		Task:        req.GetTask(),
+		Tags:        req.GetTags(),
+	})
+	if err != nil {
+		return nil, toStatus("StartTimer", err)
+	}
+	return timeEntryToProto(entry), nil
+}
+
+func (s *Service) StopTimer(ctx context.Context, req *ccwsv1.StopTimerRequest) (*ccwsv1.StopTimerResponse, error) {
+	var request api.StopTimerRequest
+	if req.GetIdle() != nil {
+		request.Idle = req.GetIdle().AsDuration()
+	}
+	if req.GetSplitAt() != nil {
+		request.SplitAt = req.GetSplitAt().AsTime()
+	}
+
+	stopped, split, err := s.api.StopTimer(ctx, request)
+	if err != nil {
+		return nil, toStatus("StopTimer", err)
+	}
+	return &ccwsv1.StopTimerResponse{Stopped: timeEntryToProto(stopped), Split: timeEntryToProto(split)}, nil
+}
+
+func (s *Service) ListEntries(ctx context.Context, req *ccwsv1.ListEntriesRequest) (*ccwsv1.ListEntriesResponse, error) {
+	period, err := periodFromProto(req.GetPeriod())
+	if err != nil {
+		return nil, toStatus("ListEntries", err)
+	}
+
+	entries, err := s.api.Entries(ctx, period)
+	if err != nil {
+		return nil, toStatus("ListEntries", err)
+	}
+
+	resp := &ccwsv1.ListEntriesResponse{Entries: make([]*ccwsv1.TimeEntry, len(entries))}
+	for i := range entries {
+		resp.Entries[i] = timeEntryToProto(&entries[i])
+	}
+	return resp, nil
+}
+
+func (s *Service) GetSummary(ctx context.Context, req *ccwsv1.GetSummaryRequest) (*ccwsv1.Summary, error) {
+	period, err := periodFromProto(req.GetPeriod())
+	if err != nil {
+		return nil, toStatus("GetSummary", err)
+	}
+
+	summary, err := s.api.Summary(ctx, period)
+	if err != nil {
+		return nil, toStatus("GetSummary", err)
+	}
+	return summaryToProto(summary), nil
+}
+
+func (s *Service) SubscribeEvents(req *ccwsv1.SubscribeEventsRequest, stream grpc.ServerStreamingServer[ccwsv1.Event]) error {
+	types := make([]clockify.WebhookEvent, len(req.GetTypes()))
+	for i, t := range req.GetTypes() {
+		types[i] = clockify.WebhookEvent(t)
+	}
+
+	envelopes, cancel := s.api.Subscribe(types...)
+	defer cancel()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case envelope, ok := <-envelopes:
+			if !ok {
+				// The API is shutting down
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+
+			event, err := EventToProto(envelope)
+			if err != nil {
+				slog.Error("rpc_event_encode_failed", "event_id", envelope.ID, "error", err)
+				continue
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// EventToProto converts the envelope to the Event message, also the protobuf form events are
+// published to message brokers in
+func EventToProto(envelope events.Envelope) (*ccwsv1.Event, error) {
+	data, err := payloadToStruct(envelope.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &ccwsv1.Event{
+		Id:          envelope.ID,
+		Type:        string(envelope.Type),
+		WorkspaceId: envelope.WorkspaceID,
+		OccurredAt:  timestampOf(envelope.OccurredAt),
+		Data:        data,
+	}, nil
+}
+
+// payloadToStruct converts an event payload through its JSON form, nil payloads stay unset
+func payloadToStruct(payload any) (*structpb.Struct, error) {
+	if payload == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(fields)
+}
@@ -0,0 +1,74 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Hukyl/CCWS/internal/api"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/clockify/clockifytest"
+	"github.com/Hukyl/CCWS/internal/rpc"
+	"github.com/Hukyl/CCWS/internal/rpc/ccwsv1"
+)
+
+// TestStopTimerSplit stops a timer at a boundary, the response carrying both entries
+func TestStopTimerSplit(t *testing.T) {
+	srv := clockifytest.NewServer()
+	t.Cleanup(srv.Close)
+	workspace := srv.AddWorkspace("RPC")
+	user := srv.CurrentUser()
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	srv.AddTimeEntry(clockify.TimeEntry{
+		WorkspaceID:  workspace.ID,
+		UserID:       user.ID,
+		Description:  "Deep work",
+		TimeInterval: &clockify.TimeInterval{Start: start},
+	})
+
+	service := rpc.NewService(api.New(srv.Client(), &workspace, &user))
+	splitAt := start.Add(time.Hour)
+	resp, err := service.StopTimer(context.Background(), &ccwsv1.StopTimerRequest{SplitAt: timestamppb.New(splitAt)})
+	if err != nil {
+		t.Fatalf("StopTimer: %v", err)
+	}
+
+	stopped, split := resp.GetStopped(), resp.GetSplit()
+	if stopped == nil || split == nil {
+		t.Fatalf("StopTimer returned stopped %v and split %v, want both", stopped, split)
+	}
+	if got := stopped.GetEnd().AsTime(); !got.Equal(splitAt) {
+		t.Errorf("stopped entry ends at %s, want %s", got, splitAt)
+	}
+	if got := split.GetStart().AsTime(); !got.Equal(splitAt) {
+		t.Errorf("split entry starts at %s, want %s", got, splitAt)
+	}
+	if split.GetId() == stopped.GetId() || split.GetDescription() != "Deep work" {
+		t.Errorf("split entry %q %q, want a new entry continuing %q", split.GetId(), split.GetDescription(), stopped.GetId())
+	}
+}
+
+// TestStopTimerWithoutSplit leaves the split entry unset
+func TestStopTimerWithoutSplit(t *testing.T) {
+	srv := clockifytest.NewServer()
+	t.Cleanup(srv.Close)
+	workspace := srv.AddWorkspace("RPC")
+	user := srv.CurrentUser()
+	srv.AddTimeEntry(clockify.TimeEntry{
+		WorkspaceID:  workspace.ID,
+		UserID:       user.ID,
+		TimeInterval: &clockify.TimeInterval{Start: time.Now().Add(-time.Hour)},
+	})
+
+	service := rpc.NewService(api.New(srv.Client(), &workspace, &user))
+	resp, err := service.StopTimer(context.Background(), &ccwsv1.StopTimerRequest{})
+	if err != nil {
+		t.Fatalf("StopTimer: %v", err)
+	}
+	if resp.GetStopped() == nil || resp.GetSplit() != nil {
+		t.Errorf("StopTimer returned stopped %v and split %v, want only the stopped entry", resp.GetStopped(), resp.GetSplit())
+	}
+}
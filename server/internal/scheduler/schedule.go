@@ -0,0 +1,46 @@
+package scheduler
+
+import "time"
+
+// Schedule decides when a job runs next
+type Schedule interface {
+	// Next returns the first run time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// ScheduleFunc adapts a function to the Schedule interface
+type ScheduleFunc func(t time.Time) time.Time
+
+func (f ScheduleFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+// Every runs a job at a fixed interval
+func Every(interval time.Duration) Schedule {
+	return ScheduleFunc(func(t time.Time) time.Time {
+		return t.Add(interval)
+	})
+}
+
+// Daily runs a job every day at the given local time
+func Daily(hour, minute int) Schedule {
+	return ScheduleFunc(func(t time.Time) time.Time {
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	})
+}
+
+// Weekly runs a job every week on the given day at the given local time
+func Weekly(weekday time.Weekday, hour, minute int) Schedule {
+	daily := Daily(hour, minute)
+	return ScheduleFunc(func(t time.Time) time.Time {
+		next := daily.Next(t)
+		for next.Weekday() != weekday {
+			next = daily.Next(next)
+		}
+		return next
+	})
+}
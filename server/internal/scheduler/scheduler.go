@@ -0,0 +1,245 @@
+// Package scheduler runs recurring background jobs such as digests and watchdogs.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/audit"
+)
+
+// JobFunc is the work done on every run of a job
+type JobFunc func(ctx context.Context) error
+
+// job is a registered JobFunc with its schedule
+type job struct {
+	name     string
+	schedule Schedule
+	run      JobFunc
+
+	jitter       time.Duration
+	timeout      time.Duration
+	allowOverlap bool
+
+	running atomic.Int32
+}
+
+// JobOption configures a job registered with Scheduler.Add
+type JobOption func(*job)
+
+// WithJitter delays every run by a random duration up to max, spreading out jobs that share a schedule
+func WithJitter(max time.Duration) JobOption {
+	return func(j *job) {
+		j.jitter = max
+	}
+}
+
+// WithTimeout cancels the context of a run after d
+func WithTimeout(d time.Duration) JobOption {
+	return func(j *job) {
+		j.timeout = d
+	}
+}
+
+// AllowOverlap lets a run start while the previous one is still going.
+// By default such runs are skipped.
+func AllowOverlap() JobOption {
+	return func(j *job) {
+		j.allowOverlap = true
+	}
+}
+
+// Lease is a lock held by one replica at a time that expires unless renewed, see
+// WithLeaderElection
+type Lease interface {
+	// Acquire takes the lease when it is free, or renews it when held already, reporting
+	// whether it is held now
+	Acquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+	// TTL is how long the lease is held after Acquire
+	TTL() time.Duration
+}
+
+// Option configures a Scheduler
+type Option func(*Scheduler)
+
+// WithLeaderElection runs the jobs only while the scheduler holds the lease, which the replicas
+// of a deployment contend for, so that one of them runs the jobs and the others stand by to
+// take over. The lease is renewed every third of its TTL, given up as soon as renewing fails,
+// and released on shutdown.
+func WithLeaderElection(lease Lease) Option {
+	return func(s *Scheduler) {
+		s.lease = lease
+	}
+}
+
+// Scheduler runs jobs on their schedules until its context is done
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*job
+
+	lease  Lease // Nil runs the jobs unconditionally
+	leader atomic.Bool
+}
+
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add registers a job. Jobs added after Run has started are not picked up.
+func (s *Scheduler) Add(name string, schedule Schedule, run JobFunc, opts ...JobOption) {
+	j := &job{name: name, schedule: schedule, run: run}
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, j)
+}
+
+// Run starts every job and blocks until ctx is done and the running jobs have returned
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	if s.lease != nil {
+		// Contended for before the first run is due
+		s.renew(ctx)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.campaign(ctx)
+		}()
+	}
+	for _, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, j, &wg)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job, wg *sync.WaitGroup) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if j.jitter > 0 {
+			next = next.Add(rand.N(j.jitter))
+		}
+		slog.Debug("job_scheduled", "job", j.name, "next_run", next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !s.leading() {
+			slog.Debug("job_skipped", "job", j.name, "reason", "another replica is the leader")
+			continue
+		}
+		if !j.allowOverlap && j.running.Load() > 0 {
+			slog.Warn("job_skipped", "job", j.name, "reason", "previous run still in progress")
+			continue
+		}
+
+		wg.Add(1)
+		j.running.Add(1)
+		go func() {
+			defer wg.Done()
+			defer j.running.Add(-1)
+			j.execute(ctx)
+		}()
+	}
+}
+
+// leading reports whether the scheduler runs its jobs, always without leader election
+func (s *Scheduler) leading() bool {
+	return s.lease == nil || s.leader.Load()
+}
+
+// campaign renews the lease until ctx is done, then releases it
+func (s *Scheduler) campaign(ctx context.Context) {
+	ticker := time.NewTicker(s.lease.TTL() / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.leader.Store(false)
+			release, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.lease.TTL()/3)
+			defer cancel()
+			if err := s.lease.Release(release); err != nil {
+				slog.Warn("leadership_release_failed", "error", err)
+			}
+			return
+		case <-ticker.C:
+			s.renew(ctx)
+		}
+	}
+}
+
+// renew acquires or renews the lease. Failing to reach the lease counts as losing it, another
+// replica may take over once it expires.
+func (s *Scheduler) renew(ctx context.Context) {
+	// Bounded so that a hung call cannot outlast the lease
+	ctx, cancel := context.WithTimeout(ctx, s.lease.TTL()/3)
+	defer cancel()
+
+	held, err := s.lease.Acquire(ctx)
+	if err != nil {
+		slog.Warn("leader_election_failed", "error", err)
+		held = false
+	}
+	switch was := s.leader.Swap(held); {
+	case held && !was:
+		slog.Info("leadership_acquired")
+	case !held && was:
+		slog.Warn("leadership_lost")
+	}
+}
+
+// execute performs a single run, logging its outcome and recovering from panics
+func (j *job) execute(ctx context.Context) {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	// Clockify writes of the job are audited as made by it
+	ctx = audit.WithActor(ctx, "job:"+j.name)
+
+	started := time.Now()
+	slog.Debug("job_started", "job", j.name)
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return j.run(ctx)
+	}()
+
+	if err != nil {
+		slog.Error("job_failed", "job", j.name, "error", err, "duration", time.Since(started))
+		return
+	}
+	slog.Info("job_finished", "job", j.name, "duration", time.Since(started))
+}
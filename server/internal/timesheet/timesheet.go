@@ -0,0 +1,133 @@
+// Package timesheet checks a user's week of time entries for gaps and overlaps and, once it
+// passes, submits it for approval.
+package timesheet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/notify"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// SubmitDay is the day weeks are submitted on, the last workday of the week
+const SubmitDay = time.Friday
+
+// Kinds of issues
+const (
+	IssueRunning = "running_timer"
+	IssueOverlap = "overlap"
+	IssueGap     = "gap"
+)
+
+// Issue is a problem keeping a week from being submitted
+type Issue struct {
+	Kind       string
+	Start, End time.Time
+	Problem    string // e.g. "2:00 gap"
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s %s-%s: %s", i.Start.Format("Mon 2006-01-02"), i.Start.Format("15:04"), i.End.Format("15:04"), i.Problem)
+}
+
+// Validate returns the running timers, the overlapping entries and, unless maxGap is 0, the
+// gaps longer than maxGap between the entries of a day in loc
+func Validate(entries []clockify.TimeEntry, maxGap time.Duration, loc *time.Location) []Issue {
+	entries = slices.DeleteFunc(slices.Clone(entries), func(e clockify.TimeEntry) bool { return e.TimeInterval == nil })
+	slices.SortFunc(entries, func(a, b clockify.TimeEntry) int {
+		return a.TimeInterval.Start.Compare(b.TimeInterval.Start)
+	})
+
+	var (
+		issues []Issue
+		// End of the latest entry so far, which overlapping entries start before
+		lastEnd time.Time
+	)
+	for _, entry := range entries {
+		start := entry.TimeInterval.Start.In(loc)
+		if entry.TimeInterval.End == nil {
+			issues = append(issues, Issue{Kind: IssueRunning, Start: start, End: start, Problem: fmt.Sprintf("timer '%s' is still running", entry)})
+			continue
+		}
+		end := entry.TimeInterval.End.In(loc)
+
+		switch {
+		case lastEnd.IsZero():
+		case start.Before(lastEnd):
+			issues = append(issues, Issue{Kind: IssueOverlap, Start: start, End: lastEnd, Problem: fmt.Sprintf("'%s' overlaps another entry", entry)})
+		case maxGap > 0 && sameDay(lastEnd, start) && start.Sub(lastEnd) > maxGap:
+			issues = append(issues, Issue{Kind: IssueGap, Start: lastEnd, End: start, Problem: report.FormatDuration(start.Sub(lastEnd)) + " gap"})
+		}
+		if end.After(lastEnd) {
+			lastEnd = end
+		}
+	}
+	return issues
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// Submitter validates the current week of a user and submits it for approval, notifying the
+// user of the outcome
+type Submitter struct {
+	client      *clockify.APIClient
+	workspaceID string
+	user        clockify.User
+	maxGap      time.Duration
+	notifier    notify.Notifier
+}
+
+// New creates a submitter for the weeks of the user, the owner of the client's credentials
+func New(client *clockify.APIClient, workspaceID string, user clockify.User, maxGap time.Duration, notifier notify.Notifier) *Submitter {
+	return &Submitter{client: client, workspaceID: workspaceID, user: user, maxGap: maxGap, notifier: notifier}
+}
+
+// Submit validates the user's current week and submits it when it has no issue. It is a
+// scheduler.JobFunc.
+func (s *Submitter) Submit(ctx context.Context) error {
+	client := s.client.WithContext(ctx)
+	now := time.Now().In(s.user.Location(time.Local))
+	week := report.Week(now, 0)
+
+	entries, err := report.FetchEntries(client, s.workspaceID, s.user.ID, week)
+	if err != nil {
+		return fmt.Errorf("failed to list time entries: %w", err)
+	}
+
+	if issues := Validate(entries, s.maxGap, now.Location()); len(issues) > 0 {
+		slog.Info("timesheet_not_submitted", "user_id", s.user.ID, "week", week.String(), "issues", len(issues))
+		lines := make([]string, len(issues))
+		for i, issue := range issues {
+			lines[i] = issue.String()
+		}
+		return s.notifier.Notify(ctx, notify.Message{
+			Title: fmt.Sprintf("Timesheet of %s not submitted", week),
+			Text:  "Fix these and submit it for approval:\n" + strings.Join(lines, "\n"),
+		})
+	}
+
+	logged := report.Summarize(week, entries, nil, now).Total
+	if _, err := client.SubmitWeekForApproval(s.workspaceID, week.Start); err != nil {
+		slog.Warn("timesheet_submit_failed", "user_id", s.user.ID, "week", week.String(), "error", err)
+		notifyErr := s.notifier.Notify(ctx, notify.Message{
+			Title: fmt.Sprintf("Timesheet of %s not submitted", week),
+			Text:  fmt.Sprintf("Clockify refused the submission: %v", err),
+		})
+		return errors.Join(fmt.Errorf("failed to submit the week: %w", err), notifyErr)
+	}
+
+	slog.Info("timesheet_submitted", "user_id", s.user.ID, "week", week.String(), "logged", logged)
+	return s.notifier.Notify(ctx, notify.Message{
+		Title:  fmt.Sprintf("Timesheet of %s submitted for approval", week),
+		Fields: []notify.Field{{Name: "Logged", Value: report.FormatDuration(logged)}},
+	})
+}
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/cache"
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/outbox"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// cacheTTL bounds how stale browsed data can get without an explicit refresh
+const cacheTTL = 5 * time.Minute
+
+// store is the TUI's view of Clockify, caching entries per period and the project list
+type store struct {
+	client      *clockify.APIClient
+	workspaceID string
+	userID      string
+
+	entries  *cache.Cache[time.Time, []clockify.TimeEntry] // Keyed by period start
+	projects *cache.Cache[string, []clockify.Project]      // Keyed by workspace ID
+
+	queue *outbox.Queue // Writes failing while Clockify is unreachable, nil to fail them
+}
+
+// errQueued is returned by writes queued while Clockify is unreachable
+var errQueued = errors.New("queued until Clockify is reachable")
+
+// queued queues a write that failed with err if Clockify is unreachable, returning errQueued
+func (s *store) queued(err error, enqueue func(*outbox.Queue) (outbox.Operation, error)) error {
+	if s.queue == nil || !clockify.IsUnreachable(err) {
+		return err
+	}
+	if _, qErr := enqueue(s.queue); qErr != nil {
+		return fmt.Errorf("%w, and queueing failed: %w", err, qErr)
+	}
+	return errQueued
+}
+
+func newStore(client *clockify.APIClient, workspaceID, userID string) *store {
+	return &store{
+		client:      client,
+		workspaceID: workspaceID,
+		userID:      userID,
+		entries:     cache.New[time.Time, []clockify.TimeEntry](cacheTTL),
+		projects:    cache.New[string, []clockify.Project](cacheTTL),
+	}
+}
+
+// Entries returns the entries of the period, oldest first
+func (s *store) Entries(period report.Period) ([]clockify.TimeEntry, error) {
+	return s.entries.GetOrLoad(period.Start, func() ([]clockify.TimeEntry, error) {
+		entries, err := report.FetchEntries(s.client, s.workspaceID, s.userID, period)
+		if err != nil {
+			return nil, err
+		}
+		slices.SortFunc(entries, func(a, b clockify.TimeEntry) int {
+			return a.TimeInterval.Start.Compare(b.TimeInterval.Start)
+		})
+		return entries, nil
+	})
+}
+
+func (s *store) Projects() ([]clockify.Project, error) {
+	return s.projects.GetOrLoad(s.workspaceID, func() ([]clockify.Project, error) {
+		var all []clockify.Project
+		for projects, err := range s.client.IterProjects(s.workspaceID) {
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, projects...)
+		}
+		return all, nil
+	})
+}
+
+// ProjectName returns the name of the project, or "" if it is unknown
+func (s *store) ProjectName(projectID string) string {
+	projects, err := s.Projects()
+	if err != nil {
+		return ""
+	}
+	for _, project := range projects {
+		if project.ID == projectID {
+			return project.Name
+		}
+	}
+	return ""
+}
+
+// FindProject looks a project up by name, ignoring case
+func (s *store) FindProject(name string) (*clockify.Project, error) {
+	projects, err := s.Projects()
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		if strings.EqualFold(project.Name, name) {
+			return &project, nil
+		}
+	}
+	return nil, fmt.Errorf("project '%s' not found", name)
+}
+
+// Running returns the running entry, never cached since it changes all the time
+func (s *store) Running() (*clockify.TimeEntry, error) {
+	return s.client.GetRunningTimeEntry(s.workspaceID, s.userID)
+}
+
+// Changed drops cached entries after a mutation
+func (s *store) Changed() {
+	s.entries.Clear()
+}
+
+func (s *store) StartTimer(description string) error {
+	start := time.Now()
+	_, err := s.client.StartTimer(s.workspaceID, s.userID, description, nil, nil, nil)
+	s.Changed()
+	return s.queued(err, func(q *outbox.Queue) (outbox.Operation, error) {
+		return q.Create(s.workspaceID, s.userID, clockify.NewTimeEntryRequest{Start: start, Description: description}, nil)
+	})
+}
+
+func (s *store) StopTimer(running *clockify.TimeEntry) error {
+	end := time.Now()
+	_, err := s.client.StopTimeEntry(s.workspaceID, s.userID, end)
+	s.Changed()
+	return s.queued(err, func(q *outbox.Queue) (outbox.Operation, error) {
+		var entryID string
+		if running != nil {
+			entryID = running.ID
+		}
+		return q.Stop(s.workspaceID, s.userID, entryID, end)
+	})
+}
+
+// Update saves the entry after modify has changed it
+func (s *store) Update(entry clockify.TimeEntry, modify func(*clockify.UpdateTimeEntryRequest)) error {
+	if entry.IsLocked {
+		return clockify.ErrLocked
+	}
+
+	request := clockify.UpdateTimeEntryRequest{
+		Start:       entry.TimeInterval.Start,
+		End:         entry.TimeInterval.End,
+		Billable:    entry.Billable,
+		Description: entry.Description,
+		ProjectID:   entry.ProjectID,
+		TaskID:      entry.TaskID,
+		TagIDs:      entry.TagIDs,
+		Type:        entry.Type,
+	}
+	modify(&request)
+
+	_, err := s.client.UpdateTimeEntry(s.workspaceID, entry.ID, request)
+	s.Changed()
+	return s.queued(err, func(q *outbox.Queue) (outbox.Operation, error) {
+		return q.Update(s.workspaceID, entry, request)
+	})
+}
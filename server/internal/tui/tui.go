@@ -0,0 +1,397 @@
+// Package tui implements an interactive terminal timesheet browser.
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/outbox"
+	"github.com/Hukyl/CCWS/internal/report"
+)
+
+// Option configures the timesheet browser
+type Option func(*store)
+
+// WithQueue queues the edits made while Clockify is unreachable
+func WithQueue(queue *outbox.Queue) Option {
+	return func(s *store) {
+		s.queue = queue
+	}
+}
+
+// Run starts the timesheet browser for the user's entries in the workspace and blocks until it exits
+func Run(client *clockify.APIClient, workspace clockify.Workspace, user clockify.User, opts ...Option) error {
+	s := newStore(client, workspace.ID, user.ID)
+	for _, opt := range opts {
+		opt(s)
+	}
+	m := newModel(s, workspace)
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+type viewKind int
+
+const (
+	dayView viewKind = iota
+	weekView
+)
+
+type inputMode int
+
+const (
+	browsing inputMode = iota
+	startingTimer
+	editingDescription
+	editingProject
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	runningStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+// * Messages
+
+type loadedMsg struct {
+	period  report.Period
+	entries []clockify.TimeEntry
+	running *clockify.TimeEntry
+	err     error
+}
+
+type actionDoneMsg struct {
+	status string
+	err    error
+}
+
+type tickMsg time.Time
+
+// * Model
+
+type model struct {
+	store     *store
+	workspace clockify.Workspace
+
+	view    viewKind
+	anchor  time.Time
+	entries []clockify.TimeEntry
+	running *clockify.TimeEntry
+	cursor  int
+	loading bool
+
+	mode   inputMode
+	input  textinput.Model
+	status string
+	err    error
+}
+
+func newModel(s *store, workspace clockify.Workspace) model {
+	input := textinput.New()
+	input.CharLimit = 200
+
+	return model{store: s, workspace: workspace, view: weekView, anchor: time.Now(), input: input, loading: true}
+}
+
+func (m model) period() report.Period {
+	if m.view == dayView {
+		return report.Day(m.anchor)
+	}
+	return report.Week(m.anchor, 0)
+}
+
+func (m model) load() tea.Cmd {
+	period := m.period()
+	return func() tea.Msg {
+		entries, err := m.store.Entries(period)
+		if err != nil {
+			return loadedMsg{period: period, err: err}
+		}
+		running, err := m.store.Running()
+		return loadedMsg{period: period, entries: entries, running: running, err: err}
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.load(), tick())
+}
+
+func (m model) selected() *clockify.TimeEntry {
+	if m.cursor < 0 || m.cursor >= len(m.entries) {
+		return nil
+	}
+	return &m.entries[m.cursor]
+}
+
+// * Update
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		// Only re-renders, so that the running timer's elapsed time stays current
+		return m, tick()
+
+	case loadedMsg:
+		if msg.period != m.period() {
+			return m, nil // Stale response for a period the user has already left
+		}
+		m.loading = false
+		m.err = msg.err
+		m.entries = msg.entries
+		m.running = msg.running
+		m.cursor = min(m.cursor, max(len(m.entries)-1, 0))
+		return m, nil
+
+	case actionDoneMsg:
+		m.err = msg.err
+		if errors.Is(msg.err, errQueued) {
+			m.err = nil
+			msg.status += ", " + errQueued.Error()
+		}
+		if m.err == nil {
+			m.status = msg.status
+		}
+		m.loading = true
+		return m, m.load()
+
+	case tea.KeyMsg:
+		if m.mode != browsing {
+			return m.updateInput(msg)
+		}
+		return m.updateBrowsing(msg)
+	}
+
+	return m, nil
+}
+
+func (m model) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(m.entries)-1, 0))
+	case "left", "h":
+		return m.move(-1)
+	case "right", "l":
+		return m.move(1)
+	case "t":
+		m.anchor = time.Now()
+		return m.reload()
+	case "d":
+		m.view = dayView
+		return m.reload()
+	case "w":
+		m.view = weekView
+		return m.reload()
+	case "r":
+		m.store.Changed()
+		return m.reload()
+	case "s":
+		return m.prompt(startingTimer, "", "Description: ")
+	case "x":
+		if m.running == nil {
+			m.status = "No timer is running"
+			return m, nil
+		}
+		return m, func() tea.Msg {
+			return actionDoneMsg{status: "Timer stopped", err: m.store.StopTimer(m.running)}
+		}
+	case "e":
+		if entry := m.selected(); entry != nil {
+			return m.prompt(editingDescription, entry.Description, "Description: ")
+		}
+	case "p":
+		if entry := m.selected(); entry != nil {
+			return m.prompt(editingProject, m.store.ProjectName(entry.ProjectID), "Project: ")
+		}
+	}
+
+	return m, nil
+}
+
+// move shifts the browsed period by n days or weeks
+func (m model) move(n int) (tea.Model, tea.Cmd) {
+	if m.view == dayView {
+		m.anchor = m.anchor.AddDate(0, 0, n)
+	} else {
+		m.anchor = m.anchor.AddDate(0, 0, 7*n)
+	}
+	m.cursor = 0
+	return m.reload()
+}
+
+func (m model) reload() (tea.Model, tea.Cmd) {
+	m.loading = true
+	return m, m.load()
+}
+
+func (m model) prompt(mode inputMode, value, label string) (tea.Model, tea.Cmd) {
+	m.mode = mode
+	m.input.Prompt = label
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+func (m model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = browsing
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		mode, value := m.mode, strings.TrimSpace(m.input.Value())
+		m.mode = browsing
+		m.input.Blur()
+		return m, m.submit(mode, value)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// submit performs the action of the finished prompt
+func (m model) submit(mode inputMode, value string) tea.Cmd {
+	entry := m.selected()
+	s := m.store
+
+	switch mode {
+	case startingTimer:
+		return func() tea.Msg {
+			return actionDoneMsg{status: "Timer started", err: s.StartTimer(value)}
+		}
+	case editingDescription:
+		if entry == nil {
+			return nil
+		}
+		e := *entry
+		return func() tea.Msg {
+			err := s.Update(e, func(r *clockify.UpdateTimeEntryRequest) { r.Description = value })
+			return actionDoneMsg{status: "Description updated", err: err}
+		}
+	case editingProject:
+		if entry == nil {
+			return nil
+		}
+		e := *entry
+		return func() tea.Msg {
+			projectID := ""
+			if value != "" {
+				project, err := s.FindProject(value)
+				if err != nil {
+					return actionDoneMsg{err: err}
+				}
+				projectID = project.ID
+			}
+			err := s.Update(e, func(r *clockify.UpdateTimeEntryRequest) {
+				r.ProjectID = projectID
+				r.TaskID = "" // Tasks belong to the old project
+			})
+			return actionDoneMsg{status: "Project updated", err: err}
+		}
+	}
+
+	return nil
+}
+
+// * View
+
+func (m model) View() string {
+	var b strings.Builder
+	now := time.Now()
+	period := m.period()
+
+	var total time.Duration
+	for _, entry := range m.entries {
+		total += report.EntryDuration(entry, now)
+	}
+
+	kind := "Week"
+	if m.view == dayView {
+		kind = "Day"
+	}
+	fmt.Fprintf(&b, "%s\n", titleStyle.Render(fmt.Sprintf("%s · %s %s · total %s", m.workspace.Name, kind, period, report.FormatDuration(total))))
+
+	if m.running != nil {
+		elapsed := report.EntryDuration(*m.running, now).Round(time.Second)
+		fmt.Fprintf(&b, "%s\n", runningStyle.Render(fmt.Sprintf("▶ %s — %s", describe(m.running.Description), elapsed)))
+	}
+	b.WriteString("\n")
+
+	switch {
+	case m.loading && len(m.entries) == 0:
+		b.WriteString("Loading…\n")
+	case len(m.entries) == 0:
+		b.WriteString("No time entries\n")
+	}
+
+	for i, entry := range m.entries {
+		line := m.formatEntry(entry, now)
+		if i == m.cursor {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.mode != browsing {
+		b.WriteString(m.input.View() + "\n")
+	}
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	} else if m.status != "" {
+		b.WriteString(m.status + "\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓ select · ←/→ previous/next · d/w day/week · t today · s start · x stop · e edit description · p edit project · r refresh · q quit"))
+
+	return b.String()
+}
+
+func (m model) formatEntry(entry clockify.TimeEntry, now time.Time) string {
+	start := entry.TimeInterval.Start.Local()
+	end := "now  "
+	if entry.TimeInterval.End != nil {
+		end = entry.TimeInterval.End.Local().Format("15:04")
+	}
+
+	project := m.store.ProjectName(entry.ProjectID)
+	if project == "" {
+		project = report.NoProject
+	}
+
+	return fmt.Sprintf("%s  %s–%s  %6s  %-20.20s  %s",
+		start.Format("Mon 01-02"),
+		start.Format("15:04"),
+		end,
+		report.FormatDuration(report.EntryDuration(entry, now)),
+		project,
+		describe(entry.Description),
+	)
+}
+
+func describe(description string) string {
+	if description == "" {
+		return "(no description)"
+	}
+	return description
+}
@@ -0,0 +1,178 @@
+// Package tunnel exposes the local webhook server through an ngrok or cloudflared tunnel, so
+// Clockify can reach it during local development without port forwarding.
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider is the program opening the tunnel
+type Provider string
+
+// Provider values
+const (
+	Ngrok       Provider = "ngrok"
+	Cloudflared Provider = "cloudflared"
+)
+
+// StartTimeout is how long Start waits for the tunnel to report its public URL
+const StartTimeout = 30 * time.Second
+
+var ErrUnknownProvider = errors.New("unknown tunnel provider")
+
+// Tunnel is a running tunnel process
+type Tunnel struct {
+	provider Provider
+	url      string
+	cmd      *exec.Cmd
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Start opens a tunnel to the local address, e.g. ":8080" or "localhost:8080", and waits
+// until the provider has printed the public URL
+func Start(ctx context.Context, provider Provider, localAddr string) (*Tunnel, error) {
+	target, err := localURL(localAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	var findURL func(line string) string
+	switch provider {
+	case Ngrok:
+		args = []string{"http", target, "--log", "stdout", "--log-format", "json"}
+		findURL = ngrokURL
+	case Cloudflared:
+		args = []string{"tunnel", "--no-autoupdate", "--url", target}
+		findURL = cloudflaredURL
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+
+	// cloudflared logs to stderr, ngrok to stdout, both are scanned
+	output, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(string(provider), args...)
+	cmd.Stdout, cmd.Stderr = writer, writer
+	err = cmd.Start()
+	writer.Close()
+	if err != nil {
+		output.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", provider, err)
+	}
+
+	t := &Tunnel{provider: provider, cmd: cmd, done: make(chan struct{})}
+	urls := make(chan string, 1)
+	go t.scan(output, findURL, urls)
+	go func() {
+		cmd.Wait()
+		close(t.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, StartTimeout)
+	defer cancel()
+
+	select {
+	case t.url = <-urls:
+		slog.Info("tunnel_started", "provider", provider, "url", t.url, "target", target)
+		return t, nil
+	case <-t.done:
+		return nil, fmt.Errorf("%s exited before opening the tunnel", provider)
+	case <-ctx.Done():
+		t.Close()
+		return nil, fmt.Errorf("%s did not report a public URL: %w", provider, ctx.Err())
+	}
+}
+
+// URL returns the public base URL of the tunnel, e.g. https://abc.ngrok-free.app
+func (t *Tunnel) URL() string {
+	return t.url
+}
+
+// Close stops the tunnel process and waits for it to exit
+func (t *Tunnel) Close() error {
+	t.closeOnce.Do(func() {
+		if err := t.cmd.Process.Signal(os.Interrupt); err != nil {
+			t.cmd.Process.Kill()
+		}
+		select {
+		case <-t.done:
+		case <-time.After(5 * time.Second):
+			t.cmd.Process.Kill()
+			<-t.done
+		}
+		slog.Info("tunnel_stopped", "provider", t.provider)
+	})
+	return nil
+}
+
+// scan forwards the first public URL found in the output, then drains the rest so the
+// process never blocks on a full pipe
+func (t *Tunnel) scan(output io.ReadCloser, findURL func(string) string, urls chan<- string) {
+	defer output.Close()
+
+	found := false
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		line := scanner.Text()
+		slog.Debug("tunnel_output", "provider", t.provider, "line", line)
+		if found {
+			continue
+		}
+		if url := findURL(line); url != "" {
+			urls <- url
+			found = true
+		}
+	}
+}
+
+// ngrokURL extracts the URL from the "started tunnel" JSON log line
+func ngrokURL(line string) string {
+	var entry struct {
+		Msg string `json:"msg"`
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return ""
+	}
+	if entry.Msg != "started tunnel" || !strings.HasPrefix(entry.URL, "https://") {
+		return ""
+	}
+	return entry.URL
+}
+
+var cloudflaredURLPattern = regexp.MustCompile(`https://[a-z0-9-]+\.trycloudflare\.com`)
+
+// cloudflaredURL extracts the quick tunnel URL from the banner cloudflared prints
+func cloudflaredURL(line string) string {
+	return cloudflaredURLPattern.FindString(line)
+}
+
+// localURL turns a listen address into the URL the tunnel forwards to
+func localURL(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid local address %q: %w", addr, err)
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,180 @@
+package warehouse
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+)
+
+const (
+	bigQueryScope  = "https://www.googleapis.com/auth/bigquery"
+	metadataURL    = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	jwtGrantType   = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	assertionTTL   = time.Hour
+	tokenRefreshIn = time.Minute // Before the token expires
+)
+
+// tokenSource hands out OAuth access tokens, fetching a new one once the last is about to expire
+type tokenSource struct {
+	fetch  func(ctx context.Context, client *http.Client) (*accessToken, error)
+	client *http.Client
+
+	mu      sync.Mutex
+	current *accessToken
+}
+
+type accessToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"` // Seconds
+	expiry      time.Time
+}
+
+func (s *tokenSource) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && time.Until(s.current.expiry) > tokenRefreshIn {
+		return s.current.AccessToken, nil
+	}
+	token, err := s.fetch(ctx, s.client)
+	if err != nil {
+		return "", fmt.Errorf("failed to get a Google access token: %w", err)
+	}
+	token.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	s.current = token
+	return token.AccessToken, nil
+}
+
+// serviceAccount is the JSON key of a Google service account
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// newTokenSource authenticates as the service account of the key file, or as the one of the
+// instance, through the metadata server of Compute Engine, GKE or Cloud Run, without one
+func newTokenSource(credentialsFile string, client *http.Client) (*tokenSource, error) {
+	if credentialsFile == "" {
+		return &tokenSource{fetch: fetchMetadataToken, client: client}, nil
+	}
+
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", credentialsFile, err)
+	}
+	if account.Type != "service_account" {
+		return nil, fmt.Errorf("invalid credentials file %s: must be a service account key, got type %q", credentialsFile, account.Type)
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid credentials file %s: no PEM private key", credentialsFile)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", credentialsFile, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid credentials file %s: the private key is not an RSA key", credentialsFile)
+	}
+
+	return &tokenSource{
+		fetch: func(ctx context.Context, client *http.Client) (*accessToken, error) {
+			return account.fetchToken(ctx, client, key)
+		},
+		client: client,
+	}, nil
+}
+
+// fetchToken exchanges a self-signed JWT assertion for an access token
+func (a serviceAccount) fetchToken(ctx context.Context, client *http.Client, key *rsa.PrivateKey) (*accessToken, error) {
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": a.PrivateKeyID})
+	if err != nil {
+		return nil, err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   a.ClientEmail,
+		"scope": bigQueryScope,
+		"aud":   a.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(assertionTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type": {jwtGrantType},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	}
+	// Requesting a token twice only grants another one
+	req, err := http.NewRequestWithContext(clockify.Idempotent(ctx), http.MethodPost, a.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doToken(client, req)
+}
+
+func fetchMetadataToken(ctx context.Context, client *http.Client) (*accessToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return doToken(client, req)
+}
+
+func doToken(client *http.Client, req *http.Request) (*accessToken, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var token accessToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("no access token in the response")
+	}
+	return &token, nil
+}
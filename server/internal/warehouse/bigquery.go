@@ -0,0 +1,298 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/parquet"
+)
+
+const (
+	// DefaultEndpoint is the BigQuery REST API
+	DefaultEndpoint = "https://bigquery.googleapis.com/bigquery/v2"
+
+	bigQueryAttempts = 3
+	bigQueryTimeout  = 2 * time.Minute
+	// queryWait is how long a request waits for its query job before polling again
+	queryWait = 30 * time.Second
+	// timestampLayout formats TIMESTAMP parameters
+	timestampLayout = "2006-01-02 15:04:05.000000+00"
+)
+
+// BigQueryOption configures a BigQuery client
+type BigQueryOption func(*BigQuery)
+
+// WithEndpoint sets the URL of the REST API, DefaultEndpoint by default, e.g. for an emulator
+func WithEndpoint(endpoint string) BigQueryOption {
+	return func(b *BigQuery) {
+		b.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// BigQuery runs GoogleSQL queries in a dataset of a Google Cloud project through the REST API
+type BigQuery struct {
+	project, dataset string
+	endpoint         string
+	client           *http.Client
+	tokens           *tokenSource
+}
+
+// NewBigQuery creates a client of the dataset, authenticated by the service account key file
+// or, without one, by the metadata server of the instance it runs on
+func NewBigQuery(project, dataset, credentialsFile string, opts ...BigQueryOption) (*BigQuery, error) {
+	b := &BigQuery{
+		project:  project,
+		dataset:  dataset,
+		endpoint: DefaultEndpoint,
+		client:   clockify.RetryingClient(nil, bigQueryAttempts, bigQueryTimeout),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	tokens, err := newTokenSource(credentialsFile, b.client)
+	if err != nil {
+		return nil, err
+	}
+	b.tokens = tokens
+	return b, nil
+}
+
+// table returns the quoted name of a table of the dataset
+func (b *BigQuery) table(name string) string {
+	return fmt.Sprintf("`%s.%s.%s`", b.project, b.dataset, name)
+}
+
+// queryParameter is a named parameter of a query
+type queryParameter struct {
+	Name  string         `json:"name"`
+	Type  parameterType  `json:"parameterType"`
+	Value parameterValue `json:"parameterValue"`
+}
+
+type parameterType struct {
+	Type        string         `json:"type"`
+	ArrayType   *parameterType `json:"arrayType,omitempty"`
+	StructTypes []structType   `json:"structTypes,omitempty"`
+}
+
+type structType struct {
+	Name string        `json:"name"`
+	Type parameterType `json:"type"`
+}
+
+// parameterValue is NULL with neither a value nor array or struct values
+type parameterValue struct {
+	Value        *string                   `json:"value,omitempty"`
+	ArrayValues  []parameterValue          `json:"arrayValues,omitempty"`
+	StructValues map[string]parameterValue `json:"structValues,omitempty"`
+}
+
+type queryRequest struct {
+	Query           string           `json:"query"`
+	UseLegacySQL    bool             `json:"useLegacySql"`
+	ParameterMode   string           `json:"parameterMode,omitempty"`
+	QueryParameters []queryParameter `json:"queryParameters,omitempty"`
+	TimeoutMs       int64            `json:"timeoutMs"`
+	// BigQuery runs a query once per request ID, repeated requests wait for the same job
+	RequestID string `json:"requestId"`
+}
+
+type queryResponse struct {
+	JobComplete  bool `json:"jobComplete"`
+	JobReference struct {
+		JobID    string `json:"jobId"`
+		Location string `json:"location"`
+	} `json:"jobReference"`
+	Rows []struct {
+		F []struct {
+			V any `json:"v"`
+		} `json:"f"`
+	} `json:"rows"`
+	NumDMLAffectedRows string `json:"numDmlAffectedRows"`
+}
+
+// affected returns the number of rows a DML statement changed
+func (r *queryResponse) affected() int64 {
+	n, _ := strconv.ParseInt(r.NumDMLAffectedRows, 10, 64)
+	return n
+}
+
+// query runs the statement and waits for its job to complete
+func (b *BigQuery) query(ctx context.Context, statement string, params ...queryParameter) (*queryResponse, error) {
+	request := queryRequest{
+		Query:           statement,
+		ParameterMode:   "NAMED",
+		QueryParameters: params,
+		TimeoutMs:       queryWait.Milliseconds(),
+		RequestID:       newRequestID(),
+	}
+	var resp queryResponse
+	if err := b.do(clockify.Idempotent(ctx), http.MethodPost, fmt.Sprintf("%s/projects/%s/queries", b.endpoint, url.PathEscape(b.project)), request, &resp); err != nil {
+		return nil, err
+	}
+
+	for !resp.JobComplete {
+		query := url.Values{
+			"location":   {resp.JobReference.Location},
+			"timeoutMs":  {strconv.FormatInt(queryWait.Milliseconds(), 10)},
+			"maxResults": {"1000"},
+		}
+		u := fmt.Sprintf("%s/projects/%s/queries/%s?%s", b.endpoint, url.PathEscape(b.project), url.PathEscape(resp.JobReference.JobID), query.Encode())
+		if err := b.do(ctx, http.MethodGet, u, nil, &resp); err != nil {
+			return nil, err
+		}
+	}
+	return &resp, nil
+}
+
+// newRequestID returns a random ID of a query request
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (b *BigQuery) do(ctx context.Context, method, u string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	token, err := b.tokens.token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query BigQuery: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("BigQuery: %s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return fmt.Errorf("BigQuery: %s", resp.Status)
+	}
+	return json.Unmarshal(data, result)
+}
+
+// sqlType returns the GoogleSQL type of a column kind
+func sqlType(kind parquet.Kind) parameterType {
+	switch kind {
+	case parquet.Int32, parquet.Int64:
+		return parameterType{Type: "INT64"}
+	case parquet.Float64:
+		return parameterType{Type: "FLOAT64"}
+	case parquet.Bool:
+		return parameterType{Type: "BOOL"}
+	case parquet.Timestamp:
+		return parameterType{Type: "TIMESTAMP"}
+	case parquet.StringList:
+		return parameterType{Type: "ARRAY", ArrayType: &parameterType{Type: "STRING"}}
+	default:
+		return parameterType{Type: "STRING"}
+	}
+}
+
+func (t parameterType) String() string {
+	if t.ArrayType != nil {
+		return "ARRAY<" + t.ArrayType.String() + ">"
+	}
+	return t.Type
+}
+
+// parameter returns the value of a column as a parameter, nil being NULL
+func parameter(value any) parameterValue {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return parameterValue{}
+	case string:
+		s = v
+	case int32:
+		s = strconv.FormatInt(int64(v), 10)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case int:
+		s = strconv.Itoa(v)
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	case time.Time:
+		s = v.UTC().Format(timestampLayout)
+	case []string:
+		// An empty array is NULL, which BigQuery stores as an empty array
+		values := make([]parameterValue, len(v))
+		for i, item := range v {
+			values[i] = parameter(item)
+		}
+		return parameterValue{ArrayValues: values}
+	default:
+		s = fmt.Sprint(v)
+	}
+	return parameterValue{Value: &s}
+}
+
+// rowsParameter passes the rows as an array of structs of the columns
+func rowsParameter(name string, columns []parquet.Column, rows [][]any) queryParameter {
+	fields := make([]structType, len(columns))
+	for i, col := range columns {
+		fields[i] = structType{Name: col.Name, Type: sqlType(col.Kind)}
+	}
+	values := make([]parameterValue, len(rows))
+	for i, row := range rows {
+		value := parameterValue{StructValues: make(map[string]parameterValue, len(columns))}
+		for j, col := range columns {
+			value.StructValues[col.Name] = parameter(row[j])
+		}
+		values[i] = value
+	}
+	return queryParameter{
+		Name:  name,
+		Type:  parameterType{Type: "ARRAY", ArrayType: &parameterType{Type: "STRUCT", StructTypes: fields}},
+		Value: parameterValue{ArrayValues: values},
+	}
+}
+
+func scalarParameter(name string, kind parquet.Kind, value any) queryParameter {
+	return queryParameter{Name: name, Type: sqlType(kind), Value: parameter(value)}
+}
+
+func stringsParameter(name string, values []string) queryParameter {
+	return queryParameter{Name: name, Type: sqlType(parquet.StringList), Value: parameter(values)}
+}
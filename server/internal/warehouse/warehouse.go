@@ -0,0 +1,291 @@
+// Package warehouse keeps tables of time entries and projects in a Google BigQuery dataset up
+// to date, for BI dashboards of the whole workspace.
+//
+// Each sync upserts the time entries started within a window, and every project, with MERGE
+// statements keyed by ID, then deletes the rows of that scope Clockify no longer has. Tables
+// are created on the first sync. The time entries table, partitioned by month of start, has
+// the columns of the Parquet export, and an empty one is filled with the whole history.
+package warehouse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/export"
+	"github.com/Hukyl/CCWS/internal/parquet"
+)
+
+// Tables of the dataset
+const (
+	TimeEntriesTable = "time_entries"
+	ProjectsTable    = "projects"
+)
+
+// DefaultWindow is how far back the time entries are synced unless WithWindow is given
+const DefaultWindow = 7 * 24 * time.Hour
+
+// batchSize bounds the rows of a MERGE, whose parameters count towards the request size limit
+const batchSize = 500
+
+// projectColumns is the schema of the projects table
+var projectColumns = []parquet.Column{
+	{Name: "id", Kind: parquet.String},
+	{Name: "workspace_id", Kind: parquet.String},
+	{Name: "name", Kind: parquet.String},
+	{Name: "client_id", Kind: parquet.String, Optional: true},
+	{Name: "client_name", Kind: parquet.String, Optional: true},
+	{Name: "billable", Kind: parquet.Bool},
+	{Name: "public", Kind: parquet.Bool},
+	{Name: "archived", Kind: parquet.Bool},
+	{Name: "color", Kind: parquet.String, Optional: true},
+	{Name: "hourly_rate", Kind: parquet.Int64, Optional: true},
+	{Name: "currency", Kind: parquet.String, Optional: true},
+}
+
+// table describes a table of the dataset, keyed by its first column
+type table struct {
+	name    string
+	columns []parquet.Column
+	// GoogleSQL of the PARTITION BY and CLUSTER BY clauses, if any
+	partitionBy, clusterBy string
+}
+
+var (
+	timeEntries = table{
+		name:        TimeEntriesTable,
+		columns:     export.TimeEntryColumns,
+		partitionBy: "TIMESTAMP_TRUNC(`start`, MONTH)",
+		clusterBy:   "`workspace_id`, `user_id`",
+	}
+	projects = table{name: ProjectsTable, columns: projectColumns, clusterBy: "`workspace_id`"}
+)
+
+// Option configures a Syncer
+type Option func(*Syncer)
+
+// WithWindow sets how long before now the time entries synced started, 0 for all of them.
+// Older entries changed in Clockify are only synced again once the table is emptied.
+func WithWindow(window time.Duration) Option {
+	return func(s *Syncer) {
+		s.window = window
+	}
+}
+
+// Syncer syncs a workspace to the dataset
+type Syncer struct {
+	bq          *BigQuery
+	client      *clockify.APIClient
+	workspaceID string
+	window      time.Duration
+	created     bool // Whether the tables are known to exist
+}
+
+// NewSyncer creates a syncer of the workspace's time entries, those of every user, which
+// requires admin rights
+func NewSyncer(bq *BigQuery, client *clockify.APIClient, workspaceID string, opts ...Option) *Syncer {
+	s := &Syncer{bq: bq, client: client, workspaceID: workspaceID, window: DefaultWindow}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// result counts the rows a sync changed
+type result struct {
+	TimeEntries, Projects int   // Upserted
+	Deleted               int64 // Rows of deleted entries and projects
+	Full                  bool  // Whether the whole history was synced
+}
+
+// Sync upserts the time entries of the window and the projects. It is a scheduler job.
+func (s *Syncer) Sync(ctx context.Context) error {
+	r, err := s.sync(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("warehouse_synced", "time_entries", r.TimeEntries, "projects", r.Projects, "deleted", r.Deleted, "full", r.Full)
+	return nil
+}
+
+func (s *Syncer) sync(ctx context.Context) (result, error) {
+	client := s.client.WithContext(ctx)
+	if !s.created {
+		for _, t := range []table{timeEntries, projects} {
+			if _, err := s.bq.query(ctx, s.createStatement(t)); err != nil {
+				return result{}, fmt.Errorf("failed to create table %s: %w", t.name, err)
+			}
+		}
+		s.created = true
+	}
+
+	var since *time.Time
+	full, err := s.empty(ctx, timeEntries)
+	if err != nil {
+		return result{}, err
+	}
+	if !full && s.window > 0 {
+		start := time.Now().Add(-s.window)
+		since = &start
+	}
+	r := result{Full: full || since == nil}
+
+	users, err := clockify.Collect(client.IterWorkspaceUsers(s.workspaceID))
+	if err != nil {
+		return r, fmt.Errorf("failed to read users: %w", err)
+	}
+	lookup, err := export.NewLookup(client, s.workspaceID, users)
+	if err != nil {
+		return r, err
+	}
+
+	var rows [][]any
+	var ids, userIDs []string
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+		entries, err := clockify.Collect(client.IterTimeEntries(s.workspaceID, user.ID, since, nil))
+		if err != nil {
+			return r, fmt.Errorf("failed to read time entries of user %s: %w", user.ID, err)
+		}
+		for _, entry := range entries {
+			// Entries starting before the window are out of the scope pruned below
+			if entry.TimeInterval == nil || since != nil && entry.TimeInterval.Start.Before(*since) {
+				continue
+			}
+			rows = append(rows, lookup.TimeEntryRow(entry))
+			ids = append(ids, entry.ID)
+		}
+	}
+	if err := s.upsert(ctx, timeEntries, rows); err != nil {
+		return r, err
+	}
+	r.TimeEntries = len(rows)
+
+	// Only the entries of the users synced, those of members removed since are kept
+	filter := "`workspace_id` = @workspace AND `user_id` IN UNNEST(@users)"
+	params := []queryParameter{scalarParameter("workspace", parquet.String, s.workspaceID), stringsParameter("users", userIDs)}
+	if since != nil {
+		filter += " AND `start` >= @since"
+		params = append(params, scalarParameter("since", parquet.Timestamp, *since))
+	}
+	deleted, err := s.prune(ctx, timeEntries, filter, ids, params...)
+	if err != nil {
+		return r, err
+	}
+	r.Deleted += deleted
+
+	rows, ids = nil, nil
+	for _, project := range lookup.Projects() {
+		rows = append(rows, projectRow(project))
+		ids = append(ids, project.ID)
+	}
+	if err := s.upsert(ctx, projects, rows); err != nil {
+		return r, err
+	}
+	r.Projects = len(rows)
+	deleted, err = s.prune(ctx, projects, "`workspace_id` = @workspace", ids, scalarParameter("workspace", parquet.String, s.workspaceID))
+	if err != nil {
+		return r, err
+	}
+	r.Deleted += deleted
+	return r, nil
+}
+
+func projectRow(project clockify.Project) []any {
+	var rate, currency any
+	if project.HourlyRate != nil {
+		rate, currency = project.HourlyRate.Amount, optional(project.HourlyRate.Currency)
+	}
+	return []any{
+		project.ID,
+		project.WorkspaceID,
+		project.Name,
+		optional(project.ClientID),
+		optional(project.ClientName),
+		project.Billable,
+		project.Public,
+		project.Archived,
+		optional(project.Color),
+		rate,
+		currency,
+	}
+}
+
+// createStatement returns the DDL creating the table unless it exists
+func (s *Syncer) createStatement(t table) string {
+	columns := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		columns[i] = fmt.Sprintf("`%s` %s", col.Name, sqlType(col.Kind))
+		// Arrays cannot be NOT NULL, BigQuery stores NULL ones as empty
+		if !col.Optional && col.Kind != parquet.StringList {
+			columns[i] += " NOT NULL"
+		}
+	}
+
+	statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)", s.bq.table(t.name), strings.Join(columns, ",\n  "))
+	if t.partitionBy != "" {
+		statement += "\nPARTITION BY " + t.partitionBy
+	}
+	if t.clusterBy != "" {
+		statement += "\nCLUSTER BY " + t.clusterBy
+	}
+	return statement
+}
+
+// empty reports whether the table has no rows of the workspace
+func (s *Syncer) empty(ctx context.Context, t table) (bool, error) {
+	resp, err := s.bq.query(ctx,
+		fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE `workspace_id` = @workspace", s.bq.table(t.name)),
+		scalarParameter("workspace", parquet.String, s.workspaceID))
+	if err != nil {
+		return false, fmt.Errorf("failed to count the rows of %s: %w", t.name, err)
+	}
+	if len(resp.Rows) == 0 || len(resp.Rows[0].F) == 0 {
+		return true, nil
+	}
+	return resp.Rows[0].F[0].V == "0", nil
+}
+
+// upsert merges the rows into the table by their key, in batches
+func (s *Syncer) upsert(ctx context.Context, t table, rows [][]any) error {
+	key := t.columns[0].Name
+	var set, names, values []string
+	for _, col := range t.columns {
+		names = append(names, fmt.Sprintf("`%s`", col.Name))
+		values = append(values, fmt.Sprintf("s.`%s`", col.Name))
+		if col.Name != key {
+			set = append(set, fmt.Sprintf("`%s` = s.`%s`", col.Name, col.Name))
+		}
+	}
+	statement := fmt.Sprintf("MERGE %s t\nUSING UNNEST(@rows) s\nON t.`%s` = s.`%s`\nWHEN MATCHED THEN UPDATE SET %s\nWHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)",
+		s.bq.table(t.name), key, key, strings.Join(set, ", "), strings.Join(names, ", "), strings.Join(values, ", "))
+
+	for batch := range slices.Chunk(rows, batchSize) {
+		if _, err := s.bq.query(ctx, statement, rowsParameter("rows", t.columns, batch)); err != nil {
+			return fmt.Errorf("failed to upsert into %s: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+// prune deletes the rows of the table matching the filter whose key is not among the kept
+func (s *Syncer) prune(ctx context.Context, t table, filter string, keep []string, params ...queryParameter) (int64, error) {
+	statement := fmt.Sprintf("DELETE FROM %s WHERE %s AND `%s` NOT IN UNNEST(@keep)", s.bq.table(t.name), filter, t.columns[0].Name)
+	resp, err := s.bq.query(ctx, statement, append(params, stringsParameter("keep", keep))...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete from %s: %w", t.name, err)
+	}
+	return resp.affected(), nil
+}
+
+// optional returns nil, a NULL, for the empty string
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
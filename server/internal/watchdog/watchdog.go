@@ -0,0 +1,174 @@
+// Package watchdog protects against forgotten timers: it reports timers running longer than
+// a threshold and can stop them at a cutoff. Breaks have a threshold of their own.
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Hukyl/CCWS/internal/clockify"
+	"github.com/Hukyl/CCWS/internal/events"
+)
+
+// Option configures a Watchdog
+type Option func(*Watchdog)
+
+// WithStopAfter stops timers once they have run for cutoff, ending them at start+cutoff
+// so the forgotten time is not logged. Zero, the default, never stops timers.
+func WithStopAfter(cutoff time.Duration) Option {
+	return func(w *Watchdog) {
+		w.stopAfter = cutoff
+	}
+}
+
+// WithBreakThreshold reports break timers once they have run for threshold instead of the
+// threshold of work timers. Zero, the default, never reports them. Breaks are still stopped at
+// the cutoff of WithStopAfter.
+func WithBreakThreshold(threshold time.Duration) Option {
+	return func(w *Watchdog) {
+		w.breakThreshold = threshold
+	}
+}
+
+// WithUsers limits the check to the given users. By default every workspace user is checked,
+// which requires admin rights.
+func WithUsers(userIDs ...string) Option {
+	return func(w *Watchdog) {
+		w.userIDs = userIDs
+	}
+}
+
+// Watchdog checks running timers against the threshold on every Check
+type Watchdog struct {
+	client      *clockify.APIClient
+	registry    *events.Registry
+	workspaceID string
+	threshold   time.Duration
+	stopAfter   time.Duration
+	userIDs     []string
+
+	breakThreshold time.Duration // 0 never reports breaks
+
+	mu sync.Mutex
+	// Users of the entries already reported by entry ID, so each timer is reported once
+	reported map[string]string
+}
+
+// New creates a watchdog dispatching events.LongRunningTimerEvent for timers running
+// longer than threshold
+func New(client *clockify.APIClient, registry *events.Registry, workspaceID string, threshold time.Duration, opts ...Option) *Watchdog {
+	w := &Watchdog{
+		client:      client,
+		registry:    registry,
+		workspaceID: workspaceID,
+		threshold:   threshold,
+		reported:    make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Check inspects the running timers once. It is a scheduler.JobFunc.
+func (w *Watchdog) Check(ctx context.Context) error {
+	client := w.client.WithContext(ctx)
+
+	userIDs, err := w.users(client)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	now := time.Now()
+	running := make(map[string]bool)
+	// Users whose running timer is unknown, their reported entries are kept
+	failed := make(map[string]bool)
+
+	var errs []error
+	for _, userID := range userIDs {
+		entry, err := client.GetRunningTimeEntry(w.workspaceID, userID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get running timer of user %s: %w", userID, err))
+			failed[userID] = true
+			continue
+		}
+		if entry == nil || entry.TimeInterval == nil {
+			continue
+		}
+		running[entry.ID] = true
+
+		elapsed := now.Sub(entry.TimeInterval.Start)
+		threshold := w.threshold
+		if entry.IsBreak() {
+			threshold = w.breakThreshold
+		}
+		if (!entry.IsBreak() || threshold > 0) && elapsed >= threshold && w.markReported(entry.ID, userID) {
+			slog.Info("long_running_timer", "user_id", userID, "time_entry_id", entry.ID, "elapsed", elapsed.Round(time.Minute), "break", entry.IsBreak())
+			w.registry.Dispatch(ctx, events.Event{
+				Type:        events.LongRunningTimerEvent,
+				Source:      events.SourceCCWS,
+				WorkspaceID: w.workspaceID,
+				Payload:     entry,
+				ReceivedAt:  now,
+			})
+		}
+
+		if w.stopAfter > 0 && elapsed >= w.stopAfter {
+			end := entry.TimeInterval.Start.Add(w.stopAfter)
+			if _, err := client.StopTimeEntry(w.workspaceID, userID, end); err != nil {
+				errs = append(errs, fmt.Errorf("failed to stop timer of user %s: %w", userID, err))
+				continue
+			}
+			slog.Warn("long_running_timer_stopped", "user_id", userID, "time_entry_id", entry.ID, "end", end)
+		}
+	}
+
+	w.forgetStopped(running, failed)
+	return errors.Join(errs...)
+}
+
+func (w *Watchdog) users(client *clockify.APIClient) ([]string, error) {
+	if w.userIDs != nil {
+		return w.userIDs, nil
+	}
+
+	var ids []string
+	for users, err := range client.IterWorkspaceUsers(w.workspaceID) {
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+// markReported records the user's entry as reported, returning false if it already was
+func (w *Watchdog) markReported(entryID, userID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.reported[entryID]; ok {
+		return false
+	}
+	w.reported[entryID] = userID
+	return true
+}
+
+// forgetStopped drops reported entries that are no longer running, but those of the users
+// whose lookup failed: it does not mean their timer stopped
+func (w *Watchdog) forgetStopped(running, failed map[string]bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for id, userID := range w.reported {
+		if !failed[userID] && !running[id] {
+			delete(w.reported, id)
+		}
+	}
+}